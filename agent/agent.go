@@ -0,0 +1,409 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides key agent which holds unlocked keys in memory and signs messages for local clients.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/policy"
+	"github.com/DE-labtory/heimdall/ratelimit"
+	"github.com/DE-labtory/iLogger"
+)
+
+var ErrKeyNotFound = errors.New("key not found - agent does not hold the key")
+var ErrUnauthorized = errors.New("unauthorized - client is not allowed to use the key")
+var ErrUnknownRequest = errors.New("unknown request type")
+var ErrKeyNotSupported = errors.New("key type is not supported by agent")
+var ErrAgentClosed = errors.New("agent is closed")
+
+// request types
+const (
+	ListRequest        = "LIST"
+	SignRequest        = "SIGN"
+	SubmitRequest      = "SUBMIT"
+	ApproveRequest     = "APPROVE"
+	ListPendingRequest = "LIST_PENDING"
+	ReleaseRequest     = "RELEASE"
+)
+
+// request is a message sent from client to agent.
+type request struct {
+	Type      string
+	KeyID     heimdall.KeyID
+	Message   []byte
+	HashOpt   string
+	RequestID string
+	Approval  *policy.Approval
+}
+
+// response is a message sent from agent to client.
+type response struct {
+	KeyIDs    []heimdall.KeyID
+	Signature []byte
+	RequestID string
+	Pending   []*Pending
+	Err       string
+}
+
+// Credential is a credential of a client process connected to agent.
+type Credential struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// Authorizer decides whether a client connected to agent can use a key.
+type Authorizer func(cred *Credential, keyId heimdall.KeyID) bool
+
+// AllowSameUser authorizes clients run by the same user with agent process.
+func AllowSameUser(cred *Credential, keyId heimdall.KeyID) bool {
+	return cred.UID == uint32(os.Getuid())
+}
+
+// AllowUIDs authorizes clients run by one of the users.
+func AllowUIDs(uids ...uint32) Authorizer {
+	return func(cred *Credential, keyId heimdall.KeyID) bool {
+		for _, uid := range uids {
+			if cred.UID == uid {
+				return true
+			}
+		}
+
+		return false
+	}
+}
+
+// Agent holds unlocked private keys and answers sign requests over unix socket.
+type Agent struct {
+	mutex         sync.RWMutex
+	keys          map[heimdall.KeyID]heimdall.PriKey
+	authorizer    Authorizer
+	keyLimiter    *ratelimit.Limiter
+	callerLimiter *ratelimit.Limiter
+	quorum        *quorum
+	listener      net.Listener
+	closed        bool
+}
+
+// NewAgent makes agent which authorizes clients by authorizer. (AllowSameUser is used if authorizer is nil)
+func NewAgent(authorizer Authorizer) *Agent {
+	if authorizer == nil {
+		authorizer = AllowSameUser
+	}
+
+	return &Agent{
+		keys:       make(map[heimdall.KeyID]heimdall.PriKey),
+		authorizer: authorizer,
+	}
+}
+
+// LimitPerKey limits signing rate of each key held by agent.
+func (agent *Agent) LimitPerKey(limiter *ratelimit.Limiter) {
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	agent.keyLimiter = limiter
+}
+
+// LimitPerCaller limits signing rate of each client user (UID).
+func (agent *Agent) LimitPerCaller(limiter *ratelimit.Limiter) {
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	agent.callerLimiter = limiter
+}
+
+// AddKey adds unlocked private key to agent.
+func (agent *Agent) AddKey(pri heimdall.PriKey) {
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	agent.keys[pri.ID()] = pri
+}
+
+// RemoveKey removes private key from agent and clears it from memory.
+func (agent *Agent) RemoveKey(keyId heimdall.KeyID) error {
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	pri, exists := agent.keys[keyId]
+	if !exists {
+		return ErrKeyNotFound
+	}
+
+	pri.Clear()
+	delete(agent.keys, keyId)
+
+	return nil
+}
+
+// RemoveAll removes all private keys from agent and clears them from memory.
+func (agent *Agent) RemoveAll() {
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	for keyId, pri := range agent.keys {
+		pri.Clear()
+		delete(agent.keys, keyId)
+	}
+}
+
+// Listen makes unix socket which only owner can access at socketPath and serves clients.
+func (agent *Agent) Listen(socketPath string) error {
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Chmod(socketPath, 0600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	return agent.Serve(listener)
+}
+
+// Serve accepts clients from listener and answers their requests until agent is closed.
+func (agent *Agent) Serve(listener net.Listener) error {
+	agent.mutex.Lock()
+	if agent.closed {
+		agent.mutex.Unlock()
+		return ErrAgentClosed
+	}
+	agent.listener = listener
+	agent.mutex.Unlock()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if agent.isClosed() {
+				return nil
+			}
+			return err
+		}
+
+		go agent.handleConn(conn)
+	}
+}
+
+// Close stops serving and clears all private keys held by agent.
+func (agent *Agent) Close() error {
+	agent.mutex.Lock()
+	agent.closed = true
+	listener := agent.listener
+	agent.mutex.Unlock()
+
+	agent.RemoveAll()
+
+	if listener != nil {
+		return listener.Close()
+	}
+
+	return nil
+}
+
+func (agent *Agent) requiresQuorum() bool {
+	agent.mutex.RLock()
+	defer agent.mutex.RUnlock()
+
+	return agent.quorum != nil
+}
+
+func (agent *Agent) isClosed() bool {
+	agent.mutex.RLock()
+	defer agent.mutex.RUnlock()
+
+	return agent.closed
+}
+
+// handleConn answers requests of a client until the connection is closed.
+func (agent *Agent) handleConn(conn net.Conn) {
+	defer conn.Close()
+
+	cred, err := peerCredential(conn)
+	if err != nil {
+		iLogger.Errorf(nil, "[Heimdall] failed to get agent client credential - %s", err)
+		return
+	}
+
+	decoder := json.NewDecoder(conn)
+	encoder := json.NewEncoder(conn)
+
+	for {
+		var req request
+		if err := decoder.Decode(&req); err != nil {
+			if err != io.EOF {
+				iLogger.Errorf(nil, "[Heimdall] invalid agent request - %s", err)
+			}
+			return
+		}
+
+		if err := encoder.Encode(agent.handleRequest(cred, &req)); err != nil {
+			return
+		}
+	}
+}
+
+func (agent *Agent) handleRequest(cred *Credential, req *request) *response {
+	switch req.Type {
+	case ListRequest:
+		return &response{KeyIDs: agent.list(cred)}
+	case SignRequest:
+		if agent.requiresQuorum() {
+			return &response{Err: ErrApprovalRequired.Error()}
+		}
+		signature, err := agent.sign(cred, req)
+		if err != nil {
+			return &response{Err: err.Error()}
+		}
+		return &response{Signature: signature}
+	case SubmitRequest:
+		requestId, err := agent.submit(cred, req)
+		if err != nil {
+			return &response{Err: err.Error()}
+		}
+		return &response{RequestID: requestId}
+	case ApproveRequest:
+		if err := agent.approve(req.RequestID, req.Approval); err != nil {
+			return &response{Err: err.Error()}
+		}
+		return &response{RequestID: req.RequestID}
+	case ListPendingRequest:
+		pendings, err := agent.listPending(cred)
+		if err != nil {
+			return &response{Err: err.Error()}
+		}
+		return &response{Pending: pendings}
+	case ReleaseRequest:
+		signature, err := agent.release(cred, req.RequestID)
+		if err != nil {
+			return &response{Err: err.Error()}
+		}
+		return &response{Signature: signature}
+	default:
+		return &response{Err: ErrUnknownRequest.Error()}
+	}
+}
+
+// list returns IDs of keys which the client can use.
+func (agent *Agent) list(cred *Credential) []heimdall.KeyID {
+	agent.mutex.RLock()
+	defer agent.mutex.RUnlock()
+
+	keyIds := make([]heimdall.KeyID, 0, len(agent.keys))
+	for keyId := range agent.keys {
+		if agent.authorizer(cred, keyId) {
+			keyIds = append(keyIds, keyId)
+		}
+	}
+
+	return keyIds
+}
+
+// sign signs the requested message with the requested key if the client is authorized.
+func (agent *Agent) sign(cred *Credential, req *request) ([]byte, error) {
+	if !agent.authorizer(cred, req.KeyID) {
+		return nil, ErrUnauthorized
+	}
+
+	agent.mutex.RLock()
+	defer agent.mutex.RUnlock()
+
+	pri, exists := agent.keys[req.KeyID]
+	if !exists {
+		return nil, ErrKeyNotFound
+	}
+
+	if _, ok := pri.(*hecdsa.PriKey); !ok {
+		return nil, ErrKeyNotSupported
+	}
+
+	hashOpt, err := hashOptByName(req.HashOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := agent.takeTokens(cred, req.KeyID); err != nil {
+		return nil, err
+	}
+
+	signature, err := hecdsa.NewSigner(pri).Sign(req.Message, hecdsa.NewSignerOpts(hashOpt))
+	if err != nil {
+		agent.refundTokens(cred, req.KeyID)
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// takeTokens takes tokens from caller and key limiters only if both of them allow the request.
+func (agent *Agent) takeTokens(cred *Credential, keyId heimdall.KeyID) error {
+	caller := strconv.FormatUint(uint64(cred.UID), 10)
+	if agent.callerLimiter != nil && !agent.callerLimiter.Allow(caller) {
+		return ratelimit.ErrRateLimited
+	}
+
+	if agent.keyLimiter != nil && !agent.keyLimiter.Allow(keyId) {
+		if agent.callerLimiter != nil {
+			agent.callerLimiter.Refund(caller)
+		}
+		return ratelimit.ErrRateLimited
+	}
+
+	return nil
+}
+
+// refundTokens puts back tokens taken by takeTokens when signing fails.
+func (agent *Agent) refundTokens(cred *Credential, keyId heimdall.KeyID) {
+	if agent.callerLimiter != nil {
+		agent.callerLimiter.Refund(strconv.FormatUint(uint64(cred.UID), 10))
+	}
+
+	if agent.keyLimiter != nil {
+		agent.keyLimiter.Refund(keyId)
+	}
+}
+
+// hashOptByName makes hash option from its name. Empty name means hash is selected by the signing key.
+func hashOptByName(name string) (*hashing.HashOpt, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	return hashing.NewHashOpt(name)
+}
+
+// hashOptName returns name of hash option in signer option, or empty name if hash is selected by the signing key.
+func hashOptName(opts heimdall.SignerOpts) string {
+	if opts == nil || opts.HashOpt() == nil {
+		return ""
+	}
+
+	return opts.HashOpt().Name
+}
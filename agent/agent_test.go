@@ -0,0 +1,243 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package agent_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/agent"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpAgent(t *testing.T, authorizer agent.Authorizer) (pri heimdall.PriKey, keyAgent *agent.Agent, socketPath string, tearDown func()) {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err = hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	socketPath = filepath.Join(os.TempDir(), "heimdall-agent-test.sock")
+	os.Remove(socketPath)
+
+	keyAgent = agent.NewAgent(authorizer)
+	keyAgent.AddKey(pri)
+
+	go keyAgent.Listen(socketPath)
+
+	// wait for agent to listen
+	for i := 0; i < 100; i++ {
+		if _, err := os.Stat(socketPath); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return pri, keyAgent, socketPath, func() {
+		keyAgent.Close()
+		os.Remove(socketPath)
+	}
+}
+
+func TestClient_Sign(t *testing.T) {
+	// given
+	pri, _, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello world")
+
+	client := agent.NewClient(socketPath)
+
+	// when
+	signature, err := client.Sign(pri.ID(), message, signerOpt)
+	otherSignature, err2 := client.Sign(pri.ID(), message, signerOpt)
+	_, wrongKeyErr := client.Sign("ITwrongKeyID", message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, err2)
+	assert.Equal(t, agent.ErrKeyNotFound, wrongKeyErr)
+
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hecdsa.Verify(pri.PublicKey(), otherSignature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestClient_List(t *testing.T) {
+	// given
+	pri, _, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	client := agent.NewClient(socketPath)
+
+	// when
+	keyIds, err := client.List()
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []heimdall.KeyID{pri.ID()}, keyIds)
+}
+
+func TestAgent_Unauthorized(t *testing.T) {
+	// given
+	denyAll := func(cred *agent.Credential, keyId heimdall.KeyID) bool {
+		return false
+	}
+	pri, _, socketPath, tearDown := setUpAgent(t, denyAll)
+	defer tearDown()
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	client := agent.NewClient(socketPath)
+
+	// when
+	signature, err := client.Sign(pri.ID(), []byte("hello world"), hecdsa.NewSignerOpts(hashOpt))
+	keyIds, listErr := client.List()
+
+	// then
+	assert.Nil(t, signature)
+	assert.Equal(t, agent.ErrUnauthorized, err)
+	assert.NoError(t, listErr)
+	assert.Empty(t, keyIds)
+}
+
+func TestAllowUIDs(t *testing.T) {
+	// given
+	authorizer := agent.AllowUIDs(1000, 1001)
+
+	// when
+	allowed := authorizer(&agent.Credential{UID: 1001}, "ITkey")
+	denied := authorizer(&agent.Credential{UID: 0}, "ITkey")
+
+	// then
+	assert.True(t, allowed)
+	assert.False(t, denied)
+}
+
+func TestAgent_LimitPerKey(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	keyAgent.LimitPerKey(ratelimit.NewLimiter(1, 0.001))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	client := agent.NewClient(socketPath)
+
+	// when
+	_, err = client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+	_, limitedErr := client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.ErrRateLimited, limitedErr)
+}
+
+func TestAgent_LimitPerCaller(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	keyAgent.LimitPerCaller(ratelimit.NewLimiter(2, 0.001))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	client := agent.NewClient(socketPath)
+
+	// when
+	_, err = client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+	_, err2 := client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+	_, limitedErr := client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, err2)
+	assert.Equal(t, ratelimit.ErrRateLimited, limitedErr)
+}
+
+func TestAgent_LimitOnlyServedRequests(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	keyAgent.LimitPerCaller(ratelimit.NewLimiter(2, 0.001))
+	keyAgent.LimitPerKey(ratelimit.NewLimiter(1, 0.001))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	invalidOpt := hecdsa.NewSignerOpts(&hashing.HashOpt{Name: "MD5"})
+
+	client := agent.NewClient(socketPath)
+
+	// when
+	_, invalidErr := client.Sign(pri.ID(), []byte("hello world"), invalidOpt)
+	_, err = client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+	_, keyLimitedErr := client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+	keyAgent.LimitPerKey(nil)
+	_, callerErr := client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+
+	// then
+	assert.Equal(t, hashing.ErrNotSupportedHashFunc.Error(), invalidErr.Error())
+	assert.NoError(t, err)
+	assert.Equal(t, ratelimit.ErrRateLimited, keyLimitedErr)
+	assert.NoError(t, callerErr)
+}
+
+func TestAgent_RefundFailedSign(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	keyAgent.LimitPerCaller(ratelimit.NewLimiter(1, 0.001))
+	keyAgent.LimitPerKey(ratelimit.NewLimiter(1, 0.001))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	client := agent.NewClient(socketPath)
+
+	// when
+	pri.(heimdall.UsagePolicyHolder).SetUsagePolicy(&heimdall.KeyUsagePolicy{NotAfter: time.Now().Add(-time.Hour)})
+	_, expiredErr := client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+	pri.(heimdall.UsagePolicyHolder).SetUsagePolicy(nil)
+	_, err = client.Sign(pri.ID(), []byte("hello world"), signerOpt)
+
+	// then
+	assert.Equal(t, heimdall.ErrKeyExpired.Error(), expiredErr.Error())
+	assert.NoError(t, err)
+}
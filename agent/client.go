@@ -0,0 +1,160 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides client of key agent for processes sharing unlocked keys.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/policy"
+	"github.com/DE-labtory/heimdall/ratelimit"
+)
+
+// Client requests signing to agent listening on unix socket.
+type Client struct {
+	socketPath string
+}
+
+func NewClient(socketPath string) *Client {
+	return &Client{socketPath: socketPath}
+}
+
+// List returns IDs of keys which this client can use.
+func (client *Client) List() ([]heimdall.KeyID, error) {
+	resp, err := client.request(&request{Type: ListRequest})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.KeyIDs, nil
+}
+
+// Sign requests signature for a message using key held by agent.
+func (client *Client) Sign(keyId heimdall.KeyID, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	req := &request{
+		Type:    SignRequest,
+		KeyID:   keyId,
+		Message: message,
+		HashOpt: hashOptName(opts),
+	}
+
+	resp, err := client.request(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// agent signs in its default signature version, so the signature is converted to the version requested by opts.
+	_, signature, err := heimdall.DecodeSignature(resp.Signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+}
+
+// Submit queues sign request to agent requiring quorum approval, and returns ID of the pending request.
+func (client *Client) Submit(keyId heimdall.KeyID, message []byte, opts heimdall.SignerOpts) (string, error) {
+	req := &request{
+		Type:    SubmitRequest,
+		KeyID:   keyId,
+		Message: message,
+		HashOpt: hashOptName(opts),
+	}
+
+	resp, err := client.request(req)
+	if err != nil {
+		return "", err
+	}
+
+	return resp.RequestID, nil
+}
+
+// Approve sends approval of an approver, which is a signature on approval digest of the pending request, to agent.
+// (see Pending.ApprovalDigest)
+func (client *Client) Approve(requestId string, approval *policy.Approval) error {
+	_, err := client.request(&request{
+		Type:      ApproveRequest,
+		RequestID: requestId,
+		Approval:  approval,
+	})
+
+	return err
+}
+
+// ListPending returns sign requests waiting for approvals on keys which the client can use, so that approvers can review them.
+func (client *Client) ListPending() ([]*Pending, error) {
+	resp, err := client.request(&request{Type: ListPendingRequest})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Pending, nil
+}
+
+// Release returns signature of submitted request once it is approved by quorum.
+func (client *Client) Release(requestId string) ([]byte, error) {
+	resp, err := client.request(&request{
+		Type:      ReleaseRequest,
+		RequestID: requestId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Signature, nil
+}
+
+// request sends a request to agent and receives its response.
+func (client *Client) request(req *request) (*response, error) {
+	conn, err := net.Dial("unix", client.socketPath)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	if err = json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	resp := new(response)
+	if err = json.NewDecoder(conn).Decode(resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Err != "" {
+		return nil, toError(resp.Err)
+	}
+
+	return resp, nil
+}
+
+// toError converts error message from agent to the known error if possible.
+func toError(errMsg string) error {
+	for _, err := range []error{ErrKeyNotFound, ErrUnauthorized, ErrUnknownRequest, ErrKeyNotSupported, ratelimit.ErrRateLimited,
+		ErrApprovalRequired, ErrRequestNotFound, ErrNotApprover, ErrInvalidApproval, ErrPendingApproval} {
+		if err.Error() == errMsg {
+			return err
+		}
+	}
+
+	return errors.New(errMsg)
+}
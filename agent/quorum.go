@@ -0,0 +1,287 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides quorum approval workflow which holds sign requests until M of N approvers approve them.
+
+package agent
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/policy"
+)
+
+var ErrApprovalRequired = errors.New("approval required - submit sign request and wait for approvals")
+var ErrRequestNotFound = errors.New("sign request not found - it is released, expired or never submitted")
+var ErrNotApprover = errors.New("not approver - approval key is not registered")
+var ErrInvalidApproval = errors.New("invalid approval - signature of approver is not valid")
+var ErrPendingApproval = errors.New("pending approval - sign request is not approved by enough approvers")
+var ErrInvalidQuorum = errors.New("invalid quorum - m should be between 1 and the number of distinct approvers")
+
+// Pending is a sign request waiting for approvals.
+type Pending struct {
+	ID        string
+	KeyID     heimdall.KeyID
+	Message   []byte
+	HashOpt   string
+	Approvers []heimdall.KeyID
+	Expiry    time.Time
+
+	// UID of the client who submitted the request
+	uid       uint32
+	approvals map[heimdall.KeyID]bool
+}
+
+// approvalDomain separates approval digests from signatures of approver keys on other data.
+const approvalDomain = "heimdall agent approval"
+
+// ApprovalDigest returns digest which approvers sign to approve the request. It binds request ID, key ID, hash option,
+// message and expiry, so that an approval can not be replayed on other requests of the same message.
+func (pending *Pending) ApprovalDigest() []byte {
+	digest := sha256.New()
+	for _, field := range [][]byte{
+		[]byte(approvalDomain),
+		[]byte(pending.ID),
+		[]byte(pending.KeyID),
+		[]byte(pending.HashOpt),
+		pending.Message,
+	} {
+		length := make([]byte, 8)
+		binary.BigEndian.PutUint64(length, uint64(len(field)))
+		digest.Write(length)
+		digest.Write(field)
+	}
+
+	expiry := make([]byte, 8)
+	binary.BigEndian.PutUint64(expiry, uint64(pending.Expiry.UnixNano()))
+	digest.Write(expiry)
+
+	return digest.Sum(nil)
+}
+
+// quorum holds sign requests until m of approvers approve them.
+type quorum struct {
+	m         int
+	approvers map[heimdall.KeyID]heimdall.PubKey
+	ttl       time.Duration
+	pending   map[string]*Pending
+}
+
+// RequireQuorum makes agent release signature only after m of approvers signed the requested message.
+// Sign requests should be submitted, and they are dropped if not released within ttl.
+// m should be between 1 and the number of distinct approvers.
+func (agent *Agent) RequireQuorum(m int, approvers []heimdall.PubKey, ttl time.Duration) error {
+	approverMap := make(map[heimdall.KeyID]heimdall.PubKey)
+	for _, approver := range approvers {
+		approverMap[approver.ID()] = approver
+	}
+
+	if m < 1 || m > len(approverMap) {
+		return ErrInvalidQuorum
+	}
+
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	agent.quorum = &quorum{
+		m:         m,
+		approvers: approverMap,
+		ttl:       ttl,
+		pending:   make(map[string]*Pending),
+	}
+
+	return nil
+}
+
+// submit queues sign request of an authorized client, and returns ID of the pending request.
+func (agent *Agent) submit(cred *Credential, req *request) (string, error) {
+	if !agent.authorizer(cred, req.KeyID) {
+		return "", ErrUnauthorized
+	}
+
+	if _, err := hashOptByName(req.HashOpt); err != nil {
+		return "", err
+	}
+
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	if agent.quorum == nil {
+		return "", ErrUnknownRequest
+	}
+
+	if _, exists := agent.keys[req.KeyID]; !exists {
+		return "", ErrKeyNotFound
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+
+	pending := &Pending{
+		ID:        hex.EncodeToString(id),
+		KeyID:     req.KeyID,
+		Message:   req.Message,
+		HashOpt:   req.HashOpt,
+		Expiry:    time.Now().Add(agent.quorum.ttl),
+		uid:       cred.UID,
+		approvals: make(map[heimdall.KeyID]bool),
+	}
+	agent.quorum.pending[pending.ID] = pending
+
+	return pending.ID, nil
+}
+
+// approve records approval on pending request after verifying signature of the approver on its approval digest.
+func (agent *Agent) approve(requestId string, approval *policy.Approval) error {
+	if approval == nil {
+		return ErrInvalidApproval
+	}
+
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	pending, err := agent.findPending(requestId)
+	if err != nil {
+		return err
+	}
+
+	approver, exists := agent.quorum.approvers[approval.KeyID]
+	if !exists {
+		return ErrNotApprover
+	}
+
+	hashOpt, err := hashOptByName(pending.HashOpt)
+	if err != nil {
+		return err
+	}
+
+	valid, err := hecdsa.Verify(approver, approval.Signature, pending.ApprovalDigest(), hecdsa.NewSignerOpts(hashOpt))
+	if err != nil || !valid {
+		return ErrInvalidApproval
+	}
+
+	if !pending.approvals[approval.KeyID] {
+		pending.approvals[approval.KeyID] = true
+		pending.Approvers = append(pending.Approvers, approval.KeyID)
+	}
+
+	return nil
+}
+
+// snapshot copies the request, so that it can be read after agent mutex is released while approvals are recorded.
+// agent mutex should be locked by caller.
+func (pending *Pending) snapshot() *Pending {
+	return &Pending{
+		ID:        pending.ID,
+		KeyID:     pending.KeyID,
+		Message:   append([]byte(nil), pending.Message...),
+		HashOpt:   pending.HashOpt,
+		Approvers: append([]heimdall.KeyID(nil), pending.Approvers...),
+		Expiry:    pending.Expiry,
+		uid:       pending.uid,
+	}
+}
+
+// listPending returns copies of sign requests waiting for approvals on keys which the client can use.
+func (agent *Agent) listPending(cred *Credential) ([]*Pending, error) {
+	agent.mutex.Lock()
+	defer agent.mutex.Unlock()
+
+	if agent.quorum == nil {
+		return nil, ErrUnknownRequest
+	}
+	agent.quorum.dropExpired(time.Now())
+
+	pendings := make([]*Pending, 0, len(agent.quorum.pending))
+	for _, pending := range agent.quorum.pending {
+		if agent.authorizer(cred, pending.KeyID) {
+			pendings = append(pendings, pending.snapshot())
+		}
+	}
+
+	return pendings, nil
+}
+
+// release signs pending request approved by quorum, only for the client who submitted it.
+// The request is taken out of pending before signing, so concurrent releases of it never both get a signature,
+// and it is put back if signing fails unless quorum is replaced meanwhile.
+func (agent *Agent) release(cred *Credential, requestId string) ([]byte, error) {
+	agent.mutex.Lock()
+	pending, err := agent.findPending(requestId)
+	if err == nil && pending.uid != cred.UID {
+		err = ErrUnauthorized
+	} else if err == nil && len(pending.approvals) < agent.quorum.m {
+		err = ErrPendingApproval
+	}
+	quorum := agent.quorum
+	if err == nil {
+		delete(quorum.pending, requestId)
+	}
+	agent.mutex.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := agent.sign(cred, &request{
+		KeyID:   pending.KeyID,
+		Message: pending.Message,
+		HashOpt: pending.HashOpt,
+	})
+	if err != nil {
+		agent.mutex.Lock()
+		if agent.quorum == quorum {
+			quorum.pending[requestId] = pending
+		}
+		agent.mutex.Unlock()
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// findPending finds pending request which is not expired. agent mutex should be locked by caller.
+func (agent *Agent) findPending(requestId string) (*Pending, error) {
+	if agent.quorum == nil {
+		return nil, ErrUnknownRequest
+	}
+	agent.quorum.dropExpired(time.Now())
+
+	pending, exists := agent.quorum.pending[requestId]
+	if !exists {
+		return nil, ErrRequestNotFound
+	}
+
+	return pending, nil
+}
+
+func (quorum *quorum) dropExpired(now time.Time) {
+	for id, pending := range quorum.pending {
+		if now.After(pending.Expiry) {
+			delete(quorum.pending, id)
+		}
+	}
+}
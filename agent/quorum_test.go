@@ -0,0 +1,350 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package agent_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/agent"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func approve(t *testing.T, approver heimdall.PriKey, pending *agent.Pending, opts heimdall.SignerOpts) *policy.Approval {
+	signature, err := hecdsa.NewSigner(approver).Sign(pending.ApprovalDigest(), opts)
+	assert.NoError(t, err)
+
+	return &policy.Approval{KeyID: approver.ID(), Signature: signature}
+}
+
+func pendingOf(t *testing.T, client *agent.Client, requestId string) *agent.Pending {
+	pendings, err := client.ListPending()
+	assert.NoError(t, err)
+	for _, pending := range pendings {
+		if pending.ID == requestId {
+			return pending
+		}
+	}
+
+	t.Fatalf("no pending request of ID [%s]", requestId)
+	return nil
+}
+
+func setUpApprovers(t *testing.T, n int) []heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+
+	approvers := make([]heimdall.PriKey, n)
+	for i := range approvers {
+		approvers[i], err = hecdsa.GenerateKey(keyGenOpt)
+		assert.NoError(t, err)
+	}
+
+	return approvers
+}
+
+func TestAgent_RequireQuorum(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	approvers := setUpApprovers(t, 3)
+	assert.NoError(t, keyAgent.RequireQuorum(2, []heimdall.PubKey{approvers[0].PublicKey(), approvers[1].PublicKey(), approvers[2].PublicKey()}, time.Minute))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("network config update")
+
+	client := agent.NewClient(socketPath)
+
+	// when
+	_, err = client.Sign(pri.ID(), message, signerOpt)
+
+	// then
+	assert.Equal(t, agent.ErrApprovalRequired, err)
+
+	// when
+	requestId, err := client.Submit(pri.ID(), message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	pendings, err := client.ListPending()
+	assert.NoError(t, err)
+	assert.Len(t, pendings, 1)
+	assert.Equal(t, requestId, pendings[0].ID)
+	assert.Equal(t, message, pendings[0].Message)
+	pending := pendings[0]
+
+	// when approved by only one approver
+	assert.NoError(t, client.Approve(requestId, approve(t, approvers[0], pending, signerOpt)))
+	assert.NoError(t, client.Approve(requestId, approve(t, approvers[0], pending, signerOpt)))
+	_, err = client.Release(requestId)
+
+	// then
+	assert.Equal(t, agent.ErrPendingApproval, err)
+
+	// when approved by quorum
+	assert.NoError(t, client.Approve(requestId, approve(t, approvers[2], pending, signerOpt)))
+	signature, err := client.Release(requestId)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	_, err = client.Release(requestId)
+	assert.Equal(t, agent.ErrRequestNotFound, err)
+}
+
+func TestAgent_RequireQuorum_InvalidApproval(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	approvers := setUpApprovers(t, 2)
+	assert.NoError(t, keyAgent.RequireQuorum(1, []heimdall.PubKey{approvers[0].PublicKey()}, time.Minute))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("network config update")
+
+	client := agent.NewClient(socketPath)
+	requestId, err := client.Submit(pri.ID(), message, signerOpt)
+	assert.NoError(t, err)
+	pending := pendingOf(t, client, requestId)
+	otherPending := *pending
+	otherPending.Message = []byte("other message")
+
+	// when
+	notApproverErr := client.Approve(requestId, approve(t, approvers[1], pending, signerOpt))
+	invalidErr := client.Approve(requestId, approve(t, approvers[0], &otherPending, signerOpt))
+	notFoundErr := client.Approve("unknown", approve(t, approvers[0], pending, signerOpt))
+
+	// then
+	assert.Equal(t, agent.ErrNotApprover, notApproverErr)
+	assert.Equal(t, agent.ErrInvalidApproval, invalidErr)
+	assert.Equal(t, agent.ErrRequestNotFound, notFoundErr)
+
+	_, err = client.Release(requestId)
+	assert.Equal(t, agent.ErrPendingApproval, err)
+}
+
+func TestAgent_RequireQuorum_Expired(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	approvers := setUpApprovers(t, 1)
+	assert.NoError(t, keyAgent.RequireQuorum(1, []heimdall.PubKey{approvers[0].PublicKey()}, 10*time.Millisecond))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("network config update")
+
+	client := agent.NewClient(socketPath)
+	requestId, err := client.Submit(pri.ID(), message, signerOpt)
+	assert.NoError(t, err)
+	pending := pendingOf(t, client, requestId)
+
+	// when
+	time.Sleep(20 * time.Millisecond)
+	err = client.Approve(requestId, approve(t, approvers[0], pending, signerOpt))
+
+	// then
+	assert.Equal(t, agent.ErrRequestNotFound, err)
+}
+
+func TestAgent_RequireQuorum_ReplayedApproval(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	approvers := setUpApprovers(t, 1)
+	assert.NoError(t, keyAgent.RequireQuorum(1, []heimdall.PubKey{approvers[0].PublicKey()}, time.Minute))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("network config update")
+
+	client := agent.NewClient(socketPath)
+	requestId, err := client.Submit(pri.ID(), message, signerOpt)
+	assert.NoError(t, err)
+	approval := approve(t, approvers[0], pendingOf(t, client, requestId), signerOpt)
+	assert.NoError(t, client.Approve(requestId, approval))
+	_, err = client.Release(requestId)
+	assert.NoError(t, err)
+
+	laterRequestId, err := client.Submit(pri.ID(), message, signerOpt)
+	assert.NoError(t, err)
+
+	// when
+	err = client.Approve(laterRequestId, approval)
+
+	// then
+	assert.Equal(t, agent.ErrInvalidApproval, err)
+
+	_, err = client.Release(laterRequestId)
+	assert.Equal(t, agent.ErrPendingApproval, err)
+}
+
+func TestAgent_RequireQuorum_ListPendingAuthorized(t *testing.T) {
+	// given
+	allowOther := true
+	var otherPri heimdall.PriKey
+	authorizer := func(cred *agent.Credential, keyId heimdall.KeyID) bool {
+		return allowOther || keyId != otherPri.ID()
+	}
+
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, authorizer)
+	defer tearDown()
+
+	otherPri = setUpApprovers(t, 1)[0]
+	keyAgent.AddKey(otherPri)
+
+	approvers := setUpApprovers(t, 1)
+	assert.NoError(t, keyAgent.RequireQuorum(1, []heimdall.PubKey{approvers[0].PublicKey()}, time.Minute))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	client := agent.NewClient(socketPath)
+	requestId, err := client.Submit(pri.ID(), []byte("network config update"), signerOpt)
+	assert.NoError(t, err)
+	_, err = client.Submit(otherPri.ID(), []byte("other config update"), signerOpt)
+	assert.NoError(t, err)
+
+	// when
+	allowOther = false
+	pendings, err := client.ListPending()
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, pendings, 1)
+	assert.Equal(t, requestId, pendings[0].ID)
+}
+
+func TestAgent_RequireQuorum_ConcurrentRelease(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	approvers := setUpApprovers(t, 1)
+	assert.NoError(t, keyAgent.RequireQuorum(1, []heimdall.PubKey{approvers[0].PublicKey()}, time.Minute))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	client := agent.NewClient(socketPath)
+	requestId, err := client.Submit(pri.ID(), []byte("network config update"), signerOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, client.Approve(requestId, approve(t, approvers[0], pendingOf(t, client, requestId), signerOpt)))
+
+	// when
+	var wg sync.WaitGroup
+	errs := make([]error, 8)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = agent.NewClient(socketPath).Release(requestId)
+		}(i)
+	}
+	wg.Wait()
+
+	// then
+	released := 0
+	for _, err := range errs {
+		if err == nil {
+			released++
+		} else {
+			assert.Equal(t, agent.ErrRequestNotFound, err)
+		}
+	}
+	assert.Equal(t, 1, released)
+}
+
+func TestAgent_RequireQuorum_ListPendingWhileApproving(t *testing.T) {
+	// given
+	pri, keyAgent, socketPath, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+
+	approvers := setUpApprovers(t, 8)
+	approverPubs := make([]heimdall.PubKey, len(approvers))
+	for i, approver := range approvers {
+		approverPubs[i] = approver.PublicKey()
+	}
+	assert.NoError(t, keyAgent.RequireQuorum(len(approvers), approverPubs, time.Minute))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	client := agent.NewClient(socketPath)
+	requestId, err := client.Submit(pri.ID(), []byte("network config update"), signerOpt)
+	assert.NoError(t, err)
+	pending := pendingOf(t, client, requestId)
+
+	// when
+	var wg sync.WaitGroup
+	for _, approver := range approvers {
+		wg.Add(2)
+		go func(approver heimdall.PriKey) {
+			defer wg.Done()
+			assert.NoError(t, agent.NewClient(socketPath).Approve(requestId, approve(t, approver, pending, signerOpt)))
+		}(approver)
+		go func() {
+			defer wg.Done()
+			_, err := agent.NewClient(socketPath).ListPending()
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// then
+	assert.Len(t, pendingOf(t, client, requestId).Approvers, len(approvers))
+}
+
+func TestAgent_RequireQuorum_InvalidM(t *testing.T) {
+	// given
+	_, keyAgent, _, tearDown := setUpAgent(t, nil)
+	defer tearDown()
+	approvers := setUpApprovers(t, 2)
+	duplicated := []heimdall.PubKey{approvers[0].PublicKey(), approvers[0].PublicKey(), approvers[1].PublicKey()}
+
+	// when
+	zeroErr := keyAgent.RequireQuorum(0, duplicated, time.Minute)
+	tooManyErr := keyAgent.RequireQuorum(3, duplicated, time.Minute)
+	err := keyAgent.RequireQuorum(2, duplicated, time.Minute)
+
+	// then
+	assert.Equal(t, agent.ErrInvalidQuorum, zeroErr)
+	assert.Equal(t, agent.ErrInvalidQuorum, tooManyErr)
+	assert.NoError(t, err)
+}
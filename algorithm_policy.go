@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides allow and deny lists of algorithms enforced at key generation, signing and key loading,
+// so that an organization can ban weak algorithms network-wide by configuration.
+
+package heimdall
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+)
+
+var ErrKeyAlgorithmDenied = errors.New("key algorithm denied - key type is not allowed by algorithm policy")
+var ErrHashAlgorithmDenied = errors.New("hash algorithm denied - hash function is not allowed by algorithm policy")
+var ErrCipherDenied = errors.New("cipher denied - encryption algorithm is not allowed by algorithm policy")
+var ErrKDFDenied = errors.New("kdf denied - key derivation function is not allowed by algorithm policy")
+
+// AlgorithmList permits algorithms listed in Allow, or every algorithm if Allow is empty, except algorithms listed in Deny.
+type AlgorithmList struct {
+	Allow []string `json:",omitempty"`
+	Deny  []string `json:",omitempty"`
+}
+
+// Permits returns true if any of names is allowed and none of names is denied.
+// Several names of an algorithm can be given, such as family and key type. (ex. ECDSA, P-224)
+func (list *AlgorithmList) Permits(names ...string) bool {
+	if contains(list.Deny, names) {
+		return false
+	}
+
+	return len(list.Allow) == 0 || contains(list.Allow, names)
+}
+
+func contains(list []string, names []string) bool {
+	for _, entry := range list {
+		for _, name := range names {
+			if entry == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// AlgorithmPolicy is a set of algorithm lists for each kind of algorithm.
+// Keys are matched by family, key type or key ID prefix (ex. ECDSA, ECDSA_P-224, P-224, ECP224),
+// hashes by name (ex. SHA224), ciphers by algorithm with or without key length (ex. AES, AES128) and KDFs by name (ex. PBKDF2).
+type AlgorithmPolicy struct {
+	Keys    AlgorithmList
+	Hashes  AlgorithmList
+	Ciphers AlgorithmList
+	KDFs    AlgorithmList
+}
+
+var algorithmPolicy = &AlgorithmPolicy{}
+var algorithmPolicyMutex = &sync.RWMutex{}
+
+// SetAlgorithmPolicy replaces algorithm policy of the process. Nil policy permits every algorithm.
+func SetAlgorithmPolicy(policy *AlgorithmPolicy) {
+	if policy == nil {
+		policy = &AlgorithmPolicy{}
+	}
+
+	algorithmPolicyMutex.Lock()
+	defer algorithmPolicyMutex.Unlock()
+
+	algorithmPolicy = policy
+}
+
+// CurrentAlgorithmPolicy returns algorithm policy of the process.
+func CurrentAlgorithmPolicy() *AlgorithmPolicy {
+	algorithmPolicyMutex.RLock()
+	defer algorithmPolicyMutex.RUnlock()
+
+	return algorithmPolicy
+}
+
+// CheckKey checks if key type of key generation option is permitted.
+func (policy *AlgorithmPolicy) CheckKey(keyGenOpts KeyGenOpts) error {
+	keyType, err := KeyTypeOf(keyGenOpts)
+	if err != nil {
+		return err
+	}
+
+	if !policy.Keys.Permits(keyType.Family, keyType.String(), keyType.ToString(), keyIDPrefixOf(keyType)) {
+		return ErrKeyAlgorithmDenied
+	}
+
+	return nil
+}
+
+// CheckHash checks if hash function of name is permitted.
+func (policy *AlgorithmPolicy) CheckHash(name string) error {
+	if !policy.Hashes.Permits(name) {
+		return ErrHashAlgorithmDenied
+	}
+
+	return nil
+}
+
+// CheckCipher checks if encryption algorithm with key length in bits is permitted.
+func (policy *AlgorithmPolicy) CheckCipher(algorithm string, keyLen int) error {
+	if !policy.Ciphers.Permits(algorithm, algorithm+strconv.Itoa(keyLen)) {
+		return ErrCipherDenied
+	}
+
+	return nil
+}
+
+// CheckKDF checks if key derivation function of name is permitted.
+func (policy *AlgorithmPolicy) CheckKDF(name string) error {
+	if !policy.KDFs.Permits(name) {
+		return ErrKDFDenied
+	}
+
+	return nil
+}
+
+// CheckKeyAlgorithm checks key generation option against algorithm policy of the process.
+func CheckKeyAlgorithm(keyGenOpts KeyGenOpts) error {
+	return CurrentAlgorithmPolicy().CheckKey(keyGenOpts)
+}
+
+// CheckHashAlgorithm checks hash function against algorithm policy of the process.
+func CheckHashAlgorithm(name string) error {
+	return CurrentAlgorithmPolicy().CheckHash(name)
+}
+
+// CheckCipherAlgorithm checks encryption algorithm against algorithm policy of the process.
+func CheckCipherAlgorithm(algorithm string, keyLen int) error {
+	return CurrentAlgorithmPolicy().CheckCipher(algorithm, keyLen)
+}
+
+// CheckKDFAlgorithm checks key derivation function against algorithm policy of the process.
+func CheckKDFAlgorithm(name string) error {
+	return CurrentAlgorithmPolicy().CheckKDF(name)
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package heimdall_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlgorithmList_Permits(t *testing.T) {
+	// given
+	allowList := &heimdall.AlgorithmList{Allow: []string{"ECDSA"}, Deny: []string{"P-224"}}
+	denyList := &heimdall.AlgorithmList{Deny: []string{"SHA224"}}
+
+	// then
+	assert.True(t, allowList.Permits("ECDSA", "P-256"))
+	assert.False(t, allowList.Permits("ECDSA", "P-224"))
+	assert.False(t, allowList.Permits("RSA", "RSA2048"))
+	assert.True(t, denyList.Permits("SHA256"))
+	assert.False(t, denyList.Permits("SHA224"))
+}
+
+func TestAlgorithmPolicy_CheckKey(t *testing.T) {
+	// given
+	policy := &heimdall.AlgorithmPolicy{
+		Keys:    heimdall.AlgorithmList{Deny: []string{"ECP224"}},
+		Ciphers: heimdall.AlgorithmList{Deny: []string{"AES128"}},
+		KDFs:    heimdall.AlgorithmList{Allow: []string{"SCRYPT"}},
+	}
+	p224Opt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP224)
+	assert.NoError(t, err)
+	p256Opt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+
+	// then
+	assert.Equal(t, heimdall.ErrKeyAlgorithmDenied, policy.CheckKey(p224Opt))
+	assert.NoError(t, policy.CheckKey(p256Opt))
+	assert.Equal(t, heimdall.ErrCipherDenied, policy.CheckCipher("AES", 128))
+	assert.NoError(t, policy.CheckCipher("AES", 192))
+	assert.Equal(t, heimdall.ErrKDFDenied, policy.CheckKDF("PBKDF2"))
+	assert.NoError(t, policy.CheckKDF("SCRYPT"))
+}
+
+func TestSetAlgorithmPolicy(t *testing.T) {
+	// given
+	p224Opt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP224)
+	assert.NoError(t, err)
+	p256Opt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	p224Pri, err := hecdsa.GenerateKey(p224Opt)
+	assert.NoError(t, err)
+	p256Pri, err := hecdsa.GenerateKey(p256Opt)
+	assert.NoError(t, err)
+	sha224Opt, err := hashing.NewHashOpt(hashing.SHA224)
+	assert.NoError(t, err)
+
+	// when
+	heimdall.SetAlgorithmPolicy(&heimdall.AlgorithmPolicy{
+		Keys:   heimdall.AlgorithmList{Deny: []string{"P-224"}},
+		Hashes: heimdall.AlgorithmList{Deny: []string{hashing.SHA224}},
+	})
+	defer heimdall.SetAlgorithmPolicy(nil)
+
+	// then
+	_, err = hecdsa.GenerateKey(p224Opt)
+	assert.Equal(t, heimdall.ErrKeyAlgorithmDenied, err)
+
+	_, err = hecdsa.NewSigner(p224Pri).Sign([]byte("hello"), hecdsa.NewSignerOpts(nil))
+	assert.Equal(t, heimdall.ErrKeyAlgorithmDenied, err)
+
+	_, err = hecdsa.NewSigner(p256Pri).Sign([]byte("hello"), hecdsa.NewSignerOpts(sha224Opt))
+	assert.Equal(t, heimdall.ErrHashAlgorithmDenied, err)
+
+	_, err = hecdsa.NewSigner(p256Pri).Sign([]byte("hello"), hecdsa.NewSignerOpts(nil))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,311 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides append-only and hash-chained audit log of key lifecycle and signing events.
+
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/iLogger"
+)
+
+var ErrEntryModified = errors.New("invalid audit log - entry is modified")
+var ErrChainBroken = errors.New("invalid audit log - hash chain is broken")
+var ErrLogTruncated = errors.New("invalid audit log - log is truncated")
+var ErrInvalidSignature = errors.New("invalid audit log - entry signature is not valid")
+var ErrSignatureNotExist = errors.New("invalid audit log - entry is not signed")
+
+// audit event types
+const (
+	KeyGenerated  = "KEY_GENERATED"
+	KeyImported   = "KEY_IMPORTED"
+	KeyStored     = "KEY_STORED"
+	KeyLoaded     = "KEY_LOADED"
+	KeyDeleted    = "KEY_DELETED"
+	MessageSigned = "MESSAGE_SIGNED"
+)
+
+// auditEvents maps key lifecycle events of heimdall packages to audit event types.
+var auditEvents = map[event.Type]string{
+	event.KeyCreated:    KeyGenerated,
+	event.KeyImported:   KeyImported,
+	event.KeyStored:     KeyStored,
+	event.KeyLoaded:     KeyLoaded,
+	event.KeyDeleted:    KeyDeleted,
+	event.MessageSigned: MessageSigned,
+}
+
+// Entry is a record of audit log, which is chained to previous entry by hash.
+type Entry struct {
+	Seq       uint64
+	Time      time.Time
+	Event     string
+	KeyID     heimdall.KeyID
+	Detail    string
+	PrevHash  []byte
+	Hash      []byte
+	Signature []byte
+}
+
+// Checkpoint is a head of audit log which should be kept apart from the log to detect truncation.
+type Checkpoint struct {
+	Seq  uint64
+	Hash []byte
+}
+
+// Log is an append-only audit log file.
+type Log struct {
+	mutex       sync.Mutex
+	file        *os.File
+	hashOpt     *hashing.HashOpt
+	pri         heimdall.PriKey
+	signerOpts  heimdall.SignerOpts
+	head        *Checkpoint
+	unsubscribe []func()
+}
+
+// Open opens audit log file to append entries. Entries are signed by pri if pri is not nil.
+func Open(logPath string, hashOpt *hashing.HashOpt, pri heimdall.PriKey, signerOpts heimdall.SignerOpts) (*Log, error) {
+	head, err := verifyLogFile(logPath, hashOpt, nil, nil, nil)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Log{
+		file:       file,
+		hashOpt:    hashOpt,
+		pri:        pri,
+		signerOpts: signerOpts,
+		head:       head,
+	}, nil
+}
+
+// Record appends an event to audit log.
+func (log *Log) Record(event string, keyId heimdall.KeyID, detail string) error {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	entry := &Entry{
+		Time:   time.Now().UTC(),
+		Event:  event,
+		KeyID:  keyId,
+		Detail: detail,
+	}
+
+	if log.head != nil {
+		entry.Seq = log.head.Seq + 1
+		entry.PrevHash = log.head.Hash
+	}
+
+	hash, err := entryHash(entry, log.hashOpt)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+
+	// entry is signed without publishing MessageSigned event, since handlers of the event run while log mutex is held
+	// and may record the entry to this log or to another log which records entries of this log back.
+	if log.pri != nil {
+		entry.Signature, err = hecdsa.SignWithoutEvent(log.pri, entry.Hash, log.signerOpts)
+		if err != nil {
+			return err
+		}
+	}
+
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if _, err = log.file.Write(append(entryBytes, '\n')); err != nil {
+		return err
+	}
+
+	if err = log.file.Sync(); err != nil {
+		return err
+	}
+
+	log.head = &Checkpoint{Seq: entry.Seq, Hash: entry.Hash}
+
+	return nil
+}
+
+// Head returns checkpoint of the last entry. (nil if log is empty)
+func (log *Log) Head() *Checkpoint {
+	log.mutex.Lock()
+	defer log.mutex.Unlock()
+
+	if log.head == nil {
+		return nil
+	}
+
+	return &Checkpoint{Seq: log.head.Seq, Hash: log.head.Hash}
+}
+
+// Subscribe records key generation, import, store, load and deletion, and message signing published on bus,
+// and returns a function cancelling it. Signing of entries does not publish events, so it is not recorded.
+// Events are recorded synchronously, and failure of recording is logged since publishers do not wait for it.
+func (log *Log) Subscribe(bus *event.Bus) func() {
+	types := make([]event.Type, 0, len(auditEvents))
+	for eventType := range auditEvents {
+		types = append(types, eventType)
+	}
+
+	unsubscribe := bus.Subscribe(func(e *event.Event) {
+		if err := log.Record(auditEvents[e.Type], e.KeyID, e.Detail); err != nil {
+			iLogger.Errorf(nil, "[Heimdall] failed to record audit event %s of key %s - %s", e.Type, e.KeyID, err)
+		}
+	}, types...)
+
+	log.mutex.Lock()
+	log.unsubscribe = append(log.unsubscribe, unsubscribe)
+	log.mutex.Unlock()
+
+	return unsubscribe
+}
+
+// Close cancels subscriptions of log and closes log file.
+func (log *Log) Close() error {
+	log.mutex.Lock()
+	unsubscribes := log.unsubscribe
+	log.unsubscribe = nil
+	log.mutex.Unlock()
+
+	for _, unsubscribe := range unsubscribes {
+		unsubscribe()
+	}
+
+	return log.file.Close()
+}
+
+// Verify verifies hash chain of audit log, and signatures of entries if pub is not nil.
+// Truncation or rewriting of log is detected by checkpoint taken before, which can be nil.
+// The entry at the sequence of checkpoint should still have the hash of checkpoint.
+func Verify(logPath string, hashOpt *hashing.HashOpt, pub heimdall.PubKey, signerOpts heimdall.SignerOpts, checkpoint *Checkpoint) error {
+	_, err := verifyLogFile(logPath, hashOpt, pub, signerOpts, checkpoint)
+	return err
+}
+
+// ReadEntries reads all entries of audit log without verification.
+func ReadEntries(logPath string) ([]*Entry, error) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make([]*Entry, 0)
+	decoder := json.NewDecoder(file)
+	for {
+		entry := new(Entry)
+		if err := decoder.Decode(entry); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// verifyLogFile verifies entries of audit log file and returns checkpoint of the last entry.
+// If checkpoint is not nil, the entry with the same sequence should have the same hash.
+func verifyLogFile(logPath string, hashOpt *hashing.HashOpt, pub heimdall.PubKey, signerOpts heimdall.SignerOpts, checkpoint *Checkpoint) (*Checkpoint, error) {
+	entries, err := ReadEntries(logPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var head *Checkpoint
+	for _, entry := range entries {
+		if head == nil {
+			if entry.Seq != 0 || entry.PrevHash != nil {
+				return nil, ErrLogTruncated
+			}
+		} else if entry.Seq != head.Seq+1 || !bytes.Equal(entry.PrevHash, head.Hash) {
+			return nil, ErrChainBroken
+		}
+
+		hash, err := entryHash(entry, hashOpt)
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(hash, entry.Hash) {
+			return nil, ErrEntryModified
+		}
+
+		if pub != nil {
+			if entry.Signature == nil {
+				return nil, ErrSignatureNotExist
+			}
+
+			valid, err := hecdsa.Verify(pub, entry.Signature, entry.Hash, signerOpts)
+			if err != nil {
+				return nil, err
+			}
+
+			if !valid {
+				return nil, ErrInvalidSignature
+			}
+		}
+
+		if checkpoint != nil && entry.Seq == checkpoint.Seq && !bytes.Equal(entry.Hash, checkpoint.Hash) {
+			return nil, ErrEntryModified
+		}
+
+		head = &Checkpoint{Seq: entry.Seq, Hash: entry.Hash}
+	}
+
+	if checkpoint != nil && (head == nil || head.Seq < checkpoint.Seq) {
+		return nil, ErrLogTruncated
+	}
+
+	return head, nil
+}
+
+// entryHash calculates hash of entry except its hash and signature.
+func entryHash(entry *Entry, hashOpt *hashing.HashOpt) ([]byte, error) {
+	content := *entry
+	content.Hash = nil
+	content.Signature = nil
+
+	contentBytes, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return hashing.Hash(contentBytes, hashOpt)
+}
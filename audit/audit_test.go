@@ -0,0 +1,276 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package audit_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/audit"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+var testLogDir = filepath.Join(heimdall.WorkingDir, "./.testAuditLog")
+
+func setUpLog(t *testing.T, pri heimdall.PriKey) (logPath string, checkpoint *audit.Checkpoint, tearDown func()) {
+	err := os.MkdirAll(testLogDir, 0755)
+	assert.NoError(t, err)
+	logPath = filepath.Join(testLogDir, "audit.log")
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	log, err := audit.Open(logPath, hashOpt, pri, hecdsa.NewSignerOpts(hashOpt))
+	assert.NoError(t, err)
+
+	assert.NoError(t, log.Record(audit.KeyGenerated, "ITkey", "P-384"))
+	assert.NoError(t, log.Record(audit.KeyStored, "ITkey", ""))
+	assert.NoError(t, log.Record(audit.MessageSigned, "ITkey", "block 1"))
+
+	checkpoint = log.Head()
+	assert.NoError(t, log.Close())
+
+	return logPath, checkpoint, func() {
+		os.RemoveAll(testLogDir)
+	}
+}
+
+func TestLog_Record(t *testing.T) {
+	// given
+	logPath, checkpoint, tearDown := setUpLog(t, nil)
+	defer tearDown()
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	// when
+	log, err := audit.Open(logPath, hashOpt, nil, nil)
+	assert.NoError(t, err)
+	err = log.Record(audit.KeyDeleted, "ITkey", "")
+	assert.NoError(t, log.Close())
+
+	// then
+	assert.NoError(t, err)
+	entries, err := audit.ReadEntries(logPath)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 4)
+	assert.Equal(t, checkpoint.Hash, entries[3].PrevHash)
+	assert.Equal(t, uint64(3), entries[3].Seq)
+}
+
+func TestVerify(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	otherPri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	logPath, checkpoint, tearDown := setUpLog(t, pri)
+	defer tearDown()
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpts := hecdsa.NewSignerOpts(hashOpt)
+
+	// when
+	err = audit.Verify(logPath, hashOpt, pri.PublicKey(), signerOpts, checkpoint)
+	wrongSignerErr := audit.Verify(logPath, hashOpt, otherPri.PublicKey(), signerOpts, checkpoint)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, audit.ErrInvalidSignature, wrongSignerErr)
+}
+
+func TestVerify_Tampered(t *testing.T) {
+	// given
+	logPath, checkpoint, tearDown := setUpLog(t, nil)
+	defer tearDown()
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	logBytes, err := ioutil.ReadFile(logPath)
+	assert.NoError(t, err)
+	lines := strings.SplitAfter(string(logBytes), "\n")
+
+	// when
+	modified := strings.Replace(string(logBytes), "block 1", "block 2", 1)
+	assert.NoError(t, ioutil.WriteFile(logPath, []byte(modified), 0600))
+	modifiedErr := audit.Verify(logPath, hashOpt, nil, nil, checkpoint)
+
+	assert.NoError(t, ioutil.WriteFile(logPath, []byte(lines[0]+lines[1]), 0600))
+	truncatedErr := audit.Verify(logPath, hashOpt, nil, nil, checkpoint)
+
+	assert.NoError(t, ioutil.WriteFile(logPath, []byte(lines[1]+lines[2]), 0600))
+	headTruncatedErr := audit.Verify(logPath, hashOpt, nil, nil, nil)
+
+	assert.NoError(t, ioutil.WriteFile(logPath, []byte(lines[0]+lines[2]), 0600))
+	removedErr := audit.Verify(logPath, hashOpt, nil, nil, nil)
+
+	// then
+	assert.Equal(t, audit.ErrEntryModified, modifiedErr)
+	assert.Equal(t, audit.ErrLogTruncated, truncatedErr)
+	assert.Equal(t, audit.ErrLogTruncated, headTruncatedErr)
+	assert.Equal(t, audit.ErrChainBroken, removedErr)
+}
+
+func TestVerify_RewoundAndAppended(t *testing.T) {
+	// given
+	logPath, checkpoint, tearDown := setUpLog(t, nil)
+	defer tearDown()
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	logBytes, err := ioutil.ReadFile(logPath)
+	assert.NoError(t, err)
+	lines := strings.SplitAfter(string(logBytes), "\n")
+
+	// when
+	assert.NoError(t, ioutil.WriteFile(logPath, []byte(lines[0]), 0600))
+	log, err := audit.Open(logPath, hashOpt, nil, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, log.Record(audit.MessageSigned, "ITkey", "forged"))
+	}
+	assert.NoError(t, log.Close())
+
+	err = audit.Verify(logPath, hashOpt, nil, nil, checkpoint)
+
+	// then
+	assert.Equal(t, audit.ErrEntryModified, err)
+}
+
+func TestLog_Subscribe(t *testing.T) {
+	// given
+	assert.NoError(t, os.MkdirAll(testLogDir, 0755))
+	defer os.RemoveAll(testLogDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+	logPath := filepath.Join(testLogDir, "audit.log")
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpts := hecdsa.NewSignerOpts(hashOpt)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	logPri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	log, err := audit.Open(logPath, hashOpt, logPri, signerOpts)
+	assert.NoError(t, err)
+	log.Subscribe(event.DefaultBus)
+
+	kdfOpt, err := kdf.NewOpts("SCRYPT", map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+
+	// when
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	loadedPri, err := keyStore.LoadPriKey(pri.ID(), "password")
+	assert.NoError(t, err)
+	_, err = hecdsa.NewSigner(loadedPri).Sign([]byte("block 1"), signerOpts)
+	assert.NoError(t, err)
+	assert.NoError(t, keystore.DeleteKey(pri.ID(), heimdall.TestPriKeyDir))
+	assert.NoError(t, log.Close())
+
+	_, err = hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// then
+	assert.NoError(t, audit.Verify(logPath, hashOpt, logPri.PublicKey(), signerOpts, nil))
+	entries, err := audit.ReadEntries(logPath)
+	assert.NoError(t, err)
+
+	events := make([]string, 0)
+	for _, entry := range entries {
+		assert.Equal(t, pri.ID(), entry.KeyID)
+		events = append(events, entry.Event)
+	}
+	assert.Equal(t, []string{audit.KeyGenerated, audit.KeyStored, audit.KeyLoaded, audit.MessageSigned, audit.KeyDeleted}, events)
+}
+
+func TestLog_Subscribe_SignedLogs(t *testing.T) {
+	// given
+	assert.NoError(t, os.MkdirAll(testLogDir, 0755))
+	defer os.RemoveAll(testLogDir)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpts := hecdsa.NewSignerOpts(hashOpt)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	logPaths := []string{filepath.Join(testLogDir, "audit1.log"), filepath.Join(testLogDir, "audit2.log")}
+	logPris := make([]heimdall.PriKey, len(logPaths))
+	logs := make([]*audit.Log, len(logPaths))
+	for i := range logPris {
+		logPris[i], err = hecdsa.GenerateKey(keyGenOpt)
+		assert.NoError(t, err)
+	}
+	for i, logPath := range logPaths {
+		logs[i], err = audit.Open(logPath, hashOpt, logPris[i], signerOpts)
+		assert.NoError(t, err)
+		logs[i].Subscribe(event.DefaultBus)
+	}
+
+	// when
+	signed := make(chan error)
+	go func() {
+		_, err := hecdsa.NewSigner(pri).Sign([]byte("block 1"), signerOpts)
+		signed <- err
+	}()
+
+	// then
+	select {
+	case err := <-signed:
+		assert.NoError(t, err)
+	case <-time.After(10 * time.Second):
+		t.Fatal("signing is blocked by recording audit logs")
+	}
+
+	for i, log := range logs {
+		assert.NoError(t, log.Close())
+		assert.NoError(t, audit.Verify(logPaths[i], hashOpt, logPris[i].PublicKey(), signerOpts, nil))
+
+		entries, err := audit.ReadEntries(logPaths[i])
+		assert.NoError(t, err)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, pri.ID(), entries[0].KeyID)
+		assert.Equal(t, audit.MessageSigned, entries[0].Event)
+	}
+}
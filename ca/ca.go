@@ -0,0 +1,321 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides certificate authority which issues certificates of nodes for CSRs validated by CSR policy.
+
+package ca
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/fileperm"
+)
+
+var ErrCANotSet = errors.New("CA certificate chain and signer should not be empty")
+var ErrCertNotIssued = errors.New("certificate not issued - certificate of serial number is not issued by the CA")
+var ErrNotEnrolled = errors.New("not enrolled - certificate is not valid certificate issued by the CA")
+var ErrSubjectMismatch = errors.New("subject mismatch - CSR for reenrollment should have subject of the enrolled certificate")
+
+// DefaultValidity is the period issued certificates are valid for, if options of CA do not set it.
+const DefaultValidity = 365 * 24 * time.Hour
+
+// clock skew tolerated by issued certificates, which are valid from a little before issuance
+const issueSkew = time.Minute
+
+// state of CA kept in CertDirPath: issued certificates by serial number, and revocation list
+const stateDirName = ".ca"
+const issuedDirName = "issued"
+const revocationFileName = "revocation.json"
+
+// Options is options of CA. Policy, Validity, BaseURL and CertDirPath are optional.
+type Options struct {
+	// Chain is CA certificate followed by its issuers up to root, which is sent with issued certificates.
+	Chain []*x509.Certificate
+
+	// Signer is private key of CA certificate. (ex. hecdsa.PriKey)
+	Signer crypto.Signer
+
+	// Policy is checked on every CSR before issuance. CSR signature and key are checked by default policy if it is nil.
+	Policy *cert.CSRPolicy
+
+	// Validity is the period issued certificates are valid for, which ends no later than CA certificate.
+	Validity time.Duration
+
+	// BaseURL is URL where handler of the CA is served, which is set as CRL distribution point and issuing
+	// certificate URL of issued certificates. (ex. https://ca.example.com:7054)
+	BaseURL string
+
+	// CertDirPath is certificate store directory where issued certificates are stored by key ID, so that they are served
+	// by handler of the CA. Certificate already stored for the key is kept. (see cert.Store)
+	// Issued certificates by serial number, revocations and CRL number are also kept in its ".ca" directory,
+	// and are loaded by New, so that the CA survives restart.
+	CertDirPath string
+}
+
+// CA is a certificate authority which issues certificates for CSRs, and revokes them by CRL.
+// Issued and revoked certificates are kept in memory, and are also kept in CertDirPath if it is set.
+type CA struct {
+	opts       Options
+	revocation *cert.RevocationList
+	mutex      sync.RWMutex
+	issued     map[string]*x509.Certificate
+}
+
+// New makes CA of options.
+func New(opts *Options) (*CA, error) {
+	if len(opts.Chain) == 0 || opts.Chain[0] == nil || opts.Signer == nil {
+		return nil, ErrCANotSet
+	}
+
+	caOpts := *opts
+	if caOpts.Validity <= 0 {
+		caOpts.Validity = DefaultValidity
+	}
+
+	if caOpts.CertDirPath == "" {
+		revocation, err := cert.NewRevocationList(caOpts.Chain[0], caOpts.Signer, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		return &CA{
+			opts:       caOpts,
+			revocation: revocation,
+			issued:     make(map[string]*x509.Certificate),
+		}, nil
+	}
+
+	stateDirPath := filepath.Join(caOpts.CertDirPath, stateDirName)
+	revocation, err := cert.LoadRevocationList(caOpts.Chain[0], caOpts.Signer, 0, filepath.Join(stateDirPath, revocationFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	issued, err := loadIssued(filepath.Join(stateDirPath, issuedDirName))
+	if err != nil {
+		return nil, err
+	}
+
+	return &CA{
+		opts:       caOpts,
+		revocation: revocation,
+		issued:     issued,
+	}, nil
+}
+
+// loadIssued loads certificates issued by the CA from directory, by serial number.
+func loadIssued(dirPath string) (map[string]*x509.Certificate, error) {
+	issued := make(map[string]*x509.Certificate)
+
+	files, err := ioutil.ReadDir(dirPath)
+	if os.IsNotExist(err) {
+		return issued, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".crt" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dirPath, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		issuedCert, err := cert.PemToX509Cert(data)
+		if err != nil {
+			return nil, err
+		}
+		issued[issuedCert.SerialNumber.String()] = issuedCert
+	}
+
+	return issued, nil
+}
+
+// storeIssued keeps certificate issued by the CA in CertDirPath, by serial number.
+func (ca *CA) storeIssued(issued *x509.Certificate) error {
+	dirPath := filepath.Join(ca.opts.CertDirPath, stateDirName, issuedDirName)
+	if err := fileperm.MkdirAll(dirPath); err != nil {
+		return err
+	}
+
+	return fileperm.WriteFile(filepath.Join(dirPath, issued.SerialNumber.String()+".crt"), cert.X509CertToPem(issued))
+}
+
+// Cert returns CA certificate.
+func (ca *CA) Cert() *x509.Certificate {
+	return ca.opts.Chain[0]
+}
+
+// Enroll validates CSR by policy of CA, and issues certificate for it.
+// Issued certificate is returned with certificate chain of CA.
+func (ca *CA) Enroll(csr *x509.CertificateRequest) ([]*x509.Certificate, error) {
+	if err := cert.ValidateCSR(csr, ca.opts.Policy); err != nil {
+		return nil, err
+	}
+
+	issued, err := ca.issue(csr)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]*x509.Certificate{issued}, ca.opts.Chain...), nil
+}
+
+// Reenroll issues new certificate for CSR of the node enrolled by current certificate, which should be
+// unexpired and unrevoked certificate issued by the CA. Key of CSR may be a new key, but subject should be the same.
+func (ca *CA) Reenroll(current *x509.Certificate, csr *x509.CertificateRequest) ([]*x509.Certificate, error) {
+	if err := ca.checkEnrolled(current); err != nil {
+		return nil, err
+	}
+
+	if csr.Subject.String() != current.Subject.String() {
+		return nil, ErrSubjectMismatch
+	}
+
+	return ca.Enroll(csr)
+}
+
+// Revoke revokes certificate of serial number issued by the CA, which is in CRL of the CA from then on.
+func (ca *CA) Revoke(serialNumber *big.Int) error {
+	ca.mutex.RLock()
+	issued, ok := ca.issued[serialNumber.String()]
+	ca.mutex.RUnlock()
+
+	if !ok {
+		return ErrCertNotIssued
+	}
+
+	if err := ca.revocation.Revoke(serialNumber); err != nil {
+		return err
+	}
+	if pub, err := cert.ToPubKey(issued.PublicKey); err == nil {
+		event.Publish(event.CertRevoked, pub.ID(), serialNumber.String())
+	}
+
+	return nil
+}
+
+// IsRevoked checks if certificate of serial number is revoked by the CA.
+func (ca *CA) IsRevoked(serialNumber *big.Int) bool {
+	return ca.revocation.IsRevoked(serialNumber)
+}
+
+// CRL makes current CRL of the CA in DER.
+func (ca *CA) CRL() ([]byte, error) {
+	return ca.revocation.CRL()
+}
+
+// checkEnrolled checks if certificate is issued by the CA, and is neither expired nor revoked.
+func (ca *CA) checkEnrolled(current *x509.Certificate) error {
+	ca.mutex.RLock()
+	issued, ok := ca.issued[current.SerialNumber.String()]
+	ca.mutex.RUnlock()
+
+	if !ok || ca.revocation.IsRevoked(current.SerialNumber) || !issued.Equal(current) {
+		return ErrNotEnrolled
+	}
+
+	now := time.Now()
+	if now.Before(current.NotBefore) || now.After(current.NotAfter) {
+		return ErrNotEnrolled
+	}
+
+	return nil
+}
+
+// issue issues certificate of subject, names and public key of CSR, valid for both server and client authentication.
+func (ca *CA) issue(csr *x509.CertificateRequest) (*x509.Certificate, error) {
+	pub, err := cert.ToPubKey(csr.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, err
+	}
+
+	caCert := ca.Cert()
+	notAfter := time.Now().Add(ca.opts.Validity)
+	if notAfter.After(caCert.NotAfter) {
+		notAfter = caCert.NotAfter
+	}
+
+	keyUsage := x509.KeyUsageDigitalSignature
+	if _, ok := csr.PublicKey.(*rsa.PublicKey); ok {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:   serialNumber,
+		Subject:        csr.Subject,
+		DNSNames:       csr.DNSNames,
+		IPAddresses:    csr.IPAddresses,
+		EmailAddresses: csr.EmailAddresses,
+		URIs:           csr.URIs,
+		NotBefore:      time.Now().Add(-issueSkew),
+		NotAfter:       notAfter,
+		KeyUsage:       keyUsage,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		SubjectKeyId:   pub.SKI(),
+	}
+	if ca.opts.BaseURL != "" {
+		cert.SetDistributionPoints(template, ca.opts.BaseURL)
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, template, caCert, csr.PublicKey, ca.opts.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	issued, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if ca.opts.CertDirPath != "" {
+		if err := ca.storeIssued(issued); err != nil {
+			return nil, err
+		}
+
+		if err := cert.Store(issued, ca.opts.CertDirPath); err != nil {
+			return nil, err
+		}
+	}
+
+	ca.mutex.Lock()
+	ca.issued[serialNumber.String()] = issued
+	ca.mutex.Unlock()
+	event.Publish(event.CertIssued, pub.ID(), serialNumber.String())
+
+	return issued, nil
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ca_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/ca"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpCA(t *testing.T, baseURL string) *ca.CA {
+	testCA, err := ca.New(setUpCAOpts(t, baseURL))
+	assert.NoError(t, err)
+
+	return testCA
+}
+
+func setUpCAOpts(t *testing.T, baseURL string) *ca.Options {
+	rootPri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := mocks.TestRootCertTemplate
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour * 24 * 180)
+	template.CRLDistributionPoints = nil
+	template.KeyUsage |= x509.KeyUsageCRLSign
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &rootPri.PublicKey, rootPri)
+	assert.NoError(t, err)
+	rootCert, err := x509.ParseCertificate(derBytes)
+	assert.NoError(t, err)
+
+	return &ca.Options{
+		Chain:   []*x509.Certificate{rootCert},
+		Signer:  rootPri,
+		BaseURL: baseURL,
+	}
+}
+
+func createCSR(t *testing.T, pri crypto.Signer, commonName string, ipAddresses ...net.IP) []byte {
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: commonName},
+		DNSNames:    []string{commonName + ".example.com"},
+		IPAddresses: ipAddresses,
+	}, pri)
+	assert.NoError(t, err)
+
+	return csrDER
+}
+
+func enroll(t *testing.T, testCA *ca.CA, commonName string) (*ecdsa.PrivateKey, []*x509.Certificate) {
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csr, err := cert.ParseCSR(createCSR(t, pri, commonName))
+	assert.NoError(t, err)
+
+	chain, err := testCA.Enroll(csr)
+	assert.NoError(t, err)
+
+	return pri, chain
+}
+
+func TestNew(t *testing.T) {
+	// given
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	// when
+	_, chainErr := ca.New(&ca.Options{Signer: pri})
+	_, signerErr := ca.New(&ca.Options{Chain: []*x509.Certificate{{}}})
+
+	// then
+	assert.Equal(t, ca.ErrCANotSet, chainErr)
+	assert.Equal(t, ca.ErrCANotSet, signerErr)
+}
+
+func TestCA_Enroll(t *testing.T) {
+	// given
+	testCA := setUpCA(t, "https://ca.example.com")
+
+	// when
+	pri, chain := enroll(t, testCA, "node1")
+
+	// then
+	assert.Len(t, chain, 2)
+	assert.Equal(t, testCA.Cert(), chain[1])
+	assert.NoError(t, cert.ValidateChainOrder(chain))
+	assert.Equal(t, "node1", chain[0].Subject.CommonName)
+	assert.Equal(t, []string{"node1.example.com"}, chain[0].DNSNames)
+	assert.Equal(t, &pri.PublicKey, chain[0].PublicKey)
+	assert.Equal(t, []string{"https://ca.example.com" + cert.CRLPath}, chain[0].CRLDistributionPoints)
+	assert.Contains(t, chain[0].ExtKeyUsage, x509.ExtKeyUsageClientAuth)
+	assert.False(t, chain[0].NotAfter.After(testCA.Cert().NotAfter))
+}
+
+func TestCA_Enroll_InvalidCSR(t *testing.T) {
+	// given
+	testCA := setUpCA(t, "")
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csr, err := cert.ParseCSR(createCSR(t, pri, "node1"))
+	assert.NoError(t, err)
+	csr.Signature[len(csr.Signature)-1] ^= 0x01
+
+	// when
+	_, err = testCA.Enroll(csr)
+
+	// then
+	assert.Equal(t, cert.ErrCSRSignatureInvalid, err)
+}
+
+func TestCA_Reenroll(t *testing.T) {
+	// given
+	testCA := setUpCA(t, "")
+	_, chain := enroll(t, testCA, "node1")
+	_, other := enroll(t, testCA, "node2")
+
+	newPri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csr, err := cert.ParseCSR(createCSR(t, newPri, "node1"))
+	assert.NoError(t, err)
+
+	// when
+	reenrolled, err := testCA.Reenroll(chain[0], csr)
+	_, mismatchErr := testCA.Reenroll(other[0], csr)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, &newPri.PublicKey, reenrolled[0].PublicKey)
+	assert.NotEqual(t, chain[0].SerialNumber, reenrolled[0].SerialNumber)
+	assert.Equal(t, ca.ErrSubjectMismatch, mismatchErr)
+}
+
+func TestCA_Reenroll_NotEnrolled(t *testing.T) {
+	// given
+	testCA := setUpCA(t, "")
+	otherCA := setUpCA(t, "")
+	pri, chain := enroll(t, testCA, "node1")
+	_, otherChain := enroll(t, otherCA, "node1")
+	csr, err := cert.ParseCSR(createCSR(t, pri, "node1"))
+	assert.NoError(t, err)
+
+	// when
+	_, otherErr := testCA.Reenroll(otherChain[0], csr)
+	assert.NoError(t, testCA.Revoke(chain[0].SerialNumber))
+	_, revokedErr := testCA.Reenroll(chain[0], csr)
+
+	// then
+	assert.Equal(t, ca.ErrNotEnrolled, otherErr)
+	assert.Equal(t, ca.ErrNotEnrolled, revokedErr)
+}
+
+func TestCA_Revoke(t *testing.T) {
+	// given
+	testCA := setUpCA(t, "")
+	_, chain := enroll(t, testCA, "node1")
+
+	// when
+	err := testCA.Revoke(chain[0].SerialNumber)
+	notIssuedErr := testCA.Revoke(big.NewInt(1))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, ca.ErrCertNotIssued, notIssuedErr)
+	assert.True(t, testCA.IsRevoked(chain[0].SerialNumber))
+
+	crlBytes, err := testCA.CRL()
+	assert.NoError(t, err)
+	crl, err := x509.ParseCRL(crlBytes)
+	assert.NoError(t, err)
+	assert.Len(t, crl.TBSCertList.RevokedCertificates, 1)
+	assert.Equal(t, chain[0].SerialNumber, crl.TBSCertList.RevokedCertificates[0].SerialNumber)
+}
+
+func TestCA_Revoke_AfterRestart(t *testing.T) {
+	// given
+	opts := setUpCAOpts(t, "")
+	opts.CertDirPath = heimdall.TestCertDir
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	testCA, err := ca.New(opts)
+	assert.NoError(t, err)
+	pri, chain := enroll(t, testCA, "node1")
+	_, otherChain := enroll(t, testCA, "node2")
+	assert.NoError(t, testCA.Revoke(chain[0].SerialNumber))
+	crlBytes, err := testCA.CRL()
+	assert.NoError(t, err)
+	crl, err := x509.ParseRevocationList(crlBytes)
+	assert.NoError(t, err)
+
+	// when
+	restarted, err := ca.New(opts)
+	assert.NoError(t, err)
+
+	// then
+	assert.True(t, restarted.IsRevoked(chain[0].SerialNumber))
+	assert.False(t, restarted.IsRevoked(otherChain[0].SerialNumber))
+
+	restartedCRLBytes, err := restarted.CRL()
+	assert.NoError(t, err)
+	restartedCRL, err := x509.ParseRevocationList(restartedCRLBytes)
+	assert.NoError(t, err)
+	assert.Len(t, restartedCRL.RevokedCertificates, 1)
+	assert.Equal(t, chain[0].SerialNumber, restartedCRL.RevokedCertificates[0].SerialNumber)
+	assert.Equal(t, 1, restartedCRL.Number.Cmp(crl.Number))
+
+	csr, err := cert.ParseCSR(createCSR(t, pri, "node1"))
+	assert.NoError(t, err)
+	_, revokedErr := restarted.Reenroll(chain[0], csr)
+	assert.Equal(t, ca.ErrNotEnrolled, revokedErr)
+	assert.NoError(t, restarted.Revoke(otherChain[0].SerialNumber))
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides client of CA handler, with which nodes obtain certificates from CA over the network.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/DE-labtory/heimdall/cert"
+)
+
+var ErrEmptyResponse = errors.New("empty response - CA returned no certificate")
+
+// Client requests enrollment, reenrollment and revocation to CA handler at BaseURL.
+type Client struct {
+	// BaseURL is URL where handler of CA is served. (ex. https://ca.example.com:7054)
+	BaseURL string
+
+	// HTTPClient sends requests, which should have TLS client certificate of the node for reenrollment and revocation.
+	// http.DefaultClient is used if it is nil.
+	HTTPClient *http.Client
+}
+
+// Enroll requests certificate for CSR in PEM or DER, authenticated by enrollment ID and secret.
+// Issued certificate is returned with certificate chain of CA.
+func (client *Client) Enroll(ctx context.Context, csr []byte, enrollmentId, secret string) ([]*x509.Certificate, error) {
+	respBody, err := client.post(ctx, EnrollPath, csr, func(req *http.Request) {
+		req.SetBasicAuth(enrollmentId, secret)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return parseChain(respBody)
+}
+
+// Reenroll requests new certificate for CSR in PEM or DER, authenticated by TLS client certificate of HTTPClient.
+func (client *Client) Reenroll(ctx context.Context, csr []byte) ([]*x509.Certificate, error) {
+	respBody, err := client.post(ctx, ReenrollPath, csr, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseChain(respBody)
+}
+
+// Revoke requests revocation of certificate of serial number, which should be TLS client certificate of HTTPClient.
+func (client *Client) Revoke(ctx context.Context, serialNumber *big.Int) error {
+	_, err := client.post(ctx, RevokePath, []byte(serialNumber.String()), nil)
+	return err
+}
+
+// post sends POST request to path of CA handler and returns response body, or error of non-2xx status.
+func (client *Client) post(ctx context.Context, path string, body []byte, setUp func(req *http.Request)) ([]byte, error) {
+	url := strings.TrimSuffix(client.BaseURL, "/") + path
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	if setUp != nil {
+		setUp(req)
+	}
+
+	httpClient := client.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode >= 300 {
+		return nil, errors.New("CA request failed - http status code :[" + strconv.Itoa(resp.StatusCode) + "] " + strings.TrimSpace(string(respBody)))
+	}
+
+	return respBody, nil
+}
+
+// parseChain parses certificate chain in PEM returned by CA handler.
+func parseChain(respBody []byte) ([]*x509.Certificate, error) {
+	chain, err := cert.PemToX509Certs(respBody)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(chain) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	return chain, nil
+}
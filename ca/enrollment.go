@@ -0,0 +1,131 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides enrollment client, with which a node bootstraps by obtaining and storing its certificate from CA.
+
+package ca
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+)
+
+var ErrCertDirNotSet = errors.New("certificate store directory of enrollment client should not be empty")
+var ErrCertKeyMismatch = errors.New("certificate key mismatch - issued certificate is not certificate of the CSR key")
+
+// EnrollmentClient enrolls a node to CA, verifies the issued certificate chain, and stores it in certificate store.
+type EnrollmentClient struct {
+	// CertDirPath is certificate store directory where issued certificate and its chain are stored.
+	CertDirPath string
+
+	// TrustStore has trust anchors of CA, which issued certificate chain is verified against.
+	// Root certificates in CertDirPath are trusted if it is nil. (see cert.NewDirTrustStore)
+	TrustStore heimdall.TrustStore
+
+	// Skew is clock skew tolerated on validity period of issued certificate.
+	Skew time.Duration
+
+	// HTTPClient sends requests to CA. http.DefaultClient is used if it is nil.
+	HTTPClient *http.Client
+}
+
+// Enroll submits CSR in PEM or DER to CA handler at caURL with enrollment token, which is the secret registered
+// for common name of CSR. (see AllowSecrets) Issued certificate chain is verified and stored, and returned.
+func (client *EnrollmentClient) Enroll(csr []byte, caURL, token string) ([]*x509.Certificate, error) {
+	return client.EnrollWithContext(context.Background(), csr, caURL, token)
+}
+
+// EnrollWithContext is Enroll whose request to CA is cancelled along with ctx.
+func (client *EnrollmentClient) EnrollWithContext(ctx context.Context, csr []byte, caURL, token string) ([]*x509.Certificate, error) {
+	if client.CertDirPath == "" {
+		return nil, ErrCertDirNotSet
+	}
+
+	csrReq, err := cert.ParseCSR(csr)
+	if err != nil {
+		return nil, err
+	}
+
+	caClient := &Client{BaseURL: caURL, HTTPClient: client.HTTPClient}
+	chain, err := caClient.Enroll(ctx, csr, csrReq.Subject.CommonName, token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.verify(csrReq, chain); err != nil {
+		return nil, err
+	}
+
+	if err := cert.Store(chain[0], client.CertDirPath); err != nil {
+		return nil, err
+	}
+
+	if err := cert.StoreChain(chain, client.CertDirPath); err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
+
+// verify checks if certificate chain is ordered, its leaf is certificate of CSR key,
+// and it chains to trust anchors of trust store.
+func (client *EnrollmentClient) verify(csr *x509.CertificateRequest, chain []*x509.Certificate) error {
+	if err := cert.ValidateChainOrder(chain); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(chain[0].RawSubjectPublicKeyInfo, csr.RawSubjectPublicKeyInfo) {
+		return ErrCertKeyMismatch
+	}
+
+	trustStore := client.TrustStore
+	if trustStore == nil {
+		trustStore = cert.NewDirTrustStore(client.CertDirPath)
+	}
+
+	verifier := cert.NewVerifierWithTrustStore(&chainTrustStore{TrustStore: trustStore, chain: chain}, client.Skew, nil)
+	return verifier.VerifyChain(chain[0])
+}
+
+// chainTrustStore is trust store which also has intermediates of issued certificate chain.
+// Roots of the chain are not trusted unless they are roots of the trust store.
+type chainTrustStore struct {
+	heimdall.TrustStore
+	chain []*x509.Certificate
+}
+
+func (store *chainTrustStore) Intermediates() ([]*x509.Certificate, error) {
+	intermediates, err := store.TrustStore.Intermediates()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, chainCert := range store.chain[1:] {
+		if !bytes.Equal(chainCert.RawIssuer, chainCert.RawSubject) {
+			intermediates = append(intermediates, chainCert)
+		}
+	}
+
+	return intermediates, nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ca_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/ca"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnrollmentClient_Enroll(t *testing.T) {
+	// given
+	testCA, server := setUpServer(t)
+	defer server.Close()
+
+	assert.NoError(t, cert.Store(testCA.Cert(), heimdall.TestCertDir))
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	client := &ca.EnrollmentClient{
+		CertDirPath: heimdall.TestCertDir,
+		HTTPClient:  newClient(testCA, server).HTTPClient,
+	}
+
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	keyId := hecdsa.NewPubKey(&pri.PublicKey).ID()
+
+	// when
+	chain, err := client.Enroll(createCSR(t, pri, "node1"), server.URL, "secret1")
+	_, tokenErr := client.Enroll(createCSR(t, pri, "node1"), server.URL, "secret2")
+
+	// then
+	assert.NoError(t, err)
+	assert.Contains(t, tokenErr.Error(), "403")
+
+	stored, err := cert.Load(keyId, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	assert.Equal(t, chain[0].Raw, stored.Raw)
+
+	storedChain, err := cert.LoadChain(keyId, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	assert.Len(t, storedChain, 2)
+}
+
+func TestEnrollmentClient_Enroll_UntrustedCA(t *testing.T) {
+	// given
+	testCA, server := setUpServer(t)
+	defer server.Close()
+
+	otherCA := setUpCA(t, "")
+	trustStore := cert.NewMemTrustStore()
+	trustStore.AddRoot(otherCA.Cert())
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	client := &ca.EnrollmentClient{
+		CertDirPath: heimdall.TestCertDir,
+		TrustStore:  trustStore,
+		HTTPClient:  newClient(testCA, server).HTTPClient,
+	}
+
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	// when
+	_, err = client.Enroll(createCSR(t, pri, "node1"), server.URL, "secret1")
+	_, dirErr := (&ca.EnrollmentClient{}).Enroll(createCSR(t, pri, "node1"), server.URL, "secret1")
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, ca.ErrCertDirNotSet, dirErr)
+	_, err = os.Stat(heimdall.TestCertDir)
+	assert.True(t, os.IsNotExist(err))
+}
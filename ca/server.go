@@ -0,0 +1,206 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides HTTP handler of CA, where nodes enroll, reenroll and revoke certificates over the network.
+
+package ca
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/DE-labtory/heimdall/cert"
+)
+
+// paths served by handler of CA, in addition to paths of cert.NewDistributionHandler
+const (
+	EnrollPath   = "/enroll"
+	ReenrollPath = "/reenroll"
+	RevokePath   = "/revoke"
+)
+
+// maxRequestSize limits size of CSR or serial number posted to the handler.
+const maxRequestSize = 64 * 1024
+
+// Authorizer decides if enrollment of CSR is allowed for request, such as by credentials of the request.
+type Authorizer func(r *http.Request, csr *x509.CertificateRequest) bool
+
+// AllowSecrets allows enrollment of nodes registered with secrets, which authenticate by HTTP basic authentication
+// with enrollment ID and secret. Common name of CSR should be the enrollment ID.
+func AllowSecrets(secrets map[string]string) Authorizer {
+	return func(r *http.Request, csr *x509.CertificateRequest) bool {
+		enrollmentId, secret, ok := r.BasicAuth()
+		if !ok || enrollmentId != csr.Subject.CommonName {
+			return false
+		}
+
+		registered, ok := secrets[enrollmentId]
+		return ok && subtle.ConstantTimeCompare([]byte(secret), []byte(registered)) == 1
+	}
+}
+
+// NewHandler makes HTTP handler of CA. CSR in PEM or DER is posted to EnrollPath and ReenrollPath, and issued
+// certificate chain is returned in PEM. Serial number of certificate in decimal is posted to RevokePath.
+// Enrollment is allowed by authorizer, and every enrollment is refused if authorizer is nil.
+// Reenrollment and revocation are allowed for TLS client certificate issued by the CA, which is the certificate
+// to be revoked for revocation. (see TLSConfig) CA certificate, CRL and issued certificates are served at
+// paths of cert.NewDistributionHandler.
+func NewHandler(ca *CA, authorizer Authorizer) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.Handle("/", cert.NewDistributionHandler(&cert.Distribution{
+		CACert:      ca.Cert(),
+		CRL:         ca.CRL,
+		CertDirPath: ca.opts.CertDirPath,
+	}))
+
+	mux.HandleFunc(EnrollPath, func(w http.ResponseWriter, r *http.Request) {
+		csr, ok := readCSR(w, r)
+		if !ok {
+			return
+		}
+
+		if authorizer == nil || !authorizer(r, csr) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		chain, err := ca.Enroll(csr)
+		writeChain(w, chain, err)
+	})
+
+	mux.HandleFunc(ReenrollPath, func(w http.ResponseWriter, r *http.Request) {
+		csr, ok := readCSR(w, r)
+		if !ok {
+			return
+		}
+
+		current, ok := clientCert(w, r)
+		if !ok {
+			return
+		}
+
+		chain, err := ca.Reenroll(current, csr)
+		writeChain(w, chain, err)
+	})
+
+	mux.HandleFunc(RevokePath, func(w http.ResponseWriter, r *http.Request) {
+		body, ok := readBody(w, r)
+		if !ok {
+			return
+		}
+
+		serialNumber, ok := new(big.Int).SetString(strings.TrimSpace(string(body)), 10)
+		if !ok {
+			http.Error(w, "invalid serial number", http.StatusBadRequest)
+			return
+		}
+
+		current, ok := clientCert(w, r)
+		if !ok {
+			return
+		}
+
+		if err := ca.checkEnrolled(current); err != nil || current.SerialNumber.Cmp(serialNumber) != 0 {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		if err := ca.Revoke(serialNumber); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	return mux
+}
+
+// TLSConfig makes TLS configuration of server of the handler with server certificate, which verifies
+// TLS client certificates issued by the CA for reenrollment and revocation. Client certificate is optional for enrollment.
+func (ca *CA) TLSConfig(serverCert tls.Certificate) *tls.Config {
+	clientCAs := x509.NewCertPool()
+	for _, chainCert := range ca.opts.Chain {
+		clientCAs.AddCert(chainCert)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientCAs:    clientCAs,
+		MinVersion:   tls.VersionTLS12,
+	}
+}
+
+// readBody reads body of POST request, limited to maxRequestSize.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return nil, false
+	}
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	return body, true
+}
+
+// readCSR reads CSR in PEM or DER posted in request.
+func readCSR(w http.ResponseWriter, r *http.Request) (*x509.CertificateRequest, bool) {
+	body, ok := readBody(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	csr, err := cert.ParseCSR(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return nil, false
+	}
+
+	return csr, true
+}
+
+// clientCert returns TLS client certificate of request, which is verified against CA by TLS configuration.
+func clientCert(w http.ResponseWriter, r *http.Request) (*x509.Certificate, bool) {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return nil, false
+	}
+
+	return r.TLS.PeerCertificates[0], true
+}
+
+// writeChain writes issued certificate chain in PEM, or error of issuance.
+func writeChain(w http.ResponseWriter, chain []*x509.Certificate, err error) {
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pem-certificate-chain")
+	w.Write(cert.X509CertsToPem(chain))
+}
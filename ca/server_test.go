@@ -0,0 +1,175 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ca_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/ca"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/stretchr/testify/assert"
+)
+
+var testSecrets = map[string]string{"node1": "secret1"}
+
+func setUpServer(t *testing.T) (*ca.CA, *httptest.Server) {
+	testCA := setUpCA(t, "")
+
+	serverPri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csr, err := cert.ParseCSR(createCSR(t, serverPri, "ca", net.IPv4(127, 0, 0, 1)))
+	assert.NoError(t, err)
+	serverChain, err := testCA.Enroll(csr)
+	assert.NoError(t, err)
+
+	server := httptest.NewUnstartedServer(ca.NewHandler(testCA, ca.AllowSecrets(testSecrets)))
+	server.TLS = testCA.TLSConfig(toTLSCert(serverChain, serverPri))
+	server.StartTLS()
+
+	return testCA, server
+}
+
+func toTLSCert(chain []*x509.Certificate, pri *ecdsa.PrivateKey) tls.Certificate {
+	tlsCert := tls.Certificate{PrivateKey: pri, Leaf: chain[0]}
+	for _, chainCert := range chain {
+		tlsCert.Certificate = append(tlsCert.Certificate, chainCert.Raw)
+	}
+
+	return tlsCert
+}
+
+func newClient(testCA *ca.CA, server *httptest.Server, clientCerts ...tls.Certificate) *ca.Client {
+	rootCAs := x509.NewCertPool()
+	rootCAs.AddCert(testCA.Cert())
+
+	return &ca.Client{
+		BaseURL: server.URL,
+		HTTPClient: &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: rootCAs, Certificates: clientCerts},
+		}},
+	}
+}
+
+func TestClient_Enroll(t *testing.T) {
+	// given
+	testCA, server := setUpServer(t)
+	defer server.Close()
+	client := newClient(testCA, server)
+
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csrDER := createCSR(t, pri, "node1")
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+
+	// when
+	chain, err := client.Enroll(context.Background(), csrPEM, "node1", "secret1")
+	_, wrongSecretErr := client.Enroll(context.Background(), csrDER, "node1", "secret2")
+	_, wrongIdErr := client.Enroll(context.Background(), csrDER, "node2", "secret1")
+	_, invalidErr := client.Enroll(context.Background(), []byte("garbage"), "node1", "secret1")
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, chain, 2)
+	assert.Equal(t, &pri.PublicKey, chain[0].PublicKey)
+	assert.Equal(t, testCA.Cert().Raw, chain[1].Raw)
+	assert.Contains(t, wrongSecretErr.Error(), "403")
+	assert.Contains(t, wrongIdErr.Error(), "403")
+	assert.Contains(t, invalidErr.Error(), "400")
+}
+
+func TestClient_Reenroll(t *testing.T) {
+	// given
+	testCA, server := setUpServer(t)
+	defer server.Close()
+
+	pri, chain := enroll(t, testCA, "node1")
+	client := newClient(testCA, server, toTLSCert(chain, pri))
+	anonymous := newClient(testCA, server)
+
+	newPri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csrDER := createCSR(t, newPri, "node1")
+
+	// when
+	reenrolled, err := client.Reenroll(context.Background(), csrDER)
+	_, anonymousErr := anonymous.Reenroll(context.Background(), csrDER)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, &newPri.PublicKey, reenrolled[0].PublicKey)
+	assert.Contains(t, anonymousErr.Error(), "401")
+}
+
+func TestClient_Revoke(t *testing.T) {
+	// given
+	testCA, server := setUpServer(t)
+	defer server.Close()
+
+	pri, chain := enroll(t, testCA, "node1")
+	_, other := enroll(t, testCA, "node2")
+	client := newClient(testCA, server, toTLSCert(chain, pri))
+
+	// when
+	otherErr := client.Revoke(context.Background(), other[0].SerialNumber)
+	err := client.Revoke(context.Background(), chain[0].SerialNumber)
+	revokedErr := client.Revoke(context.Background(), chain[0].SerialNumber)
+
+	// then
+	assert.Contains(t, otherErr.Error(), "403")
+	assert.NoError(t, err)
+	assert.True(t, testCA.IsRevoked(chain[0].SerialNumber))
+	assert.False(t, testCA.IsRevoked(other[0].SerialNumber))
+	assert.Contains(t, revokedErr.Error(), "403")
+}
+
+func TestNewHandler(t *testing.T) {
+	// given
+	testCA, server := setUpServer(t)
+	defer server.Close()
+	client := newClient(testCA, server).HTTPClient
+
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	enrollReq := httptest.NewRequest(http.MethodPost, ca.EnrollPath, bytes.NewReader(createCSR(t, pri, "node1")))
+
+	// when
+	caResp, err := client.Get(server.URL + cert.CACertPath)
+	assert.NoError(t, err)
+	caResp.Body.Close()
+	methodResp, err := client.Get(server.URL + ca.EnrollPath)
+	assert.NoError(t, err)
+	methodResp.Body.Close()
+	noAuthorizer := httptest.NewRecorder()
+	ca.NewHandler(testCA, nil).ServeHTTP(noAuthorizer, enrollReq)
+
+	// then
+	assert.Equal(t, http.StatusOK, caResp.StatusCode)
+	assert.Equal(t, http.StatusMethodNotAllowed, methodResp.StatusCode)
+	assert.Equal(t, http.StatusForbidden, noAuthorizer.Code)
+}
@@ -0,0 +1,310 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides offline root CA key ceremony which splits root key into shares and records transcript.
+
+package ceremony
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrTemplateNil = errors.New("root certificate template should not be nil")
+var ErrInvalidSharePem = errors.New("invalid share - failed to decode share PEM block")
+var ErrTranscriptMismatch = errors.New("invalid transcript - transcript does not correspond to root certificate")
+var ErrKeyGenOptNil = errors.New("key generation option should not be nil")
+var ErrHashOptNil = errors.New("hash option should not be nil")
+var ErrShareKeyIDMismatch = errors.New("invalid shares - shares do not belong to the same root key")
+
+const SharePemType = "HEIMDALL ROOT KEY SHARE"
+
+// Options provides options of root key ceremony.
+type Options struct {
+	KeyGenOpt heimdall.KeyGenOpts
+	HashOpt   *hashing.HashOpt
+	// Template provides subject and validity of root certificate.
+	Template *x509.Certificate
+	// Threshold is the number of shares needed to recover root key.
+	Threshold int
+	// ShareOutputs are separate outputs (ex. files on different USB drives) each share is written to.
+	ShareOutputs []io.Writer
+}
+
+// Event is a step of key ceremony recorded in transcript.
+type Event struct {
+	Time        time.Time
+	Description string
+}
+
+// Transcript records key ceremony, and is signed by root key for auditing.
+type Transcript struct {
+	RootKeyID    heimdall.KeyID
+	RootCertHash string
+	HashOpt      string
+	Threshold    int
+	ShareDigests []string
+	Events       []*Event
+	Signature    []byte
+}
+
+// Result is a result of key ceremony.
+type Result struct {
+	RootCert   *x509.Certificate
+	Transcript *Transcript
+}
+
+// Run generates root key, writes shares of the key to outputs and makes self-signed root certificate.
+// Root key is cleared from memory after the ceremony, so it can only be recovered by combining shares.
+func Run(opts *Options) (*Result, error) {
+	if opts.Template == nil {
+		return nil, ErrTemplateNil
+	}
+
+	if opts.KeyGenOpt == nil {
+		return nil, ErrKeyGenOptNil
+	}
+
+	if opts.HashOpt == nil {
+		return nil, ErrHashOptNil
+	}
+
+	if opts.Threshold < 2 || opts.Threshold > len(opts.ShareOutputs) {
+		return nil, ErrInvalidThreshold
+	}
+
+	transcript := &Transcript{
+		HashOpt:   opts.HashOpt.Name,
+		Threshold: opts.Threshold,
+	}
+	transcript.record("ceremony started")
+
+	pri, err := hecdsa.GenerateKey(opts.KeyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer pri.Clear()
+
+	transcript.RootKeyID = pri.ID()
+	transcript.record("root key generated with " + opts.KeyGenOpt.ToString())
+
+	rootCert, err := createRootCert(pri, opts.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	certHash, err := hashing.Hash(rootCert.Raw, opts.HashOpt)
+	if err != nil {
+		return nil, err
+	}
+	transcript.RootCertHash = hex.EncodeToString(certHash)
+	transcript.record("self-signed root certificate created")
+
+	keyBytes, err := pri.ToByte()
+	if err != nil {
+		return nil, err
+	}
+	defer clearBytes(keyBytes)
+
+	shares, err := Split(keyBytes, len(opts.ShareOutputs), opts.Threshold)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, share := range shares {
+		shareBytes := EncodeShare(share, pri.ID())
+		if _, err := opts.ShareOutputs[i].Write(shareBytes); err != nil {
+			return nil, err
+		}
+
+		shareDigest, err := hashing.Hash(shareBytes, opts.HashOpt)
+		if err != nil {
+			return nil, err
+		}
+		transcript.ShareDigests = append(transcript.ShareDigests, hex.EncodeToString(shareDigest))
+		transcript.record("root key share " + strconv.Itoa(int(share.Index)) + " written")
+	}
+
+	transcript.record("ceremony finished")
+
+	if err = transcript.sign(pri, opts.HashOpt); err != nil {
+		return nil, err
+	}
+
+	return &Result{
+		RootCert:   rootCert,
+		Transcript: transcript,
+	}, nil
+}
+
+// createRootCert makes self-signed root certificate from template.
+func createRootCert(pri heimdall.PriKey, template *x509.Certificate) (*x509.Certificate, error) {
+	rootTemplate := *template
+	if rootTemplate.SerialNumber == nil {
+		serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+		if err != nil {
+			return nil, err
+		}
+		rootTemplate.SerialNumber = serialNumber
+	}
+
+	rootTemplate.IsCA = true
+	rootTemplate.BasicConstraintsValid = true
+	rootTemplate.KeyUsage |= x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	rootTemplate.SubjectKeyId = pri.SKI()
+
+	signer := pri.(*hecdsa.PriKey)
+	derBytes, err := x509.CreateCertificate(rand.Reader, &rootTemplate, &rootTemplate, signer.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return x509.ParseCertificate(derBytes)
+}
+
+func (transcript *Transcript) record(description string) {
+	transcript.Events = append(transcript.Events, &Event{
+		Time:        time.Now().UTC(),
+		Description: description,
+	})
+}
+
+// signedBytes returns bytes of transcript which are signed. (transcript without signature)
+func (transcript *Transcript) signedBytes() ([]byte, error) {
+	unsigned := *transcript
+	unsigned.Signature = nil
+
+	return json.Marshal(unsigned)
+}
+
+func (transcript *Transcript) sign(pri heimdall.PriKey, hashOpt *hashing.HashOpt) error {
+	message, err := transcript.signedBytes()
+	if err != nil {
+		return err
+	}
+
+	transcript.Signature, err = hecdsa.Sign(pri, message, hecdsa.NewSignerOpts(hashOpt))
+
+	return err
+}
+
+// VerifyTranscript verifies that transcript is signed by root key and corresponds to root certificate.
+func VerifyTranscript(transcript *Transcript, rootCert *x509.Certificate) (bool, error) {
+	hashOpt, err := hashing.NewHashOpt(transcript.HashOpt)
+	if err != nil {
+		return false, err
+	}
+
+	certHash, err := hashing.Hash(rootCert.Raw, hashOpt)
+	if err != nil {
+		return false, err
+	}
+
+	if hex.EncodeToString(certHash) != transcript.RootCertHash {
+		return false, ErrTranscriptMismatch
+	}
+
+	if err = heimdall.SKIValidCheck(transcript.RootKeyID, rootCert.SubjectKeyId); err != nil {
+		return false, ErrTranscriptMismatch
+	}
+
+	message, err := transcript.signedBytes()
+	if err != nil {
+		return false, err
+	}
+
+	return hecdsa.VerifyWithCert(rootCert, transcript.Signature, message, hecdsa.NewSignerOpts(hashOpt))
+}
+
+// EncodeShare encodes share to PEM format with key ID of root key.
+func EncodeShare(share *Share, keyId heimdall.KeyID) []byte {
+	return pem.EncodeToMemory(&pem.Block{
+		Type: SharePemType,
+		Headers: map[string]string{
+			"Key-ID": keyId,
+			"Index":  strconv.Itoa(int(share.Index)),
+		},
+		Bytes: share.Value,
+	})
+}
+
+// DecodeShare decodes PEM formatted share.
+func DecodeShare(shareBytes []byte) (*Share, heimdall.KeyID, error) {
+	block, _ := pem.Decode(shareBytes)
+	if block == nil || block.Type != SharePemType {
+		return nil, "", ErrInvalidSharePem
+	}
+
+	index, err := strconv.Atoi(block.Headers["Index"])
+	if err != nil || index < 1 || index > 255 {
+		return nil, "", ErrInvalidSharePem
+	}
+
+	return &Share{Index: byte(index), Value: block.Bytes}, block.Headers["Key-ID"], nil
+}
+
+// RecoverRootKey recovers root key by combining PEM formatted shares.
+// All shares should carry the same Key-ID, and the recovered key should have that ID.
+func RecoverRootKey(shareBytesList [][]byte) (heimdall.PriKey, error) {
+	shares := make([]*Share, 0, len(shareBytesList))
+	var rootKeyId heimdall.KeyID
+	for i, shareBytes := range shareBytesList {
+		share, keyId, err := DecodeShare(shareBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if keyId == "" || (i > 0 && keyId != rootKeyId) {
+			return nil, ErrShareKeyIDMismatch
+		}
+		rootKeyId = keyId
+
+		shares = append(shares, share)
+	}
+
+	keyBytes, err := Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+	defer clearBytes(keyBytes)
+
+	recoverer := &hecdsa.KeyRecoverer{}
+	key, err := recoverer.RecoverKeyFromByte(keyBytes, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pri := key.(heimdall.PriKey)
+	if !heimdall.MatchKeyID(rootKeyId, pri) {
+		pri.Clear()
+		return nil, ErrShareKeyIDMismatch
+	}
+
+	return pri, nil
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ceremony_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"io"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/ceremony"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpCeremony(t *testing.T) (*ceremony.Result, []*bytes.Buffer) {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	outputs := []*bytes.Buffer{new(bytes.Buffer), new(bytes.Buffer), new(bytes.Buffer)}
+
+	result, err := ceremony.Run(&ceremony.Options{
+		KeyGenOpt:    keyGenOpt,
+		HashOpt:      hashOpt,
+		Template:     &mocks.TestRootCertTemplate,
+		Threshold:    2,
+		ShareOutputs: []io.Writer{outputs[0], outputs[1], outputs[2]},
+	})
+	assert.NoError(t, err)
+
+	return result, outputs
+}
+
+func TestRun(t *testing.T) {
+	// when
+	result, outputs := setUpCeremony(t)
+
+	// then
+	assert.True(t, result.RootCert.IsCA)
+	assert.Equal(t, result.Transcript.RootKeyID, hecdsa.NewPubKey(result.RootCert.PublicKey.(*ecdsa.PublicKey)).ID())
+	assert.Len(t, result.Transcript.ShareDigests, 3)
+	assert.NotNil(t, result.Transcript.Signature)
+
+	for _, output := range outputs {
+		assert.NotEmpty(t, output.Bytes())
+	}
+}
+
+func TestVerifyTranscript(t *testing.T) {
+	// given
+	result, _ := setUpCeremony(t)
+	otherResult, _ := setUpCeremony(t)
+
+	// when
+	valid, err := ceremony.VerifyTranscript(result.Transcript, result.RootCert)
+	_, mismatchErr := ceremony.VerifyTranscript(result.Transcript, otherResult.RootCert)
+
+	result.Transcript.Threshold = 1
+	tampered, tamperedErr := ceremony.VerifyTranscript(result.Transcript, result.RootCert)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, ceremony.ErrTranscriptMismatch, mismatchErr)
+	assert.NoError(t, tamperedErr)
+	assert.False(t, tampered)
+}
+
+func TestRecoverRootKey(t *testing.T) {
+	// given
+	result, outputs := setUpCeremony(t)
+
+	// when
+	pri, err := ceremony.RecoverRootKey([][]byte{outputs[2].Bytes(), outputs[0].Bytes()})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, result.Transcript.RootKeyID, pri.ID())
+}
+
+func TestRecoverRootKey_KeyIDMismatch(t *testing.T) {
+	// given
+	_, outputs := setUpCeremony(t)
+	_, otherOutputs := setUpCeremony(t)
+
+	share, _, err := ceremony.DecodeShare(outputs[1].Bytes())
+	assert.NoError(t, err)
+	relabeled := ceremony.EncodeShare(share, "ITforgedKeyID")
+
+	// when
+	_, mixedErr := ceremony.RecoverRootKey([][]byte{outputs[0].Bytes(), otherOutputs[1].Bytes()})
+	_, relabeledErr := ceremony.RecoverRootKey([][]byte{outputs[0].Bytes(), relabeled})
+
+	forgedShare, _, err := ceremony.DecodeShare(outputs[0].Bytes())
+	assert.NoError(t, err)
+	_, forgedErr := ceremony.RecoverRootKey([][]byte{ceremony.EncodeShare(forgedShare, "ITforgedKeyID"), relabeled})
+
+	// then
+	assert.Equal(t, ceremony.ErrShareKeyIDMismatch, mixedErr)
+	assert.Equal(t, ceremony.ErrShareKeyIDMismatch, relabeledErr)
+	assert.Equal(t, ceremony.ErrShareKeyIDMismatch, forgedErr)
+}
+
+func TestRun_NilOptions(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	outputs := []io.Writer{new(bytes.Buffer), new(bytes.Buffer)}
+
+	// when
+	_, keyGenOptErr := ceremony.Run(&ceremony.Options{
+		HashOpt:      hashOpt,
+		Template:     &mocks.TestRootCertTemplate,
+		Threshold:    2,
+		ShareOutputs: outputs,
+	})
+	_, hashOptErr := ceremony.Run(&ceremony.Options{
+		KeyGenOpt:    keyGenOpt,
+		Template:     &mocks.TestRootCertTemplate,
+		Threshold:    2,
+		ShareOutputs: outputs,
+	})
+
+	// then
+	assert.Equal(t, ceremony.ErrKeyGenOptNil, keyGenOptErr)
+	assert.Equal(t, ceremony.ErrHashOptNil, hashOptErr)
+}
+
+func TestDecodeShare(t *testing.T) {
+	// given
+	share := &ceremony.Share{Index: 3, Value: []byte{1, 2, 3}}
+	shareBytes := ceremony.EncodeShare(share, "ITkeyID")
+
+	// when
+	decoded, keyId, err := ceremony.DecodeShare(shareBytes)
+	_, _, invalidErr := ceremony.DecodeShare([]byte("invalid share"))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, share, decoded)
+	assert.Equal(t, "ITkeyID", keyId)
+	assert.Equal(t, ceremony.ErrInvalidSharePem, invalidErr)
+}
@@ -0,0 +1,161 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Shamir's secret sharing over GF(2^8) for splitting root key.
+
+package ceremony
+
+import (
+	"crypto/rand"
+	"errors"
+)
+
+var ErrInvalidThreshold = errors.New("invalid threshold - threshold should be between 2 and number of shares")
+var ErrTooManyShares = errors.New("invalid number of shares - number of shares should not exceed 255")
+var ErrEmptySecret = errors.New("secret to split should not be empty")
+var ErrNotEnoughShares = errors.New("not enough shares to combine secret")
+var ErrInvalidShare = errors.New("invalid share - shares should have same length and distinct non-zero index")
+
+// Share is a piece of secret split by Shamir's secret sharing.
+type Share struct {
+	Index byte
+	Value []byte
+}
+
+// Split splits secret into n shares which can be combined by any threshold shares.
+func Split(secret []byte, n, threshold int) ([]*Share, error) {
+	if len(secret) == 0 {
+		return nil, ErrEmptySecret
+	}
+
+	if n > 255 {
+		return nil, ErrTooManyShares
+	}
+
+	if threshold < 2 || threshold > n {
+		return nil, ErrInvalidThreshold
+	}
+
+	shares := make([]*Share, n)
+	for i := range shares {
+		shares[i] = &Share{
+			Index: byte(i + 1),
+			Value: make([]byte, len(secret)),
+		}
+	}
+
+	// coefficients of polynomial whose constant term is a byte of secret
+	coefficients := make([]byte, threshold)
+	defer clearBytes(coefficients)
+
+	for pos, secretByte := range secret {
+		coefficients[0] = secretByte
+		if _, err := rand.Read(coefficients[1:]); err != nil {
+			return nil, err
+		}
+
+		for _, share := range shares {
+			share.Value[pos] = evaluatePolynomial(coefficients, share.Index)
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine recovers secret from shares by Lagrange interpolation.
+func Combine(shares []*Share) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, ErrNotEnoughShares
+	}
+
+	secretLen := len(shares[0].Value)
+	seen := make(map[byte]bool)
+	for _, share := range shares {
+		if share.Index == 0 || seen[share.Index] || len(share.Value) != secretLen {
+			return nil, ErrInvalidShare
+		}
+		seen[share.Index] = true
+	}
+
+	secret := make([]byte, secretLen)
+	for pos := range secret {
+		var value byte
+		for i, share := range shares {
+			// lagrange basis polynomial of share i evaluated at x = 0
+			basis := byte(1)
+			for j, other := range shares {
+				if i == j {
+					continue
+				}
+				basis = gfMul(basis, gfDiv(other.Index, other.Index^share.Index))
+			}
+			value ^= gfMul(share.Value[pos], basis)
+		}
+		secret[pos] = value
+	}
+
+	return secret, nil
+}
+
+// evaluatePolynomial evaluates polynomial at x by Horner's method.
+func evaluatePolynomial(coefficients []byte, x byte) byte {
+	result := byte(0)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = gfMul(result, x) ^ coefficients[i]
+	}
+
+	return result
+}
+
+// gfMul multiplies a and b in GF(2^8) with the AES reduction polynomial (x^8 + x^4 + x^3 + x + 1).
+func gfMul(a, b byte) byte {
+	var product byte
+	for b > 0 {
+		if b&1 == 1 {
+			product ^= a
+		}
+		carry := a & 0x80
+		a <<= 1
+		if carry != 0 {
+			a ^= 0x1b
+		}
+		b >>= 1
+	}
+
+	return product
+}
+
+// gfInverse returns multiplicative inverse of a in GF(2^8). (a^254 = a^-1)
+func gfInverse(a byte) byte {
+	result := byte(1)
+	for i := 0; i < 254; i++ {
+		result = gfMul(result, a)
+	}
+
+	return result
+}
+
+// gfDiv divides a by b in GF(2^8).
+func gfDiv(a, b byte) byte {
+	return gfMul(a, gfInverse(b))
+}
+
+func clearBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ceremony_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall/ceremony"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplit(t *testing.T) {
+	tests := map[string]struct {
+		secret    []byte
+		n         int
+		threshold int
+		err       error
+	}{
+		"valid": {
+			secret:    []byte("root key"),
+			n:         5,
+			threshold: 3,
+			err:       nil,
+		},
+		"empty secret": {
+			secret:    []byte{},
+			n:         5,
+			threshold: 3,
+			err:       ceremony.ErrEmptySecret,
+		},
+		"threshold bigger than shares": {
+			secret:    []byte("root key"),
+			n:         2,
+			threshold: 3,
+			err:       ceremony.ErrInvalidThreshold,
+		},
+		"threshold one": {
+			secret:    []byte("root key"),
+			n:         2,
+			threshold: 1,
+			err:       ceremony.ErrInvalidThreshold,
+		},
+		"too many shares": {
+			secret:    []byte("root key"),
+			n:         256,
+			threshold: 3,
+			err:       ceremony.ErrTooManyShares,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("running test case [%s]", testName)
+
+		// when
+		shares, err := ceremony.Split(test.secret, test.n, test.threshold)
+
+		// then
+		assert.Equal(t, test.err, err)
+		if err == nil {
+			assert.Len(t, shares, test.n)
+		}
+	}
+}
+
+func TestCombine(t *testing.T) {
+	// given
+	secret := []byte("this is root key of it-chain network")
+	shares, err := ceremony.Split(secret, 5, 3)
+	assert.NoError(t, err)
+
+	// when
+	combined, err := ceremony.Combine([]*ceremony.Share{shares[4], shares[0], shares[2]})
+	allCombined, err2 := ceremony.Combine(shares)
+	notEnough, err3 := ceremony.Combine([]*ceremony.Share{shares[0], shares[1]})
+	_, err4 := ceremony.Combine([]*ceremony.Share{shares[0], shares[0], shares[1]})
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, err2)
+	assert.NoError(t, err3)
+	assert.Equal(t, ceremony.ErrInvalidShare, err4)
+	assert.Equal(t, secret, combined)
+	assert.Equal(t, secret, allCombined)
+	assert.NotEqual(t, secret, notEnough)
+}
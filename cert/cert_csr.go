@@ -0,0 +1,149 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides parsing and validation of certificate signing requests, so that CA can vet them before issuance.
+
+package cert
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"strconv"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hrsa"
+)
+
+var ErrInvalidCSR = errors.New("invalid CSR - CSR should be PEM block of CERTIFICATE REQUEST or DER")
+var ErrCSRSignatureInvalid = errors.New("invalid CSR - signature of CSR is not valid")
+var ErrCSRKeyTooSmall = errors.New("invalid CSR - RSA key of CSR is smaller than minimum size of CSR policy")
+
+// DefaultMinRSABits is minimum size of RSA key of CSR, if CSR policy does not set it.
+const DefaultMinRSABits = 2048
+
+// CSRPolicy is policy which CSRs should satisfy to be issued.
+type CSRPolicy struct {
+	// AlgorithmPolicy restricts key types of CSRs. (ex. {"Keys":{"Allow":["P-256","P-384","ED25519"]}})
+	// Algorithm policy of the process is applied if it is nil.
+	AlgorithmPolicy *heimdall.AlgorithmPolicy
+
+	// MinRSABits is minimum size of RSA key. DefaultMinRSABits is applied if it is zero.
+	MinRSABits int
+
+	// PermittedDNSDomains and ExcludedDNSDomains are applied to DNS names of CSR like ChainPolicy.
+	PermittedDNSDomains []string
+	ExcludedDNSDomains  []string
+
+	// SubjectCheck is called with subject of CSR, if it is not nil.
+	SubjectCheck func(subject pkix.Name) error
+
+	// SANCheck is called with CSR to check its subject alternative names, such as IP addresses, emails and URIs,
+	// if it is not nil.
+	SANCheck func(csr *x509.CertificateRequest) error
+}
+
+// ParseCSR parses CSR(Certificate Signing Request) in PEM or DER. Signature of CSR is not checked. (see ValidateCSR)
+func ParseCSR(csrBytes []byte) (*x509.CertificateRequest, error) {
+	if block, _ := pem.Decode(csrBytes); block != nil {
+		if block.Type != "CERTIFICATE REQUEST" && block.Type != "NEW CERTIFICATE REQUEST" {
+			return nil, ErrInvalidCSR
+		}
+		csrBytes = block.Bytes
+	}
+
+	csr, err := x509.ParseCertificateRequest(csrBytes)
+	if err != nil {
+		return nil, ErrInvalidCSR
+	}
+
+	return csr, nil
+}
+
+// ValidateCSR checks signature of CSR, and checks its key, subject and subject alternative names by policy.
+// Nil policy checks key by algorithm policy of the process and DefaultMinRSABits only.
+func ValidateCSR(csr *x509.CertificateRequest, policy *CSRPolicy) error {
+	if policy == nil {
+		policy = &CSRPolicy{}
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return ErrCSRSignatureInvalid
+	}
+
+	if err := policy.checkKey(csr); err != nil {
+		return err
+	}
+
+	if policy.SubjectCheck != nil {
+		if err := policy.SubjectCheck(csr.Subject); err != nil {
+			return err
+		}
+	}
+
+	namePolicy := &ChainPolicy{
+		PermittedDNSDomains: policy.PermittedDNSDomains,
+		ExcludedDNSDomains:  policy.ExcludedDNSDomains,
+	}
+	for _, name := range csr.DNSNames {
+		if err := namePolicy.checkName(name); err != nil {
+			return err
+		}
+	}
+
+	if policy.SANCheck != nil {
+		return policy.SANCheck(csr)
+	}
+
+	return nil
+}
+
+// checkKey checks size of RSA key and key type of CSR.
+func (policy *CSRPolicy) checkKey(csr *x509.CertificateRequest) error {
+	var keyGenOpt heimdall.KeyGenOpts
+	if rsaPub, ok := csr.PublicKey.(*rsa.PublicKey); ok {
+		minBits := policy.MinRSABits
+		if minBits == 0 {
+			minBits = DefaultMinRSABits
+		}
+
+		if rsaPub.N.BitLen() < minBits {
+			return ErrCSRKeyTooSmall
+		}
+
+		// RSA key of size other than key generation options is not supported
+		rsaKeyGenOpt, err := hrsa.NewKeyGenOpt(heimdall.RSA + strconv.Itoa(rsaPub.N.BitLen()))
+		if err != nil {
+			return ErrPubKeyNotSupported
+		}
+		keyGenOpt = rsaKeyGenOpt
+	} else {
+		pub, err := ToPubKey(csr.PublicKey)
+		if err != nil {
+			return err
+		}
+		keyGenOpt = pub.KeyGenOpt()
+	}
+
+	if policy.AlgorithmPolicy != nil {
+		return policy.AlgorithmPolicy.CheckKey(keyGenOpt)
+	}
+
+	return heimdall.CheckKeyAlgorithm(keyGenOpt)
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cert_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/stretchr/testify/assert"
+)
+
+func createCSR(t *testing.T, pri crypto.Signer, commonName string, dnsNames ...string) []byte {
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: commonName},
+		DNSNames: dnsNames,
+	}, pri)
+	assert.NoError(t, err)
+
+	return csrDER
+}
+
+func TestParseCSR(t *testing.T) {
+	// given
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csrDER := createCSR(t, pri, "node1", "node1.example.com")
+	csrPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER})
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: csrDER})
+
+	// when
+	pemCSR, pemErr := cert.ParseCSR(csrPEM)
+	derCSR, derErr := cert.ParseCSR(csrDER)
+	_, typeErr := cert.ParseCSR(certPEM)
+	_, garbageErr := cert.ParseCSR([]byte("garbage"))
+
+	// then
+	assert.NoError(t, pemErr)
+	assert.NoError(t, derErr)
+	assert.Equal(t, "node1", pemCSR.Subject.CommonName)
+	assert.Equal(t, pemCSR.Raw, derCSR.Raw)
+	assert.Equal(t, cert.ErrInvalidCSR, typeErr)
+	assert.Equal(t, cert.ErrInvalidCSR, garbageErr)
+}
+
+func TestValidateCSR(t *testing.T) {
+	// given
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csr, err := cert.ParseCSR(createCSR(t, pri, "node1", "node1.example.com"))
+	assert.NoError(t, err)
+
+	tampered, err := cert.ParseCSR(createCSR(t, pri, "node1", "node1.example.com"))
+	assert.NoError(t, err)
+	tampered.Signature[len(tampered.Signature)-1] ^= 0x01
+
+	// when
+	err = cert.ValidateCSR(csr, nil)
+	tamperedErr := cert.ValidateCSR(tampered, nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, cert.ErrCSRSignatureInvalid, tamperedErr)
+}
+
+func TestValidateCSR_KeyPolicy(t *testing.T) {
+	// given
+	ecPri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	ecCSR, err := cert.ParseCSR(createCSR(t, ecPri, "node1"))
+	assert.NoError(t, err)
+
+	rsaPri, err := rsa.GenerateKey(rand.Reader, 1024)
+	assert.NoError(t, err)
+	rsaCSR, err := cert.ParseCSR(createCSR(t, rsaPri, "node1"))
+	assert.NoError(t, err)
+
+	policy := &cert.CSRPolicy{AlgorithmPolicy: &heimdall.AlgorithmPolicy{Keys: heimdall.AlgorithmList{Deny: []string{"P-256"}}}}
+
+	// when
+	ecErr := cert.ValidateCSR(ecCSR, policy)
+	rsaErr := cert.ValidateCSR(rsaCSR, nil)
+	rsaAllowedErr := cert.ValidateCSR(rsaCSR, &cert.CSRPolicy{MinRSABits: 1024})
+
+	// then
+	assert.Equal(t, heimdall.ErrKeyAlgorithmDenied, ecErr)
+	assert.Equal(t, cert.ErrCSRKeyTooSmall, rsaErr)
+	assert.NoError(t, rsaAllowedErr)
+}
+
+func TestValidateCSR_NamePolicy(t *testing.T) {
+	// given
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csr, err := cert.ParseCSR(createCSR(t, pri, "admin", "node1.example.com"))
+	assert.NoError(t, err)
+	errAdmin := errors.New("admin is reserved")
+
+	// when
+	excludedErr := cert.ValidateCSR(csr, &cert.CSRPolicy{ExcludedDNSDomains: []string{"example.com"}})
+	notPermittedErr := cert.ValidateCSR(csr, &cert.CSRPolicy{PermittedDNSDomains: []string{"example.org"}})
+	subjectErr := cert.ValidateCSR(csr, &cert.CSRPolicy{SubjectCheck: func(subject pkix.Name) error {
+		if subject.CommonName == "admin" {
+			return errAdmin
+		}
+		return nil
+	}})
+	sanErr := cert.ValidateCSR(csr, &cert.CSRPolicy{
+		PermittedDNSDomains: []string{"example.com"},
+		SANCheck: func(csr *x509.CertificateRequest) error {
+			assert.Equal(t, []string{"node1.example.com"}, csr.DNSNames)
+			return nil
+		},
+	})
+
+	// then
+	assert.Equal(t, cert.ErrNameExcluded, excludedErr)
+	assert.Equal(t, cert.ErrNameNotPermitted, notPermittedErr)
+	assert.Equal(t, errAdmin, subjectErr)
+	assert.NoError(t, sanErr)
+}
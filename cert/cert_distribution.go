@@ -0,0 +1,263 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides HTTP handler distributing CRL, CA certificate and issued certificates of local CA,
+// so that CRL distribution point (CDP) and authority information access (AIA) URLs of issued certificates work.
+
+package cert
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+)
+
+var ErrCANotSet = errors.New("CA certificate and signer should not be nil")
+
+// paths served by distribution handler
+const (
+	CACertPath = "/ca.crt"
+	CRLPath    = "/ca.crl"
+	CertsPath  = "/certs/"
+)
+
+// DefaultCRLValidity is the period until next update of CRL made by RevocationList.
+const DefaultCRLValidity = 24 * time.Hour
+
+// RevocationList keeps revoked certificates of local CA, and makes CRL signed by the CA.
+// Revoked certificates and CRL number are also kept in state file if the list is loaded by LoadRevocationList.
+type RevocationList struct {
+	caCert    *x509.Certificate
+	signer    crypto.Signer
+	validity  time.Duration
+	statePath string
+	mutex     sync.Mutex
+	number    int64
+	revoked   []pkix.RevokedCertificate
+}
+
+// revocationState is content of state file of revocation list.
+type revocationState struct {
+	Number  int64
+	Revoked []pkix.RevokedCertificate
+}
+
+// NewRevocationList makes revocation list of CA. CRL is valid for validity, or DefaultCRLValidity if validity is not positive.
+func NewRevocationList(caCert *x509.Certificate, signer crypto.Signer, validity time.Duration) (*RevocationList, error) {
+	if caCert == nil || signer == nil {
+		return nil, ErrCANotSet
+	}
+
+	if validity <= 0 {
+		validity = DefaultCRLValidity
+	}
+
+	return &RevocationList{
+		caCert:   caCert,
+		signer:   signer,
+		validity: validity,
+	}, nil
+}
+
+// LoadRevocationList makes revocation list of CA like NewRevocationList, restoring revoked certificates and CRL number
+// from statePath if it exists. The list keeps them in statePath from then on, so that revocations survive restart of
+// the CA and CRL number never goes back.
+func LoadRevocationList(caCert *x509.Certificate, signer crypto.Signer, validity time.Duration, statePath string) (*RevocationList, error) {
+	list, err := NewRevocationList(caCert, signer, validity)
+	if err != nil {
+		return nil, err
+	}
+	list.statePath = statePath
+
+	data, err := ioutil.ReadFile(statePath)
+	if os.IsNotExist(err) {
+		return list, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	state := &revocationState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+	list.number = state.Number
+	list.revoked = state.Revoked
+
+	return list, nil
+}
+
+// Revoke adds certificate of serial number to revocation list.
+func (list *RevocationList) Revoke(serialNumber *big.Int) error {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	if list.isRevoked(serialNumber) {
+		return nil
+	}
+
+	revoked := append(append([]pkix.RevokedCertificate(nil), list.revoked...), pkix.RevokedCertificate{
+		SerialNumber:   serialNumber,
+		RevocationTime: time.Now(),
+	})
+	if err := list.saveState(list.number, revoked); err != nil {
+		return err
+	}
+	list.revoked = revoked
+
+	return nil
+}
+
+// IsRevoked checks if certificate of serial number is in revocation list.
+func (list *RevocationList) IsRevoked(serialNumber *big.Int) bool {
+	list.mutex.Lock()
+	defer list.mutex.Unlock()
+
+	return list.isRevoked(serialNumber)
+}
+
+// isRevoked checks if certificate of serial number is in revocation list. list mutex should be locked by caller.
+func (list *RevocationList) isRevoked(serialNumber *big.Int) bool {
+	for _, revoked := range list.revoked {
+		if revoked.SerialNumber.Cmp(serialNumber) == 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CRL makes current CRL in DER signed by CA.
+func (list *RevocationList) CRL() ([]byte, error) {
+	list.mutex.Lock()
+	if err := list.saveState(list.number+1, list.revoked); err != nil {
+		list.mutex.Unlock()
+		return nil, err
+	}
+	list.number++
+	template := &x509.RevocationList{
+		Number:              big.NewInt(list.number),
+		ThisUpdate:          time.Now(),
+		NextUpdate:          time.Now().Add(list.validity),
+		RevokedCertificates: append([]pkix.RevokedCertificate(nil), list.revoked...),
+	}
+	list.mutex.Unlock()
+
+	return x509.CreateRevocationList(rand.Reader, template, list.caCert, list.signer)
+}
+
+// saveState writes CRL number and revoked certificates to state file, if the list has state file.
+// list mutex should be locked by caller.
+func (list *RevocationList) saveState(number int64, revoked []pkix.RevokedCertificate) error {
+	if list.statePath == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(&revocationState{Number: number, Revoked: revoked})
+	if err != nil {
+		return err
+	}
+
+	if err := fileperm.MkdirAll(filepath.Dir(list.statePath)); err != nil {
+		return err
+	}
+
+	return fileperm.WriteFileAtomic(list.statePath, list.statePath+".tmp", data)
+}
+
+// Distribution is a set of materials served by distribution handler. CRL and CertDirPath are optional.
+type Distribution struct {
+	CACert *x509.Certificate
+	// CRL returns current CRL in DER.
+	CRL func() ([]byte, error)
+	// CertDirPath is certificate store directory of issued certificates, served by key ID. (ex. /certs/<key ID>)
+	CertDirPath string
+}
+
+// NewDistributionHandler makes HTTP handler serving CA certificate at CACertPath, CRL at CRLPath
+// and issued certificates at CertsPath in DER. The handler can be mounted on any server of the node.
+func NewDistributionHandler(distribution *Distribution) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(CACertPath, func(w http.ResponseWriter, r *http.Request) {
+		if distribution.CACert == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeDER(w, "application/pkix-cert", distribution.CACert.Raw)
+	})
+
+	mux.HandleFunc(CRLPath, func(w http.ResponseWriter, r *http.Request) {
+		if distribution.CRL == nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		crlBytes, err := distribution.CRL()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		writeDER(w, "application/pkix-crl", crlBytes)
+	})
+
+	mux.HandleFunc(CertsPath, func(w http.ResponseWriter, r *http.Request) {
+		keyId := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, CertsPath), certFileExt)
+		if distribution.CertDirPath == "" || heimdall.ValidateKeyID(keyId) != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		cert, err := Load(keyId, distribution.CertDirPath)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+
+		writeDER(w, "application/pkix-cert", cert.Raw)
+	})
+
+	return mux
+}
+
+// SetDistributionPoints sets CRL distribution point and issuing certificate URL (AIA) of certificate template
+// to URLs of distribution handler served at baseURL. (ex. http://node1.example.com:8080)
+func SetDistributionPoints(template *x509.Certificate, baseURL string) {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	template.CRLDistributionPoints = []string{baseURL + CRLPath}
+	template.IssuingCertificateURL = []string{baseURL + CACertPath}
+}
+
+func writeDER(w http.ResponseWriter, contentType string, derBytes []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Write(derBytes)
+}
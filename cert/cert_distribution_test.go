@@ -0,0 +1,121 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cert_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpLocalCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	rootPri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := mocks.TestRootCertTemplate
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.CRLDistributionPoints = nil
+	template.KeyUsage |= x509.KeyUsageCRLSign
+	template.SubjectKeyId = hecdsa.NewPubKey(&rootPri.PublicKey).SKI()
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &rootPri.PublicKey, rootPri)
+	assert.NoError(t, err)
+	rootCert, err := x509.ParseCertificate(derBytes)
+	assert.NoError(t, err)
+
+	return rootCert, rootPri
+}
+
+func getDER(t *testing.T, url string) (int, []byte) {
+	resp, err := http.Get(url)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	return resp.StatusCode, body
+}
+
+func TestNewDistributionHandler(t *testing.T) {
+	// given
+	rootCert, rootPri := setUpLocalCA(t)
+	revocationList, err := cert.NewRevocationList(rootCert, rootPri, 0)
+	assert.NoError(t, err)
+
+	server := httptest.NewServer(cert.NewDistributionHandler(&cert.Distribution{
+		CACert:      rootCert,
+		CRL:         revocationList.CRL,
+		CertDirPath: heimdall.TestCertDir,
+	}))
+	defer server.Close()
+
+	leafPri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := mocks.TestCertTemplate
+	template.SerialNumber = big.NewInt(100)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	cert.SetDistributionPoints(&template, server.URL+"/")
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, rootCert, &leafPri.PublicKey, rootPri)
+	assert.NoError(t, err)
+	leafCert, err := x509.ParseCertificate(derBytes)
+	assert.NoError(t, err)
+
+	assert.NoError(t, cert.Store(leafCert, heimdall.TestCertDir))
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	caStatus, caBytes := getDER(t, leafCert.IssuingCertificateURL[0])
+	certStatus, certBytes := getDER(t, server.URL+cert.CertsPath+hecdsa.NewPubKey(&leafPri.PublicKey).ID())
+	unknownStatus, _ := getDER(t, server.URL+cert.CertsPath+"unknown")
+	validErr := cert.Verify(leafCert)
+
+	assert.NoError(t, revocationList.Revoke(leafCert.SerialNumber))
+	revokedErr := cert.Verify(leafCert)
+
+	// then
+	assert.Equal(t, http.StatusOK, caStatus)
+	assert.Equal(t, rootCert.Raw, caBytes)
+	assert.Equal(t, http.StatusOK, certStatus)
+	assert.Equal(t, leafCert.Raw, certBytes)
+	assert.Equal(t, http.StatusNotFound, unknownStatus)
+	assert.NoError(t, validErr)
+	assert.Equal(t, cert.ErrCertRevoked, revokedErr)
+}
+
+func TestNewRevocationList_NotSet(t *testing.T) {
+	// when
+	_, err := cert.NewRevocationList(nil, nil, 0)
+
+	// then
+	assert.Equal(t, cert.ErrCANotSet, err)
+}
@@ -37,6 +37,34 @@ func PemToX509Cert(certPEMBlock []byte) (cert *x509.Certificate, err error) {
 	return cert, nil
 }
 
+// PemToX509Certs converts PEM bundle of multiple certificates to x.509 certificates in the order of the bundle.
+func PemToX509Certs(bundle []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, bundle = pem.Decode(bundle)
+		if block == nil {
+			break
+		}
+
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := DERToX509Cert(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errors.New("failed to decode PEM block ")
+	}
+
+	return certs, nil
+}
+
 // X509CertToPem converts x.509 certificate format to PEM format.
 func X509CertToPem(cert *x509.Certificate) []byte {
 	return DERCertToPem(cert.Raw)
@@ -51,6 +79,16 @@ func DERCertToPem(derBytes []byte) []byte {
 	return pemBytes
 }
 
+// X509CertsToPem converts x.509 certificates to PEM bundle.
+func X509CertsToPem(certs []*x509.Certificate) []byte {
+	var bundle []byte
+	for _, cert := range certs {
+		bundle = append(bundle, X509CertToPem(cert)...)
+	}
+
+	return bundle
+}
+
 // X509CertToDER converts x.509 certificate to DER format.
 func X509CertToDER(cert *x509.Certificate) []byte {
 	return cert.Raw
@@ -0,0 +1,120 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides OCSP revocation checking of certificate verifier, which asks OCSP responder of the certificate
+// in addition to CRLs of trust store.
+
+package cert
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"golang.org/x/crypto/ocsp"
+)
+
+var ErrNoIssuerInTrustStore = errors.New("no issuer in trust store - issuer of certificate is needed to check OCSP response")
+
+// OCSP failure modes, which decide if certificate is valid when its revocation status can not be obtained from
+// OCSP responder. (ex. responder is unreachable or its response is invalid)
+const (
+	// OCSPSoftFail accepts certificate whose revocation status is not obtained.
+	OCSPSoftFail = "soft-fail"
+	// OCSPHardFail rejects certificate whose revocation status is not obtained.
+	OCSPHardFail = "hard-fail"
+)
+
+// NewVerifierWithOCSP makes verifier which also checks revocation of certificates by their OCSP responders
+// (authority information access extension) in failure mode, after CRLs of trust store. Issuers of certificates
+// should be in trust store to validate signed OCSP responses. Certificates without OCSP server are checked by CRLs only.
+// Failure mode other than OCSPSoftFail is regarded as OCSPHardFail. Policy may be nil.
+func NewVerifierWithOCSP(trustStore heimdall.TrustStore, skew time.Duration, policy *ChainPolicy, mode string) heimdall.CertVerifier {
+	if mode != OCSPSoftFail {
+		mode = OCSPHardFail
+	}
+
+	return &Verifier{
+		trustStore: trustStore,
+		skew:       skew,
+		policy:     policy,
+		ocspMode:   mode,
+		ocspClient: &http.Client{Timeout: DefaultOCSPTimeout},
+	}
+}
+
+// checkOCSP checks revocation of certificate by OCSP response signed by its issuer or responder delegated by the issuer.
+// Revoked certificate is rejected in any failure mode.
+func (verifier *Verifier) checkOCSP(cert *x509.Certificate) error {
+	if len(cert.OCSPServer) == 0 {
+		return nil
+	}
+
+	err := verifier.requestOCSPStatus(cert)
+	if err == ErrCertRevoked {
+		publishRevoked(cert)
+		return err
+	}
+
+	if err != nil && verifier.ocspMode == OCSPSoftFail {
+		return nil
+	}
+
+	return err
+}
+
+// requestOCSPStatus requests OCSP response of certificate, and checks the response within clock skew.
+func (verifier *Verifier) requestOCSPStatus(cert *x509.Certificate) error {
+	issuer, err := verifier.findIssuer(cert)
+	if err != nil {
+		return err
+	}
+
+	responseBytes, err := requestOCSP(verifier.ocspClient, cert, issuer)
+	if err != nil {
+		return err
+	}
+
+	response, err := ocsp.ParseResponseForCert(responseBytes, cert, issuer)
+	if err != nil {
+		return err
+	}
+
+	return checkOCSPResponse(response, verifier.skew)
+}
+
+// findIssuer finds issuer of certificate among roots and intermediates of trust store.
+func (verifier *Verifier) findIssuer(cert *x509.Certificate) (*x509.Certificate, error) {
+	roots, err := verifier.trustStore.Roots()
+	if err != nil {
+		return nil, err
+	}
+
+	intermediates, err := verifier.trustStore.Intermediates()
+	if err != nil {
+		return nil, err
+	}
+
+	issuers := findIssuers(cert, append(roots, intermediates...))
+	if len(issuers) == 0 {
+		return nil, ErrNoIssuerInTrustStore
+	}
+
+	return issuers[0], nil
+}
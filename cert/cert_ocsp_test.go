@@ -0,0 +1,121 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cert_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpOCSPCert(t *testing.T, testCA *mocks.CA) *x509.Certificate {
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := mocks.TestCertTemplate
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	leaf, err := testCA.Issue(&pri.PublicKey, &template)
+	assert.NoError(t, err)
+
+	return leaf
+}
+
+func TestNewVerifierWithOCSP(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	leaf := setUpOCSPCert(t, testCA)
+	trustStore := cert.NewMemTrustStore()
+	trustStore.AddRoot(testCA.RootCert)
+
+	softVerifier := cert.NewVerifierWithOCSP(trustStore, 0, nil, cert.OCSPSoftFail)
+	hardVerifier := cert.NewVerifierWithOCSP(trustStore, 0, nil, cert.OCSPHardFail)
+
+	// when
+	softErr := softVerifier.Verify(leaf)
+	hardErr := hardVerifier.Verify(leaf)
+
+	// then
+	assert.NoError(t, softErr)
+	assert.NoError(t, hardErr)
+
+	// when revoked
+	testCA.Revoke(leaf.SerialNumber)
+	softErr = softVerifier.Verify(leaf)
+	hardErr = hardVerifier.Verify(leaf)
+
+	// then
+	assert.Equal(t, cert.ErrCertRevoked, softErr)
+	assert.Equal(t, cert.ErrCertRevoked, hardErr)
+}
+
+func TestNewVerifierWithOCSP_ResponderFailure(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	leaf := setUpOCSPCert(t, testCA)
+	trustStore := cert.NewMemTrustStore()
+	trustStore.AddRoot(testCA.RootCert)
+	testCA.SetFault(http.StatusServiceUnavailable)
+
+	// when
+	softErr := cert.NewVerifierWithOCSP(trustStore, 0, nil, cert.OCSPSoftFail).Verify(leaf)
+	hardErr := cert.NewVerifierWithOCSP(trustStore, 0, nil, cert.OCSPHardFail).Verify(leaf)
+	defaultErr := cert.NewVerifierWithOCSP(trustStore, 0, nil, "").Verify(leaf)
+
+	// then
+	assert.NoError(t, softErr)
+	assert.Error(t, hardErr)
+	assert.Error(t, defaultErr)
+}
+
+func TestNewVerifierWithOCSP_NoIssuer(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	otherCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer otherCA.Close()
+
+	leaf := setUpOCSPCert(t, testCA)
+	trustStore := cert.NewMemTrustStore()
+	trustStore.AddRoot(otherCA.RootCert)
+
+	// when
+	softErr := cert.NewVerifierWithOCSP(trustStore, 0, nil, cert.OCSPSoftFail).Verify(leaf)
+	hardErr := cert.NewVerifierWithOCSP(trustStore, 0, nil, cert.OCSPHardFail).Verify(leaf)
+
+	// then
+	assert.NoError(t, softErr)
+	assert.Equal(t, cert.ErrNoIssuerInTrustStore, hardErr)
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides PKI policy enforced on verified certificate chains beyond x509 defaults.
+
+package cert
+
+import (
+	"crypto/x509"
+	"errors"
+	"strings"
+)
+
+var ErrNameNotPermitted = errors.New("name constraint violation - name of certificate is not in permitted domains")
+var ErrNameExcluded = errors.New("name constraint violation - name of certificate is in excluded domains")
+var ErrExtKeyUsageMissing = errors.New("invalid certificate - required extended key usage is missing")
+
+// ChainPolicy is PKI policy which verified certificate chains should satisfy.
+type ChainPolicy struct {
+	// DNSName is checked against names of leaf certificate and name constraints of CAs, if it is not empty.
+	DNSName string
+
+	// PermittedDNSDomains and ExcludedDNSDomains are name constraints of the organization applied to DNS names
+	// of leaf certificate, on top of name constraints in CA certificates. (ex. "example.com" covers "node.example.com")
+	PermittedDNSDomains []string
+	ExcludedDNSDomains  []string
+
+	// RequiredExtKeyUsages should all be in leaf certificate and allowed by every CA in the chain.
+	// Server authentication is required if it is empty, as x509 does by default.
+	RequiredExtKeyUsages []x509.ExtKeyUsage
+
+	// ElementCheck is called for each certificate of verified chain, from leaf (index 0) to root.
+	ElementCheck func(cert *x509.Certificate, index int, chain []*x509.Certificate) error
+}
+
+// check returns nil if any of verified chains satisfies policy, otherwise the violation of the first chain.
+func (policy *ChainPolicy) check(chains [][]*x509.Certificate) error {
+	var firstErr error
+	for _, chain := range chains {
+		err := policy.checkChain(chain)
+		if err == nil {
+			return nil
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+func (policy *ChainPolicy) checkChain(chain []*x509.Certificate) error {
+	leaf := chain[0]
+
+	for _, name := range leaf.DNSNames {
+		if err := policy.checkName(name); err != nil {
+			return err
+		}
+	}
+
+	for _, usage := range policy.RequiredExtKeyUsages {
+		if !hasExtKeyUsage(leaf, usage) {
+			return ErrExtKeyUsageMissing
+		}
+	}
+
+	if policy.ElementCheck != nil {
+		for i, cert := range chain {
+			if err := policy.ElementCheck(cert, i, chain); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (policy *ChainPolicy) checkName(name string) error {
+	for _, domain := range policy.ExcludedDNSDomains {
+		if matchDomain(name, domain) {
+			return ErrNameExcluded
+		}
+	}
+
+	if len(policy.PermittedDNSDomains) == 0 {
+		return nil
+	}
+
+	for _, domain := range policy.PermittedDNSDomains {
+		if matchDomain(name, domain) {
+			return nil
+		}
+	}
+
+	return ErrNameNotPermitted
+}
+
+// matchDomain checks if name is domain or its subdomain.
+func matchDomain(name, domain string) bool {
+	name = strings.ToLower(strings.TrimSuffix(name, "."))
+	domain = strings.ToLower(strings.TrimPrefix(strings.TrimSuffix(domain, "."), "."))
+
+	return name == domain || strings.HasSuffix(name, "."+domain)
+}
+
+func hasExtKeyUsage(cert *x509.Certificate, usage x509.ExtKeyUsage) bool {
+	for _, certUsage := range cert.ExtKeyUsage {
+		if certUsage == usage || certUsage == x509.ExtKeyUsageAny {
+			return true
+		}
+	}
+
+	return false
+}
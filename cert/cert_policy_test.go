@@ -0,0 +1,119 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cert_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpPolicyCA(t *testing.T) (*mocks.CA, *x509.Certificate) {
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	assert.NoError(t, cert.Store(testCA.RootCert, heimdall.TestCertDir))
+
+	pri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	template := mocks.TestCertTemplate
+	template.DNSNames = []string{"node1.example.com"}
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	leafCert, err := testCA.Issue(&pri.PublicKey, &template)
+	assert.NoError(t, err)
+
+	return testCA, leafCert
+}
+
+func TestVerifier_VerifyChain_Policy(t *testing.T) {
+	// given
+	testCA, leafCert := setUpPolicyCA(t)
+	defer testCA.Close()
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	visited := 0
+	policy := &cert.ChainPolicy{
+		DNSName:              "node1.example.com",
+		PermittedDNSDomains:  []string{"example.com"},
+		ExcludedDNSDomains:   []string{"internal.example.com"},
+		RequiredExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		ElementCheck: func(cert *x509.Certificate, index int, chain []*x509.Certificate) error {
+			visited++
+			return nil
+		},
+	}
+
+	// when
+	err := cert.NewVerifierWithPolicy(heimdall.TestCertDir, 0, policy).VerifyChain(leafCert)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, visited)
+}
+
+func TestVerifier_VerifyChain_PolicyViolation(t *testing.T) {
+	// given
+	testCA, leafCert := setUpPolicyCA(t)
+	defer testCA.Close()
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	errRejected := errors.New("rejected by organization policy")
+
+	tests := map[string]struct {
+		policy *cert.ChainPolicy
+		err    error
+	}{
+		"not permitted": {
+			policy: &cert.ChainPolicy{PermittedDNSDomains: []string{"example.org"}},
+			err:    cert.ErrNameNotPermitted,
+		},
+		"excluded": {
+			policy: &cert.ChainPolicy{ExcludedDNSDomains: []string{"example.com"}},
+			err:    cert.ErrNameExcluded,
+		},
+		"missing usage": {
+			policy: &cert.ChainPolicy{RequiredExtKeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}},
+			err:    cert.ErrExtKeyUsageMissing,
+		},
+		"element check": {
+			policy: &cert.ChainPolicy{ElementCheck: func(cert *x509.Certificate, index int, chain []*x509.Certificate) error {
+				if cert.IsCA {
+					return errRejected
+				}
+				return nil
+			}},
+			err: errRejected,
+		},
+	}
+
+	for name, test := range tests {
+		// when
+		err := cert.NewVerifierWithPolicy(heimdall.TestCertDir, 0, test.policy).VerifyChain(leafCert)
+
+		// then
+		assert.Equal(t, test.err, err, name)
+	}
+}
@@ -0,0 +1,248 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides tls.Config builders which staple OCSP responses of the local certificate
+// and verify OCSP responses stapled by peers, so that handshakes do not wait for CRL downloads.
+
+package cert
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+var ErrNoOCSPServer = errors.New("no OCSP server - certificate has no OCSP server URL")
+var ErrNoStapledOCSP = errors.New("no stapled OCSP response - peer did not staple OCSP response")
+var ErrOCSPStatusUnknown = errors.New("invalid OCSP response - responder does not know the certificate")
+var ErrOCSPNotYetValid = errors.New("invalid OCSP response - OCSP response's this update time is future time")
+var ErrOCSPExpired = errors.New("invalid OCSP response - OCSP response's next update time is past, OCSP response is stale")
+var ErrNoVerifiedChain = errors.New("no verified chain - peer certificate should be verified before OCSP response")
+
+// DefaultOCSPTimeout is the timeout of requests to OCSP responders.
+var DefaultOCSPTimeout = 5 * time.Second
+
+// OCSPStapler fetches OCSP response of local certificate from its OCSP server and caches it.
+// The response is refreshed when half of its validity has passed, and the stale response is stapled if refresh fails.
+type OCSPStapler struct {
+	cert       tls.Certificate
+	leaf       *x509.Certificate
+	issuer     *x509.Certificate
+	client     *http.Client
+	mutex      sync.Mutex
+	response   []byte
+	refreshAt  time.Time
+	nextUpdate time.Time
+}
+
+// NewOCSPStapler makes stapler of local certificate issued by issuer.
+func NewOCSPStapler(cert tls.Certificate, issuer *x509.Certificate) (*OCSPStapler, error) {
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(leaf.OCSPServer) == 0 {
+		return nil, ErrNoOCSPServer
+	}
+
+	return &OCSPStapler{
+		cert:   cert,
+		leaf:   leaf,
+		issuer: issuer,
+		client: &http.Client{Timeout: DefaultOCSPTimeout},
+	}, nil
+}
+
+// GetCertificate returns local certificate with cached OCSP response, to be used as tls.Config GetCertificate.
+// Certificate is returned without OCSP response if no valid response can be fetched, leaving revocation check to peer.
+func (stapler *OCSPStapler) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	response, err := stapler.Response()
+
+	cert := stapler.cert
+	if err == nil {
+		cert.OCSPStaple = response
+	}
+
+	return &cert, nil
+}
+
+// Response returns cached OCSP response in DER, refreshing it if needed.
+func (stapler *OCSPStapler) Response() ([]byte, error) {
+	stapler.mutex.Lock()
+	defer stapler.mutex.Unlock()
+
+	now := time.Now()
+	if stapler.response != nil && now.Before(stapler.refreshAt) {
+		return stapler.response, nil
+	}
+
+	err := stapler.refresh()
+	if err != nil && (stapler.response == nil || !now.Before(stapler.nextUpdate)) {
+		return nil, err
+	}
+
+	return stapler.response, nil
+}
+
+// Refresh fetches OCSP response regardless of cache.
+func (stapler *OCSPStapler) Refresh() error {
+	stapler.mutex.Lock()
+	defer stapler.mutex.Unlock()
+
+	return stapler.refresh()
+}
+
+func (stapler *OCSPStapler) refresh() error {
+	responseBytes, err := requestOCSP(stapler.client, stapler.leaf, stapler.issuer)
+	if err != nil {
+		return err
+	}
+
+	response, err := ocsp.ParseResponseForCert(responseBytes, stapler.leaf, stapler.issuer)
+	if err != nil {
+		return err
+	}
+
+	if err := checkOCSPResponse(response, 0); err != nil {
+		return err
+	}
+
+	stapler.response = responseBytes
+	stapler.nextUpdate = response.NextUpdate
+	stapler.refreshAt = response.ThisUpdate.Add(response.NextUpdate.Sub(response.ThisUpdate) / 2)
+
+	return nil
+}
+
+// requestOCSP posts OCSP request of certificate to its OCSP servers, and returns the first successful response.
+func requestOCSP(client *http.Client, cert, issuer *x509.Certificate) ([]byte, error) {
+	request, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ErrNoOCSPServer
+	for _, url := range cert.OCSPServer {
+		var resp *http.Response
+		resp, err = client.Post(url, "application/ocsp-request", bytes.NewReader(request))
+		if err != nil {
+			continue
+		}
+
+		body, readErr := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			err = errors.New("failed to retrieve OCSP response - http status code :[" + strconv.Itoa(resp.StatusCode) + "]")
+			continue
+		}
+		if readErr != nil {
+			err = readErr
+			continue
+		}
+
+		return body, nil
+	}
+
+	return nil, err
+}
+
+// checkOCSPResponse checks status and validity period of OCSP response with clock skew.
+// NextUpdate of zero means newer information is always available, so the response is regarded as fresh only at this update.
+func checkOCSPResponse(response *ocsp.Response, skew time.Duration) error {
+	switch response.Status {
+	case ocsp.Revoked:
+		return ErrCertRevoked
+	case ocsp.Unknown:
+		return ErrOCSPStatusUnknown
+	}
+
+	now := time.Now()
+	if now.Add(skew).Before(response.ThisUpdate) {
+		return ErrOCSPNotYetValid
+	}
+
+	if !response.NextUpdate.IsZero() && now.Add(-skew).After(response.NextUpdate) {
+		return ErrOCSPExpired
+	}
+
+	return nil
+}
+
+// VerifyStapledOCSP verifies OCSP response stapled by peer in verified connection state.
+// If peer did not staple response, ErrNoStapledOCSP is returned only when staple is required.
+func VerifyStapledOCSP(state tls.ConnectionState, requireStaple bool, skew time.Duration) error {
+	if len(state.OCSPResponse) == 0 {
+		if requireStaple {
+			return ErrNoStapledOCSP
+		}
+		return nil
+	}
+
+	if len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) < 2 {
+		return ErrNoVerifiedChain
+	}
+
+	chain := state.VerifiedChains[0]
+	response, err := ocsp.ParseResponseForCert(state.OCSPResponse, chain[0], chain[1])
+	if err != nil {
+		return err
+	}
+
+	return checkOCSPResponse(response, skew)
+}
+
+// NewServerTLSConfig makes server tls.Config which staples OCSP response of cert issued by issuer.
+// Client certificates are required and verified with clientCAs if clientCAs is not nil.
+func NewServerTLSConfig(cert tls.Certificate, issuer *x509.Certificate, clientCAs *x509.CertPool) (*tls.Config, error) {
+	stapler, err := NewOCSPStapler(cert, issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: stapler.GetCertificate,
+	}
+
+	if clientCAs != nil {
+		config.ClientCAs = clientCAs
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return config, nil
+}
+
+// NewClientTLSConfig makes client tls.Config which verifies server certificate with rootCAs, and then OCSP response
+// stapled by server within clock skew. If requireStaple is true, handshake with server which does not staple OCSP response fails.
+func NewClientTLSConfig(rootCAs *x509.CertPool, requireStaple bool, skew time.Duration) *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		RootCAs:    rootCAs,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			return VerifyStapledOCSP(state, requireStaple, skew)
+		},
+	}
+}
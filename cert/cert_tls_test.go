@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cert_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpTLSCert(t *testing.T, testCA *mocks.CA) tls.Certificate {
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := mocks.TestCertTemplate
+	template.DNSNames = []string{"localhost"}
+	leaf, err := testCA.Issue(&pri.PublicKey, &template)
+	assert.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{leaf.Raw}, PrivateKey: pri, Leaf: leaf}
+}
+
+// handshake connects client and server configs over in-memory connection, and returns errors of both sides.
+func handshake(serverConfig, clientConfig *tls.Config) (error, error) {
+	serverConn, clientConn := net.Pipe()
+	defer serverConn.Close()
+	defer clientConn.Close()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		server := tls.Server(serverConn, serverConfig)
+		serverErr <- server.Handshake()
+		serverConn.Close()
+	}()
+
+	client := tls.Client(clientConn, clientConfig)
+	clientErr := client.Handshake()
+	clientConn.Close()
+
+	return <-serverErr, clientErr
+}
+
+func TestNewServerTLSConfig(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	tlsCert := setUpTLSCert(t, testCA)
+	roots := x509.NewCertPool()
+	roots.AddCert(testCA.RootCert)
+
+	serverConfig, err := cert.NewServerTLSConfig(tlsCert, testCA.RootCert, nil)
+	assert.NoError(t, err)
+	clientConfig := cert.NewClientTLSConfig(roots, true, 0)
+	clientConfig.ServerName = "localhost"
+
+	// when
+	serverErr, clientErr := handshake(serverConfig, clientConfig)
+
+	// then
+	assert.NoError(t, serverErr)
+	assert.NoError(t, clientErr)
+
+	// when revoked
+	testCA.Revoke(tlsCert.Leaf.SerialNumber)
+	revokedConfig, err := cert.NewServerTLSConfig(tlsCert, testCA.RootCert, nil)
+	assert.NoError(t, err)
+	stapler, err := cert.NewOCSPStapler(tlsCert, testCA.RootCert)
+	assert.NoError(t, err)
+	_, clientErr = handshake(revokedConfig, clientConfig)
+
+	// then
+	assert.Error(t, clientErr)
+	assert.Equal(t, cert.ErrCertRevoked, stapler.Refresh())
+}
+
+func TestOCSPStapler_Response(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	tlsCert := setUpTLSCert(t, testCA)
+	stapler, err := cert.NewOCSPStapler(tlsCert, testCA.RootCert)
+	assert.NoError(t, err)
+
+	// when
+	response, err := stapler.Response()
+	testCA.SetFault(500)
+	cachedResponse, cachedErr := stapler.Response()
+	refreshErr := stapler.Refresh()
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, cachedErr)
+	assert.Equal(t, response, cachedResponse)
+	assert.Error(t, refreshErr)
+}
+
+func TestNewClientTLSConfig_NoStaple(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	tlsCert := setUpTLSCert(t, testCA)
+	roots := x509.NewCertPool()
+	roots.AddCert(testCA.RootCert)
+	serverConfig := &tls.Config{Certificates: []tls.Certificate{tlsCert}}
+
+	requiringConfig := cert.NewClientTLSConfig(roots, true, 0)
+	requiringConfig.ServerName = "localhost"
+	optionalConfig := cert.NewClientTLSConfig(roots, false, 0)
+	optionalConfig.ServerName = "localhost"
+
+	// when
+	_, requiringErr := handshake(serverConfig, requiringConfig)
+	_, optionalErr := handshake(serverConfig, optionalConfig)
+
+	// then
+	assert.Equal(t, cert.ErrNoStapledOCSP, requiringErr)
+	assert.NoError(t, optionalErr)
+}
@@ -18,6 +18,8 @@ package cert
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"errors"
@@ -26,21 +28,44 @@ import (
 	"path/filepath"
 	"strconv"
 	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hecdsa"
 )
 
 var ErrCertGenTimeIsFuture = errors.New("invalid certificate - certificate's generated time is not past time")
 var ErrCertExpired = errors.New("invalid certificate - certificate is expired")
 var ErrCertRevoked = errors.New("invalid certificate - revoked certificate")
 var ErrNoRootCertInPath = errors.New("no root certificate in certificate directory path")
+var ErrCRLNotYetValid = errors.New("invalid CRL - CRL's this update time is future time")
+var ErrCRLExpired = errors.New("invalid CRL - CRL's next update time is past, CRL is stale")
+var ErrNotPinned = errors.New("invalid certificate - certificate chain does not contain pinned public key")
 
 // VerifyCertChain verifies a certificate from local certificates in certificate store directory.
 func VerifyChain(cert *x509.Certificate, certDirPath string) error {
-	roots, err := makeRootsPool(certDirPath)
+	return VerifyChainWithClockSkew(cert, certDirPath, 0)
+}
+
+// VerifyChainWithClockSkew verifies certificate chain, tolerating validity periods off by at most skew,
+// so that marginally desynchronized nodes do not reject freshly issued certificates.
+func VerifyChainWithClockSkew(cert *x509.Certificate, certDirPath string, skew time.Duration) error {
+	return verifyChain(cert, NewDirTrustStore(certDirPath), skew, nil)
+}
+
+// verifyChain verifies certificate chain with trust anchors of trust store within clock skew,
+// and checks policy on the verified chains if it is not nil.
+func verifyChain(cert *x509.Certificate, trustStore heimdall.TrustStore, skew time.Duration, policy *ChainPolicy) error {
+	roots, err := makePool(trustStore.Roots)
 	if err != nil {
 		return err
 	}
 
-	intermediates, err := makeIntermediatesPool(certDirPath)
+	if len(roots.Subjects()) == 0 {
+		return ErrNoRootCertInPath
+	}
+
+	intermediates, err := makePool(trustStore.Intermediates)
 	if err != nil {
 		return err
 	}
@@ -49,86 +74,151 @@ func VerifyChain(cert *x509.Certificate, certDirPath string) error {
 		Roots:         roots,
 		Intermediates: intermediates,
 	}
+	if policy != nil {
+		opts.DNSName = policy.DNSName
+		opts.KeyUsages = policy.RequiredExtKeyUsages
+	}
+
+	chains, err := cert.Verify(opts)
+	if invalidErr, ok := err.(x509.CertificateInvalidError); ok && invalidErr.Reason == x509.Expired && skew > 0 {
+		// x509 reports both expired and not yet valid certificates as expired, so try both ends of skew window
+		now := time.Now()
+		for _, currentTime := range []time.Time{now.Add(-skew), now.Add(skew)} {
+			opts.CurrentTime = currentTime
+			if skewChains, skewErr := cert.Verify(opts); skewErr == nil {
+				chains, err = skewChains, nil
+				break
+			}
+		}
+	}
 
-	_, err = cert.Verify(opts)
 	if err != nil {
 		return err
 	}
 
+	pins, err := trustStore.Pins()
+	if err != nil {
+		return err
+	}
+
+	if err := checkPins(chains, pins); err != nil {
+		return err
+	}
+
+	if policy != nil {
+		return policy.check(chains)
+	}
+
 	return nil
 }
 
-// makeRootsPool makes certificate pool of root certificates in certificate store directory.
-func makeRootsPool(certDirPath string) (rootsPool *x509.CertPool, err error) {
-	rootsPool = x509.NewCertPool()
+// makePool makes certificate pool of certificates from trust store.
+func makePool(certs func() ([]*x509.Certificate, error)) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
 
-	files, err := ioutil.ReadDir(certDirPath)
+	poolCerts, err := certs()
 	if err != nil {
 		return nil, err
 	}
 
-	for _, file := range files {
-		certFilePath := filepath.Join(certDirPath, file.Name())
-		certPEMBlock, err := ioutil.ReadFile(certFilePath)
-		if err != nil {
-			return nil, err
-		}
+	for _, cert := range poolCerts {
+		pool.AddCert(cert)
+	}
 
-		cert, err := PemToX509Cert(certPEMBlock)
-		if cert.IsCA == true && bytes.Compare(cert.RawIssuer, cert.RawSubject) == 0 {
-			rootsPool.AddCert(cert)
-		}
+	return pool, nil
+}
+
+// checkPins checks if any verified chain contains a pinned public key. Every chain passes if nothing is pinned.
+func checkPins(chains [][]*x509.Certificate, pins [][]byte) error {
+	if len(pins) == 0 {
+		return nil
 	}
 
-	if len(rootsPool.Subjects()) == 0 {
-		return nil, ErrNoRootCertInPath
+	for _, chain := range chains {
+		for _, cert := range chain {
+			pin := PinOf(cert)
+			for _, pinned := range pins {
+				if bytes.Equal(pin, pinned) {
+					return nil
+				}
+			}
+		}
 	}
 
-	return rootsPool, nil
+	return ErrNotPinned
 }
 
-// makeIntermediatesPool makes certificate pool of intermediate certificates in certificate store directory.
-func makeIntermediatesPool(certDirPath string) (intermediatesPool *x509.CertPool, err error) {
-	intermediatesPool = x509.NewCertPool()
-
+// readCertsInDir reads every certificate in certificate store directory, including all certificates of chain bundles.
+// Files which are not PEM certificates are skipped.
+func readCertsInDir(certDirPath string) ([]*x509.Certificate, error) {
 	files, err := ioutil.ReadDir(certDirPath)
 	if err != nil {
 		return nil, err
 	}
 
+	var certs []*x509.Certificate
 	for _, file := range files {
-		certFilePath := filepath.Join(certDirPath, file.Name())
-		certPEMBlock, err := ioutil.ReadFile(certFilePath)
+		if file.IsDir() {
+			continue
+		}
+
+		certPEMBlock, err := ioutil.ReadFile(filepath.Join(certDirPath, file.Name()))
 		if err != nil {
 			return nil, err
 		}
 
-		cert, err := PemToX509Cert(certPEMBlock)
-		if cert.IsCA == true && bytes.Compare(cert.RawIssuer, cert.RawSubject) != 0 {
-			intermediatesPool.AddCert(cert)
+		fileCerts, err := PemToX509Certs(certPEMBlock)
+		if err != nil {
+			continue
 		}
+		certs = append(certs, fileCerts...)
 	}
 
-	return intermediatesPool, nil
+	return certs, nil
 }
 
 // VerifyCert verifies a certificate's validity.
 func Verify(cert *x509.Certificate) error {
+	return VerifyWithClockSkew(cert, 0)
+}
+
+// VerifyWithClockSkew verifies a certificate's validity, tolerating validity periods of the certificate
+// and CRLs off by at most skew.
+func VerifyWithClockSkew(cert *x509.Certificate, skew time.Duration) error {
+	return VerifyWithContext(context.Background(), cert, skew)
+}
+
+// VerifyWithContext verifies a certificate's validity within clock skew like VerifyWithClockSkew,
+// giving up requesting CRLs when context is done.
+func VerifyWithContext(ctx context.Context, cert *x509.Certificate, skew time.Duration) error {
+	return verify(cert, skew, func(cert *x509.Certificate) ([]*pkix.CertificateList, error) {
+		return requestCRLs(ctx, cert)
+	})
+}
+
+// verify verifies a certificate's validity within clock skew, and checks revocation by CRLs of the certificate.
+func verify(cert *x509.Certificate, skew time.Duration, crls func(cert *x509.Certificate) ([]*pkix.CertificateList, error)) error {
 	// check if expired or invalid generation time
-	err := checkTime(cert.NotBefore, cert.NotAfter)
+	err := checkTime(cert.NotBefore, cert.NotAfter, skew)
+	if err != nil {
+		return err
+	}
+
+	certCRLs, err := crls(cert)
 	if err != nil {
 		return err
 	}
 
 	// check if revoked
-	for _, url := range cert.CRLDistributionPoints {
-		crl, err := requestCRL(url)
+	for _, crl := range certCRLs {
+		err = checkCRLTime(crl, skew)
 		if err != nil {
 			return err
 		}
 
 		err = checkRevocation(cert, crl)
 		if err != nil {
+			publishRevoked(cert)
 			return err
 		}
 	}
@@ -136,22 +226,66 @@ func Verify(cert *x509.Certificate) error {
 	return nil
 }
 
-// checkTime checks if entered certificate's generated/expired time is valid.
-func checkTime(notBefore time.Time, notAfter time.Time) error {
-	if time.Now().Before(notBefore) {
+// publishRevoked publishes CertRevoked event of certificate found revoked.
+func publishRevoked(cert *x509.Certificate) {
+	if pub, ok := cert.PublicKey.(*ecdsa.PublicKey); ok {
+		event.Publish(event.CertRevoked, hecdsa.NewPubKey(pub).ID(), cert.SerialNumber.String())
+	}
+}
+
+// checkTime checks if entered certificate's generated/expired time is valid within clock skew.
+func checkTime(notBefore time.Time, notAfter time.Time, skew time.Duration) error {
+	now := time.Now()
+
+	if now.Add(skew).Before(notBefore) {
 		return ErrCertGenTimeIsFuture
 	}
 
-	if time.Now().After(notAfter) {
+	if now.Add(-skew).After(notAfter) {
 		return ErrCertExpired
 	}
 
 	return nil
 }
 
+// checkCRLTime checks if CRL is issued and not stale within clock skew. CRL without next update time never goes stale.
+func checkCRLTime(crl *pkix.CertificateList, skew time.Duration) error {
+	now := time.Now()
+
+	if now.Add(skew).Before(crl.TBSCertList.ThisUpdate) {
+		return ErrCRLNotYetValid
+	}
+
+	nextUpdate := crl.TBSCertList.NextUpdate
+	if !nextUpdate.IsZero() && now.Add(-skew).After(nextUpdate) {
+		return ErrCRLExpired
+	}
+
+	return nil
+}
+
+// requestCRLs requests CRLs from every CRL distribution point of the certificate.
+func requestCRLs(ctx context.Context, cert *x509.Certificate) ([]*pkix.CertificateList, error) {
+	crls := make([]*pkix.CertificateList, 0, len(cert.CRLDistributionPoints))
+	for _, url := range cert.CRLDistributionPoints {
+		crl, err := requestCRL(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		crls = append(crls, crl)
+	}
+
+	return crls, nil
+}
+
 // requestCRL requests CRL(Certificate Revocation List) from CRLDistributionURL.
-func requestCRL(url string) (*pkix.CertificateList, error) {
-	resp, err := http.Get(url)
+func requestCRL(ctx context.Context, url string) (*pkix.CertificateList, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, err
 	} else if resp.StatusCode >= 300 {
@@ -178,3 +312,55 @@ func checkRevocation(cert *x509.Certificate, crl *pkix.CertificateList) error {
 
 	return nil
 }
+
+// Verifier is an implementation of heimdall CertVerifier with trust anchors and revocation lists of trust store.
+type Verifier struct {
+	trustStore heimdall.TrustStore
+	skew       time.Duration
+	policy     *ChainPolicy
+	ocspMode   string
+	ocspClient *http.Client
+}
+
+func NewVerifier(certDirPath string) heimdall.CertVerifier {
+	return &Verifier{trustStore: NewDirTrustStore(certDirPath)}
+}
+
+// NewVerifierWithClockSkew makes verifier tolerating validity periods of certificates and CRLs off by at most skew.
+func NewVerifierWithClockSkew(certDirPath string, skew time.Duration) heimdall.CertVerifier {
+	return &Verifier{
+		trustStore: NewDirTrustStore(certDirPath),
+		skew:       skew,
+	}
+}
+
+// NewVerifierWithPolicy makes verifier enforcing policy on certificate chains, in addition to clock skew tolerance.
+func NewVerifierWithPolicy(certDirPath string, skew time.Duration, policy *ChainPolicy) heimdall.CertVerifier {
+	return NewVerifierWithTrustStore(NewDirTrustStore(certDirPath), skew, policy)
+}
+
+// NewVerifierWithTrustStore makes verifier with trust anchors and revocation lists of trust store,
+// such as MemTrustStore filled from channel configuration. Policy may be nil.
+func NewVerifierWithTrustStore(trustStore heimdall.TrustStore, skew time.Duration, policy *ChainPolicy) heimdall.CertVerifier {
+	return &Verifier{
+		trustStore: trustStore,
+		skew:       skew,
+		policy:     policy,
+	}
+}
+
+func (verifier *Verifier) VerifyChain(cert *x509.Certificate) error {
+	return verifyChain(cert, verifier.trustStore, verifier.skew, verifier.policy)
+}
+
+func (verifier *Verifier) Verify(cert *x509.Certificate) error {
+	if err := verify(cert, verifier.skew, verifier.trustStore.CRLs); err != nil {
+		return err
+	}
+
+	if verifier.ocspMode == "" {
+		return nil
+	}
+
+	return verifier.checkOCSP(cert)
+}
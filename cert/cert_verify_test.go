@@ -17,11 +17,13 @@
 package cert_test
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
 	"io"
 	"math/big"
 	"net/http"
@@ -136,3 +138,147 @@ func TestVerify(t *testing.T) {
 	assert.Error(t, revokedErr)
 	assert.NoError(t, clientErr)
 }
+
+func TestVerifier_VerifyChain(t *testing.T) {
+	// given
+	rootPri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+
+	mocks.TestRootCertTemplate.SubjectKeyId = hecdsa.NewPriKey(rootPri).SKI()
+	derBytes, err := x509.CreateCertificate(rand.Reader, &mocks.TestRootCertTemplate, &mocks.TestRootCertTemplate, &rootPri.PublicKey, rootPri)
+	assert.NoError(t, err)
+	rootCert, _ := cert.DERToX509Cert(derBytes)
+
+	err = cert.Store(rootCert, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	verifier := cert.NewVerifier(heimdall.TestCertDir)
+
+	// when
+	err = verifier.VerifyChain(rootCert)
+
+	// then
+	assert.NoError(t, err)
+}
+
+func TestVerify_MockCA(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	// normal client cert
+	clientPri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	clientCert, err := testCA.Issue(&clientPri.PublicKey, &mocks.TestCertTemplate)
+	assert.NoError(t, err)
+
+	// revoked cert
+	revokedPri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	revokedCert, err := testCA.Issue(&revokedPri.PublicKey, &mocks.TestCertTemplate)
+	assert.NoError(t, err)
+	testCA.Revoke(revokedCert.SerialNumber)
+
+	// when
+	clientErr := cert.Verify(clientCert)
+	revokedErr := cert.Verify(revokedCert)
+
+	// then
+	assert.NoError(t, clientErr)
+	assert.Equal(t, cert.ErrCertRevoked, revokedErr)
+}
+
+func TestVerifyWithContext(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	pri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	clientCert, err := testCA.Issue(&pri.PublicKey, &mocks.TestCertTemplate)
+	assert.NoError(t, err)
+
+	cancelledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	err = cert.VerifyWithContext(context.Background(), clientCert, 0)
+	cancelledErr := cert.VerifyWithContext(cancelledCtx, clientCert, 0)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, errors.Is(cancelledErr, context.Canceled))
+}
+
+func TestVerifyWithClockSkew(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	pri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	template := mocks.TestCertTemplate
+	template.NotBefore = time.Now().Add(time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	futureCert, err := testCA.Issue(&pri.PublicKey, &template)
+	assert.NoError(t, err)
+
+	// when
+	err = cert.Verify(futureCert)
+	skewErr := cert.VerifyWithClockSkew(futureCert, 5*time.Minute)
+
+	// then
+	assert.Equal(t, cert.ErrCertGenTimeIsFuture, err)
+	assert.NoError(t, skewErr)
+}
+
+func TestVerifyWithClockSkew_StaleCRL(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+	testCA.SetNextUpdate(-time.Minute)
+
+	pri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	clientCert, err := testCA.Issue(&pri.PublicKey, &mocks.TestCertTemplate)
+	assert.NoError(t, err)
+
+	// when
+	err = cert.Verify(clientCert)
+	skewErr := cert.NewVerifierWithClockSkew(heimdall.TestCertDir, 5*time.Minute).Verify(clientCert)
+
+	// then
+	assert.Equal(t, cert.ErrCRLExpired, err)
+	assert.NoError(t, skewErr)
+}
+
+func TestVerifyChainWithClockSkew(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	assert.NoError(t, cert.Store(testCA.RootCert, heimdall.TestCertDir))
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	pri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	template := mocks.TestCertTemplate
+	template.NotBefore = time.Now().Add(time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	futureCert, err := testCA.Issue(&pri.PublicKey, &template)
+	assert.NoError(t, err)
+
+	// when
+	err = cert.VerifyChain(futureCert, heimdall.TestCertDir)
+	skewErr := cert.VerifyChainWithClockSkew(futureCert, heimdall.TestCertDir, 5*time.Minute)
+
+	// then
+	assert.Error(t, err)
+	assert.NoError(t, skewErr)
+}
@@ -17,7 +17,10 @@
 package cert
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"crypto/x509"
 	"errors"
 	"io/ioutil"
@@ -26,20 +29,33 @@ import (
 	"strings"
 
 	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
 	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hrsa"
+)
+
+var ErrEmptyChain = errors.New("empty certificate chain")
+var ErrChainOrder = errors.New("invalid chain order - each certificate should be issued by the next certificate")
+var ErrPubKeyNotSupported = errors.New("public key not supported - public key should be ECDSA, Ed25519 or RSA key")
+
+// file extensions of certificate and certificate chain bundle
+const (
+	certFileExt  = ".crt"
+	chainFileExt = ".chain.pem"
 )
 
 // StoreCert stores a certificate to certificate store directory.
 func Store(cert *x509.Certificate, certDirPath string) error {
 	certPEMBlock := X509CertToPem(cert)
 
-	certFilePath, err := makeCertFilePath(certDirPath, cert)
+	certFilePath, err := makeCertFilePath(certDirPath, cert, certFileExt)
 	if err != nil {
 		return err
 	}
 
 	if _, err := os.Stat(certFilePath); os.IsNotExist(err) {
-		err = ioutil.WriteFile(certFilePath, certPEMBlock, 0700)
+		err = fileperm.WriteFile(certFilePath, certPEMBlock)
 		if err != nil {
 			return err
 		}
@@ -48,26 +64,109 @@ func Store(cert *x509.Certificate, certDirPath string) error {
 	return nil
 }
 
-// makeCertFilePath makes certificate file path for a certificate by its key ID.
-func makeCertFilePath(certDirPath string, cert *x509.Certificate) (certFilePath string, err error) {
-	if _, err := os.Stat(certDirPath); os.IsNotExist(err) {
-		err = os.MkdirAll(certDirPath, 0755)
+// ValidateChainOrder checks if chain is ordered from leaf to root, so each certificate is issued by the next one.
+func ValidateChainOrder(chain []*x509.Certificate) error {
+	if len(chain) == 0 {
+		return ErrEmptyChain
+	}
+
+	for i := 0; i < len(chain)-1; i++ {
+		if err := chain[i].CheckSignatureFrom(chain[i+1]); err != nil {
+			return ErrChainOrder
+		}
+	}
+
+	return nil
+}
+
+// StoreChain stores certificate chain (leaf first, followed by intermediates and optionally root) as a PEM bundle
+// named by key ID of the leaf certificate.
+func StoreChain(chain []*x509.Certificate, certDirPath string) error {
+	if err := ValidateChainOrder(chain); err != nil {
+		return err
+	}
+
+	chainFilePath, err := makeCertFilePath(certDirPath, chain[0], chainFileExt)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(chainFilePath); os.IsNotExist(err) {
+		err = fileperm.WriteFile(chainFilePath, X509CertsToPem(chain))
 		if err != nil {
-			return "", err
+			return err
 		}
 	}
 
-	var pub heimdall.Key
+	return nil
+}
+
+// ImportChain stores PEM bundle produced by external CA, after checking its order.
+func ImportChain(bundle []byte, certDirPath string) ([]*x509.Certificate, error) {
+	chain, err := PemToX509Certs(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := StoreChain(chain, certDirPath); err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
+
+// LoadChain loads certificate chain of leaf certificate whose key ID is keyId.
+func LoadChain(keyId heimdall.KeyID, certDirPath string) ([]*x509.Certificate, error) {
+	fileperm.WarnInsecure(certDirPath)
+
+	bundle, err := readCertFile(filepath.Join(certDirPath, keyId+chainFileExt))
+	if err != nil {
+		return nil, err
+	}
+
+	chain, err := PemToX509Certs(bundle)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ValidateChainOrder(chain); err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
 
-	switch cert.PublicKey.(type) {
+// ToPubKey converts public key of certificate or CSR to heimdall public key of ECDSA, Ed25519 or RSA.
+func ToPubKey(pub crypto.PublicKey) (heimdall.PubKey, error) {
+	switch pub := pub.(type) {
 	case *ecdsa.PublicKey:
-		pub = hecdsa.NewPubKey(cert.PublicKey.(*ecdsa.PublicKey))
+		return hecdsa.NewPubKey(pub), nil
+	case ed25519.PublicKey:
+		return hed25519.NewPubKey(pub), nil
+	case *rsa.PublicKey:
+		return hrsa.NewPubKey(pub), nil
 	default:
-		return "", errors.New("public key in certificate not supported")
+		return nil, ErrPubKeyNotSupported
+	}
+}
+
+// makeCertFilePath makes certificate file path for a certificate by its key ID.
+func makeCertFilePath(certDirPath string, cert *x509.Certificate, ext string) (certFilePath string, err error) {
+	if _, err := os.Stat(certDirPath); os.IsNotExist(err) {
+		err = fileperm.MkdirAll(certDirPath)
+		if err != nil {
+			return "", err
+		}
+	}
+	fileperm.WarnInsecure(certDirPath)
+
+	pub, err := ToPubKey(cert.PublicKey)
+	if err != nil {
+		return "", err
 	}
 
 	keyId := pub.ID()
-	certFilePath = filepath.Join(certDirPath, keyId+".crt")
+	certFilePath = filepath.Join(certDirPath, keyId+ext)
 
 	return certFilePath, nil
 }
@@ -94,19 +193,33 @@ func Load(keyId heimdall.KeyID, certDirPath string) (cert *x509.Certificate, err
 
 // findCertFileByKeyId finds a certificate file path by entered key ID.
 func findCertFileByKeyId(certDirPath, keyId string) (certFilePath string, err error) {
+	fileperm.WarnInsecure(certDirPath)
+
 	files, err := ioutil.ReadDir(certDirPath)
 	if err != nil {
 		return "", errors.New("invalid cert directory path - failed to read directory path")
 	}
 
-	for _, file := range files {
-		if strings.Contains(file.Name(), keyId) {
-			certFilePath = filepath.Join(certDirPath, file.Name())
-			break
+	// prefer certificate file to chain bundle of the key
+	if _, err := os.Stat(filepath.Join(certDirPath, keyId+certFileExt)); err == nil {
+		return filepath.Join(certDirPath, keyId+certFileExt), nil
+	}
+
+	// files stored before algorithm prefixed key IDs are named by legacy key ID
+	names := []string{keyId}
+	if info, err := heimdall.ParseKeyID(keyId); err == nil && !info.IsLegacy() {
+		names = append(names, heimdall.SKIToKeyID(info.SKI))
+	}
+
+	for _, name := range names {
+		for _, file := range files {
+			if strings.Contains(file.Name(), name) {
+				return filepath.Join(certDirPath, file.Name()), nil
+			}
 		}
 	}
 
-	return certFilePath, nil
+	return "", nil
 }
 
 // readCertFile reads certificate file and returns pem bytes of the certificate.
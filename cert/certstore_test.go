@@ -28,6 +28,8 @@ import (
 	"github.com/DE-labtory/heimdall"
 	"github.com/DE-labtory/heimdall/cert"
 	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hrsa"
 	"github.com/DE-labtory/heimdall/mocks"
 	"github.com/stretchr/testify/assert"
 )
@@ -79,3 +81,142 @@ func TestLoad(t *testing.T) {
 
 	defer os.RemoveAll(heimdall.TestCertDir)
 }
+
+func TestLoad_Ed25519(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	signer := pri.(*hed25519.PriKey)
+
+	template := mocks.TestRootCertTemplate
+	template.SubjectKeyId = pri.SKI()
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, signer.Public(), signer)
+	assert.NoError(t, err)
+	rootCert, err := cert.DERToX509Cert(derBytes)
+	assert.NoError(t, err)
+
+	err = cert.Store(rootCert, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	testCert, err := cert.Load(pri.ID(), heimdall.TestCertDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, rootCert, testCert)
+}
+
+func TestLoad_RSA(t *testing.T) {
+	// given
+	keyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA1024)
+	assert.NoError(t, err)
+	pri, err := hrsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	signer := pri.(*hrsa.PriKey)
+
+	template := mocks.TestRootCertTemplate
+	template.SubjectKeyId = pri.SKI()
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, signer.Public(), signer)
+	assert.NoError(t, err)
+	rootCert, err := cert.DERToX509Cert(derBytes)
+	assert.NoError(t, err)
+
+	err = cert.Store(rootCert, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	testCert, err := cert.Load(pri.ID(), heimdall.TestCertDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, rootCert, testCert)
+}
+
+func makeChain(t *testing.T) (rootCert, interCert, leafCert *x509.Certificate) {
+	rootPri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	rootTemplate := mocks.TestRootCertTemplate
+	rootTemplate.SubjectKeyId = hecdsa.NewPriKey(rootPri).SKI()
+	derBytes, err := x509.CreateCertificate(rand.Reader, &rootTemplate, &rootTemplate, &rootPri.PublicKey, rootPri)
+	assert.NoError(t, err)
+	rootCert, err = cert.DERToX509Cert(derBytes)
+	assert.NoError(t, err)
+
+	interPri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	interTemplate := mocks.TestIntermediateCertTemplate
+	interTemplate.SubjectKeyId = hecdsa.NewPriKey(interPri).SKI()
+	derBytes, err = x509.CreateCertificate(rand.Reader, &interTemplate, rootCert, &interPri.PublicKey, rootPri)
+	assert.NoError(t, err)
+	interCert, err = cert.DERToX509Cert(derBytes)
+	assert.NoError(t, err)
+
+	leafPri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	leafTemplate := mocks.TestCertTemplate
+	leafTemplate.SubjectKeyId = hecdsa.NewPriKey(leafPri).SKI()
+	derBytes, err = x509.CreateCertificate(rand.Reader, &leafTemplate, interCert, &leafPri.PublicKey, interPri)
+	assert.NoError(t, err)
+	leafCert, err = cert.DERToX509Cert(derBytes)
+	assert.NoError(t, err)
+
+	return rootCert, interCert, leafCert
+}
+
+func TestStoreChain(t *testing.T) {
+	// given
+	rootCert, interCert, leafCert := makeChain(t)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	err := cert.StoreChain([]*x509.Certificate{leafCert, interCert, rootCert}, heimdall.TestCertDir)
+
+	// then
+	assert.NoError(t, err)
+
+	keyId := hecdsa.NewPubKey(leafCert.PublicKey.(*ecdsa.PublicKey)).ID()
+	chain, err := cert.LoadChain(keyId, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []*x509.Certificate{leafCert, interCert, rootCert}, chain)
+
+	loaded, err := cert.Load(keyId, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	assert.Equal(t, leafCert, loaded)
+}
+
+func TestStoreChain_WrongOrder(t *testing.T) {
+	// given
+	rootCert, interCert, leafCert := makeChain(t)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	wrongOrderErr := cert.StoreChain([]*x509.Certificate{interCert, leafCert, rootCert}, heimdall.TestCertDir)
+	emptyErr := cert.StoreChain(nil, heimdall.TestCertDir)
+
+	// then
+	assert.Equal(t, cert.ErrChainOrder, wrongOrderErr)
+	assert.Equal(t, cert.ErrEmptyChain, emptyErr)
+}
+
+func TestImportChain(t *testing.T) {
+	// given
+	rootCert, interCert, leafCert := makeChain(t)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	assert.NoError(t, cert.Store(rootCert, heimdall.TestCertDir))
+	bundle := cert.X509CertsToPem([]*x509.Certificate{leafCert, interCert})
+
+	// when
+	chain, err := cert.ImportChain(bundle, heimdall.TestCertDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []*x509.Certificate{leafCert, interCert}, chain)
+
+	// intermediate in the bundle is used for verifying chain
+	assert.NoError(t, cert.VerifyChain(leafCert, heimdall.TestCertDir))
+}
@@ -0,0 +1,200 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides trust store implementations on certificate directory and in memory.
+
+package cert
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"sync"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+)
+
+// PinOf returns pin of certificate, which is SHA-256 hash of its SubjectPublicKeyInfo.
+func PinOf(cert *x509.Certificate) []byte {
+	hash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hash[:]
+}
+
+// DirTrustStore is an implementation of heimdall TrustStore with certificates in certificate store directory.
+// Self-signed CA certificates are roots and the other CA certificates are intermediates.
+// CRLs are requested from CRL distribution points of certificate.
+type DirTrustStore struct {
+	certDirPath string
+}
+
+func NewDirTrustStore(certDirPath string) heimdall.TrustStore {
+	return &DirTrustStore{certDirPath: certDirPath}
+}
+
+func (store *DirTrustStore) Roots() ([]*x509.Certificate, error) {
+	fileperm.WarnInsecure(store.certDirPath)
+
+	certs, err := readCertsInDir(store.certDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var roots []*x509.Certificate
+	for _, cert := range certs {
+		if isRoot(cert) {
+			roots = append(roots, cert)
+		}
+	}
+
+	return roots, nil
+}
+
+func (store *DirTrustStore) Intermediates() ([]*x509.Certificate, error) {
+	certs, err := readCertsInDir(store.certDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var intermediates []*x509.Certificate
+	for _, cert := range certs {
+		if cert.IsCA && !isRoot(cert) {
+			intermediates = append(intermediates, cert)
+		}
+	}
+
+	return intermediates, nil
+}
+
+func (store *DirTrustStore) Pins() ([][]byte, error) {
+	return nil, nil
+}
+
+func (store *DirTrustStore) CRLs(cert *x509.Certificate) ([]*pkix.CertificateList, error) {
+	return requestCRLs(context.Background(), cert)
+}
+
+// MemTrustStore is an implementation of heimdall TrustStore in memory, for trust anchors from configuration.
+// CRLs are not requested from network, only CRLs added to the store are used.
+type MemTrustStore struct {
+	mutex         sync.RWMutex
+	roots         []*x509.Certificate
+	intermediates []*x509.Certificate
+	pins          [][]byte
+	crls          []*pkix.CertificateList
+}
+
+func NewMemTrustStore() *MemTrustStore {
+	return &MemTrustStore{}
+}
+
+func (store *MemTrustStore) AddRoot(cert *x509.Certificate) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.roots = append(store.roots, cert)
+}
+
+func (store *MemTrustStore) AddIntermediate(cert *x509.Certificate) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.intermediates = append(store.intermediates, cert)
+}
+
+// AddPin pins SHA-256 hash of SubjectPublicKeyInfo. (see PinOf)
+func (store *MemTrustStore) AddPin(pin []byte) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.pins = append(store.pins, pin)
+}
+
+// AddCRL parses DER or PEM encoded CRL and adds it to the store.
+func (store *MemTrustStore) AddCRL(crlBytes []byte) error {
+	crl, err := x509.ParseCRL(crlBytes)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.crls = append(store.crls, crl)
+
+	return nil
+}
+
+func (store *MemTrustStore) Roots() ([]*x509.Certificate, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return append([]*x509.Certificate(nil), store.roots...), nil
+}
+
+func (store *MemTrustStore) Intermediates() ([]*x509.Certificate, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return append([]*x509.Certificate(nil), store.intermediates...), nil
+}
+
+func (store *MemTrustStore) Pins() ([][]byte, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return append([][]byte(nil), store.pins...), nil
+}
+
+// CRLs returns CRLs signed by issuer of the certificate among roots and intermediates in the store.
+func (store *MemTrustStore) CRLs(cert *x509.Certificate) ([]*pkix.CertificateList, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	issuers := findIssuers(cert, append(append([]*x509.Certificate(nil), store.roots...), store.intermediates...))
+
+	var crls []*pkix.CertificateList
+	for _, crl := range store.crls {
+		for _, issuer := range issuers {
+			if issuer.CheckCRLSignature(crl) == nil {
+				crls = append(crls, crl)
+				break
+			}
+		}
+	}
+
+	return crls, nil
+}
+
+// findIssuers returns candidates which issued the certificate.
+func findIssuers(cert *x509.Certificate, candidates []*x509.Certificate) []*x509.Certificate {
+	var issuers []*x509.Certificate
+	for _, candidate := range candidates {
+		if bytes.Equal(candidate.RawSubject, cert.RawIssuer) && cert.CheckSignatureFrom(candidate) == nil {
+			issuers = append(issuers, candidate)
+		}
+	}
+
+	return issuers
+}
+
+// isRoot checks if certificate is self-signed CA certificate.
+func isRoot(cert *x509.Certificate) bool {
+	return cert.IsCA && bytes.Equal(cert.RawIssuer, cert.RawSubject)
+}
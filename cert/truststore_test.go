@@ -0,0 +1,165 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package cert_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpTrustStoreCA(t *testing.T) (*mocks.CA, *ecdsa.PrivateKey, *x509.Certificate) {
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+
+	pri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	leafCert, err := testCA.Issue(&pri.PublicKey, &mocks.TestCertTemplate)
+	assert.NoError(t, err)
+
+	return testCA, pri, leafCert
+}
+
+func TestMemTrustStore_VerifyChain(t *testing.T) {
+	// given
+	testCA, _, leafCert := setUpTrustStoreCA(t)
+	defer testCA.Close()
+
+	emptyStore := cert.NewMemTrustStore()
+	trustStore := cert.NewMemTrustStore()
+	trustStore.AddRoot(testCA.RootCert)
+
+	// when
+	err := cert.NewVerifierWithTrustStore(trustStore, 0, nil).VerifyChain(leafCert)
+	emptyErr := cert.NewVerifierWithTrustStore(emptyStore, 0, nil).VerifyChain(leafCert)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, cert.ErrNoRootCertInPath, emptyErr)
+}
+
+func TestMemTrustStore_Pins(t *testing.T) {
+	// given
+	testCA, _, leafCert := setUpTrustStoreCA(t)
+	defer testCA.Close()
+	otherCA, _, otherCert := setUpTrustStoreCA(t)
+	defer otherCA.Close()
+
+	pinnedStore := cert.NewMemTrustStore()
+	pinnedStore.AddRoot(testCA.RootCert)
+	pinnedStore.AddPin(cert.PinOf(leafCert))
+
+	otherPinnedStore := cert.NewMemTrustStore()
+	otherPinnedStore.AddRoot(testCA.RootCert)
+	otherPinnedStore.AddPin(cert.PinOf(otherCert))
+
+	// when
+	err := cert.NewVerifierWithTrustStore(pinnedStore, 0, nil).VerifyChain(leafCert)
+	otherErr := cert.NewVerifierWithTrustStore(otherPinnedStore, 0, nil).VerifyChain(leafCert)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, cert.ErrNotPinned, otherErr)
+}
+
+func TestMemTrustStore_CRLs(t *testing.T) {
+	// given
+	testCA, _, leafCert := setUpTrustStoreCA(t)
+	defer testCA.Close()
+	otherCA, _, _ := setUpTrustStoreCA(t)
+	defer otherCA.Close()
+
+	testCA.Revoke(leafCert.SerialNumber)
+	crl, err := testCA.CRL()
+	assert.NoError(t, err)
+	otherCRL, err := otherCA.CRL()
+	assert.NoError(t, err)
+
+	trustStore := cert.NewMemTrustStore()
+	trustStore.AddRoot(testCA.RootCert)
+	assert.NoError(t, trustStore.AddCRL(otherCRL))
+	verifier := cert.NewVerifierWithTrustStore(trustStore, 0, nil)
+
+	// when
+	crls, crlsErr := trustStore.CRLs(leafCert)
+	notRevokedErr := verifier.Verify(leafCert)
+
+	assert.NoError(t, trustStore.AddCRL(crl))
+	revokedErr := verifier.Verify(leafCert)
+
+	// then
+	assert.NoError(t, crlsErr)
+	assert.Empty(t, crls)
+	assert.NoError(t, notRevokedErr)
+	assert.Equal(t, cert.ErrCertRevoked, revokedErr)
+}
+
+func TestDirTrustStore(t *testing.T) {
+	// given
+	testCA, _, leafCert := setUpTrustStoreCA(t)
+	defer testCA.Close()
+	assert.NoError(t, cert.Store(testCA.RootCert, heimdall.TestCertDir))
+	assert.NoError(t, cert.Store(leafCert, heimdall.TestCertDir))
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	trustStore := cert.NewDirTrustStore(heimdall.TestCertDir)
+
+	// when
+	roots, rootsErr := trustStore.Roots()
+	intermediates, intermediatesErr := trustStore.Intermediates()
+
+	// then
+	assert.NoError(t, rootsErr)
+	assert.Equal(t, []*x509.Certificate{testCA.RootCert}, roots)
+	assert.NoError(t, intermediatesErr)
+	assert.Empty(t, intermediates)
+}
+
+func TestVerifyWithCert_TrustStore(t *testing.T) {
+	// given
+	testCA, pri, leafCert := setUpTrustStoreCA(t)
+	defer testCA.Close()
+
+	trustStore := cert.NewMemTrustStore()
+	trustStore.AddRoot(testCA.RootCert)
+	verifier := hecdsa.NewVerifierWithCertVerifier(cert.NewVerifierWithTrustStore(trustStore, 0, nil))
+
+	message := []byte("hello")
+	signerOpt := hecdsa.NewSignerOpts(nil)
+	signature, err := hecdsa.Sign(hecdsa.NewPriKey(pri), message, signerOpt)
+	assert.NoError(t, err)
+
+	// when
+	valid, err := verifier.VerifyWithCert(leafCert, signature, message, signerOpt)
+	_, untrustedErr := hecdsa.NewVerifierWithCertVerifier(cert.NewVerifierWithTrustStore(cert.NewMemTrustStore(), 0, nil)).
+		VerifyWithCert(leafCert, signature, message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, cert.ErrNoRootCertInPath, untrustedErr)
+}
@@ -0,0 +1,28 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides certificate verifier interface.
+
+package heimdall
+
+import "crypto/x509"
+
+// CertVerifier verifies certificate chain from trusted certificates and validity of a certificate.
+type CertVerifier interface {
+	VerifyChain(cert *x509.Certificate) error
+	Verify(cert *x509.Certificate) error
+}
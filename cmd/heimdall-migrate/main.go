@@ -0,0 +1,105 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// heimdall-migrate converts legacy key directory holding PEM or DER key files into encrypted keystore format.
+//
+//	heimdall-migrate -legacy ./legacy_keys -pri ./.private_keys -pub ./.public_keys
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/DE-labtory/heimdall/config"
+	"github.com/DE-labtory/heimdall/keystore"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+var ErrPwdMismatch = errors.New("passphrases do not match")
+
+func main() {
+	legacyDirPath := flag.String("legacy", "", "legacy key directory to migrate")
+	priKeyRootPath := flag.String("pri", ".private_keys", "root directory of migrated private key directories")
+	pubKeyDirPath := flag.String("pub", ".public_keys", "directory of migrated public keys")
+	flag.Parse()
+
+	if *legacyDirPath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	conf, err := config.NewDefaultConfig()
+	errorCheck(err)
+
+	pwd, err := promptPwd()
+	errorCheck(err)
+
+	migrations, err := keystore.MigrateLegacyDir(*legacyDirPath, *priKeyRootPath, *pubKeyDirPath, pwd, conf.EncOpt, conf.KdfOpt)
+	errorCheck(err)
+
+	failed := false
+	for _, migration := range migrations {
+		if migration.Err != nil {
+			failed = true
+			fmt.Printf("FAIL %s: %s\n", migration.Name, migration.Err)
+			continue
+		}
+
+		kind := "public"
+		if migration.Private {
+			kind = "private"
+		}
+		fmt.Printf("OK   %s: %s key %s\n", migration.Name, kind, migration.KeyID)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// promptPwd reads passphrase of migrated private keys twice from terminal without echo.
+func promptPwd() (string, error) {
+	fmt.Print("passphrase: ")
+	pwd, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+
+	fmt.Print("confirm passphrase: ")
+	confirm, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+	fmt.Println()
+	if err != nil {
+		return "", err
+	}
+
+	if !bytes.Equal(pwd, confirm) {
+		return "", ErrPwdMismatch
+	}
+
+	return string(pwd), nil
+}
+
+func errorCheck(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
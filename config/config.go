@@ -20,7 +20,10 @@
 package config
 
 import (
+	"encoding/json"
 	"errors"
+	"io/ioutil"
+	"time"
 
 	"github.com/DE-labtory/heimdall"
 	"github.com/DE-labtory/heimdall/encryption"
@@ -32,22 +35,48 @@ import (
 var ErrInvalidSecLv = errors.New("invalid security level")
 
 type Config struct {
-	SecLv       int
-	KeyDirPath  string
-	CertDirPath string
-	KeyGenOpt   heimdall.KeyGenOpts
-	EncOpt      *encryption.Opts
-	KdfOpt      *kdf.Opts
-	SigAlgo     string
-	HashOpt     *hashing.HashOpt
+	SecLv           int
+	KeyDirPath      string
+	CertDirPath     string
+	KeyGenOpt       heimdall.KeyGenOpts
+	EncOpt          *encryption.Opts
+	KdfOpt          *kdf.Opts
+	SigAlgo         string
+	HashOpt         *hashing.HashOpt
+	AlgorithmPolicy *heimdall.AlgorithmPolicy
+	KeyIDFormat     *heimdall.KeyIDFormat
+	SKIHash         string
 }
 
-// NewSimpleConfig makes configuration by input security level
+// NewSimpleConfig makes configuration by input security level. Keys encrypting private keys are derived by scrypt
+// with parameters calibrated on the host for kdf.DefaultCalibrationTarget, instead of fixed defaults.
 func NewSimpleConfig(secLv int) (conf *Config, err error) {
 	conf = new(Config)
 	return conf, conf.initSimpleConfig(secLv)
 }
 
+// NewSimpleConfigWithKDF makes configuration by input security level, deriving keys for private key encryption
+// by key derivation function of name with its default parameters. (ex. kdf.ARGON2ID)
+// Key derivation functions without work factor such as kdf.HKDF are rejected with kdf.ErrKdfNotForPassword.
+func NewSimpleConfigWithKDF(secLv int, kdfName string) (conf *Config, err error) {
+	conf, err = NewSimpleConfig(secLv)
+	if err != nil {
+		return conf, err
+	}
+
+	kdfParams, err := kdf.DefaultParamsOf(kdfName)
+	if err != nil {
+		return conf, err
+	}
+
+	if !kdf.IsPasswordKDF(kdfName) {
+		return conf, kdf.ErrKdfNotForPassword
+	}
+
+	conf.KdfOpt, err = kdf.NewOpts(kdfName, kdfParams)
+	return conf, err
+}
+
 // NewDefaultConfig makes configuration by security level 192
 func NewDefaultConfig() (conf *Config, err error) {
 	conf = new(Config)
@@ -106,7 +135,7 @@ func (conf *Config) initSimpleConfig(secLv int) error {
 	}
 	conf.EncOpt = encOpt
 
-	kdfOpt, err := kdf.NewOpts("SCRYPT", kdf.DefaultScryptParams)
+	kdfOpt, err := kdf.CalibrateOpts(kdf.SCRYPT, kdf.DefaultCalibrationTarget)
 	if err != nil {
 		return err
 	}
@@ -115,6 +144,22 @@ func (conf *Config) initSimpleConfig(secLv int) error {
 	return nil
 }
 
+// CalibrateKDF replaces parameters of key derivation function with parameters calibrated on the host,
+// so that deriving a key takes about target duration instead of fixed defaults or kdf.DefaultCalibrationTarget.
+func (conf *Config) CalibrateKDF(targetDuration time.Duration) error {
+	if conf.KdfOpt == nil {
+		return kdf.ErrKdfNotCalibratable
+	}
+
+	kdfOpt, err := kdf.CalibrateOpts(conf.KdfOpt.KdfName, targetDuration)
+	if err != nil {
+		return err
+	}
+
+	conf.KdfOpt = kdfOpt
+	return nil
+}
+
 // todo: 받을 parameter 결정..
 // NewDetailConfig makes configuration by parameters corresponding to config struct members
 func NewDetailConfig() (conf *Config, err error) {
@@ -126,3 +171,81 @@ func NewDetailConfig() (conf *Config, err error) {
 func (conf *Config) initDetailConfig() error {
 	return nil
 }
+
+// LoadAlgorithmPolicy reads json formatted algorithm policy from file, such as
+// {"Keys":{"Deny":["P-224"]},"Hashes":{"Allow":["SHA256","SHA384","SHA512"]}}
+func LoadAlgorithmPolicy(path string) (*heimdall.AlgorithmPolicy, error) {
+	policyBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &heimdall.AlgorithmPolicy{}
+	if err := json.Unmarshal(policyBytes, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// ApplyAlgorithmPolicy checks algorithms of configuration against policy, and enforces policy in the process
+// at key generation, signing and key loading.
+func (conf *Config) ApplyAlgorithmPolicy(policy *heimdall.AlgorithmPolicy) error {
+	if err := conf.CheckAlgorithms(policy); err != nil {
+		return err
+	}
+
+	conf.AlgorithmPolicy = policy
+	heimdall.SetAlgorithmPolicy(policy)
+
+	return nil
+}
+
+// CheckAlgorithms checks if algorithms of configuration are permitted by policy.
+func (conf *Config) CheckAlgorithms(policy *heimdall.AlgorithmPolicy) error {
+	if conf.KeyGenOpt != nil {
+		if err := policy.CheckKey(conf.KeyGenOpt); err != nil {
+			return err
+		}
+	}
+
+	if conf.HashOpt != nil {
+		if err := policy.CheckHash(conf.HashOpt.Name); err != nil {
+			return err
+		}
+	}
+
+	if conf.EncOpt != nil {
+		if err := policy.CheckCipher(conf.EncOpt.Algorithm, conf.EncOpt.KeyLen); err != nil {
+			return err
+		}
+	}
+
+	if conf.KdfOpt != nil {
+		if err := policy.CheckKDF(conf.KdfOpt.KdfName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ApplyKeyIDFormat makes key IDs of the process in format, such as {"Prefix":"heimdall:","Encoding":"MULTIBASE"}.
+func (conf *Config) ApplyKeyIDFormat(format *heimdall.KeyIDFormat) error {
+	if err := heimdall.SetKeyIDFormat(format); err != nil {
+		return err
+	}
+
+	conf.KeyIDFormat = format
+	return nil
+}
+
+// ApplySKIHash makes SKIs and key IDs of keys in the process by hash function of name. (ex. hashing.SHA3_256)
+func (conf *Config) ApplySKIHash(name string) error {
+	if err := heimdall.SetSKIHash(name); err != nil {
+		return err
+	}
+
+	conf.SKIHash = name
+	return nil
+}
@@ -18,11 +18,18 @@
 package config_test
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
+	"github.com/DE-labtory/heimdall"
 	"github.com/DE-labtory/heimdall/config"
 	"github.com/DE-labtory/heimdall/hashing"
 	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -77,6 +84,51 @@ func TestNewSimpleConfig(t *testing.T) {
 	}
 }
 
+func TestNewSimpleConfig_CalibratedKDF(t *testing.T) {
+	// when
+	conf, err := config.NewSimpleConfig(192)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, kdf.SCRYPT, conf.KdfOpt.KdfName)
+	assert.NoError(t, kdf.CheckLimits(conf.KdfOpt))
+
+	N, err := strconv.Atoi(conf.KdfOpt.KdfParams["N"])
+	assert.NoError(t, err)
+	assert.True(t, N >= 1<<14)
+	assert.Equal(t, 0, N&(N-1))
+}
+
+func TestNewSimpleConfigWithKDF(t *testing.T) {
+	// when
+	conf, err := config.NewSimpleConfigWithKDF(192, kdf.ARGON2ID)
+	_, notSupportedErr := config.NewSimpleConfigWithKDF(192, "BCRYPT")
+	_, notForPasswordErr := config.NewSimpleConfigWithKDF(192, kdf.HKDF)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, kdf.ARGON2ID, conf.KdfOpt.KdfName)
+	assert.Equal(t, kdf.DefaultArgon2idParams, conf.KdfOpt.KdfParams)
+	assert.Equal(t, hecdsa.ECP384, conf.KeyGenOpt.ToString())
+	assert.Equal(t, kdf.ErrKdfNotSupported, notSupportedErr)
+	assert.Equal(t, kdf.ErrKdfNotForPassword, notForPasswordErr)
+}
+
+func TestConfig_CalibrateKDF(t *testing.T) {
+	// given
+	conf, err := config.NewSimpleConfigWithKDF(128, kdf.ARGON2ID)
+	assert.NoError(t, err)
+
+	// when
+	err = conf.CalibrateKDF(time.Millisecond)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, kdf.ARGON2ID, conf.KdfOpt.KdfName)
+	assert.Equal(t, "1", conf.KdfOpt.KdfParams["iteration"])
+	assert.NoError(t, kdf.CheckLimits(conf.KdfOpt))
+}
+
 func TestNewDefaultConfig(t *testing.T) {
 	// when
 	conf, err := config.NewDefaultConfig()
@@ -89,3 +141,60 @@ func TestNewDefaultConfig(t *testing.T) {
 // todo: 기능 완성되면 작성
 func TestNewDetailConfig(t *testing.T) {
 }
+
+func TestConfig_ApplyAlgorithmPolicy(t *testing.T) {
+	// given
+	assert.NoError(t, os.MkdirAll(heimdall.TestKeyDir, 0700))
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	policyPath := filepath.Join(heimdall.TestKeyDir, "algorithm_policy.json")
+	assert.NoError(t, ioutil.WriteFile(policyPath, []byte(`{"Keys":{"Deny":["P-224"]},"Hashes":{"Deny":["SHA384"]}}`), 0600))
+	conf, err := config.NewDefaultConfig()
+	assert.NoError(t, err)
+	otherConf, err := config.NewSimpleConfig(128)
+	assert.NoError(t, err)
+
+	// when
+	policy, err := config.LoadAlgorithmPolicy(policyPath)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"P-224"}, policy.Keys.Deny)
+	assert.Equal(t, heimdall.ErrHashAlgorithmDenied, conf.ApplyAlgorithmPolicy(policy))
+	assert.NoError(t, otherConf.ApplyAlgorithmPolicy(policy))
+	defer heimdall.SetAlgorithmPolicy(nil)
+	assert.Equal(t, policy, heimdall.CurrentAlgorithmPolicy())
+}
+
+func TestConfig_ApplyKeyIDFormat(t *testing.T) {
+	// given
+	conf, err := config.NewDefaultConfig()
+	assert.NoError(t, err)
+	format := &heimdall.KeyIDFormat{Prefix: "heimdall:", Encoding: heimdall.KeyIDMultibase}
+
+	// when
+	err = conf.ApplyKeyIDFormat(format)
+	defer heimdall.SetKeyIDFormat(nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, format, conf.KeyIDFormat)
+	assert.Equal(t, format, heimdall.CurrentKeyIDFormat())
+	assert.Equal(t, heimdall.ErrInvalidKeyIDFormat, conf.ApplyKeyIDFormat(&heimdall.KeyIDFormat{Encoding: "BASE64"}))
+	assert.Equal(t, format, conf.KeyIDFormat)
+}
+
+func TestConfig_ApplySKIHash(t *testing.T) {
+	// given
+	conf, err := config.NewDefaultConfig()
+	assert.NoError(t, err)
+
+	// when
+	err = conf.ApplySKIHash(hashing.SHA3_256)
+	defer heimdall.SetSKIHash("")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hashing.SHA3_256, conf.SKIHash)
+	assert.Equal(t, hashing.SHA3_256, heimdall.CurrentSKIHash())
+	assert.Equal(t, heimdall.ErrSKIHashNotSupported, conf.ApplySKIHash(hashing.SHA512))
+}
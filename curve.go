@@ -0,0 +1,104 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides registry of elliptic curve names, so that curves other than NIST curves can be registered at runtime.
+
+package heimdall
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+var ErrCurveRegistered = errors.New("curve registered - curve name or key ID prefix is already registered")
+var ErrInvalidCurveName = errors.New("invalid curve name - curve name should not be empty or contain option delimiter")
+var ErrInvalidKeyIDPrefix = errors.New("invalid key ID prefix - prefix of curve should start with EC and should not contain key ID delimiter")
+
+// curveInfo is bit length and key ID prefix of a registered curve.
+type curveInfo struct {
+	name        string
+	bitLen      int
+	keyIDPrefix string
+}
+
+// curves maps upper case curve names to registered curves.
+var curves = map[string]*curveInfo{
+	"P-224":     {"P-224", 224, "ECP224"},
+	"P-256":     {"P-256", 256, "ECP256"},
+	"P-384":     {"P-384", 384, "ECP384"},
+	"P-521":     {"P-521", 521, "ECP521"},
+	"SECP256K1": {"secp256k1", 256, "ECSECP256K1"},
+
+	"BRAINPOOLP256R1": {"brainpoolP256r1", 256, "ECBP256R1"},
+	"BRAINPOOLP384R1": {"brainpoolP384r1", 384, "ECBP384R1"},
+}
+var curvesMutex = &sync.RWMutex{}
+
+// RegisterCurve registers name of elliptic curve with its bit length and key ID prefix (ex. brainpoolP256r1, 256, ECBP256R1),
+// so that key types of the curve can be parsed and validated, and key IDs of the curve can be made and parsed.
+// Curve names are case insensitive. Implementation of the curve should be registered to algorithm packages such as hecdsa.
+func RegisterCurve(name string, bitLen int, keyIDPrefix string) error {
+	if name == "" || strings.Contains(name, OptDelimiter) || bitLen <= 0 {
+		return ErrInvalidCurveName
+	}
+
+	if !strings.HasPrefix(keyIDPrefix, "EC") || strings.Contains(keyIDPrefix, KeyIDDelimiter) {
+		return ErrInvalidKeyIDPrefix
+	}
+
+	curvesMutex.Lock()
+	defer curvesMutex.Unlock()
+
+	upper := strings.ToUpper(name)
+	if _, exists := curves[upper]; exists {
+		return ErrCurveRegistered
+	}
+
+	for _, curve := range curves {
+		if curve.keyIDPrefix == keyIDPrefix {
+			return ErrCurveRegistered
+		}
+	}
+
+	curves[upper] = &curveInfo{name: name, bitLen: bitLen, keyIDPrefix: keyIDPrefix}
+
+	return nil
+}
+
+// lookupCurve finds registered curve by case insensitive name.
+func lookupCurve(name string) (*curveInfo, bool) {
+	curvesMutex.RLock()
+	defer curvesMutex.RUnlock()
+
+	curve, ok := curves[strings.ToUpper(name)]
+	return curve, ok
+}
+
+// lookupCurveByKeyIDPrefix finds registered curve by key ID prefix.
+func lookupCurveByKeyIDPrefix(keyIDPrefix string) (*curveInfo, bool) {
+	curvesMutex.RLock()
+	defer curvesMutex.RUnlock()
+
+	for _, curve := range curves {
+		if curve.keyIDPrefix == keyIDPrefix {
+			return curve, true
+		}
+	}
+
+	return nil, false
+}
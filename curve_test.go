@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package heimdall_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterCurve(t *testing.T) {
+	// given
+	err := heimdall.RegisterCurve("brainpoolP512r1", 512, "ECBP512R1")
+	assert.NoError(t, err)
+
+	// when
+	keyType, err := heimdall.ParseKeyType("ECDSA_BRAINPOOLP512R1")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "brainpoolP512r1", keyType.Curve)
+	assert.Equal(t, 512, keyType.BitLen)
+	assert.NoError(t, keyType.Validate())
+
+	keyId, err := heimdall.MakeKeyID(keyType, []byte{0x01, 0x02})
+	assert.NoError(t, err)
+	info, err := heimdall.ParseKeyID(keyId)
+	assert.NoError(t, err)
+	assert.Equal(t, keyType, info.KeyType)
+}
+
+func TestParseKeyType_Brainpool(t *testing.T) {
+	for _, test := range []struct {
+		keyType string
+		curve   string
+		bitLen  int
+	}{
+		{"ECDSA_BRAINPOOLP256R1", "brainpoolP256r1", 256},
+		{"ECDSA_BRAINPOOLP384R1", "brainpoolP384r1", 384},
+	} {
+		t.Logf("running test case [%s]", test.keyType)
+
+		// when
+		keyType, err := heimdall.ParseKeyType(test.keyType)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, test.curve, keyType.Curve)
+		assert.Equal(t, test.bitLen, keyType.BitLen)
+		assert.NoError(t, keyType.Validate())
+	}
+}
+
+func TestRegisterCurve_Invalid(t *testing.T) {
+	assert.Equal(t, heimdall.ErrCurveRegistered, heimdall.RegisterCurve("p-256", 256, "ECOTHER256"))
+	assert.Equal(t, heimdall.ErrCurveRegistered, heimdall.RegisterCurve("OTHER-256", 256, "ECP256"))
+	assert.Equal(t, heimdall.ErrInvalidCurveName, heimdall.RegisterCurve("", 256, "ECEMPTY"))
+	assert.Equal(t, heimdall.ErrInvalidCurveName, heimdall.RegisterCurve("OTHER_256", 256, "ECOTHER256"))
+	assert.Equal(t, heimdall.ErrInvalidKeyIDPrefix, heimdall.RegisterCurve("OTHER-256", 256, "OTHER256"))
+	assert.Equal(t, heimdall.ErrInvalidKeyIDPrefix, heimdall.RegisterCurve("OTHER-256", 256, "ECx256"))
+}
@@ -85,3 +85,9 @@ func (opt *Opts) initOpts(algorithm string, keyLen int, opMode string) error {
 func (opt *Opts) ToString() string {
 	return opt.Algorithm + heimdall.OptDelimiter + strconv.Itoa(opt.KeyLen) + heimdall.OptDelimiter + opt.OpMode
 }
+
+// WeakerThan checks if key encrypted with the option should be re-encrypted with other option,
+// which has longer key of the same algorithm and operation mode.
+func (opt *Opts) WeakerThan(other *Opts) bool {
+	return opt.Algorithm == other.Algorithm && opt.OpMode == other.OpMode && opt.KeyLen < other.KeyLen
+}
@@ -86,3 +86,16 @@ func TestOpts_ToString(t *testing.T) {
 	// when
 	assert.Equal(t, "AES_256_CTR", strEncOpt)
 }
+
+func TestOpts_WeakerThan(t *testing.T) {
+	// given
+	aes128, err := encryption.NewOpts(encryption.AES, 128, encryption.CTR)
+	assert.NoError(t, err)
+	aes256, err := encryption.NewOpts(encryption.AES, 256, encryption.CTR)
+	assert.NoError(t, err)
+
+	// when, then
+	assert.True(t, aes128.WeakerThan(aes256))
+	assert.False(t, aes256.WeakerThan(aes128))
+	assert.False(t, aes256.WeakerThan(aes256))
+}
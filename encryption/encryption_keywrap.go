@@ -0,0 +1,191 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides AES Key Wrap (RFC 3394) and AES Key Wrap with Padding (RFC 5649),
+// so keys can be wrapped by KEKs of HSMs and cloud KMS, and wrapped keys from them can be imported.
+
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/subtle"
+	"encoding/binary"
+	"errors"
+)
+
+var ErrInvalidKeyWrapInput = errors.New("invalid key wrap input - key to wrap should be multiple of 8 bytes and at least 16 bytes")
+var ErrEmptyKeyWrapInput = errors.New("invalid key wrap input - key to wrap should not be empty")
+var ErrKeyUnwrapFailed = errors.New("key unwrap failed - wrapped key is tampered or wrapped with other KEK")
+
+// keyWrapIV is default initial value of RFC 3394.
+var keyWrapIV = []byte{0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6, 0xA6}
+
+// keyWrapPadIV is prefix of alternative initial value of RFC 5649, which is followed by 32 bits length of the key.
+var keyWrapPadIV = []byte{0xA6, 0x59, 0x59, 0xA6}
+
+// KeyWrap wraps key by KEK of 128, 192 or 256 bits with AES Key Wrap. (RFC 3394)
+// Key should be multiple of 8 bytes and at least 16 bytes (ex. AES key). Use KeyWrapWithPadding for other keys.
+func KeyWrap(kek, key []byte) ([]byte, error) {
+	if len(key) < 16 || len(key)%8 != 0 {
+		return nil, ErrInvalidKeyWrapInput
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrap(block, keyWrapIV, key), nil
+}
+
+// KeyUnwrap unwraps key wrapped by KeyWrap with KEK, and checks integrity of the key.
+func KeyUnwrap(kek, wrappedKey []byte) ([]byte, error) {
+	if len(wrappedKey) < 24 || len(wrappedKey)%8 != 0 {
+		return nil, ErrKeyUnwrapFailed
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	iv, key := unwrap(block, wrappedKey)
+	if subtle.ConstantTimeCompare(iv, keyWrapIV) != 1 {
+		return nil, ErrKeyUnwrapFailed
+	}
+
+	return key, nil
+}
+
+// KeyWrapWithPadding wraps key of any length by KEK with AES Key Wrap with Padding. (RFC 5649)
+func KeyWrapWithPadding(kek, key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptyKeyWrapInput
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	iv := make([]byte, 8)
+	copy(iv, keyWrapPadIV)
+	binary.BigEndian.PutUint32(iv[4:], uint32(len(key)))
+
+	padded := make([]byte, (len(key)+7)/8*8)
+	copy(padded, key)
+
+	// a key of one block is encrypted with the initial value as one AES block
+	if len(padded) == 8 {
+		wrappedKey := make([]byte, 16)
+		block.Encrypt(wrappedKey, append(iv, padded...))
+		return wrappedKey, nil
+	}
+
+	return wrap(block, iv, padded), nil
+}
+
+// KeyUnwrapWithPadding unwraps key wrapped by KeyWrapWithPadding with KEK, and checks integrity and length of the key.
+func KeyUnwrapWithPadding(kek, wrappedKey []byte) ([]byte, error) {
+	if len(wrappedKey) < 16 || len(wrappedKey)%8 != 0 {
+		return nil, ErrKeyUnwrapFailed
+	}
+
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, err
+	}
+
+	var iv, padded []byte
+	if len(wrappedKey) == 16 {
+		plaintext := make([]byte, 16)
+		block.Decrypt(plaintext, wrappedKey)
+		iv, padded = plaintext[:8], plaintext[8:]
+	} else {
+		iv, padded = unwrap(block, wrappedKey)
+	}
+
+	keyLen := int(binary.BigEndian.Uint32(iv[4:]))
+	if subtle.ConstantTimeCompare(iv[:4], keyWrapPadIV) != 1 || keyLen <= len(padded)-8 || keyLen > len(padded) {
+		clearBytes(padded)
+		return nil, ErrKeyUnwrapFailed
+	}
+
+	for _, b := range padded[keyLen:] {
+		if b != 0 {
+			clearBytes(padded)
+			return nil, ErrKeyUnwrapFailed
+		}
+	}
+
+	return padded[:keyLen], nil
+}
+
+// wrap is wrapping process of RFC 3394 in index based form, with initial value iv.
+func wrap(block cipher.Block, iv, plaintext []byte) []byte {
+	n := len(plaintext) / 8
+	ciphertext := make([]byte, 8+len(plaintext))
+	copy(ciphertext, iv)
+	copy(ciphertext[8:], plaintext)
+
+	buf := make([]byte, 16)
+	for j := 0; j < 6; j++ {
+		for i := 1; i <= n; i++ {
+			copy(buf, ciphertext[:8])
+			copy(buf[8:], ciphertext[i*8:])
+			block.Encrypt(buf, buf)
+
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(ciphertext[:8], binary.BigEndian.Uint64(buf[:8])^t)
+			copy(ciphertext[i*8:], buf[8:])
+		}
+	}
+
+	return ciphertext
+}
+
+// unwrap is unwrapping process of RFC 3394 in index based form, which returns initial value and plaintext.
+func unwrap(block cipher.Block, ciphertext []byte) (iv, plaintext []byte) {
+	n := len(ciphertext)/8 - 1
+	iv = make([]byte, 8)
+	copy(iv, ciphertext[:8])
+	plaintext = make([]byte, len(ciphertext)-8)
+	copy(plaintext, ciphertext[8:])
+
+	buf := make([]byte, 16)
+	for j := 5; j >= 0; j-- {
+		for i := n; i >= 1; i-- {
+			t := uint64(n*j + i)
+			binary.BigEndian.PutUint64(buf[:8], binary.BigEndian.Uint64(iv)^t)
+			copy(buf[8:], plaintext[(i-1)*8:i*8])
+			block.Decrypt(buf, buf)
+
+			copy(iv, buf[:8])
+			copy(plaintext[(i-1)*8:], buf[8:])
+		}
+	}
+
+	return iv, plaintext
+}
+
+// clearBytes clears unwrapped key which fails integrity check.
+func clearBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package encryption_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/stretchr/testify/assert"
+)
+
+func decodeHex(t *testing.T, str string) []byte {
+	b, err := hex.DecodeString(str)
+	assert.NoError(t, err)
+
+	return b
+}
+
+func TestKeyWrap(t *testing.T) {
+	// test vectors of RFC 3394 section 4
+	testCases := map[string]struct {
+		kek, key, wrappedKey string
+	}{
+		"128 bits key with 128 bits KEK": {
+			"000102030405060708090A0B0C0D0E0F",
+			"00112233445566778899AABBCCDDEEFF",
+			"1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5",
+		},
+		"128 bits key with 256 bits KEK": {
+			"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+			"00112233445566778899AABBCCDDEEFF",
+			"64E8C3F9CE0F5BA263E9777905818A2A93C8191E7D6E8AE7",
+		},
+		"256 bits key with 256 bits KEK": {
+			"000102030405060708090A0B0C0D0E0F101112131415161718191A1B1C1D1E1F",
+			"00112233445566778899AABBCCDDEEFF000102030405060708090A0B0C0D0E0F",
+			"28C9F404C4B810F4CBCCB35CFB87F8263F5786E2D80ED326CBC7F0E71A99F43BFB988B9B7A02DD21",
+		},
+	}
+
+	for testName, test := range testCases {
+		t.Logf("running test case [%s]", testName)
+
+		// given
+		kek := decodeHex(t, test.kek)
+		key := decodeHex(t, test.key)
+
+		// when
+		wrappedKey, wrapErr := encryption.KeyWrap(kek, key)
+		unwrappedKey, unwrapErr := encryption.KeyUnwrap(kek, wrappedKey)
+
+		// then
+		assert.NoError(t, wrapErr)
+		assert.Equal(t, decodeHex(t, test.wrappedKey), wrappedKey)
+		assert.NoError(t, unwrapErr)
+		assert.Equal(t, key, unwrappedKey)
+	}
+}
+
+func TestKeyWrap_InvalidInput(t *testing.T) {
+	// given
+	kek := make([]byte, 16)
+
+	for _, keyLen := range []int{0, 8, 17} {
+		t.Logf("running test case [%d]", keyLen)
+
+		// when
+		wrappedKey, err := encryption.KeyWrap(kek, make([]byte, keyLen))
+
+		// then
+		assert.Equal(t, encryption.ErrInvalidKeyWrapInput, err)
+		assert.Nil(t, wrappedKey)
+	}
+}
+
+func TestKeyUnwrap_Failure(t *testing.T) {
+	// given
+	kek := decodeHex(t, "000102030405060708090A0B0C0D0E0F")
+	wrappedKey := decodeHex(t, "1FA68B0A8112B447AEF34BD8FB5A7B829D3E862371D2CFE5")
+	tampered := append([]byte{}, wrappedKey...)
+	tampered[10] ^= 0x01
+	otherKek := make([]byte, 16)
+
+	testCases := map[string]struct {
+		kek, wrappedKey []byte
+	}{
+		"tampered":  {kek, tampered},
+		"other KEK": {otherKek, wrappedKey},
+		"short":     {kek, wrappedKey[:16]},
+		"unaligned": {kek, wrappedKey[:23]},
+	}
+
+	for testName, test := range testCases {
+		t.Logf("running test case [%s]", testName)
+
+		// when
+		key, err := encryption.KeyUnwrap(test.kek, test.wrappedKey)
+
+		// then
+		assert.Equal(t, encryption.ErrKeyUnwrapFailed, err)
+		assert.Nil(t, key)
+	}
+}
+
+func TestKeyWrapWithPadding(t *testing.T) {
+	// test vectors of RFC 5649 section 6
+	kek := decodeHex(t, "5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	testCases := map[string]struct {
+		key, wrappedKey string
+	}{
+		"20 bytes key": {
+			"c37b7e6492584340bed12207808941155068f738",
+			"138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a",
+		},
+		"7 bytes key": {
+			"466f7250617369",
+			"afbeb0f07dfbf5419200f2ccb50bb24f",
+		},
+	}
+
+	for testName, test := range testCases {
+		t.Logf("running test case [%s]", testName)
+
+		// given
+		key := decodeHex(t, test.key)
+
+		// when
+		wrappedKey, wrapErr := encryption.KeyWrapWithPadding(kek, key)
+		unwrappedKey, unwrapErr := encryption.KeyUnwrapWithPadding(kek, wrappedKey)
+
+		// then
+		assert.NoError(t, wrapErr)
+		assert.Equal(t, decodeHex(t, test.wrappedKey), wrappedKey)
+		assert.NoError(t, unwrapErr)
+		assert.Equal(t, key, unwrappedKey)
+	}
+}
+
+func TestKeyWrapWithPadding_PrivateKey(t *testing.T) {
+	// given
+	kek := make([]byte, 32)
+	for _, keyLen := range []int{1, 8, 9, 121, 138} {
+		t.Logf("running test case [%d]", keyLen)
+		key := make([]byte, keyLen)
+		for i := range key {
+			key[i] = byte(i + 1)
+		}
+
+		// when
+		wrappedKey, wrapErr := encryption.KeyWrapWithPadding(kek, key)
+		unwrappedKey, unwrapErr := encryption.KeyUnwrapWithPadding(kek, wrappedKey)
+
+		// then
+		assert.NoError(t, wrapErr)
+		assert.Len(t, wrappedKey, (keyLen+7)/8*8+8)
+		assert.NoError(t, unwrapErr)
+		assert.Equal(t, key, unwrappedKey)
+	}
+}
+
+func TestKeyWrapWithPadding_Failure(t *testing.T) {
+	// given
+	kek := decodeHex(t, "5840df6e29b02af1ab493b705bf16ea1ae8338f4dcc176a8")
+	wrappedKey := decodeHex(t, "138bdeaa9b8fa7fc61f97742e72248ee5ae6ae5360d1ae6a5f54f373fa543b6a")
+	tampered := append([]byte{}, wrappedKey...)
+	tampered[20] ^= 0x01
+
+	// when
+	_, emptyErr := encryption.KeyWrapWithPadding(kek, nil)
+	_, tamperedErr := encryption.KeyUnwrapWithPadding(kek, tampered)
+	_, otherKekErr := encryption.KeyUnwrapWithPadding(make([]byte, 24), wrappedKey)
+	_, unwrapErr := encryption.KeyUnwrap(kek, wrappedKey)
+
+	// then
+	assert.Equal(t, encryption.ErrEmptyKeyWrapInput, emptyErr)
+	assert.Equal(t, encryption.ErrKeyUnwrapFailed, tamperedErr)
+	assert.Equal(t, encryption.ErrKeyUnwrapFailed, otherKekErr)
+	assert.Equal(t, encryption.ErrKeyUnwrapFailed, unwrapErr)
+}
@@ -0,0 +1,255 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides streaming encryption of large data such as ledger snapshots and backups.
+// Data is split into chunks sealed by AES-GCM, whose nonces carry chunk counter and flag of the last chunk
+// (STREAM construction), so that reordered, dropped or truncated chunks are detected.
+
+package encryption
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/kdf"
+)
+
+var ErrInvalidStream = errors.New("invalid stream - stream is tampered or encrypted with other key")
+var ErrTruncatedStream = errors.New("truncated stream - stream ends before the last chunk")
+var ErrUnknownStreamVersion = errors.New("unknown stream version")
+var ErrStreamClosed = errors.New("stream closed - data can not be written after close")
+
+// StreamChunkSize is size of plaintext in a chunk, except the last chunk which may be shorter.
+const StreamChunkSize = 64 * 1024
+
+const (
+	streamVersion  byte = 0x01
+	streamSaltSize      = 16
+	streamInfo          = "heimdall stream encryption"
+	lastChunkFlag  byte = 0x01
+)
+
+// streamEncryptor encrypts data written to it, and writes encrypted chunks to the underlying writer.
+type streamEncryptor struct {
+	writer  io.Writer
+	aead    cipher.AEAD
+	counter uint64
+	buffer  []byte
+	closed  bool
+}
+
+// NewStreamEncryptor returns writer encrypting data with key of AES-128, AES-192 or AES-256.
+// Header with random salt is written first, and key of the stream is derived from key and salt,
+// so the same key can encrypt many streams. Close should be called to write the last chunk.
+func NewStreamEncryptor(writer io.Writer, key []byte) (io.WriteCloser, error) {
+	salt := make([]byte, streamSaltSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	aead, err := newStreamAEAD(key, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := writer.Write(append([]byte{streamVersion}, salt...)); err != nil {
+		return nil, err
+	}
+
+	return &streamEncryptor{
+		writer: writer,
+		aead:   aead,
+		buffer: make([]byte, 0, StreamChunkSize),
+	}, nil
+}
+
+// Write buffers data, and writes chunks that are full. A full chunk is kept until more data is written,
+// since the last chunk is known only at Close.
+func (encryptor *streamEncryptor) Write(data []byte) (int, error) {
+	if encryptor.closed {
+		return 0, ErrStreamClosed
+	}
+
+	written := 0
+	for len(data) > 0 {
+		if len(encryptor.buffer) == StreamChunkSize {
+			if err := encryptor.writeChunk(false); err != nil {
+				return written, err
+			}
+		}
+
+		n := copy(encryptor.buffer[len(encryptor.buffer):StreamChunkSize], data)
+		encryptor.buffer = encryptor.buffer[:len(encryptor.buffer)+n]
+		data = data[n:]
+		written += n
+	}
+
+	return written, nil
+}
+
+// Close writes the last chunk. It does not close the underlying writer.
+func (encryptor *streamEncryptor) Close() error {
+	if encryptor.closed {
+		return nil
+	}
+	encryptor.closed = true
+
+	return encryptor.writeChunk(true)
+}
+
+func (encryptor *streamEncryptor) writeChunk(last bool) error {
+	nonce := streamNonce(encryptor.aead.NonceSize(), encryptor.counter, last)
+	sealed := encryptor.aead.Seal(nil, nonce, encryptor.buffer, nil)
+	encryptor.counter++
+	encryptor.buffer = encryptor.buffer[:0]
+
+	_, err := encryptor.writer.Write(sealed)
+	return err
+}
+
+// streamDecryptor reads encrypted chunks from the underlying reader, and returns decrypted data.
+type streamDecryptor struct {
+	reader  *bufio.Reader
+	aead    cipher.AEAD
+	counter uint64
+	chunk   []byte
+	plain   []byte
+	done    bool
+}
+
+// NewStreamDecryptor returns reader decrypting stream written by NewStreamEncryptor with the same key.
+// Data of a chunk is returned only after the chunk is authenticated, and reading returns ErrTruncatedStream
+// if the stream ends before the last chunk.
+func NewStreamDecryptor(reader io.Reader, key []byte) (io.Reader, error) {
+	header := make([]byte, 1+streamSaltSize)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrTruncatedStream
+		}
+		return nil, err
+	}
+
+	if header[0] != streamVersion {
+		return nil, ErrUnknownStreamVersion
+	}
+
+	aead, err := newStreamAEAD(key, header[1:])
+	if err != nil {
+		return nil, err
+	}
+
+	return &streamDecryptor{
+		reader: bufio.NewReaderSize(reader, StreamChunkSize+aead.Overhead()+1),
+		aead:   aead,
+		chunk:  make([]byte, StreamChunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (decryptor *streamDecryptor) Read(data []byte) (int, error) {
+	for len(decryptor.plain) == 0 {
+		if decryptor.done {
+			return 0, io.EOF
+		}
+
+		if err := decryptor.readChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(data, decryptor.plain)
+	decryptor.plain = decryptor.plain[n:]
+
+	return n, nil
+}
+
+// readChunk reads and opens a chunk. Chunk shorter than full size, or full chunk at the end of stream, is the last chunk.
+func (decryptor *streamDecryptor) readChunk() error {
+	n, err := io.ReadFull(decryptor.reader, decryptor.chunk)
+	last := false
+	switch {
+	case err == io.EOF:
+		return ErrTruncatedStream
+	case err == io.ErrUnexpectedEOF:
+		last = true
+	case err != nil:
+		return err
+	default:
+		if _, peekErr := decryptor.reader.Peek(1); peekErr == io.EOF {
+			last = true
+		}
+	}
+
+	nonce := streamNonce(decryptor.aead.NonceSize(), decryptor.counter, last)
+	plain, err := decryptor.aead.Open(nil, nonce, decryptor.chunk[:n], nil)
+	if err != nil {
+		// chunk at the end of stream which opens as non-last chunk means the stream is cut at chunk boundary
+		nonce = streamNonce(decryptor.aead.NonceSize(), decryptor.counter, false)
+		if _, openErr := decryptor.aead.Open(nil, nonce, decryptor.chunk[:n], nil); last && openErr == nil {
+			return ErrTruncatedStream
+		}
+		return ErrInvalidStream
+	}
+
+	decryptor.counter++
+	decryptor.plain = plain
+	decryptor.done = last
+
+	return nil
+}
+
+// newStreamAEAD makes AES-GCM of key derived from key and salt of the stream by HKDF.
+func newStreamAEAD(key, salt []byte) (cipher.AEAD, error) {
+	switch len(key) {
+	case 16, 24, 32:
+	default:
+		return nil, ErrKeyLengthNotSupported
+	}
+
+	hkdfOpt, err := kdf.NewOpts(kdf.HKDF, map[string]string{"hashOpt": hashing.SHA384, "info": streamInfo})
+	if err != nil {
+		return nil, err
+	}
+
+	streamKey, err := kdf.DeriveKey(key, salt, len(key)*8, hkdfOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(streamKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// streamNonce makes nonce of big endian chunk counter followed by flag of the last chunk.
+func streamNonce(size int, counter uint64, last bool) []byte {
+	nonce := make([]byte, size)
+	binary.BigEndian.PutUint64(nonce[size-9:size-1], counter)
+	if last {
+		nonce[size-1] = lastChunkFlag
+	}
+
+	return nonce
+}
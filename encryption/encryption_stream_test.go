@@ -0,0 +1,121 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package encryption_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/stretchr/testify/assert"
+)
+
+func encryptStream(t *testing.T, key, data []byte) []byte {
+	buffer := new(bytes.Buffer)
+	encryptor, err := encryption.NewStreamEncryptor(buffer, key)
+	assert.NoError(t, err)
+
+	_, err = encryptor.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, encryptor.Close())
+
+	return buffer.Bytes()
+}
+
+func TestStreamEncryptor(t *testing.T) {
+	sizes := []int{0, 1, encryption.StreamChunkSize, encryption.StreamChunkSize + 1, 3*encryption.StreamChunkSize + 5}
+
+	for _, keyLen := range []int{16, 24, 32} {
+		for _, size := range sizes {
+			t.Logf("running test case [key %d bytes, data %d bytes]", keyLen, size)
+
+			// given
+			key := make([]byte, keyLen)
+			_, err := rand.Read(key)
+			assert.NoError(t, err)
+			data := make([]byte, size)
+			_, err = rand.Read(data)
+			assert.NoError(t, err)
+			encrypted := encryptStream(t, key, data)
+
+			// when
+			decryptor, err := encryption.NewStreamDecryptor(bytes.NewReader(encrypted), key)
+			assert.NoError(t, err)
+			decrypted, err := ioutil.ReadAll(decryptor)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, len(data), len(decrypted))
+			assert.True(t, bytes.Equal(data, decrypted))
+		}
+	}
+}
+
+func TestStreamDecryptor_Invalid(t *testing.T) {
+	// given
+	key := make([]byte, 32)
+	otherKey := make([]byte, 32)
+	otherKey[0] = 0x01
+	data := make([]byte, 2*encryption.StreamChunkSize+10)
+	encrypted := encryptStream(t, key, data)
+
+	chunkSize := encryption.StreamChunkSize + 16
+	tampered := append([]byte{}, encrypted...)
+	tampered[20] ^= 0x01
+
+	tests := map[string]struct {
+		encrypted []byte
+		key       []byte
+		err       error
+	}{
+		"other key":                   {encrypted, otherKey, encryption.ErrInvalidStream},
+		"tampered":                    {tampered, key, encryption.ErrInvalidStream},
+		"truncated at chunk boundary": {encrypted[:17+chunkSize], key, encryption.ErrTruncatedStream},
+		"truncated in chunk":          {encrypted[:len(encrypted)-1], key, encryption.ErrInvalidStream},
+		"header only":                 {encrypted[:17], key, encryption.ErrTruncatedStream},
+	}
+
+	for testName, test := range tests {
+		t.Logf("running test case [%s]", testName)
+
+		// when
+		decryptor, err := encryption.NewStreamDecryptor(bytes.NewReader(test.encrypted), test.key)
+		assert.NoError(t, err)
+		_, err = ioutil.ReadAll(decryptor)
+
+		// then
+		assert.Equal(t, test.err, err)
+	}
+}
+
+func TestNewStreamDecryptor_InvalidHeader(t *testing.T) {
+	// given
+	key := make([]byte, 16)
+
+	// when
+	_, truncatedErr := encryption.NewStreamDecryptor(bytes.NewReader([]byte{0x01}), key)
+	_, versionErr := encryption.NewStreamDecryptor(bytes.NewReader(make([]byte, 17)), key)
+	_, keyLenErr := encryption.NewStreamEncryptor(new(bytes.Buffer), make([]byte, 10))
+
+	// then
+	assert.Equal(t, encryption.ErrTruncatedStream, truncatedErr)
+	assert.Equal(t, encryption.ErrUnknownStreamVersion, versionErr)
+	assert.Equal(t, encryption.ErrKeyLengthNotSupported, keyLenErr)
+}
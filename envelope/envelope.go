@@ -0,0 +1,180 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides signature envelope which carries a message, its signature and countersignatures on the signature.
+// Envelope encryption of payloads under a master key is in envelope_encryption.go.
+
+package envelope
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrNotSigned = errors.New("envelope is not signed")
+var ErrInvalidSignature = errors.New("invalid signature - signature does not match message and key")
+var ErrSignerKeyMismatch = errors.New("key mismatch - signature is not made by the key")
+var ErrCountersignatureNotFound = errors.New("countersignature of the key not found")
+
+// prefix of data covered by countersignature, so that countersignature can not be used as a signature on message
+var countersignPrefix = []byte("heimdall countersignature\x00")
+
+// Signature is a signature in envelope with information needed for verifying it.
+type Signature struct {
+	KeyID     heimdall.KeyID
+	HashOpt   string
+	Signature []byte
+}
+
+// Envelope is a signed message, which can be countersigned by additional keys such as notary or timestamp authority.
+type Envelope struct {
+	Message           []byte
+	Signature         *Signature
+	Countersignatures []*Signature
+}
+
+// Sign makes envelope of message signed by signer.
+func Sign(signer heimdall.Signer, message []byte, opts heimdall.SignerOpts) (*Envelope, error) {
+	signature, err := sign(signer, message, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Envelope{
+		Message:   message,
+		Signature: signature,
+	}, nil
+}
+
+// Countersign adds countersignature of signer, which covers the original signature bytes.
+func (env *Envelope) Countersign(signer heimdall.Signer, opts heimdall.SignerOpts) error {
+	if env.Signature == nil {
+		return ErrNotSigned
+	}
+
+	countersignature, err := sign(signer, countersignedData(env.Signature), opts)
+	if err != nil {
+		return err
+	}
+	env.Countersignatures = append(env.Countersignatures, countersignature)
+
+	return nil
+}
+
+// Verify verifies signature on message by public key of the signer.
+func (env *Envelope) Verify(pub heimdall.PubKey) error {
+	if env.Signature == nil {
+		return ErrNotSigned
+	}
+
+	return verify(pub, env.Signature, env.Message)
+}
+
+// VerifyCountersignature verifies countersignature of pub covers the original signature bytes.
+// It does not verify the original signature, so Verify should be called as well.
+func (env *Envelope) VerifyCountersignature(pub heimdall.PubKey) error {
+	if env.Signature == nil {
+		return ErrNotSigned
+	}
+
+	for _, countersignature := range env.Countersignatures {
+		if heimdall.MatchKeyID(countersignature.KeyID, pub) {
+			return verify(pub, countersignature, countersignedData(env.Signature))
+		}
+	}
+
+	return ErrCountersignatureNotFound
+}
+
+// Marshal encodes envelope to JSON.
+func (env *Envelope) Marshal() ([]byte, error) {
+	return json.Marshal(env)
+}
+
+// Unmarshal decodes envelope from JSON.
+func Unmarshal(data []byte) (*Envelope, error) {
+	env := &Envelope{}
+	if err := json.Unmarshal(data, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func sign(signer heimdall.Signer, data []byte, opts heimdall.SignerOpts) (*Signature, error) {
+	signature, err := signer.Sign(data, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signature{
+		KeyID:     signer.KeyID(),
+		HashOpt:   hashOptName(opts),
+		Signature: signature,
+	}, nil
+}
+
+func verify(pub heimdall.PubKey, signature *Signature, data []byte) error {
+	if !heimdall.MatchKeyID(signature.KeyID, pub) {
+		return ErrSignerKeyMismatch
+	}
+
+	hashOpt, err := hashOptByName(signature.HashOpt)
+	if err != nil {
+		return err
+	}
+
+	valid, err := hecdsa.Verify(pub, signature.Signature, data, hecdsa.NewSignerOpts(hashOpt))
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func countersignedData(signature *Signature) []byte {
+	data := make([]byte, 0, len(countersignPrefix)+len(signature.Signature))
+	data = append(data, countersignPrefix...)
+
+	return append(data, signature.Signature...)
+}
+
+// hashOptByName makes hash option from its name. Empty name means hash was selected by the key, so the same one is selected again.
+func hashOptByName(name string) (*hashing.HashOpt, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	return hashing.NewHashOpt(name)
+}
+
+// hashOptName returns name of hash option in signer option, or empty name if hash is selected by the key.
+func hashOptName(opts heimdall.SignerOpts) string {
+	if opts == nil || opts.HashOpt() == nil {
+		return ""
+	}
+
+	return opts.HashOpt().Name
+}
@@ -0,0 +1,205 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides envelope encryption, which encrypts payload with a random data key and wraps the data key
+// with a master key, so that many objects can be encrypted under one identity key or KMS key.
+
+package envelope
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"io"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/seal"
+)
+
+var ErrUnwrapNotSupported = errors.New("unwrap not supported - key wrapper has no private key")
+var ErrEmptyPayload = errors.New("payload to encrypt should not be nil")
+
+// dataKeySize is size of data key, which is AES-256 key.
+const dataKeySize = 32
+
+// KeyWrapper wraps and unwraps data keys with a master key. It is implemented by keys in keystore,
+// and can be implemented by KMS backends, which wrap data keys without exposing the master key.
+type KeyWrapper interface {
+	KeyID() heimdall.KeyID
+	WrapKey(dataKey []byte) ([]byte, error)
+	UnwrapKey(wrappedKey []byte) ([]byte, error)
+}
+
+// EncryptedEnvelope is payload encrypted by AES-256-GCM with data key, and the data key wrapped by master key.
+type EncryptedEnvelope struct {
+	KeyID      heimdall.KeyID
+	WrappedKey []byte
+	Nonce      []byte
+	Ciphertext []byte
+}
+
+// Encrypt encrypts payload with a new data key, and wraps the data key by wrapper.
+// Additional data is authenticated but not encrypted, and the same one should be given to decrypt.
+func Encrypt(payload []byte, wrapper KeyWrapper, additionalData []byte) (*EncryptedEnvelope, error) {
+	if payload == nil {
+		return nil, ErrEmptyPayload
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		return nil, err
+	}
+	defer clearBytes(dataKey)
+
+	wrappedKey, err := wrapper.WrapKey(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newDataKeyAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return &EncryptedEnvelope{
+		KeyID:      wrapper.KeyID(),
+		WrappedKey: wrappedKey,
+		Nonce:      nonce,
+		Ciphertext: aead.Seal(nil, nonce, payload, additionalData),
+	}, nil
+}
+
+// Decrypt unwraps data key by wrapper, and decrypts payload with the data key.
+// KeyID of envelope tells which master key to use, and wrapper of other master key fails to unwrap the data key.
+func (env *EncryptedEnvelope) Decrypt(wrapper KeyWrapper, additionalData []byte) ([]byte, error) {
+	dataKey, err := wrapper.UnwrapKey(env.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	defer clearBytes(dataKey)
+
+	aead, err := newDataKeyAEAD(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, env.Nonce, env.Ciphertext, additionalData)
+}
+
+// Marshal encodes encrypted envelope to JSON.
+func (env *EncryptedEnvelope) Marshal() ([]byte, error) {
+	return json.Marshal(env)
+}
+
+// UnmarshalEncrypted decodes encrypted envelope from JSON.
+func UnmarshalEncrypted(data []byte) (*EncryptedEnvelope, error) {
+	env := &EncryptedEnvelope{}
+	if err := json.Unmarshal(data, env); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+// KeyPairWrapper wraps data keys by sealing them to public key, so that only the owner of private key can unwrap them.
+// Wrapper without private key can only wrap.
+type KeyPairWrapper struct {
+	pub heimdall.PubKey
+	pri heimdall.PriKey
+}
+
+// NewKeyPairWrapper makes key wrapper of ECDSA key pair. pri can be nil for wrapping only.
+func NewKeyPairWrapper(pub heimdall.PubKey, pri heimdall.PriKey) *KeyPairWrapper {
+	return &KeyPairWrapper{pub: pub, pri: pri}
+}
+
+func (wrapper *KeyPairWrapper) KeyID() heimdall.KeyID {
+	return wrapper.pub.ID()
+}
+
+func (wrapper *KeyPairWrapper) WrapKey(dataKey []byte) ([]byte, error) {
+	return seal.SealWithKey(dataKey, wrapper.pub)
+}
+
+func (wrapper *KeyPairWrapper) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	if wrapper.pri == nil {
+		return nil, ErrUnwrapNotSupported
+	}
+
+	return seal.UnsealWithKey(wrappedKey, wrapper.pri)
+}
+
+// KeyStoreWrapper wraps data keys with key of keyId in keystore. Private key is loaded with password only to unwrap,
+// and cleared right after.
+type KeyStoreWrapper struct {
+	keyStore heimdall.KeyStore
+	keyId    heimdall.KeyID
+	pwd      string
+}
+
+func NewKeyStoreWrapper(keyStore heimdall.KeyStore, keyId heimdall.KeyID, pwd string) *KeyStoreWrapper {
+	return &KeyStoreWrapper{
+		keyStore: keyStore,
+		keyId:    keyId,
+		pwd:      pwd,
+	}
+}
+
+func (wrapper *KeyStoreWrapper) KeyID() heimdall.KeyID {
+	return wrapper.keyId
+}
+
+func (wrapper *KeyStoreWrapper) WrapKey(dataKey []byte) ([]byte, error) {
+	pub, err := wrapper.keyStore.LoadPubKey(wrapper.keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	return seal.SealWithKey(dataKey, pub)
+}
+
+func (wrapper *KeyStoreWrapper) UnwrapKey(wrappedKey []byte) ([]byte, error) {
+	pri, err := wrapper.keyStore.LoadPriKey(wrapper.keyId, wrapper.pwd)
+	if err != nil {
+		return nil, err
+	}
+	defer pri.Clear()
+
+	return seal.UnsealWithKey(wrappedKey, pri)
+}
+
+func newDataKeyAEAD(dataKey []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func clearBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
@@ -0,0 +1,98 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package envelope_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/envelope"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/seal"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncrypt(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	wrapper := envelope.NewKeyPairWrapper(pri.PublicKey(), pri)
+	payload := []byte("ledger snapshot")
+	additionalData := []byte("block 100")
+
+	env, err := envelope.Encrypt(payload, envelope.NewKeyPairWrapper(pri.PublicKey(), nil), additionalData)
+	assert.NoError(t, err)
+	envBytes, err := env.Marshal()
+	assert.NoError(t, err)
+
+	// when
+	decodedEnv, err := envelope.UnmarshalEncrypted(envBytes)
+	assert.NoError(t, err)
+	decrypted, err := decodedEnv.Decrypt(wrapper, additionalData)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, payload, decrypted)
+	assert.Equal(t, pri.ID(), decodedEnv.KeyID)
+}
+
+func TestEncryptedEnvelope_Decrypt_Invalid(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	otherPri := generateKey(t)
+	env, err := envelope.Encrypt([]byte("payload"), envelope.NewKeyPairWrapper(pri.PublicKey(), nil), nil)
+	assert.NoError(t, err)
+
+	// when
+	_, wrapOnlyErr := env.Decrypt(envelope.NewKeyPairWrapper(pri.PublicKey(), nil), nil)
+	_, otherKeyErr := env.Decrypt(envelope.NewKeyPairWrapper(otherPri.PublicKey(), otherPri), nil)
+	_, adErr := env.Decrypt(envelope.NewKeyPairWrapper(pri.PublicKey(), pri), []byte("other"))
+
+	// then
+	assert.Equal(t, envelope.ErrUnwrapNotSupported, wrapOnlyErr)
+	assert.Equal(t, seal.ErrKeyIDMismatch, otherKeyErr)
+	assert.Error(t, adErr)
+}
+
+func TestKeyStoreWrapper(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	wrapper := envelope.NewKeyStoreWrapper(keyStore, pri.ID(), "password")
+	env, err := envelope.Encrypt([]byte("payload"), wrapper, nil)
+	assert.NoError(t, err)
+
+	// when
+	decrypted, err := env.Decrypt(wrapper, nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("payload"), decrypted)
+}
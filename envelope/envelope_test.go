@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package envelope_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/envelope"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateKey(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func signerOpts(t *testing.T) heimdall.SignerOpts {
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+
+	return hecdsa.NewSignerOpts(hashOpt)
+}
+
+func TestEnvelope_Countersign(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	notary := generateKey(t)
+
+	env, err := envelope.Sign(hecdsa.NewSigner(pri), []byte("block header"), signerOpts(t))
+	assert.NoError(t, err)
+
+	// when
+	err = env.Countersign(hecdsa.NewSigner(notary), signerOpts(t))
+
+	// then
+	assert.NoError(t, err)
+
+	data, err := env.Marshal()
+	assert.NoError(t, err)
+	received, err := envelope.Unmarshal(data)
+	assert.NoError(t, err)
+
+	assert.NoError(t, received.Verify(pri.PublicKey()))
+	assert.NoError(t, received.VerifyCountersignature(notary.PublicKey()))
+	assert.Equal(t, envelope.ErrCountersignatureNotFound, received.VerifyCountersignature(generateKey(t).PublicKey()))
+}
+
+func TestEnvelope_VerifyCountersignature_SignatureReplaced(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	notary := generateKey(t)
+
+	env, err := envelope.Sign(hecdsa.NewSigner(pri), []byte("block header"), signerOpts(t))
+	assert.NoError(t, err)
+	assert.NoError(t, env.Countersign(hecdsa.NewSigner(notary), signerOpts(t)))
+
+	// when original signature is replaced by another valid signature
+	other, err := envelope.Sign(hecdsa.NewSigner(pri), []byte("block header"), signerOpts(t))
+	assert.NoError(t, err)
+	env.Signature = other.Signature
+
+	// then
+	assert.NoError(t, env.Verify(pri.PublicKey()))
+	assert.Equal(t, envelope.ErrInvalidSignature, env.VerifyCountersignature(notary.PublicKey()))
+}
+
+func TestEnvelope_Verify_WrongKey(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	env, err := envelope.Sign(hecdsa.NewSigner(pri), []byte("block header"), signerOpts(t))
+	assert.NoError(t, err)
+
+	// when
+	err = env.Verify(generateKey(t).PublicKey())
+
+	// then
+	assert.Equal(t, envelope.ErrSignerKeyMismatch, err)
+}
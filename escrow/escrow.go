@@ -0,0 +1,189 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides key escrow which wraps private keys to recovery agents, so that enterprises can recover lost keys.
+
+package escrow
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+	"github.com/DE-labtory/heimdall/seal"
+)
+
+var ErrNoRecoveryAgent = errors.New("no recovery agent - key should be escrowed to at least one agent")
+var ErrNotRecoveryAgent = errors.New("not recovery agent - key is not escrowed to the agent")
+var ErrKeyIDMismatch = errors.New("key ID mismatch - recovered key is not the escrowed key")
+
+// Record is an escrow record of a private key wrapped to each recovery agent.
+type Record struct {
+	KeyID heimdall.KeyID
+	// Wrapped maps key ID of recovery agent to the private key sealed under the agent public key.
+	Wrapped map[heimdall.KeyID][]byte
+}
+
+// Escrow wraps private key to every recovery agent public key. Keys of algorithm without registered recoverer
+// are rejected, since their escrow could never be recovered.
+func Escrow(pri heimdall.PriKey, agents []heimdall.PubKey) (*Record, error) {
+	if len(agents) == 0 {
+		return nil, ErrNoRecoveryAgent
+	}
+
+	if _, err := heimdall.KeyRecovererOfKeyID(pri.ID()); err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := pri.ToByte()
+	if err != nil {
+		return nil, err
+	}
+	defer clearBytes(keyBytes)
+
+	record := &Record{
+		KeyID:   pri.ID(),
+		Wrapped: make(map[heimdall.KeyID][]byte, len(agents)),
+	}
+
+	for _, agent := range agents {
+		wrapped, err := seal.SealWithKey(keyBytes, agent)
+		if err != nil {
+			return nil, err
+		}
+		record.Wrapped[agent.ID()] = wrapped
+	}
+
+	return record, nil
+}
+
+// Recover unwraps escrowed private key by private key of a recovery agent,
+// with the recoverer registered for algorithm of the escrowed key ID.
+func Recover(record *Record, agentPri heimdall.PriKey) (heimdall.PriKey, error) {
+	wrapped, ok := record.Wrapped[agentPri.ID()]
+	if !ok {
+		return nil, ErrNotRecoveryAgent
+	}
+
+	recoverer, err := heimdall.KeyRecovererOfKeyID(record.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes, err := seal.UnsealWithKey(wrapped, agentPri)
+	if err != nil {
+		return nil, err
+	}
+	defer clearBytes(keyBytes)
+
+	key, err := recoverer.RecoverKeyFromByte(keyBytes, true)
+	if err != nil {
+		return nil, err
+	}
+
+	pri := key.(heimdall.PriKey)
+	if !heimdall.MatchKeyID(record.KeyID, pri) {
+		pri.Clear()
+		return nil, ErrKeyIDMismatch
+	}
+
+	return pri, nil
+}
+
+// Store writes escrow record in escrowDirPath as a file named by key ID.
+func Store(record *Record, escrowDirPath string) error {
+	if err := fileperm.MkdirAll(escrowDirPath); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	return fileperm.WriteFile(filepath.Join(escrowDirPath, record.KeyID), data)
+}
+
+// Load reads escrow record of keyId in escrowDirPath.
+func Load(keyId heimdall.KeyID, escrowDirPath string) (*Record, error) {
+	if err := heimdall.ValidateKeyID(keyId); err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(heimdall.KeyIDFilePath(escrowDirPath, keyId))
+	if err != nil {
+		return nil, err
+	}
+
+	record := &Record{}
+	if err := json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// KeyStore is a keystore in escrow mode, which escrows every stored private key to recovery agents.
+type KeyStore struct {
+	keyStore      heimdall.KeyStore
+	agents        []heimdall.PubKey
+	escrowDirPath string
+}
+
+// NewKeyStore wraps keyStore so that private keys are escrowed to agents in escrowDirPath before being stored.
+func NewKeyStore(keyStore heimdall.KeyStore, agents []heimdall.PubKey, escrowDirPath string) heimdall.KeyStore {
+	return &KeyStore{
+		keyStore:      keyStore,
+		agents:        agents,
+		escrowDirPath: escrowDirPath,
+	}
+}
+
+// StorePriKey escrows private key first, so that no key is stored without being recoverable.
+func (keyStore *KeyStore) StorePriKey(pri heimdall.PriKey, pwd string) error {
+	record, err := Escrow(pri, keyStore.agents)
+	if err != nil {
+		return err
+	}
+
+	if err := Store(record, keyStore.escrowDirPath); err != nil {
+		return err
+	}
+
+	return keyStore.keyStore.StorePriKey(pri, pwd)
+}
+
+func (keyStore *KeyStore) LoadPriKey(keyId heimdall.KeyID, pwd string) (heimdall.PriKey, error) {
+	return keyStore.keyStore.LoadPriKey(keyId, pwd)
+}
+
+func (keyStore *KeyStore) StorePubKey(pub heimdall.PubKey) error {
+	return keyStore.keyStore.StorePubKey(pub)
+}
+
+func (keyStore *KeyStore) LoadPubKey(keyId heimdall.KeyID) (heimdall.PubKey, error) {
+	return keyStore.keyStore.LoadPubKey(keyId)
+}
+
+func clearBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
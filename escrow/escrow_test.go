@@ -0,0 +1,179 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package escrow_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/escrow"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func generateKey(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func TestEscrowAndRecover(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	agent1 := generateKey(t)
+	agent2 := generateKey(t)
+
+	// when
+	record, err := escrow.Escrow(pri, []heimdall.PubKey{agent1.PublicKey(), agent2.PublicKey()})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), record.KeyID)
+	assert.Len(t, record.Wrapped, 2)
+
+	for _, agent := range []heimdall.PriKey{agent1, agent2} {
+		// when
+		recovered, err := escrow.Recover(record, agent)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, pri, recovered)
+	}
+}
+
+func TestEscrowAndRecover_Ed25519(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	agent := generateKey(t)
+
+	record, err := escrow.Escrow(pri, []heimdall.PubKey{agent.PublicKey()})
+	assert.NoError(t, err)
+
+	// when
+	recovered, err := escrow.Recover(record, agent)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri, recovered)
+}
+
+func TestEscrow_NoRecoverer(t *testing.T) {
+	// given key of algorithm whose recoverer is not registered in this test
+	ski := []byte("0123456789abcdef0123456789abcdef")
+	pri := mocks.NewPriKey(ski)
+	keyId, err := heimdall.MakeKeyID(&mocks.KeyGenOpt{Name: heimdall.BLS12381}, ski)
+	assert.NoError(t, err)
+	pri.Pub.KeyID = keyId
+
+	// when
+	record, err := escrow.Escrow(pri, []heimdall.PubKey{generateKey(t).PublicKey()})
+
+	// then
+	assert.Equal(t, heimdall.ErrUnknownKeyType, err)
+	assert.Nil(t, record)
+}
+
+func TestEscrow_NoAgent(t *testing.T) {
+	// given
+	pri := generateKey(t)
+
+	// when
+	record, err := escrow.Escrow(pri, nil)
+
+	// then
+	assert.Equal(t, escrow.ErrNoRecoveryAgent, err)
+	assert.Nil(t, record)
+}
+
+func TestRecover_NotRecoveryAgent(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	agent := generateKey(t)
+	other := generateKey(t)
+	record, err := escrow.Escrow(pri, []heimdall.PubKey{agent.PublicKey()})
+	assert.NoError(t, err)
+
+	// when
+	recovered, err := escrow.Recover(record, other)
+
+	// then
+	assert.Equal(t, escrow.ErrNotRecoveryAgent, err)
+	assert.Nil(t, recovered)
+}
+
+func TestRecover_KeyIDMismatch(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	agent := generateKey(t)
+	record, err := escrow.Escrow(pri, []heimdall.PubKey{agent.PublicKey()})
+	assert.NoError(t, err)
+	record.KeyID = generateKey(t).ID()
+
+	// when
+	recovered, err := escrow.Recover(record, agent)
+
+	// then
+	assert.Equal(t, escrow.ErrKeyIDMismatch, err)
+	assert.Nil(t, recovered)
+}
+
+func TestKeyStore_StorePriKey(t *testing.T) {
+	// given
+	pri := generateKey(t)
+	agent := generateKey(t)
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyStore := escrow.NewKeyStore(
+		hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt),
+		[]heimdall.PubKey{agent.PublicKey()},
+		heimdall.TestKeyDir,
+	)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	err = keyStore.StorePriKey(pri, "password")
+
+	// then
+	assert.NoError(t, err)
+
+	loaded, err := keyStore.LoadPriKey(pri.ID(), "password")
+	assert.NoError(t, err)
+	assert.Equal(t, pri, loaded)
+
+	record, err := escrow.Load(pri.ID(), heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	recovered, err := escrow.Recover(record, agent)
+	assert.NoError(t, err)
+	assert.Equal(t, pri, recovered)
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides key lifecycle events, so that monitoring and SIEM integrations can react to identity changes.
+
+package event
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/iLogger"
+)
+
+// Type is a type of key lifecycle event.
+type Type string
+
+// key lifecycle event types
+const (
+	KeyCreated         Type = "KEY_CREATED"
+	KeyImported        Type = "KEY_IMPORTED"
+	KeyStored          Type = "KEY_STORED"
+	KeyLoaded          Type = "KEY_LOADED"
+	KeyUpgraded        Type = "KEY_UPGRADED"
+	KeyPasswordChanged Type = "KEY_PASSWORD_CHANGED"
+	KeyRotated         Type = "KEY_ROTATED"
+	KeyExpired         Type = "KEY_EXPIRED"
+	KeyDeleted         Type = "KEY_DELETED"
+	MessageSigned      Type = "MESSAGE_SIGNED"
+	CertIssued         Type = "CERT_ISSUED"
+	CertRevoked        Type = "CERT_REVOKED"
+)
+
+// Event is a key lifecycle event. For certificate events, KeyID is ID of the certified public key.
+type Event struct {
+	Type   Type
+	KeyID  heimdall.KeyID
+	Time   time.Time
+	Detail string
+}
+
+// Handler handles published events. Events are delivered synchronously, so handlers should return quickly.
+type Handler func(e *Event)
+
+type subscriber struct {
+	handler Handler
+	types   map[Type]bool
+}
+
+// Bus delivers published events to subscribers.
+type Bus struct {
+	mutex       sync.RWMutex
+	nextId      int
+	subscribers map[int]*subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{subscribers: make(map[int]*subscriber)}
+}
+
+// Subscribe registers handler for events of types (all events if types are empty), and returns a function cancelling it.
+func (bus *Bus) Subscribe(handler Handler, types ...Type) func() {
+	bus.mutex.Lock()
+	defer bus.mutex.Unlock()
+
+	typeMap := make(map[Type]bool)
+	for _, eventType := range types {
+		typeMap[eventType] = true
+	}
+
+	id := bus.nextId
+	bus.nextId++
+	bus.subscribers[id] = &subscriber{
+		handler: handler,
+		types:   typeMap,
+	}
+
+	return func() {
+		bus.mutex.Lock()
+		defer bus.mutex.Unlock()
+
+		delete(bus.subscribers, id)
+	}
+}
+
+// Publish delivers event to subscribers of its type. A panic in handler does not affect other handlers and the publisher.
+func (bus *Bus) Publish(e *Event) {
+	bus.mutex.RLock()
+	handlers := make([]Handler, 0, len(bus.subscribers))
+	for _, subscriber := range bus.subscribers {
+		if len(subscriber.types) == 0 || subscriber.types[e.Type] {
+			handlers = append(handlers, subscriber.handler)
+		}
+	}
+	bus.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		deliver(handler, e)
+	}
+}
+
+func deliver(handler Handler, e *Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			iLogger.Errorf(nil, "[Heimdall] panic in event handler - %v", r)
+		}
+	}()
+
+	handler(e)
+}
+
+// DefaultBus is the bus which heimdall packages publish events to.
+var DefaultBus = NewBus()
+
+// Subscribe registers handler on DefaultBus.
+func Subscribe(handler Handler, types ...Type) func() {
+	return DefaultBus.Subscribe(handler, types...)
+}
+
+// Publish publishes event of eventType on keyId to DefaultBus.
+func Publish(eventType Type, keyId heimdall.KeyID, detail string) {
+	DefaultBus.Publish(&Event{
+		Type:   eventType,
+		KeyID:  keyId,
+		Time:   time.Now(),
+		Detail: detail,
+	})
+}
@@ -0,0 +1,81 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package event_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBus_Subscribe(t *testing.T) {
+	// given
+	bus := event.NewBus()
+
+	var all, created []*event.Event
+	unsubscribeAll := bus.Subscribe(func(e *event.Event) { all = append(all, e) })
+	bus.Subscribe(func(e *event.Event) { created = append(created, e) }, event.KeyCreated)
+
+	// when
+	bus.Publish(&event.Event{Type: event.KeyCreated, KeyID: "IT1"})
+	bus.Publish(&event.Event{Type: event.KeyDeleted, KeyID: "IT1"})
+	unsubscribeAll()
+	bus.Publish(&event.Event{Type: event.KeyCreated, KeyID: "IT2"})
+
+	// then
+	assert.Len(t, all, 2)
+	assert.Len(t, created, 2)
+	assert.Equal(t, event.KeyDeleted, all[1].Type)
+	assert.Equal(t, "IT2", created[1].KeyID)
+}
+
+func TestBus_Publish_HandlerPanic(t *testing.T) {
+	// given
+	bus := event.NewBus()
+
+	delivered := 0
+	bus.Subscribe(func(e *event.Event) { panic("handler failure") })
+	bus.Subscribe(func(e *event.Event) { delivered++ })
+
+	// when
+	bus.Publish(&event.Event{Type: event.KeyLoaded})
+
+	// then
+	assert.Equal(t, 1, delivered)
+}
+
+func TestPublish_KeyCreated(t *testing.T) {
+	// given
+	var events []*event.Event
+	unsubscribe := event.Subscribe(func(e *event.Event) { events = append(events, e) }, event.KeyCreated)
+	defer unsubscribe()
+
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+
+	// when
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, pri.ID(), events[0].KeyID)
+	assert.False(t, events[0].Time.IsZero())
+}
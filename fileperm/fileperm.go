@@ -0,0 +1,138 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides platform independent functions for writing files only the owner can access.
+
+package fileperm
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/DE-labtory/iLogger"
+)
+
+var ErrInsecurePermission = errors.New("insecure permission - users other than owner can access the path")
+
+// WriteFile writes data to a file which only the owner can access.
+func WriteFile(path string, data []byte) error {
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+
+	return restrict(path, false)
+}
+
+// WriteFileAtomic writes data to a file which only the owner can access, through temporary file tempPath on the same
+// file system. The temporary file is synced to disk and renamed to path, and then the directory of path is synced,
+// so crash in the middle of writing leaves either the previous file or the new file, never a truncated file.
+func WriteFileAtomic(path, tempPath string, data []byte) error {
+	if err := writeFileSync(tempPath, data); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return err
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// writeFileSync writes data to a file which only the owner can access, and syncs it to disk.
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return restrict(path, false)
+}
+
+// MkdirAll makes a directory which only the owner can access, along with any necessary parents.
+func MkdirAll(path string) error {
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return err
+	}
+
+	return restrict(path, true)
+}
+
+// Restrict changes permission of path so that only the owner can access it.
+func Restrict(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	return restrict(path, info.IsDir())
+}
+
+// Check returns ErrInsecurePermission if users other than the owner can access path.
+func Check(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+
+	return check(path)
+}
+
+// warned records paths already warned, so that directories can be checked whenever they are opened.
+var warned sync.Map
+
+// WarnInsecure logs warning for each existing path which users other than the owner can access.
+// Each path is warned only once per process.
+func WarnInsecure(paths ...string) {
+	for _, path := range paths {
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+
+		err := Check(path)
+		if err == nil || os.IsNotExist(err) {
+			continue
+		}
+
+		if _, loaded := warned.LoadOrStore(path, true); loaded {
+			continue
+		}
+
+		if err == ErrInsecurePermission {
+			iLogger.Warnf(nil, "[Heimdall] %s is accessible by other users - restrict its permission", path)
+		} else {
+			iLogger.Warnf(nil, "[Heimdall] failed to check permission of %s - %s", path, err)
+		}
+	}
+}
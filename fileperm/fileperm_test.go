@@ -0,0 +1,92 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package fileperm_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFile(t *testing.T) {
+	// given
+	filePath := filepath.Join(heimdall.TestKeyDir, "key")
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	err := fileperm.MkdirAll(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	err = fileperm.WriteFile(filePath, []byte("secret"))
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, fileperm.Check(heimdall.TestKeyDir))
+	assert.NoError(t, fileperm.Check(filePath))
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	// given
+	filePath := filepath.Join(heimdall.TestKeyDir, "key")
+	tempPath := filePath + ".tmp"
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	err := fileperm.MkdirAll(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	err = fileperm.WriteFile(filePath, []byte("old secret"))
+	assert.NoError(t, err)
+
+	// when
+	err = fileperm.WriteFileAtomic(filePath, tempPath, []byte("secret"))
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, fileperm.Check(filePath))
+
+	data, err := ioutil.ReadFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("secret"), data)
+	_, err = os.Stat(tempPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCheck(t *testing.T) {
+	// given
+	filePath := filepath.Join(heimdall.TestKeyDir, "key")
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	assert.NoError(t, os.MkdirAll(heimdall.TestKeyDir, 0755))
+	assert.NoError(t, fileperm.WriteFile(filePath, []byte("secret")))
+	assert.NoError(t, os.Chmod(heimdall.TestKeyDir, 0755))
+
+	// when
+	insecureErr := fileperm.Check(heimdall.TestKeyDir)
+	err := fileperm.Restrict(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	restrictedErr := fileperm.Check(heimdall.TestKeyDir)
+	notExistErr := fileperm.Check(filepath.Join(heimdall.TestKeyDir, "not exist"))
+
+	// then
+	assert.Equal(t, fileperm.ErrInsecurePermission, insecureErr)
+	assert.NoError(t, restrictedErr)
+	assert.True(t, os.IsNotExist(notExistErr))
+}
@@ -0,0 +1,57 @@
+//go:build !windows
+// +build !windows
+
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides unix file modes restricting access to the owner.
+
+package fileperm
+
+import "os"
+
+func restrict(path string, isDir bool) error {
+	if isDir {
+		return os.Chmod(path, 0700)
+	}
+
+	return os.Chmod(path, 0600)
+}
+
+// syncDir syncs directory, so that renamed entry in the directory is durable.
+func syncDir(path string) error {
+	dir, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+
+	return dir.Sync()
+}
+
+func check(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if info.Mode().Perm()&0077 != 0 {
+		return ErrInsecurePermission
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+//go:build windows
+// +build windows
+
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Windows ACLs restricting access to the owner, because unix file modes are ignored on Windows.
+
+package fileperm
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	advapi32 = syscall.NewLazyDLL("advapi32.dll")
+	kernel32 = syscall.NewLazyDLL("kernel32.dll")
+
+	procConvertStringSDToSD = advapi32.NewProc("ConvertStringSecurityDescriptorToSecurityDescriptorW")
+	procConvertSDToStringSD = advapi32.NewProc("ConvertSecurityDescriptorToStringSecurityDescriptorW")
+	procSetFileSecurity     = advapi32.NewProc("SetFileSecurityW")
+	procGetFileSecurity     = advapi32.NewProc("GetFileSecurityW")
+	procLocalFree           = kernel32.NewProc("LocalFree")
+)
+
+const (
+	sddlRevision                     = 1
+	daclSecurityInformation          = 0x00000004
+	protectedDaclSecurityInformation = 0x80000000
+)
+
+// SIDs of groups which should not be allowed to access keys. (Everyone, Authenticated Users, Users, Guests)
+var publicSIDs = []string{"WD", "AU", "BU", "BG"}
+
+// ownerSID returns SID of the user running this process.
+func ownerSID() (string, error) {
+	token, err := syscall.OpenCurrentProcessToken()
+	if err != nil {
+		return "", err
+	}
+	defer token.Close()
+
+	user, err := token.GetTokenUser()
+	if err != nil {
+		return "", err
+	}
+
+	return user.User.Sid.String()
+}
+
+// restrict sets protected DACL which allows only the owner and SYSTEM, removing inherited ACEs.
+func restrict(path string, isDir bool) error {
+	sid, err := ownerSID()
+	if err != nil {
+		return err
+	}
+
+	inherit := ""
+	if isDir {
+		inherit = "OICI"
+	}
+
+	sddl, err := syscall.UTF16PtrFromString(fmt.Sprintf("D:P(A;%s;FA;;;%s)(A;%s;FA;;;SY)", inherit, sid, inherit))
+	if err != nil {
+		return err
+	}
+
+	var sd uintptr
+	ret, _, err := procConvertStringSDToSD.Call(uintptr(unsafe.Pointer(sddl)), sddlRevision, uintptr(unsafe.Pointer(&sd)), 0)
+	if ret == 0 {
+		return err
+	}
+	defer procLocalFree.Call(sd)
+
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+
+	ret, _, err = procSetFileSecurity.Call(uintptr(unsafe.Pointer(pathPtr)), daclSecurityInformation|protectedDaclSecurityInformation, sd)
+	if ret == 0 {
+		return err
+	}
+
+	return nil
+}
+
+// syncDir does nothing on Windows, where directories can not be opened for sync and rename is journaled by NTFS.
+func syncDir(path string) error {
+	return nil
+}
+
+// check reads DACL of path and finds ACE allowing access to public groups.
+func check(path string) error {
+	sddl, err := readDACL(path)
+	if err != nil {
+		return err
+	}
+
+	for _, ace := range strings.Split(sddl, "(")[1:] {
+		fields := strings.Split(strings.TrimSuffix(ace, ")"), ";")
+		if len(fields) < 6 || fields[0] != "A" {
+			continue
+		}
+
+		for _, sid := range publicSIDs {
+			if fields[5] == sid {
+				return ErrInsecurePermission
+			}
+		}
+	}
+
+	return nil
+}
+
+// readDACL returns DACL of path in SDDL format.
+func readDACL(path string) (string, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+
+	var needed uint32
+	procGetFileSecurity.Call(uintptr(unsafe.Pointer(pathPtr)), daclSecurityInformation, 0, 0, uintptr(unsafe.Pointer(&needed)))
+	if needed == 0 {
+		return "", syscall.EINVAL
+	}
+
+	sd := make([]byte, needed)
+	ret, _, err := procGetFileSecurity.Call(uintptr(unsafe.Pointer(pathPtr)), daclSecurityInformation, uintptr(unsafe.Pointer(&sd[0])), uintptr(needed), uintptr(unsafe.Pointer(&needed)))
+	if ret == 0 {
+		return "", err
+	}
+
+	var sddl *uint16
+	ret, _, err = procConvertSDToStringSD.Call(uintptr(unsafe.Pointer(&sd[0])), sddlRevision, daclSecurityInformation, uintptr(unsafe.Pointer(&sddl)), 0)
+	if ret == 0 {
+		return "", err
+	}
+	defer procLocalFree.Call(uintptr(unsafe.Pointer(sddl)))
+
+	buf := (*[1 << 20]uint16)(unsafe.Pointer(sddl))
+	length := 0
+	for buf[length] != 0 {
+		length++
+	}
+
+	return syscall.UTF16ToString(buf[:length:length]), nil
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides fingerprints of keys and certificates, rendered as hex or PGP words for out-of-band verification.
+
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var ErrUnknownWord = errors.New("unknown word - word is not in PGP word list")
+var ErrWordOrder = errors.New("wrong word order - word is at wrong position, words may be swapped or missing")
+
+// DefaultWordCount is the number of words (64 bits of fingerprint) which are read aloud during node onboarding.
+const DefaultWordCount = 8
+
+// Fingerprint is SHA-256 hash of public key (PKIX) or certificate (DER).
+type Fingerprint []byte
+
+// OfKey returns fingerprint of public key. Private key has the same fingerprint with its public key.
+func OfKey(key heimdall.Key) (Fingerprint, error) {
+	if pri, ok := key.(heimdall.PriKey); ok {
+		key = pri.PublicKey()
+	}
+
+	pubBytes, err := key.ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha256.Sum256(pubBytes)
+	return hash[:], nil
+}
+
+// OfCert returns fingerprint of certificate.
+func OfCert(cert *x509.Certificate) Fingerprint {
+	hash := sha256.Sum256(cert.Raw)
+	return hash[:]
+}
+
+// Hex returns upper case hex of fingerprint separated by colons. (ex. E5:82:94:F2...)
+func (fingerprint Fingerprint) Hex() string {
+	hexBytes := make([]string, len(fingerprint))
+	for i, b := range fingerprint {
+		hexBytes[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return strings.Join(hexBytes, ":")
+}
+
+// Words returns PGP words of the first count bytes of fingerprint. Whole fingerprint is rendered if count is not positive or exceeds it.
+func (fingerprint Fingerprint) Words(count int) []string {
+	if count <= 0 || count > len(fingerprint) {
+		count = len(fingerprint)
+	}
+
+	words := make([]string, count)
+	for i, b := range fingerprint[:count] {
+		if i%2 == 0 {
+			words[i] = evenWords[b]
+		} else {
+			words[i] = oddWords[b]
+		}
+	}
+
+	return words
+}
+
+func (fingerprint Fingerprint) String() string {
+	return strings.Join(fingerprint.Words(DefaultWordCount), " ")
+}
+
+// MatchWords checks if words read from peer are the PGP words of the beginning of fingerprint.
+func (fingerprint Fingerprint) MatchWords(words []string) bool {
+	parsed, err := ParseWords(words)
+	if err != nil || len(parsed) == 0 || len(parsed) > len(fingerprint) {
+		return false
+	}
+
+	for i := range parsed {
+		if parsed[i] != fingerprint[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseWords parses PGP words (case insensitive) back to fingerprint bytes.
+// Since even and odd positions use different lists, swapped or missing words are detected as ErrWordOrder.
+func ParseWords(words []string) (Fingerprint, error) {
+	fingerprint := make(Fingerprint, len(words))
+	for i, word := range words {
+		even, isEven := wordIndex(&evenWords, word)
+		odd, isOdd := wordIndex(&oddWords, word)
+
+		switch {
+		case i%2 == 0 && isEven:
+			fingerprint[i] = even
+		case i%2 == 1 && isOdd:
+			fingerprint[i] = odd
+		case isEven || isOdd:
+			return nil, ErrWordOrder
+		default:
+			return nil, ErrUnknownWord
+		}
+	}
+
+	return fingerprint, nil
+}
+
+func wordIndex(wordList *[256]string, word string) (byte, bool) {
+	for i, listWord := range wordList {
+		if strings.EqualFold(listWord, word) {
+			return byte(i), true
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,160 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package fingerprint_test
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/fingerprint"
+	"github.com/DE-labtory/heimdall/hbls"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+// example of PGP word list
+const exampleHex = "E58294F2E9A227486E8B061B31CC528FD7FA3F19"
+const exampleWords = "topmost Istanbul Pluto vagabond treadmill Pacific brackish dictator goldfish Medusa " +
+	"afflict bravado chatter revolver Dupont midsummer stopwatch whimsical cowbell bottomless"
+
+func TestFingerprint_Words(t *testing.T) {
+	// given
+	fpBytes, err := hex.DecodeString(exampleHex)
+	assert.NoError(t, err)
+	fp := fingerprint.Fingerprint(fpBytes)
+
+	// when
+	words := fp.Words(0)
+	shortWords := fp.Words(fingerprint.DefaultWordCount)
+
+	// then
+	assert.Equal(t, exampleWords, strings.Join(words, " "))
+	assert.Equal(t, strings.Fields(exampleWords)[:fingerprint.DefaultWordCount], shortWords)
+	assert.Equal(t, "E5:82:94:F2", fp[:4].Hex())
+}
+
+func TestParseWords(t *testing.T) {
+	// given
+	words := strings.Fields(strings.ToLower(exampleWords))
+	swapped := []string{words[1], words[0]}
+
+	// when
+	fp, err := fingerprint.ParseWords(words)
+	_, swappedErr := fingerprint.ParseWords(swapped)
+	_, unknownErr := fingerprint.ParseWords([]string{"heimdall"})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, exampleHex, strings.ToUpper(hex.EncodeToString(fp)))
+	assert.Equal(t, fingerprint.ErrWordOrder, swappedErr)
+	assert.Equal(t, fingerprint.ErrUnknownWord, unknownErr)
+}
+
+func TestOfKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	otherPri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	priFp, err := fingerprint.OfKey(pri)
+	assert.NoError(t, err)
+	pubFp, err := fingerprint.OfKey(pri.PublicKey())
+	assert.NoError(t, err)
+	otherFp, err := fingerprint.OfKey(otherPri)
+	assert.NoError(t, err)
+
+	// then
+	assert.Equal(t, priFp, pubFp)
+	assert.True(t, pubFp.MatchWords(strings.Fields(priFp.String())))
+	assert.False(t, pubFp.MatchWords(otherFp.Words(fingerprint.DefaultWordCount)))
+}
+
+func TestOfCert(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	template := mocks.TestCertTemplate
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, pri.(*hecdsa.PriKey).Public(), pri.(*hecdsa.PriKey))
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(derBytes)
+	assert.NoError(t, err)
+
+	// when
+	fp := fingerprint.OfCert(cert)
+
+	// then
+	assert.Len(t, fp, 32)
+	assert.Len(t, strings.Split(fp.Hex(), ":"), 32)
+}
+
+// Ed25519 public key of seed 00 01 02 ... 1f, and its fingerprints by ssh-keygen -l
+const sshTestPubHex = "03a107bff3ce10be1d70dd18e74bc09967e4d6309ba50d5f1ddc8664125531b8"
+const sshTestSHA256 = "SHA256:lbmsoA0yIEcEiVDRnMWuzm+nV+3ZEEpVIURqFoeSspg"
+const sshTestMD5 = "MD5:f1:02:17:de:ef:c1:30:83:11:8b:82:a0:0e:67:7f:d5"
+
+func TestSHA256(t *testing.T) {
+	// given
+	pubBytes, err := hex.DecodeString(sshTestPubHex)
+	assert.NoError(t, err)
+	pub := hed25519.NewPubKey(pubBytes)
+
+	// when
+	sha256Fp, err := fingerprint.SHA256(pub)
+	assert.NoError(t, err)
+	md5Fp, md5Err := fingerprint.MD5Colon(pub)
+
+	// then
+	assert.Equal(t, sshTestSHA256, sha256Fp)
+	assert.NoError(t, md5Err)
+	assert.Equal(t, sshTestMD5, md5Fp)
+}
+
+func TestSHA256_PriKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	blsKeyGenOpt, err := hbls.NewKeyGenOpt(hbls.BLS12381)
+	assert.NoError(t, err)
+	blsPri, err := hbls.GenerateKey(blsKeyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	priFp, err := fingerprint.SHA256(pri)
+	assert.NoError(t, err)
+	pubFp, err := fingerprint.SHA256(pri.PublicKey())
+	assert.NoError(t, err)
+	_, blsErr := fingerprint.SHA256(blsPri)
+
+	// then
+	assert.True(t, strings.HasPrefix(priFp, "SHA256:"))
+	assert.Equal(t, pubFp, priFp)
+	assert.Equal(t, fingerprint.ErrSSHKeyNotSupported, blsErr)
+}
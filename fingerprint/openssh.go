@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides OpenSSH style fingerprints of public keys, which are compared with output of ssh-keygen -l.
+
+package fingerprint
+
+import (
+	"crypto/x509"
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"golang.org/x/crypto/ssh"
+)
+
+var ErrSSHKeyNotSupported = errors.New("key not supported by SSH - key should be RSA, Ed25519 or ECDSA key on P-256, P-384 or P-521")
+
+// SHA256 returns OpenSSH style SHA-256 fingerprint of public key, which is unpadded base64 of SHA-256 hash of SSH wire
+// format of the key. (ex. SHA256:uNiVztksCsDhcc0u9e8BujQXVUpKZIDTMczCvj3tD2s)
+// TLS style fingerprint of the same key is colon separated hex of OfKey. (see Fingerprint.Hex)
+func SHA256(key heimdall.Key) (string, error) {
+	sshPub, err := toSSHPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return ssh.FingerprintSHA256(sshPub), nil
+}
+
+// MD5Colon returns legacy OpenSSH style MD5 fingerprint of public key, which is colon separated lower case hex
+// of MD5 hash of SSH wire format of the key, as shown by ssh-keygen -l -E md5. (ex. MD5:16:27:ac:a5:76:28:2d:36...)
+func MD5Colon(key heimdall.Key) (string, error) {
+	sshPub, err := toSSHPublicKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	return "MD5:" + ssh.FingerprintLegacyMD5(sshPub), nil
+}
+
+// toSSHPublicKey converts public key, or public key of private key, to SSH public key.
+func toSSHPublicKey(key heimdall.Key) (ssh.PublicKey, error) {
+	if pri, ok := key.(heimdall.PriKey); ok {
+		key = pri.PublicKey()
+	}
+
+	pubBytes, err := key.ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(pubBytes)
+	if err != nil {
+		return nil, ErrSSHKeyNotSupported
+	}
+
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, ErrSSHKeyNotSupported
+	}
+
+	return sshPub, nil
+}
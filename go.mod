@@ -1,11 +1,34 @@
 module github.com/DE-labtory/heimdall
 
+go 1.20
+
 require (
 	github.com/DE-labtory/iLogger v0.0.0-20180921150123-3d4855e59818
+	github.com/aws/aws-sdk-go-v2 v1.26.0
+	github.com/aws/aws-sdk-go-v2/service/kms v1.30.0
 	github.com/btcsuite/btcutil v0.0.0-20180706230648-ab6388e0c60a
+	github.com/cloudflare/circl v1.3.7
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.2.0
+	github.com/go-piv/piv-go v1.11.0
+	github.com/google/go-tpm v0.9.0
+	github.com/kilic/bls12-381 v0.1.0
+	github.com/stretchr/testify v1.2.2
+	github.com/syndtr/goleveldb v1.0.0
+	github.com/tjfoc/gmsm v1.4.1
+	github.com/tyler-smith/go-bip39 v1.1.0
+	golang.org/x/crypto v0.17.0
+	software.sslmate.com/src/go-pkcs12 v0.5.0
+)
+
+require (
+	github.com/aws/aws-sdk-go-v2/internal/configsources v1.3.4 // indirect
+	github.com/aws/aws-sdk-go-v2/internal/endpoints/v2 v2.6.4 // indirect
+	github.com/aws/smithy-go v1.20.1 // indirect
+	github.com/davecgh/go-spew v1.1.1 // indirect
+	github.com/golang/snappy v0.0.0-20180518054509-2e65f85255db // indirect
 	github.com/konsorten/go-windows-terminal-sequences v1.0.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/sirupsen/logrus v1.1.1 // indirect
-	github.com/stretchr/testify v1.2.2
-	golang.org/x/crypto v0.0.0-20180910181607-0e37d006457b
-	golang.org/x/sys v0.0.0-20181019160139-8e24a49d80f8 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+	golang.org/x/term v0.15.0 // indirect
 )
@@ -20,23 +20,116 @@
 package hashing
 
 import (
+	"crypto/sha256"
 	"crypto/sha512"
 	"errors"
 	"hash"
+	"sync"
+
+	"github.com/tjfoc/gmsm/sm3"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/blake2s"
+	"golang.org/x/crypto/sha3"
 )
 
 const (
+	// SHA224 and SHA256 are SHA-512/224 and SHA-512/256 for historical reasons. SHA2_256 is SHA-256 of FIPS 180-4.
 	SHA224 = "SHA224"
 	SHA256 = "SHA256"
 	SHA384 = "SHA384"
 	SHA512 = "SHA512"
+
+	SHA2_256 = "SHA2-256"
+
+	SHA3_256    = "SHA3-256"
+	SHA3_384    = "SHA3-384"
+	SHA3_512    = "SHA3-512"
+	BLAKE2B_256 = "BLAKE2B-256"
+	BLAKE2B_384 = "BLAKE2B-384"
+	BLAKE2B_512 = "BLAKE2B-512"
+	BLAKE2S_256 = "BLAKE2S-256"
+
+	// BLAKE2S_128 is available only as keyed hash (MAC). (see NewKeyedHashOpt)
+	BLAKE2S_128 = "BLAKE2S-128"
+
+	// SM3 is hash function of Chinese national standard (GM/T 0004), used with SM2 signature.
+	SM3 = "SM3"
 )
 
 var ErrNotSupportedHashFunc = errors.New("not supported hash function")
+var ErrNilHashFunc = errors.New("nil hash function")
+var ErrEmptyHashKey = errors.New("empty hash key - key of keyed hash should not be empty")
+var ErrKeyedHashNotSupported = errors.New("keyed hash not supported - hash function should be BLAKE2b or BLAKE2s")
+
+// hashFuncs maps hash option names to implementations. SHA-2 of standard library and SHA-3 and BLAKE2b of x/crypto
+// select assembly implementations (ex. AVX2, SHA-NI, ARMv8 SHA2) by runtime CPU detection.
+var hashFuncs = map[string]func() hash.Hash{
+	SHA224:      sha512.New512_224,
+	SHA256:      sha512.New512_256,
+	SHA384:      sha512.New384,
+	SHA512:      sha512.New,
+	SHA2_256:    sha256.New,
+	SHA3_256:    sha3.New256,
+	SHA3_384:    sha3.New384,
+	SHA3_512:    sha3.New512,
+	BLAKE2B_256: newBlake2b256,
+	BLAKE2B_384: newBlake2b384,
+	BLAKE2B_512: newBlake2b512,
+	BLAKE2S_256: newBlake2s256,
+	SM3:         sm3.New,
+}
+var hashFuncsMutex = &sync.RWMutex{}
+
+func newBlake2b256() hash.Hash {
+	hashFunc, _ := blake2b.New256(nil)
+	return hashFunc
+}
+
+func newBlake2b384() hash.Hash {
+	hashFunc, _ := blake2b.New384(nil)
+	return hashFunc
+}
+
+func newBlake2b512() hash.Hash {
+	hashFunc, _ := blake2b.New512(nil)
+	return hashFunc
+}
+
+func newBlake2s256() hash.Hash {
+	hashFunc, _ := blake2s.New256(nil)
+	return hashFunc
+}
+
+// keyedHashFuncs maps hash option names to implementations of keyed mode, which makes MAC without HMAC construction.
+// Key should be at most 64 bytes for BLAKE2b and 32 bytes for BLAKE2s.
+var keyedHashFuncs = map[string]func(key []byte) (hash.Hash, error){
+	BLAKE2B_256: blake2b.New256,
+	BLAKE2B_384: blake2b.New384,
+	BLAKE2B_512: blake2b.New512,
+	BLAKE2S_256: blake2s.New256,
+	BLAKE2S_128: blake2s.New128,
+}
+
+// RegisterHashFunc registers implementation of hash function by name, replacing the existing one.
+// Nodes whose profile is dominated by hashing can register an optimized backend, typically in init of a file
+// behind a build tag. The implementation should produce the same digest as the one it replaces.
+func RegisterHashFunc(name string, hashFunc func() hash.Hash) error {
+	if hashFunc == nil {
+		return ErrNilHashFunc
+	}
+
+	hashFuncsMutex.Lock()
+	defer hashFuncsMutex.Unlock()
+
+	hashFuncs[name] = hashFunc
+
+	return nil
+}
 
 type HashOpt struct {
 	Name     string
 	HashFunc func() hash.Hash
+	keyed    bool
 }
 
 func NewHashOpt(name string) (*HashOpt, error) {
@@ -45,19 +138,50 @@ func NewHashOpt(name string) (*HashOpt, error) {
 }
 
 func (opt *HashOpt) initHashOpt(name string) error {
-	switch name {
-	case "SHA224":
-		opt.HashFunc = sha512.New512_224
-	case "SHA256":
-		opt.HashFunc = sha512.New512_256
-	case "SHA384":
-		opt.HashFunc = sha512.New384
-	case "SHA512":
-		opt.HashFunc = sha512.New
-	default:
+	hashFuncsMutex.RLock()
+	hashFunc, ok := hashFuncs[name]
+	hashFuncsMutex.RUnlock()
+
+	if !ok {
 		return ErrNotSupportedHashFunc
 	}
+	opt.HashFunc = hashFunc
 	opt.Name = name
 
 	return nil
 }
+
+// NewKeyedHashOpt makes hash option of BLAKE2 in keyed mode, whose digest is MAC of data with key.
+// Keyed hash is faster than HMAC, since BLAKE2 processes key in the first block instead of hashing twice.
+func NewKeyedHashOpt(name string, key []byte) (*HashOpt, error) {
+	keyedHashFunc, ok := keyedHashFuncs[name]
+	if !ok {
+		return nil, ErrKeyedHashNotSupported
+	}
+
+	if len(key) == 0 {
+		return nil, ErrEmptyHashKey
+	}
+
+	// check key length once, so that HashFunc does not fail
+	if _, err := keyedHashFunc(key); err != nil {
+		return nil, err
+	}
+
+	keyCopy := make([]byte, len(key))
+	copy(keyCopy, key)
+
+	return &HashOpt{
+		Name: name,
+		HashFunc: func() hash.Hash {
+			hashFunc, _ := keyedHashFunc(keyCopy)
+			return hashFunc
+		},
+		keyed: true,
+	}, nil
+}
+
+// IsKeyed checks if hash option is made by NewKeyedHashOpt.
+func (opt *HashOpt) IsKeyed() bool {
+	return opt.keyed
+}
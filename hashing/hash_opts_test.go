@@ -17,6 +17,9 @@
 package hashing_test
 
 import (
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
 	"testing"
 
 	"github.com/DE-labtory/heimdall/hashing"
@@ -34,3 +37,102 @@ func TestNewHashOpt(t *testing.T) {
 	assert.Equal(t, strHash, hashOpt.Name)
 	assert.NoError(t, err)
 }
+
+func TestNewHashOpt_Accelerated(t *testing.T) {
+	tests := map[string]string{
+		hashing.SHA3_256:    "3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532",
+		hashing.BLAKE2B_256: "bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319",
+		hashing.BLAKE2B_384: "6f56a82c8e7ef526dfe182eb5212f7db9df1317e57815dbda46083fc30f54ee6c66ba83be64b302d7cba6ce15bb556f4",
+		hashing.BLAKE2S_256: "508c5e8c327c14e2e1a72ba34eeb452f37458b209ed63a294d999b4c86675982",
+	}
+
+	for name, expected := range tests {
+		t.Logf("running test case [%s]", name)
+
+		// given
+		hashOpt, err := hashing.NewHashOpt(name)
+		assert.NoError(t, err)
+
+		// when
+		digest, err := hashing.Hash([]byte("abc"), hashOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, expected, hex.EncodeToString(digest))
+	}
+}
+
+func TestNewHashOpt_SM3(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SM3)
+	assert.NoError(t, err)
+
+	// when
+	digest, err := hashing.Hash([]byte("abc"), hashOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "66c7f0f462eeedd9d1f2d46bdc10e4e24167c4875cf2f7a2297da02b8f4ba8e0", hex.EncodeToString(digest))
+}
+
+func TestNewKeyedHashOpt(t *testing.T) {
+	tests := map[string]string{
+		hashing.BLAKE2B_256: "66c28e9d1dcd69d6756fc52125fe1838cf0c6a87d058545a9ff676bf51beaa6f",
+		hashing.BLAKE2S_256: "681292b7430869b63cc287afa24131975d06df4b130b5cd463f12a2e10b1ac2f",
+		hashing.BLAKE2S_128: "1c4572c125284d4d3f6b4c525d26e0e0",
+	}
+
+	for name, expected := range tests {
+		t.Logf("running test case [%s]", name)
+
+		// given
+		hashOpt, err := hashing.NewKeyedHashOpt(name, []byte("secret key"))
+		assert.NoError(t, err)
+
+		// when
+		digest, err := hashing.Hash([]byte("abc"), hashOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.True(t, hashOpt.IsKeyed())
+		assert.Equal(t, expected, hex.EncodeToString(digest))
+	}
+}
+
+func TestNewKeyedHashOpt_Invalid(t *testing.T) {
+	// when
+	_, notSupportedErr := hashing.NewKeyedHashOpt(hashing.SHA384, []byte("secret key"))
+	_, emptyKeyErr := hashing.NewKeyedHashOpt(hashing.BLAKE2B_256, nil)
+	_, longKeyErr := hashing.NewKeyedHashOpt(hashing.BLAKE2S_256, make([]byte, 33))
+	_, unkeyedErr := hashing.NewHashOpt(hashing.BLAKE2S_128)
+
+	// then
+	assert.Equal(t, hashing.ErrKeyedHashNotSupported, notSupportedErr)
+	assert.Equal(t, hashing.ErrEmptyHashKey, emptyKeyErr)
+	assert.Error(t, longKeyErr)
+	assert.Equal(t, hashing.ErrNotSupportedHashFunc, unkeyedErr)
+}
+
+func TestRegisterHashFunc(t *testing.T) {
+	// given
+	backendCalled := false
+	backend := func() hash.Hash {
+		backendCalled = true
+		return sha512.New384()
+	}
+
+	// when
+	err := hashing.RegisterHashFunc(hashing.SHA384, backend)
+	defer hashing.RegisterHashFunc(hashing.SHA384, sha512.New384)
+	nilErr := hashing.RegisterHashFunc(hashing.SHA384, nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hashing.ErrNilHashFunc, nilErr)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	_, err = hashing.Hash([]byte("abc"), hashOpt)
+	assert.NoError(t, err)
+	assert.True(t, backendCalled)
+}
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides incremental hashing whose state can be exported and restored,
+// so that hashing of huge data such as ledgers can checkpoint and resume across process restarts.
+
+package hashing
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"hash"
+	"io/ioutil"
+
+	"github.com/DE-labtory/heimdall/fileperm"
+)
+
+var ErrStateNotSupported = errors.New("state not supported - hash function can not export its state")
+var ErrInvalidHashState = errors.New("invalid hash state")
+
+// Hasher hashes data written incrementally, and counts written bytes so that data can be resumed from the offset.
+type Hasher struct {
+	opt     *HashOpt
+	hash    hash.Hash
+	written uint64
+}
+
+// hashState is exported state of Hasher.
+type hashState struct {
+	Name    string
+	Written uint64
+	State   []byte
+}
+
+// NewHasher returns incremental hasher of hash option.
+func NewHasher(opt *HashOpt) *Hasher {
+	return &Hasher{
+		opt:  opt,
+		hash: opt.HashFunc(),
+	}
+}
+
+// Write adds data to running hash.
+func (hasher *Hasher) Write(data []byte) (int, error) {
+	n, err := hasher.hash.Write(data)
+	hasher.written += uint64(n)
+
+	return n, err
+}
+
+// Sum returns hash of written data, without changing the running hash.
+func (hasher *Hasher) Sum() []byte {
+	return hasher.hash.Sum(nil)
+}
+
+// Written returns number of bytes written, which is the offset to resume writing data from.
+func (hasher *Hasher) Written() uint64 {
+	return hasher.written
+}
+
+// MarshalBinary exports state of hasher, using MarshalBinary of the underlying hash.
+// State of keyed hash is not exported, since it contains the key.
+func (hasher *Hasher) MarshalBinary() ([]byte, error) {
+	if hasher.opt.IsKeyed() {
+		return nil, ErrStateNotSupported
+	}
+
+	marshaler, ok := hasher.hash.(encoding.BinaryMarshaler)
+	if !ok {
+		return nil, ErrStateNotSupported
+	}
+
+	state, err := marshaler.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(&hashState{
+		Name:    hasher.opt.Name,
+		Written: hasher.written,
+		State:   state,
+	})
+}
+
+// UnmarshalBinary restores state exported by MarshalBinary.
+func (hasher *Hasher) UnmarshalBinary(data []byte) error {
+	state := &hashState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return ErrInvalidHashState
+	}
+
+	opt, err := NewHashOpt(state.Name)
+	if err != nil {
+		return err
+	}
+
+	restored := opt.HashFunc()
+	unmarshaler, ok := restored.(encoding.BinaryUnmarshaler)
+	if !ok {
+		return ErrStateNotSupported
+	}
+
+	if err := unmarshaler.UnmarshalBinary(state.State); err != nil {
+		return ErrInvalidHashState
+	}
+
+	hasher.opt = opt
+	hasher.hash = restored
+	hasher.written = state.Written
+
+	return nil
+}
+
+// RestoreHasher returns hasher of state exported by MarshalBinary.
+func RestoreHasher(state []byte) (*Hasher, error) {
+	hasher := &Hasher{}
+	if err := hasher.UnmarshalBinary(state); err != nil {
+		return nil, err
+	}
+
+	return hasher, nil
+}
+
+// Checkpoint stores state of hasher into a file. The file is replaced by rename,
+// so that a crash during checkpoint does not break the previous checkpoint.
+func (hasher *Hasher) Checkpoint(path string) error {
+	state, err := hasher.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	return fileperm.WriteFileAtomic(path, path+".tmp", state)
+}
+
+// ResumeHasher returns hasher of state stored by Checkpoint.
+func ResumeHasher(path string) (*Hasher, error) {
+	state, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return RestoreHasher(state)
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hashing_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasher_MarshalBinary(t *testing.T) {
+	// given
+	data := []byte("This data will be hashed across process restarts")
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	digest, err := hashing.Hash(data, hashOpt)
+	assert.NoError(t, err)
+
+	hasher := hashing.NewHasher(hashOpt)
+	hasher.Write(data[:10])
+
+	// when
+	state, err := hasher.MarshalBinary()
+
+	// then
+	assert.NoError(t, err)
+
+	restored, err := hashing.RestoreHasher(state)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(10), restored.Written())
+	restored.Write(data[restored.Written():])
+	assert.Equal(t, digest, restored.Sum())
+
+	_, err = hashing.RestoreHasher([]byte("{\"Name\":\"SHA384\",\"State\":\"AAAA\"}"))
+	assert.Equal(t, hashing.ErrInvalidHashState, err)
+}
+
+func TestHasher_Checkpoint(t *testing.T) {
+	// given
+	data := []byte("This data will be hashed across process restarts")
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	digest, err := hashing.Hash(data, hashOpt)
+	assert.NoError(t, err)
+
+	assert.NoError(t, os.MkdirAll(heimdall.TestKeyDir, 0700))
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	checkpointPath := filepath.Join(heimdall.TestKeyDir, "ledger.hashstate")
+
+	hasher := hashing.NewHasher(hashOpt)
+	hasher.Write(data[:20])
+
+	// when
+	err = hasher.Checkpoint(checkpointPath)
+
+	// then
+	assert.NoError(t, err)
+
+	resumed, err := hashing.ResumeHasher(checkpointPath)
+	assert.NoError(t, err)
+	resumed.Write(data[resumed.Written():])
+	assert.Equal(t, digest, resumed.Sum())
+}
+
+func TestHasher_MarshalBinary_Keyed(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewKeyedHashOpt(hashing.BLAKE2B_256, []byte("secret key"))
+	assert.NoError(t, err)
+	hasher := hashing.NewHasher(hashOpt)
+	hasher.Write([]byte("data"))
+
+	// when
+	_, err = hasher.MarshalBinary()
+
+	// then
+	assert.Equal(t, hashing.ErrStateNotSupported, err)
+}
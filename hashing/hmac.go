@@ -0,0 +1,93 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides HMAC with hash options, so that messages and files can be authenticated
+// with the same option system used for hashing.
+
+package hashing
+
+import (
+	"crypto/hmac"
+	"errors"
+	"hash"
+)
+
+var ErrEmptyHMACKey = errors.New("empty hmac key - key should not be empty")
+
+// HMAC computes HMAC of data with key, using hash function of hash option.
+func HMAC(data, key []byte, opt *HashOpt) ([]byte, error) {
+	if data == nil {
+		return nil, ErrTargetDataNil
+	}
+
+	writer, err := NewHMACWriter(key, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	writer.Write(data)
+	return writer.Sum(), nil
+}
+
+// VerifyHMAC checks if mac is HMAC of data with key in constant time.
+func VerifyHMAC(data, key, mac []byte, opt *HashOpt) (bool, error) {
+	expected, err := HMAC(data, key, opt)
+	if err != nil {
+		return false, err
+	}
+
+	return hmac.Equal(expected, mac), nil
+}
+
+// HMACWriter computes HMAC of data written incrementally, so that large data such as files can be authenticated
+// without loading it into memory. (ex. io.Copy(writer, file))
+type HMACWriter struct {
+	opt *HashOpt
+	mac hash.Hash
+}
+
+// NewHMACWriter returns HMAC writer of key and hash option.
+func NewHMACWriter(key []byte, opt *HashOpt) (*HMACWriter, error) {
+	if len(key) == 0 {
+		return nil, ErrEmptyHMACKey
+	}
+
+	return &HMACWriter{
+		opt: opt,
+		mac: hmac.New(opt.HashFunc, key),
+	}, nil
+}
+
+// Write adds data to running HMAC.
+func (writer *HMACWriter) Write(data []byte) (int, error) {
+	return writer.mac.Write(data)
+}
+
+// Sum returns HMAC of written data, without changing the running HMAC.
+func (writer *HMACWriter) Sum() []byte {
+	return writer.mac.Sum(nil)
+}
+
+// Verify checks if mac is HMAC of written data in constant time.
+func (writer *HMACWriter) Verify(mac []byte) bool {
+	return hmac.Equal(writer.Sum(), mac)
+}
+
+// Reset clears written data, keeping the key.
+func (writer *HMACWriter) Reset() {
+	writer.mac.Reset()
+}
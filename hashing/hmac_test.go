@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hashing_test
+
+import (
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHMAC(t *testing.T) {
+	// RFC 4231, test case 2
+	tests := map[string]string{
+		hashing.SHA384: "af45d2e376484031617f78d2b58a6b1b9c7ef464f5a01b47e42ec3736322445e8e2240ca5e69e2c78b3239ecfab21649",
+		hashing.SHA512: "164b7a7bfcf819e2e395fbe73b56e0a387bd64222e831fd610270cd7ea2505549758bf75c05a994a6d034f65f8f0e6fdcaeab1a34d4a6b4b636e070a38bce737",
+	}
+
+	for name, expected := range tests {
+		t.Logf("running test case [%s]", name)
+
+		// given
+		hashOpt, err := hashing.NewHashOpt(name)
+		assert.NoError(t, err)
+
+		// when
+		mac, err := hashing.HMAC([]byte("what do ya want for nothing?"), []byte("Jefe"), hashOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, expected, hex.EncodeToString(mac))
+	}
+}
+
+func TestHMAC_Invalid(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+
+	// when
+	_, nilDataErr := hashing.HMAC(nil, []byte("key"), hashOpt)
+	_, emptyKeyErr := hashing.HMAC([]byte("data"), nil, hashOpt)
+
+	// then
+	assert.Equal(t, hashing.ErrTargetDataNil, nilDataErr)
+	assert.Equal(t, hashing.ErrEmptyHMACKey, emptyKeyErr)
+}
+
+func TestVerifyHMAC(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	key := []byte("key")
+	mac, err := hashing.HMAC([]byte("data"), key, hashOpt)
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hashing.VerifyHMAC([]byte("data"), key, mac, hashOpt)
+	tampered, tamperedErr := hashing.VerifyHMAC([]byte("date"), key, mac, hashOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.NoError(t, tamperedErr)
+	assert.False(t, tampered)
+}
+
+func TestHMACWriter(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA3_256)
+	assert.NoError(t, err)
+	key := []byte("key")
+	data := strings.Repeat("large data of keystore file ", 1000)
+	mac, err := hashing.HMAC([]byte(data), key, hashOpt)
+	assert.NoError(t, err)
+
+	writer, err := hashing.NewHMACWriter(key, hashOpt)
+	assert.NoError(t, err)
+
+	// when
+	_, err = io.Copy(writer, strings.NewReader(data))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, mac, writer.Sum())
+	assert.True(t, writer.Verify(mac))
+
+	writer.Reset()
+	assert.False(t, writer.Verify(mac))
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Merkle tree of RFC 6962, with inclusion proofs of leaves.
+// Leaf and node hashes are prefixed with different bytes, so that a node can not be presented as a leaf.
+
+package merkle
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/DE-labtory/heimdall/hashing"
+)
+
+var ErrEmptyLeaves = errors.New("empty leaves - merkle tree should have at least one leaf")
+var ErrNilHashOpt = errors.New("nil hash option - hash option should be specified")
+var ErrLeafIndexOutOfRange = errors.New("leaf index out of range - index should be less than number of leaves")
+var ErrInvalidProof = errors.New("invalid proof - proof does not match the number of leaves")
+
+const (
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
+)
+
+// Tree is a Merkle tree holding hashes of every level, from leaf hashes to root.
+// The last node of a level without sibling is promoted to the next level, as in RFC 6962.
+type Tree struct {
+	opt    *hashing.HashOpt
+	levels [][][]byte
+}
+
+// Proof is an inclusion proof of a leaf, with hashes of siblings from the leaf level to the root.
+type Proof struct {
+	Index     int
+	LeafCount int
+	Hashes    [][]byte
+}
+
+// NewTree builds Merkle tree of leaves, using hash function of hash option.
+func NewTree(leaves [][]byte, opt *hashing.HashOpt) (*Tree, error) {
+	if len(leaves) == 0 {
+		return nil, ErrEmptyLeaves
+	}
+
+	if opt == nil {
+		return nil, ErrNilHashOpt
+	}
+
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = LeafHash(leaf, opt)
+	}
+
+	levels := [][][]byte{level}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+				continue
+			}
+
+			next = append(next, nodeHash(level[i], level[i+1], opt))
+		}
+
+		levels = append(levels, next)
+		level = next
+	}
+
+	return &Tree{
+		opt:    opt,
+		levels: levels,
+	}, nil
+}
+
+// Root computes Merkle root of leaves without keeping the tree.
+func Root(leaves [][]byte, opt *hashing.HashOpt) ([]byte, error) {
+	tree, err := NewTree(leaves, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return tree.Root(), nil
+}
+
+// Root returns Merkle root of the tree.
+func (tree *Tree) Root() []byte {
+	return tree.levels[len(tree.levels)-1][0]
+}
+
+// LeafCount returns number of leaves of the tree.
+func (tree *Tree) LeafCount() int {
+	return len(tree.levels[0])
+}
+
+// Proof makes inclusion proof of leaf at index.
+func (tree *Tree) Proof(index int) (*Proof, error) {
+	if index < 0 || index >= tree.LeafCount() {
+		return nil, ErrLeafIndexOutOfRange
+	}
+
+	hashes := make([][]byte, 0, len(tree.levels)-1)
+	position := index
+	for _, level := range tree.levels[:len(tree.levels)-1] {
+		sibling := position ^ 1
+		if sibling < len(level) {
+			hashes = append(hashes, level[sibling])
+		}
+
+		position /= 2
+	}
+
+	return &Proof{
+		Index:     index,
+		LeafCount: tree.LeafCount(),
+		Hashes:    hashes,
+	}, nil
+}
+
+// VerifyProof verifies that leaf is included in the tree of root, following the algorithm of RFC 9162 2.1.3.2.
+func VerifyProof(root, leaf []byte, proof *Proof, opt *hashing.HashOpt) (bool, error) {
+	if opt == nil {
+		return false, ErrNilHashOpt
+	}
+
+	if proof == nil || proof.Index < 0 || proof.Index >= proof.LeafCount {
+		return false, ErrInvalidProof
+	}
+
+	position := proof.Index
+	last := proof.LeafCount - 1
+	hash := LeafHash(leaf, opt)
+	for _, sibling := range proof.Hashes {
+		if last == 0 {
+			return false, ErrInvalidProof
+		}
+
+		if position%2 == 1 || position == last {
+			hash = nodeHash(sibling, hash, opt)
+
+			// skip levels where the node is promoted without sibling
+			for position%2 == 0 && position != 0 {
+				position /= 2
+				last /= 2
+			}
+		} else {
+			hash = nodeHash(hash, sibling, opt)
+		}
+
+		position /= 2
+		last /= 2
+	}
+
+	if last != 0 {
+		return false, ErrInvalidProof
+	}
+
+	return bytes.Equal(hash, root), nil
+}
+
+// LeafHash returns hash of leaf data prefixed with leaf marker.
+func LeafHash(leaf []byte, opt *hashing.HashOpt) []byte {
+	hashFunc := opt.HashFunc()
+	hashFunc.Write([]byte{leafPrefix})
+	hashFunc.Write(leaf)
+
+	return hashFunc.Sum(nil)
+}
+
+// nodeHash returns hash of two child hashes prefixed with node marker.
+func nodeHash(left, right []byte, opt *hashing.HashOpt) []byte {
+	hashFunc := opt.HashFunc()
+	hashFunc.Write([]byte{nodePrefix})
+	hashFunc.Write(left)
+	hashFunc.Write(right)
+
+	return hashFunc.Sum(nil)
+}
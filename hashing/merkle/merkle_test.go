@@ -0,0 +1,137 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package merkle_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hashing/merkle"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoot(t *testing.T) {
+	tests := map[string]struct {
+		leaves   [][]byte
+		expected string
+	}{
+		"three leaves": {
+			leaves:   [][]byte{[]byte("a"), []byte("b"), []byte("c")},
+			expected: "f558be2464938cd3266e7a25cc876fd6697768c8a8d312fb7013d006ab98686937d89ad92547b429ad69264c11fc538b",
+		},
+		"seven leaves": {
+			leaves:   [][]byte{{0}, {1}, {2}, {3}, {4}, {5}, {6}},
+			expected: "d4106461fe9644b746963e85073baa102d8668d0c4a596e8aa04d5da079d542d3c54183a76b6b080ca750c1e7310f576",
+		},
+	}
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	for testName, test := range tests {
+		t.Logf("running test case [%s]", testName)
+
+		// when
+		root, err := merkle.Root(test.leaves, hashOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, hex.EncodeToString(root))
+	}
+}
+
+func TestNewTree_EmptyLeaves(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	// when
+	tree, err := merkle.NewTree(nil, hashOpt)
+
+	// then
+	assert.Nil(t, tree)
+	assert.Equal(t, merkle.ErrEmptyLeaves, err)
+}
+
+func TestVerifyProof(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	for count := 1; count <= 9; count++ {
+		leaves := make([][]byte, count)
+		for i := range leaves {
+			leaves[i] = []byte{byte(i)}
+		}
+
+		tree, err := merkle.NewTree(leaves, hashOpt)
+		assert.NoError(t, err)
+
+		for index, leaf := range leaves {
+			proof, err := tree.Proof(index)
+			assert.NoError(t, err)
+
+			// when
+			valid, err := merkle.VerifyProof(tree.Root(), leaf, proof, hashOpt)
+			tampered, tamperedErr := merkle.VerifyProof(tree.Root(), []byte("tampered"), proof, hashOpt)
+
+			// then
+			assert.NoError(t, err)
+			assert.True(t, valid, "leaf %d of %d", index, count)
+			assert.NoError(t, tamperedErr)
+			assert.False(t, tampered)
+		}
+	}
+}
+
+func TestVerifyProof_WrongLeafCount(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	leaves := [][]byte{{0}, {1}, {2}, {3}, {4}}
+
+	tree, err := merkle.NewTree(leaves, hashOpt)
+	assert.NoError(t, err)
+	proof, err := tree.Proof(4)
+	assert.NoError(t, err)
+	proof.LeafCount = 8
+
+	// when
+	valid, err := merkle.VerifyProof(tree.Root(), leaves[4], proof, hashOpt)
+
+	// then
+	assert.False(t, valid)
+	assert.Equal(t, merkle.ErrInvalidProof, err)
+}
+
+func TestTree_Proof_OutOfRange(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+
+	tree, err := merkle.NewTree([][]byte{[]byte("a")}, hashOpt)
+	assert.NoError(t, err)
+
+	// when
+	proof, err := tree.Proof(1)
+
+	// then
+	assert.Nil(t, proof)
+	assert.Equal(t, merkle.ErrLeafIndexOutOfRange, err)
+}
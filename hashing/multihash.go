@@ -0,0 +1,122 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides multihash encoding of digests, so that stored digests and identifiers tell their hash function.
+// (see https://multiformats.io/multihash)
+
+package hashing
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+var ErrNoMultihashCode = errors.New("no multihash code - hash function should have multihash code and not be keyed")
+var ErrInvalidMultihash = errors.New("invalid multihash - multihash should be varint code, varint length and digest of the length")
+
+// multihashCodes maps hash option names to codes of multicodec table.
+var multihashCodes = map[string]uint64{
+	SHA2_256:    0x12,
+	SHA512:      0x13,
+	SHA3_512:    0x14,
+	SHA3_384:    0x15,
+	SHA3_256:    0x16,
+	SHA384:      0x20,
+	SHA224:      0x1014,
+	SHA256:      0x1015,
+	BLAKE2B_256: 0xb220,
+	BLAKE2B_384: 0xb230,
+	BLAKE2B_512: 0xb240,
+	BLAKE2S_256: 0xb260,
+	SM3:         0x534d,
+}
+
+// HashMultihash hashes data like Hash, and returns the digest in multihash format.
+func HashMultihash(data []byte, opt *HashOpt) ([]byte, error) {
+	if opt.IsKeyed() {
+		return nil, ErrNoMultihashCode
+	}
+
+	digest, err := Hash(data, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	return EncodeMultihash(opt.Name, digest)
+}
+
+// EncodeMultihash encodes digest made by hash function of name in multihash format.
+// Digest may be truncated, such as SKI of 20 bytes from SHA-256.
+func EncodeMultihash(name string, digest []byte) ([]byte, error) {
+	code, ok := multihashCodes[name]
+	if !ok {
+		return nil, ErrNoMultihashCode
+	}
+
+	if len(digest) == 0 || len(digest) > digestSizeOf(name) {
+		return nil, ErrInvalidMultihash
+	}
+
+	multihash := make([]byte, 0, 2*binary.MaxVarintLen64+len(digest))
+	multihash = binary.AppendUvarint(multihash, code)
+	multihash = binary.AppendUvarint(multihash, uint64(len(digest)))
+
+	return append(multihash, digest...), nil
+}
+
+// DecodeMultihash parses multihash to hash option of its hash function and digest.
+func DecodeMultihash(multihash []byte) (*HashOpt, []byte, error) {
+	code, n := binary.Uvarint(multihash)
+	if n <= 0 {
+		return nil, nil, ErrInvalidMultihash
+	}
+	multihash = multihash[n:]
+
+	length, n := binary.Uvarint(multihash)
+	if n <= 0 || length == 0 || length != uint64(len(multihash)-n) {
+		return nil, nil, ErrInvalidMultihash
+	}
+	digest := multihash[n:]
+
+	for name, multihashCode := range multihashCodes {
+		if multihashCode != code {
+			continue
+		}
+
+		if len(digest) > digestSizeOf(name) {
+			return nil, nil, ErrInvalidMultihash
+		}
+
+		hashOpt, err := NewHashOpt(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return hashOpt, digest, nil
+	}
+
+	return nil, nil, ErrNoMultihashCode
+}
+
+// digestSizeOf returns digest size of hash function of name.
+func digestSizeOf(name string) int {
+	hashFuncsMutex.RLock()
+	hashFunc := hashFuncs[name]
+	hashFuncsMutex.RUnlock()
+
+	return hashFunc().Size()
+}
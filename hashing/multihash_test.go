@@ -0,0 +1,97 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hashing_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashMultihash(t *testing.T) {
+	tests := map[string]string{
+		hashing.SHA2_256:    "1220ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		hashing.BLAKE2B_256: "a0e40220bddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319",
+	}
+
+	for name, expected := range tests {
+		t.Logf("running test case [%s]", name)
+
+		// given
+		hashOpt, err := hashing.NewHashOpt(name)
+		assert.NoError(t, err)
+
+		// when
+		multihash, err := hashing.HashMultihash([]byte("abc"), hashOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, expected, hex.EncodeToString(multihash))
+	}
+}
+
+func TestDecodeMultihash(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA3_384)
+	assert.NoError(t, err)
+	digest, err := hashing.Hash([]byte("abc"), hashOpt)
+	assert.NoError(t, err)
+	multihash, err := hashing.EncodeMultihash(hashing.SHA3_384, digest)
+	assert.NoError(t, err)
+
+	// when
+	decodedOpt, decodedDigest, err := hashing.DecodeMultihash(multihash)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hashing.SHA3_384, decodedOpt.Name)
+	assert.Equal(t, digest, decodedDigest)
+}
+
+func TestDecodeMultihash_Invalid(t *testing.T) {
+	for testName, multihash := range map[string][]byte{
+		"empty":           {},
+		"short digest":    {0x12, 0x14, 0x01},
+		"garbage follows": {0x12, 0x01, 0x01, 0x02},
+		"too long digest": append([]byte{0x12, 0x21}, make([]byte, 33)...),
+	} {
+		// when
+		_, _, err := hashing.DecodeMultihash(multihash)
+
+		// then
+		assert.Equal(t, hashing.ErrInvalidMultihash, err, testName)
+	}
+
+	// unknown code
+	_, _, err := hashing.DecodeMultihash([]byte{0x00, 0x01, 0x01})
+	assert.Equal(t, hashing.ErrNoMultihashCode, err)
+}
+
+func TestHashMultihash_Keyed(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewKeyedHashOpt(hashing.BLAKE2B_256, []byte("secret key"))
+	assert.NoError(t, err)
+
+	// when
+	_, err = hashing.HashMultihash([]byte("abc"), hashOpt)
+
+	// then
+	assert.Equal(t, hashing.ErrNoMultihashCode, err)
+}
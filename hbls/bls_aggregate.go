@@ -0,0 +1,117 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides aggregation of BLS signatures and public keys, and verification of aggregated signatures.
+
+package hbls
+
+import (
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+var ErrEmptyAggregation = errors.New("empty aggregation - at least one signature or public key should be given")
+var ErrMessageCount = errors.New("message count mismatch - one message should be given for each public key")
+var ErrDuplicateMessage = errors.New("duplicate message - messages of aggregate verification should be distinct")
+
+// AggregateSignatures adds signatures into one signature of the same size, in format of version in signer option.
+// Signatures of any supported version are accepted.
+func AggregateSignatures(signatures [][]byte, opts heimdall.SignerOpts) ([]byte, error) {
+	if len(signatures) == 0 {
+		return nil, ErrEmptyAggregation
+	}
+
+	g2 := bls12381.NewG2()
+	aggregated := g2.Zero()
+	for _, signature := range signatures {
+		point, err := decodeSignature(signature)
+		if err != nil {
+			return nil, err
+		}
+
+		g2.Add(aggregated, aggregated, point)
+	}
+
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), g2.ToCompressed(aggregated))
+}
+
+// AggregatePubKeys adds public keys into one public key, which verifies aggregated signature of the same message.
+func AggregatePubKeys(pubs []heimdall.PubKey) (heimdall.PubKey, error) {
+	if len(pubs) == 0 {
+		return nil, ErrEmptyAggregation
+	}
+
+	g1 := bls12381.NewG1()
+	aggregated := g1.Zero()
+	for _, pub := range pubs {
+		blsPub, ok := pub.(*PubKey)
+		if !ok {
+			return nil, ErrNotBLSPubKey
+		}
+
+		g1.Add(aggregated, aggregated, blsPub.point)
+	}
+
+	if g1.IsZero(aggregated) {
+		return nil, ErrInvalidPubKey
+	}
+
+	return &PubKey{point: g1.Affine(aggregated)}, nil
+}
+
+// FastAggregateVerify verifies aggregated signature of the same message by the public keys. (ex. votes of a block)
+// Every public key should have been checked by VerifyPossession, otherwise a rogue key can forge the signature.
+func FastAggregateVerify(pubs []heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	pub, err := AggregatePubKeys(pubs)
+	if err != nil {
+		return false, err
+	}
+
+	return Verify(pub, signature, message, opts)
+}
+
+// AggregateVerify verifies aggregated signature of distinct messages, each of which is signed by public key of the same index.
+func AggregateVerify(pubs []heimdall.PubKey, messages [][]byte, signature []byte, opts heimdall.SignerOpts) (bool, error) {
+	if len(pubs) == 0 {
+		return false, ErrEmptyAggregation
+	}
+
+	if len(pubs) != len(messages) {
+		return false, ErrMessageCount
+	}
+
+	seen := make(map[string]bool)
+	for i, message := range messages {
+		if seen[string(message)] {
+			return false, ErrDuplicateMessage
+		}
+		seen[string(message)] = true
+
+		if err := checkAlgorithm(pubs[i], opts); err != nil {
+			return false, err
+		}
+	}
+
+	point, err := decodeSignature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	return verifyPairs(pubs, messages, point, signatureDST)
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hbls_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hbls"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFastAggregateVerify(t *testing.T) {
+	// given
+	message := []byte("block hash")
+	var pubs []heimdall.PubKey
+	var signatures [][]byte
+	for i := 0; i < 3; i++ {
+		pri := setUpPriKey(t)
+		signature, err := hbls.NewSigner(pri).Sign(message, hbls.NewSignerOpts())
+		assert.NoError(t, err)
+
+		pubs = append(pubs, pri.PublicKey())
+		signatures = append(signatures, signature)
+	}
+
+	// when
+	aggregated, err := hbls.AggregateSignatures(signatures, hbls.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, aggregated, 96)
+
+	valid, err := hbls.FastAggregateVerify(pubs, aggregated, message, hbls.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hbls.FastAggregateVerify(pubs[:2], aggregated, message, hbls.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestAggregateVerify(t *testing.T) {
+	// given
+	messages := [][]byte{[]byte("tx 1"), []byte("tx 2"), []byte("tx 3")}
+	var pubs []heimdall.PubKey
+	var signatures [][]byte
+	for _, message := range messages {
+		pri := setUpPriKey(t)
+		signature, err := hbls.NewSigner(pri).Sign(message, hbls.NewSignerOpts())
+		assert.NoError(t, err)
+
+		pubs = append(pubs, pri.PublicKey())
+		signatures = append(signatures, signature)
+	}
+
+	aggregated, err := hbls.AggregateSignatures(signatures, hbls.NewSignerOpts())
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hbls.AggregateVerify(pubs, messages, aggregated, hbls.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	swapped := [][]byte{messages[1], messages[0], messages[2]}
+	valid, err = hbls.AggregateVerify(pubs, swapped, aggregated, hbls.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestAggregateVerify_Invalid(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signature, err := hbls.NewSigner(pri).Sign([]byte("tx"), hbls.NewSignerOpts())
+	assert.NoError(t, err)
+	pubs := []heimdall.PubKey{pri.PublicKey(), pri.PublicKey()}
+
+	// when
+	_, duplicateErr := hbls.AggregateVerify(pubs, [][]byte{[]byte("tx"), []byte("tx")}, signature, hbls.NewSignerOpts())
+	_, countErr := hbls.AggregateVerify(pubs, [][]byte{[]byte("tx")}, signature, hbls.NewSignerOpts())
+	_, emptyErr := hbls.AggregateSignatures(nil, hbls.NewSignerOpts())
+	_, emptyPubErr := hbls.AggregatePubKeys(nil)
+
+	// then
+	assert.Equal(t, hbls.ErrDuplicateMessage, duplicateErr)
+	assert.Equal(t, hbls.ErrMessageCount, countErr)
+	assert.Equal(t, hbls.ErrEmptyAggregation, emptyErr)
+	assert.Equal(t, hbls.ErrEmptyAggregation, emptyPubErr)
+}
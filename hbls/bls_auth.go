@@ -0,0 +1,182 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides BLS signing and verifying related functions, and proof of possession of secret key.
+
+package hbls
+
+import (
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature - BLS signature should be 96 bytes compressed point of G2, optionally prefixed by version byte")
+
+// domain separation tags of proof of possession scheme (draft-irtf-cfrg-bls-signature),
+// so that signatures of messages and proofs of possession are never interchangeable.
+var (
+	signatureDST  = []byte("BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+	possessionDST = []byte("BLS_POP_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_")
+)
+
+// signatureSize is byte length of compressed point of G2.
+const signatureSize = 96
+
+// Sign generates signature for a data using private key.
+func Sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	// remove private key from memory.
+	defer pri.Clear()
+
+	return sign(pri, message, opts)
+}
+
+// sign generates signature without clearing private key, for signers holding the key for several signatures.
+func sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	if err := heimdall.CheckKeyUsage(pri, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	if err := checkAlgorithm(pri, opts); err != nil {
+		return nil, err
+	}
+
+	signature, err := signWithDST(pri, message, signatureDST)
+	if err != nil {
+		return nil, err
+	}
+
+	event.Publish(event.MessageSigned, pri.ID(), "")
+
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+}
+
+// signWithDST multiplies message hashed to G2 by secret scalar.
+func signWithDST(pri heimdall.PriKey, message, dst []byte) ([]byte, error) {
+	blsPri, ok := pri.(*PriKey)
+	if !ok {
+		return nil, ErrNotBLSPriKey
+	}
+
+	g2 := bls12381.NewG2()
+	point, err := g2.HashToCurve(message, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	return g2.ToCompressed(g2.MulScalarBig(point, point, blsPri.secret)), nil
+}
+
+// checkAlgorithm rejects scheme parameters of other algorithms, and key algorithm denied by algorithm policy.
+func checkAlgorithm(key heimdall.Key, opts heimdall.SignerOpts) error {
+	if err := heimdall.SchemeParamsOf(opts).Validate(key.KeyGenOpt()); err != nil {
+		return err
+	}
+
+	return heimdall.CheckKeyAlgorithm(key.KeyGenOpt())
+}
+
+// decodeSignature returns point of legacy (96 bytes) or versioned (version byte and 96 bytes) signature.
+func decodeSignature(signature []byte) (*bls12381.PointG2, error) {
+	if len(signature) == signatureSize+1 {
+		var err error
+		if _, signature, err = heimdall.DecodeSignature(signature); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(signature) != signatureSize {
+		return nil, ErrInvalidSignature
+	}
+
+	point, err := bls12381.NewG2().FromCompressed(signature)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+
+	return point, nil
+}
+
+// Verify verifies the signature using pubKey(public key) and original message, then returns boolean value.
+// Signature of any supported version is accepted regardless of version in signer option.
+func Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	if err := checkAlgorithm(pub, opts); err != nil {
+		return false, err
+	}
+
+	point, err := decodeSignature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	return verifyPairs([]heimdall.PubKey{pub}, [][]byte{message}, point, signatureDST)
+}
+
+// verifyPairs checks e(pub_1, H(message_1)) * ... * e(pub_n, H(message_n)) == e(g1, signature).
+func verifyPairs(pubs []heimdall.PubKey, messages [][]byte, signature *bls12381.PointG2, dst []byte) (bool, error) {
+	engine := bls12381.NewEngine()
+
+	for i, pub := range pubs {
+		blsPub, ok := pub.(*PubKey)
+		if !ok {
+			return false, ErrNotBLSPubKey
+		}
+
+		if engine.G1.IsZero(blsPub.point) {
+			return false, ErrInvalidPubKey
+		}
+
+		point, err := engine.G2.HashToCurve(messages[i], dst)
+		if err != nil {
+			return false, err
+		}
+
+		engine.AddPair(engine.G1.New().Set(blsPub.point), point)
+	}
+
+	engine.AddPairInv(engine.G1.One(), signature)
+
+	return engine.Check(), nil
+}
+
+// ProvePossession makes proof of possession of private key, which is signature of its public key.
+// Public keys of others should be checked by VerifyPossession before they are aggregated, against rogue key attack.
+func ProvePossession(pri heimdall.PriKey) ([]byte, error) {
+	pubBytes, err := pri.PublicKey().ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	return signWithDST(pri, pubBytes, possessionDST)
+}
+
+// VerifyPossession verifies proof of possession of private key of the public key.
+func VerifyPossession(pub heimdall.PubKey, proof []byte) (bool, error) {
+	pubBytes, err := pub.ToByte()
+	if err != nil {
+		return false, err
+	}
+
+	point, err := decodeSignature(proof)
+	if err != nil {
+		return false, err
+	}
+
+	return verifyPairs([]heimdall.PubKey{pub}, [][]byte{pubBytes}, point, possessionDST)
+}
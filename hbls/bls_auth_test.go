@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hbls_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hbls"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	pub := pri.PublicKey()
+	message := []byte("hello")
+
+	// when
+	signature, err := hbls.Sign(pri, message, hbls.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, signature, 96)
+	valid, err := hbls.Verify(pub, signature, message, hbls.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestVerify_WrongMessage(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signature, err := hbls.NewSigner(pri).Sign([]byte("hello"), hbls.NewSignerOpts())
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hbls.NewVerifier().Verify(pri.PublicKey(), signature, []byte("world"), hbls.NewSignerOpts())
+	_, lengthErr := hbls.Verify(pri.PublicKey(), signature[1:], []byte("hello"), hbls.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, hbls.ErrInvalidSignature, lengthErr)
+}
+
+func TestVerify_SignatureVersion(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	message := []byte("hello")
+	signature, err := hbls.NewSigner(pri).Sign(message, hbls.NewSignerOpts().WithSignatureVersion(heimdall.SignatureVersion1))
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hbls.Verify(pri.PublicKey(), signature, message, hbls.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, heimdall.SignatureVersion1, signature[0])
+}
+
+func TestProvePossession(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	otherPri := setUpPriKey(t)
+
+	// when
+	proof, err := hbls.ProvePossession(pri)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hbls.VerifyPossession(pri.PublicKey(), proof)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hbls.VerifyPossession(otherPri.PublicKey(), proof)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	// proof of possession is not a signature of the public key
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+	valid, err = hbls.Verify(pri.PublicKey(), proof, pubBytes, hbls.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides BLS12-381 key related functions.
+// Public keys are points of G1 (48 bytes compressed), so that signatures are points of G2 (96 bytes compressed).
+
+package hbls
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	bls12381 "github.com/kilic/bls12-381"
+)
+
+var ErrKeyType = errors.New("invalid key type - key type should be heimdall.PRIVATEKEY or heimdall.PUBLICKEY")
+var ErrNotBLSPriKey = errors.New("invalid private key - key is not BLS private key")
+var ErrNotBLSPubKey = errors.New("invalid public key - key is not BLS public key")
+var ErrInvalidPriKey = errors.New("invalid private key - secret should be 32 bytes scalar in range of group order")
+var ErrInvalidPubKey = errors.New("invalid public key - public key should be 48 bytes compressed point of G1 except identity")
+
+// secretSize is byte length of secret scalar.
+const secretSize = 32
+
+// groupOrder is order of G1 and G2, which bounds secret scalar.
+var groupOrder = bls12381.NewG1().Q()
+
+func GenerateKey(keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	opt, err := ToKeyGenOpt(keyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(opt); err != nil {
+		return nil, err
+	}
+
+	// secret in [1, order - 1]
+	secret, err := rand.Int(rand.Reader, new(big.Int).Sub(groupOrder, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	secret.Add(secret, big.NewInt(1))
+
+	key := NewPriKey(secret)
+	event.Publish(event.KeyCreated, key.ID(), "")
+
+	return key, nil
+}
+
+// PriKey is an implementation of heimdall PriKey for using BLS secret key
+type PriKey struct {
+	heimdall.KeyUsage
+	secret *big.Int
+	pub    *PubKey
+}
+
+func NewPriKey(secret *big.Int) heimdall.PriKey {
+	g1 := bls12381.NewG1()
+	point := g1.MulScalarBig(g1.New(), g1.One(), secret)
+
+	return &PriKey{secret: secret, pub: &PubKey{point: point}}
+}
+
+func (priKey *PriKey) ID() heimdall.KeyID {
+	return priKey.pub.ID()
+}
+
+func (priKey *PriKey) SKI() []byte {
+	return priKey.pub.SKI()
+}
+
+func (priKey *PriKey) ToByte() ([]byte, error) {
+	keyBytes := make([]byte, secretSize)
+	return priKey.secret.FillBytes(keyBytes), nil
+}
+
+func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return &KeyGenOpt{}
+}
+
+func (priKey *PriKey) IsPrivate() bool {
+	return true
+}
+
+func (priKey *PriKey) PublicKey() heimdall.PubKey {
+	return priKey.pub
+}
+
+// SetUsagePolicy sets usage policy of the key and of its public key, which is shared with the key.
+func (priKey *PriKey) SetUsagePolicy(policy *heimdall.KeyUsagePolicy) {
+	priKey.KeyUsage.SetUsagePolicy(policy)
+	priKey.pub.SetUsagePolicy(policy)
+}
+
+func (priKey *PriKey) Clear() {
+	// clear secret scalar to 0
+	priKey.secret.Set(big.NewInt(0))
+}
+
+// PubKey is an implementation of heimdall PubKey for using BLS public key
+type PubKey struct {
+	heimdall.KeyUsage
+	point *bls12381.PointG1
+}
+
+func (pubKey *PubKey) ID() heimdall.KeyID {
+	// return base58 encoded ski with algorithm prefix (ex. BLS12381x...)
+	keyId, err := heimdall.MakeKeyID(&KeyGenOpt{}, pubKey.SKI())
+	if err != nil {
+		return heimdall.SKIToKeyID(pubKey.SKI())
+	}
+
+	return keyId
+}
+
+func (pubKey *PubKey) SKI() []byte {
+	return heimdall.MakeSKI(pubKey.SKIMaterial())
+}
+
+// SKIMaterial returns compressed point of public key, which SKI is hashed from.
+func (pubKey *PubKey) SKIMaterial() []byte {
+	return bls12381.NewG1().ToCompressed(pubKey.point)
+}
+
+// ToByte returns compressed point of public key. (zcash serialization)
+func (pubKey *PubKey) ToByte() ([]byte, error) {
+	return bls12381.NewG1().ToCompressed(pubKey.point), nil
+}
+
+func (pubKey *PubKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return &KeyGenOpt{}
+}
+
+func (pubKey *PubKey) IsPrivate() bool {
+	return false
+}
+
+// init registers recoverer of BLS12381 keys, so that key files of them are loaded without choosing a recoverer.
+func init() {
+	heimdall.RegisterKeyRecoverer(heimdall.BLS12381, &KeyRecoverer{})
+}
+
+type KeyRecoverer struct {
+}
+
+// RecoverKeyFromByte recovers private key of 32 bytes big endian scalar, or public key of compressed point.
+// Public key should be in G1 and should not be identity, which would make any signature valid.
+func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	switch isPrivate {
+	case true:
+		if len(keyBytes) != secretSize {
+			return nil, ErrInvalidPriKey
+		}
+
+		secret := new(big.Int).SetBytes(keyBytes)
+		if secret.Sign() == 0 || secret.Cmp(groupOrder) >= 0 {
+			return nil, ErrInvalidPriKey
+		}
+
+		return NewPriKey(secret), nil
+
+	case false:
+		g1 := bls12381.NewG1()
+		point, err := g1.FromCompressed(keyBytes)
+		if err != nil || g1.IsZero(point) {
+			return nil, ErrInvalidPubKey
+		}
+
+		return &PubKey{point: point}, nil
+
+	default:
+		return nil, ErrKeyType
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hbls_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hbls"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpPriKey(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hbls.NewKeyGenOpt(hbls.BLS12381)
+	assert.NoError(t, err)
+	pri, err := hbls.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func TestGenerateKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hbls.NewKeyGenOpt("bls12381")
+	assert.NoError(t, err)
+
+	// when
+	pri, err := hbls.GenerateKey(keyGenOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, pri.IsPrivate())
+	assert.Equal(t, hbls.BLS12381, pri.KeyGenOpt().ToString())
+}
+
+func TestNewKeyGenOpt_NotSupported(t *testing.T) {
+	// when
+	_, err := hbls.NewKeyGenOpt("ED25519")
+
+	// then
+	assert.Equal(t, hbls.ErrKeyGenOptNotSupported, err)
+}
+
+func TestPriKey_ID(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+
+	// when
+	keyId := pri.ID()
+
+	// then
+	assert.True(t, strings.HasPrefix(keyId, "BLS12381x"))
+	assert.Equal(t, pri.PublicKey().ID(), keyId)
+
+	info, err := heimdall.ParseKeyID(keyId)
+	assert.NoError(t, err)
+	assert.Equal(t, heimdall.BLS12381, info.KeyType.Family)
+	assert.Equal(t, pri.SKI(), info.SKI)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	recoverer := &hbls.KeyRecoverer{}
+
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+
+	// when
+	recoveredPri, priErr := recoverer.RecoverKeyFromByte(priBytes, true)
+	recoveredPub, pubErr := recoverer.RecoverKeyFromByte(pubBytes, false)
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+	assert.Len(t, priBytes, 32)
+	assert.Len(t, pubBytes, 48)
+	assert.Equal(t, pri.ID(), recoveredPri.ID())
+	assert.Equal(t, pri.ID(), recoveredPub.ID())
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte_Invalid(t *testing.T) {
+	// given
+	recoverer := &hbls.KeyRecoverer{}
+	identity := make([]byte, 48)
+	identity[0] = 0xc0
+
+	// when
+	_, zeroErr := recoverer.RecoverKeyFromByte(make([]byte, 32), true)
+	_, identityErr := recoverer.RecoverKeyFromByte(identity, false)
+	_, wrongErr := recoverer.RecoverKeyFromByte([]byte("wrong"), false)
+
+	// then
+	assert.Equal(t, hbls.ErrInvalidPriKey, zeroErr)
+	assert.Equal(t, hbls.ErrInvalidPubKey, identityErr)
+	assert.Equal(t, hbls.ErrInvalidPubKey, wrongErr)
+}
+
+func TestPriKey_Clear(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+
+	// when
+	pri.Clear()
+
+	// then
+	keyBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, 32), keyBytes)
+}
@@ -0,0 +1,241 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides hierarchical deterministic derivation of ECDSA keys (BIP32, and SLIP-0010 for P-256),
+// so that a node can derive per-channel or per-epoch signing keys from one master key.
+
+package hd
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrCurveNotSupported = errors.New("curve not supported - hd keys should be on secp256k1 or P-256")
+var ErrInvalidSeed = errors.New("invalid seed - seed should be 16 to 64 bytes")
+var ErrInvalidPath = errors.New("invalid derivation path - path should be indexes from m, such as m/44'/0'/1")
+
+// HardenedOffset is added to index of hardened child, whose key is not derived from public key of parent.
+const HardenedOffset uint32 = 0x80000000
+
+// DefaultSeedSize is size of seed of generated master key, which is 256 bits of BIP32 recommendation.
+const DefaultSeedSize = 32
+
+// maxDepth is the maximum depth of derivation, since depth is serialized in a byte.
+const maxDepth = 255
+
+// seedKeys maps curves to HMAC keys deriving master keys from seeds. (BIP32, SLIP-0010)
+var seedKeys = map[string][]byte{
+	hecdsa.ECSECP256K1: []byte("Bitcoin seed"),
+	hecdsa.ECP256:      []byte("Nist256p1 seed"),
+}
+
+// ExtendedKey is ECDSA private key extended by chain code, which derives child keys.
+type ExtendedKey struct {
+	curve     elliptic.Curve
+	key       []byte
+	chainCode []byte
+	depth     int
+	index     uint32
+}
+
+// GenerateMasterKey generates master key on curve from random seed.
+func GenerateMasterKey(curve string) (*ExtendedKey, error) {
+	seed := make([]byte, DefaultSeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+
+	return NewMasterKey(seed, curve)
+}
+
+// NewMasterKey derives master key on curve (hecdsa.ECSECP256K1 or hecdsa.ECP256) from seed, such as seed of BIP39 mnemonic.
+func NewMasterKey(seed []byte, curve string) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, ErrInvalidSeed
+	}
+
+	seedKey, ok := seedKeys[curve]
+	if !ok {
+		return nil, ErrCurveNotSupported
+	}
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(curve)
+	if err != nil {
+		return nil, err
+	}
+
+	I := hmacSHA512(seedKey, seed)
+	for !validScalar(keyGenOpt.Curve, I[:32]) {
+		I = hmacSHA512(seedKey, I)
+	}
+
+	return &ExtendedKey{curve: keyGenOpt.Curve, key: I[:32], chainCode: I[32:]}, nil
+}
+
+// Child derives child key of index. Index of hardened child should be added by HardenedOffset.
+func (key *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	if key.depth >= maxDepth {
+		return nil, ErrInvalidPath
+	}
+
+	var data []byte
+	if index >= HardenedOffset {
+		data = append([]byte{0}, key.key...)
+	} else {
+		x, y := key.curve.ScalarBaseMult(key.key)
+		data = elliptic.MarshalCompressed(key.curve, x, y)
+	}
+	data = binary.BigEndian.AppendUint32(data, index)
+
+	N := key.curve.Params().N
+	for {
+		I := hmacSHA512(key.chainCode, data)
+
+		childKey := new(big.Int).SetBytes(I[:32])
+		if childKey.Cmp(N) < 0 {
+			childKey.Add(childKey, new(big.Int).SetBytes(key.key))
+			childKey.Mod(childKey, N)
+			if childKey.Sign() != 0 {
+				return &ExtendedKey{
+					curve:     key.curve,
+					key:       childKey.FillBytes(make([]byte, 32)),
+					chainCode: I[32:],
+					depth:     key.depth + 1,
+					index:     index,
+				}, nil
+			}
+		}
+
+		// invalid child key is skipped by deriving again from the right half (SLIP-0010)
+		data = binary.BigEndian.AppendUint32(append([]byte{1}, I[32:]...), index)
+	}
+}
+
+// Derive derives descendant key along path from the key, such as m/44'/0'/1 (' or h for hardened index).
+func (key *ExtendedKey) Derive(path string) (*ExtendedKey, error) {
+	indexes, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	derived := key
+	for _, index := range indexes {
+		child, err := derived.Child(index)
+		if derived != key {
+			derived.Clear()
+		}
+		if err != nil {
+			return nil, err
+		}
+		derived = child
+	}
+
+	if derived == key {
+		return &ExtendedKey{
+			curve:     key.curve,
+			key:       append([]byte{}, key.key...),
+			chainCode: append([]byte{}, key.chainCode...),
+			depth:     key.depth,
+			index:     key.index,
+		}, nil
+	}
+
+	return derived, nil
+}
+
+// ParsePath parses derivation path into child indexes, adding HardenedOffset to hardened indexes.
+func ParsePath(path string) ([]uint32, error) {
+	elements := strings.Split(path, "/")
+	if elements[0] != "m" || len(elements) > maxDepth+1 {
+		return nil, ErrInvalidPath
+	}
+
+	indexes := make([]uint32, 0, len(elements)-1)
+	for _, element := range elements[1:] {
+		hardened := strings.HasSuffix(element, "'") || strings.HasSuffix(element, "h") || strings.HasSuffix(element, "H")
+		if hardened {
+			element = element[:len(element)-1]
+		}
+
+		index, err := strconv.ParseUint(element, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, ErrInvalidPath
+		}
+		if hardened {
+			index += uint64(HardenedOffset)
+		}
+		indexes = append(indexes, uint32(index))
+	}
+
+	return indexes, nil
+}
+
+// PriKey returns ECDSA private key of the extended key.
+func (key *ExtendedKey) PriKey() heimdall.PriKey {
+	pri := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(key.key)}
+	pri.Curve = key.curve
+	pri.X, pri.Y = key.curve.ScalarBaseMult(key.key)
+
+	return hecdsa.NewPriKey(pri)
+}
+
+// Depth returns the number of derivations from master key.
+func (key *ExtendedKey) Depth() int {
+	return key.depth
+}
+
+// Index returns index of the key in its parent.
+func (key *ExtendedKey) Index() uint32 {
+	return key.index
+}
+
+// ChainCode returns chain code of the key.
+func (key *ExtendedKey) ChainCode() []byte {
+	return key.chainCode
+}
+
+// Clear clears private key and chain code of the extended key to 0.
+func (key *ExtendedKey) Clear() {
+	for i := range key.key {
+		key.key[i] = 0
+	}
+	for i := range key.chainCode {
+		key.chainCode[i] = 0
+	}
+}
+
+func validScalar(curve elliptic.Curve, scalar []byte) bool {
+	k := new(big.Int).SetBytes(scalar)
+	return k.Sign() > 0 && k.Cmp(curve.Params().N) < 0
+}
+
+func hmacSHA512(key, data []byte) []byte {
+	mac := hmac.New(sha512.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
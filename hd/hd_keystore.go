@@ -0,0 +1,152 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides storage of master keys, so that only the master key file is kept and child keys are derived on loading.
+
+package hd
+
+import (
+	"encoding/asn1"
+	"errors"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+)
+
+var ErrNotMasterKey = errors.New("not master key - key file has no chain code, or extended key is not master key")
+
+// chainCodeKEKOpt derives key wrapping chain code from master private key.
+var chainCodeKEKOpt = &kdf.Opts{
+	KdfName:   kdf.HKDF,
+	KdfParams: map[string]string{"hashOpt": hashing.SHA384, "info": "heimdall hd chain code"},
+}
+
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// StoreMasterKey stores master key in keyDirPath as key file encrypted with pwd. Chain code is kept in metadata of
+// the key file, wrapped by key derived from the private key, so the key file is still loaded as ECDSA key by hecdsa.
+func StoreMasterKey(master *ExtendedKey, pwd, keyDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) error {
+	if master.depth != 0 {
+		return ErrNotMasterKey
+	}
+
+	pri := master.PriKey()
+	defer pri.Clear()
+
+	wrappedChainCode, err := wrapChainCode(master.key, master.chainCode)
+	if err != nil {
+		return err
+	}
+
+	if err := hecdsa.StorePriKey(pri, pwd, keyDirPath, encOpt, kdfOpt); err != nil {
+		return err
+	}
+
+	metadata, err := hecdsa.GetKeyMetadata(pri.ID(), keyDirPath)
+	if err != nil {
+		return err
+	}
+	metadata.ChainCode = wrappedChainCode
+
+	return hecdsa.SetKeyMetadata(pri.ID(), metadata, pwd, keyDirPath)
+}
+
+// LoadMasterKey loads master key stored by StoreMasterKey in keyDirPath with pwd.
+func LoadMasterKey(keyDirPath, pwd string) (*ExtendedKey, error) {
+	pri, err := hecdsa.LoadPriKey(keyDirPath, pwd)
+	if err != nil {
+		return nil, err
+	}
+	defer pri.Clear()
+
+	metadata, err := hecdsa.GetKeyMetadata(pri.ID(), keyDirPath)
+	if err == hecdsa.ErrKeyMetadataNotExist || (err == nil && metadata.ChainCode == nil) {
+		return nil, ErrNotMasterKey
+	} else if err != nil {
+		return nil, err
+	}
+
+	curve := pri.KeyGenOpt().ToString()
+	if _, ok := seedKeys[curve]; !ok {
+		return nil, ErrCurveNotSupported
+	}
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(curve)
+	if err != nil {
+		return nil, err
+	}
+
+	priBytes, err := pri.ToByte()
+	if err != nil {
+		return nil, err
+	}
+	var privateKey ecPrivateKey
+	if _, err := asn1.Unmarshal(priBytes, &privateKey); err != nil {
+		return nil, err
+	}
+	key := new(big.Int).SetBytes(privateKey.PrivateKey).FillBytes(make([]byte, 32))
+
+	chainCode, err := unwrapChainCode(key, metadata.ChainCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExtendedKey{curve: keyGenOpt.Curve, key: key, chainCode: chainCode}, nil
+}
+
+// DeriveKey loads master key in keyDirPath with pwd, and derives private key along path from it.
+func DeriveKey(keyDirPath, pwd, path string) (heimdall.PriKey, error) {
+	master, err := LoadMasterKey(keyDirPath, pwd)
+	if err != nil {
+		return nil, err
+	}
+	defer master.Clear()
+
+	derived, err := master.Derive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer derived.Clear()
+
+	return derived.PriKey(), nil
+}
+
+func wrapChainCode(key, chainCode []byte) ([]byte, error) {
+	kek, err := kdf.DeriveKey(key, nil, 256, chainCodeKEKOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryption.KeyWrap(kek, chainCode)
+}
+
+func unwrapChainCode(key, wrappedChainCode []byte) ([]byte, error) {
+	kek, err := kdf.DeriveKey(key, nil, 256, chainCodeKEKOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	return encryption.KeyUnwrap(kek, wrappedChainCode)
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hd_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hd"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpOpts(t *testing.T) (*encryption.Opts, *kdf.Opts) {
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	return encOpt, kdfOpt
+}
+
+func TestStoreMasterKey(t *testing.T) {
+	// given
+	encOpt, kdfOpt := setUpOpts(t)
+	master, err := hd.NewMasterKey(testSeed, hecdsa.ECSECP256K1)
+	assert.NoError(t, err)
+	expected, err := master.Derive("m/0H/1/2H")
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	err = hd.StoreMasterKey(master, "password", heimdall.TestKeyDir, encOpt, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+
+	loaded, err := hd.LoadMasterKey(heimdall.TestKeyDir, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, master.ChainCode(), loaded.ChainCode())
+	assert.Equal(t, master.PriKey().ID(), loaded.PriKey().ID())
+
+	derived, err := hd.DeriveKey(heimdall.TestKeyDir, "password", "m/0H/1/2H")
+	assert.NoError(t, err)
+	assert.Equal(t, expected.PriKey().ID(), derived.ID())
+
+	// master key file is loaded as ECDSA key
+	pri, err := hecdsa.LoadPriKey(heimdall.TestKeyDir, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, master.PriKey().ID(), pri.ID())
+}
+
+func TestStoreMasterKey_NotMaster(t *testing.T) {
+	// given
+	encOpt, kdfOpt := setUpOpts(t)
+	master, err := hd.GenerateMasterKey(hecdsa.ECP256)
+	assert.NoError(t, err)
+	child, err := master.Child(0)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	childErr := hd.StoreMasterKey(child, "password", heimdall.TestKeyDir, encOpt, kdfOpt)
+	assert.NoError(t, hecdsa.StorePriKey(child.PriKey(), "password", heimdall.TestKeyDir, encOpt, kdfOpt))
+	_, loadErr := hd.LoadMasterKey(heimdall.TestKeyDir, "password")
+
+	// then
+	assert.Equal(t, hd.ErrNotMasterKey, childErr)
+	assert.Equal(t, hd.ErrNotMasterKey, loadErr)
+}
+
+func TestLoadMasterKey_TamperedChainCode(t *testing.T) {
+	// given
+	encOpt, kdfOpt := setUpOpts(t)
+	master, err := hd.GenerateMasterKey(hecdsa.ECP256)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	assert.NoError(t, hd.StoreMasterKey(master, "password", heimdall.TestKeyDir, encOpt, kdfOpt))
+
+	metadata, err := hecdsa.GetKeyMetadata(master.PriKey().ID(), heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	metadata.ChainCode[0] ^= 1
+	assert.NoError(t, hecdsa.SetKeyMetadata(master.PriKey().ID(), metadata, "password", heimdall.TestKeyDir))
+
+	// when
+	_, err = hd.LoadMasterKey(heimdall.TestKeyDir, "password")
+
+	// then
+	assert.Equal(t, encryption.ErrKeyUnwrapFailed, err)
+}
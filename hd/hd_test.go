@@ -0,0 +1,140 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hd_test
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hd"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+type testVector struct {
+	path      string
+	chainCode string
+	key       string
+}
+
+// test vector 1 of SLIP-0010, which is also test vector 1 of BIP32 for secp256k1
+var testSeed, _ = hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+var testVectors = map[string][]testVector{
+	hecdsa.ECSECP256K1: {
+		{"m", "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0H", "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0H/1", "2a7857631386ba23dacac34180dd1983734e444fdbf774041578e9b6adb37c19", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+		{"m/0H/1/2H", "04466b9cc8e161e966409ca52986c584f07e9dc81f735db683c3ff6ec7b1503f", "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca"},
+		{"m/0H/1/2H/2", "cfb71883f01676f587d023cc53a35bc7f88f724b1f8c2892ac1275ac822a3edd", "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4"},
+		{"m/0H/1/2H/2/1000000000", "c783e67b921d2beb8f6b389cc646d7263b4145701dadd2161548a8b078e65e9e", "471b76e389e528d6de6d816857e012c5455051cad6660850e58372a6c3e6e7c8"},
+	},
+	hecdsa.ECP256: {
+		{"m", "beeb672fe4621673f722f38529c07392fecaa61015c80c34f29ce8b41b3cb6ea", "612091aaa12e22dd2abef664f8a01a82cae99ad7441b7ef8110424915c268bc2"},
+		{"m/0H", "3460cea53e6a6bb5fb391eeef3237ffd8724bf0a40e94943c98b83825342ee11", "6939694369114c67917a182c59ddb8cafc3004e63ca5d3b84403ba8613debc0c"},
+		{"m/0H/1", "4187afff1aafa8445010097fb99d23aee9f599450c7bd140b6826ac22ba21d0c", "284e9d38d07d21e4e281b645089a94f4cf5a5a81369acf151a1c3a57f18b2129"},
+	},
+}
+
+func TestExtendedKey_Derive_TestVector(t *testing.T) {
+	for curve, vectors := range testVectors {
+		// given
+		master, err := hd.NewMasterKey(testSeed, curve)
+		assert.NoError(t, err)
+
+		for _, vector := range vectors {
+			// when
+			derived, err := master.Derive(vector.path)
+
+			// then
+			assert.NoError(t, err)
+			assert.Equal(t, vector.chainCode, hex.EncodeToString(derived.ChainCode()), curve+" "+vector.path)
+
+			expected, err := hex.DecodeString(vector.key)
+			assert.NoError(t, err)
+			assert.Equal(t, testKeyID(t, curve, expected), derived.PriKey().ID(), curve+" "+vector.path)
+		}
+	}
+}
+
+// testKeyID returns key ID of private key scalar on curve.
+func testKeyID(t *testing.T, curve string, scalar []byte) string {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(curve)
+	assert.NoError(t, err)
+
+	pub := &ecdsa.PublicKey{Curve: keyGenOpt.Curve}
+	pub.X, pub.Y = keyGenOpt.Curve.ScalarBaseMult(scalar)
+
+	return hecdsa.NewPubKey(pub).ID()
+}
+
+func TestExtendedKey_Child(t *testing.T) {
+	// given
+	master, err := hd.GenerateMasterKey(hecdsa.ECP256)
+	assert.NoError(t, err)
+
+	// when
+	child, err := master.Child(1 + hd.HardenedOffset)
+	assert.NoError(t, err)
+	derived, err := master.Derive("m/1'")
+	assert.NoError(t, err)
+
+	// then
+	assert.Equal(t, 1, child.Depth())
+	assert.Equal(t, 1+hd.HardenedOffset, child.Index())
+	assert.Equal(t, child.PriKey().ID(), derived.PriKey().ID())
+	assert.NotEqual(t, master.PriKey().ID(), child.PriKey().ID())
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	signature, err := hecdsa.NewSigner(child.PriKey()).Sign([]byte("hello"), signerOpt)
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(child.PriKey().PublicKey(), signature, []byte("hello"), signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestNewMasterKey_Invalid(t *testing.T) {
+	// when
+	_, seedErr := hd.NewMasterKey(testSeed[:8], hecdsa.ECP256)
+	_, curveErr := hd.NewMasterKey(testSeed, hecdsa.ECP384)
+
+	// then
+	assert.Equal(t, hd.ErrInvalidSeed, seedErr)
+	assert.Equal(t, hd.ErrCurveNotSupported, curveErr)
+}
+
+func TestParsePath(t *testing.T) {
+	// when
+	indexes, err := hd.ParsePath("m/44'/0h/1")
+	root, rootErr := hd.ParsePath("m")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []uint32{44 + hd.HardenedOffset, hd.HardenedOffset, 1}, indexes)
+	assert.NoError(t, rootErr)
+	assert.Empty(t, root)
+
+	for _, invalid := range []string{"", "44'/0", "m/", "m/-1", "m/2147483648", "m/1''", "m/a"} {
+		_, err := hd.ParsePath(invalid)
+		assert.Equal(t, hd.ErrInvalidPath, err, invalid)
+	}
+}
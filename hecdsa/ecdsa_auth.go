@@ -24,11 +24,13 @@ import (
 	"crypto/rand"
 	"encoding/asn1"
 	"errors"
+	"io"
 	"math/big"
 
 	"crypto/x509"
 
 	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
 	"github.com/DE-labtory/heimdall/hashing"
 )
 
@@ -45,8 +47,8 @@ type ecdsaSignature struct {
 	R, S *big.Int
 }
 
-// marshalECDSASignature returns encoding format (ASN.1) of signature.
-func marshalECDSASignature(r, s *big.Int) ([]byte, error) {
+// MarshalSignature returns encoding format (ASN.1) of signature, so signature made outside of hecdsa can be verified by Verify.
+func MarshalSignature(r, s *big.Int) ([]byte, error) {
 	return asn1.Marshal(ecdsaSignature{r, s})
 }
 
@@ -81,41 +83,224 @@ func unmarshalECDSASignature(signature []byte) (*big.Int, *big.Int, error) {
 	return ecdsaSig.R, ecdsaSig.S, nil
 }
 
+// SignWithKeyInLocal generates signature for a data using private key stored in local without password.
 func SignWithKeyInLocal(keyDirPath string, message []byte, signerOpt heimdall.SignerOpts) ([]byte, error) {
 	pri, err := LoadPriKeyWithoutPwd(keyDirPath)
 	if err != nil {
 		return nil, err
 	}
 
-	return Sign(pri.(*PriKey), message, signerOpt)
+	return Sign(pri, message, signerOpt)
 }
 
 // Sign generates signature for a data using private key.
 func Sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
-	digest, err := hashing.Hash(message, opts.HashOpt())
+	// remove private key from memory.
+	defer pri.Clear()
+
+	return sign(pri, message, opts)
+}
+
+// sign generates signature without clearing private key, for signers holding the key for several signatures.
+func sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	digest, err := digestOf(pri, message, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	r, s, err := ecdsa.Sign(rand.Reader, pri.(*PriKey).internalPriKey, digest)
+	return signWithDigest(pri, digest, opts)
+}
+
+// SignWithoutEvent generates signature like Signer without publishing MessageSigned event, for signers which record
+// their signatures themselves while handling events. (ex. audit log signing its entries) Private key is not cleared.
+func SignWithoutEvent(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	digest, err := digestOf(pri, message, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	signature, err := marshalECDSASignature(r, s)
+	return encodedSignature(pri, digest, opts)
+}
+
+// signReader generates signature for data read from reader until EOF, hashing the data incrementally.
+func signReader(pri heimdall.PriKey, reader io.Reader, opts heimdall.SignerOpts) ([]byte, error) {
+	digest, err := digestOfReader(pri, reader, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	// remove private key from memory.
-	defer pri.Clear()
+	return signWithDigest(pri, digest, opts)
+}
+
+// signWithDigest generates signature for digest of message hashed by hash option of signer option,
+// and publishes MessageSigned event of the key.
+func signWithDigest(pri heimdall.PriKey, digest []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	signature, err := encodedSignature(pri, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	event.Publish(event.MessageSigned, pri.ID(), "")
 
 	return signature, nil
 }
 
+// encodedSignature generates ECDSA or Schnorr signature for digest, encoded in signature version of signer option.
+func encodedSignature(pri heimdall.PriKey, digest []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	if err := heimdall.CheckKeyUsage(pri, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	ecdsaPri, ok := pri.(*PriKey)
+	if !ok {
+		return nil, ErrNotECDSAPriKey
+	}
+
+	internalPriKey := ecdsaPri.internalPriKey
+	if isSchnorr(opts) {
+		hashOpt, err := heimdall.HashOptOf(opts, pri)
+		if err != nil {
+			return nil, err
+		}
+
+		signature, err := signSchnorr(internalPriKey, digest, hashOpt)
+		if err != nil {
+			return nil, err
+		}
+
+		return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+	}
+
+	r, s, err := signDigest(internalPriKey, digest, pri, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if isLowS(opts) {
+		s = normalizeLowS(internalPriKey.Curve, s)
+	}
+
+	signature, err := MarshalSignature(r, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+}
+
+// signDigest signs digest with random nonce, or with nonce of RFC 6979 if signer option selects deterministic signature.
+func signDigest(internalPriKey *ecdsa.PrivateKey, digest []byte, pri heimdall.PriKey, opts heimdall.SignerOpts) (*big.Int, *big.Int, error) {
+	if !isDeterministic(opts) {
+		return ecdsa.Sign(rand.Reader, internalPriKey, digest)
+	}
+
+	hashOpt, err := heimdall.HashOptOf(opts, pri)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	r, s := signDeterministic(internalPriKey, digest, hashOpt.HashFunc)
+	return r, s, nil
+}
+
+// digestOf hashes message with hash option of signer option, or with default hash option of the key's curve if not specified.
+// Key and hash algorithms denied by algorithm policy are rejected.
+func digestOf(key heimdall.Key, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	hashOpt, err := allowedHashOptOf(key, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return hashing.Hash(message, hashOpt)
+}
+
+// digestOfReader hashes data read from reader until EOF like digestOf, without loading the whole data in memory.
+func digestOfReader(key heimdall.Key, reader io.Reader, opts heimdall.SignerOpts) ([]byte, error) {
+	hashOpt, err := allowedHashOptOf(key, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	hasher := hashing.NewHasher(hashOpt)
+	if _, err := io.Copy(hasher, reader); err != nil {
+		return nil, err
+	}
+
+	return hasher.Sum(), nil
+}
+
+// allowedHashOptOf returns hash option of signer option, after checking scheme parameters and algorithm policy.
+func allowedHashOptOf(key heimdall.Key, opts heimdall.SignerOpts) (*hashing.HashOpt, error) {
+	if err := heimdall.SchemeParamsOf(opts).Validate(key.KeyGenOpt()); err != nil {
+		return nil, err
+	}
+
+	hashOpt, err := heimdall.HashOptOf(opts, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(key.KeyGenOpt()); err != nil {
+		return nil, err
+	}
+
+	if err := heimdall.CheckHashAlgorithm(hashOpt.Name); err != nil {
+		return nil, err
+	}
+
+	return hashOpt, nil
+}
+
 // Verify verifies the signature using pubKey(public key) and digest of original message, then returns boolean value.
+// Signature of any supported version is accepted regardless of version in signer option.
 func Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
-	digest, err := hashing.Hash(message, opts.HashOpt())
+	return verify(pub, signature, message, opts, false)
+}
+
+// VerifyStrict verifies the signature like Verify, but rejects signature not in low-S form with ErrHighS,
+// so that a signature used as identifier (ex. transaction ID) cannot be altered into another valid signature.
+func VerifyStrict(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	return verify(pub, signature, message, opts, true)
+}
+
+func verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts, strict bool) (bool, error) {
+	digest, err := digestOf(pub, message, opts)
+	if err != nil {
+		return false, err
+	}
+
+	return verifyDigest(pub, signature, digest, opts, strict)
+}
+
+// verifyReader verifies the signature for data read from reader until EOF, hashing the data incrementally.
+func verifyReader(pub heimdall.PubKey, signature []byte, reader io.Reader, opts heimdall.SignerOpts, strict bool) (bool, error) {
+	digest, err := digestOfReader(pub, reader, opts)
+	if err != nil {
+		return false, err
+	}
+
+	return verifyDigest(pub, signature, digest, opts, strict)
+}
+
+// verifyDigest verifies the signature for digest of message hashed by hash option of signer option.
+// Public keys other than ECDSA public key are rejected with ErrNotECDSAPubKey.
+func verifyDigest(pub heimdall.PubKey, signature, digest []byte, opts heimdall.SignerOpts, strict bool) (bool, error) {
+	ecdsaPub, ok := pub.(*PubKey)
+	if !ok {
+		return false, ErrNotECDSAPubKey
+	}
+	internalPubKey := ecdsaPub.internalPubKey
+
+	if isSchnorr(opts) {
+		hashOpt, err := heimdall.HashOptOf(opts, pub)
+		if err != nil {
+			return false, err
+		}
+
+		return verifySchnorr(internalPubKey, signature, digest, hashOpt)
+	}
+
+	_, signature, err := heimdall.DecodeSignature(signature)
 	if err != nil {
 		return false, err
 	}
@@ -125,12 +310,30 @@ func Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.Signer
 		return false, err
 	}
 
-	valid := ecdsa.Verify(pub.(*PubKey).internalPubKey, digest, r, s)
+	if strict && !IsLowS(internalPubKey.Curve, s) {
+		return false, ErrHighS
+	}
+
+	valid := ecdsa.Verify(internalPubKey, digest, r, s)
 	return valid, nil
 }
 
 // VerifyWithCert verify a signature with certificate.
 func VerifyWithCert(cert *x509.Certificate, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
-	pub := NewPubKey(cert.PublicKey.(*ecdsa.PublicKey))
-	return Verify(pub, signature, message, opts)
+	ecdsaPubKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return false, ErrNotECDSAPubKey
+	}
+
+	return Verify(NewPubKey(ecdsaPubKey), signature, message, opts)
+}
+
+// VerifyStrictWithCert verifies a signature with certificate, rejecting signature not in low-S form.
+func VerifyStrictWithCert(cert *x509.Certificate, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	ecdsaPubKey, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return false, ErrNotECDSAPubKey
+	}
+
+	return VerifyStrict(NewPubKey(ecdsaPubKey), signature, message, opts)
 }
@@ -30,6 +30,7 @@ import (
 	"github.com/DE-labtory/heimdall/cert"
 	"github.com/DE-labtory/heimdall/hashing"
 	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
 	"github.com/DE-labtory/heimdall/mocks"
 	"github.com/stretchr/testify/assert"
 )
@@ -101,6 +102,86 @@ func TestVerify(t *testing.T) {
 	assert.False(t, inValid2)
 }
 
+func TestVerify_DefaultHashOpt(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	pub := pri.PublicKey()
+	sha384Opt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	sha256Opt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+
+	message := []byte("hello")
+
+	// when
+	signature, err := hecdsa.Sign(pri, message, hecdsa.NewSignerOpts(nil))
+
+	// then
+	assert.NoError(t, err)
+
+	valid, err := hecdsa.Verify(pub, signature, message, hecdsa.NewSignerOpts(sha384Opt))
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hecdsa.Verify(pub, signature, message, hecdsa.NewSignerOpts(sha256Opt))
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestSignAndVerify_NotECDSAKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	ed25519Pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	pri := setUpPriKey(t)
+	message := []byte("hello")
+	signature, err := hecdsa.Sign(pri, message, signerOpt)
+	assert.NoError(t, err)
+
+	// when
+	_, signErr := hecdsa.Sign(ed25519Pri, message, signerOpt)
+	valid, verifyErr := hecdsa.Verify(ed25519Pri.PublicKey(), signature, message, signerOpt)
+
+	// then
+	assert.Equal(t, hecdsa.ErrNotECDSAPriKey, signErr)
+	assert.Equal(t, hecdsa.ErrNotECDSAPubKey, verifyErr)
+	assert.False(t, valid)
+}
+
+func TestVerify_SignatureVersion(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	pub := pri.PublicKey()
+	signer := hecdsa.NewSigner(pri)
+	message := []byte("hello")
+
+	// when
+	legacySignature, err := signer.Sign(message, hecdsa.NewSignerOpts(nil))
+	assert.NoError(t, err)
+	versionedSignature, err := signer.Sign(message, hecdsa.NewSignerOpts(nil).WithSignatureVersion(heimdall.SignatureVersion1))
+	assert.NoError(t, err)
+
+	// then
+	legacyVersion, _, err := heimdall.DecodeSignature(legacySignature)
+	assert.NoError(t, err)
+	assert.Equal(t, heimdall.SignatureVersionLegacy, legacyVersion)
+	assert.Equal(t, heimdall.SignatureVersion1, versionedSignature[0])
+
+	for _, signature := range [][]byte{legacySignature, versionedSignature} {
+		valid, err := hecdsa.Verify(pub, signature, message, hecdsa.NewSignerOpts(nil))
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+
+	_, err = hecdsa.Verify(pub, append([]byte{0x7f}, legacySignature...), message, hecdsa.NewSignerOpts(nil))
+	assert.Equal(t, heimdall.ErrUnsupportedSignatureVersion, err)
+}
+
 func TestVerifyWithCert(t *testing.T) {
 	// given
 	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
@@ -115,15 +196,16 @@ func TestVerifyWithCert(t *testing.T) {
 	assert.NoError(t, err)
 	hPri := hecdsa.NewPriKey(rootPri)
 
-	signature, err := hecdsa.Sign(hPri, message, signerOpt)
-	assert.NotNil(t, signature)
-	assert.NoError(t, err)
-
 	mocks.TestRootCertTemplate.SubjectKeyId = hPri.SKI()
 	derBytes, err := x509.CreateCertificate(rand.Reader, &mocks.TestRootCertTemplate, &mocks.TestRootCertTemplate, rootPub, rootPri)
 	assert.NoError(t, err)
 	rootCert, _ := cert.DERToX509Cert(derBytes)
 
+	// Sign clears private key, so certificate is created before signing.
+	signature, err := hecdsa.Sign(hPri, message, signerOpt)
+	assert.NotNil(t, signature)
+	assert.NoError(t, err)
+
 	// when
 	valid, NoErr := hecdsa.VerifyWithCert(rootCert, signature, message, signerOpt)
 
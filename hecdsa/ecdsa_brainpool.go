@@ -0,0 +1,209 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Brainpool curves of RFC 5639, whose equation y² = x³ + ax + b has a ≠ -3,
+// so they can not be implemented by elliptic.CurveParams.
+
+package hecdsa
+
+import (
+	"crypto/elliptic"
+	"math/big"
+)
+
+// weierstrassCurve is a short Weierstrass curve y² = x³ + ax + b over prime field of any a.
+// Points are in affine coordinates, and point at infinity is (0, 0) as in elliptic package.
+// Arithmetic is not constant time, like curves of elliptic.CurveParams.
+type weierstrassCurve struct {
+	params *elliptic.CurveParams
+	a      *big.Int
+}
+
+func newWeierstrassCurve(name string, bitSize int, p, a, b, gx, gy, n string) *weierstrassCurve {
+	return &weierstrassCurve{
+		params: &elliptic.CurveParams{
+			P:       hexInt(p),
+			N:       hexInt(n),
+			B:       hexInt(b),
+			Gx:      hexInt(gx),
+			Gy:      hexInt(gy),
+			BitSize: bitSize,
+			Name:    name,
+		},
+		a: hexInt(a),
+	}
+}
+
+func hexInt(s string) *big.Int {
+	i, _ := new(big.Int).SetString(s, 16)
+	return i
+}
+
+var brainpoolP256r1 = newWeierstrassCurve(ECBP256R1, 256,
+	"A9FB57DBA1EEA9BC3E660A909D838D726E3BF623D52620282013481D1F6E5377",
+	"7D5A0975FC2C3057EEF67530417AFFE7FB8055C126DC5C6CE94A4B44F330B5D9",
+	"26DC5C6CE94A4B44F330B5D9BBD77CBF958416295CF7E1CE6BCCDC18FF8C07B6",
+	"8BD2AEB9CB7E57CB2C4B482FFC81B7AFB9DE27E1E3BD23C23A4453BD9ACE3262",
+	"547EF835C3DAC4FD97F8461A14611DC9C27745132DED8E545C1D54C72F046997",
+	"A9FB57DBA1EEA9BC3E660A909D838D718C397AA3B561A6F7901E0E82974856A7",
+)
+
+var brainpoolP384r1 = newWeierstrassCurve(ECBP384R1, 384,
+	"8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B412B1DA197FB71123ACD3A729901D1A71874700133107EC53",
+	"7BC382C63D8C150C3C72080ACE05AFA0C2BEA28E4FB22787139165EFBA91F90F8AA5814A503AD4EB04A8C7DD22CE2826",
+	"04A8C7DD22CE28268B39B55416F0447C2FB77DE107DCD2A62E880EA53EEB62D57CB4390295DBC9943AB78696FA504C11",
+	"1D1C64F068CF45FFA2A63A81B7C13F6B8847A3E77EF14FE3DB7FCAFE0CBD10E8E826E03436D646AAEF87B2E247D4AF1E",
+	"8ABE1D7520F9C2A45CB1EB8E95CFD55262B70B29FEEC5864E19C054FF99129280E4646217791811142820341263C5315",
+	"8CB91E82A3386D280F5D6F7E50E641DF152F7109ED5456B31F166E6CAC0425A7CF3AB6AF6B7FC3103B883202E9046565",
+)
+
+func (curve *weierstrassCurve) Params() *elliptic.CurveParams {
+	return curve.params
+}
+
+// rhs returns x³ + ax + b.
+func (curve *weierstrassCurve) rhs(x *big.Int) *big.Int {
+	p := curve.params.P
+
+	y2 := new(big.Int).Mul(x, x)
+	y2.Add(y2, curve.a)
+	y2.Mul(y2, x)
+	y2.Add(y2, curve.params.B)
+
+	return y2.Mod(y2, p)
+}
+
+func (curve *weierstrassCurve) IsOnCurve(x, y *big.Int) bool {
+	p := curve.params.P
+	if x.Sign() < 0 || x.Cmp(p) >= 0 || y.Sign() < 0 || y.Cmp(p) >= 0 {
+		return false
+	}
+
+	y2 := new(big.Int).Mul(y, y)
+	return y2.Mod(y2, p).Cmp(curve.rhs(x)) == 0
+}
+
+func (curve *weierstrassCurve) Add(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+	if isInfinity(x1, y1) {
+		return new(big.Int).Set(x2), new(big.Int).Set(y2)
+	}
+	if isInfinity(x2, y2) {
+		return new(big.Int).Set(x1), new(big.Int).Set(y1)
+	}
+
+	p := curve.params.P
+	if x1.Cmp(x2) == 0 {
+		if y1.Cmp(y2) == 0 {
+			return curve.Double(x1, y1)
+		}
+		return new(big.Int), new(big.Int)
+	}
+
+	// λ = (y2 - y1) / (x2 - x1)
+	lambda := new(big.Int).Sub(x2, x1)
+	lambda.ModInverse(lambda.Mod(lambda, p), p)
+	lambda.Mul(lambda, new(big.Int).Sub(y2, y1))
+	lambda.Mod(lambda, p)
+
+	return curve.addWithSlope(lambda, x1, y1, x2)
+}
+
+func (curve *weierstrassCurve) Double(x1, y1 *big.Int) (*big.Int, *big.Int) {
+	if isInfinity(x1, y1) || y1.Sign() == 0 {
+		return new(big.Int), new(big.Int)
+	}
+
+	p := curve.params.P
+
+	// λ = (3x² + a) / 2y
+	lambda := new(big.Int).Lsh(y1, 1)
+	lambda.ModInverse(lambda.Mod(lambda, p), p)
+	numerator := new(big.Int).Mul(x1, x1)
+	numerator.Mul(numerator, big.NewInt(3))
+	numerator.Add(numerator, curve.a)
+	lambda.Mul(lambda, numerator)
+	lambda.Mod(lambda, p)
+
+	return curve.addWithSlope(lambda, x1, y1, x1)
+}
+
+// addWithSlope returns x3 = λ² - x1 - x2 and y3 = λ(x1 - x3) - y1.
+func (curve *weierstrassCurve) addWithSlope(lambda, x1, y1, x2 *big.Int) (*big.Int, *big.Int) {
+	p := curve.params.P
+
+	x3 := new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, x1)
+	x3.Sub(x3, x2)
+	x3.Mod(x3, p)
+
+	y3 := new(big.Int).Sub(x1, x3)
+	y3.Mul(y3, lambda)
+	y3.Sub(y3, y1)
+	y3.Mod(y3, p)
+
+	return x3, y3
+}
+
+func (curve *weierstrassCurve) ScalarMult(x1, y1 *big.Int, k []byte) (*big.Int, *big.Int) {
+	x, y := new(big.Int), new(big.Int)
+	for _, b := range k {
+		for bit := 7; bit >= 0; bit-- {
+			x, y = curve.Double(x, y)
+			if b>>uint(bit)&1 == 1 {
+				x, y = curve.Add(x, y, x1, y1)
+			}
+		}
+	}
+
+	return x, y
+}
+
+func (curve *weierstrassCurve) ScalarBaseMult(k []byte) (*big.Int, *big.Int) {
+	return curve.ScalarMult(curve.params.Gx, curve.params.Gy, k)
+}
+
+// UnmarshalCompressed decodes compressed point of SEC 1, taking square root of x³ + ax + b.
+func (curve *weierstrassCurve) UnmarshalCompressed(data []byte) (*big.Int, *big.Int) {
+	byteLen := (curve.params.BitSize + 7) / 8
+	if len(data) != 1+byteLen || (data[0] != 2 && data[0] != 3) {
+		return nil, nil
+	}
+
+	p := curve.params.P
+	x := new(big.Int).SetBytes(data[1:])
+	if x.Cmp(p) >= 0 {
+		return nil, nil
+	}
+
+	y := new(big.Int).ModSqrt(curve.rhs(x), p)
+	if y == nil {
+		return nil, nil
+	}
+	if byte(y.Bit(0)) != data[0]&1 {
+		y.Sub(p, y)
+	}
+
+	if !curve.IsOnCurve(x, y) {
+		return nil, nil
+	}
+
+	return x, y
+}
+
+func isInfinity(x, y *big.Int) bool {
+	return x.Sign() == 0 && y.Sign() == 0
+}
@@ -0,0 +1,54 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalCompressed_Brainpool(t *testing.T) {
+	for _, curve := range []elliptic.Curve{brainpoolP256r1, brainpoolP384r1} {
+		t.Logf("running test case [%s]", curve.Params().Name)
+
+		// given
+		pri, err := ecdsa.GenerateKey(curve, rand.Reader)
+		assert.NoError(t, err)
+		compressed := elliptic.MarshalCompressed(curve, pri.X, pri.Y)
+
+		// when
+		x, y := unmarshalCompressed(curve, compressed)
+
+		// then
+		assert.True(t, curve.IsOnCurve(pri.X, pri.Y))
+		assert.Equal(t, pri.X, x)
+		assert.Equal(t, pri.Y, y)
+
+		// when
+		compressed[1] ^= 0xff
+		x, y = unmarshalCompressed(curve, compressed[:len(compressed)-1])
+
+		// then
+		assert.Nil(t, x)
+		assert.Nil(t, y)
+	}
+}
@@ -0,0 +1,266 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides registry of elliptic curves for ECDSA keys, and encoding of keys on curves x509 does not know.
+
+package hecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/decred/dcrd/dcrec/secp256k1/v4"
+)
+
+var ErrInvalidCurveOID = errors.New("invalid curve OID - curve OID should not be empty or registered already")
+var ErrInvalidECKey = errors.New("invalid EC key - key is not on registered curve")
+
+// oidPublicKeyECDSA is algorithm identifier of ECDSA public keys. (RFC 5480)
+var oidPublicKeyECDSA = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+
+// PointDecompressor is implemented by curves whose equation is not y² = x³ - 3x + b (ex. Brainpool curves),
+// which elliptic.UnmarshalCompressed assumes. It returns nil if data is not a compressed point on the curve.
+type PointDecompressor interface {
+	UnmarshalCompressed(data []byte) (x, y *big.Int)
+}
+
+// registeredCurve is an elliptic curve with its object identifier, and decompression of points on the curve.
+type registeredCurve struct {
+	curve      elliptic.Curve
+	oid        asn1.ObjectIdentifier
+	decompress func(data []byte) (x, y *big.Int)
+}
+
+// curves maps upper case curve names to curves. Keys on NIST curves are encoded by x509,
+// and keys on other curves are encoded by marshalECPrivateKey and marshalPKIXPublicKey.
+var curves = map[string]*registeredCurve{
+	ECP224: {elliptic.P224(), asn1.ObjectIdentifier{1, 3, 132, 0, 33}, nil},
+	ECP256: {elliptic.P256(), asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7}, nil},
+	ECP384: {elliptic.P384(), asn1.ObjectIdentifier{1, 3, 132, 0, 34}, nil},
+	ECP521: {elliptic.P521(), asn1.ObjectIdentifier{1, 3, 132, 0, 35}, nil},
+
+	strings.ToUpper(ECSECP256K1): {secp256k1.S256(), asn1.ObjectIdentifier{1, 3, 132, 0, 10}, unmarshalCompressedSecp256k1},
+
+	strings.ToUpper(ECBP256R1): {brainpoolP256r1, asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 7}, brainpoolP256r1.UnmarshalCompressed},
+	strings.ToUpper(ECBP384R1): {brainpoolP384r1, asn1.ObjectIdentifier{1, 3, 36, 3, 3, 2, 8, 1, 1, 11}, brainpoolP384r1.UnmarshalCompressed},
+}
+var curvesMutex = &sync.RWMutex{}
+
+// RegisterCurve registers elliptic curve (ex. Brainpool or custom domain parameters) with key ID prefix and object identifier,
+// so that ECDSA keys on the curve can be generated, identified, stored, loaded and used for signing.
+// Name of the curve is Params().Name, and the curve implementation should be safe for use in ECDSA.
+// Curves whose equation is not y² = x³ - 3x + b should implement PointDecompressor.
+func RegisterCurve(curve elliptic.Curve, keyIDPrefix string, oid asn1.ObjectIdentifier) error {
+	if len(oid) == 0 {
+		return ErrInvalidCurveOID
+	}
+
+	curvesMutex.Lock()
+	defer curvesMutex.Unlock()
+
+	for _, registered := range curves {
+		if registered.oid.Equal(oid) {
+			return ErrInvalidCurveOID
+		}
+	}
+
+	params := curve.Params()
+	if err := heimdall.RegisterCurve(params.Name, params.BitSize, keyIDPrefix); err != nil {
+		return err
+	}
+
+	registered := &registeredCurve{curve: curve, oid: oid}
+	if decompressor, ok := curve.(PointDecompressor); ok {
+		registered.decompress = decompressor.UnmarshalCompressed
+	}
+	curves[strings.ToUpper(params.Name)] = registered
+
+	return nil
+}
+
+// curveByName finds registered curve by case insensitive name.
+func curveByName(name string) (*registeredCurve, bool) {
+	curvesMutex.RLock()
+	defer curvesMutex.RUnlock()
+
+	registered, ok := curves[strings.ToUpper(name)]
+	return registered, ok
+}
+
+// curveByOID finds registered curve by object identifier.
+func curveByOID(oid asn1.ObjectIdentifier) (*registeredCurve, bool) {
+	curvesMutex.RLock()
+	defer curvesMutex.RUnlock()
+
+	for _, registered := range curves {
+		if registered.oid.Equal(oid) {
+			return registered, true
+		}
+	}
+
+	return nil, false
+}
+
+// unmarshalCompressed decodes compressed point by decompression of registered curve,
+// or by elliptic.UnmarshalCompressed for curves of a = -3.
+func unmarshalCompressed(curve elliptic.Curve, data []byte) (*big.Int, *big.Int) {
+	if registered, ok := curveByName(curve.Params().Name); ok && registered.decompress != nil {
+		return registered.decompress(data)
+	}
+
+	return elliptic.UnmarshalCompressed(curve, data)
+}
+
+// unmarshalCompressedSecp256k1 decodes compressed point on secp256k1, whose equation is y² = x³ + 7.
+func unmarshalCompressedSecp256k1(data []byte) (*big.Int, *big.Int) {
+	if len(data) != secp256k1.PubKeyBytesLenCompressed {
+		return nil, nil
+	}
+
+	pub, err := secp256k1.ParsePubKey(data)
+	if err != nil {
+		return nil, nil
+	}
+
+	return pub.X(), pub.Y()
+}
+
+// isX509Curve checks if x509 encodes keys on the curve.
+func isX509Curve(curve elliptic.Curve) bool {
+	switch curve {
+	case elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return true
+	}
+
+	return false
+}
+
+// ecPrivateKey is ASN.1 structure of EC private key. (SEC 1, RFC 5915)
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// publicKeyInfo is ASN.1 structure of subject public key info. (RFC 5280)
+type publicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// marshalECPrivateKey encodes private key on registered curve in SEC 1 format.
+func marshalECPrivateKey(pri *ecdsa.PrivateKey) ([]byte, error) {
+	registered, ok := curveByName(pri.Curve.Params().Name)
+	if !ok {
+		return nil, ErrCurveNotSupported
+	}
+
+	privateKey := make([]byte, (pri.Curve.Params().N.BitLen()+7)/8)
+	return asn1.Marshal(ecPrivateKey{
+		Version:       1,
+		PrivateKey:    pri.D.FillBytes(privateKey),
+		NamedCurveOID: registered.oid,
+		PublicKey:     asn1.BitString{Bytes: elliptic.Marshal(pri.Curve, pri.X, pri.Y)},
+	})
+}
+
+// parseECPrivateKey decodes private key on registered curve encoded by marshalECPrivateKey.
+func parseECPrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+	var privateKey ecPrivateKey
+	rest, err := asn1.Unmarshal(der, &privateKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, ErrInvalidECKey
+	}
+
+	registered, ok := curveByOID(privateKey.NamedCurveOID)
+	if !ok {
+		return nil, ErrCurveNotSupported
+	}
+
+	d := new(big.Int).SetBytes(privateKey.PrivateKey)
+	if d.Sign() <= 0 || d.Cmp(registered.curve.Params().N) >= 0 {
+		return nil, ErrInvalidECKey
+	}
+
+	pri := &ecdsa.PrivateKey{D: d}
+	pri.Curve = registered.curve
+	pri.X, pri.Y = registered.curve.ScalarBaseMult(privateKey.PrivateKey)
+
+	return pri, nil
+}
+
+// marshalPKIXPublicKey encodes public key on registered curve in subject public key info format.
+func marshalPKIXPublicKey(pub *ecdsa.PublicKey) ([]byte, error) {
+	registered, ok := curveByName(pub.Curve.Params().Name)
+	if !ok {
+		return nil, ErrCurveNotSupported
+	}
+
+	oidBytes, err := asn1.Marshal(registered.oid)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey := elliptic.Marshal(pub.Curve, pub.X, pub.Y)
+	return asn1.Marshal(publicKeyInfo{
+		Algorithm: pkix.AlgorithmIdentifier{
+			Algorithm:  oidPublicKeyECDSA,
+			Parameters: asn1.RawValue{FullBytes: oidBytes},
+		},
+		PublicKey: asn1.BitString{Bytes: publicKey, BitLength: 8 * len(publicKey)},
+	})
+}
+
+// parsePKIXPublicKey decodes public key on registered curve encoded by marshalPKIXPublicKey.
+func parsePKIXPublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	var info publicKeyInfo
+	rest, err := asn1.Unmarshal(der, &info)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 || !info.Algorithm.Algorithm.Equal(oidPublicKeyECDSA) {
+		return nil, ErrInvalidECKey
+	}
+
+	var oid asn1.ObjectIdentifier
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &oid); err != nil {
+		return nil, err
+	}
+
+	registered, ok := curveByOID(oid)
+	if !ok {
+		return nil, ErrCurveNotSupported
+	}
+
+	x, y := elliptic.Unmarshal(registered.curve, info.PublicKey.RightAlign())
+	if x == nil {
+		return nil, ErrInvalidECKey
+	}
+
+	return &ecdsa.PublicKey{Curve: registered.curve, X: x, Y: y}, nil
+}
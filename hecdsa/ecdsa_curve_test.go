@@ -0,0 +1,309 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"crypto/elliptic"
+	"encoding/asn1"
+	"encoding/hex"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCurveName = "TESTCURVE256"
+
+var testCurveOnce sync.Once
+
+// setUpTestCurve registers a curve with P-256 domain parameters under another name,
+// which x509 does not know.
+func setUpTestCurve(t *testing.T) elliptic.Curve {
+	params := *elliptic.P256().Params()
+	params.Name = testCurveName
+
+	testCurveOnce.Do(func() {
+		err := hecdsa.RegisterCurve(&params, "ECTEST256", asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 1})
+		assert.NoError(t, err)
+	})
+
+	return &params
+}
+
+func TestRegisterCurve(t *testing.T) {
+	// given
+	setUpTestCurve(t)
+
+	// when
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(testCurveName)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, testCurveName, keyGenOpt.ToString())
+	assert.Equal(t, 256, keyGenOpt.KeySize())
+}
+
+func TestRegisterCurve_Registered(t *testing.T) {
+	// given
+	setUpTestCurve(t)
+	params := *elliptic.P256().Params()
+	params.Name = testCurveName
+
+	// when
+	err := hecdsa.RegisterCurve(&params, "ECTESTAGAIN", asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 99999, 2})
+
+	// then
+	assert.Equal(t, heimdall.ErrCurveRegistered, err)
+}
+
+func TestRegisterCurve_RegisteredOID(t *testing.T) {
+	// given
+	params := *elliptic.P256().Params()
+	params.Name = "TESTCURVEOID"
+
+	// when
+	err := hecdsa.RegisterCurve(&params, "ECTESTOID", asn1.ObjectIdentifier{1, 2, 840, 10045, 3, 1, 7})
+
+	// then
+	assert.Equal(t, hecdsa.ErrInvalidCurveOID, err)
+}
+
+func TestRegisterCurve_KeyID(t *testing.T) {
+	// given
+	setUpTestCurve(t)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(testCurveName)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	info, err := heimdall.ParseKeyID(pri.ID())
+
+	// then
+	assert.NoError(t, err)
+	assert.Contains(t, pri.ID(), "ECTEST256x")
+	assert.Equal(t, testCurveName, info.KeyType.Curve)
+	assert.Equal(t, pri.SKI(), info.SKI)
+}
+
+func TestRegisterCurve_ToByte(t *testing.T) {
+	// given
+	setUpTestCurve(t)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(testCurveName)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	recoverer := &hecdsa.KeyRecoverer{}
+
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+
+	// when
+	recoveredPri, priErr := recoverer.RecoverKeyFromByte(priBytes, true)
+	recoveredPub, pubErr := recoverer.RecoverKeyFromByte(pubBytes, false)
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri.ID(), recoveredPri.ID())
+	assert.Equal(t, pri.ID(), recoveredPub.ID())
+}
+
+func TestRegisterCurve_KeyStore(t *testing.T) {
+	// given
+	setUpTestCurve(t)
+	kdfOpt, err := kdf.NewOpts("SCRYPT", map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(testCurveName)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	loadedPri, priErr := keyStore.LoadPriKey(pri.ID(), "password")
+	loadedPub, pubErr := keyStore.LoadPubKey(pri.ID())
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri, loadedPri)
+	assert.Equal(t, pri.PublicKey(), loadedPub)
+}
+
+func TestRegisterCurve_Sign(t *testing.T) {
+	// given
+	setUpTestCurve(t)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(testCurveName)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+
+	// when
+	signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestSecp256k1_KeyStore(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts("SCRYPT", map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECSECP256K1)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+
+	// when
+	loadedPri, err := keyStore.LoadPriKey(pri.ID(), "password")
+	assert.NoError(t, err)
+	signature, signErr := hecdsa.NewSigner(loadedPri).Sign(message, signerOpt)
+
+	// then
+	assert.NoError(t, signErr)
+	assert.Equal(t, pri, loadedPri)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+// Brainpool keys and signatures generated by OpenSSL 3. (openssl ecparam -genkey, openssl dgst -sign)
+var brainpoolVectors = []struct {
+	curve     string
+	hashOpt   string
+	priKeyDER string
+	pubKeyDER string
+	signature string
+}{
+	{
+		curve:     hecdsa.ECBP256R1,
+		hashOpt:   hashing.SHA2_256,
+		priKeyDER: "3078020101042073e2499f4f5f5bbf180205ba6b9a2716595d42ca875ec8dcef373a96b98f3baba00b06092b2403030208010107a14403420004a44ed0e0ed416cccc4ff677cad478f80c0a7676f4c396ebdd3ff7079c626908d3623776070f565757b3f11243ccc891626e6d5cbc4ef4537518654bd7cf51f45",
+		pubKeyDER: "305a301406072a8648ce3d020106092b240303020801010703420004a44ed0e0ed416cccc4ff677cad478f80c0a7676f4c396ebdd3ff7079c626908d3623776070f565757b3f11243ccc891626e6d5cbc4ef4537518654bd7cf51f45",
+		signature: "304402206f2dbcddd8f5f97f0cee277dcc92e072ae3f66f4a67a771038f6ce6dabe9e78f022073996011a4704c228afffeeda4788c71169ed35014760e1eba365fba75945c5f",
+	},
+	{
+		curve:     hecdsa.ECBP384R1,
+		hashOpt:   hashing.SHA384,
+		priKeyDER: "3081a802010104308173675835649700eaee864c21ec496d95d2c65530fa4918134fa32fd880fea2e5fe1889b9106fbbdf479213061d3d66a00b06092b240303020801010ba164036200043bdb8b7c1e86dbdc8915e3e258026e7d1e6f6b76de112c9aa9c472031e35a6b8f971fd41e81fd9b12b73799edfbaafba7f0dbc49f61c13782f41b66df38495946df2977304e256002dfbf241939ca913979d1fef239ade8bbdb7b98bb7464beb",
+		pubKeyDER: "307a301406072a8648ce3d020106092b240303020801010b036200043bdb8b7c1e86dbdc8915e3e258026e7d1e6f6b76de112c9aa9c472031e35a6b8f971fd41e81fd9b12b73799edfbaafba7f0dbc49f61c13782f41b66df38495946df2977304e256002dfbf241939ca913979d1fef239ade8bbdb7b98bb7464beb",
+		signature: "306502306d04f760c828c47e9eab07486929899bd3127cb1a83c0475530bb6ed78414e9570bf31a852e1726550c3f3b90e72e1180231008674fd4be3287a034a50ad2352dd951659785a776f790ac2d0a49d2f2f869eb35f9148c5a739c72b6b998ef749343934",
+	},
+}
+
+func TestBrainpool_KnownAnswer(t *testing.T) {
+	recoverer := &hecdsa.KeyRecoverer{}
+
+	for _, vector := range brainpoolVectors {
+		t.Logf("running test case [%s]", vector.curve)
+
+		// given
+		priKeyDER, err := hex.DecodeString(vector.priKeyDER)
+		assert.NoError(t, err)
+		signature, err := hex.DecodeString(vector.signature)
+		assert.NoError(t, err)
+		hashOpt, err := hashing.NewHashOpt(vector.hashOpt)
+		assert.NoError(t, err)
+		signerOpt := hecdsa.NewSignerOpts(hashOpt)
+		message := []byte("heimdall brainpool")
+
+		// when
+		pri, err := recoverer.RecoverKeyFromByte(priKeyDER, true)
+		assert.NoError(t, err)
+		pubKeyDER, err := pri.(heimdall.PriKey).PublicKey().ToByte()
+		assert.NoError(t, err)
+		valid, err := hecdsa.Verify(pri.(heimdall.PriKey).PublicKey(), signature, message, signerOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.True(t, valid)
+		assert.Equal(t, vector.pubKeyDER, hex.EncodeToString(pubKeyDER))
+		assert.Equal(t, vector.curve, pri.KeyGenOpt().ToString())
+		assert.Contains(t, pri.ID(), "ECBP")
+
+		ownSignature, err := hecdsa.Sign(pri.(heimdall.PriKey), message, signerOpt)
+		assert.NoError(t, err)
+		valid, err = hecdsa.Verify(pri.(heimdall.PriKey).PublicKey(), ownSignature, message, signerOpt)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+}
+
+func TestBrainpool_KeyStore(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts("SCRYPT", map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECBP256R1)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	loadedPri, priErr := keyStore.LoadPriKey(pri.ID(), "password")
+	loadedPub, pubErr := keyStore.LoadPubKey(pri.ID())
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri, loadedPri)
+	assert.Equal(t, pri.ID(), loadedPub.ID())
+}
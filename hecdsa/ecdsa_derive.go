@@ -0,0 +1,148 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides purpose-specific subkey derivation from a master ECDSA key.
+
+package hecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"golang.org/x/crypto/hkdf"
+)
+
+var ErrNotECDSAPriKey = errors.New("invalid private key - key is not ECDSA private key")
+var ErrMasterKeyMismatch = errors.New("master key mismatch - subkey is derived from other master key")
+var ErrEmptyPurpose = errors.New("purpose of subkey should not be empty")
+
+// subkey purposes
+const (
+	PurposeSigning = "signing"
+	PurposeTLS     = "tls"
+)
+
+// Derivation is public data of subkey derivation, which should be recorded in metadata of the subkey.
+// The subkey can be derived again from the master key with it, while it reveals nothing about the keys.
+type Derivation struct {
+	MasterKeyID heimdall.KeyID
+	SubKeyID    heimdall.KeyID
+	Purpose     string
+	Curve       string
+	Salt        []byte
+}
+
+// DeriveSubKey derives subkey for purpose from master key by HKDF with random salt.
+// Subkeys of different purposes are independent, so compromise of one subkey exposes neither master key nor other subkeys.
+func DeriveSubKey(master heimdall.PriKey, purpose string, keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, *Derivation, error) {
+	opt, err := ToKeyGenOpt(keyGenOpt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	salt := make([]byte, 32)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, nil, err
+	}
+
+	derivation := &Derivation{
+		MasterKeyID: master.ID(),
+		Purpose:     purpose,
+		Curve:       opt.ToString(),
+		Salt:        salt,
+	}
+
+	sub, err := deriveSubKey(master, derivation)
+	if err != nil {
+		return nil, nil, err
+	}
+	derivation.SubKeyID = sub.ID()
+
+	return sub, derivation, nil
+}
+
+// RederiveSubKey derives subkey again from master key with recorded derivation data.
+func RederiveSubKey(master heimdall.PriKey, derivation *Derivation) (heimdall.PriKey, error) {
+	if !heimdall.MatchKeyID(derivation.MasterKeyID, master) {
+		return nil, ErrMasterKeyMismatch
+	}
+
+	sub, err := deriveSubKey(master, derivation)
+	if err != nil {
+		return nil, err
+	}
+
+	if !heimdall.MatchKeyID(derivation.SubKeyID, sub) {
+		sub.Clear()
+		return nil, ErrWrongKeyID
+	}
+
+	return sub, nil
+}
+
+func deriveSubKey(master heimdall.PriKey, derivation *Derivation) (heimdall.PriKey, error) {
+	masterKey, ok := master.(*PriKey)
+	if !ok {
+		return nil, ErrNotECDSAPriKey
+	}
+
+	if derivation.Purpose == "" {
+		return nil, ErrEmptyPurpose
+	}
+
+	opt, err := NewKeyGenOpt(derivation.Curve)
+	if err != nil {
+		return nil, err
+	}
+	params := opt.Curve.Params()
+
+	ikm := masterKey.internalPriKey.D.Bytes()
+	defer clearBytes(ikm)
+
+	info := []byte("heimdall subkey\x00" + derivation.Purpose + "\x00" + derivation.Curve)
+	reader := hkdf.New(sha256.New, ikm, derivation.Salt, info)
+
+	// 64 more bits than the order makes bias of the reduction negligible (FIPS 186-4 B.4.1)
+	b := make([]byte, (params.N.BitLen()+64+7)/8)
+	if _, err := io.ReadFull(reader, b); err != nil {
+		return nil, err
+	}
+	defer clearBytes(b)
+
+	// d = b mod (n - 1) + 1
+	nMinusOne := new(big.Int).Sub(params.N, big.NewInt(1))
+	d := new(big.Int).SetBytes(b)
+	d.Mod(d, nMinusOne)
+	d.Add(d, big.NewInt(1))
+
+	sub := &ecdsa.PrivateKey{D: d}
+	sub.PublicKey.Curve = opt.Curve
+	sub.PublicKey.X, sub.PublicKey.Y = opt.Curve.ScalarBaseMult(d.Bytes())
+
+	return NewPriKey(sub), nil
+}
+
+func clearBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveSubKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	master, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	signingKey, signingDerivation, err := hecdsa.DeriveSubKey(master, hecdsa.PurposeSigning, keyGenOpt)
+	assert.NoError(t, err)
+	tlsKey, tlsDerivation, err := hecdsa.DeriveSubKey(master, hecdsa.PurposeTLS, keyGenOpt)
+	assert.NoError(t, err)
+
+	// then
+	assert.NotEqual(t, signingKey.ID(), tlsKey.ID())
+	assert.NotEqual(t, master.ID(), signingKey.ID())
+	assert.Equal(t, master.ID(), signingDerivation.MasterKeyID)
+	assert.Equal(t, signingKey.ID(), signingDerivation.SubKeyID)
+	assert.Equal(t, tlsKey.ID(), tlsDerivation.SubKeyID)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpts := hecdsa.NewSignerOpts(hashOpt)
+	signature, err := hecdsa.NewSigner(signingKey).Sign([]byte("block"), signerOpts)
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(signingKey.PublicKey(), signature, []byte("block"), signerOpts)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestRederiveSubKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	master, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	other, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	sub, derivation, err := hecdsa.DeriveSubKey(master, hecdsa.PurposeTLS, keyGenOpt)
+	assert.NoError(t, err)
+
+	data, err := json.Marshal(derivation)
+	assert.NoError(t, err)
+	recorded := &hecdsa.Derivation{}
+	assert.NoError(t, json.Unmarshal(data, recorded))
+
+	// when
+	rederived, err := hecdsa.RederiveSubKey(master, recorded)
+	_, masterErr := hecdsa.RederiveSubKey(other, recorded)
+	recorded.Purpose = hecdsa.PurposeSigning
+	_, purposeErr := hecdsa.RederiveSubKey(master, recorded)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, sub, rederived)
+	assert.Equal(t, hecdsa.ErrMasterKeyMismatch, masterErr)
+	assert.Equal(t, hecdsa.ErrWrongKeyID, purposeErr)
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides ECIES public key encryption with ECDSA keys, so payloads can be addressed to existing identity keys.
+// Ciphertext is uncompressed ephemeral point || nonce || AES-GCM ciphertext, and the AES-256 key is derived from
+// the X coordinate of ECDH shared point by HKDF-SHA256.
+
+package hecdsa
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"golang.org/x/crypto/hkdf"
+)
+
+var ErrInvalidECIESCiphertext = errors.New("invalid ECIES ciphertext - ciphertext should be ephemeral point of the key's curve, nonce and sealed payload")
+var ErrECIESKeyCleared = errors.New("ECIES decryption failed - private key is cleared")
+
+// info for deriving ECIES key by HKDF
+var eciesInfo = []byte("heimdall ecies")
+
+// eciesNonceSize is nonce size of AES-GCM.
+const eciesNonceSize = 12
+
+// Encrypt encrypts plaintext to the owner of the public key by ECIES with ephemeral key on the key's curve.
+// additionalData is authenticated but not encrypted, and should be given again for decryption.
+func (pubKey *PubKey) Encrypt(plaintext, additionalData []byte) ([]byte, error) {
+	if err := heimdall.CheckKeyUsage(pubKey, heimdall.PurposeEncrypt); err != nil {
+		return nil, err
+	}
+
+	pub := pubKey.internalPubKey
+
+	ephemeralPri, err := ecdsa.GenerateKey(pub.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	defer ephemeralPri.D.SetInt64(0)
+
+	ephemeralPoint := elliptic.Marshal(pub.Curve, ephemeralPri.X, ephemeralPri.Y)
+
+	aead, err := newECIESAEAD(pub, ephemeralPri.D, pub.X, pub.Y, ephemeralPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, eciesNonceSize)
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	ciphertext := append(ephemeralPoint, nonce...)
+
+	return aead.Seal(ciphertext, nonce, plaintext, additionalData), nil
+}
+
+// Decrypt decrypts ECIES ciphertext encrypted to the public key of the private key.
+func (priKey *PriKey) Decrypt(ciphertext, additionalData []byte) ([]byte, error) {
+	if err := heimdall.CheckKeyUsage(priKey, heimdall.PurposeEncrypt); err != nil {
+		return nil, err
+	}
+
+	pri := priKey.internalPriKey
+	if pri.D.Sign() == 0 {
+		return nil, ErrECIESKeyCleared
+	}
+
+	pointSize := 1 + 2*((pri.Curve.Params().BitSize+7)/8)
+	if len(ciphertext) < pointSize+eciesNonceSize {
+		return nil, ErrInvalidECIESCiphertext
+	}
+
+	ephemeralPoint := ciphertext[:pointSize]
+	x, y := elliptic.Unmarshal(pri.Curve, ephemeralPoint)
+	if x == nil {
+		return nil, ErrInvalidECIESCiphertext
+	}
+
+	aead, err := newECIESAEAD(&pri.PublicKey, pri.D, x, y, ephemeralPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := ciphertext[pointSize : pointSize+eciesNonceSize]
+
+	return aead.Open(nil, nonce, ciphertext[pointSize+eciesNonceSize:], additionalData)
+}
+
+// newECIESAEAD computes ECDH shared point of scalar d and point (x, y), derives AES-256 key from its X coordinate by HKDF
+// salted with ephemeral point and bound to recipient public key, and makes AES-GCM with the key.
+func newECIESAEAD(recipient *ecdsa.PublicKey, d, x, y *big.Int, ephemeralPoint []byte) (cipher.AEAD, error) {
+	curve := recipient.Curve
+	byteLen := (curve.Params().BitSize + 7) / 8
+
+	sharedX, sharedY := curve.ScalarMult(x, y, d.FillBytes(make([]byte, byteLen)))
+	if sharedX.Sign() == 0 && sharedY.Sign() == 0 {
+		return nil, ErrInvalidECIESCiphertext
+	}
+
+	sharedSecret := sharedX.FillBytes(make([]byte, byteLen))
+	defer func() {
+		for i := range sharedSecret {
+			sharedSecret[i] = 0
+		}
+	}()
+
+	info := append(append([]byte{}, eciesInfo...), elliptic.Marshal(curve, recipient.X, recipient.Y)...)
+
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, ephemeralPoint, info), key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
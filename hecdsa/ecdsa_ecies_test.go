@@ -0,0 +1,120 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPubKey_Encrypt(t *testing.T) {
+	for _, curve := range []string{hecdsa.ECP224, hecdsa.ECP256, hecdsa.ECP384, hecdsa.ECP521} {
+		t.Logf("running test case [%s]", curve)
+
+		// given
+		keyGenOpt, err := hecdsa.NewKeyGenOpt(curve)
+		assert.NoError(t, err)
+		pri, err := hecdsa.GenerateKey(keyGenOpt)
+		assert.NoError(t, err)
+		pub := pri.PublicKey().(*hecdsa.PubKey)
+		plaintext := []byte("confidential payload")
+		additionalData := []byte("channel")
+
+		// when
+		ciphertext, err := pub.Encrypt(plaintext, additionalData)
+		assert.NoError(t, err)
+		decrypted, err := pri.(*hecdsa.PriKey).Decrypt(ciphertext, additionalData)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, plaintext, decrypted)
+	}
+}
+
+func TestPubKey_Encrypt_Randomized(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	pub := pri.PublicKey().(*hecdsa.PubKey)
+
+	// when
+	ciphertext1, err1 := pub.Encrypt([]byte("hello"), nil)
+	ciphertext2, err2 := pub.Encrypt([]byte("hello"), nil)
+
+	// then
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.NotEqual(t, ciphertext1, ciphertext2)
+}
+
+func TestPriKey_Decrypt_Failure(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	otherPri := setUpPriKey(t)
+	ciphertext, err := pri.PublicKey().(*hecdsa.PubKey).Encrypt([]byte("hello"), []byte("ad"))
+	assert.NoError(t, err)
+
+	tampered := append([]byte{}, ciphertext...)
+	tampered[len(tampered)-1] ^= 0x01
+
+	invalidPoint := append([]byte{}, ciphertext...)
+	invalidPoint[1] ^= 0x01
+
+	testCases := map[string]struct {
+		pri            *hecdsa.PriKey
+		ciphertext     []byte
+		additionalData []byte
+		expectedErr    error
+	}{
+		"wrong key":             {otherPri, ciphertext, []byte("ad"), nil},
+		"wrong additional data": {pri, ciphertext, []byte("other"), nil},
+		"tampered ciphertext":   {pri, tampered, []byte("ad"), nil},
+		"invalid point":         {pri, invalidPoint, []byte("ad"), hecdsa.ErrInvalidECIESCiphertext},
+		"short ciphertext":      {pri, ciphertext[:10], []byte("ad"), hecdsa.ErrInvalidECIESCiphertext},
+	}
+
+	for testName, test := range testCases {
+		t.Logf("running test case [%s]", testName)
+
+		// when
+		plaintext, err := test.pri.Decrypt(test.ciphertext, test.additionalData)
+
+		// then
+		assert.Error(t, err)
+		assert.Nil(t, plaintext)
+		if test.expectedErr != nil {
+			assert.Equal(t, test.expectedErr, err)
+		}
+	}
+}
+
+func TestPriKey_Decrypt_ClearedKey(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	ciphertext, err := pri.PublicKey().(*hecdsa.PubKey).Encrypt([]byte("hello"), nil)
+	assert.NoError(t, err)
+	pri.Clear()
+
+	// when
+	plaintext, err := pri.Decrypt(ciphertext, nil)
+
+	// then
+	assert.Equal(t, hecdsa.ErrECIESKeyCleared, err)
+	assert.Nil(t, plaintext)
+}
@@ -17,11 +17,12 @@
 package hecdsa
 
 import (
+	"crypto"
 	"crypto/ecdsa"
+	"io"
 
 	"crypto/rand"
 
-	"crypto/sha256"
 	"errors"
 	"math/big"
 
@@ -30,22 +31,36 @@ import (
 	"crypto/elliptic"
 
 	"github.com/DE-labtory/heimdall"
-	"github.com/btcsuite/btcutil/base58"
+	"github.com/DE-labtory/heimdall/event"
 )
 
 var ErrKeyType = errors.New("invalid key type - key type should be heimdall.PRIVATEKEY or heimdall.PUBLICKEY")
+var ErrNotECDSAPubKey = errors.New("invalid public key - key is not ECDSA public key")
 
 func GenerateKey(keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
-	pri, err := ecdsa.GenerateKey(keyGenOpt.(*KeyGenOpt).Curve, rand.Reader)
+	opt, err := ToKeyGenOpt(keyGenOpt)
 	if err != nil {
 		return nil, err
 	}
 
-	return &PriKey{pri}, nil
+	if err := heimdall.CheckKeyAlgorithm(opt); err != nil {
+		return nil, err
+	}
+
+	pri, err := ecdsa.GenerateKey(opt.Curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &PriKey{internalPriKey: pri}
+	event.Publish(event.KeyCreated, key.ID(), "")
+
+	return key, nil
 }
 
 // PriKey is an implementation of heimdall PriKey for using ECDSA private key
 type PriKey struct {
+	heimdall.KeyUsage
 	internalPriKey *ecdsa.PrivateKey
 }
 
@@ -54,21 +69,25 @@ func NewPriKey(internalPriKey *ecdsa.PrivateKey) heimdall.PriKey {
 }
 
 func (priKey *PriKey) ID() heimdall.KeyID {
-	pubKey := PubKey{&priKey.internalPriKey.PublicKey}
+	pubKey := PubKey{internalPubKey: &priKey.internalPriKey.PublicKey}
 	return pubKey.ID()
 }
 
 func (priKey *PriKey) SKI() []byte {
-	pubKey := PubKey{&priKey.internalPriKey.PublicKey}
+	pubKey := PubKey{internalPubKey: &priKey.internalPriKey.PublicKey}
 	return pubKey.SKI()
 }
 
 func (priKey *PriKey) ToByte() ([]byte, error) {
+	if !isX509Curve(priKey.internalPriKey.Curve) {
+		return marshalECPrivateKey(priKey.internalPriKey)
+	}
+
 	return x509.MarshalECPrivateKey(priKey.internalPriKey)
 }
 
 func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
-	pubKey := PubKey{&priKey.internalPriKey.PublicKey}
+	pubKey := PubKey{internalPubKey: &priKey.internalPriKey.PublicKey}
 	keyGenOpt, _ := NewKeyGenOpt(pubKey.internalPubKey.Curve.Params().Name)
 
 	return keyGenOpt
@@ -79,7 +98,7 @@ func (priKey *PriKey) IsPrivate() bool {
 }
 
 func (priKey *PriKey) PublicKey() heimdall.PubKey {
-	return &PubKey{&priKey.internalPriKey.PublicKey}
+	return &PubKey{KeyUsage: priKey.KeyUsage, internalPubKey: &priKey.internalPriKey.PublicKey}
 }
 
 func (priKey *PriKey) Clear() {
@@ -87,8 +106,23 @@ func (priKey *PriKey) Clear() {
 	priKey.internalPriKey.D.Set(big.NewInt(0))
 }
 
+// Public implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Public() crypto.PublicKey {
+	return &priKey.internalPriKey.PublicKey
+}
+
+// Sign implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if err := heimdall.CheckKeyUsage(priKey, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	return priKey.internalPriKey.Sign(rand, digest, opts)
+}
+
 // PubKey is an implementation of heimdall PubKey for using ECDSA public key
 type PubKey struct {
+	heimdall.KeyUsage
 	internalPubKey *ecdsa.PublicKey
 }
 
@@ -97,26 +131,30 @@ func NewPubKey(internalPubKey *ecdsa.PublicKey) heimdall.PubKey {
 }
 
 func (pubKey *PubKey) ID() heimdall.KeyID {
-	// return base58 encoded ski with key id prefix
-	return heimdall.KeyIDPrefix + base58.Encode(pubKey.SKI())
+	// return base58 encoded ski with algorithm prefix (ex. ECP384x...)
+	params := pubKey.internalPubKey.Curve.Params()
+	keyId, err := heimdall.MakeKeyID(&heimdall.KeyType{Family: heimdall.ECDSA, Curve: params.Name, BitLen: params.BitSize}, pubKey.SKI())
+	if err != nil {
+		return heimdall.SKIToKeyID(pubKey.SKI())
+	}
+
+	return keyId
 }
 
 func (pubKey *PubKey) SKI() []byte {
-	// get keyBytes from key
-	keyBitString := elliptic.Marshal(pubKey.internalPubKey.Curve, pubKey.internalPubKey.X, pubKey.internalPubKey.Y)
-
-	// get ski from keyBytes
-	hash := sha256.New()
-	hash.Write(keyBitString)
-	hashValue := hash.Sum(nil)
-
-	ski := make([]byte, 20)
-	ski = hashValue[:20]
+	return heimdall.MakeSKI(pubKey.SKIMaterial())
+}
 
-	return ski
+// SKIMaterial returns uncompressed point of public key, which SKI is hashed from.
+func (pubKey *PubKey) SKIMaterial() []byte {
+	return elliptic.Marshal(pubKey.internalPubKey.Curve, pubKey.internalPubKey.X, pubKey.internalPubKey.Y)
 }
 
 func (pubKey *PubKey) ToByte() ([]byte, error) {
+	if !isX509Curve(pubKey.internalPubKey.Curve) {
+		return marshalPKIXPublicKey(pubKey.internalPubKey)
+	}
+
 	return x509.MarshalPKIXPublicKey(pubKey.internalPubKey)
 }
 
@@ -129,6 +167,11 @@ func (pubKey *PubKey) IsPrivate() bool {
 	return false
 }
 
+// init registers recoverer of ECDSA keys, so that key files of them are loaded without choosing a recoverer.
+func init() {
+	heimdall.RegisterKeyRecoverer(heimdall.ECDSA, &KeyRecoverer{})
+}
+
 type KeyRecoverer struct {
 }
 
@@ -137,7 +180,11 @@ func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate boo
 	case true:
 		internalPriKey, err := x509.ParseECPrivateKey(keyBytes)
 		if err != nil {
-			return nil, err
+			// key on registered curve which x509 does not know
+			var customErr error
+			if internalPriKey, customErr = parseECPrivateKey(keyBytes); customErr != nil {
+				return nil, err
+			}
 		}
 
 		pri := NewPriKey(internalPriKey)
@@ -147,10 +194,21 @@ func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate boo
 	case false:
 		internalPubKey, err := x509.ParsePKIXPublicKey(keyBytes)
 		if err != nil {
-			return nil, err
+			// key on registered curve which x509 does not know
+			customPubKey, customErr := parsePKIXPublicKey(keyBytes)
+			if customErr != nil {
+				return nil, err
+			}
+
+			internalPubKey = customPubKey
+		}
+
+		ecdsaPubKey, ok := internalPubKey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, ErrNotECDSAPubKey
 		}
 
-		pub := NewPubKey(internalPubKey.(*ecdsa.PublicKey))
+		pub := NewPubKey(ecdsaPubKey)
 
 		return pub, nil
 
@@ -17,9 +17,11 @@
 package hecdsa_test
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"crypto/sha256"
 	"testing"
 
 	"github.com/DE-labtory/heimdall"
@@ -234,3 +236,16 @@ func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
 	assert.Equal(t, pri, priKey)
 	assert.Equal(t, pub, pubKey)
 }
+
+func TestPriKey_Sign(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	digest := sha256.Sum256([]byte("hello"))
+
+	// when
+	signature, err := pri.Sign(rand.Reader, digest[:], crypto.SHA256)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, ecdsa.VerifyASN1(pri.Public().(*ecdsa.PublicKey), digest[:], signature))
+}
@@ -21,8 +21,9 @@ package hecdsa
 
 import (
 	"crypto/elliptic"
-
 	"errors"
+
+	"github.com/DE-labtory/heimdall"
 )
 
 var ErrCurveNotSupported = errors.New("curve not supported")
@@ -32,6 +33,13 @@ const (
 	ECP256 = "P-256"
 	ECP384 = "P-384"
 	ECP521 = "P-521"
+
+	// ECSECP256K1 is curve of Bitcoin and Ethereum keys, which is not a NIST curve. (SEC 2)
+	ECSECP256K1 = "secp256k1"
+
+	// ECBP256R1 and ECBP384R1 are Brainpool curves, which are required by some European regulations. (RFC 5639)
+	ECBP256R1 = "brainpoolP256r1"
+	ECBP384R1 = "brainpoolP384r1"
 )
 
 type KeyGenOpt struct {
@@ -44,19 +52,13 @@ func NewKeyGenOpt(strCurve string) (*KeyGenOpt, error) {
 }
 
 func (opt *KeyGenOpt) initKeyGenOpt(strCurve string) error {
-	switch strCurve {
-	case "P-224":
-		opt.Curve = elliptic.P224()
-	case "P-256":
-		opt.Curve = elliptic.P256()
-	case "P-384":
-		opt.Curve = elliptic.P384()
-	case "P-521":
-		opt.Curve = elliptic.P521()
-	default:
+	registered, ok := curveByName(strCurve)
+	if !ok {
 		return ErrCurveNotSupported
 	}
 
+	opt.Curve = registered.curve
+
 	return nil
 }
 
@@ -67,3 +69,24 @@ func (opt *KeyGenOpt) ToString() string {
 func (opt *KeyGenOpt) KeySize() int {
 	return opt.Curve.Params().BitSize
 }
+
+func (opt *KeyGenOpt) Algorithm() string {
+	return heimdall.ECDSA
+}
+
+func (opt *KeyGenOpt) Bits() int {
+	return opt.Curve.Params().BitSize
+}
+
+// ToKeyGenOpt converts ECDSA key generation option of any implementation (ex. heimdall.KeyType) to KeyGenOpt.
+func ToKeyGenOpt(opts heimdall.KeyGenOpts) (*KeyGenOpt, error) {
+	if opt, ok := opts.(*KeyGenOpt); ok {
+		return opt, nil
+	}
+
+	if opts == nil || opts.Algorithm() != heimdall.ECDSA {
+		return nil, ErrCurveNotSupported
+	}
+
+	return NewKeyGenOpt(opts.ToString())
+}
@@ -16,21 +16,24 @@
  */
 
 // This file provides functions for storing and loading ECDSA key pair.
+// Key files are kept in heimdall.Storage, whose default is directory of file system. (see ecdsa_storage.go)
 
 package hecdsa
 
 import (
+	"bytes"
+	"crypto/hmac"
 	"crypto/rand"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"strings"
+	"sync"
 
 	"github.com/DE-labtory/heimdall"
 	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hashing"
 	"github.com/DE-labtory/heimdall/kdf"
 	"github.com/DE-labtory/iLogger"
 )
@@ -38,46 +41,132 @@ import (
 var ErrWrongKeyID = errors.New("wrong key id - failed to find key using key ID")
 var ErrEmptyKeyPath = errors.New("invalid keyPath - keyPath empty")
 var ErrMultiplePriKey = errors.New("private key in directory should be one")
+var ErrInvalidKeyFile = errors.New("invalid key file - encryption hints not exist")
+var ErrInvalidKeyFileMAC = errors.New("invalid key file mac - password is wrong or key file is tampered")
+var ErrKeyFileMACMissing = errors.New("key file mac missing - key file of version 1 or later should have mac")
+var ErrKeyUsageNotSupported = errors.New("key usage not supported - key type cannot hold usage policy in key file")
+var ErrUnsupportedKeyFileVersion = errors.New("unsupported key file version - key file is stored by newer version of heimdall")
+var ErrKeyAlreadyExists = errors.New("key already exist - key file of the key ID is stored, store with overwrite to replace it")
+var ErrKeyFileSKIMismatch = errors.New("key file SKI mismatch - SKI of key file is not SKI of the key by recorded SKI hash")
+var ErrUnversionedKeyFile = errors.New("unversioned key file - strict key files require version and mac, migrate the key file first")
+var ErrUnauthenticatedUsagePolicy = errors.New("unauthenticated usage policy - key file without header mac cannot hold usage policy")
+
+// keyFileMACInfo binds MAC key to key file MAC, so that it differs from encryption key derived from the same password.
+const keyFileMACInfo = "heimdall key file mac"
+
+// KeyFileVersion is version of key file format written by this package. Key files without version are stored before
+// key file format was versioned, and are upgraded by keystore.Migrate or LoadPriKeyWithUpgrade.
+// MAC of version 1 covers encrypted key only, and MAC of version 2 also covers header fields including metadata.
+const KeyFileVersion = 2
+
+// keyFileHeaderMACVersion is the first key file version whose MAC covers header fields.
+const keyFileHeaderMACVersion = 2
+
+var strictKeyFiles = false
+var strictKeyFilesMutex = &sync.RWMutex{}
+
+// SetStrictKeyFiles sets whether key files without version or MAC are rejected at loading in the process.
+// Key files without version are loaded by default so that they can be upgraded, which also loads a key file whose
+// version and MAC are stripped. Once key files are migrated (see keystore.Migrate), strict key files should be set,
+// so that stripping them does not bypass the key file MAC.
+func SetStrictKeyFiles(strict bool) {
+	strictKeyFilesMutex.Lock()
+	defer strictKeyFilesMutex.Unlock()
+
+	strictKeyFiles = strict
+}
+
+// StrictKeyFiles checks if key files without version or MAC are rejected at loading in the process.
+func StrictKeyFiles() bool {
+	strictKeyFilesMutex.RLock()
+	defer strictKeyFilesMutex.RUnlock()
+
+	return strictKeyFiles
+}
 
 // struct for encrypted key's file format.
 type KeyFile struct {
+	Version      int `json:",omitempty"`
 	SKI          []byte
+	SKIHash      string `json:",omitempty"`
+	KeyGenOpt    string `json:",omitempty"`
 	EncryptedKey string
 	Hints        *EncryptionHints
+	Metadata     *KeyMetadata `json:",omitempty"`
 }
 
 // struct for providing hints of encryption and key derivation function.
+// MAC is HMAC-SHA384 of header fields and encrypted key (encrypt-then-MAC), which is not in key files without version.
 type EncryptionHints struct {
 	EncOpt  *encryption.Opts
 	KDFOpt  *kdf.Opts
 	KDFSalt []byte
+	MAC     []byte `json:",omitempty"`
 }
 
 func StorePriKeyWithoutPwd(key heimdall.PriKey, keyDirPath string) error {
-	keyId := key.ID()
-
-	keyFilePath, err := makeKeyFilePath(keyId, keyDirPath)
+	keyBytes, err := key.ToByte()
 	if err != nil {
 		return err
 	}
 
-	files, err := ioutil.ReadDir(keyDirPath)
-	if err != nil {
+	storage := NewFileStorage(keyDirPath)
+	if err := checkKeyNotExist(storage, key.ID()); err != nil {
 		return err
-	} else if len(files) > 0 {
-		for _, file := range files {
-			os.Remove(filepath.Join(keyDirPath, file.Name()))
+	}
+
+	return putPriKeyFile(storage, key.ID(), keyBytes)
+}
+
+// StorePriKey stores private key with password. It returns ErrKeyAlreadyExists if key file of the key ID is stored already.
+// Key file of other key is replaced, since private key directory holds only one key.
+func StorePriKey(key heimdall.PriKey, pwd, keyDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) error {
+	return storePriKey(NewFileStorage(keyDirPath), key, pwd, encOpt, kdfOpt, false)
+}
+
+// StorePriKeyWithOverwrite stores private key with password like StorePriKey, replacing key file of the key ID if it is stored already.
+func StorePriKeyWithOverwrite(key heimdall.PriKey, pwd, keyDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) error {
+	return storePriKey(NewFileStorage(keyDirPath), key, pwd, encOpt, kdfOpt, true)
+}
+
+// storePriKey encrypts private key with password, and stores it as the only private key in storage.
+// Key file of the same key ID is replaced only if overwrite is set.
+func storePriKey(storage heimdall.Storage, key heimdall.PriKey, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts, overwrite bool) error {
+	if err := kdf.CheckPasswordKDF(kdfOpt); err != nil {
+		return err
+	}
+
+	if !overwrite {
+		if err := checkKeyNotExist(storage, key.ID()); err != nil {
+			return err
 		}
 	}
 
-	keyBytes, err := key.ToByte()
+	jsonKeyFile, err := EncryptKeyFile(key, pwd, encOpt, kdfOpt, newKeyMetadata())
 	if err != nil {
 		return err
 	}
 
-	if _, err := os.Stat(keyFilePath); os.IsNotExist(err) {
-		err = ioutil.WriteFile(keyFilePath, keyBytes, 0700)
-		if err != nil {
+	if err = putPriKeyFile(storage, key.ID(), jsonKeyFile); err != nil {
+		return err
+	}
+
+	event.Publish(event.KeyStored, key.ID(), storageLocation(storage, key.ID()))
+
+	return nil
+}
+
+// checkKeyNotExist returns ErrKeyAlreadyExists if storage has key file of key ID.
+func checkKeyNotExist(storage heimdall.Storage, keyId heimdall.KeyID) error {
+	names, err := keyFileNames(storage, keyId)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		if _, err := storage.Get(name); err == nil {
+			return ErrKeyAlreadyExists
+		} else if err != heimdall.ErrKeyNotFound {
 			return err
 		}
 	}
@@ -85,190 +174,363 @@ func StorePriKeyWithoutPwd(key heimdall.PriKey, keyDirPath string) error {
 	return nil
 }
 
-// StorePriKey stores private key with password.
-func StorePriKey(key heimdall.PriKey, pwd, keyDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) error {
-	ski := key.SKI()
-	keyId := key.ID()
+// keyFileNames returns names which key file of key ID may be stored under in storage. Storage is listed only for
+// legacy key ID, whose key file may be stored under algorithm prefixed key ID of the same SKI. (see heimdall.KeyIDFileNamesIn)
+func keyFileNames(storage heimdall.Storage, keyId heimdall.KeyID) ([]string, error) {
+	if info, err := heimdall.ParseKeyID(keyId); err != nil || !info.IsLegacy() {
+		return heimdall.KeyIDFileNames(keyId), nil
+	}
 
-	keyFilePath, err := makeKeyFilePath(keyId, keyDirPath)
+	listed, err := storage.List()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	files, err := ioutil.ReadDir(keyDirPath)
+	return heimdall.KeyIDFileNamesIn(keyId, listed), nil
+}
+
+// putPriKeyFile puts private key file in storage, removing existing one since private key storage holds only one key.
+// New key file is put before existing ones are removed, so a failed put leaves the existing key in storage.
+func putPriKeyFile(storage heimdall.Storage, keyId heimdall.KeyID, keyFile []byte) error {
+	names, err := storage.List()
 	if err != nil {
 		return err
-	} else if len(files) > 0 {
-		iLogger.Info(nil, "[Heimdall] private key already exist - will be overwritten")
+	}
 
-		for _, file := range files {
-			os.Remove(filepath.Join(keyDirPath, file.Name()))
+	if err := storage.Put(keyId, keyFile); err != nil {
+		return err
+	}
+
+	if len(names) > 0 {
+		iLogger.Info(nil, "[Heimdall] private key already exist - overwritten")
+	}
+
+	for _, name := range names {
+		if name != keyId {
+			storage.Delete(name)
 		}
 	}
 
+	return nil
+}
+
+// EncryptKeyFile encrypts private key with key derived from password, and makes json formatted KeyFile of current version
+// with metadata, which can be nil. Key derivation functions without work factor such as kdf.HKDF are rejected.
+func EncryptKeyFile(key heimdall.PriKey, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts, metadata *KeyMetadata) ([]byte, error) {
+	if err := kdf.CheckPasswordKDF(kdfOpt); err != nil {
+		return nil, err
+	}
+
+	return encryptKeyFile(key, pwd, encOpt, kdfOpt, metadata)
+}
+
+// encryptKeyFile makes json formatted KeyFile like EncryptKeyFile, without checking key derivation function.
+func encryptKeyFile(key heimdall.PriKey, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts, metadata *KeyMetadata) ([]byte, error) {
 	salt := make([]byte, 8)
-	_, err = rand.Read(salt)
+	_, err := rand.Read(salt)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	dKey, err := kdf.DeriveKey([]byte(pwd), salt, encOpt.KeyLen, kdfOpt)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	encryptedKeyBytes, err := encryption.EncryptKey(key, dKey, encOpt)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	encHints := makeEncryptionHints(encOpt, kdfOpt, salt)
-
-	jsonKeyFile, err := makeJsonKeyFile(encHints, ski, encryptedKeyBytes)
+	keyFile := makeKeyFile(makeEncryptionHints(encOpt, kdfOpt, salt, nil), key.SKI(), keyGenOptString(key), encryptedKeyBytes, metadata)
+	keyFile.Hints.MAC, err = keyFileMAC(dKey, keyFile, encryptedKeyBytes)
 	if err != nil {
-		return err
-	}
-
-	if _, err := os.Stat(keyFilePath); os.IsNotExist(err) {
-		err = ioutil.WriteFile(keyFilePath, jsonKeyFile, 0700)
-		if err != nil {
-			return err
-		}
+		return nil, err
 	}
 
-	return nil
+	return json.Marshal(keyFile)
 }
 
-// StorePubKey stores public key.
+// StorePubKey stores public key. It returns ErrKeyAlreadyExists if key file of the key ID is stored already.
 func StorePubKey(key heimdall.PubKey, keyDirPath string) error {
-	keyId := key.ID()
+	return storePubKey(NewFileStorage(keyDirPath), key, false)
+}
 
-	keyFilePath, err := makeKeyFilePath(keyId, keyDirPath)
-	if err != nil {
-		return err
-	}
+// StorePubKeyWithOverwrite stores public key like StorePubKey, replacing key file of the key ID if it is stored already.
+func StorePubKeyWithOverwrite(key heimdall.PubKey, keyDirPath string) error {
+	return storePubKey(NewFileStorage(keyDirPath), key, true)
+}
+
+// storePubKey stores public key in storage. Key file of the same key ID is replaced only if overwrite is set.
+func storePubKey(storage heimdall.Storage, key heimdall.PubKey, overwrite bool) error {
+	keyId := key.ID()
 
 	keyBytes, err := key.ToByte()
 	if err != nil {
 		return err
 	}
 
-	if _, err := os.Stat(keyFilePath); os.IsNotExist(err) {
-		err = ioutil.WriteFile(keyFilePath, keyBytes, 0700)
-		if err != nil {
+	if !overwrite {
+		if err := checkKeyNotExist(storage, keyId); err != nil {
 			return err
 		}
 	}
 
-	return nil
-}
-
-// makeKeyFilePath makes key file path (absolute) of the key file.
-func makeKeyFilePath(keyFileName string, keyDirPath string) (string, error) {
-	if _, err := os.Stat(keyDirPath); os.IsNotExist(err) {
-		err = os.MkdirAll(keyDirPath, 0755)
-		if err != nil {
-			iLogger.Errorf(nil, "[Heimdall] %s", err)
-			return "", err
-		}
+	if err = storage.Put(keyId, keyBytes); err != nil {
+		return err
 	}
 
-	return filepath.Join(keyDirPath, keyFileName), nil
+	event.Publish(event.KeyStored, keyId, storageLocation(storage, keyId))
+
+	return nil
 }
 
 // makeEncryptionHints makes encryption hints for decryption later.
-func makeEncryptionHints(encOpt *encryption.Opts, kdfOpt *kdf.Opts, kdfSalt []byte) *EncryptionHints {
+func makeEncryptionHints(encOpt *encryption.Opts, kdfOpt *kdf.Opts, kdfSalt []byte, mac []byte) *EncryptionHints {
 	return &EncryptionHints{
 		EncOpt:  encOpt,
 		KDFOpt:  kdfOpt,
 		KDFSalt: kdfSalt,
+		MAC:     mac,
 	}
 }
 
-// makeJsonKeyFile marshals keyFile struct to json format.
-func makeJsonKeyFile(encHints *EncryptionHints, ski []byte, encryptedKeyBytes []byte) ([]byte, error) {
-	keyFile := KeyFile{
-		SKI:          ski,
-		EncryptedKey: hex.EncodeToString(encryptedKeyBytes),
-		Hints:        encHints,
+// keyFileHeader is the fields of key file covered by MAC of key file version 2, in addition to encrypted key.
+type keyFileHeader struct {
+	Version   int
+	SKI       []byte
+	SKIHash   string
+	KeyGenOpt string
+	EncOpt    *encryption.Opts
+	KDFOpt    *kdf.Opts
+	KDFSalt   []byte
+	Metadata  *KeyMetadata
+}
+
+// keyFileMAC computes HMAC of key file, with MAC key derived from the encryption key by HKDF.
+// Key files of version 2 or later are authenticated with their header fields, and older ones with encrypted key only.
+func keyFileMAC(dKey []byte, keyFile *KeyFile, encryptedKeyBytes []byte) ([]byte, error) {
+	data := encryptedKeyBytes
+	if keyFile.Version >= keyFileHeaderMACVersion {
+		header, err := json.Marshal(keyFileHeader{
+			Version:   keyFile.Version,
+			SKI:       keyFile.SKI,
+			SKIHash:   keyFile.SKIHash,
+			KeyGenOpt: keyFile.KeyGenOpt,
+			EncOpt:    keyFile.Hints.EncOpt,
+			KDFOpt:    keyFile.Hints.KDFOpt,
+			KDFSalt:   keyFile.Hints.KDFSalt,
+			Metadata:  keyFile.Metadata,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		// length of header separates it from encrypted key
+		data = make([]byte, 8, 8+len(header)+len(encryptedKeyBytes))
+		binary.BigEndian.PutUint64(data, uint64(len(header)))
+		data = append(append(data, header...), encryptedKeyBytes...)
 	}
 
-	return json.Marshal(keyFile)
+	return keyFileMACOf(dKey, keyFile.Hints.KDFSalt, data)
 }
 
-func LoadPriKeyWithoutPwd(keyDirPath string) (heimdall.PriKey, error) {
-	if _, err := os.Stat(keyDirPath); os.IsNotExist(err) {
-		iLogger.Error(nil, "[Heimdall] Key dir not exist")
+// keyFileMACOf computes HMAC-SHA384 of data with MAC key derived from the encryption key by HKDF.
+func keyFileMACOf(dKey, kdfSalt, data []byte) ([]byte, error) {
+	macKdfOpt, err := kdf.NewOpts(kdf.HKDF, map[string]string{"hashOpt": hashing.SHA384, "info": keyFileMACInfo})
+	if err != nil {
 		return nil, err
 	}
 
-	files, err := ioutil.ReadDir(keyDirPath)
+	macKey, err := kdf.DeriveKey(dKey, kdfSalt, 384, macKdfOpt)
 	if err != nil {
-		iLogger.Error(nil, "[Heimdall] Error during read key dir")
 		return nil, err
-	} else if len(files) > 1 {
-		iLogger.Error(nil, "[Heimdall] Multiple private key file detected")
-		return nil, ErrMultiplePriKey
-	} else if len(files) < 1 {
-		iLogger.Error(nil, "[Heimdall] Empty key path")
-		return nil, ErrEmptyKeyPath
 	}
 
-	keyPath := filepath.Join(keyDirPath, files[0].Name())
-	keyBytes, err := loadKeyFile(keyPath)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
 	if err != nil {
-		iLogger.Error(nil, "[Heimdall] Error during load key file")
 		return nil, err
 	}
 
-	recoverer := &KeyRecoverer{}
-	key, err := recoverer.RecoverKeyFromByte(keyBytes, true)
+	return hashing.HMAC(data, macKey, hashOpt)
+}
+
+// makeKeyFile makes KeyFile of current version.
+func makeKeyFile(encHints *EncryptionHints, ski []byte, keyGenOpt string, encryptedKeyBytes []byte, metadata *KeyMetadata) *KeyFile {
+	return &KeyFile{
+		Version:      KeyFileVersion,
+		SKI:          ski,
+		SKIHash:      keyFileSKIHash(),
+		KeyGenOpt:    keyGenOpt,
+		EncryptedKey: hex.EncodeToString(encryptedKeyBytes),
+		Hints:        encHints,
+		Metadata:     metadata,
+	}
+}
+
+// keyFileSKIHash returns SKI hash of the process to be recorded in key file, which is omitted for default SKI hash.
+func keyFileSKIHash() string {
+	if skiHash := heimdall.CurrentSKIHash(); skiHash != heimdall.DefaultSKIHash {
+		return skiHash
+	}
+
+	return ""
+}
+
+func LoadPriKeyWithoutPwd(keyDirPath string) (heimdall.PriKey, error) {
+	storage := NewFileStorage(keyDirPath)
+
+	name, keyBytes, err := getPriKeyFile(storage)
+	if err != nil {
+		iLogger.Errorf(nil, "[Heimdall] Error during load key file - %s", err)
+		return nil, err
+	}
+
+	key, err := recoverKey(keyBytes, true, "")
 	if err != nil {
 		iLogger.Error(nil, "error during recover key")
 		return nil, err
 	}
+	event.Publish(event.KeyLoaded, key.ID(), storageLocation(storage, name))
 
 	return key.(heimdall.PriKey), nil
 }
 
-// LoadPriKey loads private key with password.
+// LoadPriKey loads private key with password. The key is recovered by recoverer registered for algorithm of the key file.
 func LoadPriKey(keyDirPath, pwd string) (heimdall.PriKey, error) {
-	var keyFile KeyFile
+	return loadPriKey(NewFileStorage(keyDirPath), pwd, nil)
+}
 
-	if _, err := os.Stat(keyDirPath); os.IsNotExist(err) {
+// LoadPriKeyWithRecoverer loads private key like LoadPriKey, overriding registered recoverer by recoverer.
+func LoadPriKeyWithRecoverer(keyDirPath, pwd string, recoverer heimdall.KeyRecoverer) (heimdall.PriKey, error) {
+	return loadPriKey(NewFileStorage(keyDirPath), pwd, recoverer)
+}
+
+// loadPriKey loads the only private key in storage with password, by recoverer if it is not nil.
+func loadPriKey(storage heimdall.Storage, pwd string, recoverer heimdall.KeyRecoverer) (heimdall.PriKey, error) {
+	name, jsonKeyFile, err := getPriKeyFile(storage)
+	if err != nil {
 		return nil, err
 	}
 
-	files, err := ioutil.ReadDir(keyDirPath)
+	pri, err := DecryptKeyFileWithRecoverer(jsonKeyFile, pwd, recoverer)
 	if err != nil {
 		return nil, err
-	} else if len(files) > 1 {
-		return nil, ErrMultiplePriKey
-	} else if len(files) < 1 {
-		return nil, ErrEmptyKeyPath
 	}
+	event.Publish(event.KeyLoaded, pri.ID(), storageLocation(storage, name))
+
+	return pri, nil
+}
+
+// LoadPriKeyWithUpgrade loads private key like LoadPriKey. If encryption or key derivation parameters of the key file
+// are weaker than encOpt or kdfOpt, or the key file has no MAC or older version, the key file is re-encrypted with them, so key files
+// stored with old parameters are migrated in place. The key file is replaced by rename, so it is never lost in the middle of upgrade.
+// Failure of upgrade does not fail loading the key, and the key file is upgraded at the next loading.
+func LoadPriKeyWithUpgrade(keyDirPath, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) (pri heimdall.PriKey, upgraded bool, err error) {
+	return loadPriKeyWithUpgrade(NewFileStorage(keyDirPath), pwd, encOpt, kdfOpt)
+}
 
-	keyPath := filepath.Join(keyDirPath, files[0].Name())
-	jsonKeyFile, err := loadKeyFile(keyPath)
+// loadPriKeyWithUpgrade loads the only private key in storage, and re-encrypts its key file if its parameters are weak.
+func loadPriKeyWithUpgrade(storage heimdall.Storage, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) (pri heimdall.PriKey, upgraded bool, err error) {
+	name, jsonKeyFile, err := getPriKeyFile(storage)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
 
-	if err = json.Unmarshal(jsonKeyFile, &keyFile); err != nil {
-		return nil, err
+	pri, err = DecryptKeyFile(jsonKeyFile, pwd)
+	if err != nil {
+		return nil, false, err
 	}
+	location := storageLocation(storage, name)
+	event.Publish(event.KeyLoaded, pri.ID(), location)
 
-	kdfOpt, err := kdf.NewOpts(keyFile.Hints.KDFOpt.KdfName, keyFile.Hints.KDFOpt.KdfParams)
+	var keyFile KeyFile
+	if err := json.Unmarshal(jsonKeyFile, &keyFile); err != nil {
+		return nil, false, err
+	}
+
+	if keyFile.Version == KeyFileVersion && len(keyFile.Hints.MAC) != 0 &&
+		!keyFile.Hints.EncOpt.WeakerThan(encOpt) && !keyFile.Hints.KDFOpt.WeakerThan(kdfOpt) {
+		return pri, false, nil
+	}
+
+	if err := upgradeKeyFile(storage, name, pri, pwd, encOpt, kdfOpt, keyFile.Metadata); err != nil {
+		iLogger.Errorf(nil, "[Heimdall] failed to upgrade key file parameters - %s", err)
+		return pri, false, nil
+	}
+	event.Publish(event.KeyUpgraded, pri.ID(), location)
+
+	return pri, true, nil
+}
+
+// upgradeKeyFile re-encrypts key file with new parameters keeping its metadata, and replaces the key file of name in storage.
+func upgradeKeyFile(storage heimdall.Storage, name string, pri heimdall.PriKey, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts, metadata *KeyMetadata) error {
+	jsonKeyFile, err := EncryptKeyFile(pri, pwd, encOpt, kdfOpt, metadata)
 	if err != nil {
+		return err
+	}
+
+	return storage.Put(name, jsonKeyFile)
+}
+
+// getPriKeyFile returns name and content of private key file in storage, which should hold only one key file.
+func getPriKeyFile(storage heimdall.Storage) (name string, keyFile []byte, err error) {
+	names, err := storage.List()
+	if err != nil {
+		return "", nil, err
+	} else if len(names) > 1 {
+		return "", nil, ErrMultiplePriKey
+	} else if len(names) < 1 {
+		return "", nil, ErrEmptyKeyPath
+	}
+
+	keyFile, err = storage.Get(names[0])
+	if err != nil {
+		return "", nil, err
+	}
+
+	return names[0], keyFile, nil
+}
+
+// DecryptKeyFile recovers private key from json formatted KeyFile with password in memory.
+// Usage policy in metadata of the key file is registered for the key.
+func DecryptKeyFile(jsonKeyFile []byte, pwd string) (heimdall.PriKey, error) {
+	return DecryptKeyFileWithRecoverer(jsonKeyFile, pwd, nil)
+}
+
+// DecryptKeyFileWithRecoverer recovers private key like DecryptKeyFile by recoverer,
+// or by recoverer registered for algorithm of the key file if recoverer is nil.
+func DecryptKeyFileWithRecoverer(jsonKeyFile []byte, pwd string, recoverer heimdall.KeyRecoverer) (heimdall.PriKey, error) {
+	var keyFile KeyFile
+
+	if err := json.Unmarshal(jsonKeyFile, &keyFile); err != nil {
 		return nil, err
 	}
 
-	encOpt, err := encryption.NewOpts(keyFile.Hints.EncOpt.Algorithm, keyFile.Hints.EncOpt.KeyLen, keyFile.Hints.EncOpt.OpMode)
+	if keyFile.Hints == nil || keyFile.Hints.KDFOpt == nil || keyFile.Hints.EncOpt == nil {
+		return nil, ErrInvalidKeyFile
+	}
+
+	if keyFile.Version > KeyFileVersion {
+		return nil, ErrUnsupportedKeyFileVersion
+	}
+
+	if (keyFile.Version == 0 || len(keyFile.Hints.MAC) == 0) && StrictKeyFiles() {
+		return nil, ErrUnversionedKeyFile
+	}
+
+	// usage policy of key file whose MAC does not cover header is written by anyone who can write the key file,
+	// so the key file is rejected rather than loaded with usage policy of the attacker
+	if keyFile.Version < keyFileHeaderMACVersion && keyFile.Metadata != nil && keyFile.Metadata.UsagePolicy != nil {
+		return nil, ErrUnauthenticatedUsagePolicy
+	}
+
+	encOpt, kdfOpt, err := hintsOpts(keyFile.Hints)
 	if err != nil {
 		return nil, err
 	}
 
-	dKey, err := kdf.DeriveKey([]byte(pwd), keyFile.Hints.KDFSalt, encOpt.KeyLen, kdfOpt)
+	dKey, err := kdf.DeriveKeyWithLimits([]byte(pwd), keyFile.Hints.KDFSalt, encOpt.KeyLen, kdfOpt)
 	if err != nil {
 		return nil, err
 	}
@@ -278,82 +540,286 @@ func LoadPriKey(keyDirPath, pwd string) (heimdall.PriKey, error) {
 		return nil, err
 	}
 
+	// key file without version is stored before encrypt-then-MAC and may have no MAC, and is upgraded by LoadPriKeyWithUpgrade
+	if len(keyFile.Hints.MAC) == 0 && keyFile.Version >= 1 {
+		return nil, ErrKeyFileMACMissing
+	}
+
+	if len(keyFile.Hints.MAC) != 0 {
+		mac, err := keyFileMAC(dKey, &keyFile, encryptedKeyBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		if !hmac.Equal(mac, keyFile.Hints.MAC) {
+			return nil, ErrInvalidKeyFileMAC
+		}
+	}
+
 	keyBytes, err := encryption.DecryptKey(encryptedKeyBytes, dKey, encOpt)
 	if err != nil {
 		return nil, err
 	}
 
-	recoverer := &KeyRecoverer{}
-	key, err := recoverer.RecoverKeyFromByte(keyBytes, true)
+	key, err := recoverKeyWith(recoverer, keyBytes, true, keyFile.KeyGenOpt)
 	if err != nil {
 		return nil, err
 	}
 
+	// SKI of key file stored with other SKI hash is checked by the recorded SKI hash, not by SKI hash of the process
+	if keyFile.SKIHash != "" {
+		ski, err := heimdall.SKIWithHash(key, keyFile.SKIHash)
+		if err != nil || !bytes.Equal(ski, keyFile.SKI) {
+			key.(heimdall.PriKey).Clear()
+			return nil, ErrKeyFileSKIMismatch
+		}
+	}
+
+	// usage policy is attached only after the key file MAC covering it is checked. Key file without version or MAC
+	// is accepted without usage policy unless strict key files are set, so usage policy is enforced only on strict key files.
+	if keyFile.Metadata != nil && keyFile.Metadata.UsagePolicy != nil {
+		holder, ok := key.(heimdall.UsagePolicyHolder)
+		if !ok {
+			key.(heimdall.PriKey).Clear()
+			return nil, ErrKeyUsageNotSupported
+		}
+		holder.SetUsagePolicy(keyFile.Metadata.UsagePolicy)
+	}
+
 	return key.(heimdall.PriKey), nil
 }
 
-// LoadPubKey loads public key by key ID.
-func LoadPubKey(keyId heimdall.KeyID, keyDirPath string) (heimdall.PubKey, error) {
-	if _, err := os.Stat(keyDirPath); os.IsNotExist(err) {
-		return nil, err
+// hintsOpts returns encryption and key derivation options in encryption hints, checking them against algorithm policy.
+func hintsOpts(hints *EncryptionHints) (*encryption.Opts, *kdf.Opts, error) {
+	kdfOpt, err := kdf.NewOpts(hints.KDFOpt.KdfName, hints.KDFOpt.KdfParams)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	if err := heimdall.KeyIDPrefixCheck(keyId); err != nil {
-		return nil, err
+	encOpt, err := encryption.NewOpts(hints.EncOpt.Algorithm, hints.EncOpt.KeyLen, hints.EncOpt.OpMode)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := heimdall.CheckCipherAlgorithm(encOpt.Algorithm, encOpt.KeyLen); err != nil {
+		return nil, nil, err
+	}
+
+	if err := heimdall.CheckKDFAlgorithm(kdfOpt.KdfName); err != nil {
+		return nil, nil, err
+	}
+
+	return encOpt, kdfOpt, nil
+}
+
+// ChangePriKeyPassword re-encrypts private key of key ID in key directory with new password.
+// The key is encrypted with the same encryption and key derivation parameters, but with key derived by fresh salt.
+// The key file is replaced by rename, so it is never lost in the middle of change.
+func ChangePriKeyPassword(keyId heimdall.KeyID, oldPwd, newPwd, keyDirPath string) error {
+	return changePriKeyPassword(NewFileStorage(keyDirPath), keyId, oldPwd, newPwd)
+}
+
+func changePriKeyPassword(storage heimdall.Storage, keyId heimdall.KeyID, oldPwd, newPwd string) error {
+	if err := heimdall.ValidateKeyID(keyId); err != nil {
+		return err
 	}
 
-	keyPath, err := findKeyById(keyId, keyDirPath)
+	name, jsonKeyFile, err := getPriKeyFile(storage)
 	if err != nil {
-		return nil, err
+		return err
+	}
+
+	pri, err := DecryptKeyFile(jsonKeyFile, oldPwd)
+	if err != nil {
+		return err
+	}
+	defer pri.Clear()
+
+	if !heimdall.MatchKeyID(keyId, pri) {
+		return ErrWrongKeyID
+	}
+
+	var keyFile KeyFile
+	if err := json.Unmarshal(jsonKeyFile, &keyFile); err != nil {
+		return err
 	}
 
-	keyBytes, err := loadKeyFile(keyPath)
+	encOpt, kdfOpt, err := hintsOpts(keyFile.Hints)
 	if err != nil {
+		return err
+	}
+
+	if err := upgradeKeyFile(storage, name, pri, newPwd, encOpt, kdfOpt, keyFile.Metadata); err != nil {
+		return err
+	}
+	event.Publish(event.KeyPasswordChanged, pri.ID(), storageLocation(storage, name))
+
+	return nil
+}
+
+// LoadPubKey loads public key by key ID. The key is recovered by recoverer registered for algorithm of the key ID.
+func LoadPubKey(keyId heimdall.KeyID, keyDirPath string) (heimdall.PubKey, error) {
+	return loadPubKey(NewFileStorage(keyDirPath), keyId, nil)
+}
+
+// LoadPubKeyWithRecoverer loads public key like LoadPubKey, overriding registered recoverer by recoverer.
+func LoadPubKeyWithRecoverer(keyId heimdall.KeyID, keyDirPath string, recoverer heimdall.KeyRecoverer) (heimdall.PubKey, error) {
+	return loadPubKey(NewFileStorage(keyDirPath), keyId, recoverer)
+}
+
+// loadPubKey loads public key of key ID from storage, by recoverer if it is not nil.
+// Key file named by legacy key ID of the same SKI is also found, and key file of legacy key ID is also found under
+// algorithm prefixed key ID of the same SKI. (see heimdall.KeyIDFileNamesIn)
+func loadPubKey(storage heimdall.Storage, keyId heimdall.KeyID, recoverer heimdall.KeyRecoverer) (heimdall.PubKey, error) {
+	if err := heimdall.ValidateKeyID(keyId); err != nil {
 		return nil, err
 	}
 
-	recoverer := &KeyRecoverer{}
-	key, err := recoverer.RecoverKeyFromByte(keyBytes, false)
+	// public key files have algorithm only in key ID, and legacy key IDs have none
+	keyGenOpt := ""
+	if info, err := heimdall.ParseKeyID(keyId); err == nil && !info.IsLegacy() {
+		keyGenOpt = info.KeyType.ToString()
+	}
+
+	names, err := keyFileNames(storage, keyId)
 	if err != nil {
 		return nil, err
 	}
 
-	return key, nil
+	for _, name := range names {
+		keyBytes, err := storage.Get(name)
+		if err == heimdall.ErrKeyNotFound {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		// key file of legacy key ID found under algorithm prefixed key ID has algorithm in its name
+		nameKeyGenOpt := keyGenOpt
+		if info, err := heimdall.ParseKeyID(name); nameKeyGenOpt == "" && err == nil && !info.IsLegacy() {
+			nameKeyGenOpt = info.KeyType.ToString()
+		}
+
+		key, err := recoverKeyWith(recoverer, keyBytes, false, nameKeyGenOpt)
+		if err != nil {
+			return nil, err
+		}
+
+		return key.(heimdall.PubKey), nil
+	}
+
+	return nil, ErrWrongKeyID
+}
+
+// keyGenOptString returns backward compatible string of key generation option of the key. (ex. P-256, RSA2048)
+func keyGenOptString(key heimdall.Key) string {
+	keyGenOpt := key.KeyGenOpt()
+	if keyGenOpt == nil {
+		return ""
+	}
+
+	return keyGenOpt.ToString()
 }
 
-// findKeyById finds key file path by key id from file names in keystore path.
-func findKeyById(keyId string, keyDirPath string) (keyPath string, err error) {
-	keyPath = ""
+// recoverKey recovers key from key file, and rejects key whose algorithm is denied by algorithm policy.
+func recoverKey(keyBytes []byte, isPrivate bool, keyGenOpt string) (heimdall.Key, error) {
+	return recoverKeyWith(nil, keyBytes, isPrivate, keyGenOpt)
+}
 
-	files, err := ioutil.ReadDir(keyDirPath)
+// recoverKeyWith recovers key like recoverKey by recoverer, or by registered recoverer if recoverer is nil.
+func recoverKeyWith(recoverer heimdall.KeyRecoverer, keyBytes []byte, isPrivate bool, keyGenOpt string) (heimdall.Key, error) {
+	var key heimdall.Key
+	var err error
+	if recoverer != nil {
+		key, err = recoverer.RecoverKeyFromByte(keyBytes, isPrivate)
+	} else {
+		key, err = heimdall.RecoverKeyByOpt(keyBytes, isPrivate, keyGenOpt)
+	}
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	for _, file := range files {
-		if strings.Compare(file.Name(), keyId) == 0 {
-			keyPath = filepath.Join(keyDirPath, file.Name())
-			break
+	if err := heimdall.CheckKeyAlgorithm(key.KeyGenOpt()); err != nil {
+		if pri, ok := key.(heimdall.PriKey); ok {
+			pri.Clear()
 		}
+		return nil, err
 	}
 
-	if len(keyPath) == 0 {
-		return "", ErrWrongKeyID
-	}
+	return key, nil
+}
+
+// KeyStore is an implementation of heimdall KeyStore on storages of private and public keys.
+// Private key storage holds only one private key. If upgrade is set, key file with weaker parameters than the key store
+// is re-encrypted at loading. If overwrite is set, storing key of stored key ID replaces its key file instead of failing.
+type KeyStore struct {
+	priStorage heimdall.Storage
+	pubStorage heimdall.Storage
+	encOpt     *encryption.Opts
+	kdfOpt     *kdf.Opts
+	upgrade    bool
+	overwrite  bool
+}
 
-	return keyPath, nil
+// NewKeyStore makes key store on private and public key directories.
+func NewKeyStore(priKeyDirPath, pubKeyDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) heimdall.KeyStore {
+	return NewKeyStoreWithStorage(NewFileStorage(priKeyDirPath), NewFileStorage(pubKeyDirPath), encOpt, kdfOpt)
 }
 
-// loadJsonKeyFile reads json formatted KeyFile struct from file.
-func loadKeyFile(keyPath string) (keyBytes []byte, err error) {
-	if len(keyPath) == 0 {
-		return nil, ErrEmptyKeyPath
+// NewKeyStoreWithStorage makes key store on storage backends (ex. database, memory or remote store).
+func NewKeyStoreWithStorage(priStorage, pubStorage heimdall.Storage, encOpt *encryption.Opts, kdfOpt *kdf.Opts) heimdall.KeyStore {
+	return &KeyStore{
+		priStorage: priStorage,
+		pubStorage: pubStorage,
+		encOpt:     encOpt,
+		kdfOpt:     kdfOpt,
 	}
+}
 
-	keyBytes, err = ioutil.ReadFile(keyPath)
+// WithUpgrade sets key store to re-encrypt key file at loading, if its parameters are weaker than the key store.
+func (keyStore *KeyStore) WithUpgrade() *KeyStore {
+	keyStore.upgrade = true
+	return keyStore
+}
+
+// WithOverwrite sets key store to replace key file when key of stored key ID is stored again.
+func (keyStore *KeyStore) WithOverwrite() *KeyStore {
+	keyStore.overwrite = true
+	return keyStore
+}
+
+func (keyStore *KeyStore) StorePriKey(pri heimdall.PriKey, pwd string) error {
+	return storePriKey(keyStore.priStorage, pri, pwd, keyStore.encOpt, keyStore.kdfOpt, keyStore.overwrite)
+}
+
+// LoadPriKey loads private key in private key directory, and checks if it is the key of keyId.
+func (keyStore *KeyStore) LoadPriKey(keyId heimdall.KeyID, pwd string) (heimdall.PriKey, error) {
+	pri, err := keyStore.loadPriKey(pwd)
 	if err != nil {
 		return nil, err
 	}
 
-	return keyBytes, nil
+	if !heimdall.MatchKeyID(keyId, pri) {
+		pri.Clear()
+		return nil, ErrWrongKeyID
+	}
+
+	return pri, nil
+}
+
+func (keyStore *KeyStore) loadPriKey(pwd string) (heimdall.PriKey, error) {
+	if !keyStore.upgrade {
+		return loadPriKey(keyStore.priStorage, pwd, nil)
+	}
+
+	pri, _, err := loadPriKeyWithUpgrade(keyStore.priStorage, pwd, keyStore.encOpt, keyStore.kdfOpt)
+	return pri, err
+}
+
+func (keyStore *KeyStore) StorePubKey(pub heimdall.PubKey) error {
+	return storePubKey(keyStore.pubStorage, pub, keyStore.overwrite)
+}
+
+func (keyStore *KeyStore) LoadPubKey(keyId heimdall.KeyID) (heimdall.PubKey, error) {
+	return loadPubKey(keyStore.pubStorage, keyId, nil)
 }
@@ -0,0 +1,99 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecryptKeyFile_Version1MAC(t *testing.T) {
+	// given
+	keyGenOpt, err := NewKeyGenOpt(ECP256)
+	assert.NoError(t, err)
+	pri, err := GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	jsonKeyFile, err := EncryptKeyFile(pri, "password", encOpt, kdfOpt, nil)
+	assert.NoError(t, err)
+	var keyFile KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+
+	// key file of version 1 has MAC of encrypted key only
+	dKey, err := kdf.DeriveKey([]byte("password"), keyFile.Hints.KDFSalt, encOpt.KeyLen, kdfOpt)
+	assert.NoError(t, err)
+	encryptedKeyBytes, err := hex.DecodeString(keyFile.EncryptedKey)
+	assert.NoError(t, err)
+	keyFile.Version = 1
+	keyFile.Hints.MAC, err = keyFileMACOf(dKey, keyFile.Hints.KDFSalt, encryptedKeyBytes)
+	assert.NoError(t, err)
+	version1KeyFile, err := json.Marshal(keyFile)
+	assert.NoError(t, err)
+
+	// when
+	decryptedPri, err := DecryptKeyFile(version1KeyFile, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), decryptedPri.ID())
+}
+
+func TestLoadPriKeyWithUpgrade_HKDF(t *testing.T) {
+	// given
+	keyGenOpt, err := NewKeyGenOpt(ECP256)
+	assert.NoError(t, err)
+	pri, err := GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	hkdfOpt, err := kdf.NewOpts(kdf.HKDF, kdf.DefaultHkdfParams)
+	assert.NoError(t, err)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	// key files derived by HKDF are no longer stored, but ones stored by older versions are upgraded
+	jsonKeyFile, err := encryptKeyFile(pri, "password", encOpt, hkdfOpt, nil)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	assert.NoError(t, NewFileStorage(heimdall.TestPriKeyDir).Put(pri.ID(), jsonKeyFile))
+
+	// when
+	loadedPri, upgraded, err := LoadPriKeyWithUpgrade(heimdall.TestPriKeyDir, "password", encOpt, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, upgraded)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+
+	upgradedKeyFile, err := NewFileStorage(heimdall.TestPriKeyDir).Get(pri.ID())
+	assert.NoError(t, err)
+	var keyFile KeyFile
+	assert.NoError(t, json.Unmarshal(upgradedKeyFile, &keyFile))
+	assert.Equal(t, kdf.SCRYPT, keyFile.Hints.KDFOpt.KdfName)
+}
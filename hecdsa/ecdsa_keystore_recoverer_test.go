@@ -0,0 +1,119 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/stretchr/testify/assert"
+)
+
+// countingRecoverer counts recoveries delegated to recoverer.
+type countingRecoverer struct {
+	recoverer heimdall.KeyRecoverer
+	count     int
+}
+
+func (recoverer *countingRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	recoverer.count++
+	return recoverer.recoverer.RecoverKeyFromByte(keyBytes, isPrivate)
+}
+
+// failingRecoverer fails every recovery.
+type failingRecoverer struct{}
+
+var errRecoveryFailed = errors.New("recovery failed")
+
+func (failingRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	return nil, errRecoveryFailed
+}
+
+func setUpRSAKeyFile(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA2048)
+	assert.NoError(t, err)
+	pri, err := hrsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	setUpKeyFile(t, pri)
+
+	return pri
+}
+
+func TestRegisterKeyRecoverer(t *testing.T) {
+	// given
+	pri := setUpRSAKeyFile(t)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	recoverer := &countingRecoverer{recoverer: &hrsa.KeyRecoverer{}}
+	heimdall.RegisterKeyRecoverer(heimdall.RSA, recoverer)
+	defer heimdall.RegisterKeyRecoverer(heimdall.RSA, &hrsa.KeyRecoverer{})
+
+	// when
+	loadedPri, err := hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+	assert.Equal(t, 1, recoverer.count)
+}
+
+func TestLoadPriKeyWithRecoverer(t *testing.T) {
+	// given
+	pri := setUpRSAKeyFile(t)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	_, err := hecdsa.LoadPriKeyWithRecoverer(heimdall.TestPriKeyDir, "password", failingRecoverer{})
+
+	// then
+	assert.Equal(t, errRecoveryFailed, err)
+
+	loadedPri, err := hecdsa.LoadPriKeyWithRecoverer(heimdall.TestPriKeyDir, "password", &hrsa.KeyRecoverer{})
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+}
+
+func TestLoadPubKey_RecovererByKeyID(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, hecdsa.StorePubKey(pri.PublicKey(), heimdall.TestPubKeyDir))
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	recoverer := &countingRecoverer{recoverer: &hed25519.KeyRecoverer{}}
+	heimdall.RegisterKeyRecoverer(heimdall.ED25519, recoverer)
+	defer heimdall.RegisterKeyRecoverer(heimdall.ED25519, &hed25519.KeyRecoverer{})
+
+	// when
+	pub, err := hecdsa.LoadPubKey(pri.ID(), heimdall.TestPubKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), pub.ID())
+	assert.Equal(t, 1, recoverer.count)
+
+	_, err = hecdsa.LoadPubKeyWithRecoverer(pri.ID(), heimdall.TestPubKeyDir, failingRecoverer{})
+	assert.Equal(t, errRecoveryFailed, err)
+}
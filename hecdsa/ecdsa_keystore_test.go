@@ -17,14 +17,25 @@
 package hecdsa_test
 
 import (
-	"testing"
-
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"testing"
 
 	"github.com/DE-labtory/heimdall"
 	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hbls"
 	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hpq"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/hsm2"
+	"github.com/DE-labtory/heimdall/hx25519"
 	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/testvectors"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -65,6 +76,48 @@ func TestStorePubKey(t *testing.T) {
 	defer os.RemoveAll(heimdall.TestPubKeyDir)
 }
 
+func TestStorePriKey_AlreadyExists(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	assert.NoError(t, hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, encOpt, kdfOpt))
+
+	// when
+	existErr := hecdsa.StorePriKey(pri, "newPassword", heimdall.TestPriKeyDir, encOpt, kdfOpt)
+	withoutPwdErr := hecdsa.StorePriKeyWithoutPwd(pri, heimdall.TestPriKeyDir)
+	err = hecdsa.StorePriKeyWithOverwrite(pri, "newPassword", heimdall.TestPriKeyDir, encOpt, kdfOpt)
+
+	// then
+	assert.Equal(t, hecdsa.ErrKeyAlreadyExists, existErr)
+	assert.Equal(t, hecdsa.ErrKeyAlreadyExists, withoutPwdErr)
+	assert.NoError(t, err)
+
+	loadedPri, err := hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "newPassword")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+}
+
+func TestStorePubKey_AlreadyExists(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+	assert.NoError(t, hecdsa.StorePubKey(pri.PublicKey(), heimdall.TestPubKeyDir))
+
+	// when
+	existErr := hecdsa.StorePubKey(pri.PublicKey(), heimdall.TestPubKeyDir)
+	err := hecdsa.StorePubKeyWithOverwrite(pri.PublicKey(), heimdall.TestPubKeyDir)
+
+	// then
+	assert.Equal(t, hecdsa.ErrKeyAlreadyExists, existErr)
+	assert.NoError(t, err)
+}
+
 func TestStorePriKeyWithoutPwd(t *testing.T) {
 	// given
 	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
@@ -152,3 +205,751 @@ func TestLoadPriKeyWithoutPwd(t *testing.T) {
 
 	defer os.RemoveAll(heimdall.TestPriKeyDir)
 }
+
+func TestDecryptKeyFile(t *testing.T) {
+	// when
+	_, err := hecdsa.DecryptKeyFile([]byte(`{"SKI":null,"EncryptedKey":"00"}`), "password")
+
+	// then
+	assert.Equal(t, hecdsa.ErrInvalidKeyFile, err)
+}
+
+func TestDecryptKeyFile_HostileKDFParams(t *testing.T) {
+	// given
+	keyFile := `{"SKI":"AA==","EncryptedKey":"00","Hints":{"EncOpt":{"Algorithm":"AES","KeyLen":192,"OpMode":"CTR"},` +
+		`"KDFOpt":{"KdfName":"SCRYPT","KdfParams":{"N":"1099511627776","R":"8","P":"1"}},"KDFSalt":"AAAAAAAAAAA="}}`
+
+	// when
+	_, err := hecdsa.DecryptKeyFile([]byte(keyFile), "password")
+
+	// then
+	assert.Equal(t, kdf.ErrScryptMemoryExceedsLimit, err)
+}
+
+func TestDecryptKeyFile_MAC(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	assert.NoError(t, hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, encOpt, kdfOpt))
+
+	jsonKeyFile, err := ioutil.ReadFile(filepath.Join(heimdall.TestPriKeyDir, pri.ID()))
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+
+	tamperedKeyFile := keyFile
+	encryptedKey, err := hex.DecodeString(keyFile.EncryptedKey)
+	assert.NoError(t, err)
+	encryptedKey[0] ^= 0x01
+	tamperedKeyFile.EncryptedKey = hex.EncodeToString(encryptedKey)
+	tamperedJsonKeyFile, err := json.Marshal(tamperedKeyFile)
+	assert.NoError(t, err)
+
+	// when
+	decryptedPri, err := hecdsa.DecryptKeyFile(jsonKeyFile, "password")
+	_, wrongPwdErr := hecdsa.DecryptKeyFile(jsonKeyFile, "wrong password")
+	_, tamperedErr := hecdsa.DecryptKeyFile(tamperedJsonKeyFile, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), decryptedPri.ID())
+	assert.Len(t, keyFile.Hints.MAC, 48)
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, wrongPwdErr)
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, tamperedErr)
+}
+
+func TestDecryptKeyFile_MACCoversHeader(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	jsonKeyFile, err := hecdsa.EncryptKeyFile(pri, "password", encOpt, kdfOpt, &hecdsa.KeyMetadata{Label: "node-1"})
+	assert.NoError(t, err)
+
+	tamper := func(change func(keyFile *hecdsa.KeyFile)) []byte {
+		var keyFile hecdsa.KeyFile
+		assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+		change(&keyFile)
+		tampered, err := json.Marshal(keyFile)
+		assert.NoError(t, err)
+		return tampered
+	}
+
+	// when
+	_, metadataErr := hecdsa.DecryptKeyFile(tamper(func(keyFile *hecdsa.KeyFile) {
+		keyFile.Metadata = &hecdsa.KeyMetadata{Label: "node-2"}
+	}), "password")
+	_, removedMetadataErr := hecdsa.DecryptKeyFile(tamper(func(keyFile *hecdsa.KeyFile) {
+		keyFile.Metadata = nil
+	}), "password")
+	_, kdfErr := hecdsa.DecryptKeyFile(tamper(func(keyFile *hecdsa.KeyFile) {
+		keyFile.Hints.KDFOpt.KdfParams["P"] = "2"
+	}), "password")
+	_, keyGenOptErr := hecdsa.DecryptKeyFile(tamper(func(keyFile *hecdsa.KeyFile) {
+		keyFile.KeyGenOpt = hecdsa.ECP256
+	}), "password")
+	_, missingErr := hecdsa.DecryptKeyFile(tamper(func(keyFile *hecdsa.KeyFile) {
+		keyFile.Hints.MAC = nil
+	}), "password")
+	_, downgradedErr := hecdsa.DecryptKeyFile(tamper(func(keyFile *hecdsa.KeyFile) {
+		keyFile.Version = 1
+		keyFile.Metadata = nil
+	}), "password")
+
+	// then
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, metadataErr)
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, removedMetadataErr)
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, kdfErr)
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, keyGenOptErr)
+	assert.Equal(t, hecdsa.ErrKeyFileMACMissing, missingErr)
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, downgradedErr)
+}
+
+func TestDecryptKeyFile_StrictKeyFiles(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	assert.NoError(t, hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, encOpt, kdfOpt))
+
+	keyFilePath := filepath.Join(heimdall.TestPriKeyDir, pri.ID())
+	jsonKeyFile, err := ioutil.ReadFile(keyFilePath)
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+
+	keyFile.Version = 0
+	keyFile.Hints.MAC = nil
+	strippedJsonKeyFile, err := json.Marshal(keyFile)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(keyFilePath, strippedJsonKeyFile, 0600))
+
+	hecdsa.SetStrictKeyFiles(true)
+	defer hecdsa.SetStrictKeyFiles(false)
+
+	// when
+	decryptedPri, err := hecdsa.DecryptKeyFile(jsonKeyFile, "password")
+	_, strippedErr := hecdsa.DecryptKeyFile(strippedJsonKeyFile, "password")
+	_, loadErr := hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), decryptedPri.ID())
+	assert.Equal(t, hecdsa.ErrUnversionedKeyFile, strippedErr)
+	assert.Equal(t, hecdsa.ErrUnversionedKeyFile, loadErr)
+}
+
+func TestDecryptKeyFile_Version(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	jsonKeyFile, err := hecdsa.EncryptKeyFile(pri, "password", encOpt, kdfOpt, nil)
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+
+	newerKeyFile := keyFile
+	newerKeyFile.Version = hecdsa.KeyFileVersion + 1
+	newerJsonKeyFile, err := json.Marshal(newerKeyFile)
+	assert.NoError(t, err)
+
+	// when
+	decryptedPri, err := hecdsa.DecryptKeyFile(jsonKeyFile, "password")
+	_, newerErr := hecdsa.DecryptKeyFile(newerJsonKeyFile, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), decryptedPri.ID())
+	assert.Equal(t, hecdsa.KeyFileVersion, keyFile.Version)
+	assert.Nil(t, keyFile.Metadata)
+	assert.Equal(t, hecdsa.ErrUnsupportedKeyFileVersion, newerErr)
+}
+
+func TestDecryptKeyFile_SKIHash(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	assert.NoError(t, heimdall.SetSKIHash(hashing.SHA3_256))
+	defer heimdall.SetSKIHash("")
+	jsonKeyFile, err := hecdsa.EncryptKeyFile(pri, "password", encOpt, kdfOpt, nil)
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+	assert.NoError(t, heimdall.SetSKIHash(""))
+
+	tamperedKeyFile := keyFile
+	tamperedKeyFile.SKIHash = hashing.BLAKE2B_256
+	tamperedJsonKeyFile, err := json.Marshal(tamperedKeyFile)
+	assert.NoError(t, err)
+
+	// when
+	decryptedPri, err := hecdsa.DecryptKeyFile(jsonKeyFile, "password")
+	_, tamperedErr := hecdsa.DecryptKeyFile(tamperedJsonKeyFile, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hashing.SHA3_256, keyFile.SKIHash)
+	assert.Equal(t, pri.SKI(), decryptedPri.SKI())
+	assert.NotEqual(t, pri.SKI(), keyFile.SKI)
+	// recorded SKI hash is covered by MAC of the key file
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, tamperedErr)
+}
+
+func TestKeyStore_SKIHashChanged(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	assert.NoError(t, heimdall.SetSKIHash(hashing.BLAKE2S_256))
+	defer heimdall.SetSKIHash("")
+	keyId := pri.ID()
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	assert.NoError(t, heimdall.SetSKIHash(""))
+	loadedPri, err := keyStore.LoadPriKey(keyId, "password")
+	assert.NoError(t, err)
+	loadedPub, err := keyStore.LoadPubKey(keyId)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+	assert.True(t, heimdall.MatchKeyID(keyId, loadedPub))
+}
+
+func TestLoadPriKeyWithUpgrade_LegacyKeyFile(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	assert.NoError(t, os.MkdirAll(heimdall.TestPriKeyDir, 0700))
+	keyPath := filepath.Join(heimdall.TestPriKeyDir, testvectors.KeyFileName)
+	assert.NoError(t, ioutil.WriteFile(keyPath, []byte(testvectors.KeyFile), 0600))
+
+	// when
+	pri, upgraded, err := hecdsa.LoadPriKeyWithUpgrade(heimdall.TestPriKeyDir, testvectors.KeyFilePassword, encOpt, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, upgraded)
+	assert.Equal(t, testvectors.KeyFileName, pri.ID())
+
+	jsonKeyFile, err := ioutil.ReadFile(keyPath)
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+	assert.NotEmpty(t, keyFile.Hints.MAC)
+}
+
+func TestKeyStore(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	otherPri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	// when
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+	loadedPri, err := keyStore.LoadPriKey(pri.ID(), "password")
+	assert.NoError(t, err)
+	loadedPub, err := keyStore.LoadPubKey(pri.ID())
+	assert.NoError(t, err)
+	_, wrongIdErr := keyStore.LoadPriKey(otherPri.ID(), "password")
+
+	// then
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+	assert.Equal(t, pri.ID(), loadedPub.ID())
+	assert.Equal(t, hecdsa.ErrWrongKeyID, wrongIdErr)
+}
+
+func TestKeyStore_WithOverwrite(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStoreWithStorage(hecdsa.NewMemoryStorage(), hecdsa.NewMemoryStorage(), encOpt, kdfOpt)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	existErr := keyStore.StorePriKey(pri, "newPassword")
+	pubExistErr := keyStore.StorePubKey(pri.PublicKey())
+	overwriteKeyStore := keyStore.(*hecdsa.KeyStore).WithOverwrite()
+	err = overwriteKeyStore.StorePriKey(pri, "newPassword")
+	pubErr := overwriteKeyStore.StorePubKey(pri.PublicKey())
+
+	// then
+	assert.Equal(t, hecdsa.ErrKeyAlreadyExists, existErr)
+	assert.Equal(t, hecdsa.ErrKeyAlreadyExists, pubExistErr)
+	assert.NoError(t, err)
+	assert.NoError(t, pubErr)
+
+	loadedPri, err := overwriteKeyStore.LoadPriKey(pri.ID(), "newPassword")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+}
+
+func TestLoadPriKeyWithUpgrade(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	oldKdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	oldEncOpt, err := encryption.NewOpts(encryption.AES, 128, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "32768", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, 256, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	assert.NoError(t, hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, oldEncOpt, oldKdfOpt))
+
+	// when
+	loadedPri, upgraded, err := hecdsa.LoadPriKeyWithUpgrade(heimdall.TestPriKeyDir, "password", encOpt, kdfOpt)
+	assert.NoError(t, err)
+	_, upgradedAgain, err := hecdsa.LoadPriKeyWithUpgrade(heimdall.TestPriKeyDir, "password", encOpt, kdfOpt)
+	assert.NoError(t, err)
+
+	// then
+	assert.True(t, upgraded)
+	assert.False(t, upgradedAgain)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+
+	files, err := ioutil.ReadDir(heimdall.TestPriKeyDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+	_, err = os.Stat(heimdall.TestPriKeyDir + ".upgrade")
+	assert.True(t, os.IsNotExist(err))
+
+	jsonKeyFile, err := ioutil.ReadFile(filepath.Join(heimdall.TestPriKeyDir, files[0].Name()))
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+	assert.Equal(t, "32768", keyFile.Hints.KDFOpt.KdfParams["N"])
+	assert.Equal(t, 256, keyFile.Hints.EncOpt.KeyLen)
+
+	reloadedPri, err := hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), reloadedPri.ID())
+}
+
+func TestStorePriKey_HKDF(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	hkdfOpt, err := kdf.NewOpts(kdf.HKDF, kdf.DefaultHkdfParams)
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	storeErr := hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, encOpt, hkdfOpt)
+	_, encryptErr := hecdsa.EncryptKeyFile(pri, "password", encOpt, hkdfOpt, nil)
+
+	// then
+	assert.Equal(t, kdf.ErrKdfNotForPassword, storeErr)
+	assert.Equal(t, kdf.ErrKdfNotForPassword, encryptErr)
+	_, err = os.Stat(filepath.Join(heimdall.TestPriKeyDir, pri.ID()))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestChangePriKeyPassword(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	otherPri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, 128, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	assert.NoError(t, hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, encOpt, kdfOpt))
+	oldKeyFile := readKeyFile(t, heimdall.TestPriKeyDir)
+
+	// when
+	wrongKeyErr := hecdsa.ChangePriKeyPassword(otherPri.ID(), "password", "newPassword", heimdall.TestPriKeyDir)
+	err = hecdsa.ChangePriKeyPassword(pri.ID(), "password", "newPassword", heimdall.TestPriKeyDir)
+
+	// then
+	assert.Equal(t, hecdsa.ErrWrongKeyID, wrongKeyErr)
+	assert.NoError(t, err)
+
+	keyFile := readKeyFile(t, heimdall.TestPriKeyDir)
+	assert.NotEqual(t, oldKeyFile.Hints.KDFSalt, keyFile.Hints.KDFSalt)
+	assert.Equal(t, oldKeyFile.Hints.KDFOpt, keyFile.Hints.KDFOpt)
+	assert.Equal(t, oldKeyFile.Hints.EncOpt, keyFile.Hints.EncOpt)
+
+	loadedPri, err := hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "newPassword")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+}
+
+func readKeyFile(t *testing.T, keyDirPath string) *hecdsa.KeyFile {
+	files, err := ioutil.ReadDir(keyDirPath)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	jsonKeyFile, err := ioutil.ReadFile(filepath.Join(keyDirPath, files[0].Name()))
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+
+	return &keyFile
+}
+
+func TestKeyStore_WithUpgrade(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	oldKdfOpt, err := kdf.NewOpts(kdf.PBKDF2, map[string]string{"iteration": "1000", "hashOpt": "SHA384"})
+	assert.NoError(t, err)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	assert.NoError(t, hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, encOpt, oldKdfOpt))
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt).(*hecdsa.KeyStore).WithUpgrade()
+
+	// when
+	loadedPri, err := keyStore.LoadPriKey(pri.ID(), "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+
+	files, err := ioutil.ReadDir(heimdall.TestPriKeyDir)
+	assert.NoError(t, err)
+	jsonKeyFile, err := ioutil.ReadFile(filepath.Join(heimdall.TestPriKeyDir, files[0].Name()))
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+	assert.Equal(t, kdf.SCRYPT, keyFile.Hints.KDFOpt.KdfName)
+}
+
+func TestLoadPriKey_RSA(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA1024)
+	assert.NoError(t, err)
+	pri, err := hrsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	key, err := keyStore.LoadPriKey(pri.ID(), "password")
+	pub, pubErr := keyStore.LoadPubKey(pri.ID())
+
+	// then
+	assert.NoError(t, err)
+	assert.IsType(t, &hrsa.PriKey{}, key)
+	assert.Equal(t, pri.KeyGenOpt(), key.KeyGenOpt())
+	assert.NoError(t, pubErr)
+	assert.IsType(t, &hrsa.PubKey{}, pub)
+	assert.Equal(t, pri.ID(), pub.ID())
+}
+
+func TestLoadPriKeyWithoutPwd_RSA(t *testing.T) {
+	// given
+	keyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA1024)
+	assert.NoError(t, err)
+	pri, err := hrsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	err = hecdsa.StorePriKeyWithoutPwd(pri, heimdall.TestPriKeyDir)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	key, err := hecdsa.LoadPriKeyWithoutPwd(heimdall.TestPriKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), key.ID())
+}
+
+func TestLoadPriKey_Ed25519(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	key, err := keyStore.LoadPriKey(pri.ID(), "password")
+	pub, pubErr := keyStore.LoadPubKey(pri.ID())
+
+	// then
+	assert.NoError(t, err)
+	assert.IsType(t, &hed25519.PriKey{}, key)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.NoError(t, pubErr)
+	assert.IsType(t, &hed25519.PubKey{}, pub)
+	assert.Equal(t, pri.ID(), pub.ID())
+}
+
+func TestLoadPriKeyWithoutPwd_Ed25519(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	err = hecdsa.StorePriKeyWithoutPwd(pri, heimdall.TestPriKeyDir)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	key, err := hecdsa.LoadPriKeyWithoutPwd(heimdall.TestPriKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), key.ID())
+}
+
+func TestLoadPriKey_BLS(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyGenOpt, err := hbls.NewKeyGenOpt(hbls.BLS12381)
+	assert.NoError(t, err)
+	pri, err := hbls.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	key, err := keyStore.LoadPriKey(pri.ID(), "password")
+	pub, pubErr := keyStore.LoadPubKey(pri.ID())
+
+	// then
+	assert.NoError(t, err)
+	assert.IsType(t, &hbls.PriKey{}, key)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.NoError(t, pubErr)
+	assert.IsType(t, &hbls.PubKey{}, pub)
+	assert.Equal(t, pri.ID(), pub.ID())
+}
+
+func TestLoadPriKey_Dilithium(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyGenOpt, err := hpq.NewKeyGenOpt(hpq.DILITHIUM3)
+	assert.NoError(t, err)
+	pri, err := hpq.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	key, err := keyStore.LoadPriKey(pri.ID(), "password")
+	pub, pubErr := keyStore.LoadPubKey(pri.ID())
+
+	// then
+	assert.NoError(t, err)
+	assert.IsType(t, &hpq.PriKey{}, key)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.NoError(t, pubErr)
+	assert.IsType(t, &hpq.PubKey{}, pub)
+	assert.Equal(t, pri.ID(), pub.ID())
+}
+
+func TestLoadPriKey_SM2(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyGenOpt, err := hsm2.NewKeyGenOpt(hsm2.SM2)
+	assert.NoError(t, err)
+	pri, err := hsm2.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	key, err := keyStore.LoadPriKey(pri.ID(), "password")
+	pub, pubErr := keyStore.LoadPubKey(pri.ID())
+
+	// then
+	assert.NoError(t, err)
+	assert.IsType(t, &hsm2.PriKey{}, key)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.NoError(t, pubErr)
+	assert.IsType(t, &hsm2.PubKey{}, pub)
+	assert.Equal(t, pri.ID(), pub.ID())
+}
+
+func TestLoadPriKey_X25519(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyGenOpt, err := hx25519.NewKeyGenOpt(hx25519.X25519)
+	assert.NoError(t, err)
+	pri, err := hx25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	key, err := keyStore.LoadPriKey(pri.ID(), "password")
+	pub, pubErr := keyStore.LoadPubKey(pri.ID())
+
+	// then
+	assert.NoError(t, err)
+	assert.IsType(t, &hx25519.PriKey{}, key)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.NoError(t, pubErr)
+	assert.IsType(t, &hx25519.PubKey{}, pub)
+	assert.Equal(t, pri.ID(), pub.ID())
+}
+
+func TestLoadPubKey_DeniedAlgorithm(t *testing.T) {
+	// given
+	pub := setUpPriKey(t).PublicKey()
+	assert.NoError(t, hecdsa.StorePubKey(pub, heimdall.TestPubKeyDir))
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	heimdall.SetAlgorithmPolicy(&heimdall.AlgorithmPolicy{Keys: heimdall.AlgorithmList{Deny: []string{"ECP384"}}})
+	defer heimdall.SetAlgorithmPolicy(nil)
+
+	// when
+	_, err := hecdsa.LoadPubKey(pub.ID(), heimdall.TestPubKeyDir)
+
+	// then
+	assert.Equal(t, heimdall.ErrKeyAlgorithmDenied, err)
+}
+
+func TestLoadPubKey_LegacyKeyID(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+
+	// public key file stored before algorithm prefixed key IDs
+	assert.NoError(t, os.MkdirAll(heimdall.TestPubKeyDir, 0700))
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestPubKeyDir, heimdall.SKIToKeyID(pri.SKI())), pubBytes, 0600))
+
+	// when
+	pub, err := hecdsa.LoadPubKey(pri.ID(), heimdall.TestPubKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), pub.ID())
+}
+
+func TestLoadPubKey_ByLegacyKeyID(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	assert.NoError(t, hecdsa.StorePubKey(pri.PublicKey(), heimdall.TestPubKeyDir))
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	// when legacy key ID saved before algorithm prefixed key IDs is used
+	pub, err := hecdsa.LoadPubKey(heimdall.SKIToKeyID(pri.SKI()), heimdall.TestPubKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), pub.ID())
+}
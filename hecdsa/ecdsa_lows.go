@@ -0,0 +1,76 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides low-S normalization of ECDSA signatures to prevent signature malleability.
+
+package hecdsa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+)
+
+var ErrHighS = errors.New("invalid signature - S of signature is greater than half of curve order")
+
+// LowSSignerOpts is implemented by signer options which select signatures normalized to low-S form.
+type LowSSignerOpts interface {
+	LowS() bool
+}
+
+// isLowS checks if signer option selects low-S signature.
+func isLowS(opts interface{}) bool {
+	lowSOpts, ok := opts.(LowSSignerOpts)
+	return ok && lowSOpts.LowS()
+}
+
+// IsLowS checks if s is not greater than half of the order of curve.
+// Both (r, s) and (r, n-s) are valid signatures of a message, so only low-S form is accepted in strict verification.
+func IsLowS(curve elliptic.Curve, s *big.Int) bool {
+	halfOrder := new(big.Int).Rsh(curve.Params().N, 1)
+	return s.Cmp(halfOrder) <= 0
+}
+
+// normalizeLowS returns n-s if s is greater than half of the order of curve, otherwise returns s.
+func normalizeLowS(curve elliptic.Curve, s *big.Int) *big.Int {
+	if IsLowS(curve, s) {
+		return s
+	}
+
+	return new(big.Int).Sub(curve.Params().N, s)
+}
+
+// normalizeSignatureLowS normalizes ASN.1 encoded signature made by crypto.Signer to low-S form.
+func normalizeSignatureLowS(pub crypto.PublicKey, signature []byte) ([]byte, error) {
+	ecdsaPubKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrNotECDSAPubKey
+	}
+
+	r, s, err := unmarshalECDSASignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if IsLowS(ecdsaPubKey.Curve, s) {
+		return signature, nil
+	}
+
+	return MarshalSignature(r, normalizeLowS(ecdsaPubKey.Curve, s))
+}
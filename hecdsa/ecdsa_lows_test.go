@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"crypto/elliptic"
+	"encoding/asn1"
+	"math/big"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+type testECDSASignature struct {
+	R, S *big.Int
+}
+
+func parseTestSignature(t *testing.T, signature []byte) *testECDSASignature {
+	_, signature, err := heimdall.DecodeSignature(signature)
+	assert.NoError(t, err)
+
+	sig := new(testECDSASignature)
+	_, err = asn1.Unmarshal(signature, sig)
+	assert.NoError(t, err)
+
+	return sig
+}
+
+// toHighS makes the other valid signature (r, n-s) of low-S signature.
+func toHighS(t *testing.T, signature []byte) []byte {
+	sig := parseTestSignature(t, signature)
+	highS := new(big.Int).Sub(elliptic.P384().Params().N, sig.S)
+
+	highSSignature, err := hecdsa.MarshalSignature(sig.R, highS)
+	assert.NoError(t, err)
+
+	return highSSignature
+}
+
+func TestSign_LowS(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer := hecdsa.NewSigner(pri)
+	cryptoSigner, err := hecdsa.NewCryptoSigner(pri)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(nil).WithLowS()
+	message := []byte("hello")
+
+	for i := 0; i < 16; i++ {
+		// when
+		signature, err := signer.Sign(message, signerOpt)
+		cryptoSignature, cryptoErr := cryptoSigner.Sign(message, signerOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.NoError(t, cryptoErr)
+		assert.True(t, hecdsa.IsLowS(elliptic.P384(), parseTestSignature(t, signature).S))
+		assert.True(t, hecdsa.IsLowS(elliptic.P384(), parseTestSignature(t, cryptoSignature).S))
+
+		valid, err := hecdsa.NewStrictVerifier().Verify(pri.PublicKey(), signature, message, signerOpt)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+
+		valid, err = hecdsa.NewStrictVerifier().Verify(pri.PublicKey(), cryptoSignature, message, signerOpt)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+}
+
+func TestVerifyStrict_HighS(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signerOpt := hecdsa.NewSignerOpts(nil).WithLowS()
+	message := []byte("hello")
+	signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+	assert.NoError(t, err)
+	highSSignature := toHighS(t, signature)
+
+	// when
+	lenientValid, lenientErr := hecdsa.NewVerifier().Verify(pri.PublicKey(), highSSignature, message, signerOpt)
+	strictValid, strictErr := hecdsa.NewStrictVerifier().Verify(pri.PublicKey(), highSSignature, message, signerOpt)
+	funcValid, funcErr := hecdsa.VerifyStrict(pri.PublicKey(), highSSignature, message, signerOpt)
+
+	// then
+	assert.NoError(t, lenientErr)
+	assert.True(t, lenientValid)
+	assert.Equal(t, hecdsa.ErrHighS, strictErr)
+	assert.False(t, strictValid)
+	assert.Equal(t, hecdsa.ErrHighS, funcErr)
+	assert.False(t, funcValid)
+}
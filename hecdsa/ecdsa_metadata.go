@@ -0,0 +1,140 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides optional metadata of key files, so that a node holding several keys can distinguish them by role.
+
+package hecdsa
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var ErrKeyMetadataNotExist = errors.New("key metadata not exist - key file is stored before metadata was introduced")
+
+// key usages of metadata, which are conventions and not restrictions. Any other usage can be set.
+const (
+	UsageTLS          = "tls"
+	UsageBlockSigning = "block-signing"
+	UsageTxSigning    = "tx-signing"
+)
+
+// KeyMetadata is optional metadata of encrypted key file. CreatedAt is set when the key is stored, and the metadata
+// is kept when the key file is re-encrypted. It is covered by MAC of the key file, so it is changed only with password,
+// and tampered metadata fails loading the key. (GetKeyMetadata reads it without checking MAC) ChainCode is chain code
+// of master key of hd package, which is wrapped by key derived from the private key, so it is checked and read only
+// with the private key. UsagePolicy is attached to the key object when the key is loaded and enforced by signers and encryptors.
+type KeyMetadata struct {
+	Label       string `json:",omitempty"`
+	Usage       string `json:",omitempty"`
+	CreatedAt   time.Time
+	Version     int                      `json:",omitempty"`
+	ChainCode   []byte                   `json:",omitempty"`
+	UsagePolicy *heimdall.KeyUsagePolicy `json:",omitempty"`
+}
+
+func newKeyMetadata() *KeyMetadata {
+	return &KeyMetadata{CreatedAt: time.Now().UTC()}
+}
+
+// SetKeyMetadata sets label, usage, version and usage policy of metadata in key file of key ID. The key is decrypted
+// with password and re-encrypted with the same parameters, since metadata is covered by MAC of the key file.
+// Creation time of the key file is kept if CreatedAt of metadata is zero. Usage policy takes effect on keys loaded afterwards.
+func SetKeyMetadata(keyId heimdall.KeyID, metadata *KeyMetadata, pwd, keyDirPath string) error {
+	return setKeyMetadata(NewFileStorage(keyDirPath), keyId, metadata, pwd)
+}
+
+func setKeyMetadata(storage heimdall.Storage, keyId heimdall.KeyID, metadata *KeyMetadata, pwd string) error {
+	name, keyFile, err := getKeyFile(storage, keyId)
+	if err != nil {
+		return err
+	}
+
+	jsonKeyFile, err := json.Marshal(keyFile)
+	if err != nil {
+		return err
+	}
+
+	pri, err := DecryptKeyFile(jsonKeyFile, pwd)
+	if err != nil {
+		return err
+	}
+	defer pri.Clear()
+
+	encOpt, kdfOpt, err := hintsOpts(keyFile.Hints)
+	if err != nil {
+		return err
+	}
+
+	updated := *metadata
+	if updated.CreatedAt.IsZero() && keyFile.Metadata != nil {
+		updated.CreatedAt = keyFile.Metadata.CreatedAt
+	}
+
+	return upgradeKeyFile(storage, name, pri, pwd, encOpt, kdfOpt, &updated)
+}
+
+// GetKeyMetadata returns metadata in key file of key ID, without decrypting the key.
+func GetKeyMetadata(keyId heimdall.KeyID, keyDirPath string) (*KeyMetadata, error) {
+	return getKeyMetadata(NewFileStorage(keyDirPath), keyId)
+}
+
+func getKeyMetadata(storage heimdall.Storage, keyId heimdall.KeyID) (*KeyMetadata, error) {
+	_, keyFile, err := getKeyFile(storage, keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyFile.Metadata == nil {
+		return nil, ErrKeyMetadataNotExist
+	}
+
+	return keyFile.Metadata, nil
+}
+
+// getKeyFile returns name and parsed content of encrypted key file of key ID in storage.
+func getKeyFile(storage heimdall.Storage, keyId heimdall.KeyID) (string, *KeyFile, error) {
+	if err := heimdall.ValidateKeyID(keyId); err != nil {
+		return "", nil, err
+	}
+
+	names, err := keyFileNames(storage, keyId)
+	if err != nil {
+		return "", nil, err
+	}
+
+	for _, name := range names {
+		jsonKeyFile, err := storage.Get(name)
+		if err == heimdall.ErrKeyNotFound {
+			continue
+		} else if err != nil {
+			return "", nil, err
+		}
+
+		keyFile := &KeyFile{}
+		if err := json.Unmarshal(jsonKeyFile, keyFile); err != nil || keyFile.Hints == nil {
+			return "", nil, ErrInvalidKeyFile
+		}
+
+		return name, keyFile, nil
+	}
+
+	return "", nil, ErrWrongKeyID
+}
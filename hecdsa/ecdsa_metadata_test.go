@@ -0,0 +1,194 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpKeyFile(t *testing.T, pri heimdall.PriKey) {
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	assert.NoError(t, hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, encOpt, kdfOpt))
+}
+
+func TestSetKeyMetadata(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	setUpKeyFile(t, pri)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	stored, err := hecdsa.GetKeyMetadata(pri.ID(), heimdall.TestPriKeyDir)
+	assert.NoError(t, err)
+
+	// when
+	err = hecdsa.SetKeyMetadata(pri.ID(), &hecdsa.KeyMetadata{Label: "node-1", Usage: hecdsa.UsageTLS, Version: 2}, "password", heimdall.TestPriKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, stored.CreatedAt.IsZero())
+
+	metadata, err := hecdsa.GetKeyMetadata(pri.ID(), heimdall.TestPriKeyDir)
+	assert.NoError(t, err)
+	assert.Equal(t, "node-1", metadata.Label)
+	assert.Equal(t, hecdsa.UsageTLS, metadata.Usage)
+	assert.Equal(t, 2, metadata.Version)
+	assert.True(t, stored.CreatedAt.Equal(metadata.CreatedAt))
+
+	loadedPri, err := hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+}
+
+func TestSetKeyMetadata_UsagePolicy(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	setUpKeyFile(t, pri)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	metadata, err := hecdsa.GetKeyMetadata(pri.ID(), heimdall.TestPriKeyDir)
+	assert.NoError(t, err)
+	metadata.UsagePolicy = &heimdall.KeyUsagePolicy{Purposes: []string{heimdall.PurposeEncrypt}}
+	assert.NoError(t, hecdsa.SetKeyMetadata(pri.ID(), metadata, "password", heimdall.TestPriKeyDir))
+
+	// when
+	loadedPri, err := hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "password")
+	assert.NoError(t, err)
+
+	// then
+	_, err = hecdsa.NewSigner(loadedPri).Sign([]byte("message"), hecdsa.NewSignerOpts(nil))
+	assert.Equal(t, heimdall.ErrKeyPurposeNotAllowed, err)
+	_, err = hecdsa.NewSigner(pri).Sign([]byte("message"), hecdsa.NewSignerOpts(nil))
+	assert.NoError(t, err)
+
+	ciphertext, err := loadedPri.PublicKey().(*hecdsa.PubKey).Encrypt([]byte("message"), nil)
+	assert.NoError(t, err)
+	_, err = loadedPri.(*hecdsa.PriKey).Decrypt(ciphertext, nil)
+	assert.NoError(t, err)
+
+	metadata.UsagePolicy = &heimdall.KeyUsagePolicy{NotAfter: time.Now().Add(-time.Hour)}
+	assert.NoError(t, hecdsa.SetKeyMetadata(pri.ID(), metadata, "password", heimdall.TestPriKeyDir))
+	reloadedPri, err := hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "password")
+	assert.NoError(t, err)
+	_, err = reloadedPri.PublicKey().(*hecdsa.PubKey).Encrypt([]byte("message"), nil)
+	assert.Equal(t, heimdall.ErrKeyExpired, err)
+}
+
+func TestSetKeyMetadata_TamperedUsagePolicy(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	setUpKeyFile(t, pri)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	metadata := &hecdsa.KeyMetadata{UsagePolicy: &heimdall.KeyUsagePolicy{Purposes: []string{heimdall.PurposeEncrypt}}}
+	assert.NoError(t, hecdsa.SetKeyMetadata(pri.ID(), metadata, "password", heimdall.TestPriKeyDir))
+
+	// when
+	storage := hecdsa.NewFileStorage(heimdall.TestPriKeyDir)
+	jsonKeyFile, err := storage.Get(pri.ID())
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+	keyFile.Metadata.UsagePolicy = nil
+	tampered, err := json.Marshal(keyFile)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.Put(pri.ID(), tampered))
+
+	// then
+	_, err = hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "password")
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, err)
+}
+
+func TestSetKeyMetadata_UsagePolicyWithoutMAC(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	setUpKeyFile(t, pri)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	metadata := &hecdsa.KeyMetadata{UsagePolicy: &heimdall.KeyUsagePolicy{NotAfter: time.Now().Add(-time.Hour)}}
+	assert.NoError(t, hecdsa.SetKeyMetadata(pri.ID(), metadata, "password", heimdall.TestPriKeyDir))
+
+	// when
+	storage := hecdsa.NewFileStorage(heimdall.TestPriKeyDir)
+	jsonKeyFile, err := storage.Get(pri.ID())
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+	keyFile.Version = 0
+	keyFile.Hints.MAC = nil
+	keyFile.Metadata.UsagePolicy.NotAfter = time.Now().Add(time.Hour)
+	tampered, err := json.Marshal(keyFile)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.Put(pri.ID(), tampered))
+
+	// then
+	_, err = hecdsa.LoadPriKey(heimdall.TestPriKeyDir, "password")
+	assert.Equal(t, hecdsa.ErrUnauthenticatedUsagePolicy, err)
+	_, _, err = hecdsa.LoadPriKeyWithUpgrade(heimdall.TestPriKeyDir, "password", nil, nil)
+	assert.Equal(t, hecdsa.ErrUnauthenticatedUsagePolicy, err)
+}
+
+func TestGetKeyMetadata_ChangePriKeyPassword(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	setUpKeyFile(t, pri)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	err := hecdsa.SetKeyMetadata(pri.ID(), &hecdsa.KeyMetadata{Usage: hecdsa.UsageBlockSigning}, "password", heimdall.TestPriKeyDir)
+	assert.NoError(t, err)
+
+	// when
+	err = hecdsa.ChangePriKeyPassword(pri.ID(), "password", "newPassword", heimdall.TestPriKeyDir)
+	assert.NoError(t, err)
+	metadata, err := hecdsa.GetKeyMetadata(pri.ID(), heimdall.TestPriKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hecdsa.UsageBlockSigning, metadata.Usage)
+}
+
+func TestGetKeyMetadata_Failure(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	otherPri := setUpPriKey(t)
+	setUpKeyFile(t, pri)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	assert.NoError(t, hecdsa.StorePubKey(pri.PublicKey(), heimdall.TestPubKeyDir))
+
+	// when
+	_, notExistErr := hecdsa.GetKeyMetadata(otherPri.ID(), heimdall.TestPriKeyDir)
+	_, pubErr := hecdsa.GetKeyMetadata(pri.ID(), heimdall.TestPubKeyDir)
+
+	// then
+	assert.Equal(t, hecdsa.ErrWrongKeyID, notExistErr)
+	assert.Equal(t, hecdsa.ErrInvalidKeyFile, pubErr)
+}
@@ -0,0 +1,399 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides MuSig2 multi-party Schnorr signature, which collapses signatures of several signers
+// into one Schnorr signature verifiable by aggregated public key.
+//
+// Each signer opens MuSigSession and shares its public nonce, then shares partial signature made with nonces of all
+// signers. Anyone holding the public keys, nonces and partial signatures aggregates them by AggregatePartialSignatures.
+
+package hecdsa
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+	"sort"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var ErrEmptyMuSigKeys = errors.New("empty public keys - MuSig needs public keys of signers")
+var ErrMuSigCurveMismatch = errors.New("curve mismatch - public keys of MuSig signers should be on the same curve")
+var ErrMuSigSignerNotFound = errors.New("signer not found - public key of signer is not in public keys of MuSig session")
+var ErrMuSigCount = errors.New("count mismatch - nonces and partial signatures should be given for each public key in order")
+var ErrInvalidMuSigNonce = errors.New("invalid nonce - MuSig nonce should be two compressed points of the curve")
+var ErrInvalidPartialSignature = errors.New("invalid partial signature - partial signature does not match signer's nonce and public key")
+var ErrMuSigNonceUsed = errors.New("nonce already used - MuSig session makes only one partial signature")
+
+// domain separation tags of hashes for key and nonce coefficients
+const (
+	muSigKeyListTag   = "HEIMDALL/MuSig/keylist"
+	muSigKeyCoefTag   = "HEIMDALL/MuSig/keycoef"
+	muSigNonceCoefTag = "HEIMDALL/MuSig/noncecoef"
+)
+
+// muSigKeys is the result of key aggregation, X = sum of a_i * P_i where a_i = H(L || P_i) and L is hash of sorted keys.
+type muSigKeys struct {
+	curve        elliptic.Curve
+	pubs         []*ecdsa.PublicKey
+	coefficients []*big.Int
+	aggregated   *ecdsa.PublicKey
+}
+
+// taggedHash hashes data with tag for domain separation, then reduces it to scalar of the curve.
+func taggedHash(curve elliptic.Curve, tag string, data ...[]byte) *big.Int {
+	hashFunc := sha256.New()
+	hashFunc.Write([]byte(tag))
+	for _, d := range data {
+		hashFunc.Write(d)
+	}
+
+	return new(big.Int).Mod(new(big.Int).SetBytes(hashFunc.Sum(nil)), curve.Params().N)
+}
+
+// aggregateMuSigKeys aggregates public keys. Aggregated key does not depend on order of public keys.
+func aggregateMuSigKeys(pubs []heimdall.PubKey) (*muSigKeys, error) {
+	if len(pubs) == 0 {
+		return nil, ErrEmptyMuSigKeys
+	}
+
+	keys := &muSigKeys{}
+	encodedKeys := make([][]byte, len(pubs))
+	for i, pub := range pubs {
+		ecdsaPub, ok := pub.(*PubKey)
+		if !ok {
+			return nil, ErrNotECDSAPubKey
+		}
+
+		internalPubKey := ecdsaPub.internalPubKey
+		if keys.curve == nil {
+			keys.curve = internalPubKey.Curve
+		} else if keys.curve.Params().Name != internalPubKey.Curve.Params().Name {
+			return nil, ErrMuSigCurveMismatch
+		}
+
+		keys.pubs = append(keys.pubs, internalPubKey)
+		encodedKeys[i] = elliptic.MarshalCompressed(internalPubKey.Curve, internalPubKey.X, internalPubKey.Y)
+	}
+
+	sortedKeys := make([][]byte, len(encodedKeys))
+	copy(sortedKeys, encodedKeys)
+	sort.Slice(sortedKeys, func(i, j int) bool {
+		return bytes.Compare(sortedKeys[i], sortedKeys[j]) < 0
+	})
+	keyList := sha256.Sum256(bytes.Join(sortedKeys, nil))
+
+	var x, y *big.Int
+	for i, pub := range keys.pubs {
+		coefficient := taggedHash(keys.curve, muSigKeyCoefTag, keyList[:], encodedKeys[i])
+		keys.coefficients = append(keys.coefficients, coefficient)
+
+		ax, ay := keys.curve.ScalarMult(pub.X, pub.Y, scalarBytes(keys.curve, coefficient))
+		if x == nil {
+			x, y = ax, ay
+		} else {
+			x, y = keys.curve.Add(x, y, ax, ay)
+		}
+	}
+
+	keys.aggregated = &ecdsa.PublicKey{Curve: keys.curve, X: x, Y: y}
+	return keys, nil
+}
+
+// AggregateSchnorrPubKeys aggregates public keys of MuSig signers into the key which verifies aggregated signature.
+func AggregateSchnorrPubKeys(pubs []heimdall.PubKey) (heimdall.PubKey, error) {
+	keys, err := aggregateMuSigKeys(pubs)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPubKey(keys.aggregated), nil
+}
+
+// muSigNonce is public nonce (R1, R2) of a signer.
+type muSigNonce struct {
+	x1, y1, x2, y2 *big.Int
+}
+
+func parseMuSigNonce(curve elliptic.Curve, nonce []byte) (*muSigNonce, error) {
+	size := pointSize(curve)
+	if len(nonce) != 2*size {
+		return nil, ErrInvalidMuSigNonce
+	}
+
+	parsed := &muSigNonce{}
+	parsed.x1, parsed.y1 = unmarshalCompressed(curve, nonce[:size])
+	parsed.x2, parsed.y2 = unmarshalCompressed(curve, nonce[size:])
+	if parsed.x1 == nil || parsed.x2 == nil {
+		return nil, ErrInvalidMuSigNonce
+	}
+
+	return parsed, nil
+}
+
+// muSigContext is the common values of all signers for a message, computed from aggregated key and nonces.
+type muSigContext struct {
+	nonces    []*muSigNonce
+	nonceCoef *big.Int
+	rx, ry    *big.Int
+	challenge *big.Int
+}
+
+// newMuSigContext computes nonce coefficient b = H(X || R1 || R2 || digest), R = R1 + bR2 and challenge e.
+func newMuSigContext(keys *muSigKeys, nonces [][]byte, digest []byte, opts heimdall.SignerOpts) (*muSigContext, error) {
+	if len(nonces) != len(keys.pubs) {
+		return nil, ErrMuSigCount
+	}
+
+	curve := keys.curve
+	context := &muSigContext{}
+
+	var x1, y1, x2, y2 *big.Int
+	for _, nonce := range nonces {
+		parsed, err := parseMuSigNonce(curve, nonce)
+		if err != nil {
+			return nil, err
+		}
+		context.nonces = append(context.nonces, parsed)
+
+		if x1 == nil {
+			x1, y1, x2, y2 = parsed.x1, parsed.y1, parsed.x2, parsed.y2
+		} else {
+			x1, y1 = curve.Add(x1, y1, parsed.x1, parsed.y1)
+			x2, y2 = curve.Add(x2, y2, parsed.x2, parsed.y2)
+		}
+	}
+
+	aggregated := keys.aggregated
+	context.nonceCoef = taggedHash(curve, muSigNonceCoefTag,
+		elliptic.MarshalCompressed(curve, aggregated.X, aggregated.Y),
+		elliptic.Marshal(curve, x1, y1),
+		elliptic.Marshal(curve, x2, y2),
+		digest)
+
+	bx, by := curve.ScalarMult(x2, y2, scalarBytes(curve, context.nonceCoef))
+	context.rx, context.ry = curve.Add(x1, y1, bx, by)
+	if context.rx.Sign() == 0 && context.ry.Sign() == 0 {
+		return nil, ErrInvalidMuSigNonce
+	}
+
+	hashOpt, err := heimdall.HashOptOf(opts, NewPubKey(aggregated))
+	if err != nil {
+		return nil, err
+	}
+
+	context.challenge, err = schnorrChallenge(curve, context.rx, context.ry, aggregated, digest, hashOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	return context, nil
+}
+
+// MuSigSession is a signer's state of MuSig2 signing for a message. Session makes only one partial signature,
+// since signing twice with the same nonce leaks private key.
+type MuSigSession struct {
+	pri         *PriKey
+	keys        *muSigKeys
+	index       int
+	digest      []byte
+	opts        heimdall.SignerOpts
+	k1, k2      *big.Int
+	publicNonce []byte
+}
+
+// NewMuSigSession opens MuSig2 session of signer holding pri among signers of pubs, generating secret nonces.
+// Signer option selects hash of message and challenge, and should be the same for all signers and verifiers.
+// Usage policy of the key should allow signing.
+func NewMuSigSession(pri heimdall.PriKey, pubs []heimdall.PubKey, message []byte, opts heimdall.SignerOpts) (*MuSigSession, error) {
+	if err := heimdall.CheckKeyUsage(pri, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	ecdsaPri, ok := pri.(*PriKey)
+	if !ok {
+		return nil, ErrNotECDSAPriKey
+	}
+
+	keys, err := aggregateMuSigKeys(pubs)
+	if err != nil {
+		return nil, err
+	}
+
+	index := -1
+	for i, pub := range keys.pubs {
+		if pub.X.Cmp(ecdsaPri.internalPriKey.X) == 0 && pub.Y.Cmp(ecdsaPri.internalPriKey.Y) == 0 {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, ErrMuSigSignerNotFound
+	}
+
+	digest, err := digestOf(NewPubKey(keys.aggregated), message, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	session := &MuSigSession{
+		pri:    ecdsaPri,
+		keys:   keys,
+		index:  index,
+		digest: digest,
+		opts:   opts,
+	}
+
+	curve := keys.curve
+	for _, k := range []**big.Int{&session.k1, &session.k2} {
+		*k, err = randScalar(curve)
+		if err != nil {
+			return nil, err
+		}
+
+		x, y := curve.ScalarBaseMult(scalarBytes(curve, *k))
+		session.publicNonce = append(session.publicNonce, elliptic.MarshalCompressed(curve, x, y)...)
+	}
+
+	return session, nil
+}
+
+// randScalar generates random scalar in [1, n-1].
+func randScalar(curve elliptic.Curve) (*big.Int, error) {
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(curve.Params().N, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+
+	return k.Add(k, big.NewInt(1)), nil
+}
+
+// PublicNonce returns public nonce of the signer, which should be shared with other signers before partial signing.
+func (session *MuSigSession) PublicNonce() []byte {
+	return session.publicNonce
+}
+
+// PartialSign makes partial signature s_i = k1 + b * k2 + e * a_i * d_i with public nonces of all signers,
+// given in the same order with public keys of the session. Secret nonces are cleared after signing.
+// Usage policy of the key is checked again, since the key may expire while nonces are exchanged.
+func (session *MuSigSession) PartialSign(nonces [][]byte) ([]byte, error) {
+	if session.k1 == nil {
+		return nil, ErrMuSigNonceUsed
+	}
+
+	if err := heimdall.CheckKeyUsage(session.pri, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	context, err := newMuSigContext(session.keys, nonces, session.digest, session.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(nonces[session.index], session.publicNonce) {
+		return nil, ErrInvalidMuSigNonce
+	}
+
+	curve := session.keys.curve
+	n := curve.Params().N
+
+	s := new(big.Int).Mul(context.nonceCoef, session.k2)
+	s.Add(s, session.k1)
+	ead := new(big.Int).Mul(context.challenge, session.keys.coefficients[session.index])
+	ead.Mul(ead, session.pri.internalPriKey.D)
+	s.Add(s, ead)
+	s.Mod(s, n)
+
+	// clear secret nonces, so that the session never signs again
+	session.k1.SetInt64(0)
+	session.k2.SetInt64(0)
+	session.k1, session.k2 = nil, nil
+
+	return scalarBytes(curve, s), nil
+}
+
+// verifyPartialSignature checks if s_i * G = R1_i + b * R2_i + e * a_i * P_i.
+func verifyPartialSignature(keys *muSigKeys, context *muSigContext, index int, s *big.Int) bool {
+	curve := keys.curve
+	nonce := context.nonces[index]
+	pub := keys.pubs[index]
+
+	sx, sy := curve.ScalarBaseMult(scalarBytes(curve, s))
+
+	bx, by := curve.ScalarMult(nonce.x2, nonce.y2, scalarBytes(curve, context.nonceCoef))
+	x, y := curve.Add(nonce.x1, nonce.y1, bx, by)
+
+	ea := new(big.Int).Mul(context.challenge, keys.coefficients[index])
+	ea.Mod(ea, curve.Params().N)
+	px, py := curve.ScalarMult(pub.X, pub.Y, scalarBytes(curve, ea))
+	x, y = curve.Add(x, y, px, py)
+
+	return sx.Cmp(x) == 0 && sy.Cmp(y) == 0
+}
+
+// AggregatePartialSignatures verifies partial signatures of signers, then aggregates them into Schnorr signature
+// verifiable by Verify with aggregated public key and signer option of Schnorr. Nonces and partial signatures
+// should be given in the same order with public keys.
+func AggregatePartialSignatures(pubs []heimdall.PubKey, nonces [][]byte, partialSignatures [][]byte, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	keys, err := aggregateMuSigKeys(pubs)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(partialSignatures) != len(keys.pubs) {
+		return nil, ErrMuSigCount
+	}
+
+	digest, err := digestOf(NewPubKey(keys.aggregated), message, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	context, err := newMuSigContext(keys, nonces, digest, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := keys.curve
+	n := curve.Params().N
+	s := new(big.Int)
+	for i, partialSignature := range partialSignatures {
+		if len(partialSignature) != scalarSize(curve) {
+			return nil, ErrInvalidPartialSignature
+		}
+
+		partial := new(big.Int).SetBytes(partialSignature)
+		if partial.Cmp(n) >= 0 || !verifyPartialSignature(keys, context, i, partial) {
+			return nil, ErrInvalidPartialSignature
+		}
+
+		s.Add(s, partial)
+	}
+	s.Mod(s, n)
+
+	if s.Sign() == 0 {
+		return nil, ErrInvalidPartialSignature
+	}
+
+	signature := marshalSchnorrSignature(curve, context.rx, context.ry, s)
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+}
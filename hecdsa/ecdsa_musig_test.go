@@ -0,0 +1,197 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpMuSigKeys(t *testing.T, count int) ([]heimdall.PriKey, []heimdall.PubKey) {
+	return setUpMuSigKeysOnCurve(t, hecdsa.ECP256, count)
+}
+
+func setUpMuSigKeysOnCurve(t *testing.T, curveName string, count int) ([]heimdall.PriKey, []heimdall.PubKey) {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(curveName)
+	assert.NoError(t, err)
+
+	pris := make([]heimdall.PriKey, count)
+	pubs := make([]heimdall.PubKey, count)
+	for i := range pris {
+		pris[i], err = hecdsa.GenerateKey(keyGenOpt)
+		assert.NoError(t, err)
+		pubs[i] = pris[i].PublicKey()
+	}
+
+	return pris, pubs
+}
+
+// signMuSig runs two rounds of MuSig2 for all signers, returning public nonces and partial signatures.
+func signMuSig(t *testing.T, pris []heimdall.PriKey, pubs []heimdall.PubKey, message []byte, opts heimdall.SignerOpts) ([][]byte, [][]byte) {
+	sessions := make([]*hecdsa.MuSigSession, len(pris))
+	nonces := make([][]byte, len(pris))
+	for i, pri := range pris {
+		session, err := hecdsa.NewMuSigSession(pri, pubs, message, opts)
+		assert.NoError(t, err)
+		sessions[i] = session
+		nonces[i] = session.PublicNonce()
+	}
+
+	partialSignatures := make([][]byte, len(pris))
+	for i, session := range sessions {
+		partialSignature, err := session.PartialSign(nonces)
+		assert.NoError(t, err)
+		partialSignatures[i] = partialSignature
+	}
+
+	return nonces, partialSignatures
+}
+
+func TestAggregatePartialSignatures(t *testing.T) {
+	// given
+	pris, pubs := setUpMuSigKeys(t, 3)
+	signerOpt := hecdsa.NewSignerOpts(nil).WithSchnorr()
+	message := []byte("endorsement")
+	nonces, partialSignatures := signMuSig(t, pris, pubs, message, signerOpt)
+
+	// when
+	signature, err := hecdsa.AggregatePartialSignatures(pubs, nonces, partialSignatures, message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	aggregatedPub, err := hecdsa.AggregateSchnorrPubKeys(pubs)
+	assert.NoError(t, err)
+
+	valid, err := hecdsa.Verify(aggregatedPub, signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hecdsa.Verify(pubs[0], signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestAggregatePartialSignatures_Curves(t *testing.T) {
+	for _, curveName := range []string{hecdsa.ECSECP256K1, hecdsa.ECP384, hecdsa.ECP521} {
+		// given
+		pris, pubs := setUpMuSigKeysOnCurve(t, curveName, 3)
+		signerOpt := hecdsa.NewSignerOpts(nil).WithSchnorr()
+		message := []byte("endorsement")
+		nonces, partialSignatures := signMuSig(t, pris, pubs, message, signerOpt)
+
+		// when
+		signature, err := hecdsa.AggregatePartialSignatures(pubs, nonces, partialSignatures, message, signerOpt)
+
+		// then
+		assert.NoError(t, err, curveName)
+		aggregatedPub, err := hecdsa.AggregateSchnorrPubKeys(pubs)
+		assert.NoError(t, err, curveName)
+
+		valid, err := hecdsa.Verify(aggregatedPub, signature, message, signerOpt)
+		assert.NoError(t, err, curveName)
+		assert.True(t, valid, curveName)
+	}
+}
+
+func TestAggregateSchnorrPubKeys_OrderIndependent(t *testing.T) {
+	// given
+	_, pubs := setUpMuSigKeys(t, 3)
+	reversed := []heimdall.PubKey{pubs[2], pubs[1], pubs[0]}
+
+	// when
+	aggregatedPub, err := hecdsa.AggregateSchnorrPubKeys(pubs)
+	reversedPub, reversedErr := hecdsa.AggregateSchnorrPubKeys(reversed)
+	_, emptyErr := hecdsa.AggregateSchnorrPubKeys(nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, reversedErr)
+	assert.Equal(t, aggregatedPub.ID(), reversedPub.ID())
+	assert.Equal(t, hecdsa.ErrEmptyMuSigKeys, emptyErr)
+}
+
+func TestAggregatePartialSignatures_InvalidPartial(t *testing.T) {
+	// given
+	pris, pubs := setUpMuSigKeys(t, 2)
+	signerOpt := hecdsa.NewSignerOpts(nil).WithSchnorr()
+	message := []byte("endorsement")
+	nonces, partialSignatures := signMuSig(t, pris, pubs, message, signerOpt)
+
+	// when
+	_, swappedErr := hecdsa.AggregatePartialSignatures(pubs, nonces, [][]byte{partialSignatures[1], partialSignatures[0]}, message, signerOpt)
+	_, otherMessageErr := hecdsa.AggregatePartialSignatures(pubs, nonces, partialSignatures, []byte("other"), signerOpt)
+	_, countErr := hecdsa.AggregatePartialSignatures(pubs, nonces, partialSignatures[:1], message, signerOpt)
+
+	// then
+	assert.Equal(t, hecdsa.ErrInvalidPartialSignature, swappedErr)
+	assert.Equal(t, hecdsa.ErrInvalidPartialSignature, otherMessageErr)
+	assert.Equal(t, hecdsa.ErrMuSigCount, countErr)
+}
+
+func TestMuSigSession_PartialSign(t *testing.T) {
+	// given
+	pris, pubs := setUpMuSigKeys(t, 2)
+	_, otherPubs := setUpMuSigKeys(t, 1)
+	signerOpt := hecdsa.NewSignerOpts(nil).WithSchnorr()
+	message := []byte("endorsement")
+
+	session, err := hecdsa.NewMuSigSession(pris[0], pubs, message, signerOpt)
+	assert.NoError(t, err)
+	otherSession, err := hecdsa.NewMuSigSession(pris[1], pubs, message, signerOpt)
+	assert.NoError(t, err)
+	nonces := [][]byte{session.PublicNonce(), otherSession.PublicNonce()}
+
+	// when
+	_, err = session.PartialSign(nonces)
+	_, reusedErr := session.PartialSign(nonces)
+	_, notFoundErr := hecdsa.NewMuSigSession(pris[0], otherPubs, message, signerOpt)
+	_, nonceErr := otherSession.PartialSign([][]byte{nonces[0], []byte("nonce")})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hecdsa.ErrMuSigNonceUsed, reusedErr)
+	assert.Equal(t, hecdsa.ErrMuSigSignerNotFound, notFoundErr)
+	assert.Equal(t, hecdsa.ErrInvalidMuSigNonce, nonceErr)
+}
+
+func TestMuSigSession_ExpiredKey(t *testing.T) {
+	// given
+	pris, pubs := setUpMuSigKeys(t, 2)
+	signerOpt := hecdsa.NewSignerOpts(nil).WithSchnorr()
+	message := []byte("endorsement")
+	expired := &heimdall.KeyUsagePolicy{NotAfter: time.Now().Add(-time.Hour)}
+
+	session, err := hecdsa.NewMuSigSession(pris[0], pubs, message, signerOpt)
+	assert.NoError(t, err)
+	otherSession, err := hecdsa.NewMuSigSession(pris[1], pubs, message, signerOpt)
+	assert.NoError(t, err)
+	nonces := [][]byte{session.PublicNonce(), otherSession.PublicNonce()}
+
+	// when
+	pris[0].(heimdall.UsagePolicyHolder).SetUsagePolicy(expired)
+	_, partialErr := session.PartialSign(nonces)
+	_, sessionErr := hecdsa.NewMuSigSession(pris[0], pubs, message, signerOpt)
+
+	// then
+	assert.Equal(t, heimdall.ErrKeyExpired, partialErr)
+	assert.Equal(t, heimdall.ErrKeyExpired, sessionErr)
+}
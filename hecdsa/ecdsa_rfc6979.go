@@ -0,0 +1,160 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides deterministic ECDSA signing, whose nonce is derived from private key and digest. (RFC 6979)
+
+package hecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"errors"
+	"hash"
+	"math/big"
+)
+
+var ErrDeterministicNotSupported = errors.New("deterministic signature not supported - signer does not hold private key in memory")
+
+// DeterministicSignerOpts is implemented by signer options which select deterministic signature.
+type DeterministicSignerOpts interface {
+	Deterministic() bool
+}
+
+// isDeterministic checks if signer option selects deterministic signature.
+func isDeterministic(opts interface{}) bool {
+	deterministicOpts, ok := opts.(DeterministicSignerOpts)
+	return ok && deterministicOpts.Deterministic()
+}
+
+// nonceGenerator generates nonces of RFC 6979 section 3.2 by HMAC_DRBG of the hash function.
+type nonceGenerator struct {
+	hashFunc func() hash.Hash
+	q        *big.Int
+	k        []byte
+	v        []byte
+}
+
+// newNonceGenerator seeds HMAC_DRBG with private key and digest. (steps a to g)
+func newNonceGenerator(pri *ecdsa.PrivateKey, digest []byte, hashFunc func() hash.Hash) *nonceGenerator {
+	q := pri.Curve.Params().N
+	hashSize := hashFunc().Size()
+
+	generator := &nonceGenerator{
+		hashFunc: hashFunc,
+		q:        q,
+		k:        make([]byte, hashSize),
+		v:        make([]byte, hashSize),
+	}
+	for i := range generator.v {
+		generator.v[i] = 0x01
+	}
+
+	privateKey := int2octets(pri.D, q)
+	h1 := bits2octets(digest, q)
+
+	generator.k = generator.mac(generator.k, generator.v, []byte{0x00}, privateKey, h1)
+	generator.v = generator.mac(generator.k, generator.v)
+	generator.k = generator.mac(generator.k, generator.v, []byte{0x01}, privateKey, h1)
+	generator.v = generator.mac(generator.k, generator.v)
+
+	return generator
+}
+
+func (generator *nonceGenerator) mac(key []byte, data ...[]byte) []byte {
+	mac := hmac.New(generator.hashFunc, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+
+	return mac.Sum(nil)
+}
+
+// next returns next nonce in [1, q-1]. (step h)
+func (generator *nonceGenerator) next() *big.Int {
+	rlen := (generator.q.BitLen() + 7) / 8
+
+	for {
+		var t []byte
+		for len(t) < rlen {
+			generator.v = generator.mac(generator.k, generator.v)
+			t = append(t, generator.v...)
+		}
+
+		k := bits2int(t[:rlen], generator.q)
+		if k.Sign() > 0 && k.Cmp(generator.q) < 0 {
+			return k
+		}
+
+		generator.k = generator.mac(generator.k, generator.v, []byte{0x00})
+		generator.v = generator.mac(generator.k, generator.v)
+	}
+}
+
+// bits2int converts leftmost bits of b, as many as bit length of q, to integer.
+func bits2int(b []byte, q *big.Int) *big.Int {
+	v := new(big.Int).SetBytes(b)
+	if excess := len(b)*8 - q.BitLen(); excess > 0 {
+		v.Rsh(v, uint(excess))
+	}
+
+	return v
+}
+
+// int2octets converts integer to big endian bytes as long as q.
+func int2octets(v *big.Int, q *big.Int) []byte {
+	octets := make([]byte, (q.BitLen()+7)/8)
+	return v.FillBytes(octets)
+}
+
+// bits2octets converts digest to integer reduced modulo q, then to bytes as long as q.
+func bits2octets(b []byte, q *big.Int) []byte {
+	z := bits2int(b, q)
+	if z.Cmp(q) >= 0 {
+		z.Sub(z, q)
+	}
+
+	return int2octets(z, q)
+}
+
+// signDeterministic signs digest with nonces of RFC 6979, so that the same key and digest always make the same signature.
+func signDeterministic(pri *ecdsa.PrivateKey, digest []byte, hashFunc func() hash.Hash) (*big.Int, *big.Int) {
+	params := pri.Curve.Params()
+	n := params.N
+	e := bits2int(digest, n)
+	generator := newNonceGenerator(pri, digest, hashFunc)
+
+	for {
+		k := generator.next()
+
+		x, _ := pri.Curve.ScalarBaseMult(int2octets(k, n))
+		r := new(big.Int).Mod(x, n)
+		if r.Sign() == 0 {
+			continue
+		}
+
+		// s = k^-1 * (e + r * d) mod n
+		s := new(big.Int).Mul(r, pri.D)
+		s.Add(s, e)
+		s.Mul(s, new(big.Int).ModInverse(k, n))
+		s.Mod(s, n)
+		if s.Sign() == 0 {
+			continue
+		}
+
+		return r, s
+	}
+}
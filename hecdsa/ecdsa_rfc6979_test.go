@@ -0,0 +1,91 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/testvectors"
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6979SHA256 is a name of hash option for SHA-256, which heimdall hash option SHA256 is not.
+const rfc6979SHA256 = "RFC6979-SHA-256"
+
+func TestSign_Deterministic(t *testing.T) {
+	assert.NoError(t, hashing.RegisterHashFunc(rfc6979SHA256, sha256.New))
+
+	for _, vector := range []*testvectors.ECDSAVector{testvectors.RFC6979P256, testvectors.RFC6979P384} {
+		// given
+		pri, err := vector.PriKey()
+		assert.NoError(t, err)
+
+		hashName := vector.HashName
+		if hashName == "" {
+			hashName = rfc6979SHA256
+		}
+		hashOpt, err := hashing.NewHashOpt(hashName)
+		assert.NoError(t, err)
+		signerOpt := hecdsa.NewSignerOpts(hashOpt).WithDeterministic()
+
+		// when
+		signature, err := hecdsa.NewSigner(pri).Sign(vector.Message, signerOpt)
+
+		// then
+		assert.NoError(t, err, vector.Name)
+		expected, err := vector.Signature(heimdall.SignatureVersionLegacy)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, signature, vector.Name)
+	}
+}
+
+func TestSign_DeterministicReproducible(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signerOpt := hecdsa.NewSignerOpts(nil).WithDeterministic()
+	message := []byte("hello")
+
+	// when
+	signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+	otherSignature, otherErr := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, otherErr)
+	assert.Equal(t, signature, otherSignature)
+
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestCryptoSigner_Deterministic(t *testing.T) {
+	// given
+	signer, err := hecdsa.NewCryptoSigner(setUpPriKey(t))
+	assert.NoError(t, err)
+
+	// when
+	_, err = signer.Sign([]byte("hello"), hecdsa.NewSignerOpts(nil).WithDeterministic())
+
+	// then
+	assert.Equal(t, hecdsa.ErrDeterministicNotSupported, err)
+}
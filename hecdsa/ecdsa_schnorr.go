@@ -0,0 +1,172 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Schnorr signature on the curve of ECDSA key.
+// Signature is compressed point R and scalar s, where sG = R + eP and e = H(R || P || digest of message).
+
+package hecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+)
+
+var ErrInvalidSchnorrSignature = errors.New("invalid signature - Schnorr signature should be compressed point R and scalar s of the key's curve")
+var ErrSchnorrNotSupported = errors.New("schnorr signature not supported - signer does not hold private key in memory")
+
+// isSchnorr checks if signer option selects Schnorr signature.
+func isSchnorr(opts heimdall.SignerOpts) bool {
+	return heimdall.SchemeParamsOf(opts).Schnorr
+}
+
+// scalarBytes returns scalar in fixed width of curve order.
+func scalarBytes(curve elliptic.Curve, scalar *big.Int) []byte {
+	return scalar.FillBytes(make([]byte, scalarSize(curve)))
+}
+
+// pointSize returns byte length of compressed point of the curve.
+func pointSize(curve elliptic.Curve) int {
+	return 1 + (curve.Params().BitSize+7)/8
+}
+
+// schnorrChallenge computes e = H(R || P || digest) mod n, with hash option of the signature.
+func schnorrChallenge(curve elliptic.Curve, rx, ry *big.Int, pub *ecdsa.PublicKey, digest []byte, hashOpt *hashing.HashOpt) (*big.Int, error) {
+	data := elliptic.MarshalCompressed(curve, rx, ry)
+	data = append(data, elliptic.MarshalCompressed(curve, pub.X, pub.Y)...)
+	data = append(data, digest...)
+
+	hashValue, err := hashing.Hash(data, hashOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).Mod(new(big.Int).SetBytes(hashValue), curve.Params().N), nil
+}
+
+// schnorrNonce derives nonce from private key, digest and random bytes, so that nonce is not repeated
+// even if random source is weak. Counter-chained SHA-512 blocks are expanded to 128 bits more than curve order
+// before reduction, so that nonce is not biased on curves larger than SHA-512 such as P-521.
+func schnorrNonce(pri *ecdsa.PrivateKey, digest []byte, random io.Reader) (*big.Int, error) {
+	auxRand := make([]byte, 32)
+	if _, err := io.ReadFull(random, auxRand); err != nil {
+		return nil, err
+	}
+
+	n := pri.Curve.Params().N
+	expandedLen := (n.BitLen() + 128 + 7) / 8
+	for {
+		expanded := make([]byte, 0, expandedLen+sha512.Size)
+		for counter := byte(0); len(expanded) < expandedLen; counter++ {
+			hashFunc := sha512.New()
+			hashFunc.Write([]byte{counter})
+			hashFunc.Write(scalarBytes(pri.Curve, pri.D))
+			hashFunc.Write(digest)
+			hashFunc.Write(auxRand)
+			expanded = hashFunc.Sum(expanded)
+		}
+
+		k := new(big.Int).Mod(new(big.Int).SetBytes(expanded[:expandedLen]), n)
+		if k.Sign() != 0 {
+			return k, nil
+		}
+
+		auxRand = expanded[:32]
+	}
+}
+
+// marshalSchnorrSignature encodes compressed point R followed by s in fixed width of curve order.
+func marshalSchnorrSignature(curve elliptic.Curve, rx, ry, s *big.Int) []byte {
+	return append(elliptic.MarshalCompressed(curve, rx, ry), scalarBytes(curve, s)...)
+}
+
+// unmarshalSchnorrSignature parses legacy (bare) or versioned Schnorr signature to point R and s.
+func unmarshalSchnorrSignature(curve elliptic.Curve, signature []byte) (*big.Int, *big.Int, *big.Int, error) {
+	size := pointSize(curve) + scalarSize(curve)
+	if len(signature) == size+1 {
+		_, bare, err := heimdall.DecodeSignature(signature)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		signature = bare
+	}
+
+	if len(signature) != size {
+		return nil, nil, nil, ErrInvalidSchnorrSignature
+	}
+
+	rx, ry := unmarshalCompressed(curve, signature[:pointSize(curve)])
+	if rx == nil {
+		return nil, nil, nil, ErrInvalidSchnorrSignature
+	}
+
+	s := new(big.Int).SetBytes(signature[pointSize(curve):])
+	if s.Sign() == 0 || s.Cmp(curve.Params().N) >= 0 {
+		return nil, nil, nil, ErrInvalidSchnorrSignature
+	}
+
+	return rx, ry, s, nil
+}
+
+// signSchnorr signs digest with s = k + ed, where R = kG.
+func signSchnorr(pri *ecdsa.PrivateKey, digest []byte, hashOpt *hashing.HashOpt) ([]byte, error) {
+	k, err := schnorrNonce(pri, digest, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	curve := pri.Curve
+	rx, ry := curve.ScalarBaseMult(scalarBytes(curve, k))
+
+	e, err := schnorrChallenge(curve, rx, ry, &pri.PublicKey, digest, hashOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(big.Int).Mul(e, pri.D)
+	s.Add(s, k)
+	s.Mod(s, curve.Params().N)
+
+	return marshalSchnorrSignature(curve, rx, ry, s), nil
+}
+
+// verifySchnorr checks if sG = R + eP.
+func verifySchnorr(pub *ecdsa.PublicKey, signature, digest []byte, hashOpt *hashing.HashOpt) (bool, error) {
+	curve := pub.Curve
+	rx, ry, s, err := unmarshalSchnorrSignature(curve, signature)
+	if err != nil {
+		return false, err
+	}
+
+	e, err := schnorrChallenge(curve, rx, ry, pub, digest, hashOpt)
+	if err != nil {
+		return false, err
+	}
+
+	sx, sy := curve.ScalarBaseMult(scalarBytes(curve, s))
+	ex, ey := curve.ScalarMult(pub.X, pub.Y, scalarBytes(curve, e))
+	x, y := curve.Add(rx, ry, ex, ey)
+
+	return sx.Cmp(x) == 0 && sy.Cmp(y) == 0, nil
+}
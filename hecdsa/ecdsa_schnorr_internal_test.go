@@ -0,0 +1,50 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchnorrNonce_P521Range(t *testing.T) {
+	// given
+	pri, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	assert.NoError(t, err)
+	n := elliptic.P521().Params().N
+
+	// when
+	aboveSHA512 := 0
+	for i := 0; i < 64; i++ {
+		k, err := schnorrNonce(pri, []byte{byte(i)}, rand.Reader)
+		assert.NoError(t, err)
+
+		// then
+		assert.True(t, k.Sign() > 0 && k.Cmp(n) < 0)
+		if k.BitLen() > 512 {
+			aboveSHA512++
+		}
+	}
+
+	// nonce below 2^512 happens with probability about 2^-9, not always
+	assert.True(t, aboveSHA512 > 48)
+}
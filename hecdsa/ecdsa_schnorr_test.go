@@ -0,0 +1,112 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign_Schnorr(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signerOpt := hecdsa.NewSignerOpts(nil).WithSchnorr()
+	message := []byte("hello")
+
+	// when
+	signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	// compressed point and scalar of P-384
+	assert.Len(t, signature, 49+48)
+
+	valid, err := hecdsa.NewVerifier().Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hecdsa.Verify(pri.PublicKey(), signature, []byte("world"), signerOpt)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	// Schnorr signature is not ECDSA signature
+	_, err = hecdsa.Verify(pri.PublicKey(), signature, message, hecdsa.NewSignerOpts(nil))
+	assert.Error(t, err)
+}
+
+func TestSign_SchnorrCurves(t *testing.T) {
+	for _, curveName := range []string{hecdsa.ECSECP256K1, hecdsa.ECP256, hecdsa.ECP521, hecdsa.ECBP256R1, hecdsa.ECBP384R1} {
+		// given
+		keyGenOpt, err := hecdsa.NewKeyGenOpt(curveName)
+		assert.NoError(t, err)
+		pri, err := hecdsa.GenerateKey(keyGenOpt)
+		assert.NoError(t, err)
+		signerOpt := hecdsa.NewSignerOpts(nil).WithSchnorr()
+
+		// when
+		signature, err := hecdsa.Sign(pri, []byte("hello"), signerOpt)
+
+		// then
+		assert.NoError(t, err, curveName)
+		valid, err := hecdsa.Verify(pri.PublicKey(), signature, []byte("hello"), signerOpt)
+		assert.NoError(t, err, curveName)
+		assert.True(t, valid, curveName)
+	}
+}
+
+func TestSign_SchnorrSignatureVersion(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signerOpt := hecdsa.NewSignerOpts(nil).WithSchnorr().WithSignatureVersion(heimdall.SignatureVersion1)
+	message := []byte("hello")
+
+	// when
+	signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, heimdall.SignatureVersion1, signature[0])
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, hecdsa.NewSignerOpts(nil).WithSchnorr())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	_, err = hecdsa.Verify(pri.PublicKey(), signature[:10], message, signerOpt)
+	assert.Equal(t, hecdsa.ErrInvalidSchnorrSignature, err)
+}
+
+func TestSign_SchnorrNotSupported(t *testing.T) {
+	// given
+	signer, err := hecdsa.NewCryptoSigner(setUpPriKey(t))
+	assert.NoError(t, err)
+	rsaKeyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA1024)
+	assert.NoError(t, err)
+	rsaPri, err := hrsa.GenerateKey(rsaKeyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	_, cryptoSignerErr := signer.Sign([]byte("hello"), hecdsa.NewSignerOpts(nil).WithSchnorr())
+	_, mismatchErr := hrsa.Sign(rsaPri, []byte("hello"), hecdsa.NewSignerOpts(nil).WithSchnorr())
+
+	// then
+	assert.Equal(t, hecdsa.ErrSchnorrNotSupported, cryptoSignerErr)
+	assert.Equal(t, heimdall.ErrSchemeMismatch, mismatchErr)
+}
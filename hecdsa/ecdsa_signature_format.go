@@ -0,0 +1,348 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides encoders and decoders of ECDSA signature formats other than ASN.1 DER, for interoperability
+// with other stacks such as Ethereum, Bitcoin and JOSE.
+
+package hecdsa
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var ErrUnknownSignatureFormat = errors.New("unknown signature format - format should be one of DER, RAW and COMPACT")
+var ErrInvalidSignatureLength = errors.New("invalid signature - length of signature does not match format and curve")
+var ErrSignatureOutOfRange = errors.New("invalid signature - signature's R and S value should be less than curve order")
+var ErrInvalidRecoveryID = errors.New("invalid signature - recovery ID should be between 0 and 3")
+var ErrRecoveryIDRequired = errors.New("recovery ID required - use ToCompactSignature to find recovery ID with public key")
+var ErrPubKeyNotRecovered = errors.New("public key not recovered - signature does not match any point of the curve")
+
+// SignatureFormat is a serialization format of ECDSA signature.
+type SignatureFormat string
+
+const (
+	// SignatureFormatDER is ASN.1 DER encoding of (r, s), made by Sign. (ex. X.509, TLS)
+	SignatureFormatDER SignatureFormat = "DER"
+
+	// SignatureFormatRaw is concatenation of r and s in fixed width of curve order. (ex. JOSE, PKCS #11)
+	SignatureFormatRaw SignatureFormat = "RAW"
+
+	// SignatureFormatCompact is r || s || v, where v is recovery ID of public key. (ex. 65 bytes of Ethereum for 256 bit curve)
+	SignatureFormatCompact SignatureFormat = "COMPACT"
+)
+
+// Signature is a decoded ECDSA signature. RecoveryID is set only when decoded from compact format.
+type Signature struct {
+	R, S       *big.Int
+	RecoveryID *byte
+}
+
+// SignatureEncoder serializes signature of the curve in its format.
+type SignatureEncoder interface {
+	Encode(curve elliptic.Curve, signature *Signature) ([]byte, error)
+}
+
+// SignatureDecoder parses signature of the curve serialized in its format.
+type SignatureDecoder interface {
+	Decode(curve elliptic.Curve, signature []byte) (*Signature, error)
+}
+
+type signatureCodec interface {
+	SignatureEncoder
+	SignatureDecoder
+}
+
+var signatureCodecs = map[SignatureFormat]signatureCodec{
+	SignatureFormatDER:     derSignatureCodec{},
+	SignatureFormatRaw:     rawSignatureCodec{},
+	SignatureFormatCompact: compactSignatureCodec{},
+}
+
+func NewSignatureEncoder(format SignatureFormat) (SignatureEncoder, error) {
+	codec, ok := signatureCodecs[format]
+	if !ok {
+		return nil, ErrUnknownSignatureFormat
+	}
+
+	return codec, nil
+}
+
+func NewSignatureDecoder(format SignatureFormat) (SignatureDecoder, error) {
+	codec, ok := signatureCodecs[format]
+	if !ok {
+		return nil, ErrUnknownSignatureFormat
+	}
+
+	return codec, nil
+}
+
+// ConvertSignature converts signature of the curve from a format to another format.
+// Converting to compact format requires recovery ID, so signature not in compact format should be converted by ToCompactSignature.
+func ConvertSignature(curve elliptic.Curve, signature []byte, from, to SignatureFormat) ([]byte, error) {
+	decoder, err := NewSignatureDecoder(from)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder, err := NewSignatureEncoder(to)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := decoder.Decode(curve, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return encoder.Encode(curve, decoded)
+}
+
+// ToCompactSignature converts signature to compact format, finding recovery ID by recovering public key from signature
+// and digest. Digest is the hash of the message signed. (ex. hashed by hashing.Hash with hash option of signer option)
+func ToCompactSignature(pub heimdall.PubKey, digest, signature []byte, from SignatureFormat) ([]byte, error) {
+	ecdsaPub, ok := pub.(*PubKey)
+	if !ok {
+		return nil, ErrNotECDSAPubKey
+	}
+	curve := ecdsaPub.internalPubKey.Curve
+
+	decoder, err := NewSignatureDecoder(from)
+	if err != nil {
+		return nil, err
+	}
+
+	decoded, err := decoder.Decode(curve, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	for recoveryID := byte(0); recoveryID < 4; recoveryID++ {
+		recovered, err := recoverPublicKey(curve, digest, decoded.R, decoded.S, recoveryID)
+		if err != nil {
+			continue
+		}
+
+		if recovered.X.Cmp(ecdsaPub.internalPubKey.X) == 0 && recovered.Y.Cmp(ecdsaPub.internalPubKey.Y) == 0 {
+			decoded.RecoveryID = &recoveryID
+			return compactSignatureCodec{}.Encode(curve, decoded)
+		}
+	}
+
+	return nil, ErrPubKeyNotRecovered
+}
+
+// RecoverPubKey recovers public key of the signer from signature in compact format and digest of the message signed.
+// Recovered key should be compared with the expected signer, as any valid signature recovers some public key.
+func RecoverPubKey(curve elliptic.Curve, digest, signature []byte) (heimdall.PubKey, error) {
+	decoded, err := compactSignatureCodec{}.Decode(curve, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	recovered, err := recoverPublicKey(curve, digest, decoded.R, decoded.S, *decoded.RecoveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPubKey(recovered), nil
+}
+
+// recoverPublicKey recovers public key Q = r^-1 (sR - eG), where R is the point of x coordinate r + (recoveryID / 2) * n
+// and y coordinate of parity recoveryID % 2. (SEC 1 v2, 4.1.6)
+func recoverPublicKey(curve elliptic.Curve, digest []byte, r, s *big.Int, recoveryID byte) (*ecdsa.PublicKey, error) {
+	params := curve.Params()
+
+	x := new(big.Int).Set(r)
+	if recoveryID&2 != 0 {
+		x.Add(x, params.N)
+	}
+
+	if x.Cmp(params.P) >= 0 {
+		return nil, ErrPubKeyNotRecovered
+	}
+
+	// y^2 = x^3 - 3x + b
+	x3 := new(big.Int).Exp(x, big.NewInt(3), params.P)
+	threeX := new(big.Int).Mul(x, big.NewInt(3))
+	ySquare := new(big.Int).Sub(x3, threeX)
+	ySquare.Add(ySquare, params.B)
+	ySquare.Mod(ySquare, params.P)
+
+	y := new(big.Int).ModSqrt(ySquare, params.P)
+	if y == nil {
+		return nil, ErrPubKeyNotRecovered
+	}
+
+	if y.Bit(0) != uint(recoveryID&1) {
+		y.Sub(params.P, y)
+	}
+
+	if !curve.IsOnCurve(x, y) {
+		return nil, ErrPubKeyNotRecovered
+	}
+
+	rInv := new(big.Int).ModInverse(r, params.N)
+	e := bits2int(digest, params.N)
+
+	u1 := new(big.Int).Neg(e)
+	u1.Mul(u1, rInv)
+	u1.Mod(u1, params.N)
+	u2 := new(big.Int).Mul(s, rInv)
+	u2.Mod(u2, params.N)
+
+	x1, y1 := curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := curve.ScalarMult(x, y, u2.Bytes())
+	qx, qy := curve.Add(x1, y1, x2, y2)
+
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, ErrPubKeyNotRecovered
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: qx, Y: qy}, nil
+}
+
+// checkSignatureRange checks if both of r and s are in [1, n-1].
+func checkSignatureRange(curve elliptic.Curve, r, s *big.Int) error {
+	if r == nil {
+		return ErrInvalidSignature[1]
+	}
+
+	if s == nil {
+		return ErrInvalidSignature[2]
+	}
+
+	if r.Sign() != 1 {
+		return ErrInvalidSignature[3]
+	}
+
+	if s.Sign() != 1 {
+		return ErrInvalidSignature[4]
+	}
+
+	if r.Cmp(curve.Params().N) >= 0 || s.Cmp(curve.Params().N) >= 0 {
+		return ErrSignatureOutOfRange
+	}
+
+	return nil
+}
+
+// scalarSize returns byte length of scalar of the curve.
+func scalarSize(curve elliptic.Curve) int {
+	return (curve.Params().N.BitLen() + 7) / 8
+}
+
+type derSignatureCodec struct{}
+
+func (derSignatureCodec) Encode(curve elliptic.Curve, signature *Signature) ([]byte, error) {
+	if err := checkSignatureRange(curve, signature.R, signature.S); err != nil {
+		return nil, err
+	}
+
+	return MarshalSignature(signature.R, signature.S)
+}
+
+// Decode accepts signature with heimdall version prefix too, so signature made by Sign can be decoded directly.
+func (derSignatureCodec) Decode(curve elliptic.Curve, signature []byte) (*Signature, error) {
+	_, signature, err := heimdall.DecodeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	r, s, err := unmarshalECDSASignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkSignatureRange(curve, r, s); err != nil {
+		return nil, err
+	}
+
+	return &Signature{R: r, S: s}, nil
+}
+
+type rawSignatureCodec struct{}
+
+func (rawSignatureCodec) Encode(curve elliptic.Curve, signature *Signature) ([]byte, error) {
+	if err := checkSignatureRange(curve, signature.R, signature.S); err != nil {
+		return nil, err
+	}
+
+	size := scalarSize(curve)
+	raw := make([]byte, 2*size)
+	signature.R.FillBytes(raw[:size])
+	signature.S.FillBytes(raw[size:])
+
+	return raw, nil
+}
+
+func (rawSignatureCodec) Decode(curve elliptic.Curve, signature []byte) (*Signature, error) {
+	size := scalarSize(curve)
+	if len(signature) != 2*size {
+		return nil, ErrInvalidSignatureLength
+	}
+
+	r := new(big.Int).SetBytes(signature[:size])
+	s := new(big.Int).SetBytes(signature[size:])
+	if err := checkSignatureRange(curve, r, s); err != nil {
+		return nil, err
+	}
+
+	return &Signature{R: r, S: s}, nil
+}
+
+type compactSignatureCodec struct{}
+
+func (compactSignatureCodec) Encode(curve elliptic.Curve, signature *Signature) ([]byte, error) {
+	if signature.RecoveryID == nil {
+		return nil, ErrRecoveryIDRequired
+	}
+
+	if *signature.RecoveryID > 3 {
+		return nil, ErrInvalidRecoveryID
+	}
+
+	raw, err := rawSignatureCodec{}.Encode(curve, signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(raw, *signature.RecoveryID), nil
+}
+
+func (compactSignatureCodec) Decode(curve elliptic.Curve, signature []byte) (*Signature, error) {
+	if len(signature) != 2*scalarSize(curve)+1 {
+		return nil, ErrInvalidSignatureLength
+	}
+
+	recoveryID := signature[len(signature)-1]
+	if recoveryID > 3 {
+		return nil, ErrInvalidRecoveryID
+	}
+
+	decoded, err := rawSignatureCodec{}.Decode(curve, signature[:len(signature)-1])
+	if err != nil {
+		return nil, err
+	}
+	decoded.RecoveryID = &recoveryID
+
+	return decoded, nil
+}
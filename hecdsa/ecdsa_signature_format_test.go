@@ -0,0 +1,136 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"crypto/elliptic"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSignatureEncoder(t *testing.T) {
+	for _, format := range []hecdsa.SignatureFormat{hecdsa.SignatureFormatDER, hecdsa.SignatureFormatRaw, hecdsa.SignatureFormatCompact} {
+		// when
+		encoder, encoderErr := hecdsa.NewSignatureEncoder(format)
+		decoder, decoderErr := hecdsa.NewSignatureDecoder(format)
+
+		// then
+		assert.NoError(t, encoderErr)
+		assert.NotNil(t, encoder)
+		assert.NoError(t, decoderErr)
+		assert.NotNil(t, decoder)
+	}
+
+	// when
+	_, err := hecdsa.NewSignatureEncoder("PEM")
+
+	// then
+	assert.Equal(t, hecdsa.ErrUnknownSignatureFormat, err)
+}
+
+func TestConvertSignature(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signerOpt := hecdsa.NewSignerOpts(nil)
+	message := []byte("hello")
+	signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+	assert.NoError(t, err)
+
+	// when
+	raw, err := hecdsa.ConvertSignature(elliptic.P384(), signature, hecdsa.SignatureFormatDER, hecdsa.SignatureFormatRaw)
+	assert.NoError(t, err)
+	der, err := hecdsa.ConvertSignature(elliptic.P384(), raw, hecdsa.SignatureFormatRaw, hecdsa.SignatureFormatDER)
+	assert.NoError(t, err)
+	_, compactErr := hecdsa.ConvertSignature(elliptic.P384(), raw, hecdsa.SignatureFormatRaw, hecdsa.SignatureFormatCompact)
+
+	// then
+	assert.Len(t, raw, 96)
+	_, bare, err := heimdall.DecodeSignature(signature)
+	assert.NoError(t, err)
+	assert.Equal(t, bare, der)
+	assert.Equal(t, hecdsa.ErrRecoveryIDRequired, compactErr)
+
+	valid, err := hecdsa.Verify(pri.PublicKey(), der, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestToCompactSignature(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+	digest, err := hashing.Hash(message, hashOpt)
+	assert.NoError(t, err)
+
+	for i := 0; i < 8; i++ {
+		signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+		assert.NoError(t, err)
+
+		// when
+		compact, err := hecdsa.ToCompactSignature(pri.PublicKey(), digest, signature, hecdsa.SignatureFormatDER)
+		assert.NoError(t, err)
+		recovered, recoverErr := hecdsa.RecoverPubKey(elliptic.P256(), digest, compact)
+		der, convertErr := hecdsa.ConvertSignature(elliptic.P256(), compact, hecdsa.SignatureFormatCompact, hecdsa.SignatureFormatDER)
+
+		// then
+		assert.Len(t, compact, 65)
+		assert.NoError(t, recoverErr)
+		assert.Equal(t, pri.PublicKey().SKI(), recovered.SKI())
+		assert.NoError(t, convertErr)
+		valid, err := hecdsa.Verify(pri.PublicKey(), der, message, signerOpt)
+		assert.NoError(t, err)
+		assert.True(t, valid)
+	}
+}
+
+func TestSignatureDecoder_Invalid(t *testing.T) {
+	// given
+	rawDecoder, err := hecdsa.NewSignatureDecoder(hecdsa.SignatureFormatRaw)
+	assert.NoError(t, err)
+	compactDecoder, err := hecdsa.NewSignatureDecoder(hecdsa.SignatureFormatCompact)
+	assert.NoError(t, err)
+
+	outOfRange := make([]byte, 64)
+	for i := range outOfRange {
+		outOfRange[i] = 0xff
+	}
+	badRecoveryID := make([]byte, 65)
+	badRecoveryID[31], badRecoveryID[63], badRecoveryID[64] = 1, 1, 4
+
+	// when
+	_, lengthErr := rawDecoder.Decode(elliptic.P256(), make([]byte, 63))
+	_, zeroErr := rawDecoder.Decode(elliptic.P256(), make([]byte, 64))
+	_, rangeErr := rawDecoder.Decode(elliptic.P256(), outOfRange)
+	_, recoveryIDErr := compactDecoder.Decode(elliptic.P256(), badRecoveryID)
+
+	// then
+	assert.Equal(t, hecdsa.ErrInvalidSignatureLength, lengthErr)
+	assert.Equal(t, hecdsa.ErrInvalidSignature[3], zeroErr)
+	assert.Equal(t, hecdsa.ErrSignatureOutOfRange, rangeErr)
+	assert.Equal(t, hecdsa.ErrInvalidRecoveryID, recoveryIDErr)
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides ECDSA signer which holds private key in memory.
+
+package hecdsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+)
+
+var ErrKeyNotCryptoSigner = errors.New("invalid key - key should implement crypto.Signer")
+
+// Signer is an implementation of heimdall Signer using ECDSA private key in memory.
+// Unlike Sign, signing does not clear the key, so the owner of the key should call Clear() when it is no longer used.
+type Signer struct {
+	pri heimdall.PriKey
+}
+
+func NewSigner(pri heimdall.PriKey) heimdall.Signer {
+	return &Signer{pri: pri}
+}
+
+func (signer *Signer) KeyID() heimdall.KeyID {
+	return signer.pri.ID()
+}
+
+func (signer *Signer) Sign(message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	return sign(signer.pri, message, opts)
+}
+
+// SignReader signs data read from reader until EOF. Data is hashed incrementally,
+// so large data such as ledger snapshot can be signed without loading it in memory.
+func (signer *Signer) SignReader(reader io.Reader, opts heimdall.SignerOpts) ([]byte, error) {
+	return signReader(signer.pri, reader, opts)
+}
+
+// CryptoSigner is an implementation of heimdall Signer using private key which implements crypto.Signer,
+// such as key inside TPM or YubiKey. Signature is encoded in the same format with Sign, so it can be verified by Verify.
+type CryptoSigner struct {
+	pri    heimdall.PriKey
+	signer crypto.Signer
+}
+
+func NewCryptoSigner(pri heimdall.PriKey) (heimdall.Signer, error) {
+	signer, ok := pri.(crypto.Signer)
+	if !ok {
+		return nil, ErrKeyNotCryptoSigner
+	}
+
+	return &CryptoSigner{
+		pri:    pri,
+		signer: signer,
+	}, nil
+}
+
+func (signer *CryptoSigner) KeyID() heimdall.KeyID {
+	return signer.pri.ID()
+}
+
+// Sign signs with nonce of the key holder, so deterministic and Schnorr signatures are not supported.
+func (signer *CryptoSigner) Sign(message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	if isDeterministic(opts) {
+		return nil, ErrDeterministicNotSupported
+	}
+
+	if isSchnorr(opts) {
+		return nil, ErrSchnorrNotSupported
+	}
+
+	digest, err := digestOf(signer.pri, message, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := signer.signer.Sign(rand.Reader, digest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if isLowS(opts) {
+		signature, err = normalizeSignatureLowS(signer.signer.Public(), signature)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	event.Publish(event.MessageSigned, signer.pri.ID(), "")
+
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+}
@@ -17,13 +17,19 @@
 package hecdsa
 
 import (
+	"github.com/DE-labtory/heimdall"
 	"github.com/DE-labtory/heimdall/hashing"
 )
 
 type SignerOpts struct {
-	hashOpt *hashing.HashOpt
+	hashOpt       *hashing.HashOpt
+	version       *byte
+	deterministic bool
+	lowS          bool
+	schnorr       bool
 }
 
+// NewSignerOpts makes signer option with hash option. If hash option is nil, hash is selected by the key's curve. (ex. SHA384 for P-384)
 func NewSignerOpts(hashOpt *hashing.HashOpt) *SignerOpts {
 	return &SignerOpts{
 		hashOpt: hashOpt,
@@ -37,3 +43,52 @@ func (signerOpt *SignerOpts) Algorithm() string {
 func (signerOpt *SignerOpts) HashOpt() *hashing.HashOpt {
 	return signerOpt.hashOpt
 }
+
+// WithSignatureVersion sets format version of signatures made with the option. (ex. version negotiated with peer)
+func (signerOpt *SignerOpts) WithSignatureVersion(version byte) *SignerOpts {
+	signerOpt.version = &version
+	return signerOpt
+}
+
+// SignatureVersion returns version set by WithSignatureVersion, or heimdall.DefaultSignatureVersion if not set.
+func (signerOpt *SignerOpts) SignatureVersion() byte {
+	if signerOpt.version == nil {
+		return heimdall.DefaultSignatureVersion
+	}
+
+	return *signerOpt.version
+}
+
+// WithDeterministic selects deterministic signature of RFC 6979, whose nonce is derived from private key and digest
+// instead of random source, so that signing is safe on systems with weak entropy and signatures are reproducible.
+func (signerOpt *SignerOpts) WithDeterministic() *SignerOpts {
+	signerOpt.deterministic = true
+	return signerOpt
+}
+
+// Deterministic checks if deterministic signature is selected by WithDeterministic.
+func (signerOpt *SignerOpts) Deterministic() bool {
+	return signerOpt.deterministic
+}
+
+// WithLowS normalizes signatures made with the option to low-S form, whose S is not greater than half of curve order.
+func (signerOpt *SignerOpts) WithLowS() *SignerOpts {
+	signerOpt.lowS = true
+	return signerOpt
+}
+
+// LowS checks if low-S signature is selected by WithLowS.
+func (signerOpt *SignerOpts) LowS() bool {
+	return signerOpt.lowS
+}
+
+// WithSchnorr selects Schnorr signature on the curve of the key instead of ECDSA, so that signatures of several signers
+// can be aggregated into one by MuSigSession.
+func (signerOpt *SignerOpts) WithSchnorr() *SignerOpts {
+	signerOpt.schnorr = true
+	return signerOpt
+}
+
+func (signerOpt *SignerOpts) SchemeParams() *heimdall.SchemeParams {
+	return &heimdall.SchemeParams{Schnorr: signerOpt.schnorr}
+}
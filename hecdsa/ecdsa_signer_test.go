@@ -0,0 +1,126 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSigner_KeyID(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer := hecdsa.NewSigner(pri)
+
+	// when
+	keyId := signer.KeyID()
+
+	// then
+	assert.Equal(t, pri.ID(), keyId)
+}
+
+func TestSigner_Sign(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer := hecdsa.NewSigner(pri)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+
+	// when
+	signature, err := signer.Sign(message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestSigner_SignReader(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer := hecdsa.NewSigner(pri).(*hecdsa.Signer)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := bytes.Repeat([]byte("ledger block "), 100000)
+
+	// when
+	signature, err := signer.SignReader(bytes.NewReader(message), signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestSigner_Sign_KeepsKey(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer := hecdsa.NewSigner(pri)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+
+	// when
+	_, err = signer.Sign(message, signerOpt)
+	assert.NoError(t, err)
+	signature, err := signer.Sign(message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestCryptoSigner_Sign(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer, err := hecdsa.NewCryptoSigner(pri)
+	assert.NoError(t, err)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+
+	// when
+	signature, err := signer.Sign(message, signerOpt)
+	_, notSignerErr := hecdsa.NewCryptoSigner(mocks.NewPriKey([]byte("ski")))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), signer.KeyID())
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, hecdsa.ErrKeyNotCryptoSigner, notSignerErr)
+}
@@ -0,0 +1,188 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides storage backends of keystore on file system and memory.
+
+package hecdsa
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+	"github.com/DE-labtory/iLogger"
+)
+
+var ErrInvalidStorageName = errors.New("invalid storage name - name should not be empty or contain path separator")
+
+// FileStorage is a storage on directory, which stores each key file named by its name with owner only permission.
+// Directory which does not exist is regarded as empty storage, and is made at the first Put.
+type FileStorage struct {
+	dirPath string
+}
+
+func NewFileStorage(dirPath string) *FileStorage {
+	return &FileStorage{dirPath: dirPath}
+}
+
+// Put writes data to file of name. Data is written and synced to temporary file next to the directory and renamed,
+// so existing file is never lost or truncated by crash in the middle of writing, and a file left by crash is not taken as a key file.
+func (storage *FileStorage) Put(name string, data []byte) error {
+	if err := checkStorageName(name); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(storage.dirPath); os.IsNotExist(err) {
+		if err := fileperm.MkdirAll(storage.dirPath); err != nil {
+			iLogger.Errorf(nil, "[Heimdall] %s", err)
+			return err
+		}
+	}
+	fileperm.WarnInsecure(storage.dirPath)
+
+	tempPath := filepath.Clean(storage.dirPath) + "." + name + ".tmp"
+
+	return fileperm.WriteFileAtomic(storage.path(name), tempPath, data)
+}
+
+func (storage *FileStorage) Get(name string) ([]byte, error) {
+	if err := checkStorageName(name); err != nil {
+		return nil, err
+	}
+	fileperm.WarnInsecure(storage.dirPath)
+
+	data, err := ioutil.ReadFile(storage.path(name))
+	if os.IsNotExist(err) {
+		return nil, heimdall.ErrKeyNotFound
+	}
+
+	return data, err
+}
+
+func (storage *FileStorage) List() ([]string, error) {
+	files, err := ioutil.ReadDir(storage.dirPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	fileperm.WarnInsecure(storage.dirPath)
+
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		names = append(names, file.Name())
+	}
+
+	return names, nil
+}
+
+func (storage *FileStorage) Delete(name string) error {
+	if err := checkStorageName(name); err != nil {
+		return err
+	}
+
+	if err := os.Remove(storage.path(name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// path returns path of the file of name in the directory.
+func (storage *FileStorage) path(name string) string {
+	return filepath.Join(storage.dirPath, name)
+}
+
+// MemoryStorage is a storage in memory, for tests and short-lived keys which should never touch disk.
+type MemoryStorage struct {
+	mutex sync.RWMutex
+	data  map[string][]byte
+}
+
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{data: make(map[string][]byte)}
+}
+
+func (storage *MemoryStorage) Put(name string, data []byte) error {
+	if err := checkStorageName(name); err != nil {
+		return err
+	}
+
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	storage.data[name] = append([]byte{}, data...)
+
+	return nil
+}
+
+func (storage *MemoryStorage) Get(name string) ([]byte, error) {
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	data, ok := storage.data[name]
+	if !ok {
+		return nil, heimdall.ErrKeyNotFound
+	}
+
+	return append([]byte{}, data...), nil
+}
+
+func (storage *MemoryStorage) List() ([]string, error) {
+	storage.mutex.RLock()
+	defer storage.mutex.RUnlock()
+
+	names := make([]string, 0, len(storage.data))
+	for name := range storage.data {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+func (storage *MemoryStorage) Delete(name string) error {
+	storage.mutex.Lock()
+	defer storage.mutex.Unlock()
+
+	delete(storage.data, name)
+
+	return nil
+}
+
+// checkStorageName checks if name can be a file name in storage directory.
+func checkStorageName(name string) error {
+	if name == "" || name == "." || name == ".." || filepath.Base(name) != name {
+		return ErrInvalidStorageName
+	}
+
+	return nil
+}
+
+// storageLocation returns location of data of name in storage for events, which is file path for file storage.
+func storageLocation(storage heimdall.Storage, name string) string {
+	if fileStorage, ok := storage.(*FileStorage); ok {
+		return fileStorage.path(name)
+	}
+
+	return name
+}
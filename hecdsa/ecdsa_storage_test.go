@@ -0,0 +1,180 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage(t *testing.T) {
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	for testName, storage := range map[string]heimdall.Storage{
+		"file":   hecdsa.NewFileStorage(heimdall.TestKeyDir),
+		"memory": hecdsa.NewMemoryStorage(),
+	} {
+		t.Logf("running test case [%s]", testName)
+
+		// given
+		names, err := storage.List()
+		assert.NoError(t, err)
+		assert.Empty(t, names)
+
+		// when
+		assert.NoError(t, storage.Put("a", []byte("first")))
+		assert.NoError(t, storage.Put("b", []byte("second")))
+		assert.NoError(t, storage.Put("a", []byte("replaced")))
+
+		// then
+		data, err := storage.Get("a")
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("replaced"), data)
+		names, err = storage.List()
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a", "b"}, names)
+
+		// when
+		assert.NoError(t, storage.Delete("a"))
+		assert.NoError(t, storage.Delete("a"))
+
+		// then
+		_, err = storage.Get("a")
+		assert.Equal(t, heimdall.ErrKeyNotFound, err)
+		names, err = storage.List()
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"b"}, names)
+	}
+}
+
+func TestFileStorage_InvalidName(t *testing.T) {
+	// given
+	storage := hecdsa.NewFileStorage(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	for _, name := range []string{"", ".", "..", "../key", "dir/key"} {
+		t.Logf("running test case [%s]", name)
+
+		// when
+		putErr := storage.Put(name, []byte("data"))
+		_, getErr := storage.Get(name)
+
+		// then
+		assert.Equal(t, hecdsa.ErrInvalidStorageName, putErr)
+		assert.Equal(t, hecdsa.ErrInvalidStorageName, getErr)
+	}
+}
+
+func TestNewKeyStoreWithStorage(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	pri := setUpPriKey(t)
+	priStorage := hecdsa.NewMemoryStorage()
+	keyStore := hecdsa.NewKeyStoreWithStorage(priStorage, hecdsa.NewMemoryStorage(), encOpt, kdfOpt)
+
+	// when
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+	loadedPri, priErr := keyStore.LoadPriKey(pri.ID(), "password")
+	loadedPub, pubErr := keyStore.LoadPubKey(pri.ID())
+	_, wrongPwdErr := keyStore.LoadPriKey(pri.ID(), "wrong")
+
+	// then
+	assert.NoError(t, priErr)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri.ID(), loadedPub.ID())
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, wrongPwdErr)
+
+	names, err := priStorage.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{pri.ID()}, names)
+	_, err = os.Stat(heimdall.TestPriKeyDir)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestNewKeyStoreWithStorage_ReplacePriKey(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	oldPri := setUpPriKey(t)
+	newPri := setUpPriKey(t)
+	priStorage := hecdsa.NewMemoryStorage()
+	keyStore := hecdsa.NewKeyStoreWithStorage(priStorage, hecdsa.NewMemoryStorage(), encOpt, kdfOpt)
+	assert.NoError(t, keyStore.StorePriKey(oldPri, "password"))
+
+	// when
+	err = keyStore.StorePriKey(newPri, "password")
+
+	// then
+	assert.NoError(t, err)
+	names, err := priStorage.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{newPri.ID()}, names)
+	_, err = keyStore.LoadPriKey(oldPri.ID(), "password")
+	assert.Equal(t, hecdsa.ErrWrongKeyID, err)
+}
+
+// failingPutStorage fails every put after the first, as storage which is full or unreachable.
+type failingPutStorage struct {
+	*hecdsa.MemoryStorage
+	puts int
+}
+
+func (storage *failingPutStorage) Put(name string, data []byte) error {
+	storage.puts++
+	if storage.puts > 1 {
+		return errors.New("put failed")
+	}
+
+	return storage.MemoryStorage.Put(name, data)
+}
+
+func TestNewKeyStoreWithStorage_ReplacePriKeyFailure(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts("AES", encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	oldPri := setUpPriKey(t)
+	newPri := setUpPriKey(t)
+	priStorage := &failingPutStorage{MemoryStorage: hecdsa.NewMemoryStorage()}
+	keyStore := hecdsa.NewKeyStoreWithStorage(priStorage, hecdsa.NewMemoryStorage(), encOpt, kdfOpt)
+	assert.NoError(t, keyStore.StorePriKey(oldPri, "password"))
+
+	// when
+	err = keyStore.StorePriKey(newPri, "password")
+
+	// then
+	assert.Error(t, err)
+	loadedPri, err := keyStore.LoadPriKey(oldPri.ID(), "password")
+	assert.NoError(t, err)
+	assert.Equal(t, oldPri.ID(), loadedPri.ID())
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hecdsa_test
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifier_Verify(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+
+	signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hecdsa.NewVerifier().Verify(pri.PublicKey(), signature, message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestVerifier_VerifyReader(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := bytes.Repeat([]byte("ledger block "), 100000)
+
+	signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+	assert.NoError(t, err)
+	verifier := hecdsa.NewVerifier().(*hecdsa.Verifier)
+
+	// when
+	valid, err := verifier.VerifyReader(pri.PublicKey(), signature, bytes.NewReader(message), signerOpt)
+	tampered, tamperedErr := verifier.VerifyReader(pri.PublicKey(), signature, bytes.NewReader(message[1:]), signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.NoError(t, tamperedErr)
+	assert.False(t, tampered)
+}
+
+func TestVerifier_VerifyWithCert(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	message := []byte("hello")
+	signerOpt := hecdsa.NewSignerOpts(nil)
+
+	template := mocks.TestCertTemplate
+	template.SubjectKeyId = pri.SKI()
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, pri.Public(), pri)
+	assert.NoError(t, err)
+	peerCert, err := x509.ParseCertificate(derBytes)
+	assert.NoError(t, err)
+
+	signature, err := hecdsa.NewSigner(pri).Sign(message, signerOpt)
+	assert.NoError(t, err)
+
+	errUntrusted := errors.New("untrusted")
+	trusting := hecdsa.NewVerifierWithCertVerifier(&mocks.CertVerifier{})
+	untrusting := hecdsa.NewVerifierWithCertVerifier(&mocks.CertVerifier{
+		VerifyChainFunc: func(*x509.Certificate) error { return errUntrusted },
+	})
+	revoking := hecdsa.NewVerifierWithCertVerifier(&mocks.CertVerifier{
+		VerifyFunc: func(*x509.Certificate) error { return cert.ErrCertRevoked },
+	})
+
+	// when
+	valid, err := trusting.VerifyWithCert(peerCert, signature, message, signerOpt)
+	_, untrustedErr := untrusting.VerifyWithCert(peerCert, signature, message, signerOpt)
+	_, revokedErr := revoking.VerifyWithCert(peerCert, signature, message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, errUntrusted, untrustedErr)
+	assert.Equal(t, cert.ErrCertRevoked, revokedErr)
+}
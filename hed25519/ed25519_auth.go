@@ -0,0 +1,151 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Ed25519 signing and verifying related functions.
+
+package hed25519
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/x509"
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hashing"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature - Ed25519 signature should be 64 bytes, optionally prefixed by version byte")
+
+// Sign generates signature for a data using private key.
+func Sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	// remove private key from memory.
+	defer pri.Clear()
+
+	return sign(pri, message, opts)
+}
+
+// sign generates signature without clearing private key, for signers holding the key for several signatures.
+// Legacy signature of Ed25519 is the bare 64 bytes signature, since Ed25519 signatures have never been ASN.1 encoded.
+func sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	ed25519Pri, ok := pri.(*PriKey)
+	if !ok {
+		return nil, ErrNotEd25519PriKey
+	}
+
+	if err := heimdall.CheckKeyUsage(pri, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	message, signerOpts, err := messageOf(pri, message, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := ed25519Pri.internalPriKey.Sign(nil, message, signerOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	event.Publish(event.MessageSigned, pri.ID(), "")
+
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+}
+
+// messageOf returns message to be signed and options of crypto/ed25519 for the scheme of signer option.
+// For Ed25519ph, the message is SHA-512 digest of original message. Key and hash algorithms denied by algorithm policy are rejected.
+func messageOf(key heimdall.Key, message []byte, opts heimdall.SignerOpts) ([]byte, *ed25519.Options, error) {
+	params := heimdall.SchemeParamsOf(opts)
+	if err := params.Validate(key.KeyGenOpt()); err != nil {
+		return nil, nil, err
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(key.KeyGenOpt()); err != nil {
+		return nil, nil, err
+	}
+
+	if !params.Ed25519ph {
+		return message, &ed25519.Options{}, nil
+	}
+
+	if err := heimdall.CheckHashAlgorithm(hashing.SHA512); err != nil {
+		return nil, nil, err
+	}
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA512)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	digest, err := hashing.Hash(message, hashOpt)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return digest, &ed25519.Options{Hash: crypto.SHA512}, nil
+}
+
+// decodeSignature returns bare signature of legacy (64 bytes) or versioned (version byte and 64 bytes) signature.
+func decodeSignature(signature []byte) ([]byte, error) {
+	if len(signature) == ed25519.SignatureSize {
+		return signature, nil
+	}
+
+	if len(signature) != ed25519.SignatureSize+1 {
+		return nil, ErrInvalidSignature
+	}
+
+	_, signature, err := heimdall.DecodeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// Verify verifies the signature using pubKey(public key) and original message, then returns boolean value.
+// Signature of any supported version is accepted regardless of version in signer option.
+func Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	ed25519Pub, ok := pub.(*PubKey)
+	if !ok {
+		return false, ErrNotEd25519PubKey
+	}
+
+	message, signerOpts, err := messageOf(pub, message, opts)
+	if err != nil {
+		return false, err
+	}
+
+	signature, err = decodeSignature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	valid := ed25519.VerifyWithOptions(ed25519Pub.internalPubKey, message, signature, signerOpts) == nil
+	return valid, nil
+}
+
+// VerifyWithCert verify a signature with certificate.
+func VerifyWithCert(cert *x509.Certificate, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	ed25519PubKey, ok := cert.PublicKey.(ed25519.PublicKey)
+	if !ok {
+		return false, ErrNotEd25519PubKey
+	}
+
+	return Verify(NewPubKey(ed25519PubKey), signature, message, opts)
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hed25519_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	pub := pri.PublicKey()
+	message := []byte("hello")
+
+	// when
+	signature, err := hed25519.Sign(pri, message, hed25519.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, signature, ed25519.SignatureSize)
+	valid, err := hed25519.Verify(pub, signature, message, hed25519.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestSign_PreHash(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer := hed25519.NewSigner(pri)
+	message := []byte("hello")
+
+	// when
+	signature, err := signer.Sign(message, hed25519.NewSignerOpts().WithPreHash())
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hed25519.Verify(pri.PublicKey(), signature, message, hed25519.NewSignerOpts().WithPreHash())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// pure and pre-hashed signatures are not interchangeable
+	valid, err = hed25519.Verify(pri.PublicKey(), signature, message, hed25519.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerify_SignatureVersion(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer := hed25519.NewSigner(pri)
+	message := []byte("hello")
+
+	signature, err := signer.Sign(message, hed25519.NewSignerOpts().WithSignatureVersion(heimdall.SignatureVersion1))
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hed25519.NewVerifier().Verify(pri.PublicKey(), signature, message, hed25519.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, heimdall.SignatureVersion1, signature[0])
+	assert.Len(t, signature, ed25519.SignatureSize+1)
+}
+
+func TestVerify_WrongMessage(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signature, err := hed25519.NewSigner(pri).Sign([]byte("hello"), hed25519.NewSignerOpts())
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hed25519.Verify(pri.PublicKey(), signature, []byte("world"), hed25519.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerify_InvalidSignature(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+
+	// when
+	_, err := hed25519.Verify(pri.PublicKey(), []byte("short"), []byte("hello"), hed25519.NewSignerOpts())
+
+	// then
+	assert.Equal(t, hed25519.ErrInvalidSignature, err)
+}
+
+func TestVerifyWithCert(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "ed25519"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, pri.(*hed25519.PriKey).Public(), pri.(*hed25519.PriKey))
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	message := []byte("hello")
+	signature, err := hed25519.NewSigner(pri).Sign(message, hed25519.NewSignerOpts())
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hed25519.VerifyWithCert(cert, signature, message, hed25519.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Ed25519 key related functions.
+
+package hed25519
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"io"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+)
+
+var ErrKeyType = errors.New("invalid key type - key type should be heimdall.PRIVATEKEY or heimdall.PUBLICKEY")
+var ErrNotEd25519PriKey = errors.New("invalid private key - key is not Ed25519 private key")
+var ErrNotEd25519PubKey = errors.New("invalid public key - key is not Ed25519 public key")
+
+func GenerateKey(keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	opt, err := ToKeyGenOpt(keyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(opt); err != nil {
+		return nil, err
+	}
+
+	_, pri, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &PriKey{internalPriKey: pri}
+	event.Publish(event.KeyCreated, key.ID(), "")
+
+	return key, nil
+}
+
+// PriKey is an implementation of heimdall PriKey for using Ed25519 private key
+type PriKey struct {
+	heimdall.KeyUsage
+	internalPriKey ed25519.PrivateKey
+}
+
+func NewPriKey(internalPriKey ed25519.PrivateKey) heimdall.PriKey {
+	return &PriKey{internalPriKey: internalPriKey}
+}
+
+func (priKey *PriKey) ID() heimdall.KeyID {
+	return priKey.PublicKey().ID()
+}
+
+func (priKey *PriKey) SKI() []byte {
+	return priKey.PublicKey().SKI()
+}
+
+func (priKey *PriKey) ToByte() ([]byte, error) {
+	return x509.MarshalPKCS8PrivateKey(priKey.internalPriKey)
+}
+
+func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return &KeyGenOpt{}
+}
+
+func (priKey *PriKey) IsPrivate() bool {
+	return true
+}
+
+func (priKey *PriKey) PublicKey() heimdall.PubKey {
+	return &PubKey{KeyUsage: priKey.KeyUsage, internalPubKey: priKey.internalPriKey.Public().(ed25519.PublicKey)}
+}
+
+func (priKey *PriKey) Clear() {
+	// clear seed and public key of private key to 0
+	for i := range priKey.internalPriKey {
+		priKey.internalPriKey[i] = 0
+	}
+}
+
+// Public implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Public() crypto.PublicKey {
+	return priKey.internalPriKey.Public()
+}
+
+// Sign implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if err := heimdall.CheckKeyUsage(priKey, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	return priKey.internalPriKey.Sign(rand, message, opts)
+}
+
+// PubKey is an implementation of heimdall PubKey for using Ed25519 public key
+type PubKey struct {
+	heimdall.KeyUsage
+	internalPubKey ed25519.PublicKey
+}
+
+func NewPubKey(internalPubKey ed25519.PublicKey) heimdall.PubKey {
+	return &PubKey{internalPubKey: internalPubKey}
+}
+
+func (pubKey *PubKey) ID() heimdall.KeyID {
+	// return base58 encoded ski with algorithm prefix (ex. ED25519x...)
+	keyId, err := heimdall.MakeKeyID(&heimdall.KeyType{Family: heimdall.ED25519, BitLen: 256}, pubKey.SKI())
+	if err != nil {
+		return heimdall.SKIToKeyID(pubKey.SKI())
+	}
+
+	return keyId
+}
+
+func (pubKey *PubKey) SKI() []byte {
+	return heimdall.MakeSKI(pubKey.SKIMaterial())
+}
+
+// SKIMaterial returns public key bytes, which SKI is hashed from.
+func (pubKey *PubKey) SKIMaterial() []byte {
+	return pubKey.internalPubKey
+}
+
+func (pubKey *PubKey) ToByte() ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(pubKey.internalPubKey)
+}
+
+func (pubKey *PubKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return &KeyGenOpt{}
+}
+
+func (pubKey *PubKey) IsPrivate() bool {
+	return false
+}
+
+// init registers recoverer of ED25519 keys, so that key files of them are loaded without choosing a recoverer.
+func init() {
+	heimdall.RegisterKeyRecoverer(heimdall.ED25519, &KeyRecoverer{})
+}
+
+type KeyRecoverer struct {
+}
+
+func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	switch isPrivate {
+	case true:
+		internalPriKey, err := x509.ParsePKCS8PrivateKey(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		ed25519PriKey, ok := internalPriKey.(ed25519.PrivateKey)
+		if !ok {
+			return nil, ErrNotEd25519PriKey
+		}
+
+		pri := NewPriKey(ed25519PriKey)
+
+		return pri, nil
+
+	case false:
+		internalPubKey, err := x509.ParsePKIXPublicKey(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		ed25519PubKey, ok := internalPubKey.(ed25519.PublicKey)
+		if !ok {
+			return nil, ErrNotEd25519PubKey
+		}
+
+		pub := NewPubKey(ed25519PubKey)
+
+		return pub, nil
+
+	default:
+		return nil, ErrKeyType
+	}
+}
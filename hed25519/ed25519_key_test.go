@@ -0,0 +1,118 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hed25519_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"strings"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpPriKey(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func TestGenerateKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+
+	// when
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.NotNil(t, pri)
+	assert.True(t, pri.IsPrivate())
+	assert.False(t, pri.PublicKey().IsPrivate())
+}
+
+func TestPriKey_ID(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+
+	// when
+	keyId := pri.ID()
+
+	// then
+	assert.True(t, strings.HasPrefix(keyId, "ED25519x"))
+	assert.Equal(t, pri.PublicKey().ID(), keyId)
+	assert.Len(t, pri.SKI(), 20)
+
+	info, err := heimdall.ParseKeyID(keyId)
+	assert.NoError(t, err)
+	assert.Equal(t, heimdall.ED25519, info.KeyType.Family)
+	assert.Equal(t, pri.SKI(), info.SKI)
+}
+
+func TestPriKey_Clear(t *testing.T) {
+	// given
+	_, internalPri, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	pri := hed25519.NewPriKey(internalPri)
+
+	// when
+	pri.Clear()
+
+	// then
+	assert.Equal(t, make(ed25519.PrivateKey, ed25519.PrivateKeySize), internalPri)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	recoverer := &hed25519.KeyRecoverer{}
+
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+
+	// when
+	recoveredPri, priErr := recoverer.RecoverKeyFromByte(priBytes, true)
+	recoveredPub, pubErr := recoverer.RecoverKeyFromByte(pubBytes, false)
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri, recoveredPri)
+	assert.Equal(t, pri.PublicKey(), recoveredPub)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte_WrongBytes(t *testing.T) {
+	// given
+	recoverer := &hed25519.KeyRecoverer{}
+
+	// when
+	_, priErr := recoverer.RecoverKeyFromByte([]byte("wrong"), true)
+	_, pubErr := recoverer.RecoverKeyFromByte([]byte("wrong"), false)
+
+	// then
+	assert.Error(t, priErr)
+	assert.Error(t, pubErr)
+}
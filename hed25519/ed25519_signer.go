@@ -0,0 +1,42 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Ed25519 signer which holds private key in memory.
+
+package hed25519
+
+import (
+	"github.com/DE-labtory/heimdall"
+)
+
+// Signer is an implementation of heimdall Signer using Ed25519 private key in memory.
+// Unlike Sign, signing does not clear the key, so the owner of the key should call Clear() when it is no longer used.
+type Signer struct {
+	pri heimdall.PriKey
+}
+
+func NewSigner(pri heimdall.PriKey) heimdall.Signer {
+	return &Signer{pri: pri}
+}
+
+func (signer *Signer) KeyID() heimdall.KeyID {
+	return signer.pri.ID()
+}
+
+func (signer *Signer) Sign(message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	return sign(signer.pri, message, opts)
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Ed25519 verifier implementing heimdall Verifier.
+
+package hed25519
+
+import (
+	"crypto/x509"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+// Verifier is an implementation of heimdall Verifier for Ed25519 signatures.
+// If certificate verifier is set, VerifyWithCert validates certificate against its trust anchors before checking signature.
+type Verifier struct {
+	certVerifier heimdall.CertVerifier
+}
+
+func NewVerifier() heimdall.Verifier {
+	return &Verifier{}
+}
+
+// NewVerifierWithCertVerifier makes verifier which validates certificate chain, validity period and revocation
+// by certVerifier, so that signed message from peer can be verified in one call.
+func NewVerifierWithCertVerifier(certVerifier heimdall.CertVerifier) *Verifier {
+	return &Verifier{certVerifier: certVerifier}
+}
+
+func (verifier *Verifier) Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	return Verify(pub, signature, message, opts)
+}
+
+// VerifyWithCert verifies signature with public key of the certificate, after validating the certificate if certificate verifier is set.
+func (verifier *Verifier) VerifyWithCert(cert *x509.Certificate, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	if verifier.certVerifier != nil {
+		if err := verifier.certVerifier.VerifyChain(cert); err != nil {
+			return false, err
+		}
+
+		if err := verifier.certVerifier.Verify(cert); err != nil {
+			return false, err
+		}
+	}
+
+	return VerifyWithCert(cert, signature, message, opts)
+}
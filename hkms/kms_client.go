@@ -0,0 +1,100 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides key management service interface which remote keys are created and used through.
+
+package hkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+)
+
+var ErrCurveNotSupported = errors.New("curve not supported - KMS key should be on P-256, P-384 or P-521")
+var ErrDigestSizeNotSupported = errors.New("digest size not supported - digest should be 32, 48 or 64 bytes")
+
+// Client is a key management service which creates non-exportable signing keys and signs with them,
+// so private keys never leave the service. Keys are named by the service (ex. key ARN of AWS KMS).
+// It is implemented for AWS KMS, GCP Cloud KMS and Azure Key Vault (see NewAWSClient, NewGCPClient and NewAzureClient),
+// and can be implemented by other services, or a fake service for testing.
+type Client interface {
+	// CreateKey creates ECDSA key on curve inside KMS, and returns name and public key of the key.
+	CreateKey(curve elliptic.Curve) (keyName string, pub *ecdsa.PublicKey, err error)
+
+	// PublicKey returns public key of the key of key name.
+	PublicKey(keyName string) (*ecdsa.PublicKey, error)
+
+	// Sign signs digest with key of key name inside KMS, and returns ASN.1 DER encoded signature.
+	Sign(keyName string, digest []byte) ([]byte, error)
+}
+
+// ContextClient is a Client whose remote calls can be cancelled and time-bounded by context.
+// It is implemented by AWSClient, GCPClient and AzureClient. For other clients, context is only checked before
+// calling the service.
+type ContextClient interface {
+	Client
+
+	// CreateKeyWithContext is CreateKey bounded by context.
+	CreateKeyWithContext(ctx context.Context, curve elliptic.Curve) (keyName string, pub *ecdsa.PublicKey, err error)
+
+	// PublicKeyWithContext is PublicKey bounded by context.
+	PublicKeyWithContext(ctx context.Context, keyName string) (*ecdsa.PublicKey, error)
+
+	// SignWithContext is Sign bounded by context.
+	SignWithContext(ctx context.Context, keyName string, digest []byte) ([]byte, error)
+}
+
+// createKey creates key by client with context if client supports it, otherwise after checking context is not done.
+func createKey(ctx context.Context, client Client, curve elliptic.Curve) (string, *ecdsa.PublicKey, error) {
+	if ctxClient, ok := client.(ContextClient); ok {
+		return ctxClient.CreateKeyWithContext(ctx, curve)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return "", nil, err
+	}
+
+	return client.CreateKey(curve)
+}
+
+// publicKey fetches public key by client with context if client supports it, otherwise after checking context is not done.
+func publicKey(ctx context.Context, client Client, keyName string) (*ecdsa.PublicKey, error) {
+	if ctxClient, ok := client.(ContextClient); ok {
+		return ctxClient.PublicKeyWithContext(ctx, keyName)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return client.PublicKey(keyName)
+}
+
+// sign signs by client with context if client supports it, otherwise after checking context is not done.
+func sign(ctx context.Context, client Client, keyName string, digest []byte) ([]byte, error) {
+	if ctxClient, ok := client.(ContextClient); ok {
+		return ctxClient.SignWithContext(ctx, keyName, digest)
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return client.Sign(keyName, digest)
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides key management service client of AWS KMS.
+
+package hkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+)
+
+// description of keys created by heimdall, which is shown in AWS console
+const awsKeyDescription = "heimdall signing key"
+
+var awsKeySpecs = map[elliptic.Curve]types.KeySpec{
+	elliptic.P256(): types.KeySpecEccNistP256,
+	elliptic.P384(): types.KeySpecEccNistP384,
+	elliptic.P521(): types.KeySpecEccNistP521,
+}
+
+var awsSigningAlgorithms = map[int]types.SigningAlgorithmSpec{
+	32: types.SigningAlgorithmSpecEcdsaSha256,
+	48: types.SigningAlgorithmSpecEcdsaSha384,
+	64: types.SigningAlgorithmSpecEcdsaSha512,
+}
+
+// AWSKMSAPI is operations of AWS KMS which AWSClient uses, and is implemented by *kms.Client of AWS SDK.
+type AWSKMSAPI interface {
+	CreateKey(ctx context.Context, params *kms.CreateKeyInput, optFns ...func(*kms.Options)) (*kms.CreateKeyOutput, error)
+	GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error)
+	Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error)
+}
+
+// AWSClient is a Client of AWS KMS, whose keys are named by key ARN.
+// Credentials, region and timeouts are configured on the AWS SDK client.
+type AWSClient struct {
+	api AWSKMSAPI
+}
+
+// NewAWSClient makes Client of AWS KMS with AWS SDK client. (ex. kms.NewFromConfig(cfg))
+func NewAWSClient(api AWSKMSAPI) *AWSClient {
+	return &AWSClient{api: api}
+}
+
+func (client *AWSClient) CreateKey(curve elliptic.Curve) (string, *ecdsa.PublicKey, error) {
+	return client.CreateKeyWithContext(context.Background(), curve)
+}
+
+func (client *AWSClient) CreateKeyWithContext(ctx context.Context, curve elliptic.Curve) (string, *ecdsa.PublicKey, error) {
+	keySpec, ok := awsKeySpecs[curve]
+	if !ok {
+		return "", nil, ErrCurveNotSupported
+	}
+
+	output, err := client.api.CreateKey(ctx, &kms.CreateKeyInput{
+		Description: aws.String(awsKeyDescription),
+		KeySpec:     keySpec,
+		KeyUsage:    types.KeyUsageTypeSignVerify,
+	})
+	if err != nil {
+		return "", nil, err
+	}
+
+	keyArn := aws.ToString(output.KeyMetadata.Arn)
+	pub, err := client.PublicKeyWithContext(ctx, keyArn)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return keyArn, pub, nil
+}
+
+func (client *AWSClient) PublicKey(keyArn string) (*ecdsa.PublicKey, error) {
+	return client.PublicKeyWithContext(context.Background(), keyArn)
+}
+
+func (client *AWSClient) PublicKeyWithContext(ctx context.Context, keyArn string) (*ecdsa.PublicKey, error) {
+	output, err := client.api.GetPublicKey(ctx, &kms.GetPublicKeyInput{
+		KeyId: aws.String(keyArn),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(output.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrKeyGenOptNotSupported
+	}
+
+	if _, ok := awsKeySpecs[ecdsaPub.Curve]; !ok {
+		return nil, ErrCurveNotSupported
+	}
+
+	return ecdsaPub, nil
+}
+
+// Sign signs digest by signing algorithm of the digest size, which should match curve of the key in AWS KMS.
+// (ex. SHA-256 size digest for P-256 key)
+func (client *AWSClient) Sign(keyArn string, digest []byte) ([]byte, error) {
+	return client.SignWithContext(context.Background(), keyArn, digest)
+}
+
+func (client *AWSClient) SignWithContext(ctx context.Context, keyArn string, digest []byte) ([]byte, error) {
+	algorithm, ok := awsSigningAlgorithms[len(digest)]
+	if !ok {
+		return nil, ErrDigestSizeNotSupported
+	}
+
+	output, err := client.api.Sign(ctx, &kms.SignInput{
+		KeyId:            aws.String(keyArn),
+		Message:          digest,
+		MessageType:      types.MessageTypeDigest,
+		SigningAlgorithm: algorithm,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return output.Signature, nil
+}
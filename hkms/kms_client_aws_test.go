@@ -0,0 +1,165 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hkms_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hkms"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/kms"
+	"github.com/aws/aws-sdk-go-v2/service/kms/types"
+	"github.com/stretchr/testify/assert"
+)
+
+var fakeAWSCurves = map[types.KeySpec]elliptic.Curve{
+	types.KeySpecEccNistP256: elliptic.P256(),
+	types.KeySpecEccNistP384: elliptic.P384(),
+	types.KeySpecEccNistP521: elliptic.P521(),
+}
+
+// fakeAWSKMS is a fake AWS KMS which keeps keys in memory for testing.
+type fakeAWSKMS struct {
+	keys              map[string]*ecdsa.PrivateKey
+	signingAlgorithms []types.SigningAlgorithmSpec
+}
+
+func newFakeAWSKMS() *fakeAWSKMS {
+	return &fakeAWSKMS{keys: make(map[string]*ecdsa.PrivateKey)}
+}
+
+func (fake *fakeAWSKMS) CreateKey(ctx context.Context, params *kms.CreateKeyInput, optFns ...func(*kms.Options)) (*kms.CreateKeyOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	curve, ok := fakeAWSCurves[params.KeySpec]
+	if !ok || params.KeyUsage != types.KeyUsageTypeSignVerify {
+		return nil, errors.New("unsupported key spec")
+	}
+
+	pri, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	arn := fmt.Sprintf("arn:aws:kms:ap-northeast-2:111122223333:key/%d", len(fake.keys))
+	fake.keys[arn] = pri
+
+	return &kms.CreateKeyOutput{KeyMetadata: &types.KeyMetadata{Arn: aws.String(arn)}}, nil
+}
+
+func (fake *fakeAWSKMS) GetPublicKey(ctx context.Context, params *kms.GetPublicKeyInput, optFns ...func(*kms.Options)) (*kms.GetPublicKeyOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pri, ok := fake.keys[aws.ToString(params.KeyId)]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&pri.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kms.GetPublicKeyOutput{PublicKey: pubBytes}, nil
+}
+
+func (fake *fakeAWSKMS) Sign(ctx context.Context, params *kms.SignInput, optFns ...func(*kms.Options)) (*kms.SignOutput, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pri, ok := fake.keys[aws.ToString(params.KeyId)]
+	if !ok || params.MessageType != types.MessageTypeDigest {
+		return nil, errors.New("invalid sign request")
+	}
+	fake.signingAlgorithms = append(fake.signingAlgorithms, params.SigningAlgorithm)
+
+	signature, err := ecdsa.SignASN1(rand.Reader, pri, params.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	return &kms.SignOutput{Signature: signature}, nil
+}
+
+func TestAWSClient(t *testing.T) {
+	// given
+	fake := newFakeAWSKMS()
+	client := hkms.NewAWSClient(fake)
+	digest := make([]byte, 48)
+
+	// when
+	keyArn, pub, err := client.CreateKey(elliptic.P384())
+	assert.NoError(t, err)
+	fetchedPub, fetchErr := client.PublicKey(keyArn)
+	signature, signErr := client.Sign(keyArn, digest)
+
+	// then
+	assert.Equal(t, "arn:aws:kms:ap-northeast-2:111122223333:key/0", keyArn)
+	assert.NoError(t, fetchErr)
+	assert.True(t, pub.Equal(fetchedPub))
+	assert.NoError(t, signErr)
+	assert.True(t, ecdsa.VerifyASN1(pub, digest, signature))
+	assert.Equal(t, []types.SigningAlgorithmSpec{types.SigningAlgorithmSpecEcdsaSha384}, fake.signingAlgorithms)
+}
+
+func TestAWSClient_NotSupported(t *testing.T) {
+	// given
+	client := hkms.NewAWSClient(newFakeAWSKMS())
+	keyArn, _, err := client.CreateKey(elliptic.P256())
+	assert.NoError(t, err)
+
+	// when
+	_, _, curveErr := client.CreateKey(elliptic.P224())
+	_, digestErr := client.Sign(keyArn, make([]byte, 20))
+
+	// then
+	assert.Equal(t, hkms.ErrCurveNotSupported, curveErr)
+	assert.Equal(t, hkms.ErrDigestSizeNotSupported, digestErr)
+}
+
+func TestAWSClient_WithContext(t *testing.T) {
+	// given
+	client := hkms.NewAWSClient(newFakeAWSKMS())
+	keyArn, _, err := client.CreateKey(elliptic.P256())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	_, _, createErr := client.CreateKeyWithContext(ctx, elliptic.P256())
+	_, fetchErr := client.PublicKeyWithContext(ctx, keyArn)
+	_, signErr := client.SignWithContext(ctx, keyArn, make([]byte, 32))
+
+	// then
+	assert.Equal(t, context.Canceled, createErr)
+	assert.Equal(t, context.Canceled, fetchErr)
+	assert.Equal(t, context.Canceled, signErr)
+}
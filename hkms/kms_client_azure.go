@@ -0,0 +1,187 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides key management service client of Azure Key Vault.
+
+package hkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrInvalidAzureKey = errors.New("invalid key - Key Vault key should be EC key on P-256, P-384 or P-521")
+
+// key types of Key Vault, where EC-HSM keys are kept in HSM of premium vaults or Managed HSM
+const (
+	AzureKeyTypeEC    = "EC"
+	AzureKeyTypeECHSM = "EC-HSM"
+)
+
+var azureCurveNames = map[elliptic.Curve]string{
+	elliptic.P256(): "P-256",
+	elliptic.P384(): "P-384",
+	elliptic.P521(): "P-521",
+}
+
+// JWS algorithms of Key Vault sign operation by digest size
+var azureSigningAlgorithms = map[int]string{
+	32: "ES256",
+	48: "ES384",
+	64: "ES512",
+}
+
+// curves by size of signature in concatenation of r and s
+var azureSignatureCurves = map[int]elliptic.Curve{
+	64:  elliptic.P256(),
+	96:  elliptic.P384(),
+	132: elliptic.P521(),
+}
+
+// AzureKey is EC public key of Key Vault in JSON web key fields.
+type AzureKey struct {
+	// KID is key identifier, which is URL of key version. (ex. https://vault.vault.azure.net/keys/name/version)
+	KID string
+	// Crv is curve name. (ex. P-256)
+	Crv  string
+	X, Y []byte
+}
+
+// AzureKeyVaultAPI is operations of Azure Key Vault which AzureClient uses. It can be implemented by thin adapter of
+// azkeys.Client of Azure SDK, passing the context to the call and the other arguments to the fields of the same names
+// in the parameters.
+type AzureKeyVaultAPI interface {
+	// CreateKey creates key of key type (EC or EC-HSM) on curve with sign and verify operations, and returns its public key.
+	CreateKey(ctx context.Context, name, keyType, crv string) (*AzureKey, error)
+
+	// GetKey returns public key of key identifier.
+	GetKey(ctx context.Context, kid string) (*AzureKey, error)
+
+	// Sign signs digest with key of key identifier by JWS algorithm (ES256, ES384 or ES512),
+	// and returns signature in concatenation of r and s.
+	Sign(ctx context.Context, kid, algorithm string, digest []byte) ([]byte, error)
+}
+
+// AzureClient is a Client of Azure Key Vault, whose keys are named by key identifier of key version.
+type AzureClient struct {
+	api     AzureKeyVaultAPI
+	keyType string
+}
+
+// NewAzureClient makes Client of Azure Key Vault, which creates software protected keys (EC).
+func NewAzureClient(api AzureKeyVaultAPI) *AzureClient {
+	return &AzureClient{api: api, keyType: AzureKeyTypeEC}
+}
+
+// WithHSM sets client to create HSM protected keys (EC-HSM), which requires premium vault or Managed HSM.
+func (client *AzureClient) WithHSM() *AzureClient {
+	client.keyType = AzureKeyTypeECHSM
+	return client
+}
+
+func (client *AzureClient) CreateKey(curve elliptic.Curve) (string, *ecdsa.PublicKey, error) {
+	return client.CreateKeyWithContext(context.Background(), curve)
+}
+
+func (client *AzureClient) CreateKeyWithContext(ctx context.Context, curve elliptic.Curve) (string, *ecdsa.PublicKey, error) {
+	crv, ok := azureCurveNames[curve]
+	if !ok {
+		return "", nil, ErrCurveNotSupported
+	}
+
+	name, err := newRemoteKeyName()
+	if err != nil {
+		return "", nil, err
+	}
+
+	key, err := client.api.CreateKey(ctx, name, client.keyType, crv)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pub, err := toAzurePubKey(key)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return key.KID, pub, nil
+}
+
+func (client *AzureClient) PublicKey(kid string) (*ecdsa.PublicKey, error) {
+	return client.PublicKeyWithContext(context.Background(), kid)
+}
+
+func (client *AzureClient) PublicKeyWithContext(ctx context.Context, kid string) (*ecdsa.PublicKey, error) {
+	key, err := client.api.GetKey(ctx, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAzurePubKey(key)
+}
+
+// Sign signs digest by algorithm of the digest size, which should match curve of the key in Key Vault,
+// and converts the signature to ASN.1 DER. (ex. SHA-256 size digest for P-256 key)
+func (client *AzureClient) Sign(kid string, digest []byte) ([]byte, error) {
+	return client.SignWithContext(context.Background(), kid, digest)
+}
+
+func (client *AzureClient) SignWithContext(ctx context.Context, kid string, digest []byte) ([]byte, error) {
+	algorithm, ok := azureSigningAlgorithms[len(digest)]
+	if !ok {
+		return nil, ErrDigestSizeNotSupported
+	}
+
+	signature, err := client.api.Sign(ctx, kid, algorithm, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	curve, ok := azureSignatureCurves[len(signature)]
+	if !ok {
+		return nil, hecdsa.ErrInvalidSignatureLength
+	}
+
+	return hecdsa.ConvertSignature(curve, signature, hecdsa.SignatureFormatRaw, hecdsa.SignatureFormatDER)
+}
+
+// toAzurePubKey converts EC key of JSON web key fields to ECDSA public key.
+func toAzurePubKey(key *AzureKey) (*ecdsa.PublicKey, error) {
+	for curve, crv := range azureCurveNames {
+		if key.Crv != crv {
+			continue
+		}
+
+		pub := &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(key.X),
+			Y:     new(big.Int).SetBytes(key.Y),
+		}
+		if !curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, ErrInvalidAzureKey
+		}
+
+		return pub, nil
+	}
+
+	return nil, ErrInvalidAzureKey
+}
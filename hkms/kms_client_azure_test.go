@@ -0,0 +1,194 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hkms_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hkms"
+	"github.com/stretchr/testify/assert"
+)
+
+var fakeAzureCurves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// fakeAzureKeyVault is a fake Azure Key Vault which keeps keys in memory for testing.
+type fakeAzureKeyVault struct {
+	keys       map[string]*ecdsa.PrivateKey
+	keyTypes   []string
+	algorithms []string
+	corrupt    bool
+}
+
+func newFakeAzureKeyVault() *fakeAzureKeyVault {
+	return &fakeAzureKeyVault{keys: make(map[string]*ecdsa.PrivateKey)}
+}
+
+func (fake *fakeAzureKeyVault) CreateKey(ctx context.Context, name, keyType, crv string) (*hkms.AzureKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	curve, ok := fakeAzureCurves[crv]
+	if !ok {
+		return nil, errors.New("unsupported curve")
+	}
+
+	pri, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	kid := "https://node.vault.azure.net/keys/" + name + "/1"
+	fake.keys[kid] = pri
+	fake.keyTypes = append(fake.keyTypes, keyType)
+
+	return fake.GetKey(ctx, kid)
+}
+
+func (fake *fakeAzureKeyVault) GetKey(ctx context.Context, kid string) (*hkms.AzureKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pri, ok := fake.keys[kid]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+
+	key := &hkms.AzureKey{
+		KID: kid,
+		Crv: pri.Curve.Params().Name,
+		X:   pri.X.Bytes(),
+		Y:   pri.Y.Bytes(),
+	}
+	if fake.corrupt {
+		key.X[0] ^= 0x01
+	}
+
+	return key, nil
+}
+
+func (fake *fakeAzureKeyVault) Sign(ctx context.Context, kid, algorithm string, digest []byte) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pri, ok := fake.keys[kid]
+	if !ok {
+		return nil, errors.New("key not found")
+	}
+	fake.algorithms = append(fake.algorithms, algorithm)
+
+	r, s, err := ecdsa.Sign(rand.Reader, pri, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	size := (pri.Curve.Params().BitSize + 7) / 8
+	signature := make([]byte, 2*size)
+	r.FillBytes(signature[:size])
+	s.FillBytes(signature[size:])
+
+	return signature, nil
+}
+
+func TestAzureClient(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		t.Logf("running test case [%s]", curve.Params().Name)
+
+		// given
+		fake := newFakeAzureKeyVault()
+		client := hkms.NewAzureClient(fake)
+		digest := make([]byte, 32)
+
+		// when
+		kid, pub, err := client.CreateKey(curve)
+		assert.NoError(t, err)
+		fetchedPub, fetchErr := client.PublicKey(kid)
+		signature, signErr := client.Sign(kid, digest)
+
+		// then
+		assert.NoError(t, fetchErr)
+		assert.True(t, pub.Equal(fetchedPub))
+		assert.NoError(t, signErr)
+		assert.True(t, ecdsa.VerifyASN1(pub, digest, signature))
+		assert.Equal(t, []string{hkms.AzureKeyTypeEC}, fake.keyTypes)
+		assert.Equal(t, []string{"ES256"}, fake.algorithms)
+	}
+}
+
+func TestAzureClient_WithHSM(t *testing.T) {
+	// given
+	fake := newFakeAzureKeyVault()
+	client := hkms.NewAzureClient(fake).WithHSM()
+
+	// when
+	_, _, err := client.CreateKey(elliptic.P256())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []string{hkms.AzureKeyTypeECHSM}, fake.keyTypes)
+}
+
+func TestAzureClient_Failure(t *testing.T) {
+	// given
+	fake := newFakeAzureKeyVault()
+	client := hkms.NewAzureClient(fake)
+	kid, _, err := client.CreateKey(elliptic.P256())
+	assert.NoError(t, err)
+
+	// when
+	_, _, curveErr := client.CreateKey(elliptic.P224())
+	_, digestErr := client.Sign(kid, make([]byte, 20))
+	fake.corrupt = true
+	_, keyErr := client.PublicKey(kid)
+
+	// then
+	assert.Equal(t, hkms.ErrCurveNotSupported, curveErr)
+	assert.Equal(t, hkms.ErrDigestSizeNotSupported, digestErr)
+	assert.Equal(t, hkms.ErrInvalidAzureKey, keyErr)
+}
+
+func TestAzureClient_WithContext(t *testing.T) {
+	// given
+	client := hkms.NewAzureClient(newFakeAzureKeyVault())
+	kid, _, err := client.CreateKey(elliptic.P256())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	_, _, createErr := client.CreateKeyWithContext(ctx, elliptic.P256())
+	_, fetchErr := client.PublicKeyWithContext(ctx, kid)
+	_, signErr := client.SignWithContext(ctx, kid, make([]byte, 32))
+
+	// then
+	assert.Equal(t, context.Canceled, createErr)
+	assert.Equal(t, context.Canceled, fetchErr)
+	assert.Equal(t, context.Canceled, signErr)
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides key management service client of GCP Cloud KMS.
+
+package hkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"hash/crc32"
+)
+
+var ErrInvalidGCPPublicKey = errors.New("invalid public key - Cloud KMS public key should be PEM encoded ECDSA public key")
+var ErrGCPChecksumMismatch = errors.New("checksum mismatch - request or response of Cloud KMS is corrupted in transit")
+
+// crypto key algorithms of Cloud KMS, which fix curve and digest of the key
+var gcpAlgorithms = map[elliptic.Curve]string{
+	elliptic.P256(): "EC_SIGN_P256_SHA256",
+	elliptic.P384(): "EC_SIGN_P384_SHA384",
+}
+
+// digest names of Cloud KMS AsymmetricSignRequest by digest size
+var gcpDigestAlgorithms = map[int]string{
+	32: "sha256",
+	48: "sha384",
+	64: "sha512",
+}
+
+// crc32cTable is CRC32C table, which Cloud KMS uses for checksum of requests and responses.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// GCPKMSAPI is operations of GCP Cloud KMS which GCPClient uses. It can be implemented by thin adapter of
+// KeyManagementClient of Cloud KMS SDK, passing the context to the call and the other arguments to the fields of
+// the same names in the requests.
+type GCPKMSAPI interface {
+	// CreateCryptoKey creates crypto key of purpose ASYMMETRIC_SIGN with algorithm (ex. EC_SIGN_P256_SHA256) in key ring,
+	// and returns resource name of its primary crypto key version.
+	CreateCryptoKey(ctx context.Context, keyRing, cryptoKeyId, algorithm string) (keyVersionName string, err error)
+
+	// GetPublicKey returns PEM encoded public key of crypto key version, and CRC32C checksum of the PEM.
+	GetPublicKey(ctx context.Context, keyVersionName string) (pemKey string, pemCRC32C uint32, err error)
+
+	// AsymmetricSign signs digest of digest algorithm (sha256, sha384 or sha512) with crypto key version,
+	// and returns ASN.1 DER encoded signature and CRC32C checksum of the signature.
+	AsymmetricSign(ctx context.Context, keyVersionName, digestAlgorithm string, digest []byte, digestCRC32C uint32) (signature []byte, signatureCRC32C uint32, err error)
+}
+
+// GCPClient is a Client of GCP Cloud KMS, whose keys are named by resource name of crypto key version.
+// Keys are created in key ring of Cloud KMS (ex. projects/p/locations/global/keyRings/r), with protection level
+// configured on the key ring's defaults (ex. HSM). Requests and responses are checked by CRC32C.
+type GCPClient struct {
+	api     GCPKMSAPI
+	keyRing string
+}
+
+// NewGCPClient makes Client of GCP Cloud KMS, which creates keys in key ring.
+func NewGCPClient(api GCPKMSAPI, keyRing string) *GCPClient {
+	return &GCPClient{api: api, keyRing: keyRing}
+}
+
+func (client *GCPClient) CreateKey(curve elliptic.Curve) (string, *ecdsa.PublicKey, error) {
+	return client.CreateKeyWithContext(context.Background(), curve)
+}
+
+func (client *GCPClient) CreateKeyWithContext(ctx context.Context, curve elliptic.Curve) (string, *ecdsa.PublicKey, error) {
+	algorithm, ok := gcpAlgorithms[curve]
+	if !ok {
+		return "", nil, ErrCurveNotSupported
+	}
+
+	cryptoKeyId, err := newRemoteKeyName()
+	if err != nil {
+		return "", nil, err
+	}
+
+	keyVersionName, err := client.api.CreateCryptoKey(ctx, client.keyRing, cryptoKeyId, algorithm)
+	if err != nil {
+		return "", nil, err
+	}
+
+	pub, err := client.PublicKeyWithContext(ctx, keyVersionName)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return keyVersionName, pub, nil
+}
+
+func (client *GCPClient) PublicKey(keyVersionName string) (*ecdsa.PublicKey, error) {
+	return client.PublicKeyWithContext(context.Background(), keyVersionName)
+}
+
+func (client *GCPClient) PublicKeyWithContext(ctx context.Context, keyVersionName string) (*ecdsa.PublicKey, error) {
+	pemKey, pemCRC32C, err := client.api.GetPublicKey(ctx, keyVersionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if crc32.Checksum([]byte(pemKey), crc32cTable) != pemCRC32C {
+		return nil, ErrGCPChecksumMismatch
+	}
+
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, ErrInvalidGCPPublicKey
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidGCPPublicKey
+	}
+
+	if _, ok := gcpAlgorithms[ecdsaPub.Curve]; !ok {
+		return nil, ErrCurveNotSupported
+	}
+
+	return ecdsaPub, nil
+}
+
+// Sign signs digest by digest algorithm of the digest size, which should match algorithm of the crypto key.
+// (ex. SHA-256 size digest for EC_SIGN_P256_SHA256 key)
+func (client *GCPClient) Sign(keyVersionName string, digest []byte) ([]byte, error) {
+	return client.SignWithContext(context.Background(), keyVersionName, digest)
+}
+
+func (client *GCPClient) SignWithContext(ctx context.Context, keyVersionName string, digest []byte) ([]byte, error) {
+	digestAlgorithm, ok := gcpDigestAlgorithms[len(digest)]
+	if !ok {
+		return nil, ErrDigestSizeNotSupported
+	}
+
+	signature, signatureCRC32C, err := client.api.AsymmetricSign(ctx, keyVersionName, digestAlgorithm, digest, crc32.Checksum(digest, crc32cTable))
+	if err != nil {
+		return nil, err
+	}
+
+	if crc32.Checksum(signature, crc32cTable) != signatureCRC32C {
+		return nil, ErrGCPChecksumMismatch
+	}
+
+	return signature, nil
+}
+
+// newRemoteKeyName makes random name of key created in KMS, which is valid for both Cloud KMS and Key Vault.
+func newRemoteKeyName() (string, error) {
+	suffix := make([]byte, 16)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+
+	return "heimdall-" + hex.EncodeToString(suffix), nil
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hkms_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"hash/crc32"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hkms"
+	"github.com/stretchr/testify/assert"
+)
+
+const testKeyRing = "projects/heimdall/locations/global/keyRings/node"
+
+var fakeGCPCurves = map[string]elliptic.Curve{
+	"EC_SIGN_P256_SHA256": elliptic.P256(),
+	"EC_SIGN_P384_SHA384": elliptic.P384(),
+}
+
+func crc32c(data []byte) uint32 {
+	return crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli))
+}
+
+// fakeGCPKMS is a fake GCP Cloud KMS which keeps keys in memory for testing.
+type fakeGCPKMS struct {
+	keys     map[string]*ecdsa.PrivateKey
+	corrupt  bool
+	requests []string
+}
+
+func newFakeGCPKMS() *fakeGCPKMS {
+	return &fakeGCPKMS{keys: make(map[string]*ecdsa.PrivateKey)}
+}
+
+func (fake *fakeGCPKMS) CreateCryptoKey(ctx context.Context, keyRing, cryptoKeyId, algorithm string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	curve, ok := fakeGCPCurves[algorithm]
+	if !ok {
+		return "", errors.New("unsupported algorithm")
+	}
+
+	pri, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return "", err
+	}
+
+	keyVersionName := keyRing + "/cryptoKeys/" + cryptoKeyId + "/cryptoKeyVersions/1"
+	fake.keys[keyVersionName] = pri
+
+	return keyVersionName, nil
+}
+
+func (fake *fakeGCPKMS) GetPublicKey(ctx context.Context, keyVersionName string) (string, uint32, error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+
+	pri, ok := fake.keys[keyVersionName]
+	if !ok {
+		return "", 0, errors.New("key not found")
+	}
+
+	pubBytes, err := x509.MarshalPKIXPublicKey(&pri.PublicKey)
+	if err != nil {
+		return "", 0, err
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubBytes})
+
+	return string(pemKey), crc32c(pemKey), nil
+}
+
+func (fake *fakeGCPKMS) AsymmetricSign(ctx context.Context, keyVersionName, digestAlgorithm string, digest []byte, digestCRC32C uint32) ([]byte, uint32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	pri, ok := fake.keys[keyVersionName]
+	if !ok || crc32c(digest) != digestCRC32C {
+		return nil, 0, errors.New("invalid sign request")
+	}
+	fake.requests = append(fake.requests, digestAlgorithm)
+
+	signature, err := ecdsa.SignASN1(rand.Reader, pri, digest)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	checksum := crc32c(signature)
+	if fake.corrupt {
+		signature[len(signature)-1] ^= 0x01
+	}
+
+	return signature, checksum, nil
+}
+
+func TestGCPClient(t *testing.T) {
+	// given
+	fake := newFakeGCPKMS()
+	client := hkms.NewGCPClient(fake, testKeyRing)
+	digest := make([]byte, 32)
+
+	// when
+	keyVersionName, pub, err := client.CreateKey(elliptic.P256())
+	assert.NoError(t, err)
+	fetchedPub, fetchErr := client.PublicKey(keyVersionName)
+	signature, signErr := client.Sign(keyVersionName, digest)
+
+	// then
+	assert.Regexp(t, "^"+testKeyRing+"/cryptoKeys/heimdall-[0-9a-f]{32}/cryptoKeyVersions/1$", keyVersionName)
+	assert.NoError(t, fetchErr)
+	assert.True(t, pub.Equal(fetchedPub))
+	assert.NoError(t, signErr)
+	assert.True(t, ecdsa.VerifyASN1(pub, digest, signature))
+	assert.Equal(t, []string{"sha256"}, fake.requests)
+}
+
+func TestGCPClient_Failure(t *testing.T) {
+	// given
+	fake := newFakeGCPKMS()
+	client := hkms.NewGCPClient(fake, testKeyRing)
+	keyVersionName, _, err := client.CreateKey(elliptic.P384())
+	assert.NoError(t, err)
+
+	// when
+	_, _, curveErr := client.CreateKey(elliptic.P521())
+	_, digestErr := client.Sign(keyVersionName, make([]byte, 20))
+	fake.corrupt = true
+	_, checksumErr := client.Sign(keyVersionName, make([]byte, 48))
+
+	// then
+	assert.Equal(t, hkms.ErrCurveNotSupported, curveErr)
+	assert.Equal(t, hkms.ErrDigestSizeNotSupported, digestErr)
+	assert.Equal(t, hkms.ErrGCPChecksumMismatch, checksumErr)
+}
+
+func TestGCPClient_WithContext(t *testing.T) {
+	// given
+	client := hkms.NewGCPClient(newFakeGCPKMS(), testKeyRing)
+	keyVersionName, _, err := client.CreateKey(elliptic.P256())
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	_, _, createErr := client.CreateKeyWithContext(ctx, elliptic.P256())
+	_, fetchErr := client.PublicKeyWithContext(ctx, keyVersionName)
+	_, signErr := client.SignWithContext(ctx, keyVersionName, make([]byte, 32))
+
+	// then
+	assert.Equal(t, context.Canceled, createErr)
+	assert.Equal(t, context.Canceled, fetchErr)
+	assert.Equal(t, context.Canceled, signErr)
+}
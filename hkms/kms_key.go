@@ -0,0 +1,156 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides keys which are created and used inside key management service.
+
+package hkms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"errors"
+	"io"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrKeyGenOptNotSupported = errors.New("key generation option not supported - KMS key should be ECDSA key")
+var ErrPublicKeyNotSupported = errors.New("KMS key can not be recovered from public key bytes, use hecdsa.KeyRecoverer")
+
+// GenerateKey creates ECDSA key inside KMS.
+func GenerateKey(client Client, keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	return GenerateKeyWithContext(context.Background(), client, keyGenOpt)
+}
+
+// GenerateKeyWithContext creates ECDSA key inside KMS, giving up when context is done.
+func GenerateKeyWithContext(ctx context.Context, client Client, keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	ecdsaKeyGenOpt, err := hecdsa.ToKeyGenOpt(keyGenOpt)
+	if err != nil {
+		return nil, ErrKeyGenOptNotSupported
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(ecdsaKeyGenOpt); err != nil {
+		return nil, err
+	}
+
+	keyName, pub, err := createKey(ctx, client, ecdsaKeyGenOpt.Curve)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &PriKey{
+		client:  client,
+		keyName: keyName,
+		pub:     pub,
+	}
+	event.Publish(event.KeyCreated, key.ID(), keyName)
+
+	return key, nil
+}
+
+// PriKey is an implementation of heimdall PriKey whose private part never leaves KMS.
+type PriKey struct {
+	client  Client
+	keyName string
+	pub     *ecdsa.PublicKey
+}
+
+// NewPriKey makes key of existing key in KMS with its public key, without calling KMS.
+func NewPriKey(client Client, keyName string, pub *ecdsa.PublicKey) heimdall.PriKey {
+	return &PriKey{
+		client:  client,
+		keyName: keyName,
+		pub:     pub,
+	}
+}
+
+func (priKey *PriKey) ID() heimdall.KeyID {
+	return priKey.PublicKey().ID()
+}
+
+func (priKey *PriKey) SKI() []byte {
+	return priKey.PublicKey().SKI()
+}
+
+// ToByte returns name of the key in KMS (ex. key ARN), since private key can not be exported.
+func (priKey *PriKey) ToByte() ([]byte, error) {
+	return []byte(priKey.keyName), nil
+}
+
+// KeyName returns name of the key in KMS. (ex. key ARN)
+func (priKey *PriKey) KeyName() string {
+	return priKey.keyName
+}
+
+func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return priKey.PublicKey().KeyGenOpt()
+}
+
+func (priKey *PriKey) IsPrivate() bool {
+	return true
+}
+
+// PublicKey returns ECDSA public key, so signatures can be verified by hecdsa.
+func (priKey *PriKey) PublicKey() heimdall.PubKey {
+	return hecdsa.NewPubKey(priKey.pub)
+}
+
+// Clear does nothing, since no private key material is held in memory.
+func (priKey *PriKey) Clear() {
+}
+
+// Public implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Public() crypto.PublicKey {
+	return priKey.pub
+}
+
+// Sign implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	return priKey.client.Sign(priKey.keyName, digest)
+}
+
+// SignWithContext signs digest with the key inside KMS, giving up when context is done.
+func (priKey *PriKey) SignWithContext(ctx context.Context, digest []byte) ([]byte, error) {
+	return sign(ctx, priKey.client, priKey.keyName, digest)
+}
+
+// KeyRecoverer recovers KMS key from its name by fetching public key from KMS.
+type KeyRecoverer struct {
+	Client Client
+}
+
+func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	return recoverer.RecoverKeyFromByteWithContext(context.Background(), keyBytes, isPrivate)
+}
+
+// RecoverKeyFromByteWithContext recovers KMS key from its name, giving up fetching public key when context is done.
+func (recoverer *KeyRecoverer) RecoverKeyFromByteWithContext(ctx context.Context, keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	if !isPrivate {
+		return nil, ErrPublicKeyNotSupported
+	}
+
+	keyName := string(keyBytes)
+	pub, err := publicKey(ctx, recoverer.Client, keyName)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPriKey(recoverer.Client, keyName, pub), nil
+}
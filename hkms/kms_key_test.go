@@ -0,0 +1,143 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hkms_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hkms"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpKMSKey(t *testing.T, client hkms.Client) heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hkms.GenerateKey(client, keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func TestGenerateKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+
+	// when
+	pri, err := hkms.GenerateKey(hkms.NewAWSClient(newFakeAWSKMS()), keyGenOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, pri.IsPrivate())
+	assert.Equal(t, pri.ID(), pri.PublicKey().ID())
+	assert.Equal(t, hecdsa.ECP384, pri.KeyGenOpt().ToString())
+	keyName, err := pri.ToByte()
+	assert.NoError(t, err)
+	assert.Equal(t, pri.(*hkms.PriKey).KeyName(), string(keyName))
+}
+
+func TestGenerateKey_NotSupported(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+
+	// when
+	_, err = hkms.GenerateKey(hkms.NewAWSClient(newFakeAWSKMS()), keyGenOpt)
+
+	// then
+	assert.Equal(t, hkms.ErrKeyGenOptNotSupported, err)
+}
+
+func TestGenerateKeyWithContext(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	pri, err := hkms.GenerateKeyWithContext(context.Background(), hkms.NewAWSClient(newFakeAWSKMS()), keyGenOpt)
+	_, awsErr := hkms.GenerateKeyWithContext(ctx, hkms.NewAWSClient(newFakeAWSKMS()), keyGenOpt)
+	_, gcpErr := hkms.GenerateKeyWithContext(ctx, hkms.NewGCPClient(newFakeGCPKMS(), testKeyRing), keyGenOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.NotNil(t, pri)
+	assert.Equal(t, context.Canceled, awsErr)
+	assert.Equal(t, context.Canceled, gcpErr)
+}
+
+func TestPriKey_SignWithContext(t *testing.T) {
+	// given
+	pri := setUpKMSKey(t, hkms.NewAWSClient(newFakeAWSKMS()))
+	digest := make([]byte, 32)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	signature, err := pri.(*hkms.PriKey).SignWithContext(context.Background(), digest)
+	_, cancelledErr := pri.(*hkms.PriKey).SignWithContext(ctx, digest)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, ecdsa.VerifyASN1(pri.(*hkms.PriKey).Public().(*ecdsa.PublicKey), digest, signature))
+	assert.Equal(t, context.Canceled, cancelledErr)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
+	// given
+	client := hkms.NewAWSClient(newFakeAWSKMS())
+	pri := setUpKMSKey(t, client)
+	keyName, err := pri.ToByte()
+	assert.NoError(t, err)
+	recoverer := &hkms.KeyRecoverer{Client: client}
+
+	// when
+	key, err := recoverer.RecoverKeyFromByte(keyName, true)
+	_, pubErr := recoverer.RecoverKeyFromByte(keyName, false)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.Equal(t, hkms.ErrPublicKeyNotSupported, pubErr)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByteWithContext(t *testing.T) {
+	// given
+	client := hkms.NewAWSClient(newFakeAWSKMS())
+	pri := setUpKMSKey(t, client)
+	keyName, err := pri.ToByte()
+	assert.NoError(t, err)
+	recoverer := &hkms.KeyRecoverer{Client: client}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	_, err = recoverer.RecoverKeyFromByteWithContext(ctx, keyName, true)
+
+	// then
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides functions for storing and loading names of keys inside key management service.
+
+package hkms
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+)
+
+var ErrKeyNotExist = errors.New("KMS key file not exist")
+
+// KeyFile is a file format of KMS key, which has no private key material but name and public key of the key.
+type KeyFile struct {
+	KeyGenOpt string
+	KeyName   string
+	PublicKey []byte
+}
+
+// StoreKey stores name and public key of KMS key into key directory with the name of key ID.
+func StoreKey(key heimdall.PriKey, keyDirPath string) error {
+	return StoreKeyWithContext(context.Background(), key, keyDirPath)
+}
+
+// StoreKeyWithContext is StoreKey bounded by context. Storing touches only the local key directory,
+// so context is checked before the key file is written.
+func StoreKeyWithContext(ctx context.Context, key heimdall.PriKey, keyDirPath string) error {
+	kmsPri, ok := key.(*PriKey)
+	if !ok {
+		return ErrKeyNotKMSKey
+	}
+
+	pubBytes, err := kmsPri.PublicKey().ToByte()
+	if err != nil {
+		return err
+	}
+
+	jsonKeyFile, err := json.Marshal(&KeyFile{
+		KeyGenOpt: kmsPri.KeyGenOpt().ToString(),
+		KeyName:   kmsPri.keyName,
+		PublicKey: pubBytes,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(keyDirPath); os.IsNotExist(err) {
+		if err = fileperm.MkdirAll(keyDirPath); err != nil {
+			return err
+		}
+	}
+	fileperm.WarnInsecure(keyDirPath)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	keyFilePath := filepath.Join(keyDirPath, kmsPri.ID())
+
+	return fileperm.WriteFileAtomic(keyFilePath, keyFilePath+".tmp", jsonKeyFile)
+}
+
+// LoadKey loads KMS key of key ID with name and public key stored in key directory, without calling KMS.
+func LoadKey(client Client, keyId heimdall.KeyID, keyDirPath string) (heimdall.PriKey, error) {
+	return LoadKeyWithContext(context.Background(), client, keyId, keyDirPath)
+}
+
+// LoadKeyWithContext is LoadKey bounded by context. Loading does not call KMS, so context is checked before
+// the key file is read. Signing with the loaded key is bounded by context of SignWithContext.
+func LoadKeyWithContext(ctx context.Context, client Client, keyId heimdall.KeyID, keyDirPath string) (heimdall.PriKey, error) {
+	if err := heimdall.ValidateKeyID(keyId); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	fileperm.WarnInsecure(keyDirPath)
+
+	jsonKeyFile, err := ioutil.ReadFile(heimdall.KeyIDFilePath(keyDirPath, keyId))
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotExist
+	} else if err != nil {
+		return nil, err
+	}
+
+	var keyFile KeyFile
+	if err = json.Unmarshal(jsonKeyFile, &keyFile); err != nil {
+		return nil, err
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(keyFile.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrKeyGenOptNotSupported
+	}
+
+	key := NewPriKey(client, keyFile.KeyName, ecdsaPub)
+	if err = heimdall.SKIValidCheck(keyId, key.SKI()); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
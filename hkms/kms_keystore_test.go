@@ -0,0 +1,78 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hkms_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hkms"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadKey(t *testing.T) {
+	// given
+	client := hkms.NewAWSClient(newFakeAWSKMS())
+	pri := setUpKMSKey(t, client)
+	err := hkms.StoreKey(pri, heimdall.TestKeyDir)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	key, err := hkms.LoadKey(client, pri.ID(), heimdall.TestKeyDir)
+	_, notExistErr := hkms.LoadKey(client, "ITnotExist", heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.Equal(t, pri.(*hkms.PriKey).KeyName(), key.(*hkms.PriKey).KeyName())
+	assert.Equal(t, hkms.ErrKeyNotExist, notExistErr)
+
+	keyFile, err := ioutil.ReadFile(filepath.Join(heimdall.TestKeyDir, pri.ID()))
+	assert.NoError(t, err)
+	assert.Contains(t, string(keyFile), pri.(*hkms.PriKey).KeyName())
+}
+
+func TestStoreKeyWithContext(t *testing.T) {
+	// given
+	client := hkms.NewAWSClient(newFakeAWSKMS())
+	pri := setUpKMSKey(t, client)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	storeErr := hkms.StoreKeyWithContext(ctx, pri, heimdall.TestKeyDir)
+	_, notStoredErr := hkms.LoadKey(client, pri.ID(), heimdall.TestKeyDir)
+	assert.NoError(t, hkms.StoreKeyWithContext(context.Background(), pri, heimdall.TestKeyDir))
+	_, loadErr := hkms.LoadKeyWithContext(ctx, client, pri.ID(), heimdall.TestKeyDir)
+	key, err := hkms.LoadKeyWithContext(context.Background(), client, pri.ID(), heimdall.TestKeyDir)
+
+	// then
+	assert.Equal(t, context.Canceled, storeErr)
+	assert.Equal(t, hkms.ErrKeyNotExist, notStoredErr)
+	assert.Equal(t, context.Canceled, loadErr)
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), key.ID())
+}
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hpiv_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hpiv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// given
+	card := newFakeCard()
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+
+	policy := hpiv.KeyPolicy{PIN: hpiv.PINPolicyAlways, Touch: hpiv.TouchPolicyAlways}
+	pri, err := hpiv.GenerateKey(card, hpiv.DefaultManagementKey, hpiv.SlotSignature, keyGenOpt, policy, func() (string, error) {
+		return "123456", nil
+	})
+	assert.NoError(t, err)
+
+	// when
+	signature, err := hpiv.Sign(pri, message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestSign_Policy(t *testing.T) {
+	// given
+	card := newFakeCard()
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+
+	policy := hpiv.KeyPolicy{PIN: hpiv.PINPolicyAlways, Touch: hpiv.TouchPolicyAlways}
+	pri, err := hpiv.GenerateKey(card, hpiv.DefaultManagementKey, hpiv.SlotSignature, keyGenOpt, policy, nil)
+	assert.NoError(t, err)
+	signer, err := hpiv.NewSigner(pri)
+	assert.NoError(t, err)
+
+	// when
+	_, pinErr := signer.Sign([]byte("hello"), hecdsa.NewSignerOpts(hashOpt))
+	card.pin = ""
+	card.touched = false
+	_, touchErr := signer.Sign([]byte("hello"), hecdsa.NewSignerOpts(hashOpt))
+
+	// then
+	assert.Equal(t, hpiv.ErrWrongPIN, pinErr)
+	assert.Equal(t, hpiv.ErrTouchTimeout, touchErr)
+}
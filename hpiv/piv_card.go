@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides PIV card interface which keys on a YubiKey PIV applet are created and used through.
+
+package hpiv
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+)
+
+var ErrWrongPIN = errors.New("wrong PIN - PIN verification failed")
+var ErrTouchTimeout = errors.New("touch timeout - key was not touched before signing timed out")
+var ErrInvalidSlot = errors.New("invalid PIV slot")
+
+// Slot is a PIV key slot.
+type Slot byte
+
+// PIV key slots
+const (
+	SlotAuthentication Slot = 0x9a
+	SlotSignature      Slot = 0x9c
+	SlotKeyManagement  Slot = 0x9d
+	SlotCardAuth       Slot = 0x9e
+)
+
+// ValidSlot checks if slot is one of PIV key slots.
+func ValidSlot(slot Slot) error {
+	switch slot {
+	case SlotAuthentication, SlotSignature, SlotKeyManagement, SlotCardAuth:
+		return nil
+	default:
+		return ErrInvalidSlot
+	}
+}
+
+// PINPolicy decides when PIN is required for using a key.
+type PINPolicy int
+
+const (
+	PINPolicyNever PINPolicy = iota
+	PINPolicyOnce
+	PINPolicyAlways
+)
+
+// TouchPolicy decides when touching the YubiKey is required for using a key.
+type TouchPolicy int
+
+const (
+	TouchPolicyNever TouchPolicy = iota
+	TouchPolicyAlways
+	TouchPolicyCached
+)
+
+// KeyPolicy is PIN and touch policy of a key, fixed when the key is generated.
+type KeyPolicy struct {
+	PIN   PINPolicy
+	Touch TouchPolicy
+}
+
+// DefaultManagementKey is factory default management key of YubiKey, which should be changed before deployment.
+var DefaultManagementKey = []byte{
+	0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+	0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+	0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08,
+}
+
+// Card is a PIV applet of YubiKey which generates and uses non-exportable keys on device.
+// It can be implemented by PC/SC libraries such as go-piv, or a fake card for testing.
+type Card interface {
+	// GenerateKey generates ECDSA key in slot authenticated by management key, and returns its public key.
+	GenerateKey(managementKey []byte, slot Slot, curve elliptic.Curve, policy KeyPolicy) (*ecdsa.PublicKey, error)
+
+	// PublicKey returns public key of the key in slot.
+	PublicKey(slot Slot) (*ecdsa.PublicKey, error)
+
+	// Sign signs digest with key in slot and returns ASN.1 encoded signature.
+	// pin is verified if PIN policy of the key requires, and it blocks until touched if touch policy requires.
+	Sign(slot Slot, pin string, digest []byte) ([]byte, error)
+}
@@ -0,0 +1,207 @@
+//go:build pcsc
+// +build pcsc
+
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides PIV card over PC/SC by go-piv. It needs PC/SC library (ex. libpcsclite), so it is built with pcsc tag.
+
+package hpiv
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/go-piv/piv-go/piv"
+)
+
+var ErrCardNotFound = errors.New("card not found - no YubiKey is connected")
+var ErrInvalidManagementKey = errors.New("invalid management key - management key should be 24 bytes")
+var ErrCurveNotSupported = errors.New("curve not supported - PIV key should be on P-256 or P-384")
+
+var pivSlots = map[Slot]piv.Slot{
+	SlotAuthentication: piv.SlotAuthentication,
+	SlotSignature:      piv.SlotSignature,
+	SlotKeyManagement:  piv.SlotKeyManagement,
+	SlotCardAuth:       piv.SlotCardAuthentication,
+}
+
+var pivPINPolicies = map[PINPolicy]piv.PINPolicy{
+	PINPolicyNever:  piv.PINPolicyNever,
+	PINPolicyOnce:   piv.PINPolicyOnce,
+	PINPolicyAlways: piv.PINPolicyAlways,
+}
+
+var pivTouchPolicies = map[TouchPolicy]piv.TouchPolicy{
+	TouchPolicyNever:  piv.TouchPolicyNever,
+	TouchPolicyAlways: piv.TouchPolicyAlways,
+	TouchPolicyCached: piv.TouchPolicyCached,
+}
+
+// CardCloser is a PIV card which keeps PC/SC connection until closed.
+type CardCloser interface {
+	Card
+	io.Closer
+}
+
+// pcscCard is an implementation of Card by go-piv.
+type pcscCard struct {
+	mutex    sync.Mutex
+	yubiKey  *piv.YubiKey
+	policies map[Slot]piv.PINPolicy
+}
+
+// OpenCard opens the first YubiKey whose PC/SC card name contains name. (the first YubiKey if name is empty)
+func OpenCard(name string) (CardCloser, error) {
+	cards, err := piv.Cards()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, card := range cards {
+		if !strings.Contains(strings.ToLower(card), "yubikey") || !strings.Contains(card, name) {
+			continue
+		}
+
+		yubiKey, err := piv.Open(card)
+		if err != nil {
+			return nil, err
+		}
+
+		return &pcscCard{
+			yubiKey:  yubiKey,
+			policies: make(map[Slot]piv.PINPolicy),
+		}, nil
+	}
+
+	return nil, ErrCardNotFound
+}
+
+func (card *pcscCard) GenerateKey(managementKey []byte, slot Slot, curve elliptic.Curve, policy KeyPolicy) (*ecdsa.PublicKey, error) {
+	pivSlot, ok := pivSlots[slot]
+	if !ok {
+		return nil, ErrInvalidSlot
+	}
+
+	if len(managementKey) != 24 {
+		return nil, ErrInvalidManagementKey
+	}
+	var key [24]byte
+	copy(key[:], managementKey)
+
+	var algorithm piv.Algorithm
+	switch curve {
+	case elliptic.P256():
+		algorithm = piv.AlgorithmEC256
+	case elliptic.P384():
+		algorithm = piv.AlgorithmEC384
+	default:
+		return nil, ErrCurveNotSupported
+	}
+
+	card.mutex.Lock()
+	defer card.mutex.Unlock()
+
+	pub, err := card.yubiKey.GenerateKey(key, pivSlot, piv.Key{
+		Algorithm:   algorithm,
+		PINPolicy:   pivPINPolicies[policy.PIN],
+		TouchPolicy: pivTouchPolicies[policy.Touch],
+	})
+	if err != nil {
+		return nil, err
+	}
+	card.policies[slot] = pivPINPolicies[policy.PIN]
+
+	return toECDSAPublicKey(pub)
+}
+
+// PublicKey returns public key from attestation certificate of slot, which exists only for keys generated on YubiKey.
+func (card *pcscCard) PublicKey(slot Slot) (*ecdsa.PublicKey, error) {
+	pivSlot, ok := pivSlots[slot]
+	if !ok {
+		return nil, ErrInvalidSlot
+	}
+
+	card.mutex.Lock()
+	defer card.mutex.Unlock()
+
+	cert, err := card.yubiKey.Attest(pivSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	return toECDSAPublicKey(cert.PublicKey)
+}
+
+// Sign signs digest with key in slot. Touch policy is enforced by YubiKey, which blocks until touched.
+func (card *pcscCard) Sign(slot Slot, pin string, digest []byte) ([]byte, error) {
+	pivSlot, ok := pivSlots[slot]
+	if !ok {
+		return nil, ErrInvalidSlot
+	}
+
+	card.mutex.Lock()
+	defer card.mutex.Unlock()
+
+	cert, err := card.yubiKey.Attest(pivSlot)
+	if err != nil {
+		return nil, err
+	}
+
+	// PIN policy is inferred from attestation certificate if it is not known
+	pri, err := card.yubiKey.PrivateKey(pivSlot, cert.PublicKey, piv.KeyAuth{
+		PIN:       pin,
+		PINPolicy: card.policies[slot],
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	signer, ok := pri.(crypto.Signer)
+	if !ok {
+		return nil, ErrCurveNotSupported
+	}
+
+	signature, err := signer.Sign(rand.Reader, digest, nil)
+	if errors.As(err, new(piv.AuthErr)) {
+		return nil, ErrWrongPIN
+	}
+
+	return signature, err
+}
+
+func (card *pcscCard) Close() error {
+	card.mutex.Lock()
+	defer card.mutex.Unlock()
+
+	return card.yubiKey.Close()
+}
+
+func toECDSAPublicKey(pub crypto.PublicKey) (*ecdsa.PublicKey, error) {
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrCurveNotSupported
+	}
+
+	return ecdsaPub, nil
+}
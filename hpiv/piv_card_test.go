@@ -0,0 +1,99 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hpiv_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+
+	"github.com/DE-labtory/heimdall/hpiv"
+)
+
+// fakeCard is a fake YubiKey PIV applet which keeps keys in memory for testing.
+type fakeCard struct {
+	managementKey []byte
+	pin           string
+	keys          map[hpiv.Slot]*ecdsa.PrivateKey
+	policies      map[hpiv.Slot]hpiv.KeyPolicy
+	pinVerified   bool
+	touched       bool
+}
+
+func newFakeCard() *fakeCard {
+	return &fakeCard{
+		managementKey: hpiv.DefaultManagementKey,
+		pin:           "123456",
+		keys:          make(map[hpiv.Slot]*ecdsa.PrivateKey),
+		policies:      make(map[hpiv.Slot]hpiv.KeyPolicy),
+		touched:       true,
+	}
+}
+
+func (card *fakeCard) GenerateKey(managementKey []byte, slot hpiv.Slot, curve elliptic.Curve, policy hpiv.KeyPolicy) (*ecdsa.PublicKey, error) {
+	if !bytes.Equal(card.managementKey, managementKey) {
+		return nil, errors.New("wrong management key")
+	}
+
+	pri, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	card.keys[slot] = pri
+	card.policies[slot] = policy
+
+	return &pri.PublicKey, nil
+}
+
+func (card *fakeCard) PublicKey(slot hpiv.Slot) (*ecdsa.PublicKey, error) {
+	pri, exists := card.keys[slot]
+	if !exists {
+		return nil, errors.New("slot is empty")
+	}
+
+	return &pri.PublicKey, nil
+}
+
+func (card *fakeCard) Sign(slot hpiv.Slot, pin string, digest []byte) ([]byte, error) {
+	pri, exists := card.keys[slot]
+	if !exists {
+		return nil, errors.New("slot is empty")
+	}
+
+	policy := card.policies[slot]
+	switch policy.PIN {
+	case hpiv.PINPolicyAlways:
+		if pin != card.pin {
+			return nil, hpiv.ErrWrongPIN
+		}
+	case hpiv.PINPolicyOnce:
+		if !card.pinVerified && pin != card.pin {
+			return nil, hpiv.ErrWrongPIN
+		}
+		card.pinVerified = true
+	}
+
+	if policy.Touch != hpiv.TouchPolicyNever && !card.touched {
+		return nil, hpiv.ErrTouchTimeout
+	}
+
+	return pri.Sign(rand.Reader, digest, nil)
+}
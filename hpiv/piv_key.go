@@ -0,0 +1,158 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides keys which are generated and used on YubiKey PIV applet.
+
+package hpiv
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"io"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrKeyGenOptNotSupported = errors.New("key generation option not supported - PIV key should be ECDSA P-256 or P-384 key")
+var ErrPublicKeyNotSupported = errors.New("PIV key can not be recovered from public key bytes, use hecdsa.KeyRecoverer")
+
+// PINPrompt asks PIN of YubiKey to operator when signing. (nil if PIN policy of the key is never)
+type PINPrompt func() (string, error)
+
+// GenerateKey generates ECDSA key in slot of the card with PIN and touch policy.
+func GenerateKey(card Card, managementKey []byte, slot Slot, keyGenOpt heimdall.KeyGenOpts, policy KeyPolicy, prompt PINPrompt) (heimdall.PriKey, error) {
+	if err := ValidSlot(slot); err != nil {
+		return nil, err
+	}
+
+	ecdsaKeyGenOpt, err := hecdsa.ToKeyGenOpt(keyGenOpt)
+	if err != nil || (ecdsaKeyGenOpt.Curve != elliptic.P256() && ecdsaKeyGenOpt.Curve != elliptic.P384()) {
+		return nil, ErrKeyGenOptNotSupported
+	}
+
+	pub, err := card.GenerateKey(managementKey, slot, ecdsaKeyGenOpt.Curve, policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriKey{
+		card:   card,
+		slot:   slot,
+		pub:    pub,
+		prompt: prompt,
+	}, nil
+}
+
+// OpenKey opens key already generated in slot of the card.
+func OpenKey(card Card, slot Slot, prompt PINPrompt) (heimdall.PriKey, error) {
+	if err := ValidSlot(slot); err != nil {
+		return nil, err
+	}
+
+	pub, err := card.PublicKey(slot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriKey{
+		card:   card,
+		slot:   slot,
+		pub:    pub,
+		prompt: prompt,
+	}, nil
+}
+
+// PriKey is an implementation of heimdall PriKey whose private part never leaves YubiKey.
+type PriKey struct {
+	card   Card
+	slot   Slot
+	pub    *ecdsa.PublicKey
+	prompt PINPrompt
+}
+
+func (priKey *PriKey) ID() heimdall.KeyID {
+	return priKey.PublicKey().ID()
+}
+
+func (priKey *PriKey) SKI() []byte {
+	return priKey.PublicKey().SKI()
+}
+
+// ToByte returns slot of the key, which is only usable with the YubiKey holding the key.
+func (priKey *PriKey) ToByte() ([]byte, error) {
+	return []byte{byte(priKey.slot)}, nil
+}
+
+func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return priKey.PublicKey().KeyGenOpt()
+}
+
+func (priKey *PriKey) IsPrivate() bool {
+	return true
+}
+
+// PublicKey returns ECDSA public key, so signatures can be verified by hecdsa.
+func (priKey *PriKey) PublicKey() heimdall.PubKey {
+	return hecdsa.NewPubKey(priKey.pub)
+}
+
+// Clear does nothing because private part of the key is never in memory.
+func (priKey *PriKey) Clear() {}
+
+// Slot returns PIV slot of the key.
+func (priKey *PriKey) Slot() Slot {
+	return priKey.slot
+}
+
+// Public implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Public() crypto.PublicKey {
+	return priKey.pub
+}
+
+// Sign implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	pin := ""
+	if priKey.prompt != nil {
+		var err error
+		if pin, err = priKey.prompt(); err != nil {
+			return nil, err
+		}
+	}
+
+	return priKey.card.Sign(priKey.slot, pin, digest)
+}
+
+// KeyRecoverer recovers PIV key from its slot.
+type KeyRecoverer struct {
+	Card      Card
+	PINPrompt PINPrompt
+}
+
+func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	if !isPrivate {
+		return nil, ErrPublicKeyNotSupported
+	}
+
+	if len(keyBytes) != 1 {
+		return nil, ErrInvalidSlot
+	}
+
+	return OpenKey(recoverer.Card, Slot(keyBytes[0]), recoverer.PINPrompt)
+}
@@ -0,0 +1,67 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hpiv_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hpiv"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateKey(t *testing.T) {
+	// given
+	card := newFakeCard()
+	p256, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	p521, err := hecdsa.NewKeyGenOpt(hecdsa.ECP521)
+	assert.NoError(t, err)
+
+	// when
+	pri, err := hpiv.GenerateKey(card, hpiv.DefaultManagementKey, hpiv.SlotSignature, p256, hpiv.KeyPolicy{}, nil)
+	_, curveErr := hpiv.GenerateKey(card, hpiv.DefaultManagementKey, hpiv.SlotSignature, p521, hpiv.KeyPolicy{}, nil)
+	_, slotErr := hpiv.GenerateKey(card, hpiv.DefaultManagementKey, hpiv.Slot(0x01), p256, hpiv.KeyPolicy{}, nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hecdsa.ECP256, pri.KeyGenOpt().ToString())
+	assert.Equal(t, hpiv.ErrKeyGenOptNotSupported, curveErr)
+	assert.Equal(t, hpiv.ErrInvalidSlot, slotErr)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
+	// given
+	card := newFakeCard()
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hpiv.GenerateKey(card, hpiv.DefaultManagementKey, hpiv.SlotAuthentication, keyGenOpt, hpiv.KeyPolicy{}, nil)
+	assert.NoError(t, err)
+	keyBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+
+	recoverer := &hpiv.KeyRecoverer{Card: card}
+
+	// when
+	key, err := recoverer.RecoverKeyFromByte(keyBytes, true)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.Equal(t, hpiv.SlotAuthentication, key.(*hpiv.PriKey).Slot())
+}
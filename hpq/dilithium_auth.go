@@ -0,0 +1,109 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Dilithium signing and verifying related functions.
+
+package hpq
+
+import (
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature - length of signature does not match Dilithium mode of the key")
+
+// Sign generates signature for a data using private key.
+func Sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	// remove private key from memory.
+	defer pri.Clear()
+
+	return sign(pri, message, opts)
+}
+
+// sign generates signature without clearing private key, for signers holding the key for several signatures.
+// Legacy signature of Dilithium is the bare signature of the mode, with no ASN.1 encoding.
+func sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	dilithiumPri, ok := pri.(*PriKey)
+	if !ok {
+		return nil, ErrNotDilithiumPriKey
+	}
+
+	if err := heimdall.CheckKeyUsage(pri, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	if err := checkScheme(pri, opts); err != nil {
+		return nil, err
+	}
+
+	signature := dilithiumPri.opt.dilithiumMode().Sign(dilithiumPri.internalPriKey(), message)
+
+	event.Publish(event.MessageSigned, pri.ID(), "")
+
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+}
+
+// checkScheme checks if signer option is valid for the key and the key algorithm is permitted by algorithm policy.
+func checkScheme(key heimdall.Key, opts heimdall.SignerOpts) error {
+	if err := heimdall.SchemeParamsOf(opts).Validate(key.KeyGenOpt()); err != nil {
+		return err
+	}
+
+	return heimdall.CheckKeyAlgorithm(key.KeyGenOpt())
+}
+
+// decodeSignature returns bare signature of legacy (signature of the mode) or versioned (version byte and signature) signature.
+func decodeSignature(opt *KeyGenOpt, signature []byte) ([]byte, error) {
+	signatureSize := opt.dilithiumMode().SignatureSize()
+	if len(signature) == signatureSize {
+		return signature, nil
+	}
+
+	if len(signature) != signatureSize+1 {
+		return nil, ErrInvalidSignature
+	}
+
+	_, signature, err := heimdall.DecodeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// Verify verifies the signature using pubKey(public key) and original message, then returns boolean value.
+// Signature of any supported version is accepted regardless of version in signer option.
+func Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	dilithiumPub, ok := pub.(*PubKey)
+	if !ok {
+		return false, ErrNotDilithiumPubKey
+	}
+
+	if err := checkScheme(pub, opts); err != nil {
+		return false, err
+	}
+
+	signature, err := decodeSignature(dilithiumPub.opt, signature)
+	if err != nil {
+		return false, err
+	}
+
+	valid := dilithiumPub.opt.dilithiumMode().Verify(dilithiumPub.internalPubKey, message, signature)
+	return valid, nil
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hpq_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hpq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	pub := pri.PublicKey()
+	message := []byte("hello")
+
+	// when
+	signature, err := hpq.Sign(pri, message, hpq.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hpq.Verify(pub, signature, message, hpq.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hpq.Verify(pub, signature, []byte("world"), hpq.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerify_SignatureVersion(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer := hpq.NewSigner(pri)
+	message := []byte("hello")
+
+	legacySignature, err := signer.Sign(message, hpq.NewSignerOpts())
+	assert.NoError(t, err)
+	signature, err := signer.Sign(message, hpq.NewSignerOpts().WithSignatureVersion(heimdall.SignatureVersion1))
+	assert.NoError(t, err)
+
+	// when
+	legacyValid, legacyErr := hpq.NewVerifier().Verify(pri.PublicKey(), legacySignature, message, hpq.NewSignerOpts())
+	valid, err := hpq.NewVerifier().Verify(pri.PublicKey(), signature, message, hpq.NewSignerOpts())
+	_, truncatedErr := hpq.Verify(pri.PublicKey(), signature[:100], message, hpq.NewSignerOpts())
+
+	// then
+	assert.Equal(t, len(legacySignature)+1, len(signature))
+	assert.Equal(t, heimdall.SignatureVersion1, signature[0])
+	assert.NoError(t, legacyErr)
+	assert.True(t, legacyValid)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, hpq.ErrInvalidSignature, truncatedErr)
+}
+
+func TestVerify_OtherMode(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	keyGenOpt, err := hpq.NewKeyGenOpt(hpq.DILITHIUM2)
+	assert.NoError(t, err)
+	otherPri, err := hpq.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	message := []byte("hello")
+
+	signature, err := hpq.NewSigner(otherPri).Sign(message, hpq.NewSignerOpts())
+	assert.NoError(t, err)
+
+	// when
+	_, err = hpq.Verify(pri.PublicKey(), signature, message, hpq.NewSignerOpts())
+
+	// then
+	assert.Equal(t, hpq.ErrInvalidSignature, err)
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Dilithium key related functions.
+// Keys are stored in packed form of the Dilithium specification, whose length identifies the mode.
+
+package hpq
+
+import (
+	"crypto/rand"
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/cloudflare/circl/sign/dilithium"
+)
+
+var ErrKeyType = errors.New("invalid key type - key type should be heimdall.PRIVATEKEY or heimdall.PUBLICKEY")
+var ErrNotDilithiumPriKey = errors.New("invalid private key - key is not Dilithium private key")
+var ErrNotDilithiumPubKey = errors.New("invalid public key - key is not Dilithium public key")
+
+func GenerateKey(keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	opt, err := ToKeyGenOpt(keyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(opt); err != nil {
+		return nil, err
+	}
+
+	pub, pri, err := opt.dilithiumMode().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &PriKey{
+		opt:    opt,
+		packed: pri.Bytes(),
+		pub:    &PubKey{opt: opt, internalPubKey: pub},
+	}
+	event.Publish(event.KeyCreated, key.ID(), "")
+
+	return key, nil
+}
+
+// PriKey is an implementation of heimdall PriKey for using Dilithium private key.
+// The key is held in packed form, so that Clear() can remove it from memory, and is unpacked for each signature.
+type PriKey struct {
+	heimdall.KeyUsage
+	opt    *KeyGenOpt
+	packed []byte
+	pub    *PubKey
+}
+
+func (priKey *PriKey) ID() heimdall.KeyID {
+	return priKey.pub.ID()
+}
+
+func (priKey *PriKey) SKI() []byte {
+	return priKey.pub.SKI()
+}
+
+func (priKey *PriKey) ToByte() ([]byte, error) {
+	keyBytes := make([]byte, len(priKey.packed))
+	copy(keyBytes, priKey.packed)
+
+	return keyBytes, nil
+}
+
+func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return priKey.opt
+}
+
+func (priKey *PriKey) IsPrivate() bool {
+	return true
+}
+
+func (priKey *PriKey) PublicKey() heimdall.PubKey {
+	return priKey.pub
+}
+
+// SetUsagePolicy sets usage policy of the key and of its public key, which is shared with the key.
+func (priKey *PriKey) SetUsagePolicy(policy *heimdall.KeyUsagePolicy) {
+	priKey.KeyUsage.SetUsagePolicy(policy)
+	priKey.pub.SetUsagePolicy(policy)
+}
+
+func (priKey *PriKey) Clear() {
+	// clear packed private key to 0
+	for i := range priKey.packed {
+		priKey.packed[i] = 0
+	}
+}
+
+// internalPriKey unpacks private key for signing.
+func (priKey *PriKey) internalPriKey() dilithium.PrivateKey {
+	return priKey.opt.dilithiumMode().PrivateKeyFromBytes(priKey.packed)
+}
+
+// PubKey is an implementation of heimdall PubKey for using Dilithium public key
+type PubKey struct {
+	heimdall.KeyUsage
+	opt            *KeyGenOpt
+	internalPubKey dilithium.PublicKey
+}
+
+func (pubKey *PubKey) ID() heimdall.KeyID {
+	// return base58 encoded ski with algorithm prefix (ex. DILITHIUM3x...)
+	keyId, err := heimdall.MakeKeyID(pubKey.opt, pubKey.SKI())
+	if err != nil {
+		return heimdall.SKIToKeyID(pubKey.SKI())
+	}
+
+	return keyId
+}
+
+func (pubKey *PubKey) SKI() []byte {
+	return heimdall.MakeSKI(pubKey.SKIMaterial())
+}
+
+// SKIMaterial returns packed public key, which SKI is hashed from.
+func (pubKey *PubKey) SKIMaterial() []byte {
+	return pubKey.internalPubKey.Bytes()
+}
+
+func (pubKey *PubKey) ToByte() ([]byte, error) {
+	return pubKey.internalPubKey.Bytes(), nil
+}
+
+func (pubKey *PubKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return pubKey.opt
+}
+
+func (pubKey *PubKey) IsPrivate() bool {
+	return false
+}
+
+// init registers recoverer of Dilithium keys, so that key files of them are loaded without choosing a recoverer.
+func init() {
+	heimdall.RegisterKeyRecoverer(heimdall.DILITHIUM, &KeyRecoverer{})
+}
+
+type KeyRecoverer struct {
+}
+
+// RecoverKeyFromByte recovers packed private or public key, whose mode is identified by length of the key.
+func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	switch isPrivate {
+	case true:
+		for mode, dilithiumMode := range dilithiumModes {
+			if len(keyBytes) != dilithiumMode.PrivateKeySize() {
+				continue
+			}
+
+			opt := &KeyGenOpt{mode: mode}
+			internalPriKey := dilithiumMode.PrivateKeyFromBytes(keyBytes)
+			packed := make([]byte, len(keyBytes))
+			copy(packed, keyBytes)
+
+			return &PriKey{
+				opt:    opt,
+				packed: packed,
+				pub:    &PubKey{opt: opt, internalPubKey: internalPriKey.Public().(dilithium.PublicKey)},
+			}, nil
+		}
+
+		return nil, ErrNotDilithiumPriKey
+
+	case false:
+		for mode, dilithiumMode := range dilithiumModes {
+			if len(keyBytes) != dilithiumMode.PublicKeySize() {
+				continue
+			}
+
+			return &PubKey{
+				opt:            &KeyGenOpt{mode: mode},
+				internalPubKey: dilithiumMode.PublicKeyFromBytes(keyBytes),
+			}, nil
+		}
+
+		return nil, ErrNotDilithiumPubKey
+
+	default:
+		return nil, ErrKeyType
+	}
+}
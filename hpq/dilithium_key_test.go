@@ -0,0 +1,118 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hpq_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hpq"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpPriKey(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hpq.NewKeyGenOpt(hpq.DILITHIUM3)
+	assert.NoError(t, err)
+	pri, err := hpq.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func TestGenerateKey(t *testing.T) {
+	for _, strOpt := range []string{hpq.DILITHIUM2, "dilithium3", hpq.DILITHIUM5} {
+		// given
+		keyGenOpt, err := hpq.NewKeyGenOpt(strOpt)
+		assert.NoError(t, err)
+
+		// when
+		pri, err := hpq.GenerateKey(keyGenOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.True(t, pri.IsPrivate())
+		assert.Equal(t, strings.ToUpper(strOpt), pri.KeyGenOpt().ToString())
+	}
+}
+
+func TestNewKeyGenOpt_NotSupported(t *testing.T) {
+	for _, strOpt := range []string{"DILITHIUM4", "DILITHIUM", "BLS12381"} {
+		// when
+		_, err := hpq.NewKeyGenOpt(strOpt)
+
+		// then
+		assert.Equal(t, hpq.ErrKeyGenOptNotSupported, err, strOpt)
+	}
+}
+
+func TestPriKey_ID(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+
+	// when
+	keyId := pri.ID()
+
+	// then
+	assert.True(t, strings.HasPrefix(keyId, "DILITHIUM3x"))
+	assert.Equal(t, pri.PublicKey().ID(), keyId)
+
+	info, err := heimdall.ParseKeyID(keyId)
+	assert.NoError(t, err)
+	assert.Equal(t, &heimdall.KeyType{Family: heimdall.DILITHIUM, BitLen: 3}, info.KeyType)
+	assert.Equal(t, pri.SKI(), info.SKI)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	recoverer := &hpq.KeyRecoverer{}
+
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+
+	// when
+	recoveredPri, priErr := recoverer.RecoverKeyFromByte(priBytes, true)
+	recoveredPub, pubErr := recoverer.RecoverKeyFromByte(pubBytes, false)
+	_, wrongErr := recoverer.RecoverKeyFromByte([]byte("wrong"), false)
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri.ID(), recoveredPri.ID())
+	assert.Equal(t, pri.ID(), recoveredPub.ID())
+	assert.Equal(t, hpq.DILITHIUM3, recoveredPub.KeyGenOpt().ToString())
+	assert.Equal(t, hpq.ErrNotDilithiumPubKey, wrongErr)
+}
+
+func TestPriKey_Clear(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	keyBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+
+	// when
+	pri.Clear()
+
+	// then
+	clearedBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	assert.Equal(t, make([]byte, len(keyBytes)), clearedBytes)
+}
@@ -0,0 +1,108 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Dilithium option for key generation.
+
+package hpq
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/cloudflare/circl/sign/dilithium"
+)
+
+var ErrKeyGenOptNotSupported = errors.New("key generation option not supported - option should be DILITHIUM2, DILITHIUM3 or DILITHIUM5")
+
+const (
+	DILITHIUM2 = "DILITHIUM2"
+	DILITHIUM3 = "DILITHIUM3"
+	DILITHIUM5 = "DILITHIUM5"
+)
+
+// dilithiumModes maps mode number of key generation option to the parameter set of Dilithium (round 3).
+var dilithiumModes = map[int]dilithium.Mode{
+	2: dilithium.Mode2,
+	3: dilithium.Mode3,
+	5: dilithium.Mode5,
+}
+
+// KeyGenOpt is key generation option of Dilithium, whose mode (2, 3 or 5) selects NIST security category.
+type KeyGenOpt struct {
+	mode int
+}
+
+func NewKeyGenOpt(strOpt string) (*KeyGenOpt, error) {
+	opt := new(KeyGenOpt)
+	return opt, opt.initKeyGenOpt(strOpt)
+}
+
+func (opt *KeyGenOpt) initKeyGenOpt(strOpt string) error {
+	upper := strings.ToUpper(strOpt)
+	if !strings.HasPrefix(upper, heimdall.DILITHIUM) {
+		return ErrKeyGenOptNotSupported
+	}
+
+	mode, err := strconv.Atoi(strings.TrimPrefix(upper, heimdall.DILITHIUM))
+	if err != nil {
+		return ErrKeyGenOptNotSupported
+	}
+
+	if _, ok := dilithiumModes[mode]; !ok {
+		return ErrKeyGenOptNotSupported
+	}
+
+	opt.mode = mode
+	return nil
+}
+
+func (opt *KeyGenOpt) ToString() string {
+	return heimdall.DILITHIUM + strconv.Itoa(opt.mode)
+}
+
+func (opt *KeyGenOpt) KeySize() int {
+	return opt.mode
+}
+
+func (opt *KeyGenOpt) Algorithm() string {
+	return heimdall.DILITHIUM
+}
+
+// Bits returns mode of Dilithium, since Dilithium has neither curve nor modulus.
+func (opt *KeyGenOpt) Bits() int {
+	return opt.mode
+}
+
+// dilithiumMode returns parameter set of the mode.
+func (opt *KeyGenOpt) dilithiumMode() dilithium.Mode {
+	return dilithiumModes[opt.mode]
+}
+
+// ToKeyGenOpt converts Dilithium key generation option of any implementation (ex. heimdall.KeyType) to KeyGenOpt.
+func ToKeyGenOpt(opts heimdall.KeyGenOpts) (*KeyGenOpt, error) {
+	if opt, ok := opts.(*KeyGenOpt); ok {
+		return opt, nil
+	}
+
+	if opts == nil || opts.Algorithm() != heimdall.DILITHIUM {
+		return nil, ErrKeyGenOptNotSupported
+	}
+
+	return NewKeyGenOpt(opts.ToString())
+}
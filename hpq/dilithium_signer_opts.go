@@ -0,0 +1,56 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hpq
+
+import (
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+)
+
+type SignerOpts struct {
+	version *byte
+}
+
+// NewSignerOpts makes signer option of Dilithium, which signs message without hashing it first.
+func NewSignerOpts() *SignerOpts {
+	return &SignerOpts{}
+}
+
+func (signerOpt *SignerOpts) Algorithm() string {
+	return heimdall.DILITHIUM
+}
+
+// HashOpt returns nil, since Dilithium hashes message with SHAKE-256 by itself.
+func (signerOpt *SignerOpts) HashOpt() *hashing.HashOpt {
+	return nil
+}
+
+// WithSignatureVersion sets format version of signatures made with the option. (ex. version negotiated with peer)
+func (signerOpt *SignerOpts) WithSignatureVersion(version byte) *SignerOpts {
+	signerOpt.version = &version
+	return signerOpt
+}
+
+// SignatureVersion returns version set by WithSignatureVersion, or heimdall.DefaultSignatureVersion if not set.
+func (signerOpt *SignerOpts) SignatureVersion() byte {
+	if signerOpt.version == nil {
+		return heimdall.DefaultSignatureVersion
+	}
+
+	return *signerOpt.version
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides Dilithium verifier implementing heimdall Verifier.
+
+package hpq
+
+import (
+	"github.com/DE-labtory/heimdall"
+)
+
+// Verifier is an implementation of heimdall Verifier for Dilithium signatures.
+type Verifier struct {
+}
+
+func NewVerifier() heimdall.Verifier {
+	return &Verifier{}
+}
+
+func (verifier *Verifier) Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	return Verify(pub, signature, message, opts)
+}
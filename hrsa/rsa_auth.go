@@ -0,0 +1,206 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides RSA signing and verifying related functions.
+
+package hrsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hashing"
+)
+
+var ErrHashNotSupported = errors.New("hash not supported - hash function has no identifier for RSA signature")
+var ErrInvalidSignature = errors.New("invalid signature - RSA signature should be as long as modulus, optionally prefixed by version byte")
+
+// rsaHash is identifier of hash function in PKCS #1 v1.5 signature (DigestInfo) and in RSA-PSS signature (MGF1).
+type rsaHash struct {
+	oid  asn1.ObjectIdentifier
+	hash crypto.Hash
+}
+
+// rsaHashes maps hash option names to identifiers. BLAKE2 has no standard identifier for PKCS #1 v1.5 signature,
+// so it can be used only for RSA-PSS signature.
+var rsaHashes = map[string]rsaHash{
+	hashing.SHA224:      {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 5}, crypto.SHA512_224},
+	hashing.SHA256:      {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 6}, crypto.SHA512_256},
+	hashing.SHA384:      {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}, crypto.SHA384},
+	hashing.SHA512:      {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}, crypto.SHA512},
+	hashing.SHA3_256:    {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 8}, crypto.SHA3_256},
+	hashing.SHA3_384:    {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 9}, crypto.SHA3_384},
+	hashing.SHA3_512:    {asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 10}, crypto.SHA3_512},
+	hashing.BLAKE2B_256: {nil, crypto.BLAKE2b_256},
+	hashing.BLAKE2B_384: {nil, crypto.BLAKE2b_384},
+	hashing.BLAKE2B_512: {nil, crypto.BLAKE2b_512},
+	hashing.BLAKE2S_256: {nil, crypto.BLAKE2s_256},
+}
+
+// digestInfo is ASN.1 structure of digest signed by PKCS #1 v1.5 signature. (RFC 8017)
+type digestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+// Sign generates signature for a data using private key.
+func Sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	// remove private key from memory.
+	defer pri.Clear()
+
+	return sign(pri, message, opts)
+}
+
+// sign generates signature without clearing private key, for signers holding the key for several signatures.
+func sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	rsaPri, ok := pri.(*PriKey)
+	if !ok {
+		return nil, ErrNotRSAPriKey
+	}
+
+	if err := heimdall.CheckKeyUsage(pri, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	digest, hash, params, err := digestOf(pri, message, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var signature []byte
+	if params.PSSSaltLength != 0 {
+		signature, err = rsa.SignPSS(rand.Reader, rsaPri.internalPriKey, hash.hash, digest, &rsa.PSSOptions{SaltLength: params.PSSSaltLength})
+	} else {
+		signature, err = rsa.SignPKCS1v15(rand.Reader, rsaPri.internalPriKey, crypto.Hash(0), digest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	event.Publish(event.MessageSigned, pri.ID(), "")
+
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+}
+
+// digestOf hashes message with hash option of signer option, or with default hash option of the key if not specified.
+// For PKCS #1 v1.5 signature, the digest is DER encoded DigestInfo, so that any hash with identifier can be used.
+// Key and hash algorithms denied by algorithm policy are rejected.
+func digestOf(key heimdall.Key, message []byte, opts heimdall.SignerOpts) ([]byte, rsaHash, *heimdall.SchemeParams, error) {
+	params := heimdall.SchemeParamsOf(opts)
+	if err := params.Validate(key.KeyGenOpt()); err != nil {
+		return nil, rsaHash{}, nil, err
+	}
+
+	hashOpt, err := heimdall.HashOptOf(opts, key)
+	if err != nil {
+		return nil, rsaHash{}, nil, err
+	}
+
+	hash, ok := rsaHashes[hashOpt.Name]
+	if !ok || (params.PSSSaltLength == 0 && hash.oid == nil) {
+		return nil, rsaHash{}, nil, ErrHashNotSupported
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(key.KeyGenOpt()); err != nil {
+		return nil, rsaHash{}, nil, err
+	}
+
+	if err := heimdall.CheckHashAlgorithm(hashOpt.Name); err != nil {
+		return nil, rsaHash{}, nil, err
+	}
+
+	digest, err := hashing.Hash(message, hashOpt)
+	if err != nil {
+		return nil, rsaHash{}, nil, err
+	}
+
+	if params.PSSSaltLength != 0 {
+		return digest, hash, params, nil
+	}
+
+	digest, err = asn1.Marshal(digestInfo{
+		Algorithm: pkix.AlgorithmIdentifier{Algorithm: hash.oid, Parameters: asn1.NullRawValue},
+		Digest:    digest,
+	})
+	if err != nil {
+		return nil, rsaHash{}, nil, err
+	}
+
+	return digest, hash, params, nil
+}
+
+// decodeSignature returns bare signature of legacy (modulus length) or versioned (version byte and modulus length) signature.
+func decodeSignature(pub *rsa.PublicKey, signature []byte) ([]byte, error) {
+	if len(signature) == pub.Size() {
+		return signature, nil
+	}
+
+	if len(signature) != pub.Size()+1 {
+		return nil, ErrInvalidSignature
+	}
+
+	_, signature, err := heimdall.DecodeSignature(signature)
+	if err != nil {
+		return nil, err
+	}
+
+	return signature, nil
+}
+
+// Verify verifies the signature using pubKey(public key) and digest of original message, then returns boolean value.
+// Signature of any supported version is accepted regardless of version in signer option.
+func Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	rsaPub, ok := pub.(*PubKey)
+	if !ok {
+		return false, ErrNotRSAPubKey
+	}
+
+	digest, hash, params, err := digestOf(pub, message, opts)
+	if err != nil {
+		return false, err
+	}
+
+	signature, err = decodeSignature(rsaPub.internalPubKey, signature)
+	if err != nil {
+		return false, err
+	}
+
+	if params.PSSSaltLength != 0 {
+		err = rsa.VerifyPSS(rsaPub.internalPubKey, hash.hash, digest, signature, &rsa.PSSOptions{SaltLength: params.PSSSaltLength})
+	} else {
+		err = rsa.VerifyPKCS1v15(rsaPub.internalPubKey, crypto.Hash(0), digest, signature)
+	}
+
+	return err == nil, nil
+}
+
+// VerifyWithCert verify a signature with certificate.
+func VerifyWithCert(cert *x509.Certificate, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	rsaPubKey, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return false, ErrNotRSAPubKey
+	}
+
+	return Verify(NewPubKey(rsaPubKey), signature, message, opts)
+}
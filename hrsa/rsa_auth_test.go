@@ -0,0 +1,169 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hrsa_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha512"
+	"crypto/x509"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	pub := pri.PublicKey()
+	signerOpt := hrsa.NewSignerOpts(nil)
+	message := []byte("hello")
+
+	// when
+	signature, err := hrsa.Sign(pri, message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hrsa.Verify(pub, signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestSign_PKCS1v15(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	internalPub := pri.Public().(*rsa.PublicKey)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	message := []byte("hello")
+
+	// when
+	signature, err := hrsa.NewSigner(pri).Sign(message, hrsa.NewSignerOpts(hashOpt))
+
+	// then
+	assert.NoError(t, err)
+	digest := sha512.Sum384(message)
+	assert.NoError(t, rsa.VerifyPKCS1v15(internalPub, crypto.SHA384, digest[:], signature))
+}
+
+func TestSign_PSS(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	internalPub := pri.Public().(*rsa.PublicKey)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hrsa.NewSignerOpts(hashOpt).WithPSS(48)
+	message := []byte("hello")
+
+	// when
+	signature, err := hrsa.NewSigner(pri).Sign(message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	digest := sha512.Sum384(message)
+	assert.NoError(t, rsa.VerifyPSS(internalPub, crypto.SHA384, digest[:], signature, &rsa.PSSOptions{SaltLength: 48}))
+
+	valid, err := hrsa.NewVerifier().Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// PSS and PKCS #1 v1.5 signatures are not interchangeable
+	valid, err = hrsa.Verify(pri.PublicKey(), signature, message, hrsa.NewSignerOpts(hashOpt))
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestSign_HashNotSupported(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	hashOpt, err := hashing.NewHashOpt(hashing.BLAKE2B_256)
+	assert.NoError(t, err)
+
+	// when
+	_, pkcs1Err := hrsa.NewSigner(pri).Sign([]byte("hello"), hrsa.NewSignerOpts(hashOpt))
+	_, pssErr := hrsa.NewSigner(pri).Sign([]byte("hello"), hrsa.NewSignerOpts(hashOpt).WithPSS(32))
+
+	// then
+	assert.Equal(t, hrsa.ErrHashNotSupported, pkcs1Err)
+	assert.NoError(t, pssErr)
+}
+
+func TestVerify_SignatureVersion(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	message := []byte("hello")
+	signature, err := hrsa.NewSigner(pri).Sign(message, hrsa.NewSignerOpts(nil).WithSignatureVersion(heimdall.SignatureVersion1))
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hrsa.Verify(pri.PublicKey(), signature, message, hrsa.NewSignerOpts(nil))
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, heimdall.SignatureVersion1, signature[0])
+}
+
+func TestVerify_WrongMessage(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signature, err := hrsa.NewSigner(pri).Sign([]byte("hello"), hrsa.NewSignerOpts(nil))
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hrsa.Verify(pri.PublicKey(), signature, []byte("world"), hrsa.NewSignerOpts(nil))
+	_, lengthErr := hrsa.Verify(pri.PublicKey(), signature[1:], []byte("hello"), hrsa.NewSignerOpts(nil))
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, hrsa.ErrInvalidSignature, lengthErr)
+}
+
+func TestVerifyWithCert(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	template := mocks.TestRootCertTemplate
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	template.SerialNumber = big.NewInt(1)
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, pri.Public(), pri)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	message := []byte("hello")
+	signature, err := hrsa.NewSigner(pri).Sign(message, hrsa.NewSignerOpts(nil))
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hrsa.VerifyWithCert(cert, signature, message, hrsa.NewSignerOpts(nil))
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
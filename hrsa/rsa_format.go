@@ -0,0 +1,119 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides conversion of RSA keys between heimdall keys and PEM or DER formats.
+
+package hrsa
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var ErrInvalidPEM = errors.New("invalid PEM - failed to decode PEM block of RSA key")
+
+// PEM block types of RSA keys
+const (
+	pemTypePKCS1PriKey = "RSA PRIVATE KEY"
+	pemTypePKCS8PriKey = "PRIVATE KEY"
+	pemTypePKCS1PubKey = "RSA PUBLIC KEY"
+	pemTypePKIXPubKey  = "PUBLIC KEY"
+)
+
+// PriKeyToPem converts private key to PEM format of PKCS #1.
+func PriKeyToPem(pri heimdall.PriKey) ([]byte, error) {
+	derBytes, err := pri.ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypePKCS1PriKey, Bytes: derBytes}), nil
+}
+
+// PubKeyToPem converts public key to PEM format of PKIX.
+func PubKeyToPem(pub heimdall.PubKey) ([]byte, error) {
+	derBytes, err := pub.ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypePKIXPubKey, Bytes: derBytes}), nil
+}
+
+// PemToPriKey converts PEM formatted private key of PKCS #1 or PKCS #8 to private key.
+func PemToPriKey(pemBytes []byte) (heimdall.PriKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || (block.Type != pemTypePKCS1PriKey && block.Type != pemTypePKCS8PriKey) {
+		return nil, ErrInvalidPEM
+	}
+
+	return DERToPriKey(block.Bytes)
+}
+
+// PemToPubKey converts PEM formatted public key of PKIX or PKCS #1 to public key.
+func PemToPubKey(pemBytes []byte) (heimdall.PubKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || (block.Type != pemTypePKIXPubKey && block.Type != pemTypePKCS1PubKey) {
+		return nil, ErrInvalidPEM
+	}
+
+	return DERToPubKey(block.Bytes)
+}
+
+// DERToPriKey converts DER formatted private key of PKCS #1 or PKCS #8 to private key.
+func DERToPriKey(derBytes []byte) (heimdall.PriKey, error) {
+	internalPriKey, err := x509.ParsePKCS1PrivateKey(derBytes)
+	if err == nil {
+		return NewPriKey(internalPriKey), nil
+	}
+
+	key, pkcs8Err := x509.ParsePKCS8PrivateKey(derBytes)
+	if pkcs8Err != nil {
+		return nil, err
+	}
+
+	rsaPriKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrNotRSAPriKey
+	}
+
+	return NewPriKey(rsaPriKey), nil
+}
+
+// DERToPubKey converts DER formatted public key of PKIX or PKCS #1 to public key.
+func DERToPubKey(derBytes []byte) (heimdall.PubKey, error) {
+	key, err := x509.ParsePKIXPublicKey(derBytes)
+	if err != nil {
+		internalPubKey, pkcs1Err := x509.ParsePKCS1PublicKey(derBytes)
+		if pkcs1Err != nil {
+			return nil, err
+		}
+
+		return NewPubKey(internalPubKey), nil
+	}
+
+	rsaPubKey, ok := key.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrNotRSAPubKey
+	}
+
+	return NewPubKey(rsaPubKey), nil
+}
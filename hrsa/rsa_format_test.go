@@ -0,0 +1,90 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hrsa_test
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriKeyToPem(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+
+	// when
+	priPem, priErr := hrsa.PriKeyToPem(pri)
+	pubPem, pubErr := hrsa.PubKeyToPem(pri.PublicKey())
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+
+	recoveredPri, err := hrsa.PemToPriKey(priPem)
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), recoveredPri.ID())
+
+	recoveredPub, err := hrsa.PemToPubKey(pubPem)
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), recoveredPub.ID())
+}
+
+func TestPemToPriKey_PKCS8(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	internalPri, err := x509.ParsePKCS1PrivateKey(priBytes)
+	assert.NoError(t, err)
+	derBytes, err := x509.MarshalPKCS8PrivateKey(internalPri)
+	assert.NoError(t, err)
+
+	// when
+	recoveredPri, err := hrsa.PemToPriKey(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: derBytes}))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), recoveredPri.ID())
+}
+
+func TestPemToPubKey_PKCS1(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	derBytes := x509.MarshalPKCS1PublicKey(pri.Public().(*rsa.PublicKey))
+
+	// when
+	pub, err := hrsa.PemToPubKey(pem.EncodeToMemory(&pem.Block{Type: "RSA PUBLIC KEY", Bytes: derBytes}))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), pub.ID())
+}
+
+func TestPemToPriKey_InvalidPem(t *testing.T) {
+	// when
+	_, priErr := hrsa.PemToPriKey([]byte("not pem"))
+	_, pubErr := hrsa.PemToPubKey(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: []byte{0x01}}))
+
+	// then
+	assert.Equal(t, hrsa.ErrInvalidPEM, priErr)
+	assert.Equal(t, hrsa.ErrInvalidPEM, pubErr)
+}
@@ -0,0 +1,183 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides RSA key related functions.
+
+package hrsa
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"errors"
+	"io"
+	"math/big"
+	"strconv"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+)
+
+var ErrKeyType = errors.New("invalid key type - key type should be heimdall.PRIVATEKEY or heimdall.PUBLICKEY")
+var ErrNotRSAPriKey = errors.New("invalid private key - key is not RSA private key")
+var ErrNotRSAPubKey = errors.New("invalid public key - key is not RSA public key")
+
+func GenerateKey(keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	opt, err := ToKeyGenOpt(keyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(opt); err != nil {
+		return nil, err
+	}
+
+	pri, err := rsa.GenerateKey(rand.Reader, opt.BitLen)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &PriKey{internalPriKey: pri}
+	event.Publish(event.KeyCreated, key.ID(), "")
+
+	return key, nil
+}
+
+// PriKey is an implementation of heimdall PriKey for using RSA private key
+type PriKey struct {
+	heimdall.KeyUsage
+	internalPriKey *rsa.PrivateKey
+}
+
+func NewPriKey(internalPriKey *rsa.PrivateKey) heimdall.PriKey {
+	return &PriKey{internalPriKey: internalPriKey}
+}
+
+func (priKey *PriKey) ID() heimdall.KeyID {
+	pubKey := PubKey{internalPubKey: &priKey.internalPriKey.PublicKey}
+	return pubKey.ID()
+}
+
+func (priKey *PriKey) SKI() []byte {
+	pubKey := PubKey{internalPubKey: &priKey.internalPriKey.PublicKey}
+	return pubKey.SKI()
+}
+
+func (priKey *PriKey) ToByte() ([]byte, error) {
+	return x509.MarshalPKCS1PrivateKey(priKey.internalPriKey), nil
+}
+
+func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	pubKey := PubKey{internalPubKey: &priKey.internalPriKey.PublicKey}
+	return pubKey.KeyGenOpt()
+}
+
+func (priKey *PriKey) IsPrivate() bool {
+	return true
+}
+
+func (priKey *PriKey) PublicKey() heimdall.PubKey {
+	return &PubKey{KeyUsage: priKey.KeyUsage, internalPubKey: &priKey.internalPriKey.PublicKey}
+}
+
+func (priKey *PriKey) Clear() {
+	// clear private exponent, primes and precomputed values to 0
+	priKey.internalPriKey.D.Set(big.NewInt(0))
+	for _, prime := range priKey.internalPriKey.Primes {
+		prime.Set(big.NewInt(0))
+	}
+	priKey.internalPriKey.Precomputed = rsa.PrecomputedValues{}
+}
+
+// Public implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Public() crypto.PublicKey {
+	return &priKey.internalPriKey.PublicKey
+}
+
+// Sign implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if err := heimdall.CheckKeyUsage(priKey, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	return priKey.internalPriKey.Sign(rand, digest, opts)
+}
+
+// PubKey is an implementation of heimdall PubKey for using RSA public key
+type PubKey struct {
+	heimdall.KeyUsage
+	internalPubKey *rsa.PublicKey
+}
+
+func NewPubKey(internalPubKey *rsa.PublicKey) heimdall.PubKey {
+	return &PubKey{internalPubKey: internalPubKey}
+}
+
+func (pubKey *PubKey) ID() heimdall.KeyID {
+	// return base58 encoded ski with algorithm prefix (ex. RSA2048x...)
+	keyId, err := heimdall.MakeKeyID(&heimdall.KeyType{Family: heimdall.RSA, BitLen: pubKey.internalPubKey.N.BitLen()}, pubKey.SKI())
+	if err != nil {
+		return heimdall.SKIToKeyID(pubKey.SKI())
+	}
+
+	return keyId
+}
+
+func (pubKey *PubKey) SKI() []byte {
+	return heimdall.MakeSKI(pubKey.SKIMaterial())
+}
+
+// SKIMaterial returns PKCS#1 encoded public key, which SKI is hashed from.
+func (pubKey *PubKey) SKIMaterial() []byte {
+	return x509.MarshalPKCS1PublicKey(pubKey.internalPubKey)
+}
+
+func (pubKey *PubKey) ToByte() ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(pubKey.internalPubKey)
+}
+
+func (pubKey *PubKey) KeyGenOpt() heimdall.KeyGenOpts {
+	keyGenOpt, _ := NewKeyGenOpt(heimdall.RSA + strconv.Itoa(pubKey.internalPubKey.N.BitLen()))
+	return keyGenOpt
+}
+
+func (pubKey *PubKey) IsPrivate() bool {
+	return false
+}
+
+// init registers recoverer of RSA keys, so that key files of them are loaded without choosing a recoverer.
+func init() {
+	heimdall.RegisterKeyRecoverer(heimdall.RSA, &KeyRecoverer{})
+}
+
+type KeyRecoverer struct {
+}
+
+// RecoverKeyFromByte recovers private key of PKCS #1 or PKCS #8, or public key of PKIX or PKCS #1.
+func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	switch isPrivate {
+	case true:
+		return DERToPriKey(keyBytes)
+
+	case false:
+		return DERToPubKey(keyBytes)
+
+	default:
+		return nil, ErrKeyType
+	}
+}
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hrsa_test
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpPriKey(t *testing.T) *hrsa.PriKey {
+	keyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA1024)
+	assert.NoError(t, err)
+	pri, err := hrsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri.(*hrsa.PriKey)
+}
+
+func TestGenerateKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA1024)
+	assert.NoError(t, err)
+
+	// when
+	pri, err := hrsa.GenerateKey(keyGenOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hrsa.RSA1024, pri.KeyGenOpt().ToString())
+	assert.NoError(t, heimdall.KeyIDPrefixCheck(pri.ID()))
+	assert.Equal(t, pri.ID(), pri.PublicKey().ID())
+	assert.NoError(t, heimdall.SKIValidCheck(pri.ID(), pri.SKI()))
+}
+
+func TestGenerateKey_WrongAlgorithm(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+
+	// when
+	_, err = hrsa.GenerateKey(keyGenOpt)
+
+	// then
+	assert.Equal(t, hrsa.ErrBitsNotSupported, err)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+	recoverer := &hrsa.KeyRecoverer{}
+
+	// when
+	recoveredPri, priErr := recoverer.RecoverKeyFromByte(priBytes, true)
+	recoveredPub, pubErr := recoverer.RecoverKeyFromByte(pubBytes, false)
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri.ID(), recoveredPri.ID())
+	assert.True(t, recoveredPri.IsPrivate())
+	assert.Equal(t, pri.ID(), recoveredPub.ID())
+	assert.False(t, recoveredPub.IsPrivate())
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte_NotRSA(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	ecPri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	pubBytes, err := ecPri.PublicKey().ToByte()
+	assert.NoError(t, err)
+
+	// when
+	_, err = (&hrsa.KeyRecoverer{}).RecoverKeyFromByte(pubBytes, false)
+
+	// then
+	assert.Equal(t, hrsa.ErrNotRSAPubKey, err)
+}
+
+func TestPriKey_Sign(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	digest := sha256.Sum256([]byte("message"))
+
+	// when
+	signature, err := pri.Sign(rand.Reader, digest[:], crypto.SHA256)
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, rsa.VerifyPKCS1v15(pri.Public().(*rsa.PublicKey), crypto.SHA256, digest[:], signature))
+}
+
+func TestPriKey_Clear(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	keyId := pri.ID()
+
+	// when
+	pri.Clear()
+
+	// then
+	assert.Equal(t, keyId, pri.ID())
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	_, err = (&hrsa.KeyRecoverer{}).RecoverKeyFromByte(priBytes, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,91 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides RSA modulus size options for key generation.
+
+package hrsa
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var ErrBitsNotSupported = errors.New("RSA modulus bit length not supported")
+
+const (
+	RSA1024 = "RSA1024"
+	RSA2048 = "RSA2048"
+	RSA3072 = "RSA3072"
+	RSA4096 = "RSA4096"
+)
+
+type KeyGenOpt struct {
+	BitLen int
+}
+
+func NewKeyGenOpt(strBits string) (*KeyGenOpt, error) {
+	opt := new(KeyGenOpt)
+	return opt, opt.initKeyGenOpt(strBits)
+}
+
+func (opt *KeyGenOpt) initKeyGenOpt(strBits string) error {
+	switch strBits {
+	case RSA1024:
+		opt.BitLen = 1024
+	case RSA2048:
+		opt.BitLen = 2048
+	case RSA3072:
+		opt.BitLen = 3072
+	case RSA4096:
+		opt.BitLen = 4096
+	default:
+		return ErrBitsNotSupported
+	}
+
+	return nil
+}
+
+func (opt *KeyGenOpt) ToString() string {
+	return heimdall.RSA + strconv.Itoa(opt.BitLen)
+}
+
+func (opt *KeyGenOpt) KeySize() int {
+	return opt.BitLen
+}
+
+func (opt *KeyGenOpt) Algorithm() string {
+	return heimdall.RSA
+}
+
+func (opt *KeyGenOpt) Bits() int {
+	return opt.BitLen
+}
+
+// ToKeyGenOpt converts RSA key generation option of any implementation (ex. heimdall.KeyType) to KeyGenOpt.
+func ToKeyGenOpt(opts heimdall.KeyGenOpts) (*KeyGenOpt, error) {
+	if opt, ok := opts.(*KeyGenOpt); ok {
+		return opt, nil
+	}
+
+	if opts == nil || opts.Algorithm() != heimdall.RSA {
+		return nil, ErrBitsNotSupported
+	}
+
+	return NewKeyGenOpt(opts.ToString())
+}
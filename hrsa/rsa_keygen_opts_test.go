@@ -0,0 +1,65 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hrsa_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKeyGenOpt(t *testing.T) {
+	// given
+	inputStrFmtOpt := hrsa.RSA2048
+
+	// when
+	keyGenOpt, err := hrsa.NewKeyGenOpt(inputStrFmtOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, inputStrFmtOpt, keyGenOpt.ToString())
+	assert.Equal(t, 2048, keyGenOpt.KeySize())
+	assert.Equal(t, heimdall.RSA, keyGenOpt.Algorithm())
+}
+
+func TestNewKeyGenOpt_NotSupported(t *testing.T) {
+	// when
+	_, err := hrsa.NewKeyGenOpt("RSA512")
+
+	// then
+	assert.Equal(t, hrsa.ErrBitsNotSupported, err)
+}
+
+func TestToKeyGenOpt(t *testing.T) {
+	// given
+	keyType, err := heimdall.ParseKeyType("RSA_4096")
+	assert.NoError(t, err)
+	ecKeyType, err := heimdall.ParseKeyType("ECDSA_P-256")
+	assert.NoError(t, err)
+
+	// when
+	opt, err := hrsa.ToKeyGenOpt(keyType)
+	_, ecErr := hrsa.ToKeyGenOpt(ecKeyType)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 4096, opt.BitLen)
+	assert.Equal(t, hrsa.ErrBitsNotSupported, ecErr)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hrsa
+
+import (
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+)
+
+type SignerOpts struct {
+	hashOpt    *hashing.HashOpt
+	saltLength int
+	version    *byte
+}
+
+// NewSignerOpts makes signer option of PKCS #1 v1.5 signature with hash option.
+// If hash option is nil, hash is selected by the key's modulus size. (ex. SHA256 for RSA2048)
+func NewSignerOpts(hashOpt *hashing.HashOpt) *SignerOpts {
+	return &SignerOpts{
+		hashOpt: hashOpt,
+	}
+}
+
+func (signerOpt *SignerOpts) Algorithm() string {
+	return heimdall.RSA
+}
+
+func (signerOpt *SignerOpts) HashOpt() *hashing.HashOpt {
+	return signerOpt.hashOpt
+}
+
+// WithPSS selects RSA-PSS signature with salt length in bytes, which is usually the digest size.
+func (signerOpt *SignerOpts) WithPSS(saltLength int) *SignerOpts {
+	signerOpt.saltLength = saltLength
+	return signerOpt
+}
+
+func (signerOpt *SignerOpts) SchemeParams() *heimdall.SchemeParams {
+	return &heimdall.SchemeParams{PSSSaltLength: signerOpt.saltLength}
+}
+
+// WithSignatureVersion sets format version of signatures made with the option. (ex. version negotiated with peer)
+func (signerOpt *SignerOpts) WithSignatureVersion(version byte) *SignerOpts {
+	signerOpt.version = &version
+	return signerOpt
+}
+
+// SignatureVersion returns version set by WithSignatureVersion, or heimdall.DefaultSignatureVersion if not set.
+func (signerOpt *SignerOpts) SignatureVersion() byte {
+	if signerOpt.version == nil {
+		return heimdall.DefaultSignatureVersion
+	}
+
+	return *signerOpt.version
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides SM2 signing and verifying related functions.
+
+package hsm2
+
+import (
+	"crypto/rand"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/tjfoc/gmsm/sm2"
+)
+
+var ErrInvalidSignature = errors.New("invalid signature - SM2 signature should be ASN.1 sequence of positive R and S")
+
+// UIDSignerOpts is implemented by signer options which set user ID of signer.
+type UIDSignerOpts interface {
+	UID() []byte
+}
+
+// sm2Signature contains SM2 signature components, encoded in ASN.1 as ECDSA signature. (GM/T 0009)
+type sm2Signature struct {
+	R, S *big.Int
+}
+
+// uidOf returns user ID of signer option, or DefaultUID if the option does not set it.
+func uidOf(opts heimdall.SignerOpts) []byte {
+	if uidOpts, ok := opts.(UIDSignerOpts); ok {
+		return uidOpts.UID()
+	}
+
+	return DefaultUID
+}
+
+// Sign generates signature for a data using private key.
+func Sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	// remove private key from memory.
+	defer pri.Clear()
+
+	return sign(pri, message, opts)
+}
+
+// sign generates signature without clearing private key, for signers holding the key for several signatures.
+func sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	sm2Pri, ok := pri.(*PriKey)
+	if !ok {
+		return nil, ErrNotSM2PriKey
+	}
+
+	if err := heimdall.CheckKeyUsage(pri, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	if err := checkScheme(pri, opts); err != nil {
+		return nil, err
+	}
+
+	r, s, err := sm2.Sm2Sign(sm2Pri.internalPriKey, message, uidOf(opts), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := asn1.Marshal(sm2Signature{r, s})
+	if err != nil {
+		return nil, err
+	}
+
+	event.Publish(event.MessageSigned, pri.ID(), "")
+
+	return heimdall.EncodeSignature(heimdall.SignatureVersionOf(opts), signature)
+}
+
+// checkScheme checks if signer option is valid for the key, and the key algorithm and SM3 are permitted by algorithm policy.
+func checkScheme(key heimdall.Key, opts heimdall.SignerOpts) error {
+	if err := heimdall.SchemeParamsOf(opts).Validate(key.KeyGenOpt()); err != nil {
+		return err
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(key.KeyGenOpt()); err != nil {
+		return err
+	}
+
+	return heimdall.CheckHashAlgorithm(hashing.SM3)
+}
+
+// Verify verifies the signature using pubKey(public key) and original message, then returns boolean value.
+// Signature of any supported version is accepted regardless of version in signer option.
+func Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	sm2Pub, ok := pub.(*PubKey)
+	if !ok {
+		return false, ErrNotSM2PubKey
+	}
+
+	if err := checkScheme(pub, opts); err != nil {
+		return false, err
+	}
+
+	_, signature, err := heimdall.DecodeSignature(signature)
+	if err != nil {
+		return false, err
+	}
+
+	sig := new(sm2Signature)
+	rest, err := asn1.Unmarshal(signature, sig)
+	if err != nil || len(rest) != 0 || sig.R == nil || sig.S == nil || sig.R.Sign() != 1 || sig.S.Sign() != 1 {
+		return false, ErrInvalidSignature
+	}
+
+	valid := sm2.Sm2Verify(sm2Pub.internalPubKey, message, uidOf(opts), sig.R, sig.S)
+	return valid, nil
+}
@@ -0,0 +1,103 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hsm2_test
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hsm2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	pub := pri.PublicKey()
+	message := []byte("hello")
+
+	// when
+	signature, err := hsm2.Sign(pri, message, hsm2.NewSignerOpts())
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hsm2.Verify(pub, signature, message, hsm2.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	valid, err = hsm2.Verify(pub, signature, []byte("world"), hsm2.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestSign_UID(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	signer := hsm2.NewSigner(pri)
+	signerOpt := hsm2.NewSignerOpts().WithUID([]byte("peer@it-chain"))
+	message := []byte("hello")
+
+	// when
+	signature, err := signer.Sign(message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hsm2.NewVerifier().Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// signature is bound to user ID
+	valid, err = hsm2.Verify(pri.PublicKey(), signature, message, hsm2.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestSign_CryptoSigner(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	message := []byte("hello")
+
+	// when
+	signature, err := pri.(*hsm2.PriKey).Sign(rand.Reader, message, nil)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hsm2.Verify(pri.PublicKey(), signature, message, hsm2.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestVerify_SignatureVersion(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	message := []byte("hello")
+
+	signature, err := hsm2.NewSigner(pri).Sign(message, hsm2.NewSignerOpts().WithSignatureVersion(heimdall.SignatureVersion1))
+	assert.NoError(t, err)
+
+	// when
+	valid, err := hsm2.Verify(pri.PublicKey(), signature, message, hsm2.NewSignerOpts())
+	_, garbageErr := hsm2.Verify(pri.PublicKey(), append(signature, 0), message, hsm2.NewSignerOpts())
+
+	// then
+	assert.Equal(t, heimdall.SignatureVersion1, signature[0])
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Equal(t, hsm2.ErrInvalidSignature, garbageErr)
+}
@@ -0,0 +1,187 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides SM2 key related functions.
+
+package hsm2
+
+import (
+	"crypto"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/tjfoc/gmsm/sm2"
+	gmx509 "github.com/tjfoc/gmsm/x509"
+)
+
+var ErrKeyType = errors.New("invalid key type - key type should be heimdall.PRIVATEKEY or heimdall.PUBLICKEY")
+var ErrNotSM2PriKey = errors.New("invalid private key - key is not SM2 private key")
+var ErrNotSM2PubKey = errors.New("invalid public key - key is not SM2 public key")
+
+func GenerateKey(keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	opt, err := ToKeyGenOpt(keyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(opt); err != nil {
+		return nil, err
+	}
+
+	pri, err := sm2.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &PriKey{internalPriKey: pri}
+	event.Publish(event.KeyCreated, key.ID(), "")
+
+	return key, nil
+}
+
+// PriKey is an implementation of heimdall PriKey for using SM2 private key
+type PriKey struct {
+	heimdall.KeyUsage
+	internalPriKey *sm2.PrivateKey
+}
+
+func NewPriKey(internalPriKey *sm2.PrivateKey) heimdall.PriKey {
+	return &PriKey{internalPriKey: internalPriKey}
+}
+
+func (priKey *PriKey) ID() heimdall.KeyID {
+	return priKey.PublicKey().ID()
+}
+
+func (priKey *PriKey) SKI() []byte {
+	return priKey.PublicKey().SKI()
+}
+
+// ToByte returns unencrypted PKCS #8 encoding of private key, whose curve is identified by OID of SM2.
+func (priKey *PriKey) ToByte() ([]byte, error) {
+	return gmx509.MarshalSm2UnecryptedPrivateKey(priKey.internalPriKey)
+}
+
+func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return &KeyGenOpt{}
+}
+
+func (priKey *PriKey) IsPrivate() bool {
+	return true
+}
+
+func (priKey *PriKey) PublicKey() heimdall.PubKey {
+	return &PubKey{KeyUsage: priKey.KeyUsage, internalPubKey: &priKey.internalPriKey.PublicKey}
+}
+
+func (priKey *PriKey) Clear() {
+	// clear private key's D value to 0
+	priKey.internalPriKey.D.Set(big.NewInt(0))
+}
+
+// Public implements crypto.Signer, so the key can be used for x509 functions of SM2 certificates.
+func (priKey *PriKey) Public() crypto.PublicKey {
+	return &priKey.internalPriKey.PublicKey
+}
+
+// Sign implements crypto.Signer, signing message with SM3 digest and default user ID.
+func (priKey *PriKey) Sign(rand io.Reader, message []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if err := heimdall.CheckKeyUsage(priKey, heimdall.PurposeSign); err != nil {
+		return nil, err
+	}
+
+	return priKey.internalPriKey.Sign(rand, message, opts)
+}
+
+// PubKey is an implementation of heimdall PubKey for using SM2 public key
+type PubKey struct {
+	heimdall.KeyUsage
+	internalPubKey *sm2.PublicKey
+}
+
+func NewPubKey(internalPubKey *sm2.PublicKey) heimdall.PubKey {
+	return &PubKey{internalPubKey: internalPubKey}
+}
+
+func (pubKey *PubKey) ID() heimdall.KeyID {
+	// return base58 encoded ski with algorithm prefix (ex. SM2x...)
+	keyId, err := heimdall.MakeKeyID(&KeyGenOpt{}, pubKey.SKI())
+	if err != nil {
+		return heimdall.SKIToKeyID(pubKey.SKI())
+	}
+
+	return keyId
+}
+
+func (pubKey *PubKey) SKI() []byte {
+	return heimdall.MakeSKI(pubKey.SKIMaterial())
+}
+
+// SKIMaterial returns uncompressed point of public key, which SKI is hashed from.
+func (pubKey *PubKey) SKIMaterial() []byte {
+	return elliptic.Marshal(pubKey.internalPubKey.Curve, pubKey.internalPubKey.X, pubKey.internalPubKey.Y)
+}
+
+func (pubKey *PubKey) ToByte() ([]byte, error) {
+	return gmx509.MarshalSm2PublicKey(pubKey.internalPubKey)
+}
+
+func (pubKey *PubKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return &KeyGenOpt{}
+}
+
+func (pubKey *PubKey) IsPrivate() bool {
+	return false
+}
+
+// init registers recoverer of SM2 keys, so that key files of them are loaded without choosing a recoverer.
+func init() {
+	heimdall.RegisterKeyRecoverer(heimdall.SM2, &KeyRecoverer{})
+}
+
+type KeyRecoverer struct {
+}
+
+// RecoverKeyFromByte recovers private key of unencrypted PKCS #8 or public key of PKIX, whose algorithm is SM2.
+func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	switch isPrivate {
+	case true:
+		internalPriKey, err := gmx509.ParsePKCS8UnecryptedPrivateKey(keyBytes)
+		if err != nil || internalPriKey.D.Sign() == 0 {
+			return nil, ErrNotSM2PriKey
+		}
+
+		return NewPriKey(internalPriKey), nil
+
+	case false:
+		// point not on the curve is parsed to nil coordinates
+		internalPubKey, err := gmx509.ParseSm2PublicKey(keyBytes)
+		if err != nil || internalPubKey.X == nil {
+			return nil, ErrNotSM2PubKey
+		}
+
+		return NewPubKey(internalPubKey), nil
+
+	default:
+		return nil, ErrKeyType
+	}
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hsm2_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hsm2"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpPriKey(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hsm2.NewKeyGenOpt(hsm2.SM2)
+	assert.NoError(t, err)
+	pri, err := hsm2.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func TestGenerateKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hsm2.NewKeyGenOpt("sm2")
+	assert.NoError(t, err)
+
+	// when
+	pri, err := hsm2.GenerateKey(keyGenOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, pri.IsPrivate())
+	assert.Equal(t, hsm2.SM2, pri.KeyGenOpt().ToString())
+}
+
+func TestNewKeyGenOpt_NotSupported(t *testing.T) {
+	// when
+	_, err := hsm2.NewKeyGenOpt("P-256")
+
+	// then
+	assert.Equal(t, hsm2.ErrKeyGenOptNotSupported, err)
+}
+
+func TestPriKey_ID(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+
+	// when
+	keyId := pri.ID()
+
+	// then
+	assert.True(t, strings.HasPrefix(keyId, "SM2x"))
+	assert.Equal(t, pri.PublicKey().ID(), keyId)
+
+	info, err := heimdall.ParseKeyID(keyId)
+	assert.NoError(t, err)
+	assert.Equal(t, heimdall.SM2, info.KeyType.Family)
+	assert.Equal(t, pri.SKI(), info.SKI)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	recoverer := &hsm2.KeyRecoverer{}
+
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+
+	// when
+	recoveredPri, priErr := recoverer.RecoverKeyFromByte(priBytes, true)
+	recoveredPub, pubErr := recoverer.RecoverKeyFromByte(pubBytes, false)
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri.ID(), recoveredPri.ID())
+	assert.Equal(t, pri.ID(), recoveredPub.ID())
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte_ECDSA(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+
+	// when
+	_, priErr := (&hsm2.KeyRecoverer{}).RecoverKeyFromByte(priBytes, true)
+	_, pubErr := (&hsm2.KeyRecoverer{}).RecoverKeyFromByte(pubBytes, false)
+
+	// then
+	assert.Equal(t, hsm2.ErrNotSM2PriKey, priErr)
+	assert.Equal(t, hsm2.ErrNotSM2PubKey, pubErr)
+}
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hsm2
+
+import (
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+)
+
+// DefaultUID is the user ID of signer used when not specified. (GM/T 0009)
+var DefaultUID = []byte("1234567812345678")
+
+type SignerOpts struct {
+	uid     []byte
+	version *byte
+}
+
+// NewSignerOpts makes signer option of SM2, which signs SM3 digest of user ID, public key and message.
+func NewSignerOpts() *SignerOpts {
+	return &SignerOpts{}
+}
+
+func (signerOpt *SignerOpts) Algorithm() string {
+	return heimdall.SM2
+}
+
+// HashOpt returns nil, since SM2 signature always hashes message with SM3 by itself.
+func (signerOpt *SignerOpts) HashOpt() *hashing.HashOpt {
+	return nil
+}
+
+// WithUID sets user ID of signer, which should be the same for signing and verifying.
+func (signerOpt *SignerOpts) WithUID(uid []byte) *SignerOpts {
+	signerOpt.uid = uid
+	return signerOpt
+}
+
+// UID returns user ID set by WithUID, or DefaultUID if not set.
+func (signerOpt *SignerOpts) UID() []byte {
+	if len(signerOpt.uid) == 0 {
+		return DefaultUID
+	}
+
+	return signerOpt.uid
+}
+
+// WithSignatureVersion sets format version of signatures made with the option. (ex. version negotiated with peer)
+func (signerOpt *SignerOpts) WithSignatureVersion(version byte) *SignerOpts {
+	signerOpt.version = &version
+	return signerOpt
+}
+
+// SignatureVersion returns version set by WithSignatureVersion, or heimdall.DefaultSignatureVersion if not set.
+func (signerOpt *SignerOpts) SignatureVersion() byte {
+	if signerOpt.version == nil {
+		return heimdall.DefaultSignatureVersion
+	}
+
+	return *signerOpt.version
+}
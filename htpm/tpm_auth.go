@@ -0,0 +1,48 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides signing functions with keys inside TPM.
+
+package htpm
+
+import (
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrKeyNotTPMKey = errors.New("invalid key - key should be TPM key")
+
+// Sign generates signature for a data using key inside TPM. The signature can be verified by hecdsa.Verify.
+func Sign(pri heimdall.PriKey, message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	signer, err := NewSigner(pri)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer.Sign(message, opts)
+}
+
+// NewSigner makes heimdall Signer using key inside TPM.
+func NewSigner(pri heimdall.PriKey) (heimdall.Signer, error) {
+	if _, ok := pri.(*PriKey); !ok {
+		return nil, ErrKeyNotTPMKey
+	}
+
+	return hecdsa.NewCryptoSigner(pri)
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package htpm_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/htpm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSign(t *testing.T) {
+	// given
+	pri := setUpTPMKey(t, newSoftwareTPM(), nil)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+
+	// when
+	signature, err := htpm.Sign(pri, message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
+
+func TestSign_PCRPolicy(t *testing.T) {
+	// given
+	device := newSoftwareTPM()
+	pri := setUpTPMKey(t, device, []int{7})
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signer, err := htpm.NewSigner(pri)
+	assert.NoError(t, err)
+
+	// when
+	_, err = signer.Sign([]byte("hello"), hecdsa.NewSignerOpts(hashOpt))
+	device.extend(7, "tampered")
+	_, tamperedErr := signer.Sign([]byte("hello"), hecdsa.NewSignerOpts(hashOpt))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, htpm.ErrPCRPolicyNotSatisfied, tamperedErr)
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides TPM device interface which keys in TPM are created and used through.
+
+package htpm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"math/big"
+)
+
+var ErrPCRPolicyNotSatisfied = errors.New("PCR policy not satisfied - PCR values differ from values the key is bound to")
+
+// Device is a TPM 2.0 which creates and uses non-exportable keys inside.
+// It can be implemented by TPM libraries such as go-tpm over /dev/tpmrm0, or a TPM simulator for testing.
+type Device interface {
+	// CreateKey creates ECDSA key inside TPM and returns context blob for loading the key later.
+	// The key can be used only while current values of pcrs are unchanged if pcrs is not empty.
+	CreateKey(curve elliptic.Curve, pcrs []int) (keyContext []byte, pub *ecdsa.PublicKey, err error)
+
+	// LoadKey loads key into TPM by its context blob and returns public key of the key.
+	LoadKey(keyContext []byte) (pub *ecdsa.PublicKey, err error)
+
+	// Sign signs digest with key inside TPM.
+	Sign(keyContext []byte, digest []byte) (r, s *big.Int, err error)
+
+	// FlushKey unloads key from TPM. The key can be loaded again by its context blob.
+	FlushKey(keyContext []byte) error
+}
@@ -0,0 +1,330 @@
+//go:build !windows
+// +build !windows
+
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides TPM device over TPM character device (ex. /dev/tpmrm0) by go-tpm.
+
+package htpm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"sync"
+
+	"github.com/google/go-tpm/legacy/tpm2"
+	"github.com/google/go-tpm/tpmutil"
+)
+
+var ErrCurveNotSupported = errors.New("curve not supported - TPM key should be on P-256, P-384 or P-521")
+var ErrDigestSizeNotSupported = errors.New("digest size not supported - digest should be 32, 48 or 64 bytes")
+var ErrInvalidKeyContext = errors.New("invalid key context - context blob is not created by TPM device")
+
+// DefaultDevicePath is a path of TPM resource manager device in linux.
+const DefaultDevicePath = "/dev/tpmrm0"
+
+var tpmCurves = map[elliptic.Curve]tpm2.EllipticCurve{
+	elliptic.P256(): tpm2.CurveNISTP256,
+	elliptic.P384(): tpm2.CurveNISTP384,
+	elliptic.P521(): tpm2.CurveNISTP521,
+}
+
+var tpmHashAlgs = map[int]tpm2.Algorithm{
+	32: tpm2.AlgSHA256,
+	48: tpm2.AlgSHA384,
+	64: tpm2.AlgSHA512,
+}
+
+// storage root key template, which derives the same key from owner hierarchy of the same TPM every time.
+var srkTemplate = tpm2.Public{
+	Type:       tpm2.AlgECC,
+	NameAlg:    tpm2.AlgSHA256,
+	Attributes: tpm2.FlagStorageDefault | tpm2.FlagNoDA,
+	ECCParameters: &tpm2.ECCParams{
+		Symmetric: &tpm2.SymScheme{Alg: tpm2.AlgAES, KeyBits: 128, Mode: tpm2.AlgCFB},
+		CurveID:   tpm2.CurveNISTP256,
+	},
+}
+
+// DeviceCloser is a TPM device which keeps connection to TPM until closed.
+type DeviceCloser interface {
+	Device
+	io.Closer
+}
+
+// keyBlob is a context blob of key created under storage root key of TPM.
+type keyBlob struct {
+	Public  []byte
+	Private []byte
+	PCRs    []int
+}
+
+// goTPMDevice is an implementation of Device by go-tpm.
+type goTPMDevice struct {
+	mutex  sync.Mutex
+	rw     io.ReadWriteCloser
+	loaded map[string]tpmutil.Handle
+}
+
+// OpenDevice opens TPM at path. (DefaultDevicePath or /dev/tpm0 is opened if path is empty)
+func OpenDevice(path string) (DeviceCloser, error) {
+	var rw io.ReadWriteCloser
+	var err error
+	if path == "" {
+		rw, err = tpm2.OpenTPM()
+	} else {
+		rw, err = tpm2.OpenTPM(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &goTPMDevice{
+		rw:     rw,
+		loaded: make(map[string]tpmutil.Handle),
+	}, nil
+}
+
+// CreateKey creates signing key under storage root key. Key bound to pcrs can be used only by PCR policy session.
+func (device *goTPMDevice) CreateKey(curve elliptic.Curve, pcrs []int) ([]byte, *ecdsa.PublicKey, error) {
+	curveId, ok := tpmCurves[curve]
+	if !ok {
+		return nil, nil, ErrCurveNotSupported
+	}
+
+	device.mutex.Lock()
+	defer device.mutex.Unlock()
+
+	template := tpm2.Public{
+		Type:       tpm2.AlgECC,
+		NameAlg:    tpm2.AlgSHA256,
+		Attributes: tpm2.FlagSign | tpm2.FlagFixedTPM | tpm2.FlagFixedParent | tpm2.FlagSensitiveDataOrigin,
+		ECCParameters: &tpm2.ECCParams{
+			CurveID: curveId,
+		},
+	}
+
+	if len(pcrs) == 0 {
+		template.Attributes |= tpm2.FlagUserWithAuth
+	} else {
+		policy, err := device.pcrPolicyDigest(pcrs)
+		if err != nil {
+			return nil, nil, err
+		}
+		template.AuthPolicy = policy
+	}
+
+	srk, _, err := tpm2.CreatePrimary(device.rw, tpm2.HandleOwner, tpm2.PCRSelection{}, "", "", srkTemplate)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tpm2.FlushContext(device.rw, srk)
+
+	private, public, _, _, _, err := tpm2.CreateKey(device.rw, srk, tpm2.PCRSelection{}, "", "", template)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pub, err := decodePublicKey(public)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyContext, err := json.Marshal(&keyBlob{
+		Public:  public,
+		Private: private,
+		PCRs:    pcrs,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return keyContext, pub, nil
+}
+
+func (device *goTPMDevice) LoadKey(keyContext []byte) (*ecdsa.PublicKey, error) {
+	device.mutex.Lock()
+	defer device.mutex.Unlock()
+
+	_, blob, err := device.load(keyContext)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodePublicKey(blob.Public)
+}
+
+// Sign signs digest with loaded key, whose PCR policy is satisfied in a policy session if the key is bound to PCRs.
+func (device *goTPMDevice) Sign(keyContext []byte, digest []byte) (*big.Int, *big.Int, error) {
+	hashAlg, ok := tpmHashAlgs[len(digest)]
+	if !ok {
+		return nil, nil, ErrDigestSizeNotSupported
+	}
+
+	device.mutex.Lock()
+	defer device.mutex.Unlock()
+
+	handle, blob, err := device.load(keyContext)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scheme := &tpm2.SigScheme{Alg: tpm2.AlgECDSA, Hash: hashAlg}
+
+	var signature *tpm2.Signature
+	if len(blob.PCRs) == 0 {
+		signature, err = tpm2.Sign(device.rw, handle, "", digest, nil, scheme)
+	} else {
+		signature, err = device.signWithPCRPolicy(handle, blob.PCRs, digest, scheme)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if signature.ECC == nil {
+		return nil, nil, ErrInvalidKeyContext
+	}
+
+	return signature.ECC.R, signature.ECC.S, nil
+}
+
+func (device *goTPMDevice) FlushKey(keyContext []byte) error {
+	device.mutex.Lock()
+	defer device.mutex.Unlock()
+
+	handle, loaded := device.loaded[string(keyContext)]
+	if !loaded {
+		return nil
+	}
+	delete(device.loaded, string(keyContext))
+
+	return tpm2.FlushContext(device.rw, handle)
+}
+
+// Close flushes all loaded keys and closes connection to TPM.
+func (device *goTPMDevice) Close() error {
+	device.mutex.Lock()
+	defer device.mutex.Unlock()
+
+	for keyContext, handle := range device.loaded {
+		tpm2.FlushContext(device.rw, handle)
+		delete(device.loaded, keyContext)
+	}
+
+	return device.rw.Close()
+}
+
+// load loads key under storage root key if it is not loaded yet.
+func (device *goTPMDevice) load(keyContext []byte) (tpmutil.Handle, *keyBlob, error) {
+	blob := new(keyBlob)
+	if err := json.Unmarshal(keyContext, blob); err != nil {
+		return 0, nil, ErrInvalidKeyContext
+	}
+
+	if handle, loaded := device.loaded[string(keyContext)]; loaded {
+		return handle, blob, nil
+	}
+
+	srk, _, err := tpm2.CreatePrimary(device.rw, tpm2.HandleOwner, tpm2.PCRSelection{}, "", "", srkTemplate)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tpm2.FlushContext(device.rw, srk)
+
+	handle, _, err := tpm2.Load(device.rw, srk, "", blob.Public, blob.Private)
+	if err != nil {
+		return 0, nil, err
+	}
+	device.loaded[string(keyContext)] = handle
+
+	return handle, blob, nil
+}
+
+// pcrPolicyDigest calculates digest of policy which requires current values of pcrs in a trial session.
+func (device *goTPMDevice) pcrPolicyDigest(pcrs []int) ([]byte, error) {
+	session, err := device.startPCRPolicySession(tpm2.SessionTrial, pcrs)
+	if err != nil {
+		return nil, err
+	}
+	defer tpm2.FlushContext(device.rw, session)
+
+	return tpm2.PolicyGetDigest(device.rw, session)
+}
+
+func (device *goTPMDevice) signWithPCRPolicy(handle tpmutil.Handle, pcrs []int, digest []byte, scheme *tpm2.SigScheme) (*tpm2.Signature, error) {
+	session, err := device.startPCRPolicySession(tpm2.SessionPolicy, pcrs)
+	if err != nil {
+		return nil, err
+	}
+	defer tpm2.FlushContext(device.rw, session)
+
+	signature, err := tpm2.SignWithSession(device.rw, session, handle, "", digest, nil, scheme)
+	if isPolicyFail(err) {
+		return nil, ErrPCRPolicyNotSatisfied
+	}
+
+	return signature, err
+}
+
+// startPCRPolicySession starts session which is bound to current values of pcrs.
+func (device *goTPMDevice) startPCRPolicySession(sessionType tpm2.SessionType, pcrs []int) (tpmutil.Handle, error) {
+	session, _, err := tpm2.StartAuthSession(device.rw, tpm2.HandleNull, tpm2.HandleNull, make([]byte, 16), nil, sessionType, tpm2.AlgNull, tpm2.AlgSHA256)
+	if err != nil {
+		return 0, err
+	}
+
+	if err := tpm2.PolicyPCR(device.rw, session, nil, tpm2.PCRSelection{Hash: tpm2.AlgSHA256, PCRs: pcrs}); err != nil {
+		tpm2.FlushContext(device.rw, session)
+		return 0, err
+	}
+
+	return session, nil
+}
+
+func decodePublicKey(public []byte) (*ecdsa.PublicKey, error) {
+	decoded, err := tpm2.DecodePublic(public)
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := decoded.Key()
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidKeyContext
+	}
+
+	return ecdsaPub, nil
+}
+
+// isPolicyFail reports whether TPM rejected command because policy session is not satisfied.
+func isPolicyFail(err error) bool {
+	var sessionErr tpm2.SessionError
+	if errors.As(err, &sessionErr) {
+		return sessionErr.Code == tpm2.RCPolicyFail
+	}
+
+	return false
+}
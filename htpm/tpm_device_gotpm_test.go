@@ -0,0 +1,64 @@
+//go:build !windows
+// +build !windows
+
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package htpm_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/htpm"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenDevice(t *testing.T) {
+	if _, err := os.Stat(htpm.DefaultDevicePath); err != nil {
+		t.Skip("TPM device not found")
+	}
+
+	// given
+	device, err := htpm.OpenDevice("")
+	assert.NoError(t, err)
+	defer device.Close()
+
+	pri := setUpTPMKey(t, device, nil)
+	boundPri := setUpTPMKey(t, device, []int{7})
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello")
+
+	// when
+	signature, err := htpm.Sign(pri, message, signerOpt)
+	boundSignature, boundErr := htpm.Sign(boundPri, message, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	assert.NoError(t, boundErr)
+	valid, err = hecdsa.Verify(boundPri.PublicKey(), boundSignature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+}
@@ -0,0 +1,97 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package htpm_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall/htpm"
+)
+
+// softwareTPM is a fake TPM device which keeps keys in memory for testing.
+type softwareTPM struct {
+	keys      map[string]*ecdsa.PrivateKey
+	keyPCRs   map[string]map[int]string
+	pcrValues map[int]string
+}
+
+func newSoftwareTPM() *softwareTPM {
+	return &softwareTPM{
+		keys:      make(map[string]*ecdsa.PrivateKey),
+		keyPCRs:   make(map[string]map[int]string),
+		pcrValues: map[int]string{0: "boot", 7: "secure-boot"},
+	}
+}
+
+func (tpm *softwareTPM) CreateKey(curve elliptic.Curve, pcrs []int) ([]byte, *ecdsa.PublicKey, error) {
+	pri, err := ecdsa.GenerateKey(curve, rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyContext := make([]byte, 16)
+	rand.Read(keyContext)
+	handle := hex.EncodeToString(keyContext)
+
+	tpm.keys[handle] = pri
+	tpm.keyPCRs[handle] = make(map[int]string)
+	for _, pcr := range pcrs {
+		tpm.keyPCRs[handle][pcr] = tpm.pcrValues[pcr]
+	}
+
+	return keyContext, &pri.PublicKey, nil
+}
+
+func (tpm *softwareTPM) LoadKey(keyContext []byte) (*ecdsa.PublicKey, error) {
+	pri, exists := tpm.keys[hex.EncodeToString(keyContext)]
+	if !exists {
+		return nil, errors.New("invalid key context")
+	}
+
+	return &pri.PublicKey, nil
+}
+
+func (tpm *softwareTPM) Sign(keyContext []byte, digest []byte) (*big.Int, *big.Int, error) {
+	handle := hex.EncodeToString(keyContext)
+	pri, exists := tpm.keys[handle]
+	if !exists {
+		return nil, nil, errors.New("invalid key context")
+	}
+
+	for pcr, value := range tpm.keyPCRs[handle] {
+		if tpm.pcrValues[pcr] != value {
+			return nil, nil, htpm.ErrPCRPolicyNotSatisfied
+		}
+	}
+
+	return ecdsa.Sign(rand.Reader, pri, digest)
+}
+
+func (tpm *softwareTPM) FlushKey(keyContext []byte) error {
+	return nil
+}
+
+// extend changes value of PCR like booting with modified software.
+func (tpm *softwareTPM) extend(pcr int, value string) {
+	tpm.pcrValues[pcr] = tpm.pcrValues[pcr] + value
+}
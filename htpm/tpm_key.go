@@ -0,0 +1,127 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides keys which are created and used inside TPM.
+
+package htpm
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"errors"
+	"io"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrKeyGenOptNotSupported = errors.New("key generation option not supported - TPM key should be ECDSA key")
+var ErrPublicKeyNotSupported = errors.New("TPM key can not be recovered from public key bytes, use hecdsa.KeyRecoverer")
+
+// GenerateKey creates ECDSA key inside TPM, bound to current values of pcrs if pcrs is not empty.
+func GenerateKey(device Device, keyGenOpt heimdall.KeyGenOpts, pcrs []int) (heimdall.PriKey, error) {
+	ecdsaKeyGenOpt, err := hecdsa.ToKeyGenOpt(keyGenOpt)
+	if err != nil {
+		return nil, ErrKeyGenOptNotSupported
+	}
+
+	keyContext, pub, err := device.CreateKey(ecdsaKeyGenOpt.Curve, pcrs)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriKey{
+		device:     device,
+		keyContext: keyContext,
+		pub:        pub,
+	}, nil
+}
+
+// PriKey is an implementation of heimdall PriKey whose private part never leaves TPM.
+type PriKey struct {
+	device     Device
+	keyContext []byte
+	pub        *ecdsa.PublicKey
+}
+
+func (priKey *PriKey) ID() heimdall.KeyID {
+	return priKey.PublicKey().ID()
+}
+
+func (priKey *PriKey) SKI() []byte {
+	return priKey.PublicKey().SKI()
+}
+
+// ToByte returns context blob of the key, which is only usable with the TPM created the key.
+func (priKey *PriKey) ToByte() ([]byte, error) {
+	return priKey.keyContext, nil
+}
+
+func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return priKey.PublicKey().KeyGenOpt()
+}
+
+func (priKey *PriKey) IsPrivate() bool {
+	return true
+}
+
+// PublicKey returns ECDSA public key, so signatures can be verified by hecdsa.
+func (priKey *PriKey) PublicKey() heimdall.PubKey {
+	return hecdsa.NewPubKey(priKey.pub)
+}
+
+// Clear unloads the key from TPM.
+func (priKey *PriKey) Clear() {
+	priKey.device.FlushKey(priKey.keyContext)
+}
+
+// Public implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Public() crypto.PublicKey {
+	return priKey.pub
+}
+
+// Sign implements crypto.Signer, so the key can be used for x509 functions such as creating certificate.
+func (priKey *PriKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	r, s, err := priKey.device.Sign(priKey.keyContext, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	return hecdsa.MarshalSignature(r, s)
+}
+
+// KeyRecoverer recovers TPM key from its context blob.
+type KeyRecoverer struct {
+	Device Device
+}
+
+func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	if !isPrivate {
+		return nil, ErrPublicKeyNotSupported
+	}
+
+	pub, err := recoverer.Device.LoadKey(keyBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PriKey{
+		device:     recoverer.Device,
+		keyContext: keyBytes,
+		pub:        pub,
+	}, nil
+}
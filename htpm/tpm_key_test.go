@@ -0,0 +1,71 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package htpm_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/htpm"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpTPMKey(t *testing.T, device htpm.Device, pcrs []int) heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := htpm.GenerateKey(device, keyGenOpt, pcrs)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func TestGenerateKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+
+	// when
+	pri, err := htpm.GenerateKey(newSoftwareTPM(), keyGenOpt, nil)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, pri.IsPrivate())
+	assert.Equal(t, hecdsa.ECP256, pri.KeyGenOpt().ToString())
+	assert.Equal(t, pri.ID(), pri.PublicKey().ID())
+	assert.Equal(t, pri.SKI(), pri.PublicKey().SKI())
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
+	// given
+	device := newSoftwareTPM()
+	pri := setUpTPMKey(t, device, nil)
+	keyContext, err := pri.ToByte()
+	assert.NoError(t, err)
+
+	recoverer := &htpm.KeyRecoverer{Device: device}
+
+	// when
+	key, err := recoverer.RecoverKeyFromByte(keyContext, true)
+	_, pubErr := recoverer.RecoverKeyFromByte(keyContext, false)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.Equal(t, htpm.ErrPublicKeyNotSupported, pubErr)
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides functions for storing and loading context of keys inside TPM.
+
+package htpm
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+)
+
+var ErrKeyNotExist = errors.New("TPM key file not exist")
+
+// KeyFile is a file format of TPM key, which has no private key material but context blob of the key.
+type KeyFile struct {
+	KeyGenOpt  string
+	PublicKey  []byte
+	KeyContext []byte
+}
+
+// StoreKey stores context blob and public key of TPM key into key directory with the name of key ID.
+func StoreKey(key heimdall.PriKey, keyDirPath string) error {
+	tpmPri, ok := key.(*PriKey)
+	if !ok {
+		return ErrKeyNotTPMKey
+	}
+
+	pubBytes, err := tpmPri.PublicKey().ToByte()
+	if err != nil {
+		return err
+	}
+
+	jsonKeyFile, err := json.Marshal(&KeyFile{
+		KeyGenOpt:  tpmPri.KeyGenOpt().ToString(),
+		PublicKey:  pubBytes,
+		KeyContext: tpmPri.keyContext,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(keyDirPath); os.IsNotExist(err) {
+		if err = fileperm.MkdirAll(keyDirPath); err != nil {
+			return err
+		}
+	}
+	fileperm.WarnInsecure(keyDirPath)
+
+	keyFilePath := filepath.Join(keyDirPath, tpmPri.ID())
+
+	return fileperm.WriteFileAtomic(keyFilePath, keyFilePath+".tmp", jsonKeyFile)
+}
+
+// LoadKey loads TPM key of key ID into device from context blob stored in key directory.
+func LoadKey(device Device, keyId heimdall.KeyID, keyDirPath string) (heimdall.PriKey, error) {
+	if err := heimdall.ValidateKeyID(keyId); err != nil {
+		return nil, err
+	}
+	fileperm.WarnInsecure(keyDirPath)
+
+	jsonKeyFile, err := ioutil.ReadFile(heimdall.KeyIDFilePath(keyDirPath, keyId))
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotExist
+	} else if err != nil {
+		return nil, err
+	}
+
+	var keyFile KeyFile
+	if err = json.Unmarshal(jsonKeyFile, &keyFile); err != nil {
+		return nil, err
+	}
+
+	recoverer := &KeyRecoverer{Device: device}
+	key, err := recoverer.RecoverKeyFromByte(keyFile.KeyContext, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = heimdall.SKIValidCheck(keyId, key.SKI()); err != nil {
+		return nil, err
+	}
+
+	return key.(heimdall.PriKey), nil
+}
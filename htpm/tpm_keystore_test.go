@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package htpm_test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/htpm"
+	"github.com/DE-labtory/heimdall/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreKey(t *testing.T) {
+	// given
+	pri := setUpTPMKey(t, newSoftwareTPM(), nil)
+
+	// when
+	err := htpm.StoreKey(pri, heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestKeyDir)
+}
+
+func TestLoadKey(t *testing.T) {
+	// given
+	device := newSoftwareTPM()
+	pri := setUpTPMKey(t, device, nil)
+	err := htpm.StoreKey(pri, heimdall.TestKeyDir)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	key, err := htpm.LoadKey(device, pri.ID(), heimdall.TestKeyDir)
+	_, notExistErr := htpm.LoadKey(device, "ITnotExist", heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.Equal(t, htpm.ErrKeyNotExist, notExistErr)
+}
+
+func TestLoadKey_NodeIdentity(t *testing.T) {
+	// given
+	device := newSoftwareTPM()
+	pri := setUpTPMKey(t, device, nil)
+	template := &x509.Certificate{
+		Subject:   pkix.Name{CommonName: "it-chain node"},
+		NotBefore: time.Now().Add(-time.Hour),
+		NotAfter:  time.Now().Add(time.Hour),
+		KeyUsage:  x509.KeyUsageDigitalSignature,
+	}
+	node, err := identity.NewWithKey(pri, template, nil)
+	assert.NoError(t, err)
+	err = htpm.StoreKey(pri, heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	err = cert.Store(node.Cert, heimdall.TestCertDir)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	key, err := htpm.LoadKey(device, node.ID(), heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	loaded, err := identity.LoadWithKey(key, heimdall.TestCertDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, node.ID(), loaded.ID())
+	assert.True(t, node.Cert.Equal(loaded.Cert))
+	assert.NoError(t, loaded.Cert.CheckSignature(loaded.Cert.SignatureAlgorithm, loaded.Cert.RawTBSCertificate, loaded.Cert.Signature))
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides X25519 Diffie-Hellman key agreement, and derivation of symmetric keys from the shared secret.
+
+package hx25519
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+
+	"github.com/DE-labtory/heimdall"
+	"golang.org/x/crypto/hkdf"
+)
+
+var ErrInvalidSharedKeySize = errors.New("invalid shared key size - size should be between 16 and 64 bytes")
+
+// SharedSecret computes X25519 Diffie-Hellman shared secret with peer public key. (RFC 7748)
+// Low order peer keys, which make all zero secret, are rejected.
+// The secret is not uniformly random, so derive keys from it by DeriveSharedKey or a KDF instead of using it directly.
+func (priKey *PriKey) SharedSecret(peerPub heimdall.PubKey) ([]byte, error) {
+	if priKey.internalPriKey == nil {
+		return nil, ErrKeyCleared
+	}
+
+	if err := heimdall.CheckKeyUsage(priKey, heimdall.PurposeEncrypt); err != nil {
+		return nil, err
+	}
+
+	pub, ok := peerPub.(*PubKey)
+	if !ok {
+		return nil, ErrNotX25519PubKey
+	}
+
+	return priKey.internalPriKey.ECDH(pub.internalPubKey)
+}
+
+// DeriveSharedKey derives symmetric key of size bytes from shared secret with peer public key by HKDF-SHA256.
+// Both public keys salt the derivation in sorted order, so both peers derive the same key,
+// and info separates keys for different purposes (ex. "channel a to b").
+func (priKey *PriKey) DeriveSharedKey(peerPub heimdall.PubKey, info []byte, size int) ([]byte, error) {
+	if size < 16 || size > 64 {
+		return nil, ErrInvalidSharedKeySize
+	}
+
+	secret, err := priKey.SharedSecret(peerPub)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for i := range secret {
+			secret[i] = 0
+		}
+	}()
+
+	own := priKey.internalPubKey.Bytes()
+	peer := peerPub.(*PubKey).internalPubKey.Bytes()
+	salt := append(own, peer...)
+	if bytes.Compare(own, peer) > 0 {
+		salt = append(peer, own...)
+	}
+
+	key := make([]byte, size)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
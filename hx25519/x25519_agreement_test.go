@@ -0,0 +1,96 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hx25519_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hx25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriKey_SharedSecret(t *testing.T) {
+	// given
+	alice := setUpPriKey(t)
+	bob := setUpPriKey(t)
+
+	// when
+	aliceSecret, aliceErr := alice.SharedSecret(bob.PublicKey())
+	bobSecret, bobErr := bob.SharedSecret(alice.PublicKey())
+
+	// then
+	assert.NoError(t, aliceErr)
+	assert.NoError(t, bobErr)
+	assert.Len(t, aliceSecret, 32)
+	assert.Equal(t, aliceSecret, bobSecret)
+}
+
+func TestPriKey_SharedSecret_Failure(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	ed25519Pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	_, typeErr := pri.SharedSecret(ed25519Pri.PublicKey())
+	pri.Clear()
+	_, clearedErr := pri.SharedSecret(setUpPriKey(t).PublicKey())
+
+	// then
+	assert.Equal(t, hx25519.ErrNotX25519PubKey, typeErr)
+	assert.Equal(t, hx25519.ErrKeyCleared, clearedErr)
+}
+
+func TestPriKey_DeriveSharedKey(t *testing.T) {
+	// given
+	alice := setUpPriKey(t)
+	bob := setUpPriKey(t)
+
+	// when
+	aliceKey, aliceErr := alice.DeriveSharedKey(bob.PublicKey(), []byte("channel"), 32)
+	bobKey, bobErr := bob.DeriveSharedKey(alice.PublicKey(), []byte("channel"), 32)
+	otherKey, otherErr := alice.DeriveSharedKey(bob.PublicKey(), []byte("other channel"), 32)
+
+	// then
+	assert.NoError(t, aliceErr)
+	assert.NoError(t, bobErr)
+	assert.NoError(t, otherErr)
+	assert.Len(t, aliceKey, 32)
+	assert.Equal(t, aliceKey, bobKey)
+	assert.NotEqual(t, aliceKey, otherKey)
+}
+
+func TestPriKey_DeriveSharedKey_InvalidSize(t *testing.T) {
+	// given
+	alice := setUpPriKey(t)
+	bob := setUpPriKey(t)
+
+	for _, size := range []int{0, 15, 65} {
+		t.Logf("running test case [%d]", size)
+
+		// when
+		key, err := alice.DeriveSharedKey(bob.PublicKey(), nil, size)
+
+		// then
+		assert.Equal(t, hx25519.ErrInvalidSharedKeySize, err)
+		assert.Nil(t, key)
+	}
+}
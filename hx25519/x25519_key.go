@@ -0,0 +1,188 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides X25519 key related functions.
+// X25519 keys are key agreement keys, which are kept apart from signing keys and can not make signatures.
+
+package hx25519
+
+import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+)
+
+var ErrKeyType = errors.New("invalid key type - key type should be heimdall.PRIVATEKEY or heimdall.PUBLICKEY")
+var ErrNotX25519PriKey = errors.New("invalid private key - key is not X25519 private key")
+var ErrNotX25519PubKey = errors.New("invalid public key - key is not X25519 public key")
+var ErrKeyCleared = errors.New("private key cleared - X25519 private key is not usable after Clear")
+
+func GenerateKey(keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	opt, err := ToKeyGenOpt(keyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := heimdall.CheckKeyAlgorithm(opt); err != nil {
+		return nil, err
+	}
+
+	pri, err := ecdh.X25519().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &PriKey{internalPriKey: pri, internalPubKey: pri.PublicKey()}
+	event.Publish(event.KeyCreated, key.ID(), "")
+
+	return key, nil
+}
+
+// PriKey is an implementation of heimdall PriKey for using X25519 private key.
+// Public key is kept apart, so the key can be identified after it is cleared.
+type PriKey struct {
+	heimdall.KeyUsage
+	internalPriKey *ecdh.PrivateKey
+	internalPubKey *ecdh.PublicKey
+}
+
+func NewPriKey(internalPriKey *ecdh.PrivateKey) heimdall.PriKey {
+	return &PriKey{internalPriKey: internalPriKey, internalPubKey: internalPriKey.PublicKey()}
+}
+
+func (priKey *PriKey) ID() heimdall.KeyID {
+	return priKey.PublicKey().ID()
+}
+
+func (priKey *PriKey) SKI() []byte {
+	return priKey.PublicKey().SKI()
+}
+
+func (priKey *PriKey) ToByte() ([]byte, error) {
+	if priKey.internalPriKey == nil {
+		return nil, ErrKeyCleared
+	}
+
+	return x509.MarshalPKCS8PrivateKey(priKey.internalPriKey)
+}
+
+func (priKey *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return &KeyGenOpt{}
+}
+
+func (priKey *PriKey) IsPrivate() bool {
+	return true
+}
+
+func (priKey *PriKey) PublicKey() heimdall.PubKey {
+	return &PubKey{KeyUsage: priKey.KeyUsage, internalPubKey: priKey.internalPubKey}
+}
+
+func (priKey *PriKey) Clear() {
+	// ecdh private key is immutable, so drop the reference to the scalar
+	priKey.internalPriKey = nil
+}
+
+// PubKey is an implementation of heimdall PubKey for using X25519 public key
+type PubKey struct {
+	heimdall.KeyUsage
+	internalPubKey *ecdh.PublicKey
+}
+
+func NewPubKey(internalPubKey *ecdh.PublicKey) heimdall.PubKey {
+	return &PubKey{internalPubKey: internalPubKey}
+}
+
+func (pubKey *PubKey) ID() heimdall.KeyID {
+	// return base58 encoded ski with algorithm prefix (ex. X25519x...)
+	keyId, err := heimdall.MakeKeyID(&heimdall.KeyType{Family: heimdall.X25519, BitLen: 256}, pubKey.SKI())
+	if err != nil {
+		return heimdall.SKIToKeyID(pubKey.SKI())
+	}
+
+	return keyId
+}
+
+func (pubKey *PubKey) SKI() []byte {
+	return heimdall.MakeSKI(pubKey.SKIMaterial())
+}
+
+// SKIMaterial returns public key bytes, which SKI is hashed from.
+func (pubKey *PubKey) SKIMaterial() []byte {
+	return pubKey.internalPubKey.Bytes()
+}
+
+func (pubKey *PubKey) ToByte() ([]byte, error) {
+	return x509.MarshalPKIXPublicKey(pubKey.internalPubKey)
+}
+
+func (pubKey *PubKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return &KeyGenOpt{}
+}
+
+func (pubKey *PubKey) IsPrivate() bool {
+	return false
+}
+
+// init registers recoverer of X25519 keys, so that key files of them are loaded without choosing a recoverer.
+func init() {
+	heimdall.RegisterKeyRecoverer(heimdall.X25519, &KeyRecoverer{})
+}
+
+type KeyRecoverer struct {
+}
+
+func (recoverer *KeyRecoverer) RecoverKeyFromByte(keyBytes []byte, isPrivate bool) (heimdall.Key, error) {
+	switch isPrivate {
+	case true:
+		internalPriKey, err := x509.ParsePKCS8PrivateKey(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		x25519PriKey, ok := internalPriKey.(*ecdh.PrivateKey)
+		if !ok || x25519PriKey.Curve() != ecdh.X25519() {
+			return nil, ErrNotX25519PriKey
+		}
+
+		pri := NewPriKey(x25519PriKey)
+
+		return pri, nil
+
+	case false:
+		internalPubKey, err := x509.ParsePKIXPublicKey(keyBytes)
+		if err != nil {
+			return nil, err
+		}
+
+		x25519PubKey, ok := internalPubKey.(*ecdh.PublicKey)
+		if !ok || x25519PubKey.Curve() != ecdh.X25519() {
+			return nil, ErrNotX25519PubKey
+		}
+
+		pub := NewPubKey(x25519PubKey)
+
+		return pub, nil
+
+	default:
+		return nil, ErrKeyType
+	}
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hx25519_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hx25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpPriKey(t *testing.T) *hx25519.PriKey {
+	keyGenOpt, err := hx25519.NewKeyGenOpt(hx25519.X25519)
+	assert.NoError(t, err)
+	pri, err := hx25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri.(*hx25519.PriKey)
+}
+
+func TestGenerateKey(t *testing.T) {
+	// given
+	keyGenOpt, err := hx25519.NewKeyGenOpt(hx25519.X25519)
+	assert.NoError(t, err)
+
+	// when
+	pri, err := hx25519.GenerateKey(keyGenOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.NotNil(t, pri)
+	assert.True(t, pri.IsPrivate())
+	assert.False(t, pri.PublicKey().IsPrivate())
+}
+
+func TestPriKey_ID(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+
+	// when
+	keyId := pri.ID()
+
+	// then
+	assert.True(t, strings.HasPrefix(keyId, heimdall.X25519+heimdall.KeyIDDelimiter))
+	assert.Equal(t, keyId, pri.PublicKey().ID())
+
+	info, err := heimdall.ParseKeyID(keyId)
+	assert.NoError(t, err)
+	assert.Equal(t, heimdall.X25519, info.KeyType.Family)
+	assert.Equal(t, pri.SKI(), info.SKI)
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	priBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	pubBytes, err := pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+	recoverer := &hx25519.KeyRecoverer{}
+
+	// when
+	recoveredPri, priErr := recoverer.RecoverKeyFromByte(priBytes, true)
+	recoveredPub, pubErr := recoverer.RecoverKeyFromByte(pubBytes, false)
+
+	// then
+	assert.NoError(t, priErr)
+	assert.Equal(t, pri.ID(), recoveredPri.ID())
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri.ID(), recoveredPub.ID())
+}
+
+func TestKeyRecoverer_RecoverKeyFromByte_NotX25519(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	ed25519Pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	priBytes, err := ed25519Pri.ToByte()
+	assert.NoError(t, err)
+	pubBytes, err := ed25519Pri.PublicKey().ToByte()
+	assert.NoError(t, err)
+	recoverer := &hx25519.KeyRecoverer{}
+
+	// when
+	_, priErr := recoverer.RecoverKeyFromByte(priBytes, true)
+	_, pubErr := recoverer.RecoverKeyFromByte(pubBytes, false)
+
+	// then
+	assert.Equal(t, hx25519.ErrNotX25519PriKey, priErr)
+	assert.Equal(t, hx25519.ErrNotX25519PubKey, pubErr)
+}
+
+func TestPriKey_Clear(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	keyId := pri.ID()
+
+	// when
+	pri.Clear()
+
+	// then
+	assert.Equal(t, keyId, pri.ID())
+	_, err := pri.ToByte()
+	assert.Equal(t, hx25519.ErrKeyCleared, err)
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides X25519 option for key generation.
+
+package hx25519
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var ErrKeyGenOptNotSupported = errors.New("key generation option not supported - option should be X25519")
+
+const X25519 = heimdall.X25519
+
+// KeyGenOpt is key generation option of X25519, whose key length is fixed to 256 bits.
+type KeyGenOpt struct {
+}
+
+func NewKeyGenOpt(strOpt string) (*KeyGenOpt, error) {
+	opt := new(KeyGenOpt)
+	return opt, opt.initKeyGenOpt(strOpt)
+}
+
+func (opt *KeyGenOpt) initKeyGenOpt(strOpt string) error {
+	if strings.ToUpper(strOpt) != X25519 {
+		return ErrKeyGenOptNotSupported
+	}
+
+	return nil
+}
+
+func (opt *KeyGenOpt) ToString() string {
+	return X25519
+}
+
+func (opt *KeyGenOpt) KeySize() int {
+	return 256
+}
+
+func (opt *KeyGenOpt) Algorithm() string {
+	return heimdall.X25519
+}
+
+func (opt *KeyGenOpt) Bits() int {
+	return 256
+}
+
+// ToKeyGenOpt converts X25519 key generation option of any implementation (ex. heimdall.KeyType) to KeyGenOpt.
+func ToKeyGenOpt(opts heimdall.KeyGenOpts) (*KeyGenOpt, error) {
+	if opt, ok := opts.(*KeyGenOpt); ok {
+		return opt, nil
+	}
+
+	if opts == nil || opts.Algorithm() != heimdall.X25519 {
+		return nil, ErrKeyGenOptNotSupported
+	}
+
+	return NewKeyGenOpt(opts.ToString())
+}
@@ -0,0 +1,61 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package hx25519_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hx25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKeyGenOpt(t *testing.T) {
+	// when
+	opt, err := hx25519.NewKeyGenOpt("x25519")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hx25519.X25519, opt.ToString())
+	assert.Equal(t, 256, opt.KeySize())
+	assert.Equal(t, heimdall.X25519, opt.Algorithm())
+}
+
+func TestNewKeyGenOpt_NotSupported(t *testing.T) {
+	// when
+	_, err := hx25519.NewKeyGenOpt("ED25519")
+
+	// then
+	assert.Equal(t, hx25519.ErrKeyGenOptNotSupported, err)
+}
+
+func TestToKeyGenOpt(t *testing.T) {
+	// given
+	keyType, err := heimdall.ParseKeyType("X25519")
+	assert.NoError(t, err)
+
+	// when
+	opt, err := hx25519.ToKeyGenOpt(keyType)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hx25519.X25519, opt.ToString())
+
+	_, err = hx25519.ToKeyGenOpt(&heimdall.KeyType{Family: heimdall.ED25519, BitLen: 256})
+	assert.Equal(t, hx25519.ErrKeyGenOptNotSupported, err)
+}
@@ -0,0 +1,171 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides node identity (key and certificate) for it-chain engine.
+
+package identity
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+)
+
+var ErrTemplateNil = errors.New("certificate template should not be nil")
+var ErrKeyNotSupported = errors.New("key not supported - identity key should be ECDSA key")
+var ErrCertKeyMismatch = errors.New("invalid identity - certificate does not correspond to key")
+
+// Identity is a node identity which consists of private key and certificate of the key.
+type Identity struct {
+	PriKey heimdall.PriKey
+	Cert   *x509.Certificate
+}
+
+// New generates key and certificate of a node identity.
+// Certificate is issued by issuer, or self-signed if issuer is nil.
+func New(keyGenOpt heimdall.KeyGenOpts, template *x509.Certificate, issuer *Identity) (*Identity, error) {
+	if template == nil {
+		return nil, ErrTemplateNil
+	}
+
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWithKey(pri, template, issuer)
+}
+
+// NewWithKey issues certificate of a node identity for key which is kept outside of heimdall key store,
+// such as TPM key (htpm) or KMS key (hkms). The key should be ECDSA key implementing crypto.Signer.
+// Certificate is issued by issuer, or self-signed if issuer is nil.
+func NewWithKey(pri heimdall.PriKey, template *x509.Certificate, issuer *Identity) (*Identity, error) {
+	if template == nil {
+		return nil, ErrTemplateNil
+	}
+
+	priSigner, ok := pri.(crypto.Signer)
+	if !ok {
+		return nil, ErrKeyNotSupported
+	}
+	if _, ok := priSigner.Public().(*ecdsa.PublicKey); !ok {
+		return nil, ErrKeyNotSupported
+	}
+
+	certTemplate := *template
+	certTemplate.SubjectKeyId = pri.SKI()
+
+	if certTemplate.SerialNumber == nil {
+		serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+		if err != nil {
+			return nil, err
+		}
+		certTemplate.SerialNumber = serialNumber
+	}
+
+	parent := &certTemplate
+	signer := priSigner
+	if issuer != nil {
+		issuerSigner, ok := issuer.PriKey.(crypto.Signer)
+		if !ok {
+			return nil, ErrKeyNotSupported
+		}
+		parent = issuer.Cert
+		signer = issuerSigner
+	}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &certTemplate, parent, priSigner.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+
+	identityCert, err := cert.DERToX509Cert(derBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Identity{
+		PriKey: pri,
+		Cert:   identityCert,
+	}, nil
+}
+
+// ID returns key ID of the identity.
+func (identity *Identity) ID() heimdall.KeyID {
+	return identity.PriKey.ID()
+}
+
+// Peer returns peer identity which is sent to other nodes.
+func (identity *Identity) Peer() *PeerIdentity {
+	return &PeerIdentity{
+		ID:   identity.ID(),
+		Cert: cert.X509CertToDER(identity.Cert),
+	}
+}
+
+// Store stores private key with password and certificate of the identity.
+func Store(identity *Identity, pwd, keyDirPath, certDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) error {
+	if err := hecdsa.StorePriKey(identity.PriKey, pwd, keyDirPath, encOpt, kdfOpt); err != nil {
+		return err
+	}
+
+	return cert.Store(identity.Cert, certDirPath)
+}
+
+// Load loads identity from private key stored with password and certificate of the key.
+func Load(keyDirPath, certDirPath, pwd string) (*Identity, error) {
+	pri, err := hecdsa.LoadPriKey(keyDirPath, pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	identity, err := LoadWithKey(pri, certDirPath)
+	if err != nil {
+		pri.Clear()
+		return nil, err
+	}
+
+	return identity, nil
+}
+
+// LoadWithKey loads identity of key which is kept outside of heimdall key store, such as TPM key (htpm),
+// with certificate of the key.
+func LoadWithKey(pri heimdall.PriKey, certDirPath string) (*Identity, error) {
+	identityCert, err := cert.Load(pri.ID(), certDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	certPub, ok := identityCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok || hecdsa.NewPubKey(certPub).ID() != pri.ID() {
+		return nil, ErrCertKeyMismatch
+	}
+
+	return &Identity{
+		PriKey: pri,
+		Cert:   identityCert,
+	}, nil
+}
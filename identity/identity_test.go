@@ -0,0 +1,145 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package identity_test
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/identity"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/stretchr/testify/assert"
+)
+
+var rootTemplate = x509.Certificate{
+	Subject:               pkix.Name{CommonName: "it-chain root"},
+	NotBefore:             time.Now().Add(-time.Hour),
+	NotAfter:              time.Now().Add(time.Hour),
+	IsCA:                  true,
+	BasicConstraintsValid: true,
+	KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+}
+
+var nodeTemplate = x509.Certificate{
+	Subject:     pkix.Name{CommonName: "it-chain node"},
+	NotBefore:   time.Now().Add(-time.Hour),
+	NotAfter:    time.Now().Add(time.Hour),
+	KeyUsage:    x509.KeyUsageDigitalSignature,
+	ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+}
+
+func setUpIdentities(t *testing.T) (root, node *identity.Identity) {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+
+	root, err = identity.New(keyGenOpt, &rootTemplate, nil)
+	assert.NoError(t, err)
+	node, err = identity.New(keyGenOpt, &nodeTemplate, root)
+	assert.NoError(t, err)
+
+	return root, node
+}
+
+func TestNew(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+
+	// when
+	root, node := setUpIdentities(t)
+	_, nilErr := identity.New(keyGenOpt, nil, nil)
+
+	// then
+	assert.Equal(t, root.PriKey.SKI(), root.Cert.SubjectKeyId)
+	assert.Equal(t, node.PriKey.SKI(), node.Cert.SubjectKeyId)
+	assert.NoError(t, node.Cert.CheckSignatureFrom(root.Cert))
+	assert.Equal(t, identity.ErrTemplateNil, nilErr)
+}
+
+func TestNewWithKey(t *testing.T) {
+	// given
+	root, _ := setUpIdentities(t)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	ed25519KeyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	ed25519Pri, err := hed25519.GenerateKey(ed25519KeyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	node, err := identity.NewWithKey(pri, &nodeTemplate, root)
+	_, keyErr := identity.NewWithKey(ed25519Pri, &nodeTemplate, root)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), node.ID())
+	assert.Equal(t, pri.SKI(), node.Cert.SubjectKeyId)
+	assert.NoError(t, node.Cert.CheckSignatureFrom(root.Cert))
+	assert.Equal(t, identity.ErrKeyNotSupported, keyErr)
+}
+
+func TestLoadWithKey(t *testing.T) {
+	// given
+	root, node := setUpIdentities(t)
+	err := cert.Store(node.Cert, heimdall.TestCertDir)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	loaded, err := identity.LoadWithKey(node.PriKey, heimdall.TestCertDir)
+	_, notExistErr := identity.LoadWithKey(root.PriKey, heimdall.TestCertDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, node.ID(), loaded.ID())
+	assert.True(t, node.Cert.Equal(loaded.Cert))
+	assert.Error(t, notExistErr)
+}
+
+func TestStoreAndLoad(t *testing.T) {
+	// given
+	_, node := setUpIdentities(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	err = identity.Store(node, "password", heimdall.TestKeyDir, heimdall.TestCertDir, encOpt, kdfOpt)
+	assert.NoError(t, err)
+	loaded, err := identity.Load(heimdall.TestKeyDir, heimdall.TestCertDir, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, node.ID(), loaded.ID())
+	assert.True(t, node.Cert.Equal(loaded.Cert))
+}
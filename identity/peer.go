@@ -0,0 +1,91 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides peer identity exchanged in gossip and handshake of it-chain engine.
+
+package identity
+
+import (
+	"crypto/ecdsa"
+	"errors"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrPeerIDMismatch = errors.New("invalid peer identity - peer ID does not correspond to certificate")
+
+// PeerIdentity is an identity of other node, which can be marshaled and sent over network.
+type PeerIdentity struct {
+	ID   heimdall.KeyID
+	Cert []byte
+}
+
+// PubKey returns public key in certificate of the peer identity.
+func (peer *PeerIdentity) PubKey() (heimdall.PubKey, error) {
+	peerCert, err := cert.DERToX509Cert(peer.Cert)
+	if err != nil {
+		return nil, err
+	}
+
+	certPub, ok := peerCert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrKeyNotSupported
+	}
+
+	return hecdsa.NewPubKey(certPub), nil
+}
+
+// VerifyPeer verifies peer identity with certificates in certificate store directory,
+// and returns public key of the peer if it is valid.
+func VerifyPeer(peer *PeerIdentity, certDirPath string) (heimdall.PubKey, error) {
+	pub, err := peer.PubKey()
+	if err != nil {
+		return nil, err
+	}
+
+	if !heimdall.MatchKeyID(peer.ID, pub) {
+		return nil, ErrPeerIDMismatch
+	}
+
+	peerCert, err := cert.DERToX509Cert(peer.Cert)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = cert.VerifyChain(peerCert, certDirPath); err != nil {
+		return nil, err
+	}
+
+	if err = cert.Verify(peerCert); err != nil {
+		return nil, err
+	}
+
+	return pub, nil
+}
+
+// VerifyPeerSignature verifies peer identity by VerifyPeer first, and then verifies signature of a message
+// (ex. handshake challenge) by public key of the verified peer.
+func VerifyPeerSignature(peer *PeerIdentity, certDirPath string, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	pub, err := VerifyPeer(peer, certDirPath)
+	if err != nil {
+		return false, err
+	}
+
+	return hecdsa.Verify(pub, signature, message, opts)
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package identity_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyPeer(t *testing.T) {
+	// given
+	root, node := setUpIdentities(t)
+	_, otherNode := setUpIdentities(t)
+
+	err := cert.Store(root.Cert, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	tamperedPeer := otherNode.Peer()
+	tamperedPeer.ID = node.ID()
+
+	// when
+	pub, err := identity.VerifyPeer(node.Peer(), heimdall.TestCertDir)
+	_, mismatchErr := identity.VerifyPeer(tamperedPeer, heimdall.TestCertDir)
+	_, untrustedErr := identity.VerifyPeer(otherNode.Peer(), heimdall.TestCertDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, node.ID(), pub.ID())
+	assert.Equal(t, identity.ErrPeerIDMismatch, mismatchErr)
+	assert.Error(t, untrustedErr)
+}
+
+func TestVerifyPeerSignature(t *testing.T) {
+	// given
+	root, node := setUpIdentities(t)
+	_, otherNode := setUpIdentities(t)
+
+	err := cert.Store(root.Cert, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	challenge := []byte("handshake challenge")
+
+	signature, err := hecdsa.NewSigner(node.PriKey).Sign(challenge, signerOpt)
+	assert.NoError(t, err)
+	untrustedSignature, err := hecdsa.NewSigner(otherNode.PriKey).Sign(challenge, signerOpt)
+	assert.NoError(t, err)
+
+	// when
+	valid, err := identity.VerifyPeerSignature(node.Peer(), heimdall.TestCertDir, signature, challenge, signerOpt)
+	untrustedValid, untrustedErr := identity.VerifyPeerSignature(otherNode.Peer(), heimdall.TestCertDir, untrustedSignature, challenge, signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.Error(t, untrustedErr)
+	assert.False(t, untrustedValid)
+}
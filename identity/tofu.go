@@ -0,0 +1,167 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides trust-on-first-use pin store, a lighter-weight trust model for small networks running no CA.
+
+package identity
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+)
+
+var ErrPinMismatch = errors.New("pin mismatch - public key of peer changed since first use")
+var ErrPinNotFound = errors.New("pin not found - peer is never seen")
+
+// Pin is the public key first seen from a peer.
+type Pin struct {
+	PeerID    string
+	KeyID     heimdall.KeyID
+	PubKey    []byte
+	FirstSeen time.Time
+}
+
+// PinStore records the first seen public key of each peer in a file, and flags later changes.
+// Peer ID is a name of the peer such as node name or address, which does not change along with its key.
+type PinStore struct {
+	mutex    sync.Mutex
+	pinsPath string
+	pins     map[string]*Pin
+}
+
+// NewPinStore makes pin store saved in pinsPath, loading pins already saved in it.
+func NewPinStore(pinsPath string) (*PinStore, error) {
+	store := &PinStore{
+		pinsPath: pinsPath,
+		pins:     make(map[string]*Pin),
+	}
+
+	data, err := ioutil.ReadFile(pinsPath)
+	if os.IsNotExist(err) {
+		return store, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &store.pins); err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// Check pins public key of the peer if it is seen for the first time, and returns true.
+// It returns ErrPinMismatch if the peer presents a key other than the pinned one.
+func (store *PinStore) Check(peerId string, pub heimdall.PubKey) (bool, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if pin, exists := store.pins[peerId]; exists {
+		if !heimdall.MatchKeyID(pin.KeyID, pub) {
+			return false, ErrPinMismatch
+		}
+		return false, nil
+	}
+
+	if err := store.pin(peerId, pub); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Repin replaces pinned key of the peer, after the operator confirmed the key change is legitimate.
+func (store *PinStore) Repin(peerId string, pub heimdall.PubKey) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.pin(peerId, pub)
+}
+
+// Pinned returns pin of the peer.
+func (store *PinStore) Pinned(peerId string) (*Pin, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	pin, exists := store.pins[peerId]
+	if !exists {
+		return nil, ErrPinNotFound
+	}
+
+	return pin, nil
+}
+
+// Remove forgets pin of the peer, so its next key is trusted on first use again.
+func (store *PinStore) Remove(peerId string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if _, exists := store.pins[peerId]; !exists {
+		return ErrPinNotFound
+	}
+	delete(store.pins, peerId)
+
+	return store.save()
+}
+
+func (store *PinStore) pin(peerId string, pub heimdall.PubKey) error {
+	pubBytes, err := pub.ToByte()
+	if err != nil {
+		return err
+	}
+
+	previous, existed := store.pins[peerId]
+	store.pins[peerId] = &Pin{
+		PeerID:    peerId,
+		KeyID:     pub.ID(),
+		PubKey:    pubBytes,
+		FirstSeen: time.Now(),
+	}
+
+	if err := store.save(); err != nil {
+		if existed {
+			store.pins[peerId] = previous
+		} else {
+			delete(store.pins, peerId)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// save writes pins to a temporary file and renames it, so the pins file is never partially written.
+func (store *PinStore) save() error {
+	data, err := json.Marshal(store.pins)
+	if err != nil {
+		return err
+	}
+
+	if err := fileperm.MkdirAll(filepath.Dir(store.pinsPath)); err != nil {
+		return err
+	}
+
+	return fileperm.WriteFileAtomic(store.pinsPath, store.pinsPath+".tmp", data)
+}
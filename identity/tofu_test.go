@@ -0,0 +1,122 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package identity_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/identity"
+	"github.com/stretchr/testify/assert"
+)
+
+func generatePubKey(t *testing.T) heimdall.PubKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri.PublicKey()
+}
+
+func TestPinStore_Check(t *testing.T) {
+	// given
+	pinsPath := filepath.Join(heimdall.TestKeyDir, "pins.json")
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	store, err := identity.NewPinStore(pinsPath)
+	assert.NoError(t, err)
+
+	pub := generatePubKey(t)
+	changed := generatePubKey(t)
+
+	// when
+	firstUse, err := store.Check("node1", pub)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, firstUse)
+
+	// when
+	firstUse, err = store.Check("node1", pub)
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, firstUse)
+
+	// when pins are loaded again
+	store, err = identity.NewPinStore(pinsPath)
+	assert.NoError(t, err)
+	_, err = store.Check("node1", changed)
+
+	// then
+	assert.Equal(t, identity.ErrPinMismatch, err)
+	pin, err := store.Pinned("node1")
+	assert.NoError(t, err)
+	assert.Equal(t, pub.ID(), pin.KeyID)
+}
+
+func TestPinStore_Repin(t *testing.T) {
+	// given
+	pinsPath := filepath.Join(heimdall.TestKeyDir, "pins.json")
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	store, err := identity.NewPinStore(pinsPath)
+	assert.NoError(t, err)
+
+	pub := generatePubKey(t)
+	changed := generatePubKey(t)
+	_, err = store.Check("node1", pub)
+	assert.NoError(t, err)
+
+	// when
+	err = store.Repin("node1", changed)
+
+	// then
+	assert.NoError(t, err)
+	firstUse, err := store.Check("node1", changed)
+	assert.NoError(t, err)
+	assert.False(t, firstUse)
+}
+
+func TestPinStore_Remove(t *testing.T) {
+	// given
+	pinsPath := filepath.Join(heimdall.TestKeyDir, "pins.json")
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	store, err := identity.NewPinStore(pinsPath)
+	assert.NoError(t, err)
+	_, err = store.Check("node1", generatePubKey(t))
+	assert.NoError(t, err)
+
+	// when
+	err = store.Remove("node1")
+
+	// then
+	assert.NoError(t, err)
+	_, err = store.Pinned("node1")
+	assert.Equal(t, identity.ErrPinNotFound, err)
+	assert.Equal(t, identity.ErrPinNotFound, store.Remove("node1"))
+
+	firstUse, err := store.Check("node1", generatePubKey(t))
+	assert.NoError(t, err)
+	assert.True(t, firstUse)
+}
@@ -0,0 +1,459 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides conversion between keys and JSON Web Keys (RFC 7517), for interoperability with JOSE based services.
+
+package jwk
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"errors"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/hx25519"
+)
+
+var ErrKeyNotSupported = errors.New("key not supported by JWK - key should be ECDSA, RSA, Ed25519 or X25519 key")
+var ErrInvalidJWK = errors.New("invalid JWK - key type, curve or key parameters are not valid")
+var ErrKidMismatch = errors.New("kid mismatch - kid of JWK is not key ID of its key")
+
+// key types of JWK (RFC 7518, RFC 8037)
+const (
+	KtyEC  = "EC"
+	KtyRSA = "RSA"
+	KtyOKP = "OKP"
+)
+
+// public key uses of JWK
+const (
+	UseSig = "sig"
+	UseEnc = "enc"
+)
+
+// JWK is JSON Web Key of ECDSA (including secp256k1 of RFC 8812), RSA, Ed25519 or X25519 key.
+// Kid is key ID of the key, which is derived from SKI, so the key can be found in key store by kid.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Crv string `json:"crv,omitempty"`
+
+	X string `json:"x,omitempty"`
+	Y string `json:"y,omitempty"`
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	D  string `json:"d,omitempty"`
+	P  string `json:"p,omitempty"`
+	Q  string `json:"q,omitempty"`
+	DP string `json:"dp,omitempty"`
+	DQ string `json:"dq,omitempty"`
+	QI string `json:"qi,omitempty"`
+}
+
+// ecAlgs maps curves of ECDSA keys to their JWS algorithms.
+var ecAlgs = map[string]string{
+	hecdsa.ECP256:      "ES256",
+	hecdsa.ECP384:      "ES384",
+	hecdsa.ECP521:      "ES512",
+	hecdsa.ECSECP256K1: "ES256K",
+}
+
+// FromKey converts public or private key to JWK. Private parameters are included only for private key.
+func FromKey(key heimdall.Key) (*JWK, error) {
+	pub := key
+	if pri, ok := key.(heimdall.PriKey); ok {
+		pub = pri.PublicKey()
+	}
+
+	jwk := &JWK{Kid: key.ID()}
+	var err error
+	switch key.KeyGenOpt().Algorithm() {
+	case heimdall.ECDSA:
+		err = jwk.setECKey(key, pub)
+	case heimdall.RSA:
+		err = jwk.setRSAKey(key, pub)
+	case heimdall.ED25519:
+		err = jwk.setEd25519Key(key, pub)
+	case heimdall.X25519:
+		err = jwk.setX25519Key(key, pub)
+	default:
+		err = ErrKeyNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return jwk, nil
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// setECKey sets parameters of ECDSA key. Keys on curves which x509 does not know are decoded from their encoding directly.
+func (jwk *JWK) setECKey(key, pub heimdall.Key) error {
+	curve := pub.KeyGenOpt().ToString()
+	alg, ok := ecAlgs[curve]
+	if !ok {
+		return ErrKeyNotSupported
+	}
+	size := (pub.KeyGenOpt().KeySize() + 7) / 8
+
+	pubBytes, err := pub.ToByte()
+	if err != nil {
+		return err
+	}
+	var info subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(pubBytes, &info); err != nil {
+		return err
+	}
+	// uncompressed point is 0x04 || X || Y
+	point := info.PublicKey.RightAlign()
+	if len(point) != 1+2*size || point[0] != 4 {
+		return ErrKeyNotSupported
+	}
+
+	jwk.Kty, jwk.Crv, jwk.Alg, jwk.Use = KtyEC, curve, alg, UseSig
+	jwk.X = encode(point[1 : 1+size])
+	jwk.Y = encode(point[1+size:])
+
+	if !key.IsPrivate() {
+		return nil
+	}
+
+	priBytes, err := key.ToByte()
+	if err != nil {
+		return err
+	}
+	var privateKey ecPrivateKey
+	if _, err := asn1.Unmarshal(priBytes, &privateKey); err != nil {
+		return err
+	}
+	jwk.D = encode(new(big.Int).SetBytes(privateKey.PrivateKey).FillBytes(make([]byte, size)))
+
+	return nil
+}
+
+func (jwk *JWK) setRSAKey(key, pub heimdall.Key) error {
+	pubBytes, err := pub.ToByte()
+	if err != nil {
+		return err
+	}
+	rsaPub, err := x509.ParsePKIXPublicKey(pubBytes)
+	if err != nil {
+		return err
+	}
+
+	jwk.Kty, jwk.Use = KtyRSA, UseSig
+	jwk.N = encode(rsaPub.(*rsa.PublicKey).N.Bytes())
+	jwk.E = encode(big.NewInt(int64(rsaPub.(*rsa.PublicKey).E)).Bytes())
+
+	if !key.IsPrivate() {
+		return nil
+	}
+
+	priBytes, err := key.ToByte()
+	if err != nil {
+		return err
+	}
+	rsaPri, err := x509.ParsePKCS1PrivateKey(priBytes)
+	if err != nil {
+		return err
+	}
+	if len(rsaPri.Primes) != 2 {
+		return ErrKeyNotSupported
+	}
+
+	jwk.D = encode(rsaPri.D.Bytes())
+	jwk.P = encode(rsaPri.Primes[0].Bytes())
+	jwk.Q = encode(rsaPri.Primes[1].Bytes())
+	jwk.DP = encode(rsaPri.Precomputed.Dp.Bytes())
+	jwk.DQ = encode(rsaPri.Precomputed.Dq.Bytes())
+	jwk.QI = encode(rsaPri.Precomputed.Qinv.Bytes())
+
+	return nil
+}
+
+func (jwk *JWK) setEd25519Key(key, pub heimdall.Key) error {
+	pubBytes, err := pub.ToByte()
+	if err != nil {
+		return err
+	}
+	edPub, err := x509.ParsePKIXPublicKey(pubBytes)
+	if err != nil {
+		return err
+	}
+
+	jwk.Kty, jwk.Crv, jwk.Alg, jwk.Use = KtyOKP, "Ed25519", "EdDSA", UseSig
+	jwk.X = encode(edPub.(ed25519.PublicKey))
+
+	if !key.IsPrivate() {
+		return nil
+	}
+
+	priBytes, err := key.ToByte()
+	if err != nil {
+		return err
+	}
+	edPri, err := x509.ParsePKCS8PrivateKey(priBytes)
+	if err != nil {
+		return err
+	}
+	jwk.D = encode(edPri.(ed25519.PrivateKey).Seed())
+
+	return nil
+}
+
+func (jwk *JWK) setX25519Key(key, pub heimdall.Key) error {
+	pubBytes, err := pub.ToByte()
+	if err != nil {
+		return err
+	}
+	xPub, err := x509.ParsePKIXPublicKey(pubBytes)
+	if err != nil {
+		return err
+	}
+
+	jwk.Kty, jwk.Crv, jwk.Use = KtyOKP, "X25519", UseEnc
+	jwk.X = encode(xPub.(*ecdh.PublicKey).Bytes())
+
+	if !key.IsPrivate() {
+		return nil
+	}
+
+	priBytes, err := key.ToByte()
+	if err != nil {
+		return err
+	}
+	xPri, err := x509.ParsePKCS8PrivateKey(priBytes)
+	if err != nil {
+		return err
+	}
+	jwk.D = encode(xPri.(*ecdh.PrivateKey).Bytes())
+
+	return nil
+}
+
+// IsPrivate returns true if JWK has private parameters.
+func (jwk *JWK) IsPrivate() bool {
+	return jwk.D != ""
+}
+
+// Public returns copy of JWK without private parameters, which can be published.
+func (jwk *JWK) Public() *JWK {
+	pub := *jwk
+	pub.D, pub.P, pub.Q, pub.DP, pub.DQ, pub.QI = "", "", "", "", "", ""
+
+	return &pub
+}
+
+// ToKey converts JWK to private key if it has private parameters, or to public key.
+// Algorithm of the key is detected by kty and crv. If kid is set, it should be key ID of the key.
+func (jwk *JWK) ToKey() (heimdall.Key, error) {
+	var key heimdall.Key
+	var err error
+
+	switch {
+	case jwk.Kty == KtyEC:
+		key, err = jwk.toECKey()
+	case jwk.Kty == KtyRSA:
+		key, err = jwk.toRSAKey()
+	case jwk.Kty == KtyOKP && jwk.Crv == "Ed25519":
+		key, err = jwk.toEd25519Key()
+	case jwk.Kty == KtyOKP && jwk.Crv == "X25519":
+		key, err = jwk.toX25519Key()
+	default:
+		return nil, ErrKeyNotSupported
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if jwk.Kid != "" && !heimdall.MatchKeyID(jwk.Kid, key) {
+		return nil, ErrKidMismatch
+	}
+
+	return key, nil
+}
+
+func (jwk *JWK) toECKey() (heimdall.Key, error) {
+	if _, ok := ecAlgs[jwk.Crv]; !ok {
+		return nil, ErrKeyNotSupported
+	}
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(jwk.Crv)
+	if err != nil {
+		return nil, err
+	}
+	size := (keyGenOpt.KeySize() + 7) / 8
+
+	x, err := decodeFixed(jwk.X, size)
+	if err != nil {
+		return nil, err
+	}
+	y, err := decodeFixed(jwk.Y, size)
+	if err != nil {
+		return nil, err
+	}
+
+	pub := ecdsa.PublicKey{Curve: keyGenOpt.Curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+		return nil, ErrInvalidJWK
+	}
+	if !jwk.IsPrivate() {
+		return hecdsa.NewPubKey(&pub), nil
+	}
+
+	d, err := decodeFixed(jwk.D, size)
+	if err != nil {
+		return nil, err
+	}
+	pri := &ecdsa.PrivateKey{PublicKey: pub, D: new(big.Int).SetBytes(d)}
+	if pri.D.Sign() <= 0 || pri.D.Cmp(pub.Curve.Params().N) >= 0 {
+		return nil, ErrInvalidJWK
+	}
+	if x, y := pub.Curve.ScalarBaseMult(d); x.Cmp(pub.X) != 0 || y.Cmp(pub.Y) != 0 {
+		return nil, ErrInvalidJWK
+	}
+
+	return hecdsa.NewPriKey(pri), nil
+}
+
+func (jwk *JWK) toRSAKey() (heimdall.Key, error) {
+	n, err := decodeInt(jwk.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := decodeInt(jwk.E)
+	if err != nil {
+		return nil, err
+	}
+	if !e.IsInt64() || e.Int64() > 1<<31-1 {
+		return nil, ErrInvalidJWK
+	}
+
+	pub := rsa.PublicKey{N: n, E: int(e.Int64())}
+	if !jwk.IsPrivate() {
+		return hrsa.NewPubKey(&pub), nil
+	}
+
+	params := make([]*big.Int, 3)
+	for i, param := range []string{jwk.D, jwk.P, jwk.Q} {
+		if params[i], err = decodeInt(param); err != nil {
+			return nil, err
+		}
+	}
+
+	pri := &rsa.PrivateKey{PublicKey: pub, D: params[0], Primes: params[1:]}
+	if err := pri.Validate(); err != nil {
+		return nil, ErrInvalidJWK
+	}
+	pri.Precompute()
+
+	return hrsa.NewPriKey(pri), nil
+}
+
+func (jwk *JWK) toEd25519Key() (heimdall.Key, error) {
+	x, err := decodeFixed(jwk.X, ed25519.PublicKeySize)
+	if err != nil {
+		return nil, err
+	}
+	if !jwk.IsPrivate() {
+		return hed25519.NewPubKey(ed25519.PublicKey(x)), nil
+	}
+
+	seed, err := decodeFixed(jwk.D, ed25519.SeedSize)
+	if err != nil {
+		return nil, err
+	}
+	pri := ed25519.NewKeyFromSeed(seed)
+	if !pri.Public().(ed25519.PublicKey).Equal(ed25519.PublicKey(x)) {
+		return nil, ErrInvalidJWK
+	}
+
+	return hed25519.NewPriKey(pri), nil
+}
+
+func (jwk *JWK) toX25519Key() (heimdall.Key, error) {
+	x, err := decodeFixed(jwk.X, 32)
+	if err != nil {
+		return nil, err
+	}
+	pub, err := ecdh.X25519().NewPublicKey(x)
+	if err != nil {
+		return nil, ErrInvalidJWK
+	}
+	if !jwk.IsPrivate() {
+		return hx25519.NewPubKey(pub), nil
+	}
+
+	d, err := decodeFixed(jwk.D, 32)
+	if err != nil {
+		return nil, err
+	}
+	pri, err := ecdh.X25519().NewPrivateKey(d)
+	if err != nil || !pri.PublicKey().Equal(pub) {
+		return nil, ErrInvalidJWK
+	}
+
+	return hx25519.NewPriKey(pri), nil
+}
+
+// encode encodes parameter of JWK in base64url without padding.
+func encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeFixed decodes parameter of JWK which should be size bytes.
+func decodeFixed(s string, size int) ([]byte, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) != size {
+		return nil, ErrInvalidJWK
+	}
+
+	return b, nil
+}
+
+// decodeInt decodes parameter of JWK which is unsigned big-endian integer.
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, ErrInvalidJWK
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides JWK Sets (RFC 7517), which publish keys of a node to OIDC style relying parties.
+
+package jwk
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+)
+
+var ErrKeyNotFound = errors.New("key not found - JWK Set has no key of the kid")
+
+// Set is JWK Set, whose keys are found by kid.
+type Set struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// NewSet converts keys into JWK Set.
+func NewSet(keys ...heimdall.Key) (*Set, error) {
+	set := &Set{Keys: make([]*JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwk, err := FromKey(key)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+
+	return set, nil
+}
+
+// ParseSet parses JWK Set in JSON.
+func ParseSet(data []byte) (*Set, error) {
+	set := new(Set)
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, err
+	}
+
+	return set, nil
+}
+
+// Key returns key of kid in the set.
+func (set *Set) Key(kid string) (heimdall.Key, error) {
+	for _, jwk := range set.Keys {
+		if jwk.Kid == kid {
+			return jwk.ToKey()
+		}
+	}
+
+	return nil, ErrKeyNotFound
+}
+
+// Public returns copy of the set without private parameters, which can be published.
+func (set *Set) Public() *Set {
+	pub := &Set{Keys: make([]*JWK, len(set.Keys))}
+	for i, jwk := range set.Keys {
+		pub.Keys[i] = jwk.Public()
+	}
+
+	return pub
+}
+
+// StoreSet writes JWK Set to file of path atomically, which only the owner can access since it may have private keys.
+func StoreSet(set *Set, path string) error {
+	data, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fileperm.WriteFileAtomic(path, path+".tmp", data)
+}
+
+// LoadSet reads JWK Set from file of path.
+func LoadSet(path string) (*Set, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseSet(data)
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package jwk_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+	"github.com/DE-labtory/heimdall/jwk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSet_Key(t *testing.T) {
+	// given
+	keys := setUpKeys(t)
+	set, err := jwk.NewSet(keys[0], keys[1].PublicKey())
+	assert.NoError(t, err)
+
+	// when
+	pri, priErr := set.Key(keys[0].ID())
+	pub, pubErr := set.Key(keys[1].ID())
+	_, notFoundErr := set.Key(keys[2].ID())
+
+	// then
+	assert.NoError(t, priErr)
+	assert.NoError(t, pubErr)
+	assert.True(t, pri.IsPrivate())
+	assert.Equal(t, keys[0].ID(), pri.ID())
+	assert.False(t, pub.IsPrivate())
+	assert.Equal(t, keys[1].ID(), pub.ID())
+	assert.Equal(t, jwk.ErrKeyNotFound, notFoundErr)
+}
+
+func TestSet_Key_KidMismatch(t *testing.T) {
+	// given
+	keys := setUpKeys(t)
+	set, err := jwk.NewSet(keys[0])
+	assert.NoError(t, err)
+	set.Keys[0].Kid = keys[1].ID()
+
+	// when
+	key, err := set.Key(keys[1].ID())
+
+	// then
+	assert.Equal(t, jwk.ErrKidMismatch, err)
+	assert.Nil(t, key)
+}
+
+func TestStoreSet(t *testing.T) {
+	// given
+	keys := setUpKeys(t)
+	set, err := jwk.NewSet(keys[0], keys[4], keys[5])
+	assert.NoError(t, err)
+	assert.NoError(t, fileperm.MkdirAll(heimdall.TestKeyDir))
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	path := filepath.Join(heimdall.TestKeyDir, "jwks.json")
+
+	// when
+	err = jwk.StoreSet(set.Public(), path)
+
+	// then
+	assert.NoError(t, err)
+	loaded, err := jwk.LoadSet(path)
+	assert.NoError(t, err)
+	assert.Equal(t, set.Public(), loaded)
+	for _, key := range loaded.Keys {
+		assert.False(t, key.IsPrivate())
+	}
+}
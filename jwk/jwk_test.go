@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package jwk_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/hx25519"
+	"github.com/DE-labtory/heimdall/jwk"
+	"github.com/stretchr/testify/assert"
+)
+
+// example keys of RFC 7517 (Appendix A.2) and RFC 8037 (Appendix A.1)
+const rfc7517ECKey = `{"kty":"EC","crv":"P-256","x":"MKBCTNIcKUSDii11ySs3526iDZ8AiTo7Tu6KPAqv7D4","y":"4Etl6SRW2YiLUrN5vfvVHuhp7x8PxltmWWlbbM4IFyM","d":"870MB6gfuTJ4HtUnUvYMyJpr5eUZNP4Bk43bVdj3eAE","use":"enc","kid":"1"}`
+const rfc8037Ed25519Key = `{"kty":"OKP","crv":"Ed25519","d":"nWGxne_9WmC6hEr0kuwsxERJxWl7MmkZcDusAxyuf2A","x":"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}`
+
+func setUpKeys(t *testing.T) []heimdall.PriKey {
+	keys := make([]heimdall.PriKey, 0)
+	for _, curve := range []string{hecdsa.ECP256, hecdsa.ECP384, hecdsa.ECP521, hecdsa.ECSECP256K1} {
+		keyGenOpt, err := hecdsa.NewKeyGenOpt(curve)
+		assert.NoError(t, err)
+		pri, err := hecdsa.GenerateKey(keyGenOpt)
+		assert.NoError(t, err)
+		keys = append(keys, pri)
+	}
+
+	rsaKeyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA2048)
+	assert.NoError(t, err)
+	rsaPri, err := hrsa.GenerateKey(rsaKeyGenOpt)
+	assert.NoError(t, err)
+
+	ed25519KeyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	ed25519Pri, err := hed25519.GenerateKey(ed25519KeyGenOpt)
+	assert.NoError(t, err)
+
+	x25519KeyGenOpt, err := hx25519.NewKeyGenOpt(hx25519.X25519)
+	assert.NoError(t, err)
+	x25519Pri, err := hx25519.GenerateKey(x25519KeyGenOpt)
+	assert.NoError(t, err)
+
+	return append(keys, rsaPri, ed25519Pri, x25519Pri)
+}
+
+func TestFromKey(t *testing.T) {
+	// given
+	keys := setUpKeys(t)
+
+	for _, pri := range keys {
+		// when
+		priJWK, priErr := jwk.FromKey(pri)
+		pubJWK, pubErr := jwk.FromKey(pri.PublicKey())
+
+		// then
+		assert.NoError(t, priErr)
+		assert.NoError(t, pubErr)
+		assert.Equal(t, pri.ID(), priJWK.Kid)
+		assert.True(t, priJWK.IsPrivate())
+		assert.False(t, pubJWK.IsPrivate())
+		assert.Equal(t, pubJWK, priJWK.Public())
+
+		recoveredPri, err := priJWK.ToKey()
+		assert.NoError(t, err)
+		assert.True(t, recoveredPri.IsPrivate())
+		assert.Equal(t, pri.ID(), recoveredPri.ID())
+		recoveredPub, err := pubJWK.ToKey()
+		assert.NoError(t, err)
+		assert.False(t, recoveredPub.IsPrivate())
+		assert.Equal(t, pri.ID(), recoveredPub.ID())
+	}
+}
+
+func TestToKey_RFCExamples(t *testing.T) {
+	for _, example := range []string{rfc7517ECKey, rfc8037Ed25519Key} {
+		// given
+		expected := new(jwk.JWK)
+		assert.NoError(t, json.Unmarshal([]byte(example), expected))
+		// kid of RFC examples is not key ID of heimdall
+		expected.Kid = ""
+
+		// when
+		key, err := expected.ToKey()
+
+		// then
+		assert.NoError(t, err)
+		actual, err := jwk.FromKey(key)
+		assert.NoError(t, err)
+		assert.Equal(t, expected.X, actual.X)
+		assert.Equal(t, expected.Y, actual.Y)
+		assert.Equal(t, expected.D, actual.D)
+	}
+}
+
+func TestToKey_KidMismatch(t *testing.T) {
+	// given
+	keys := setUpKeys(t)
+	mismatchJWK, err := jwk.FromKey(keys[0])
+	assert.NoError(t, err)
+	mismatchJWK.Kid = keys[1].ID()
+	rfcJWK := new(jwk.JWK)
+	assert.NoError(t, json.Unmarshal([]byte(rfc7517ECKey), rfcJWK))
+
+	// when
+	mismatchKey, mismatchErr := mismatchJWK.ToKey()
+	_, rfcErr := rfcJWK.ToKey()
+
+	// then
+	assert.Equal(t, jwk.ErrKidMismatch, mismatchErr)
+	assert.Nil(t, mismatchKey)
+	assert.Equal(t, jwk.ErrKidMismatch, rfcErr)
+}
+
+func TestToKey_Invalid(t *testing.T) {
+	// given
+	ecJWK := new(jwk.JWK)
+	assert.NoError(t, json.Unmarshal([]byte(rfc7517ECKey), ecJWK))
+	ecJWK.D = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAE"
+	unknownJWK := &jwk.JWK{Kty: "oct"}
+
+	// when
+	_, invalidErr := ecJWK.ToKey()
+	_, unknownErr := unknownJWK.ToKey()
+
+	// then
+	assert.Equal(t, jwk.ErrInvalidJWK, invalidErr)
+	assert.Equal(t, jwk.ErrKeyNotSupported, unknownErr)
+}
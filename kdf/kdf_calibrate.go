@@ -0,0 +1,155 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides calibration of KDF parameters, so that key derivation takes about the same time on any host.
+
+package kdf
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/scrypt"
+)
+
+var ErrInvalidCalibrationTarget = errors.New("invalid calibration target - target duration should be positive")
+var ErrKdfNotCalibratable = errors.New("kdf not calibratable - only scrypt and argon2id parameters can be calibrated")
+
+// DefaultCalibrationTarget is derivation time commonly recommended for interactive login.
+var DefaultCalibrationTarget = 500 * time.Millisecond
+
+const (
+	// calibrationScryptN is N measured for calibration, which is the minimum N of calibrated parameters.
+	calibrationScryptN = 1 << 14
+
+	// minArgon2idMemory is the minimum memory (KiB) of calibrated argon2id parameters, when one pass over
+	// default memory takes longer than target.
+	minArgon2idMemory = 19 * 1024
+)
+
+var calibrationPwd = []byte("heimdall calibration")
+
+// Calibration is result of calibration, which holds parameters of KDFs for the target duration.
+type Calibration struct {
+	ScryptParams   map[string]string
+	Argon2idParams map[string]string
+}
+
+// Calibrate benchmarks the host, and returns scrypt and argon2id parameters deriving a key in about target duration.
+// Parameters are kept within DefaultLimits, so that keys encrypted with them can be loaded by other hosts.
+func Calibrate(targetDuration time.Duration) (*Calibration, error) {
+	if targetDuration <= 0 {
+		return nil, ErrInvalidCalibrationTarget
+	}
+
+	scryptParams, err := calibrateScrypt(targetDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	argon2idParams := calibrateArgon2id(targetDuration)
+
+	return &Calibration{
+		ScryptParams:   scryptParams,
+		Argon2idParams: argon2idParams,
+	}, nil
+}
+
+// CalibrateOpts returns option of key derivation function of name, with parameters calibrated for target duration.
+func CalibrateOpts(kdfName string, targetDuration time.Duration) (*Opts, error) {
+	if targetDuration <= 0 {
+		return nil, ErrInvalidCalibrationTarget
+	}
+
+	switch kdfName {
+	case SCRYPT:
+		scryptParams, err := calibrateScrypt(targetDuration)
+		if err != nil {
+			return nil, err
+		}
+		return NewOpts(SCRYPT, scryptParams)
+	case ARGON2ID:
+		return NewOpts(ARGON2ID, calibrateArgon2id(targetDuration))
+	default:
+		return nil, ErrKdfNotCalibratable
+	}
+}
+
+// calibrateScrypt doubles N while estimated time is within target, since time of scrypt is proportional to N.
+func calibrateScrypt(targetDuration time.Duration) (map[string]string, error) {
+	R, P := 8, 1
+
+	start := time.Now()
+	if _, err := scrypt.Key(calibrationPwd, TestSalt, calibrationScryptN, R, P, 32); err != nil {
+		return nil, err
+	}
+	elapsed := measured(start)
+
+	N := calibrationScryptN
+	for elapsed*time.Duration(2*N/calibrationScryptN) <= targetDuration &&
+		DefaultLimits.checkScrypt(int64(2*N), int64(R), int64(P)) == nil {
+		N *= 2
+	}
+
+	return map[string]string{
+		"N": strconv.Itoa(N),
+		"R": strconv.Itoa(R),
+		"P": strconv.Itoa(P),
+	}, nil
+}
+
+// calibrateArgon2id keeps default memory and parallelism, and adds passes over the memory while estimated time
+// is within target. If one pass takes longer than target, memory is reduced instead.
+func calibrateArgon2id(targetDuration time.Duration) map[string]string {
+	memory, _ := strconv.ParseUint(DefaultArgon2idMemory, 10, 32)
+	parallelism, _ := strconv.ParseUint(DefaultArgon2idParallelism, 10, 8)
+
+	start := time.Now()
+	argon2.IDKey(calibrationPwd, TestSalt, 1, uint32(memory), uint8(parallelism), 32)
+	elapsed := measured(start)
+
+	for elapsed > targetDuration && memory/2 >= minArgon2idMemory {
+		memory /= 2
+		elapsed /= 2
+	}
+
+	iteration := int64(targetDuration / elapsed)
+	if iteration < 1 {
+		iteration = 1
+	}
+	if iteration > DefaultLimits.MaxArgon2idIteration {
+		iteration = DefaultLimits.MaxArgon2idIteration
+	}
+
+	return map[string]string{
+		"memory":      strconv.FormatUint(memory, 10),
+		"iteration":   strconv.FormatInt(iteration, 10),
+		"parallelism": strconv.FormatUint(parallelism, 10),
+	}
+}
+
+// measured returns time since start, which is at least 1ns so that it can divide target duration.
+func measured(start time.Time) time.Duration {
+	elapsed := time.Since(start)
+	if elapsed <= 0 {
+		return time.Nanosecond
+	}
+
+	return elapsed
+}
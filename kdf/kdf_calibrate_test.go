@@ -0,0 +1,64 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kdf_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalibrate(t *testing.T) {
+	// when
+	calibration, err := kdf.Calibrate(time.Millisecond)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "16384", calibration.ScryptParams["N"])
+	assert.Equal(t, "1", calibration.Argon2idParams["iteration"])
+
+	scryptOpt, err := kdf.NewOpts(kdf.SCRYPT, calibration.ScryptParams)
+	assert.NoError(t, err)
+	assert.NoError(t, kdf.CheckLimits(scryptOpt))
+
+	argon2idOpt, err := kdf.NewOpts(kdf.ARGON2ID, calibration.Argon2idParams)
+	assert.NoError(t, err)
+	assert.NoError(t, kdf.CheckLimits(argon2idOpt))
+}
+
+func TestCalibrate_InvalidTarget(t *testing.T) {
+	// when
+	calibration, err := kdf.Calibrate(0)
+
+	// then
+	assert.Nil(t, calibration)
+	assert.Equal(t, kdf.ErrInvalidCalibrationTarget, err)
+}
+
+func TestCalibrateOpts(t *testing.T) {
+	// when
+	kdfOpt, err := kdf.CalibrateOpts(kdf.SCRYPT, time.Millisecond)
+	_, notCalibratableErr := kdf.CalibrateOpts(kdf.PBKDF2, time.Millisecond)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, kdf.SCRYPT, kdfOpt.KdfName)
+	assert.Equal(t, kdf.ErrKdfNotCalibratable, notCalibratableErr)
+}
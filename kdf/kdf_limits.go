@@ -0,0 +1,125 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides upper bounds of KDF parameters, so that hostile parameters in key files can not exhaust memory or CPU.
+
+package kdf
+
+import (
+	"errors"
+	"math"
+)
+
+var ErrScryptMemoryExceedsLimit = errors.New("scrypt memory exceeds limit - 128 * N * R bytes should not exceed MaxScryptMemory of kdf limits")
+var ErrScryptCostExceedsLimit = errors.New("scrypt cost exceeds limit - N * R * P should not exceed MaxScryptCost of kdf limits")
+var ErrPbkdf2IterationExceedsLimit = errors.New("pbkdf2 iteration exceeds limit - [iteration] should not exceed MaxPbkdf2Iteration of kdf limits")
+var ErrArgon2idMemoryExceedsLimit = errors.New("argon2id memory exceeds limit - 1024 * [memory] bytes should not exceed MaxArgon2idMemory of kdf limits")
+var ErrArgon2idIterationExceedsLimit = errors.New("argon2id iteration exceeds limit - [iteration] should not exceed MaxArgon2idIteration of kdf limits")
+
+// Limits are upper bounds of KDF parameters read from untrusted sources such as key files.
+type Limits struct {
+	// MaxScryptMemory is the maximum bytes scrypt may allocate (128 * N * R + 128 * R * P).
+	MaxScryptMemory int64
+	// MaxScryptCost is the maximum N * R * P, which is proportional to time of scrypt.
+	MaxScryptCost int64
+	// MaxPbkdf2Iteration is the maximum iteration count of pbkdf2.
+	MaxPbkdf2Iteration int64
+	// MaxArgon2idMemory is the maximum bytes argon2id may allocate (1024 * memory).
+	MaxArgon2idMemory int64
+	// MaxArgon2idIteration is the maximum passes of argon2id over the memory.
+	MaxArgon2idIteration int64
+}
+
+// DefaultLimits allow twice of default parameters, and can be replaced to tighten or loosen the bounds.
+var DefaultLimits = Limits{
+	MaxScryptMemory:      2 << 30, // 2 GiB
+	MaxScryptCost:        1 << 24,
+	MaxPbkdf2Iteration:   20000000,
+	MaxArgon2idMemory:    2 << 30, // 2 GiB, the first recommended option of RFC 9106
+	MaxArgon2idIteration: 64,
+}
+
+// CheckLimits checks that parameters of kdfOpt are within DefaultLimits.
+func CheckLimits(kdfOpt *Opts) error {
+	return DefaultLimits.Check(kdfOpt)
+}
+
+// Check checks that parameters of kdfOpt are within limits.
+func (limits Limits) Check(kdfOpt *Opts) error {
+	switch kdfOpt.KdfName {
+	case SCRYPT:
+		N, R, P, err := scryptParamsFromMap(kdfOpt.KdfParams)
+		if err != nil {
+			return err
+		}
+		return limits.checkScrypt(int64(N), int64(R), int64(P))
+	case PBKDF2:
+		iteration, _, err := pbkdf2ParamsFromMap(kdfOpt.KdfParams)
+		if err != nil {
+			return err
+		}
+		if int64(iteration) > limits.MaxPbkdf2Iteration {
+			return ErrPbkdf2IterationExceedsLimit
+		}
+		return nil
+	case ARGON2ID:
+		memory, iteration, _, err := argon2idParamsFromMap(kdfOpt.KdfParams)
+		if err != nil {
+			return err
+		}
+		if int64(memory)*1024 > limits.MaxArgon2idMemory {
+			return ErrArgon2idMemoryExceedsLimit
+		}
+		if int64(iteration) > limits.MaxArgon2idIteration {
+			return ErrArgon2idIterationExceedsLimit
+		}
+		return nil
+	case HKDF:
+		// cost of hkdf does not depend on parameters
+		return nil
+	default:
+		return ErrKdfNotSupported
+	}
+}
+
+// checkScrypt divides limits instead of multiplying parameters, so that huge parameters can not overflow.
+func (limits Limits) checkScrypt(N, R, P int64) error {
+	if R > math.MaxInt64/128 {
+		return ErrScryptMemoryExceedsLimit
+	}
+
+	maxBlocks := limits.MaxScryptMemory / (128 * R)
+	if N > maxBlocks || P > maxBlocks-N {
+		return ErrScryptMemoryExceedsLimit
+	}
+
+	if N > limits.MaxScryptCost/R || N*R > limits.MaxScryptCost/P {
+		return ErrScryptCostExceedsLimit
+	}
+
+	return nil
+}
+
+// DeriveKeyWithLimits derives a key after checking that parameters of kdfOpt are within DefaultLimits.
+// Use this instead of DeriveKey when kdfOpt is read from key files or other untrusted sources.
+func DeriveKeyWithLimits(pwd []byte, salt []byte, keyLen int, kdfOpt *Opts) ([]byte, error) {
+	if err := CheckLimits(kdfOpt); err != nil {
+		return nil, err
+	}
+
+	return DeriveKey(pwd, salt, keyLen, kdfOpt)
+}
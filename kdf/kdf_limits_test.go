@@ -0,0 +1,90 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package kdf_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits_Check(t *testing.T) {
+	// given
+	limits := kdf.Limits{MaxScryptMemory: 64 << 20, MaxScryptCost: 1 << 20, MaxPbkdf2Iteration: 100000, MaxArgon2idMemory: 64 << 20, MaxArgon2idIteration: 4}
+	tests := map[string]struct {
+		kdfName   string
+		kdfParams map[string]string
+		err       error
+	}{
+		"scrypt within limits":    {kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"}, nil},
+		"scrypt huge N":           {kdf.SCRYPT, map[string]string{"N": "1073741824", "R": "8", "P": "1"}, kdf.ErrScryptMemoryExceedsLimit},
+		"scrypt overflowing R":    {kdf.SCRYPT, map[string]string{"N": "16384", "R": "9223372036854775807", "P": "1"}, kdf.ErrScryptMemoryExceedsLimit},
+		"scrypt huge P":           {kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1024"}, kdf.ErrScryptCostExceedsLimit},
+		"pbkdf2 within limits":    {kdf.PBKDF2, map[string]string{"iteration": "10000", "hashOpt": "SHA256"}, nil},
+		"pbkdf2 huge iteration":   {kdf.PBKDF2, map[string]string{"iteration": "100000000", "hashOpt": "SHA256"}, kdf.ErrPbkdf2IterationExceedsLimit},
+		"argon2id within limits":  {kdf.ARGON2ID, map[string]string{"memory": "65536", "iteration": "3", "parallelism": "4"}, nil},
+		"argon2id huge memory":    {kdf.ARGON2ID, map[string]string{"memory": "4194304", "iteration": "3", "parallelism": "4"}, kdf.ErrArgon2idMemoryExceedsLimit},
+		"argon2id huge iteration": {kdf.ARGON2ID, map[string]string{"memory": "65536", "iteration": "100", "parallelism": "4"}, kdf.ErrArgon2idIterationExceedsLimit},
+		"hkdf":                    {kdf.HKDF, map[string]string{"hashOpt": "SHA384"}, nil},
+		"not supported function":  {"ARGON", map[string]string{}, kdf.ErrKdfNotSupported},
+	}
+
+	for testName, test := range tests {
+		t.Logf("running test case [%s]", testName)
+
+		// when
+		err := limits.Check(&kdf.Opts{KdfName: test.kdfName, KdfParams: test.kdfParams})
+
+		// then
+		assert.Equal(t, test.err, err)
+	}
+}
+
+func TestCheckLimits(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, kdf.DefaultScryptParams)
+	assert.NoError(t, err)
+	pbkdf2Opt, err := kdf.NewOpts(kdf.PBKDF2, kdf.DefaultPbkdf2Params)
+	assert.NoError(t, err)
+	argon2idOpt, err := kdf.NewOpts(kdf.ARGON2ID, kdf.DefaultArgon2idParams)
+	assert.NoError(t, err)
+
+	// when
+	scryptErr := kdf.CheckLimits(kdfOpt)
+	pbkdf2Err := kdf.CheckLimits(pbkdf2Opt)
+	argon2idErr := kdf.CheckLimits(argon2idOpt)
+
+	// then
+	assert.NoError(t, scryptErr)
+	assert.NoError(t, pbkdf2Err)
+	assert.NoError(t, argon2idErr)
+}
+
+func TestDeriveKeyWithLimits(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "4611686018427387904", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+
+	// when
+	dKey, err := kdf.DeriveKeyWithLimits([]byte("password"), kdf.TestSalt, 256, kdfOpt)
+
+	// then
+	assert.Nil(t, dKey)
+	assert.Equal(t, kdf.ErrScryptMemoryExceedsLimit, err)
+}
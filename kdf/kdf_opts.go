@@ -41,6 +41,20 @@ var ErrPbkdf2IterationValueZeroOrNegative = errors.New("pbkdf2 [iteration] shoul
 var ErrPbkdf2HashOptValueNotExist = errors.New("input parameters have no [hashOpt], pbkdf2 parameters should have [hashOpt]")
 var ErrPbkdf2HashOptValueZeroOrNegative = errors.New("invalid hash option [hashOpt]")
 
+var ErrArgon2idParamsNumber = errors.New("number of argon2id parameters should be 3")
+var ErrArgon2idMemoryValueNotExist = errors.New("input parameters have no [memory], argon2id parameters should have [memory]")
+var ErrArgon2idIterationValueNotExist = errors.New("input parameters have no [iteration], argon2id parameters should have [iteration]")
+var ErrArgon2idParallelismValueNotExist = errors.New("input parameters have no [parallelism], argon2id parameters should have [parallelism]")
+var ErrArgon2idMemoryValueZeroOrNegative = errors.New("argon2id [memory] should be non-zero and positive value")
+var ErrArgon2idIterationValueZeroOrNegative = errors.New("argon2id [iteration] should be non-zero and positive value")
+var ErrArgon2idParallelismValueOutOfRange = errors.New("argon2id [parallelism] should be between 1 and 255")
+
+var ErrHkdfParamsNumber = errors.New("number of hkdf parameters should be 1 or 2")
+var ErrHkdfHashOptValueNotExist = errors.New("input parameters have no [hashOpt], hkdf parameters should have [hashOpt]")
+var ErrHkdfUnknownParam = errors.New("unknown hkdf parameter - hkdf parameters should be [hashOpt] and optional [info]")
+
+var ErrKdfNotForPassword = errors.New("kdf not for password - key derivation function without work factor should not derive keys from passwords")
+
 // Default scrypt Parameters
 // references
 // https://media.readthedocs.org/pdf/cryptography/stable/cryptography.pdf
@@ -72,6 +86,31 @@ var DefaultPbkdf2Params = map[string]string{
 	"hashOpt":   hashing.SHA384,
 }
 
+// Default Argon2id parameters
+// [memory] is memory cost in KiB, [iteration] is number of passes over the memory and [parallelism] is number of threads.
+// Defaults are the second recommended option of RFC 9106 for memory constrained environments.
+// references
+// https://www.rfc-editor.org/rfc/rfc9106.html#section-4
+// https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html
+var DefaultArgon2idMemory = "65536" // 64 MiB
+var DefaultArgon2idIteration = "3"
+var DefaultArgon2idParallelism = "4"
+
+var DefaultArgon2idParams = map[string]string{
+	"memory":      DefaultArgon2idMemory,
+	"iteration":   DefaultArgon2idIteration,
+	"parallelism": DefaultArgon2idParallelism,
+}
+
+// Default HKDF parameters
+// HKDF derives keys from secrets of high entropy such as ECDH shared secret, not from passwords.
+// [info] is optional context binding derived key to its usage (ex. session key of a protocol).
+// references
+// https://tools.ietf.org/html/rfc5869
+var DefaultHkdfParams = map[string]string{
+	"hashOpt": hashing.SHA384,
+}
+
 // Default Salt Size (byte)
 var DefaultSaltSize = 8
 
@@ -82,6 +121,9 @@ var TestSalt = []byte{0xc8, 0x28, 0xf2, 0x58, 0xa7, 0x6a, 0xad, 0x7b}
 const (
 	SCRYPT = "SCRYPT"
 	PBKDF2 = "PBKDF2"
+	HKDF   = "HKDF"
+
+	ARGON2ID = "ARGON2ID"
 )
 
 type Opts struct {
@@ -102,11 +144,31 @@ func (opt *Opts) initOpt(kdfName string, kdfParams map[string]string) error {
 	case PBKDF2:
 		opt.KdfName = kdfName
 		return opt.initPbkdf2Params(kdfParams)
+	case HKDF:
+		opt.KdfName = kdfName
+		return opt.initHkdfParams(kdfParams)
+	case ARGON2ID:
+		opt.KdfName = kdfName
+		return opt.initArgon2idParams(kdfParams)
 	default:
 		return ErrKdfNotSupported
 	}
+}
 
-	return nil
+// DefaultParamsOf returns default parameters of key derivation function of name.
+func DefaultParamsOf(kdfName string) (map[string]string, error) {
+	switch kdfName {
+	case SCRYPT:
+		return DefaultScryptParams, nil
+	case PBKDF2:
+		return DefaultPbkdf2Params, nil
+	case HKDF:
+		return DefaultHkdfParams, nil
+	case ARGON2ID:
+		return DefaultArgon2idParams, nil
+	default:
+		return nil, ErrKdfNotSupported
+	}
 }
 
 // todo: 좀 더 자세한 제한 수치 (N, R, P)
@@ -155,3 +217,117 @@ func (opt *Opts) initPbkdf2Params(kdfParams map[string]string) error {
 
 	return nil
 }
+
+func (opt *Opts) initArgon2idParams(kdfParams map[string]string) error {
+	if len(kdfParams) != 3 {
+		return ErrArgon2idParamsNumber
+	}
+
+	_, exists := kdfParams["memory"]
+	if !exists {
+		return ErrArgon2idMemoryValueNotExist
+	}
+
+	_, exists = kdfParams["iteration"]
+	if !exists {
+		return ErrArgon2idIterationValueNotExist
+	}
+
+	_, exists = kdfParams["parallelism"]
+	if !exists {
+		return ErrArgon2idParallelismValueNotExist
+	}
+
+	opt.KdfParams = kdfParams
+
+	return nil
+}
+
+func (opt *Opts) initHkdfParams(kdfParams map[string]string) error {
+	if len(kdfParams) != 1 && len(kdfParams) != 2 {
+		return ErrHkdfParamsNumber
+	}
+
+	_, exists := kdfParams["hashOpt"]
+	if !exists {
+		return ErrHkdfHashOptValueNotExist
+	}
+
+	_, exists = kdfParams["info"]
+	if len(kdfParams) == 2 && !exists {
+		return ErrHkdfUnknownParam
+	}
+
+	opt.KdfParams = kdfParams
+
+	return nil
+}
+
+// kdfStrengths ranks key derivation functions for passwords, from the least resistant to hardware attacks.
+// HKDF has no work factor, so key derived from password by HKDF is the weakest.
+var kdfStrengths = map[string]int{
+	HKDF:     1,
+	PBKDF2:   2,
+	SCRYPT:   3,
+	ARGON2ID: 4,
+}
+
+// IsPasswordKDF checks if key derivation function of name has a work factor, so that it stretches passwords against guessing.
+// HKDF has none and derives keys only from secrets of high entropy.
+func IsPasswordKDF(kdfName string) bool {
+	return kdfStrengths[kdfName] > kdfStrengths[HKDF]
+}
+
+// CheckPasswordKDF returns ErrKdfNotForPassword if key derivation function of the option should not derive keys from passwords.
+func CheckPasswordKDF(opt *Opts) error {
+	if opt == nil || !IsPasswordKDF(opt.KdfName) {
+		return ErrKdfNotForPassword
+	}
+
+	return nil
+}
+
+// WeakerThan checks if key derived with the option should be derived again with other option.
+// Option of other function is weaker if the function is less resistant to hardware attacks (HKDF < PBKDF2 < SCRYPT < ARGON2ID),
+// and option of the same function is weaker if any of its cost parameters is lower.
+// Options whose parameters can not be parsed are not compared.
+func (opt *Opts) WeakerThan(other *Opts) bool {
+	if opt.KdfName != other.KdfName {
+		return kdfStrengths[opt.KdfName] < kdfStrengths[other.KdfName] && kdfStrengths[opt.KdfName] > 0
+	}
+
+	switch opt.KdfName {
+	case SCRYPT:
+		N, R, P, err := scryptParamsFromMap(opt.KdfParams)
+		if err != nil {
+			return false
+		}
+		otherN, otherR, otherP, err := scryptParamsFromMap(other.KdfParams)
+		if err != nil {
+			return false
+		}
+		return N < otherN || R < otherR || P < otherP
+	case PBKDF2:
+		iteration, _, err := pbkdf2ParamsFromMap(opt.KdfParams)
+		if err != nil {
+			return false
+		}
+		otherIteration, _, err := pbkdf2ParamsFromMap(other.KdfParams)
+		if err != nil {
+			return false
+		}
+		return iteration < otherIteration
+	case ARGON2ID:
+		memory, iteration, _, err := argon2idParamsFromMap(opt.KdfParams)
+		if err != nil {
+			return false
+		}
+		otherMemory, otherIteration, _, err := argon2idParamsFromMap(other.KdfParams)
+		if err != nil {
+			return false
+		}
+		return memory < otherMemory || iteration < otherIteration
+	default:
+		return false
+	}
+}
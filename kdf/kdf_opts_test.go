@@ -38,3 +38,93 @@ func TestNewOpt(t *testing.T) {
 	assert.Equal(t, kdf.DefaultScryptR, kdfOpt.KdfParams["R"])
 	assert.Equal(t, kdf.DefaultScryptP, kdfOpt.KdfParams["P"])
 }
+
+func TestNewOpt_Hkdf(t *testing.T) {
+	tests := map[string]struct {
+		kdfParams map[string]string
+		err       error
+	}{
+		"default params":  {kdf.DefaultHkdfParams, nil},
+		"with info":       {map[string]string{"hashOpt": "SHA384", "info": "session key"}, nil},
+		"no hash option":  {map[string]string{"info": "session key"}, kdf.ErrHkdfHashOptValueNotExist},
+		"unknown param":   {map[string]string{"hashOpt": "SHA384", "salt": "salt"}, kdf.ErrHkdfUnknownParam},
+		"too many params": {map[string]string{"hashOpt": "SHA384", "info": "a", "salt": "b"}, kdf.ErrHkdfParamsNumber},
+	}
+
+	for testName, test := range tests {
+		t.Logf("running test case [%s]", testName)
+
+		// when
+		_, err := kdf.NewOpts(kdf.HKDF, test.kdfParams)
+
+		// then
+		assert.Equal(t, test.err, err)
+	}
+}
+
+func TestOpts_WeakerThan(t *testing.T) {
+	tests := map[string]struct {
+		kdfName    string
+		kdfParams  map[string]string
+		otherName  string
+		otherParam map[string]string
+		weaker     bool
+	}{
+		"lower scrypt N":        {kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"}, kdf.SCRYPT, kdf.DefaultScryptParams, true},
+		"same scrypt params":    {kdf.SCRYPT, kdf.DefaultScryptParams, kdf.SCRYPT, kdf.DefaultScryptParams, false},
+		"higher scrypt N":       {kdf.SCRYPT, kdf.DefaultScryptParams, kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"}, false},
+		"lower pbkdf2":          {kdf.PBKDF2, map[string]string{"iteration": "1000", "hashOpt": "SHA384"}, kdf.PBKDF2, kdf.DefaultPbkdf2Params, true},
+		"lower argon2id memory": {kdf.ARGON2ID, map[string]string{"memory": "1024", "iteration": "3", "parallelism": "4"}, kdf.ARGON2ID, kdf.DefaultArgon2idParams, true},
+		"pbkdf2 to scrypt":      {kdf.PBKDF2, kdf.DefaultPbkdf2Params, kdf.SCRYPT, kdf.DefaultScryptParams, true},
+		"scrypt to argon2id":    {kdf.SCRYPT, kdf.DefaultScryptParams, kdf.ARGON2ID, kdf.DefaultArgon2idParams, true},
+		"argon2id to scrypt":    {kdf.ARGON2ID, kdf.DefaultArgon2idParams, kdf.SCRYPT, kdf.DefaultScryptParams, false},
+		"old pkcs8 pbkdf2":      {kdf.PBKDF2, map[string]string{"iteration": "2048", "hashOpt": "SHA2-256"}, kdf.PBKDF2, map[string]string{"iteration": "600000", "hashOpt": "SHA2-256"}, true},
+		"hkdf to pbkdf2":        {kdf.HKDF, kdf.DefaultHkdfParams, kdf.PBKDF2, kdf.DefaultPbkdf2Params, true},
+		"scrypt to hkdf":        {kdf.SCRYPT, kdf.DefaultScryptParams, kdf.HKDF, kdf.DefaultHkdfParams, false},
+		"same hkdf params":      {kdf.HKDF, kdf.DefaultHkdfParams, kdf.HKDF, kdf.DefaultHkdfParams, false},
+	}
+
+	for testName, test := range tests {
+		t.Logf("running test case [%s]", testName)
+
+		// given
+		kdfOpt, err := kdf.NewOpts(test.kdfName, test.kdfParams)
+		assert.NoError(t, err)
+		otherOpt, err := kdf.NewOpts(test.otherName, test.otherParam)
+		assert.NoError(t, err)
+
+		// when
+		weaker := kdfOpt.WeakerThan(otherOpt)
+
+		// then
+		assert.Equal(t, test.weaker, weaker)
+	}
+}
+
+func TestCheckPasswordKDF(t *testing.T) {
+	tests := map[string]struct {
+		kdfName   string
+		kdfParams map[string]string
+		err       error
+	}{
+		"scrypt":   {kdf.SCRYPT, kdf.DefaultScryptParams, nil},
+		"pbkdf2":   {kdf.PBKDF2, kdf.DefaultPbkdf2Params, nil},
+		"argon2id": {kdf.ARGON2ID, kdf.DefaultArgon2idParams, nil},
+		"hkdf":     {kdf.HKDF, kdf.DefaultHkdfParams, kdf.ErrKdfNotForPassword},
+	}
+
+	for testName, test := range tests {
+		t.Logf("running test case [%s]", testName)
+
+		// given
+		kdfOpt, err := kdf.NewOpts(test.kdfName, test.kdfParams)
+		assert.NoError(t, err)
+
+		// when
+		err = kdf.CheckPasswordKDF(kdfOpt)
+
+		// then
+		assert.Equal(t, test.err, err)
+		assert.Equal(t, test.err == nil, kdf.IsPasswordKDF(test.kdfName))
+	}
+}
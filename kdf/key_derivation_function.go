@@ -21,9 +21,12 @@ package kdf
 
 import (
 	"hash"
+	"io"
 	"strconv"
 
 	"github.com/DE-labtory/heimdall/hashing"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/hkdf"
 	"golang.org/x/crypto/pbkdf2"
 	"golang.org/x/crypto/scrypt"
 )
@@ -34,11 +37,20 @@ func DeriveKey(pwd []byte, salt []byte, keyLen int, kdfOpt *Opts) (dKey []byte,
 		return deriveKeyWithScrypt(pwd, salt, keyLen, kdfOpt.KdfParams)
 	case PBKDF2:
 		return deriveKeyWithPbkdf2(pwd, salt, keyLen, kdfOpt.KdfParams)
+	case HKDF:
+		return deriveKeyWithHkdf(pwd, salt, keyLen, kdfOpt.KdfParams)
+	case ARGON2ID:
+		return deriveKeyWithArgon2id(pwd, salt, keyLen, kdfOpt.KdfParams)
 	default:
 		return nil, ErrKdfNotSupported
 	}
 }
 
+// DeriveKey implements heimdall KDF, so the option can be used as a key derivation function.
+func (opt *Opts) DeriveKey(pwd []byte, salt []byte, keyLen int) ([]byte, error) {
+	return DeriveKey(pwd, salt, keyLen, opt)
+}
+
 // DeriveKey derives a key from input password.
 func deriveKeyWithScrypt(pwd []byte, salt []byte, keyLen int, scryptParams map[string]string) (dKey []byte, err error) {
 	N, R, P, err := scryptParamsFromMap(scryptParams)
@@ -104,6 +116,56 @@ func pbkdf2ParamsFromMap(pbkdf2Params map[string]string) (iteration int, hashFun
 	return iteration, hashFunction, err
 }
 
+func deriveKeyWithArgon2id(pwd []byte, salt []byte, keyLen int, argon2idParams map[string]string) (dKey []byte, err error) {
+	memory, iteration, parallelism, err := argon2idParamsFromMap(argon2idParams)
+	if err != nil {
+		return nil, err
+	}
+
+	return argon2.IDKey(pwd, salt, iteration, memory, parallelism, uint32(keyLen/8)), nil
+}
+
+func argon2idParamsFromMap(argon2idParams map[string]string) (memory, iteration uint32, parallelism uint8, err error) {
+	memoryValue, err := strconv.ParseUint(argon2idParams["memory"], 10, 32)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if memoryValue == 0 {
+		return 0, 0, 0, ErrArgon2idMemoryValueZeroOrNegative
+	}
+
+	iterationValue, err := strconv.ParseUint(argon2idParams["iteration"], 10, 32)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if iterationValue == 0 {
+		return 0, 0, 0, ErrArgon2idIterationValueZeroOrNegative
+	}
+
+	parallelismValue, err := strconv.ParseUint(argon2idParams["parallelism"], 10, 8)
+	if err != nil || parallelismValue == 0 {
+		return 0, 0, 0, ErrArgon2idParallelismValueOutOfRange
+	}
+
+	return uint32(memoryValue), uint32(iterationValue), uint8(parallelismValue), nil
+}
+
+// deriveKeyWithHkdf derives a key from secret of high entropy such as ECDH shared secret. (RFC 5869)
+func deriveKeyWithHkdf(secret []byte, salt []byte, keyLen int, hkdfParams map[string]string) (dKey []byte, err error) {
+	hashOpt, err := hashing.NewHashOpt(hkdfParams["hashOpt"])
+	if err != nil {
+		return nil, err
+	}
+
+	dKey = make([]byte, keyLen/8)
+	reader := hkdf.New(hashOpt.HashFunc, secret, salt, []byte(hkdfParams["info"]))
+	if _, err := io.ReadFull(reader, dKey); err != nil {
+		return nil, err
+	}
+
+	return dKey, nil
+}
+
 // TODO: json marshalling make integer type to float64 type,,,,
 // TODO: I make the all params as string type before calling KDF, but it should be solved fundamentally (unnecessary Atoi function)
 // TODO: Below is solution for this problem in ethereum.
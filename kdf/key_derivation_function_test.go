@@ -18,12 +18,15 @@
 package kdf_test
 
 import (
+	"encoding/hex"
 	"testing"
 
 	"crypto/rand"
 
+	"github.com/DE-labtory/heimdall/hashing"
 	"github.com/DE-labtory/heimdall/kdf"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/argon2"
 )
 
 func TestDeriveKey(t *testing.T) {
@@ -42,6 +45,16 @@ func TestDeriveKey(t *testing.T) {
 			kdfParams: kdf.DefaultPbkdf2Params,
 			err:       nil,
 		},
+		"argon2id option": {
+			kdfName:   "ARGON2ID",
+			kdfParams: kdf.DefaultArgon2idParams,
+			err:       nil,
+		},
+		"hkdf option": {
+			kdfName:   "HKDF",
+			kdfParams: kdf.DefaultHkdfParams,
+			err:       nil,
+		},
 		"not supported option": {
 			kdfName:   "BCRYPT",
 			kdfParams: kdf.DefaultScryptParams,
@@ -70,3 +83,90 @@ func TestDeriveKey(t *testing.T) {
 	}
 
 }
+
+func TestOpts_DeriveKey(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.PBKDF2, kdf.DefaultPbkdf2Params)
+	assert.NoError(t, err)
+	salt := []byte("saltsalt")
+
+	// when
+	dKey, err := kdfOpt.DeriveKey([]byte("password"), salt, 128)
+
+	// then
+	assert.NoError(t, err)
+	expected, err := kdf.DeriveKey([]byte("password"), salt, 128, kdfOpt)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, dKey)
+}
+
+func TestDeriveKey_Hkdf(t *testing.T) {
+	tests := map[string]struct {
+		info     string
+		expected string
+	}{
+		"with info":    {"session key", "d6300e0939f72880fa52c9f52567fc2e3acd0c02e5fb00c2162d24a9646b7f29"},
+		"without info": {"", "ff57ff7983056cc07fd2f3fde0c311a8b572fef779de889096d983e87099275e"},
+	}
+
+	for testCase, test := range tests {
+		t.Logf("running test case [%s]", testCase)
+
+		// given
+		kdfParams := map[string]string{"hashOpt": hashing.SHA384}
+		if test.info != "" {
+			kdfParams["info"] = test.info
+		}
+		kdfOpt, err := kdf.NewOpts(kdf.HKDF, kdfParams)
+		assert.NoError(t, err)
+
+		// when
+		dKey, err := kdf.DeriveKey([]byte("shared secret"), []byte("saltsalt"), 256, kdfOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, hex.EncodeToString(dKey))
+	}
+}
+
+func TestDeriveKey_Argon2id(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.ARGON2ID, map[string]string{"memory": "1024", "iteration": "2", "parallelism": "1"})
+	assert.NoError(t, err)
+	pwd := []byte("password")
+	salt := []byte("saltsalt")
+
+	// when
+	dKey, err := kdf.DeriveKey(pwd, salt, 256, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, argon2.IDKey(pwd, salt, 2, 1024, 1, 32), dKey)
+}
+
+func TestDeriveKey_Argon2idInvalidParams(t *testing.T) {
+	tests := map[string]struct {
+		kdfParams map[string]string
+		err       error
+	}{
+		"zero memory":      {map[string]string{"memory": "0", "iteration": "2", "parallelism": "1"}, kdf.ErrArgon2idMemoryValueZeroOrNegative},
+		"zero iteration":   {map[string]string{"memory": "1024", "iteration": "0", "parallelism": "1"}, kdf.ErrArgon2idIterationValueZeroOrNegative},
+		"zero parallelism": {map[string]string{"memory": "1024", "iteration": "2", "parallelism": "0"}, kdf.ErrArgon2idParallelismValueOutOfRange},
+		"huge parallelism": {map[string]string{"memory": "1024", "iteration": "2", "parallelism": "256"}, kdf.ErrArgon2idParallelismValueOutOfRange},
+	}
+
+	for testName, test := range tests {
+		t.Logf("running test case [%s]", testName)
+
+		// given
+		kdfOpt, err := kdf.NewOpts(kdf.ARGON2ID, test.kdfParams)
+		assert.NoError(t, err)
+
+		// when
+		dKey, err := kdf.DeriveKey([]byte("password"), []byte("saltsalt"), 256, kdfOpt)
+
+		// then
+		assert.Nil(t, dKey)
+		assert.Equal(t, test.err, err)
+	}
+}
@@ -20,9 +20,15 @@
 package heimdall
 
 import (
+	"bytes"
 	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/DE-labtory/heimdall/hashing"
 	"github.com/btcsuite/btcutil/base58"
 )
 
@@ -49,28 +55,298 @@ type PubKey interface {
 	Key
 }
 
-// Key ID prefix
+// Key ID prefix of legacy key IDs, which do not contain algorithm of the key. (ex. IT<base58 SKI>)
 const KeyIDPrefix = "IT"
 
-// SKIToKeyID obtains key ID from SKI(Subject Key Identifier).
+// KeyIDDelimiter separates algorithm prefix and base58 encoded SKI in key ID. (ex. ECP384x<base58 SKI>)
+// Algorithm prefixes never contain it, so the first delimiter always ends the prefix.
+const KeyIDDelimiter = "x"
+
+var ErrInvalidKeyID = errors.New("invalid key ID - key ID should be like ECP256x<base58 SKI>, RSA2048x<base58 SKI> or IT<base58 SKI>")
+
+// skiSize is size of SKI, which is SKI hash of public key truncated to 20 bytes. (see MakeSKI)
+const skiSize = 20
+
+// KeyIDInfo is parsed key ID. KeyType is nil for legacy key IDs.
+// Multihash is true if SKI is encoded in multihash format in the key ID, whose SKI hash is SKIHash. (see MakeMultihashKeyID)
+type KeyIDInfo struct {
+	KeyType   *KeyType
+	SKI       []byte
+	Multihash bool
+	SKIHash   string
+}
+
+// IsLegacy checks if key ID is legacy key ID without algorithm prefix.
+func (info *KeyIDInfo) IsLegacy() bool {
+	return info.KeyType == nil
+}
+
+// MakeKeyID makes key ID of algorithm prefix and base58 encoded SKI. (ex. ECP384x..., RSA2048x..., ED25519x..., BLS12381x..., DILITHIUM3x..., SM2x..., X25519x...)
+// Prefix and encoding of SKI follow key ID format of the process. (see SetKeyIDFormat)
+func MakeKeyID(keyGenOpts KeyGenOpts, ski []byte) (KeyID, error) {
+	return makeKeyID(keyGenOpts, ski, CurrentKeyIDFormat())
+}
+
+// makeKeyID makes key ID of algorithm prefix and SKI in key ID format.
+func makeKeyID(keyGenOpts KeyGenOpts, ski []byte, format *KeyIDFormat) (KeyID, error) {
+	keyType, err := KeyTypeOf(keyGenOpts)
+	if err != nil {
+		return "", err
+	}
+
+	if err := keyType.Validate(); err != nil {
+		return "", err
+	}
+
+	return format.Prefix + keyIDPrefixOf(keyType) + KeyIDDelimiter + format.encodeSKI(ski), nil
+}
+
+// MakeMultihashKeyID makes key ID like MakeKeyID, but SKI is encoded in multihash format, so that the key ID tells
+// the hash function of SKI. Multihash key ID is parsed and matched in the same way with key ID by MakeKeyID.
+func MakeMultihashKeyID(keyGenOpts KeyGenOpts, ski []byte) (KeyID, error) {
+	multihash, err := SKIToMultihash(ski)
+	if err != nil {
+		return "", err
+	}
+
+	return MakeKeyID(keyGenOpts, multihash)
+}
+
+// SKIToMultihash encodes SKI in multihash format of truncated SKI hash of the process. (see SetSKIHash)
+func SKIToMultihash(ski []byte) ([]byte, error) {
+	return hashing.EncodeMultihash(CurrentSKIHash(), ski)
+}
+
+// decodeSKI decodes base58 or multibase encoded SKI of key ID, which may be in multihash format.
+// Multihash of SKI is never mistaken for raw SKI, since it is longer than raw SKI.
+// Multibase SKI is decoded only if it is not plain base58 SKI, since multibase code is also a base58 character.
+// SKI hash is returned for multihash SKI, and is empty for raw SKI.
+func decodeSKI(encoded string) ([]byte, string) {
+	ski := base58.Decode(encoded)
+	if digest, skiHash, ok := toSKI(ski); ok {
+		return digest, skiHash
+	}
+
+	if digest, skiHash, ok := toSKI(decodeMultibaseSKI(encoded)); ok {
+		return digest, skiHash
+	}
+
+	return ski, ""
+}
+
+// toSKI checks if decoded bytes are raw SKI or multihash of SKI, and returns the SKI and SKI hash of multihash.
+func toSKI(decoded []byte) ([]byte, string, bool) {
+	if len(decoded) == skiSize {
+		return decoded, "", true
+	}
+
+	hashOpt, digest, err := hashing.DecodeMultihash(decoded)
+	if err == nil && IsSKIHash(hashOpt.Name) && len(digest) == skiSize {
+		return digest, hashOpt.Name, true
+	}
+
+	return nil, "", false
+}
+
+// keyIDPrefixOf returns algorithm prefix of key ID. (ex. ECP384, RSA2048, ED25519, BLS12381, DILITHIUM3, SM2, X25519)
+func keyIDPrefixOf(keyType *KeyType) string {
+	switch keyType.Family {
+	case ECDSA:
+		if curve, ok := lookupCurve(keyType.Curve); ok {
+			return curve.keyIDPrefix
+		}
+		return "EC" + strings.Replace(keyType.Curve, "-", "", -1)
+	case ED25519, BLS12381, SM2, X25519:
+		return keyType.Family
+	default:
+		return keyType.Family + strconv.Itoa(keyType.BitLen)
+	}
+}
+
+// parseKeyIDPrefix parses algorithm prefix of key ID to key type.
+func parseKeyIDPrefix(prefix string) (*KeyType, error) {
+	if strings.HasPrefix(prefix, "EC") {
+		curve, ok := lookupCurveByKeyIDPrefix(prefix)
+		if !ok {
+			return nil, ErrUnknownKeyType
+		}
+		return &KeyType{Family: ECDSA, Curve: curve.name, BitLen: curve.bitLen}, nil
+	}
+
+	return ParseKeyType(prefix)
+}
+
+// ParseKeyID parses key ID to key type and SKI. Legacy key ID is parsed to SKI only.
+// Key IDs of every encoding are parsed, and prefix of key ID format of the process is trimmed. (see SetKeyIDFormat)
+func ParseKeyID(keyId KeyID) (*KeyIDInfo, error) {
+	if trimmed := trimKeyIDPrefix(keyId); trimmed != keyId {
+		if info, err := parseKeyID(trimmed); err == nil {
+			return info, nil
+		}
+	}
+
+	return parseKeyID(keyId)
+}
+
+// parseKeyID parses key ID without prefix of key ID format.
+func parseKeyID(keyId KeyID) (*KeyIDInfo, error) {
+	if index := strings.Index(keyId, KeyIDDelimiter); index > 0 {
+		if keyType, err := parseKeyIDPrefix(keyId[:index]); err == nil {
+			ski, skiHash := decodeSKI(keyId[index+len(KeyIDDelimiter):])
+			if len(ski) == 0 {
+				return nil, ErrInvalidKeyID
+			}
+
+			return &KeyIDInfo{KeyType: keyType, SKI: ski, Multihash: skiHash != "", SKIHash: skiHash}, nil
+		}
+	}
+
+	if strings.HasPrefix(keyId, KeyIDPrefix) {
+		ski, skiHash := decodeSKI(strings.TrimPrefix(keyId, KeyIDPrefix))
+		if len(ski) == 0 {
+			return nil, ErrInvalidKeyID
+		}
+
+		return &KeyIDInfo{SKI: ski, Multihash: skiHash != "", SKIHash: skiHash}, nil
+	}
+
+	return nil, ErrInvalidKeyID
+}
+
+// ValidateKeyID checks if key ID is algorithm prefixed key ID or legacy key ID.
+func ValidateKeyID(keyId KeyID) error {
+	_, err := ParseKeyID(keyId)
+	return err
+}
+
+// MatchKeyID checks if key ID is the ID of the key. Legacy key ID matches the key of the same SKI,
+// and multihash key ID or key ID of other format matches the key of the same algorithm and SKI.
+// Key ID made by other SKI hash matches the key of SKISource public key. (see SetSKIHash)
+func MatchKeyID(keyId KeyID, key Key) bool {
+	if keyId == key.ID() {
+		return true
+	}
+
+	info, err := ParseKeyID(keyId)
+	if err != nil {
+		return false
+	}
+
+	if !info.IsLegacy() {
+		keyType, err := KeyTypeOf(key.KeyGenOpt())
+		if err != nil || keyIDPrefixOf(info.KeyType) != keyIDPrefixOf(keyType) {
+			return false
+		}
+	}
+
+	return matchSKI(info.SKI, key, info.SKIHash)
+}
+
+// KeyIDFilePath returns path of file named by key ID in directory. If only file named by legacy key ID of the same SKI exists,
+// its path is returned, so that files stored before algorithm prefixed key IDs can still be found.
+// Key of multihash key ID is looked up by key ID of the same algorithm and SKI, which names key files.
+// Key of legacy key ID is also looked up by files in directory named by algorithm prefixed key ID of the same SKI.
+func KeyIDFilePath(dirPath string, keyId KeyID) string {
+	for _, name := range KeyIDFileNamesInDir(dirPath, keyId) {
+		keyPath := filepath.Join(dirPath, name)
+		if _, err := os.Stat(keyPath); err == nil {
+			return keyPath
+		}
+	}
+
+	return filepath.Join(dirPath, keyId)
+}
+
+// KeyIDFileNames returns names which key file of key ID may be stored under, in the order to look up:
+// the key ID, key IDs of the same algorithm and SKI in key ID format of the process and in default format,
+// and legacy key ID of the same SKI. So key files are found by key IDs of multihash or other formats.
+func KeyIDFileNames(keyId KeyID) []string {
+	names := []string{keyId}
+
+	info, err := ParseKeyID(keyId)
+	if err != nil || info.IsLegacy() {
+		return names
+	}
+
+	for _, format := range []*KeyIDFormat{CurrentKeyIDFormat(), {}} {
+		if formatKeyId, err := makeKeyID(info.KeyType, info.SKI, format); err == nil && !containsName(names, formatKeyId) {
+			names = append(names, formatKeyId)
+		}
+	}
+
+	return append(names, SKIToKeyID(info.SKI))
+}
+
+// KeyIDFileNamesIn returns names like KeyIDFileNames, followed by names in listed which are algorithm prefixed key IDs
+// of the same SKI if key ID is legacy key ID. Legacy key ID has no algorithm, so key files stored under algorithm
+// prefixed key IDs are found only by SKI of names in storage.
+func KeyIDFileNamesIn(keyId KeyID, listed []string) []string {
+	names := KeyIDFileNames(keyId)
+
+	info, err := ParseKeyID(keyId)
+	if err != nil || !info.IsLegacy() {
+		return names
+	}
+
+	for _, name := range listed {
+		nameInfo, err := ParseKeyID(name)
+		if err == nil && !nameInfo.IsLegacy() && bytes.Equal(nameInfo.SKI, info.SKI) && !containsName(names, name) {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// KeyIDFileNamesInDir returns names like KeyIDFileNamesIn with names of files in directory.
+// Directory is read only for legacy key ID, and names of KeyIDFileNames are returned if it can not be read.
+func KeyIDFileNamesInDir(dirPath string, keyId KeyID) []string {
+	if info, err := ParseKeyID(keyId); err != nil || !info.IsLegacy() {
+		return KeyIDFileNames(keyId)
+	}
+
+	files, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return KeyIDFileNames(keyId)
+	}
+
+	listed := make([]string, 0, len(files))
+	for _, file := range files {
+		listed = append(listed, file.Name())
+	}
+
+	return KeyIDFileNamesIn(keyId, listed)
+}
+
+// containsName checks if names contain name.
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SKIToKeyID obtains legacy key ID from SKI(Subject Key Identifier).
 func SKIToKeyID(ski []byte) string {
 	return KeyIDPrefix + base58.Encode(ski)
 }
 
 // SKIValidCheck checks if input SKI is corresponding to key id.
 func SKIValidCheck(keyId string, ski []byte) error {
-	if SKIToKeyID(ski) != keyId {
+	info, err := ParseKeyID(keyId)
+	if err != nil || !bytes.Equal(info.SKI, ski) {
 		return errors.New("invalid SKI - SKI is not correspond to input key ID")
 	}
 
 	return nil
 }
 
-// KeyIDPrefixCheck checks if input key id has right prefix.
+// KeyIDPrefixCheck checks if input key id is valid.
+//
+// Deprecated: use ValidateKeyID, which also accepts algorithm prefixed key IDs.
 func KeyIDPrefixCheck(keyId string) error {
-	if strings.HasPrefix(keyId, KeyIDPrefix) != true {
-		return errors.New("invalid key ID - prefix should be 'IT'")
-	}
-
-	return nil
+	return ValidateKeyID(keyId)
 }
@@ -0,0 +1,135 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides format of key IDs, which configures namespace prefix and encoding of SKI in key IDs.
+
+package heimdall
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcutil/base58"
+)
+
+var ErrInvalidKeyIDFormat = errors.New("invalid key ID format - encoding should be BASE58BTC or MULTIBASE, and prefix should not contain key ID delimiter or path separator")
+
+// encodings of SKI in key ID
+const (
+	// KeyIDBase58 encodes SKI in plain base58btc. (ex. ECP384x<base58 SKI>)
+	KeyIDBase58 = "BASE58BTC"
+
+	// KeyIDMultibase encodes SKI in multibase base58btc, which tells its encoding by leading 'z'. (ex. ECP384xz<base58 SKI>)
+	KeyIDMultibase = "MULTIBASE"
+)
+
+// multibase codes of SKI encodings parsed in key IDs
+const (
+	multibaseBase58 = 'z'
+	multibaseBase16 = 'f'
+)
+
+// KeyIDFormat is format of key IDs made by MakeKeyID. Prefix is prepended to key IDs (ex. "heimdall:"),
+// and Encoding is encoding of SKI. Zero format makes key IDs of plain base58btc SKI without prefix.
+type KeyIDFormat struct {
+	Prefix   string `json:",omitempty"`
+	Encoding string `json:",omitempty"`
+}
+
+// Validate checks if encoding of format is known, and prefix can be told apart from the rest of key ID.
+func (format *KeyIDFormat) Validate() error {
+	if format.Encoding != "" && format.Encoding != KeyIDBase58 && format.Encoding != KeyIDMultibase {
+		return ErrInvalidKeyIDFormat
+	}
+
+	if strings.Contains(format.Prefix, KeyIDDelimiter) || strings.ContainsAny(format.Prefix, "/\\") {
+		return ErrInvalidKeyIDFormat
+	}
+
+	return nil
+}
+
+// encodeSKI encodes SKI by encoding of format.
+func (format *KeyIDFormat) encodeSKI(ski []byte) string {
+	if format.Encoding == KeyIDMultibase {
+		return string(multibaseBase58) + base58.Encode(ski)
+	}
+
+	return base58.Encode(ski)
+}
+
+var keyIDFormat = &KeyIDFormat{}
+var keyIDFormatMutex = &sync.RWMutex{}
+
+// SetKeyIDFormat replaces format of key IDs made in the process. Nil format restores plain base58btc key IDs.
+// Key IDs of every encoding are parsed regardless of the format, and key files stored under key IDs of other formats
+// are still found. (see KeyIDFileNames)
+func SetKeyIDFormat(format *KeyIDFormat) error {
+	if format == nil {
+		format = &KeyIDFormat{}
+	}
+
+	if err := format.Validate(); err != nil {
+		return err
+	}
+
+	keyIDFormatMutex.Lock()
+	defer keyIDFormatMutex.Unlock()
+
+	keyIDFormat = format
+	return nil
+}
+
+// CurrentKeyIDFormat returns format of key IDs made in the process.
+func CurrentKeyIDFormat() *KeyIDFormat {
+	keyIDFormatMutex.RLock()
+	defer keyIDFormatMutex.RUnlock()
+
+	return keyIDFormat
+}
+
+// trimKeyIDPrefix trims prefix of key ID format of the process from key ID.
+func trimKeyIDPrefix(keyId KeyID) KeyID {
+	prefix := CurrentKeyIDFormat().Prefix
+	if prefix == "" {
+		return keyId
+	}
+
+	return strings.TrimPrefix(keyId, prefix)
+}
+
+// decodeMultibaseSKI decodes multibase encoded SKI of key ID, which is base58btc or base16.
+func decodeMultibaseSKI(encoded string) []byte {
+	if len(encoded) < 2 {
+		return nil
+	}
+
+	switch encoded[0] {
+	case multibaseBase58:
+		return base58.Decode(encoded[1:])
+	case multibaseBase16:
+		ski, err := hex.DecodeString(encoded[1:])
+		if err != nil {
+			return nil
+		}
+		return ski
+	default:
+		return nil
+	}
+}
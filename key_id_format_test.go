@@ -0,0 +1,103 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package heimdall_test
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetKeyIDFormat(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	plainKeyId := pri.ID()
+
+	// when
+	err = heimdall.SetKeyIDFormat(&heimdall.KeyIDFormat{Prefix: "heimdall:", Encoding: heimdall.KeyIDMultibase})
+	defer heimdall.SetKeyIDFormat(nil)
+	keyId := pri.ID()
+	info, parseErr := heimdall.ParseKeyID(keyId)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(keyId, "heimdall:ECP256xz"), keyId)
+	assert.NoError(t, parseErr)
+	assert.Equal(t, pri.SKI(), info.SKI)
+	assert.Equal(t, heimdall.ECDSA, info.KeyType.Family)
+	assert.True(t, heimdall.MatchKeyID(keyId, pri))
+	assert.True(t, heimdall.MatchKeyID(plainKeyId, pri))
+	assert.NoError(t, heimdall.SetKeyIDFormat(nil))
+	assert.Equal(t, plainKeyId, pri.ID())
+}
+
+func TestSetKeyIDFormat_Invalid(t *testing.T) {
+	for _, format := range []*heimdall.KeyIDFormat{{Encoding: "BASE64"}, {Prefix: "hex:"}, {Prefix: "keys/"}} {
+		assert.Equal(t, heimdall.ErrInvalidKeyIDFormat, heimdall.SetKeyIDFormat(format), format.Prefix+format.Encoding)
+	}
+	assert.Equal(t, &heimdall.KeyIDFormat{}, heimdall.CurrentKeyIDFormat())
+}
+
+func TestParseKeyID_Multibase(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	base16KeyId := "ECP384xf" + hex.EncodeToString(pri.SKI())
+
+	// when
+	info, err := heimdall.ParseKeyID(base16KeyId)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.SKI(), info.SKI)
+	assert.True(t, heimdall.MatchKeyID(base16KeyId, pri))
+	assert.NoError(t, heimdall.KeyIDPrefixCheck(base16KeyId))
+}
+
+func TestKeyIDFilePath_KeyIDFormat(t *testing.T) {
+	// given
+	assert.NoError(t, os.MkdirAll(heimdall.TestKeyDir, 0700))
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	plainPath := filepath.Join(heimdall.TestKeyDir, pri.ID())
+	assert.NoError(t, ioutil.WriteFile(plainPath, []byte("key"), 0600))
+
+	// when
+	assert.NoError(t, heimdall.SetKeyIDFormat(&heimdall.KeyIDFormat{Encoding: heimdall.KeyIDMultibase}))
+	defer heimdall.SetKeyIDFormat(nil)
+	keyPath := heimdall.KeyIDFilePath(heimdall.TestKeyDir, pri.ID())
+
+	// then
+	assert.Equal(t, plainPath, keyPath)
+}
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+// This file provides registry of key recoverers by algorithm, so that key files are recovered by the recoverer of
+// algorithm recorded in them without callers choosing one. Algorithm packages register their recoverers at init.
+
+package heimdall
+
+import "sync"
+
+// registeredRecoverer is a key recoverer with key family algorithm it recovers.
+type registeredRecoverer struct {
+	algorithm string
+	recoverer KeyRecoverer
+}
+
+// keyRecoverers are registered recoverers in order of registration.
+var keyRecoverers []*registeredRecoverer
+var keyRecoverersMutex = &sync.RWMutex{}
+
+// RegisterKeyRecoverer registers recoverer of key family algorithm (ex. heimdall.ECDSA), replacing recoverer registered
+// for the algorithm, so that key files of the algorithm are loaded by the recoverer.
+// Algorithm packages (ex. hecdsa, hrsa) register their recoverers at init, so key files of an algorithm are recovered
+// only if its package is imported.
+func RegisterKeyRecoverer(algorithm string, recoverer KeyRecoverer) {
+	keyRecoverersMutex.Lock()
+	defer keyRecoverersMutex.Unlock()
+
+	for _, registered := range keyRecoverers {
+		if registered.algorithm == algorithm {
+			registered.recoverer = recoverer
+			return
+		}
+	}
+
+	keyRecoverers = append(keyRecoverers, &registeredRecoverer{algorithm: algorithm, recoverer: recoverer})
+}
+
+// KeyRecovererOf returns recoverer registered for key family algorithm, or ErrUnknownKeyType if there is none.
+func KeyRecovererOf(algorithm string) (KeyRecoverer, error) {
+	keyRecoverersMutex.RLock()
+	defer keyRecoverersMutex.RUnlock()
+
+	for _, registered := range keyRecoverers {
+		if registered.algorithm == algorithm {
+			return registered.recoverer, nil
+		}
+	}
+
+	return nil, ErrUnknownKeyType
+}
+
+// KeyRecovererOfKeyID returns recoverer registered for algorithm of key ID.
+// Legacy key IDs have no algorithm, and ECDSA keys are the only keys stored with them, so ECDSA recoverer is returned.
+func KeyRecovererOfKeyID(keyId KeyID) (KeyRecoverer, error) {
+	info, err := ParseKeyID(keyId)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsLegacy() {
+		return KeyRecovererOf(ECDSA)
+	}
+
+	return KeyRecovererOf(info.KeyType.Family)
+}
+
+// RecoverKeyByOpt recovers key with the recoverer registered for algorithm in key generation option.
+// If the option is empty (ex. key files stored by older versions), registered recoverers are tried,
+// starting from ECDSA recoverer since older versions stored ECDSA keys only.
+func RecoverKeyByOpt(keyBytes []byte, isPrivate bool, keyGenOpt string) (Key, error) {
+	if keyGenOpt != "" {
+		keyType, err := ParseKeyType(keyGenOpt)
+		if err != nil {
+			return nil, err
+		}
+
+		recoverer, err := KeyRecovererOf(keyType.Family)
+		if err != nil {
+			return nil, err
+		}
+
+		return recoverer.RecoverKeyFromByte(keyBytes, isPrivate)
+	}
+
+	var firstErr error
+	for _, recoverer := range legacyKeyRecoverers() {
+		key, err := recoverer.RecoverKeyFromByte(keyBytes, isPrivate)
+		if err == nil {
+			return key, nil
+		} else if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	if firstErr == nil {
+		return nil, ErrUnknownKeyType
+	}
+
+	return nil, firstErr
+}
+
+// legacyKeyRecoverers returns registered recoverers in the order to try for key files without algorithm.
+func legacyKeyRecoverers() []KeyRecoverer {
+	keyRecoverersMutex.RLock()
+	defer keyRecoverersMutex.RUnlock()
+
+	recoverers := make([]KeyRecoverer, 0, len(keyRecoverers))
+	for _, registered := range keyRecoverers {
+		if registered.algorithm == ECDSA {
+			recoverers = append([]KeyRecoverer{registered.recoverer}, recoverers...)
+		} else {
+			recoverers = append(recoverers, registered.recoverer)
+		}
+	}
+
+	return recoverers
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+package heimdall_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyRecovererOf(t *testing.T) {
+	// when
+	recoverer, err := heimdall.KeyRecovererOf(heimdall.ED25519)
+
+	// then
+	assert.NoError(t, err)
+	assert.IsType(t, &hed25519.KeyRecoverer{}, recoverer)
+
+	_, err = heimdall.KeyRecovererOf("UNKNOWN")
+	assert.Equal(t, heimdall.ErrUnknownKeyType, err)
+}
+
+func TestKeyRecovererOfKeyID(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	recoverer, err := heimdall.KeyRecovererOfKeyID(pri.ID())
+
+	// then
+	assert.NoError(t, err)
+	assert.IsType(t, &hed25519.KeyRecoverer{}, recoverer)
+
+	recoverer, err = heimdall.KeyRecovererOfKeyID(heimdall.SKIToKeyID(pri.SKI()))
+	assert.NoError(t, err)
+	assert.IsType(t, &hecdsa.KeyRecoverer{}, recoverer)
+
+	_, err = heimdall.KeyRecovererOfKeyID("invalid")
+	assert.Equal(t, heimdall.ErrInvalidKeyID, err)
+}
+
+func TestRecoverKeyByOpt(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	keyBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+
+	for _, keyGenOpt := range []string{pri.KeyGenOpt().ToString(), ""} {
+		// when
+		key, err := heimdall.RecoverKeyByOpt(keyBytes, true, keyGenOpt)
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, pri.ID(), key.ID())
+	}
+
+	_, err = heimdall.RecoverKeyByOpt(keyBytes, true, "UNKNOWN")
+	assert.Error(t, err)
+}
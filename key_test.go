@@ -18,6 +18,8 @@
 package heimdall_test
 
 import (
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -83,3 +85,202 @@ func TestKeyIDPrefixCheck(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Error(t, err2)
 }
+
+func TestMakeKeyID(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	keyId, err := heimdall.MakeKeyID(keyGenOpt, pri.SKI())
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(keyId, "ECP384x"))
+	assert.Equal(t, pri.ID(), keyId)
+}
+
+func TestMakeMultihashKeyID(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	keyId, err := heimdall.MakeMultihashKeyID(keyGenOpt, pri.SKI())
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(keyId, "ECP384x"))
+	assert.NotEqual(t, pri.ID(), keyId)
+	assert.True(t, heimdall.MatchKeyID(keyId, pri))
+
+	info, err := heimdall.ParseKeyID(keyId)
+	assert.NoError(t, err)
+	assert.True(t, info.Multihash)
+	assert.Equal(t, pri.SKI(), info.SKI)
+}
+
+func TestKeyIDFilePath_Multihash(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	keyId, err := heimdall.MakeMultihashKeyID(keyGenOpt, pri.SKI())
+	assert.NoError(t, err)
+
+	err = os.MkdirAll(heimdall.TestKeyDir, 0700)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyPath := filepath.Join(heimdall.TestKeyDir, pri.ID())
+	err = os.WriteFile(keyPath, []byte("key"), 0600)
+	assert.NoError(t, err)
+
+	// when
+	path := heimdall.KeyIDFilePath(heimdall.TestKeyDir, keyId)
+
+	// then
+	assert.Equal(t, keyPath, path)
+}
+
+func TestKeyIDFileNames(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	multihashKeyId, err := heimdall.MakeMultihashKeyID(keyGenOpt, pri.SKI())
+	assert.NoError(t, err)
+	legacyKeyId := heimdall.SKIToKeyID(pri.SKI())
+
+	// when
+	names := heimdall.KeyIDFileNames(pri.ID())
+	multihashNames := heimdall.KeyIDFileNames(multihashKeyId)
+	legacyNames := heimdall.KeyIDFileNames(legacyKeyId)
+
+	// then
+	assert.Equal(t, []string{pri.ID(), legacyKeyId}, names)
+	assert.Equal(t, []string{multihashKeyId, pri.ID(), legacyKeyId}, multihashNames)
+	assert.Equal(t, []string{legacyKeyId}, legacyNames)
+}
+
+func TestKeyIDFileNamesIn(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	otherPri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	legacyKeyId := heimdall.SKIToKeyID(pri.SKI())
+	listed := []string{otherPri.ID(), pri.ID(), "not key ID"}
+
+	// when
+	legacyNames := heimdall.KeyIDFileNamesIn(legacyKeyId, listed)
+	names := heimdall.KeyIDFileNamesIn(otherPri.ID(), listed)
+
+	// then
+	assert.Equal(t, []string{legacyKeyId, pri.ID()}, legacyNames)
+	assert.Equal(t, heimdall.KeyIDFileNames(otherPri.ID()), names)
+}
+
+func TestKeyIDFilePath_LegacyKeyID(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	err = os.MkdirAll(heimdall.TestKeyDir, 0700)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyPath := filepath.Join(heimdall.TestKeyDir, pri.ID())
+	err = os.WriteFile(keyPath, []byte("key"), 0600)
+	assert.NoError(t, err)
+
+	// when
+	path := heimdall.KeyIDFilePath(heimdall.TestKeyDir, heimdall.SKIToKeyID(pri.SKI()))
+
+	// then
+	assert.Equal(t, keyPath, path)
+}
+
+func TestSKIToMultihash(t *testing.T) {
+	// given
+	ski := make([]byte, 20)
+
+	// when
+	multihash, err := heimdall.SKIToMultihash(ski)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, append([]byte{0x12, 0x14}, ski...), multihash)
+}
+
+func TestParseKeyID(t *testing.T) {
+	ski := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+
+	for str, expected := range map[string]*heimdall.KeyType{
+		"P-256":      {Family: heimdall.ECDSA, Curve: "P-256", BitLen: 256},
+		"P-521":      {Family: heimdall.ECDSA, Curve: "P-521", BitLen: 521},
+		"RSA4096":    {Family: heimdall.RSA, BitLen: 4096},
+		"ED25519":    {Family: heimdall.ED25519, BitLen: 256},
+		"BLS12381":   {Family: heimdall.BLS12381, BitLen: 255},
+		"DILITHIUM3": {Family: heimdall.DILITHIUM, BitLen: 3},
+		"SM2":        {Family: heimdall.SM2, BitLen: 256},
+		"X25519":     {Family: heimdall.X25519, BitLen: 256},
+	} {
+		// given
+		keyId, err := heimdall.MakeKeyID(expected, ski)
+		assert.NoError(t, err, str)
+
+		// when
+		info, err := heimdall.ParseKeyID(keyId)
+
+		// then
+		assert.NoError(t, err, keyId)
+		assert.Equal(t, expected, info.KeyType, keyId)
+		assert.Equal(t, ski, info.SKI, keyId)
+		assert.False(t, info.IsLegacy())
+	}
+}
+
+func TestParseKeyID_Legacy(t *testing.T) {
+	// given
+	ski := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	keyId := heimdall.SKIToKeyID(ski)
+
+	// when
+	info, err := heimdall.ParseKeyID(keyId)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, info.IsLegacy())
+	assert.Equal(t, ski, info.SKI)
+}
+
+func TestValidateKeyID(t *testing.T) {
+	for _, keyId := range []string{"", "fake", "ECP384x", "ECP383xabc", "RSA2048x0OIl", "IT"} {
+		assert.Equal(t, heimdall.ErrInvalidKeyID, heimdall.ValidateKeyID(keyId), keyId)
+	}
+}
+
+func TestMatchKeyID(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	otherPri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// when, then
+	assert.True(t, heimdall.MatchKeyID(pri.ID(), pri))
+	assert.True(t, heimdall.MatchKeyID(heimdall.SKIToKeyID(pri.SKI()), pri))
+	assert.False(t, heimdall.MatchKeyID(otherPri.ID(), pri))
+	assert.False(t, heimdall.MatchKeyID(heimdall.SKIToKeyID(otherPri.SKI()), pri))
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides usage policy of keys, which refuses expired keys and keys tagged for other purposes at signing and encryption.
+
+package heimdall
+
+import (
+	"errors"
+	"time"
+)
+
+var ErrKeyExpired = errors.New("key expired - key is not usable after its NotAfter time")
+var ErrKeyPurposeNotAllowed = errors.New("key purpose not allowed - key is tagged for a different purpose")
+
+// key purposes of usage policy. PurposeEncrypt covers encryption, decryption and key agreement.
+const (
+	PurposeSign    = "sign"
+	PurposeEncrypt = "encrypt"
+)
+
+// KeyUsagePolicy restricts use of a key. Zero NotAfter never expires, and empty Purposes allow every purpose.
+type KeyUsagePolicy struct {
+	NotAfter time.Time
+	Purposes []string `json:",omitempty"`
+}
+
+// Check checks if key of the policy is usable for purpose at time now.
+func (policy *KeyUsagePolicy) Check(purpose string, now time.Time) error {
+	if !policy.NotAfter.IsZero() && now.After(policy.NotAfter) {
+		return ErrKeyExpired
+	}
+
+	if len(policy.Purposes) != 0 && !contains(policy.Purposes, []string{purpose}) {
+		return ErrKeyPurposeNotAllowed
+	}
+
+	return nil
+}
+
+// UsagePolicyHolder is implemented by keys which carry usage policy, such as keys embedding KeyUsage.
+// Keystores set usage policy of key file on the key object when the key is loaded.
+type UsagePolicyHolder interface {
+	UsagePolicy() *KeyUsagePolicy
+	SetUsagePolicy(policy *KeyUsagePolicy)
+}
+
+// KeyUsage is embedded in key types to carry usage policy of the key object. Policy should be set before the key is
+// shared between goroutines, and public key of a private key carries policy of the private key.
+type KeyUsage struct {
+	policy *KeyUsagePolicy
+}
+
+// UsagePolicy returns usage policy of the key, or nil if the key has no policy.
+func (usage *KeyUsage) UsagePolicy() *KeyUsagePolicy {
+	return usage.policy
+}
+
+// SetUsagePolicy sets usage policy of the key. Nil policy removes the policy.
+func (usage *KeyUsage) SetUsagePolicy(policy *KeyUsagePolicy) {
+	usage.policy = policy
+}
+
+// CheckKeyUsage checks if key is usable for purpose now by its usage policy. Keys without policy are usable
+// for every purpose.
+func CheckKeyUsage(key Key, purpose string) error {
+	holder, ok := key.(UsagePolicyHolder)
+	if !ok || holder.UsagePolicy() == nil {
+		return nil
+	}
+
+	return holder.UsagePolicy().Check(purpose, time.Now())
+}
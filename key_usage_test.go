@@ -0,0 +1,71 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package heimdall_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyUsagePolicy_Check(t *testing.T) {
+	// given
+	now := time.Now()
+	expired := &heimdall.KeyUsagePolicy{NotAfter: now.Add(-time.Hour)}
+	signOnly := &heimdall.KeyUsagePolicy{NotAfter: now.Add(time.Hour), Purposes: []string{heimdall.PurposeSign}}
+	unrestricted := &heimdall.KeyUsagePolicy{}
+
+	// then
+	assert.Equal(t, heimdall.ErrKeyExpired, expired.Check(heimdall.PurposeSign, now))
+	assert.NoError(t, signOnly.Check(heimdall.PurposeSign, now))
+	assert.Equal(t, heimdall.ErrKeyPurposeNotAllowed, signOnly.Check(heimdall.PurposeEncrypt, now))
+	assert.Equal(t, heimdall.ErrKeyExpired, signOnly.Check(heimdall.PurposeSign, now.Add(2*time.Hour)))
+	assert.NoError(t, unrestricted.Check(heimdall.PurposeEncrypt, now))
+}
+
+func TestCheckKeyUsage(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, heimdall.CheckKeyUsage(pri, heimdall.PurposeEncrypt))
+
+	keyBytes, err := pri.ToByte()
+	assert.NoError(t, err)
+	samePri, err := (&hecdsa.KeyRecoverer{}).RecoverKeyFromByte(keyBytes, true)
+	assert.NoError(t, err)
+
+	// when
+	holder := pri.(heimdall.UsagePolicyHolder)
+	holder.SetUsagePolicy(&heimdall.KeyUsagePolicy{Purposes: []string{heimdall.PurposeSign}})
+
+	// then
+	assert.NoError(t, heimdall.CheckKeyUsage(pri, heimdall.PurposeSign))
+	assert.Equal(t, heimdall.ErrKeyPurposeNotAllowed, heimdall.CheckKeyUsage(pri, heimdall.PurposeEncrypt))
+	assert.Equal(t, heimdall.ErrKeyPurposeNotAllowed, heimdall.CheckKeyUsage(pri.PublicKey(), heimdall.PurposeEncrypt))
+	// policy is kept on the key object, not on key ID
+	assert.NoError(t, heimdall.CheckKeyUsage(samePri, heimdall.PurposeEncrypt))
+
+	holder.SetUsagePolicy(nil)
+	assert.Nil(t, holder.UsagePolicy())
+	assert.NoError(t, heimdall.CheckKeyUsage(pri, heimdall.PurposeEncrypt))
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides interfaces of keystore, its storage backend and key derivation function for protecting stored keys.
+
+package heimdall
+
+import "errors"
+
+var ErrKeyNotFound = errors.New("key not found - storage has no key file of the name")
+
+// KeyStore stores keys and loads them by key ID. Private keys are protected by password.
+type KeyStore interface {
+	StorePriKey(pri PriKey, pwd string) error
+	LoadPriKey(keyId KeyID, pwd string) (PriKey, error)
+	StorePubKey(pub PubKey) error
+	LoadPubKey(keyId KeyID) (PubKey, error)
+}
+
+// Storage is a backend of keystore, which keeps key files by name (ex. file system, database, memory or remote store).
+// Private key files are encrypted before they are put, so storage does not need to protect them.
+type Storage interface {
+	// Put stores data under name, replacing existing data atomically.
+	Put(name string, data []byte) error
+	// Get returns data stored under name, or ErrKeyNotFound if there is no data of the name.
+	Get(name string) ([]byte, error)
+	// List returns names of all stored data.
+	List() ([]string, error)
+	// Delete removes data stored under name. Deleting name which does not exist is not an error.
+	Delete(name string) error
+}
+
+// KDF derives a key of keyLen bits from password and salt.
+type KDF interface {
+	DeriveKey(pwd, salt []byte, keyLen int) ([]byte, error)
+}
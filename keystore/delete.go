@@ -0,0 +1,67 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides deletion of key files, which shreds the key file before removing it.
+
+package keystore
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+)
+
+// DeleteKey overwrites key file of key ID in key directory with random bytes before removing it, so that retired key
+// is not recoverable from the disk. Shredding is best-effort, since file systems such as journaling or copy-on-write
+// file systems and SSDs may keep old blocks. It returns heimdall.ErrKeyNotFound if the key directory has no key file of key ID.
+func DeleteKey(keyId heimdall.KeyID, keyDirPath string) error {
+	if err := heimdall.ValidateKeyID(keyId); err != nil {
+		return err
+	}
+
+	deleted := false
+	for _, name := range heimdall.KeyIDFileNamesInDir(keyDirPath, keyId) {
+		path := filepath.Join(keyDirPath, name)
+
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return err
+		} else if info.IsDir() {
+			continue
+		}
+
+		if err := overwrite(path, info.Size()); err != nil {
+			return err
+		}
+
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+		deleted = true
+	}
+
+	if !deleted {
+		return heimdall.ErrKeyNotFound
+	}
+	event.Publish(event.KeyDeleted, keyId, keyDirPath)
+
+	return nil
+}
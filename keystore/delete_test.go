@@ -0,0 +1,64 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteKey(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+	assert.NoError(t, hecdsa.StorePubKey(pri.PublicKey(), heimdall.TestPubKeyDir))
+
+	var events []*event.Event
+	unsubscribe := event.Subscribe(func(e *event.Event) { events = append(events, e) }, event.KeyDeleted)
+	defer unsubscribe()
+
+	// when
+	err := keystore.DeleteKey(pri.ID(), heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	pubErr := keystore.DeleteKey(pri.ID(), heimdall.TestPubKeyDir)
+	notFoundErr := keystore.DeleteKey(pri.ID(), heimdall.TestKeyDir)
+	invalidErr := keystore.DeleteKey("invalid", heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, pubErr)
+	assert.Equal(t, heimdall.ErrKeyNotFound, notFoundErr)
+	assert.Error(t, invalidErr)
+
+	files, err := ioutil.ReadDir(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+	files, err = ioutil.ReadDir(heimdall.TestPubKeyDir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+
+	assert.Len(t, events, 2)
+	assert.Equal(t, pri.ID(), events[0].KeyID)
+}
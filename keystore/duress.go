@@ -0,0 +1,249 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides duress password which wipes private keys instead of unlocking them.
+
+package keystore
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/fileperm"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/iLogger"
+)
+
+var ErrUnlockFailed = errors.New("failed to unlock key - wrong password or invalid key file")
+var ErrDuressPwdNotSet = errors.New("duress password is not registered")
+var ErrDuressPwdUnlocksKey = errors.New("invalid duress password - duress password should not unlock private keys")
+
+// bit length of duress password verifier
+const duressVerifierLen = 256
+
+// duressFile is a format of duress password verifier file.
+type duressFile struct {
+	KDFOpt   *kdf.Opts
+	Salt     []byte
+	Verifier []byte
+}
+
+// SetDuressPwd registers duress password in duressFilePath for private keys of keyStore in priKeyDirPath.
+// The file should be placed out of private key directory, since the directory holds a key file only.
+// Duress password which unlocks any of the keys is refused, since it would unlock the keys instead of wiping them.
+func SetDuressPwd(duressPwd, duressFilePath string, kdfOpt *kdf.Opts, keyStore heimdall.KeyStore, priKeyDirPath string) error {
+	if err := checkNotUnlocking(duressPwd, keyStore, priKeyDirPath); err != nil {
+		return err
+	}
+
+	salt := make([]byte, kdf.DefaultSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+
+	verifier, err := kdf.DeriveKey([]byte(duressPwd), salt, duressVerifierLen, kdfOpt)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(&duressFile{
+		KDFOpt:   kdfOpt,
+		Salt:     salt,
+		Verifier: verifier,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := fileperm.MkdirAll(filepath.Dir(duressFilePath)); err != nil {
+		return err
+	}
+
+	return fileperm.WriteFile(duressFilePath, data)
+}
+
+// checkNotUnlocking checks that pwd does not unlock any private key of keyStore in priKeyDirPath.
+func checkNotUnlocking(pwd string, keyStore heimdall.KeyStore, priKeyDirPath string) error {
+	files, err := ioutil.ReadDir(priKeyDirPath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || heimdall.ValidateKeyID(file.Name()) != nil {
+			continue
+		}
+
+		if pri, err := keyStore.LoadPriKey(file.Name(), pwd); err == nil {
+			pri.Clear()
+			return ErrDuressPwdUnlocksKey
+		}
+	}
+
+	return nil
+}
+
+// IsDuressPwd checks if pwd is the duress password registered in duressFilePath.
+func IsDuressPwd(pwd, duressFilePath string) (bool, error) {
+	data, err := ioutil.ReadFile(duressFilePath)
+	if os.IsNotExist(err) {
+		return false, ErrDuressPwdNotSet
+	} else if err != nil {
+		return false, err
+	}
+
+	file := &duressFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return false, err
+	}
+
+	if file.KDFOpt == nil {
+		return false, ErrDuressPwdNotSet
+	}
+
+	verifier, err := kdf.DeriveKeyWithLimits([]byte(pwd), file.Salt, duressVerifierLen, file.KDFOpt)
+	if err != nil {
+		return false, err
+	}
+
+	return subtle.ConstantTimeCompare(verifier, file.Verifier) == 1, nil
+}
+
+// Wipe overwrites every file in key directory with random bytes before removing it,
+// so that the keys are not recoverable from the disk.
+// Files are removed even if some of them fail to be overwritten, and every failure is returned together.
+func Wipe(keyDirPath string) error {
+	files, err := ioutil.ReadDir(keyDirPath)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	var keyIds []heimdall.KeyID
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		if err := overwrite(filepath.Join(keyDirPath, file.Name()), file.Size()); err != nil {
+			errs = append(errs, err)
+		}
+
+		if heimdall.ValidateKeyID(file.Name()) == nil {
+			keyIds = append(keyIds, file.Name())
+		}
+	}
+
+	if err := os.RemoveAll(keyDirPath); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	for _, keyId := range keyIds {
+		event.Publish(event.KeyDeleted, keyId, keyDirPath)
+	}
+
+	return errors.Join(errs...)
+}
+
+func overwrite(path string, size int64) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	noise := make([]byte, size)
+	if _, err := rand.Read(noise); err != nil {
+		return err
+	}
+
+	if _, err := f.WriteAt(noise, 0); err != nil {
+		return err
+	}
+
+	return f.Sync()
+}
+
+// DuressKeyStore is a keystore which wipes private keys when they are unlocked with duress password.
+type DuressKeyStore struct {
+	keyStore       heimdall.KeyStore
+	priKeyDirPath  string
+	duressFilePath string
+}
+
+// NewDuressKeyStore wraps keyStore storing private keys in priKeyDirPath with duress password registered in duressFilePath.
+func NewDuressKeyStore(keyStore heimdall.KeyStore, priKeyDirPath, duressFilePath string) heimdall.KeyStore {
+	return &DuressKeyStore{
+		keyStore:       keyStore,
+		priKeyDirPath:  priKeyDirPath,
+		duressFilePath: duressFilePath,
+	}
+}
+
+// StorePriKey refuses duress password, which should never unlock private keys.
+func (keyStore *DuressKeyStore) StorePriKey(pri heimdall.PriKey, pwd string) error {
+	duress, err := IsDuressPwd(pwd, keyStore.duressFilePath)
+	if err != nil && err != ErrDuressPwdNotSet {
+		return err
+	}
+
+	if duress {
+		return ErrDuressPwdUnlocksKey
+	}
+
+	return keyStore.keyStore.StorePriKey(pri, pwd)
+}
+
+// LoadPriKey wipes private key directory if pwd is duress password.
+// Every failure is reported as ErrUnlockFailed, so duress unlock looks the same as wrong password.
+func (keyStore *DuressKeyStore) LoadPriKey(keyId heimdall.KeyID, pwd string) (heimdall.PriKey, error) {
+	duress, err := IsDuressPwd(pwd, keyStore.duressFilePath)
+	if err != nil && err != ErrDuressPwdNotSet {
+		return nil, ErrUnlockFailed
+	}
+
+	if duress {
+		if err := Wipe(keyStore.priKeyDirPath); err != nil {
+			iLogger.Error(nil, "[Heimdall] Error during wipe key dir")
+		}
+		return nil, ErrUnlockFailed
+	}
+
+	pri, err := keyStore.keyStore.LoadPriKey(keyId, pwd)
+	if err != nil {
+		return nil, ErrUnlockFailed
+	}
+
+	return pri, nil
+}
+
+func (keyStore *DuressKeyStore) StorePubKey(pub heimdall.PubKey) error {
+	return keyStore.keyStore.StorePubKey(pub)
+}
+
+func (keyStore *DuressKeyStore) LoadPubKey(keyId heimdall.KeyID) (heimdall.PubKey, error) {
+	return keyStore.keyStore.LoadPubKey(keyId)
+}
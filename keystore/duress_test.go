@@ -0,0 +1,183 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpDuressKeyStore(t *testing.T) (heimdall.KeyStore, string) {
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	duressFilePath := filepath.Join(heimdall.TestPriKeyDir, "duress")
+	hecdsaKeyStore := hecdsa.NewKeyStore(heimdall.TestKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	assert.NoError(t, keystore.SetDuressPwd("duress", duressFilePath, kdfOpt, hecdsaKeyStore, heimdall.TestKeyDir))
+
+	keyStore := keystore.NewDuressKeyStore(hecdsaKeyStore, heimdall.TestKeyDir, duressFilePath)
+
+	return keyStore, duressFilePath
+}
+
+func TestIsDuressPwd(t *testing.T) {
+	// given
+	_, duressFilePath := setUpDuressKeyStore(t)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	duress, err := keystore.IsDuressPwd("duress", duressFilePath)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, duress)
+
+	// when
+	duress, err = keystore.IsDuressPwd("password", duressFilePath)
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, duress)
+}
+
+func TestSetDuressPwd_UnlocksKey(t *testing.T) {
+	// given
+	setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	keyStore := hecdsa.NewKeyStore(heimdall.TestKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+	duressFilePath := filepath.Join(heimdall.TestPriKeyDir, "duress")
+
+	// when
+	err = keystore.SetDuressPwd("password", duressFilePath, kdfOpt, keyStore, heimdall.TestKeyDir)
+
+	// then
+	assert.Equal(t, keystore.ErrDuressPwdUnlocksKey, err)
+	_, notSetErr := keystore.IsDuressPwd("password", duressFilePath)
+	assert.Equal(t, keystore.ErrDuressPwdNotSet, notSetErr)
+}
+
+func TestIsDuressPwd_NotSet(t *testing.T) {
+	// when
+	duress, err := keystore.IsDuressPwd("duress", filepath.Join(heimdall.TestPriKeyDir, "duress"))
+
+	// then
+	assert.Equal(t, keystore.ErrDuressPwdNotSet, err)
+	assert.False(t, duress)
+}
+
+func TestDuressKeyStore_LoadPriKey(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyStore, _ := setUpDuressKeyStore(t)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	loaded, err := keyStore.LoadPriKey(pri.ID(), "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri, loaded)
+	_, err = os.Stat(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+}
+
+func TestDuressKeyStore_LoadPriKey_Duress(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyStore, _ := setUpDuressKeyStore(t)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	loaded, err := keyStore.LoadPriKey(pri.ID(), "duress")
+
+	// then
+	assert.Equal(t, keystore.ErrUnlockFailed, err)
+	assert.Nil(t, loaded)
+	_, err = os.Stat(heimdall.TestKeyDir)
+	assert.True(t, os.IsNotExist(err))
+
+	// when
+	loaded, err = keyStore.LoadPriKey(pri.ID(), "password")
+
+	// then
+	assert.Equal(t, keystore.ErrUnlockFailed, err)
+	assert.Nil(t, loaded)
+}
+
+func TestDuressKeyStore_LoadPriKey_WrongPwd(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyStore, _ := setUpDuressKeyStore(t)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	loaded, err := keyStore.LoadPriKey(pri.ID(), "wrong")
+
+	// then
+	assert.Equal(t, keystore.ErrUnlockFailed, err)
+	assert.Nil(t, loaded)
+	_, err = os.Stat(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+}
+
+func TestDuressKeyStore_StorePriKey_Duress(t *testing.T) {
+	// given
+	keyStore, _ := setUpDuressKeyStore(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	err := keyStore.StorePriKey(setUpPriKey(t), "duress")
+
+	// then
+	assert.Equal(t, keystore.ErrDuressPwdUnlocksKey, err)
+}
+
+func TestWipe_OverwriteFailed(t *testing.T) {
+	// given
+	setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	assert.NoError(t, os.Symlink(filepath.Join(heimdall.TestKeyDir, "missing"), filepath.Join(heimdall.TestKeyDir, "broken")))
+
+	// when
+	err := keystore.Wipe(heimdall.TestKeyDir)
+
+	// then
+	assert.Error(t, err)
+	_, statErr := os.Stat(heimdall.TestKeyDir)
+	assert.True(t, os.IsNotExist(statErr))
+}
@@ -0,0 +1,430 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides conversion between key files and Ethereum keystore V3 (Web3 Secret Storage) files,
+// so that existing Ethereum wallets can be used as node identities.
+
+package keystore
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/subtle"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"golang.org/x/crypto/sha3"
+)
+
+var ErrInvalidEthKeystore = errors.New("invalid ethereum keystore - keystore should be version 3 encrypted by aes-128-ctr")
+var ErrEthKeystoreMAC = errors.New("ethereum keystore MAC mismatch - wrong password or corrupted keystore")
+var ErrEthKDFNotSupported = errors.New("kdf not supported by ethereum keystore - kdf should be scrypt or pbkdf2 with hmac-sha256")
+var ErrNotEthKey = errors.New("not ethereum key - key should be ECDSA key on secp256k1")
+var ErrEthAddressMismatch = errors.New("ethereum address mismatch - address of keystore does not correspond to the key")
+
+const (
+	ethKeystoreVersion = 3
+	ethCipher          = "aes-128-ctr"
+	ethPrf             = "hmac-sha256"
+	ethKeyLen          = 32
+	ethSaltSize        = 32
+)
+
+// DefaultEthKDFOpt is key derivation option of keystores made by geth with standard scrypt parameters.
+var DefaultEthKDFOpt = &kdf.Opts{
+	KdfName:   kdf.SCRYPT,
+	KdfParams: map[string]string{"N": "262144", "R": "8", "P": "1"},
+}
+
+type ethKeystore struct {
+	Address string    `json:"address,omitempty"`
+	Crypto  ethCrypto `json:"crypto"`
+	ID      string    `json:"id"`
+	Version int       `json:"version"`
+}
+
+type ethCrypto struct {
+	Cipher       string          `json:"cipher"`
+	CipherText   string          `json:"ciphertext"`
+	CipherParams ethCipherParams `json:"cipherparams"`
+	KDF          string          `json:"kdf"`
+	KDFParams    ethKDFParams    `json:"kdfparams"`
+	MAC          string          `json:"mac"`
+}
+
+type ethCipherParams struct {
+	IV string `json:"iv"`
+}
+
+type ethKDFParams struct {
+	DKLen int    `json:"dklen"`
+	Salt  string `json:"salt"`
+	N     int    `json:"n,omitempty"`
+	R     int    `json:"r,omitempty"`
+	P     int    `json:"p,omitempty"`
+	C     int    `json:"c,omitempty"`
+	Prf   string `json:"prf,omitempty"`
+}
+
+// ImportEthKeystore decrypts Ethereum keystore with ethPwd, and stores the secp256k1 key in keyDirPath as key file encrypted with pwd.
+func ImportEthKeystore(data []byte, ethPwd, pwd, keyDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) (heimdall.KeyID, error) {
+	pri, err := DecryptEthKeystore(data, ethPwd)
+	if err != nil {
+		return "", err
+	}
+	defer pri.Clear()
+
+	if err := hecdsa.StorePriKey(pri, pwd, keyDirPath, encOpt, kdfOpt); err != nil {
+		return "", err
+	}
+
+	return pri.ID(), nil
+}
+
+// ExportEthKeystore loads private key in keyDirPath with pwd, and encrypts it into Ethereum keystore with ethPwd.
+// The key should be on secp256k1. If kdfOpt is nil, DefaultEthKDFOpt is used.
+func ExportEthKeystore(keyDirPath, pwd, ethPwd string, kdfOpt *kdf.Opts) ([]byte, error) {
+	pri, err := hecdsa.LoadPriKey(keyDirPath, pwd)
+	if err != nil {
+		return nil, err
+	}
+	defer pri.Clear()
+
+	return EncryptEthKeystore(pri, ethPwd, kdfOpt)
+}
+
+// DecryptEthKeystore decrypts Ethereum keystore V3 with pwd.
+// Address of keystore, which is optional in the format, is checked against the key if it exists.
+func DecryptEthKeystore(data []byte, pwd string) (heimdall.PriKey, error) {
+	keystore := new(ethKeystore)
+	if err := json.Unmarshal(data, keystore); err != nil {
+		return nil, err
+	}
+	if keystore.Version != ethKeystoreVersion || keystore.Crypto.Cipher != ethCipher {
+		return nil, ErrInvalidEthKeystore
+	}
+
+	kdfOpt, err := ethKDFOpt(keystore.Crypto.KDF, keystore.Crypto.KDFParams)
+	if err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(keystore.Crypto.KDFParams.Salt)
+	if err != nil {
+		return nil, ErrInvalidEthKeystore
+	}
+	iv, err := hex.DecodeString(keystore.Crypto.CipherParams.IV)
+	if err != nil || len(iv) != aes.BlockSize {
+		return nil, ErrInvalidEthKeystore
+	}
+	cipherText, err := hex.DecodeString(keystore.Crypto.CipherText)
+	if err != nil {
+		return nil, ErrInvalidEthKeystore
+	}
+	mac, err := hex.DecodeString(keystore.Crypto.MAC)
+	if err != nil {
+		return nil, ErrInvalidEthKeystore
+	}
+
+	dKey, err := kdf.DeriveKeyWithLimits([]byte(pwd), salt, ethKeyLen*8, kdfOpt)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(ethMAC(dKey, cipherText), mac) != 1 {
+		return nil, ErrEthKeystoreMAC
+	}
+
+	scalar, err := aesCTR(dKey[:16], iv, cipherText)
+	if err != nil {
+		return nil, err
+	}
+
+	pri, err := newEthPriKey(scalar)
+	if err != nil {
+		return nil, err
+	}
+
+	if keystore.Address != "" {
+		address, err := EthAddress(pri.PublicKey())
+		if err != nil {
+			return nil, err
+		}
+		if !strings.EqualFold(strings.TrimPrefix(keystore.Address, "0x"), address) {
+			pri.Clear()
+			return nil, ErrEthAddressMismatch
+		}
+	}
+
+	return pri, nil
+}
+
+// EncryptEthKeystore encrypts secp256k1 private key into Ethereum keystore V3 with pwd.
+// kdfOpt should be scrypt or pbkdf2 with hashing.SHA2_256. If kdfOpt is nil, DefaultEthKDFOpt is used.
+func EncryptEthKeystore(pri heimdall.PriKey, pwd string, kdfOpt *kdf.Opts) ([]byte, error) {
+	if kdfOpt == nil {
+		kdfOpt = DefaultEthKDFOpt
+	}
+
+	scalar, err := ethScalar(pri)
+	if err != nil {
+		return nil, err
+	}
+	address, err := EthAddress(pri.PublicKey())
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, ethSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+	id, err := newUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	kdfName, kdfParams, err := ethKDFParamsOf(kdfOpt)
+	if err != nil {
+		return nil, err
+	}
+	kdfParams.Salt = hex.EncodeToString(salt)
+
+	dKey, err := kdf.DeriveKey([]byte(pwd), salt, ethKeyLen*8, kdfOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	cipherText, err := aesCTR(dKey[:16], iv, scalar)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(ethKeystore{
+		Address: address,
+		Crypto: ethCrypto{
+			Cipher:       ethCipher,
+			CipherText:   hex.EncodeToString(cipherText),
+			CipherParams: ethCipherParams{IV: hex.EncodeToString(iv)},
+			KDF:          kdfName,
+			KDFParams:    kdfParams,
+			MAC:          hex.EncodeToString(ethMAC(dKey, cipherText)),
+		},
+		ID:      id,
+		Version: ethKeystoreVersion,
+	})
+}
+
+// EthAddress returns Ethereum address of secp256k1 public key in lowercase hex without 0x prefix,
+// which is the last 20 bytes of keccak-256 hash of the uncompressed point.
+func EthAddress(pub heimdall.PubKey) (string, error) {
+	if pub.KeyGenOpt().ToString() != hecdsa.ECSECP256K1 {
+		return "", ErrNotEthKey
+	}
+
+	pubBytes, err := pub.ToByte()
+	if err != nil {
+		return "", err
+	}
+	var info subjectPublicKeyInfo
+	if _, err := asn1.Unmarshal(pubBytes, &info); err != nil {
+		return "", err
+	}
+
+	// uncompressed point is 0x04 || X || Y
+	point := info.PublicKey.RightAlign()
+	if len(point) != 65 || point[0] != 4 {
+		return "", ErrNotEthKey
+	}
+
+	return hex.EncodeToString(keccak256(point[1:])[12:]), nil
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+// ecPrivateKey is private key structure of SEC 1, which is encoding of ECDSA private key.
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+// ethScalar returns 32 bytes private scalar of secp256k1 private key.
+func ethScalar(pri heimdall.PriKey) ([]byte, error) {
+	if pri.KeyGenOpt().ToString() != hecdsa.ECSECP256K1 {
+		return nil, ErrNotEthKey
+	}
+
+	priBytes, err := pri.ToByte()
+	if err != nil {
+		return nil, err
+	}
+	var privateKey ecPrivateKey
+	if _, err := asn1.Unmarshal(priBytes, &privateKey); err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(privateKey.PrivateKey).FillBytes(make([]byte, ethKeyLen)), nil
+}
+
+func newEthPriKey(scalar []byte) (heimdall.PriKey, error) {
+	if len(scalar) != ethKeyLen {
+		return nil, ErrInvalidEthKeystore
+	}
+
+	pri, err := newECPriKey(hecdsa.ECSECP256K1, scalar)
+	if err != nil {
+		return nil, ErrInvalidEthKeystore
+	}
+
+	return pri, nil
+}
+
+// newECPriKey makes ECDSA private key of scalar on curve.
+func newECPriKey(curve string, scalar []byte) (heimdall.PriKey, error) {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(curve)
+	if err != nil {
+		return nil, err
+	}
+
+	d := new(big.Int).SetBytes(scalar)
+	if d.Sign() <= 0 || d.Cmp(keyGenOpt.Curve.Params().N) >= 0 {
+		return nil, hecdsa.ErrInvalidECKey
+	}
+
+	pri := &ecdsa.PrivateKey{D: d}
+	pri.Curve = keyGenOpt.Curve
+	pri.X, pri.Y = keyGenOpt.Curve.ScalarBaseMult(scalar)
+
+	return hecdsa.NewPriKey(pri), nil
+}
+
+// ethKDFOpt converts kdf and kdfparams of Ethereum keystore into kdf option.
+func ethKDFOpt(kdfName string, params ethKDFParams) (*kdf.Opts, error) {
+	if params.DKLen != ethKeyLen {
+		return nil, ErrInvalidEthKeystore
+	}
+
+	switch kdfName {
+	case "scrypt":
+		return kdf.NewOpts(kdf.SCRYPT, map[string]string{
+			"N": strconv.Itoa(params.N),
+			"R": strconv.Itoa(params.R),
+			"P": strconv.Itoa(params.P),
+		})
+	case "pbkdf2":
+		if params.Prf != ethPrf {
+			return nil, ErrEthKDFNotSupported
+		}
+		return kdf.NewOpts(kdf.PBKDF2, map[string]string{
+			"iteration": strconv.Itoa(params.C),
+			"hashOpt":   hashing.SHA2_256,
+		})
+	}
+
+	return nil, ErrEthKDFNotSupported
+}
+
+// ethKDFParamsOf converts kdf option into kdf and kdfparams of Ethereum keystore, except salt.
+func ethKDFParamsOf(kdfOpt *kdf.Opts) (string, ethKDFParams, error) {
+	params := ethKDFParams{DKLen: ethKeyLen}
+
+	switch kdfOpt.KdfName {
+	case kdf.SCRYPT:
+		var err error
+		if params.N, err = strconv.Atoi(kdfOpt.KdfParams["N"]); err != nil {
+			return "", params, err
+		}
+		if params.R, err = strconv.Atoi(kdfOpt.KdfParams["R"]); err != nil {
+			return "", params, err
+		}
+		if params.P, err = strconv.Atoi(kdfOpt.KdfParams["P"]); err != nil {
+			return "", params, err
+		}
+		return "scrypt", params, nil
+	case kdf.PBKDF2:
+		if kdfOpt.KdfParams["hashOpt"] != hashing.SHA2_256 {
+			return "", params, ErrEthKDFNotSupported
+		}
+		var err error
+		if params.C, err = strconv.Atoi(kdfOpt.KdfParams["iteration"]); err != nil {
+			return "", params, err
+		}
+		params.Prf = ethPrf
+		return "pbkdf2", params, nil
+	}
+
+	return "", params, ErrEthKDFNotSupported
+}
+
+// ethMAC is keccak-256 hash of the second half of derived key and cipher text.
+func ethMAC(dKey, cipherText []byte) []byte {
+	return keccak256(dKey[16:32], cipherText)
+}
+
+func keccak256(data ...[]byte) []byte {
+	hash := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		hash.Write(d)
+	}
+
+	return hash.Sum(nil)
+}
+
+func aesCTR(key, iv, text []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]byte, len(text))
+	cipher.NewCTR(block, iv).XORKeyStream(out, text)
+
+	return out, nil
+}
+
+// newUUID returns random UUID (version 4), which is ID of Ethereum keystore.
+func newUUID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	id[6] = id[6]&0x0f | 0x40
+	id[8] = id[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:]), nil
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"crypto/ecdsa"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+// test vectors of Web3 Secret Storage Definition
+const ethTestPwd = "testpassword"
+const ethTestKey = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+
+const ethTestPbkdf2Keystore = `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"6087dab2f9fdbbfaddc31a909735c1e6"},"ciphertext":"5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46","kdf":"pbkdf2","kdfparams":{"c":262144,"dklen":32,"prf":"hmac-sha256","salt":"ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"},"mac":"517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`
+
+const ethTestScryptKeystore = `{"crypto":{"cipher":"aes-128-ctr","cipherparams":{"iv":"83dbcc02d8ccb40e466191a123791e0e"},"ciphertext":"d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c","kdf":"scrypt","kdfparams":{"dklen":32,"n":262144,"r":1,"p":8,"salt":"ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"},"mac":"2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"},"id":"3198bc9c-6672-5ab3-d995-4942343ae5b6","version":3}`
+
+func setUpEthPriKey(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECSECP256K1)
+	assert.NoError(t, err)
+
+	scalar, err := hex.DecodeString(ethTestKey)
+	assert.NoError(t, err)
+	pri := &ecdsa.PrivateKey{D: new(big.Int).SetBytes(scalar)}
+	pri.Curve = keyGenOpt.Curve
+	pri.X, pri.Y = keyGenOpt.Curve.ScalarBaseMult(scalar)
+
+	return hecdsa.NewPriKey(pri)
+}
+
+func TestDecryptEthKeystore(t *testing.T) {
+	// given
+	expected := setUpEthPriKey(t)
+
+	for _, data := range []string{ethTestPbkdf2Keystore, ethTestScryptKeystore} {
+		// when
+		pri, err := keystore.DecryptEthKeystore([]byte(data), ethTestPwd)
+		_, wrongPwdErr := keystore.DecryptEthKeystore([]byte(data), "wrong")
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, expected.ID(), pri.ID())
+		assert.Equal(t, keystore.ErrEthKeystoreMAC, wrongPwdErr)
+	}
+}
+
+func TestEncryptEthKeystore(t *testing.T) {
+	// given
+	pri := setUpEthPriKey(t)
+	kdfOpts := []*kdf.Opts{
+		{KdfName: kdf.SCRYPT, KdfParams: map[string]string{"N": "16384", "R": "8", "P": "1"}},
+		{KdfName: kdf.PBKDF2, KdfParams: map[string]string{"iteration": "1000", "hashOpt": hashing.SHA2_256}},
+	}
+
+	for _, kdfOpt := range kdfOpts {
+		// when
+		data, err := keystore.EncryptEthKeystore(pri, ethTestPwd, kdfOpt)
+
+		// then
+		assert.NoError(t, err)
+		decrypted, err := keystore.DecryptEthKeystore(data, ethTestPwd)
+		assert.NoError(t, err)
+		assert.Equal(t, pri.ID(), decrypted.ID())
+	}
+}
+
+func TestEncryptEthKeystore_NotSupported(t *testing.T) {
+	// given
+	pri := setUpEthPriKey(t)
+	p256Pri := setUpPriKey(t)
+	pbkdf2Opt := &kdf.Opts{KdfName: kdf.PBKDF2, KdfParams: map[string]string{"iteration": "1000", "hashOpt": hashing.SHA384}}
+
+	// when
+	_, keyErr := keystore.EncryptEthKeystore(p256Pri, ethTestPwd, nil)
+	_, kdfErr := keystore.EncryptEthKeystore(pri, ethTestPwd, pbkdf2Opt)
+
+	// then
+	assert.Equal(t, keystore.ErrNotEthKey, keyErr)
+	assert.Equal(t, keystore.ErrEthKDFNotSupported, kdfErr)
+}
+
+func TestEthAddress(t *testing.T) {
+	// given
+	pri := setUpEthPriKey(t)
+
+	// when
+	address, err := keystore.EthAddress(pri.PublicKey())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "008aeeda4d805471df9b2a5b0f38a0c3bcba786b", address)
+}
+
+func TestImportEthKeystore(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	keyId, err := keystore.ImportEthKeystore([]byte(ethTestPbkdf2Keystore), ethTestPwd, "password", heimdall.TestKeyDir, encOpt, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, setUpEthPriKey(t).ID(), keyId)
+
+	data, err := keystore.ExportEthKeystore(heimdall.TestKeyDir, "password", "ethPassword", kdfOpt)
+	assert.NoError(t, err)
+	pri, err := keystore.DecryptEthKeystore(data, "ethPassword")
+	assert.NoError(t, err)
+	assert.Equal(t, keyId, pri.ID())
+}
@@ -0,0 +1,258 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides integrity verification of key files in a key directory.
+
+package keystore
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/htpm"
+	"github.com/DE-labtory/heimdall/kdf"
+)
+
+var ErrInvalidFileName = errors.New("invalid key file name - file name should be key ID")
+var ErrUnknownFormat = errors.New("unknown key file format")
+var ErrSKIMismatch = errors.New("SKI mismatch - SKI in key file does not correspond to key ID")
+var ErrInvalidHints = errors.New("invalid encryption hints in key file")
+var ErrInvalidEncryptedKey = errors.New("invalid encrypted key in key file")
+
+// file kinds
+const (
+	EncryptedPriKey = "encrypted private key"
+	PlainPriKey     = "plain private key"
+	PubKey          = "public key"
+	HardwareKey     = "hardware key"
+	Unknown         = "unknown"
+)
+
+// file status
+const (
+	Valid     = "valid"
+	Corrupted = "corrupted"
+	Orphaned  = "orphaned"
+)
+
+// FileReport is a result of verifying a file in key directory.
+type FileReport struct {
+	Name   string
+	KeyID  heimdall.KeyID
+	Kind   string
+	Status string
+	Err    error
+}
+
+// Report is a result of verifying a key directory.
+type Report struct {
+	KeyDirPath string
+	Files      []*FileReport
+}
+
+// Healthy returns true if every file in key directory is valid.
+func (report *Report) Healthy() bool {
+	for _, file := range report.Files {
+		if file.Status != Valid {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Problems returns reports of corrupted or orphaned files.
+func (report *Report) Problems() []*FileReport {
+	problems := make([]*FileReport, 0)
+	for _, file := range report.Files {
+		if file.Status != Valid {
+			problems = append(problems, file)
+		}
+	}
+
+	return problems
+}
+
+// Verify walks all files in key directory and checks their structure, SKI and key ID consistency and KDF hints,
+// without decrypting any private key. Files whose names are not key IDs are reported as orphaned,
+// and files named by key ID which can not be parsed (ex. truncated) are reported as corrupted.
+func Verify(keyDirPath string) (*Report, error) {
+	files, err := ioutil.ReadDir(keyDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &Report{KeyDirPath: keyDirPath}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		keyBytes, err := ioutil.ReadFile(filepath.Join(keyDirPath, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		report.Files = append(report.Files, verifyFile(file.Name(), keyBytes))
+	}
+
+	return report, nil
+}
+
+// verifyFile verifies a key file whose name should be key ID.
+func verifyFile(name string, keyBytes []byte) *FileReport {
+	fileReport := &FileReport{Name: name, KeyID: name, Kind: Unknown}
+
+	if err := heimdall.ValidateKeyID(name); err != nil {
+		fileReport.KeyID = ""
+		fileReport.Status = Orphaned
+		fileReport.Err = ErrInvalidFileName
+		return fileReport
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(keyBytes, &fields); err == nil {
+		if _, ok := fields["EncryptedKey"]; ok {
+			fileReport.Kind = EncryptedPriKey
+			fileReport.Err = verifyEncryptedKeyFile(name, keyBytes)
+		} else if _, ok := fields["KeyContext"]; ok {
+			fileReport.Kind = HardwareKey
+			fileReport.Err = verifyHardwareKeyFile(name, keyBytes)
+		} else {
+			fileReport.Err = ErrUnknownFormat
+		}
+	} else {
+		fileReport.Kind, fileReport.Err = verifyPlainKeyFile(name, keyBytes)
+	}
+
+	if fileReport.Err == nil {
+		fileReport.Status = Valid
+	} else {
+		fileReport.Status = Corrupted
+	}
+
+	return fileReport
+}
+
+// verifyEncryptedKeyFile checks SKI and encryption hints of key file made by hecdsa.StorePriKey.
+func verifyEncryptedKeyFile(keyId heimdall.KeyID, keyBytes []byte) error {
+	var keyFile hecdsa.KeyFile
+	if err := json.Unmarshal(keyBytes, &keyFile); err != nil {
+		return err
+	}
+
+	if err := heimdall.SKIValidCheck(keyId, keyFile.SKI); err != nil {
+		return ErrSKIMismatch
+	}
+
+	if keyFile.Version > hecdsa.KeyFileVersion {
+		return hecdsa.ErrUnsupportedKeyFileVersion
+	}
+
+	hints := keyFile.Hints
+	if hints == nil || hints.KDFOpt == nil || hints.EncOpt == nil || len(hints.KDFSalt) == 0 {
+		return ErrInvalidHints
+	}
+
+	if keyFile.Version >= 1 && len(hints.MAC) == 0 {
+		return hecdsa.ErrKeyFileMACMissing
+	}
+
+	if (keyFile.Version == 0 || len(hints.MAC) == 0) && hecdsa.StrictKeyFiles() {
+		return hecdsa.ErrUnversionedKeyFile
+	}
+
+	kdfOpt, err := kdf.NewOpts(hints.KDFOpt.KdfName, hints.KDFOpt.KdfParams)
+	if err != nil {
+		return ErrInvalidHints
+	}
+
+	if err := kdf.CheckLimits(kdfOpt); err != nil {
+		return err
+	}
+
+	if _, err := encryption.NewOpts(hints.EncOpt.Algorithm, hints.EncOpt.KeyLen, hints.EncOpt.OpMode); err != nil {
+		return ErrInvalidHints
+	}
+
+	encryptedKey, err := hex.DecodeString(keyFile.EncryptedKey)
+	if err != nil || len(encryptedKey) == 0 {
+		return ErrInvalidEncryptedKey
+	}
+
+	return nil
+}
+
+// verifyHardwareKeyFile checks public key of key file made by hardware backends such as htpm,
+// recovering it by the recoverer registered for algorithm of key ID.
+func verifyHardwareKeyFile(keyId heimdall.KeyID, keyBytes []byte) error {
+	var keyFile htpm.KeyFile
+	if err := json.Unmarshal(keyBytes, &keyFile); err != nil {
+		return err
+	}
+
+	recoverer, err := heimdall.KeyRecovererOfKeyID(keyId)
+	if err != nil {
+		return ErrUnknownFormat
+	}
+
+	pub, err := recoverer.RecoverKeyFromByte(keyFile.PublicKey, false)
+	if err != nil {
+		return err
+	}
+
+	if !heimdall.MatchKeyID(keyId, pub) {
+		return ErrSKIMismatch
+	}
+
+	return nil
+}
+
+// verifyPlainKeyFile checks key file of public key or private key stored without password,
+// recovering it by the recoverer registered for algorithm of key ID.
+func verifyPlainKeyFile(keyId heimdall.KeyID, keyBytes []byte) (string, error) {
+	recoverer, err := heimdall.KeyRecovererOfKeyID(keyId)
+	if err != nil {
+		return Unknown, ErrUnknownFormat
+	}
+
+	kind := PubKey
+	key, err := recoverer.RecoverKeyFromByte(keyBytes, false)
+	if err != nil {
+		kind = PlainPriKey
+		key, err = recoverer.RecoverKeyFromByte(keyBytes, true)
+	}
+	if err != nil {
+		return Unknown, ErrUnknownFormat
+	}
+
+	if pri, ok := key.(heimdall.PriKey); ok {
+		defer pri.Clear()
+	}
+
+	if !heimdall.MatchKeyID(keyId, key) {
+		return kind, ErrSKIMismatch
+	}
+
+	return kind, nil
+}
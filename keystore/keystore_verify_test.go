@@ -0,0 +1,164 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpKeyDir(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	err = hecdsa.StorePriKey(pri, "password", heimdall.TestKeyDir, encOpt, kdfOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func TestVerify(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	otherPri := setUpPriKey(t)
+	pubBytes, err := otherPri.PublicKey().ToByte()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, otherPri.ID()), pubBytes, 0600))
+
+	// when
+	report, err := keystore.Verify(heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, report.Healthy())
+	assert.Len(t, report.Files, 2)
+	for _, file := range report.Files {
+		if file.KeyID == pri.ID() {
+			assert.Equal(t, keystore.EncryptedPriKey, file.Kind)
+		} else {
+			assert.Equal(t, keystore.PubKey, file.Kind)
+		}
+	}
+}
+
+func TestVerify_OtherAlgorithms(t *testing.T) {
+	// given
+	setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	rsaKeyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA2048)
+	assert.NoError(t, err)
+	rsaPri, err := hrsa.GenerateKey(rsaKeyGenOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, hecdsa.StorePubKey(rsaPri.PublicKey(), heimdall.TestKeyDir))
+
+	ed25519KeyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	ed25519Pri, err := hed25519.GenerateKey(ed25519KeyGenOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, hecdsa.StorePubKey(ed25519Pri.PublicKey(), heimdall.TestKeyDir))
+
+	// when
+	report, err := keystore.Verify(heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, report.Healthy())
+	assert.Len(t, report.Files, 3)
+	for _, file := range report.Files {
+		if file.KeyID == rsaPri.ID() || file.KeyID == ed25519Pri.ID() {
+			assert.Equal(t, keystore.PubKey, file.Kind)
+		}
+	}
+}
+
+func TestVerify_Problems(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	keyFilePath := filepath.Join(heimdall.TestKeyDir, pri.ID())
+	jsonKeyFile, err := ioutil.ReadFile(keyFilePath)
+	assert.NoError(t, err)
+
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+	keyFile.Hints.KDFOpt.KdfName = "BCRYPT"
+	jsonKeyFile, err = json.Marshal(keyFile)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(keyFilePath, jsonKeyFile, 0600))
+
+	otherPri := setUpPriKey(t)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, otherPri.ID()), jsonKeyFile, 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, "key.tmp"), []byte("partial"), 0600))
+
+	truncatedPri := setUpPriKey(t)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, truncatedPri.ID()), jsonKeyFile[:len(jsonKeyFile)/2], 0600))
+	garbledPri := setUpPriKey(t)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, garbledPri.ID()), []byte("\x00\x01garbage"), 0600))
+
+	// when
+	report, err := keystore.Verify(heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.False(t, report.Healthy())
+
+	problems := make(map[string]*keystore.FileReport)
+	for _, problem := range report.Problems() {
+		problems[problem.Name] = problem
+	}
+
+	assert.Len(t, problems, 5)
+	assert.Equal(t, keystore.Corrupted, problems[pri.ID()].Status)
+	assert.Equal(t, keystore.ErrInvalidHints, problems[pri.ID()].Err)
+	assert.Equal(t, keystore.ErrSKIMismatch, problems[otherPri.ID()].Err)
+	assert.Equal(t, keystore.Orphaned, problems["key.tmp"].Status)
+	assert.Equal(t, keystore.Corrupted, problems[truncatedPri.ID()].Status)
+	assert.Equal(t, keystore.Corrupted, problems[garbledPri.ID()].Status)
+}
+
+func setUpPriKey(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides keystore storage backend on LevelDB, so nodes running LevelDB for the ledger can keep
+// encrypted key files in the same database instead of loose files in key directories.
+
+package keystore
+
+import (
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// key prefixes of private and public key storages of LevelDB key store
+const (
+	LevelDBPriKeyPrefix = "heimdall/prikey/"
+	LevelDBPubKeyPrefix = "heimdall/pubkey/"
+)
+
+// LevelDBStorage is a storage on LevelDB, which keeps key files under keys of prefix and name.
+// Key files are written with sync, so a stored key survives crash of the node.
+type LevelDBStorage struct {
+	db     *leveldb.DB
+	prefix string
+}
+
+// NewLevelDBStorage makes storage on opened database. Prefix separates key files from other data (ex. ledger) in the database,
+// and should not be a prefix of other storage's prefix.
+func NewLevelDBStorage(db *leveldb.DB, prefix string) *LevelDBStorage {
+	return &LevelDBStorage{db: db, prefix: prefix}
+}
+
+// NewLevelDBKeyStore makes key store whose private and public keys are kept in opened database.
+func NewLevelDBKeyStore(db *leveldb.DB, encOpt *encryption.Opts, kdfOpt *kdf.Opts) heimdall.KeyStore {
+	return hecdsa.NewKeyStoreWithStorage(NewLevelDBStorage(db, LevelDBPriKeyPrefix), NewLevelDBStorage(db, LevelDBPubKeyPrefix), encOpt, kdfOpt)
+}
+
+func (storage *LevelDBStorage) Put(name string, data []byte) error {
+	return storage.db.Put(storage.key(name), data, &opt.WriteOptions{Sync: true})
+}
+
+func (storage *LevelDBStorage) Get(name string) ([]byte, error) {
+	data, err := storage.db.Get(storage.key(name), nil)
+	if err == leveldb.ErrNotFound {
+		return nil, heimdall.ErrKeyNotFound
+	}
+
+	return data, err
+}
+
+func (storage *LevelDBStorage) List() ([]string, error) {
+	iter := storage.db.NewIterator(util.BytesPrefix([]byte(storage.prefix)), nil)
+	defer iter.Release()
+
+	names := make([]string, 0)
+	for iter.Next() {
+		names = append(names, string(iter.Key()[len(storage.prefix):]))
+	}
+
+	return names, iter.Error()
+}
+
+func (storage *LevelDBStorage) Delete(name string) error {
+	return storage.db.Delete(storage.key(name), &opt.WriteOptions{Sync: true})
+}
+
+// key returns database key of key file of name.
+func (storage *LevelDBStorage) key(name string) []byte {
+	return []byte(storage.prefix + name)
+}
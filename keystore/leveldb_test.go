@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+func setUpLevelDB(t *testing.T) *leveldb.DB {
+	db, err := leveldb.OpenFile(heimdall.TestKeyDir, nil)
+	assert.NoError(t, err)
+
+	return db
+}
+
+func TestLevelDBStorage(t *testing.T) {
+	// given
+	db := setUpLevelDB(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer db.Close()
+	assert.NoError(t, db.Put([]byte("ledger/block/1"), []byte("block"), nil))
+	storage := keystore.NewLevelDBStorage(db, keystore.LevelDBPubKeyPrefix)
+
+	// when
+	assert.NoError(t, storage.Put("a", []byte("first")))
+	assert.NoError(t, storage.Put("b", []byte("second")))
+	assert.NoError(t, storage.Put("a", []byte("replaced")))
+
+	// then
+	data, err := storage.Get("a")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("replaced"), data)
+	names, err := storage.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, names)
+
+	// when
+	assert.NoError(t, storage.Delete("a"))
+	assert.NoError(t, storage.Delete("a"))
+
+	// then
+	_, err = storage.Get("a")
+	assert.Equal(t, heimdall.ErrKeyNotFound, err)
+	names, err = storage.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"b"}, names)
+	block, err := db.Get([]byte("ledger/block/1"), nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("block"), block)
+}
+
+func TestNewLevelDBKeyStore(t *testing.T) {
+	// given
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	db := setUpLevelDB(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyStore := keystore.NewLevelDBKeyStore(db, encOpt, kdfOpt)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(pri.PublicKey()))
+
+	// when
+	assert.NoError(t, db.Close())
+	db = setUpLevelDB(t)
+	defer db.Close()
+	keyStore = keystore.NewLevelDBKeyStore(db, encOpt, kdfOpt)
+	loadedPri, priErr := keyStore.LoadPriKey(pri.ID(), "password")
+	loadedPub, pubErr := keyStore.LoadPubKey(pri.ID())
+
+	// then
+	assert.NoError(t, priErr)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+	assert.NoError(t, pubErr)
+	assert.Equal(t, pri.ID(), loadedPub.ID())
+
+	priNames, err := keystore.NewLevelDBStorage(db, keystore.LevelDBPriKeyPrefix).List()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{pri.ID()}, priNames)
+}
@@ -0,0 +1,155 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides listing of keys stored in a key directory, for inventory of keys of a node.
+
+package keystore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+// KeyInfo is information of a stored key, which is read from its key file without decrypting private key.
+type KeyInfo struct {
+	KeyID     heimdall.KeyID
+	KeyGenOpt string
+	Private   bool
+	Kind      string
+	CreatedAt time.Time
+	Metadata  *hecdsa.KeyMetadata
+}
+
+// ListKeys returns information of keys stored in key directory, such as key ID, key generation option and whether
+// the key is private, without decrypting any private key. Creation time is taken from metadata of the key file,
+// or modification time of the key file if it has no metadata.
+// Files whose names are not key IDs or which can not be parsed are skipped, and can be found by Verify.
+func ListKeys(keyDirPath string) ([]*KeyInfo, error) {
+	files, err := ioutil.ReadDir(keyDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]*KeyInfo, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || heimdall.ValidateKeyID(file.Name()) != nil {
+			continue
+		}
+
+		keyBytes, err := ioutil.ReadFile(filepath.Join(keyDirPath, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		keyInfo, err := readKeyInfo(file.Name(), keyBytes)
+		if err != nil {
+			continue
+		}
+		if keyInfo.Metadata != nil && !keyInfo.Metadata.CreatedAt.IsZero() {
+			keyInfo.CreatedAt = keyInfo.Metadata.CreatedAt
+		} else {
+			keyInfo.CreatedAt = file.ModTime()
+		}
+
+		keys = append(keys, keyInfo)
+	}
+
+	return keys, nil
+}
+
+// FindKeys returns information of keys in key directory whose metadata has usage. (ex. hecdsa.UsageTLS)
+func FindKeys(keyDirPath, usage string) ([]*KeyInfo, error) {
+	keys, err := ListKeys(keyDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]*KeyInfo, 0)
+	for _, key := range keys {
+		if key.Metadata != nil && key.Metadata.Usage == usage {
+			found = append(found, key)
+		}
+	}
+
+	return found, nil
+}
+
+// readKeyInfo reads information of key file whose name is key ID.
+func readKeyInfo(keyId heimdall.KeyID, keyBytes []byte) (*KeyInfo, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(keyBytes, &fields); err != nil {
+		return readPlainKeyInfo(keyId, keyBytes)
+	}
+
+	keyInfo := &KeyInfo{KeyID: keyId, Private: true}
+	if _, ok := fields["EncryptedKey"]; ok {
+		keyInfo.Kind = EncryptedPriKey
+	} else if _, ok := fields["KeyGenOpt"]; ok {
+		keyInfo.Kind = HardwareKey
+	} else {
+		return nil, ErrUnknownFormat
+	}
+
+	// key files of encrypted keys and keys in TPM or KMS (htpm, hkms) have key generation option in the same field
+	var keyFile hecdsa.KeyFile
+	if err := json.Unmarshal(keyBytes, &keyFile); err != nil {
+		return nil, err
+	}
+	keyInfo.KeyGenOpt = keyFile.KeyGenOpt
+	keyInfo.Metadata = keyFile.Metadata
+
+	// key files stored before key generation option was recorded have algorithm only in key ID
+	if keyInfo.KeyGenOpt == "" {
+		if info, err := heimdall.ParseKeyID(keyId); err == nil && !info.IsLegacy() {
+			keyInfo.KeyGenOpt = info.KeyType.ToString()
+		}
+	}
+
+	return keyInfo, nil
+}
+
+// readPlainKeyInfo reads information of key file of public key or private key stored without password,
+// recovering it by the recoverer registered for algorithm of key ID.
+func readPlainKeyInfo(keyId heimdall.KeyID, keyBytes []byte) (*KeyInfo, error) {
+	recoverer, err := heimdall.KeyRecovererOfKeyID(keyId)
+	if err != nil {
+		return nil, ErrUnknownFormat
+	}
+
+	keyInfo := &KeyInfo{KeyID: keyId, Kind: PubKey}
+	key, err := recoverer.RecoverKeyFromByte(keyBytes, false)
+	if err != nil {
+		keyInfo.Kind = PlainPriKey
+		keyInfo.Private = true
+		key, err = recoverer.RecoverKeyFromByte(keyBytes, true)
+	}
+	if err != nil {
+		return nil, ErrUnknownFormat
+	}
+
+	if pri, ok := key.(heimdall.PriKey); ok {
+		defer pri.Clear()
+	}
+	keyInfo.KeyGenOpt = key.KeyGenOpt().ToString()
+
+	return keyInfo, nil
+}
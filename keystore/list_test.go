@@ -0,0 +1,110 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListKeys(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	otherPri := setUpPriKey(t)
+	pubBytes, err := otherPri.PublicKey().ToByte()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, otherPri.ID()), pubBytes, 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, "notes.txt"), []byte("notes"), 0600))
+
+	// when
+	keys, err := keystore.ListKeys(heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	for _, key := range keys {
+		assert.False(t, key.CreatedAt.IsZero())
+		if key.KeyID == pri.ID() {
+			assert.Equal(t, hecdsa.ECP384, key.KeyGenOpt)
+			assert.True(t, key.Private)
+			assert.Equal(t, keystore.EncryptedPriKey, key.Kind)
+		} else {
+			assert.Equal(t, otherPri.ID(), key.KeyID)
+			assert.Equal(t, hecdsa.ECP256, key.KeyGenOpt)
+			assert.False(t, key.Private)
+			assert.Equal(t, keystore.PubKey, key.Kind)
+		}
+	}
+}
+
+func TestListKeys_Ed25519(t *testing.T) {
+	// given
+	setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	assert.NoError(t, hecdsa.StorePubKey(pri.PublicKey(), heimdall.TestKeyDir))
+
+	// when
+	keys, err := keystore.ListKeys(heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	for _, key := range keys {
+		if key.KeyID == pri.ID() {
+			assert.Equal(t, hed25519.ED25519, key.KeyGenOpt)
+			assert.Equal(t, keystore.PubKey, key.Kind)
+		}
+	}
+}
+
+func TestFindKeys(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	err := hecdsa.SetKeyMetadata(pri.ID(), &hecdsa.KeyMetadata{Label: "node-1", Usage: hecdsa.UsageTLS}, "password", heimdall.TestKeyDir)
+	assert.NoError(t, err)
+
+	// when
+	tlsKeys, err := keystore.FindKeys(heimdall.TestKeyDir, hecdsa.UsageTLS)
+	assert.NoError(t, err)
+	blockSigningKeys, err := keystore.FindKeys(heimdall.TestKeyDir, hecdsa.UsageBlockSigning)
+	assert.NoError(t, err)
+
+	// then
+	assert.Len(t, tlsKeys, 1)
+	assert.Equal(t, pri.ID(), tlsKeys[0].KeyID)
+	assert.Equal(t, "node-1", tlsKeys[0].Metadata.Label)
+	assert.True(t, tlsKeys[0].Metadata.CreatedAt.Equal(tlsKeys[0].CreatedAt))
+	assert.Empty(t, blockSigningKeys)
+}
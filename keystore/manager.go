@@ -0,0 +1,492 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides key manager which holds several key pairs of a node by name, such as TLS key and block signing key.
+
+package keystore
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/fileperm"
+	"github.com/DE-labtory/heimdall/hbls"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hpq"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/hsm2"
+	"github.com/DE-labtory/heimdall/hx25519"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/iLogger"
+)
+
+var ErrInvalidKeyName = errors.New("invalid key name - name should consist of letters, digits, '.', '_' and '-' and not start with '.'")
+var ErrKeyNameExists = errors.New("key name exists - key pair of the name is managed already")
+var ErrKeyNameNotFound = errors.New("key name not found - no key pair is managed by the name")
+
+// names of private and public key directories of a managed key pair, and suffixes of directories
+// used while key pair is replaced
+const (
+	managedPriKeyDirName = "private_key"
+	managedPubKeyDirName = "public_key"
+	stagingSuffix        = ".new"
+	retiredSuffix        = ".old"
+)
+
+// KeyManager manages several key pairs by name. Each key pair is kept in its own directory under root directory,
+// which holds private key directory of one private key and public key directory, so key pairs of different roles
+// are generated, loaded and removed independently.
+type KeyManager struct {
+	mutex    sync.RWMutex
+	rootPath string
+	encOpt   *encryption.Opts
+	kdfOpt   *kdf.Opts
+}
+
+// NewKeyManager makes key manager of key pairs in rootPath, whose private keys are encrypted with encOpt and kdfOpt.
+func NewKeyManager(rootPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) *KeyManager {
+	return &KeyManager{
+		rootPath: rootPath,
+		encOpt:   encOpt,
+		kdfOpt:   kdfOpt,
+	}
+}
+
+// GenerateKey generates key pair of keyGenOpt, and stores it under name with password. If key pair of name exists,
+// it is replaced transactionally: the new key pair is stored first and switched by rename, and then the previous
+// key pair is wiped, so a failure in the middle leaves the previous key pair.
+func (manager *KeyManager) GenerateKey(name string, keyGenOpt heimdall.KeyGenOpts, pwd string) (heimdall.PriKey, error) {
+	return manager.GenerateKeyWithContext(context.Background(), name, keyGenOpt, pwd)
+}
+
+// GenerateKeyWithContext is GenerateKey bounded by context. If context is done before the new key pair is switched
+// in, which is after its private key is encrypted, the previous key pair of name is kept.
+func (manager *KeyManager) GenerateKeyWithContext(ctx context.Context, name string, keyGenOpt heimdall.KeyGenOpts, pwd string) (heimdall.PriKey, error) {
+	if err := validateKeyName(name); err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	pri, err := generateKey(keyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if err := manager.storeKeyPair(ctx, name, pri, pwd, true); err != nil {
+		pri.Clear()
+		return nil, err
+	}
+
+	return pri, nil
+}
+
+// StoreKey stores key pair of pri under name with password. Name of a managed key pair can not be reused
+// until the key pair is removed.
+func (manager *KeyManager) StoreKey(name string, pri heimdall.PriKey, pwd string) error {
+	return manager.StoreKeyWithContext(context.Background(), name, pri, pwd)
+}
+
+// StoreKeyWithContext is StoreKey bounded by context. If context is done before the key pair is switched in,
+// nothing is stored.
+func (manager *KeyManager) StoreKeyWithContext(ctx context.Context, name string, pri heimdall.PriKey, pwd string) error {
+	if err := validateKeyName(name); err != nil {
+		return err
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	return manager.storeKeyPair(ctx, name, pri, pwd, false)
+}
+
+// storeKeyPair stores key pair of pri in staging directory, and switches it to key pair directory of name by rename.
+// Previous key pair of name is moved to retired directory during the switch, and wiped after it.
+func (manager *KeyManager) storeKeyPair(ctx context.Context, name string, pri heimdall.PriKey, pwd string, replace bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := manager.recoverKeyPair(name); err != nil {
+		return err
+	}
+
+	keyPairPath := manager.namePath(name)
+	exists, err := pathExists(keyPairPath)
+	if err != nil {
+		return err
+	} else if exists && !replace {
+		return ErrKeyNameExists
+	}
+
+	if err := fileperm.MkdirAll(manager.rootPath); err != nil {
+		return err
+	}
+
+	stagingPath := manager.stagingPath(name)
+	if err := manager.storeKeyPairIn(stagingPath, pri, pwd); err != nil {
+		removeKeyPair(stagingPath)
+		return err
+	}
+
+	// key derivation of the private key may take long, so context is checked again before the switch
+	if err := ctx.Err(); err != nil {
+		removeKeyPair(stagingPath)
+		return err
+	}
+
+	if !exists {
+		if err := os.Rename(stagingPath, keyPairPath); err != nil {
+			removeKeyPair(stagingPath)
+			return err
+		}
+
+		return nil
+	}
+
+	retiredPath := manager.retiredPath(name)
+	if err := os.Rename(keyPairPath, retiredPath); err != nil {
+		removeKeyPair(stagingPath)
+		return err
+	}
+
+	if err := os.Rename(stagingPath, keyPairPath); err != nil {
+		if restoreErr := os.Rename(retiredPath, keyPairPath); restoreErr != nil {
+			return restoreErr
+		}
+		removeKeyPair(stagingPath)
+		return err
+	}
+
+	// new key pair is in place, so failure to clean up the previous one does not fail storing
+	if err := removeKeyPair(retiredPath); err != nil {
+		iLogger.Errorf(nil, "[Heimdall] failed to remove previous key pair of %s - %s", name, err)
+	}
+
+	return nil
+}
+
+// storeKeyPairIn stores private and public keys of pri in key pair directory.
+func (manager *KeyManager) storeKeyPairIn(keyPairPath string, pri heimdall.PriKey, pwd string) error {
+	if err := hecdsa.StorePriKey(pri, pwd, filepath.Join(keyPairPath, managedPriKeyDirName), manager.encOpt, manager.kdfOpt); err != nil {
+		return err
+	}
+
+	return hecdsa.StorePubKey(pri.PublicKey(), filepath.Join(keyPairPath, managedPubKeyDirName))
+}
+
+// recoverKeyPair cleans up switch of key pair of name interrupted by a crash. If the key pair directory is missing,
+// the previous key pair in retired directory is restored. Otherwise retired and staging directories are wiped.
+func (manager *KeyManager) recoverKeyPair(name string) error {
+	keyPairPath := manager.namePath(name)
+	retiredPath := manager.retiredPath(name)
+
+	retired, err := pathExists(retiredPath)
+	if err != nil {
+		return err
+	}
+
+	if retired {
+		exists, err := pathExists(keyPairPath)
+		if err != nil {
+			return err
+		}
+
+		if exists {
+			if err := removeKeyPair(retiredPath); err != nil {
+				return err
+			}
+		} else if err := os.Rename(retiredPath, keyPairPath); err != nil {
+			return err
+		}
+	}
+
+	return removeKeyPair(manager.stagingPath(name))
+}
+
+// GetKey loads private key of name with password.
+func (manager *KeyManager) GetKey(name, pwd string) (heimdall.PriKey, error) {
+	return manager.GetKeyWithContext(context.Background(), name, pwd)
+}
+
+// GetKeyWithContext is GetKey bounded by context. Key loaded after context is done is cleared and not returned.
+func (manager *KeyManager) GetKeyWithContext(ctx context.Context, name, pwd string) (heimdall.PriKey, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	if err := manager.checkKeyName(name); err != nil {
+		return nil, err
+	}
+
+	pri, err := hecdsa.LoadPriKey(filepath.Join(manager.keyPairPath(name), managedPriKeyDirName), pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ctx.Err(); err != nil {
+		pri.Clear()
+		return nil, err
+	}
+
+	return pri, nil
+}
+
+// GetPubKey loads public key of name, without password.
+func (manager *KeyManager) GetPubKey(name string) (heimdall.PubKey, error) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	if err := manager.checkKeyName(name); err != nil {
+		return nil, err
+	}
+
+	keyId, err := manager.keyID(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return hecdsa.LoadPubKey(keyId, filepath.Join(manager.keyPairPath(name), managedPubKeyDirName))
+}
+
+// KeyID returns key ID of key pair of name.
+func (manager *KeyManager) KeyID(name string) (heimdall.KeyID, error) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	if err := manager.checkKeyName(name); err != nil {
+		return "", err
+	}
+
+	return manager.keyID(name)
+}
+
+// NameOf returns name of key pair of key ID. Legacy key ID of the same SKI is also matched.
+func (manager *KeyManager) NameOf(keyId heimdall.KeyID) (string, error) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	names, err := manager.names()
+	if err != nil {
+		return "", err
+	}
+
+	for _, name := range names {
+		managedKeyId, err := manager.keyID(name)
+		if err != nil {
+			continue
+		} else if managedKeyId == keyId {
+			return name, nil
+		}
+
+		pub, err := hecdsa.LoadPubKey(managedKeyId, filepath.Join(manager.keyPairPath(name), managedPubKeyDirName))
+		if err == nil && heimdall.MatchKeyID(keyId, pub) {
+			return name, nil
+		}
+	}
+
+	return "", heimdall.ErrKeyNotFound
+}
+
+// Names returns names of managed key pairs in order.
+func (manager *KeyManager) Names() ([]string, error) {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	return manager.names()
+}
+
+func (manager *KeyManager) names() ([]string, error) {
+	files, err := ioutil.ReadDir(manager.rootPath)
+	if os.IsNotExist(err) {
+		return []string{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool)
+	for _, file := range files {
+		name := file.Name()
+		// key pair whose switch was interrupted is in retired directory
+		if strings.HasPrefix(name, ".") && strings.HasSuffix(name, retiredSuffix) {
+			name = strings.TrimSuffix(strings.TrimPrefix(name, "."), retiredSuffix)
+		}
+
+		if file.IsDir() && validateKeyName(name) == nil {
+			found[name] = true
+		}
+	}
+
+	names := make([]string, 0, len(found))
+	for name := range found {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// RemoveKey wipes private key of name and removes its key pair, so that the name can be used again.
+func (manager *KeyManager) RemoveKey(name string) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if err := manager.checkKeyName(name); err != nil {
+		return err
+	}
+
+	if err := manager.recoverKeyPair(name); err != nil {
+		return err
+	}
+
+	return removeKeyPair(manager.namePath(name))
+}
+
+// keyPairPath returns directory of key pair of name, which is the retired directory if switch of the key pair
+// was interrupted before the new key pair was in place.
+func (manager *KeyManager) keyPairPath(name string) string {
+	keyPairPath := manager.namePath(name)
+	if exists, _ := pathExists(keyPairPath); !exists {
+		if retired, _ := pathExists(manager.retiredPath(name)); retired {
+			return manager.retiredPath(name)
+		}
+	}
+
+	return keyPairPath
+}
+
+func (manager *KeyManager) namePath(name string) string {
+	return filepath.Join(manager.rootPath, name)
+}
+
+// stagingPath and retiredPath are hidden, since key names do not start with '.'.
+func (manager *KeyManager) stagingPath(name string) string {
+	return filepath.Join(manager.rootPath, "."+name+stagingSuffix)
+}
+
+func (manager *KeyManager) retiredPath(name string) string {
+	return filepath.Join(manager.rootPath, "."+name+retiredSuffix)
+}
+
+// removeKeyPair wipes private key of key pair directory and removes the directory. Missing directory is not an error.
+func removeKeyPair(keyPairPath string) error {
+	priKeyDirPath := filepath.Join(keyPairPath, managedPriKeyDirName)
+	if exists, err := pathExists(priKeyDirPath); err != nil {
+		return err
+	} else if exists {
+		if err := Wipe(priKeyDirPath); err != nil {
+			return err
+		}
+	}
+
+	return os.RemoveAll(keyPairPath)
+}
+
+func pathExists(path string) (bool, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// checkKeyName checks if name is valid and key pair of the name exists.
+func (manager *KeyManager) checkKeyName(name string) error {
+	if err := validateKeyName(name); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(manager.keyPairPath(name)); os.IsNotExist(err) {
+		return ErrKeyNameNotFound
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// keyID reads key ID of name from name of its public key file.
+func (manager *KeyManager) keyID(name string) (heimdall.KeyID, error) {
+	files, err := ioutil.ReadDir(filepath.Join(manager.keyPairPath(name), managedPubKeyDirName))
+	if err != nil {
+		return "", err
+	}
+
+	for _, file := range files {
+		if !file.IsDir() && heimdall.ValidateKeyID(file.Name()) == nil {
+			return file.Name(), nil
+		}
+	}
+
+	return "", heimdall.ErrKeyNotFound
+}
+
+// validateKeyName checks if name is usable as a directory name on every platform.
+func validateKeyName(name string) error {
+	if name == "" || name[0] == '.' {
+		return ErrInvalidKeyName
+	}
+
+	for _, c := range name {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return ErrInvalidKeyName
+		}
+	}
+
+	return nil
+}
+
+// generateKey generates private key by the package of algorithm of keyGenOpt.
+func generateKey(keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	switch keyGenOpt.Algorithm() {
+	case heimdall.ECDSA:
+		return hecdsa.GenerateKey(keyGenOpt)
+	case heimdall.RSA:
+		return hrsa.GenerateKey(keyGenOpt)
+	case heimdall.ED25519:
+		return hed25519.GenerateKey(keyGenOpt)
+	case heimdall.BLS12381:
+		return hbls.GenerateKey(keyGenOpt)
+	case heimdall.DILITHIUM:
+		return hpq.GenerateKey(keyGenOpt)
+	case heimdall.SM2:
+		return hsm2.GenerateKey(keyGenOpt)
+	case heimdall.X25519:
+		return hx25519.GenerateKey(keyGenOpt)
+	default:
+		return nil, heimdall.ErrUnknownKeyType
+	}
+}
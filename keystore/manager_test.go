@@ -0,0 +1,242 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpKeyManager(t *testing.T) *keystore.KeyManager {
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	return keystore.NewKeyManager(heimdall.TestKeyDir, encOpt, kdfOpt)
+}
+
+func TestKeyManager_GenerateKey(t *testing.T) {
+	// given
+	manager := setUpKeyManager(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	p256KeyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	ed25519KeyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+
+	// when
+	tlsPri, err := manager.GenerateKey("tls", p256KeyGenOpt, "password")
+	assert.NoError(t, err)
+	blockPri, err := manager.GenerateKey("block-signing", ed25519KeyGenOpt, "password")
+	assert.NoError(t, err)
+
+	// then
+	names, err := manager.Names()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"block-signing", "tls"}, names)
+
+	loadedTLSPri, err := manager.GetKey("tls", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, tlsPri.ID(), loadedTLSPri.ID())
+
+	blockPub, err := manager.GetPubKey("block-signing")
+	assert.NoError(t, err)
+	assert.Equal(t, blockPri.ID(), blockPub.ID())
+
+	keyId, err := manager.KeyID("tls")
+	assert.NoError(t, err)
+	assert.Equal(t, tlsPri.ID(), keyId)
+
+	name, err := manager.NameOf(blockPri.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, "block-signing", name)
+
+	err = manager.StoreKey("tls", setUpPriKey(t), "password")
+	assert.Equal(t, keystore.ErrKeyNameExists, err)
+}
+
+func TestKeyManager_GenerateKey_Replace(t *testing.T) {
+	// given
+	manager := setUpKeyManager(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	oldPri, err := manager.GenerateKey("tls", keyGenOpt, "password")
+	assert.NoError(t, err)
+
+	// when
+	newPri, err := manager.GenerateKey("tls", keyGenOpt, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.NotEqual(t, oldPri.ID(), newPri.ID())
+
+	loadedPri, err := manager.GetKey("tls", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, newPri.ID(), loadedPri.ID())
+
+	files, err := ioutil.ReadDir(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestKeyManager_GenerateKey_InterruptedSwitch(t *testing.T) {
+	// given
+	manager := setUpKeyManager(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	oldPri, err := manager.GenerateKey("tls", keyGenOpt, "password")
+	assert.NoError(t, err)
+
+	// crash after the previous key pair is retired and before the new key pair is in place
+	assert.NoError(t, os.Rename(filepath.Join(heimdall.TestKeyDir, "tls"), filepath.Join(heimdall.TestKeyDir, ".tls.old")))
+	assert.NoError(t, os.MkdirAll(filepath.Join(heimdall.TestKeyDir, ".tls.new", "private_key"), 0700))
+
+	// when
+	loadedPri, err := manager.GetKey("tls", "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, oldPri.ID(), loadedPri.ID())
+
+	names, err := manager.Names()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tls"}, names)
+
+	newPri, err := manager.GenerateKey("tls", keyGenOpt, "password")
+	assert.NoError(t, err)
+
+	loadedPri, err = manager.GetKey("tls", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, newPri.ID(), loadedPri.ID())
+
+	files, err := ioutil.ReadDir(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
+
+func TestKeyManager_GenerateKey_RetiredCleanupFailure(t *testing.T) {
+	// given
+	manager := setUpKeyManager(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	_, err = manager.GenerateKey("tls", keyGenOpt, "password")
+	assert.NoError(t, err)
+
+	// dangling link in private key directory fails wiping the key pair once it is retired
+	assert.NoError(t, os.Symlink(filepath.Join(heimdall.TestKeyDir, "missing"), filepath.Join(heimdall.TestKeyDir, "tls", "private_key", "link")))
+
+	// when
+	newPri, err := manager.GenerateKey("tls", keyGenOpt, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.NotNil(t, newPri)
+
+	loadedPri, err := manager.GetKey("tls", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, newPri.ID(), loadedPri.ID())
+}
+
+func TestKeyManager_WithContext(t *testing.T) {
+	// given
+	manager := setUpKeyManager(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	oldPri, err := manager.GenerateKeyWithContext(context.Background(), "tls", keyGenOpt, "password")
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	// when
+	_, generateErr := manager.GenerateKeyWithContext(ctx, "tls", keyGenOpt, "password")
+	storeErr := manager.StoreKeyWithContext(ctx, "block-signing", setUpPriKey(t), "password")
+	_, getErr := manager.GetKeyWithContext(ctx, "tls", "password")
+
+	// then
+	assert.Equal(t, context.Canceled, generateErr)
+	assert.Equal(t, context.Canceled, storeErr)
+	assert.Equal(t, context.Canceled, getErr)
+
+	loadedPri, err := manager.GetKeyWithContext(context.Background(), "tls", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, oldPri.ID(), loadedPri.ID())
+
+	names, err := manager.Names()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tls"}, names)
+}
+
+func TestKeyManager_RemoveKey(t *testing.T) {
+	// given
+	manager := setUpKeyManager(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	tlsPri := setUpPriKey(t)
+	assert.NoError(t, manager.StoreKey("tls", tlsPri, "password"))
+	assert.NoError(t, manager.StoreKey("block-signing", setUpPriKey(t), "password"))
+
+	// when
+	err := manager.RemoveKey("block-signing")
+
+	// then
+	assert.NoError(t, err)
+
+	_, err = manager.GetKey("block-signing", "password")
+	assert.Equal(t, keystore.ErrKeyNameNotFound, err)
+
+	loadedTLSPri, err := manager.GetKey("tls", "password")
+	assert.NoError(t, err)
+	assert.Equal(t, tlsPri.ID(), loadedTLSPri.ID())
+
+	names, err := manager.Names()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tls"}, names)
+}
+
+func TestKeyManager_InvalidName(t *testing.T) {
+	// given
+	manager := setUpKeyManager(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	for _, name := range []string{"", ".", "..", "../tls", "tls/key", ".hidden"} {
+		// when
+		err := manager.StoreKey(name, setUpPriKey(t), "password")
+
+		// then
+		assert.Equal(t, keystore.ErrInvalidKeyName, err, name)
+	}
+
+	_, err := manager.NameOf("ECP256x1111")
+	assert.Error(t, err)
+}
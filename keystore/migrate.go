@@ -0,0 +1,296 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides migration of legacy key directories, which hold PEM or DER key files, into encrypted key files.
+
+package keystore
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+)
+
+var ErrNotLegacyKey = errors.New("not legacy key file - file should be PEM or DER encoded ECDSA key")
+var ErrLegacyKeyIDMismatch = errors.New("key ID mismatch - legacy key file name does not correspond to the key")
+
+// Migration is a result of migrating a file in legacy key directory.
+type Migration struct {
+	Name    string
+	KeyID   heimdall.KeyID
+	Private bool
+	Err     error
+}
+
+// MigrateLegacyDir converts every key file in legacyDirPath into keystore format, preserving SKIs. Keys are stored
+// under algorithm prefixed key IDs of the same SKI, and legacy key IDs still find them. (see heimdall.KeyIDFileNamesIn)
+// Private keys are encrypted with pwd and stored in a directory named by key ID under priKeyRootPath,
+// since a private key directory holds only one key. Their public keys and legacy public keys are stored in pubKeyDirPath.
+// Legacy files are left untouched, so they should be removed by the operator after the migration is verified.
+func MigrateLegacyDir(legacyDirPath, priKeyRootPath, pubKeyDirPath, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) ([]*Migration, error) {
+	files, err := ioutil.ReadDir(legacyDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]*Migration, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		migration := &Migration{Name: file.Name()}
+		migration.KeyID, migration.Private, migration.Err = migrateLegacyFile(
+			filepath.Join(legacyDirPath, file.Name()), priKeyRootPath, pubKeyDirPath, pwd, encOpt, kdfOpt)
+		migrations = append(migrations, migration)
+	}
+
+	return migrations, nil
+}
+
+func migrateLegacyFile(path, priKeyRootPath, pubKeyDirPath, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) (heimdall.KeyID, bool, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	key, err := parseLegacyKey(data)
+	if err != nil {
+		return "", false, err
+	}
+
+	if !legacyNameMatches(filepath.Base(path), key) {
+		return key.ID(), key.IsPrivate(), ErrLegacyKeyIDMismatch
+	}
+
+	var pub heimdall.PubKey
+	switch k := key.(type) {
+	case heimdall.PriKey:
+		defer k.Clear()
+		pub = k.PublicKey()
+
+		if err := hecdsa.StorePriKey(k, pwd, filepath.Join(priKeyRootPath, k.ID()), encOpt, kdfOpt); err != nil {
+			return key.ID(), true, err
+		}
+	case heimdall.PubKey:
+		pub = k
+	}
+
+	// public key of private key and legacy public key file of the same key ID have the same content
+	if err := hecdsa.StorePubKey(pub, pubKeyDirPath); err != nil && err != hecdsa.ErrKeyAlreadyExists {
+		return key.ID(), key.IsPrivate(), err
+	}
+	event.Publish(event.KeyImported, key.ID(), path)
+
+	return key.ID(), key.IsPrivate(), nil
+}
+
+// Migrate upgrades key files of older layouts in key directory in place, so that upgrades do not strand old keys.
+// Encrypted key files of older version than hecdsa.KeyFileVersion are decrypted with pwd and re-encrypted with encOpt
+// and kdfOpt, keeping their metadata. Legacy PEM or DER key files are converted to key files named by key ID, where
+// private keys are encrypted with pwd, and the legacy files are removed. Key files of current layout are left untouched.
+// Directory holding several legacy private keys should be split by MigrateLegacyDir instead, since private key directory
+// holds only one key.
+func Migrate(keyDirPath, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) ([]*Migration, error) {
+	files, err := ioutil.ReadDir(keyDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := hecdsa.NewFileStorage(keyDirPath)
+	migrations := make([]*Migration, 0)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(keyDirPath, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var migration *Migration
+		if json.Valid(data) {
+			migration = migrateKeyFile(storage, keyDirPath, file, data, pwd, encOpt, kdfOpt)
+		} else if block, _ := pem.Decode(data); block != nil || heimdall.ValidateKeyID(file.Name()) != nil {
+			migration = migrateLegacyKeyFile(storage, keyDirPath, file, data, pwd, encOpt, kdfOpt)
+		}
+
+		if migration != nil {
+			migrations = append(migrations, migration)
+		}
+	}
+
+	return migrations, nil
+}
+
+// migrateKeyFile re-encrypts encrypted key file of older version, and returns nil if the key file needs no migration.
+func migrateKeyFile(storage heimdall.Storage, keyDirPath string, file os.FileInfo, data []byte, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) *Migration {
+	var keyFile hecdsa.KeyFile
+	if err := json.Unmarshal(data, &keyFile); err != nil || keyFile.EncryptedKey == "" || keyFile.Version >= hecdsa.KeyFileVersion {
+		return nil
+	}
+
+	migration := &Migration{Name: file.Name(), KeyID: file.Name(), Private: true}
+
+	pri, err := hecdsa.DecryptKeyFile(data, pwd)
+	if err != nil {
+		migration.Err = err
+		return migration
+	}
+	defer pri.Clear()
+	migration.KeyID = pri.ID()
+
+	// key files stored before metadata have modification time of the file as creation time
+	metadata := keyFile.Metadata
+	if metadata == nil {
+		metadata = &hecdsa.KeyMetadata{CreatedAt: file.ModTime().UTC()}
+	}
+
+	jsonKeyFile, err := hecdsa.EncryptKeyFile(pri, pwd, encOpt, kdfOpt, metadata)
+	if err != nil {
+		migration.Err = err
+		return migration
+	}
+
+	if migration.Err = storage.Put(file.Name(), jsonKeyFile); migration.Err == nil {
+		event.Publish(event.KeyUpgraded, pri.ID(), filepath.Join(keyDirPath, file.Name()))
+	}
+
+	return migration
+}
+
+// migrateLegacyKeyFile converts legacy PEM or DER key file to key file named by key ID, and removes the legacy file.
+func migrateLegacyKeyFile(storage heimdall.Storage, keyDirPath string, file os.FileInfo, data []byte, pwd string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) *Migration {
+	migration := &Migration{Name: file.Name()}
+
+	key, err := parseLegacyKey(data)
+	if err != nil {
+		migration.Err = err
+		return migration
+	}
+	migration.KeyID, migration.Private = key.ID(), key.IsPrivate()
+
+	if !legacyNameMatches(file.Name(), key) {
+		migration.Err = ErrLegacyKeyIDMismatch
+		return migration
+	}
+
+	var keyBytes []byte
+	switch k := key.(type) {
+	case heimdall.PriKey:
+		defer k.Clear()
+		keyBytes, err = hecdsa.EncryptKeyFile(k, pwd, encOpt, kdfOpt, &hecdsa.KeyMetadata{CreatedAt: file.ModTime().UTC()})
+	case heimdall.PubKey:
+		keyBytes, err = k.ToByte()
+	}
+	if err != nil {
+		migration.Err = err
+		return migration
+	}
+
+	if migration.Err = storage.Put(key.ID(), keyBytes); migration.Err != nil {
+		return migration
+	}
+
+	if file.Name() != key.ID() {
+		migration.Err = os.Remove(filepath.Join(keyDirPath, file.Name()))
+	}
+	event.Publish(event.KeyImported, key.ID(), keyDirPath)
+
+	return migration
+}
+
+// parseLegacyKey parses PEM(SEC 1, PKCS #8 or PKIX) or DER encoded ECDSA key.
+func parseLegacyKey(data []byte) (heimdall.Key, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		recoverer := &hecdsa.KeyRecoverer{}
+		if pri, err := recoverer.RecoverKeyFromByte(data, true); err == nil {
+			return pri, nil
+		}
+		if pub, err := recoverer.RecoverKeyFromByte(data, false); err == nil {
+			return pub, nil
+		}
+		return nil, ErrNotLegacyKey
+	}
+
+	switch block.Type {
+	case "EC PRIVATE KEY":
+		pri, err := x509.ParseECPrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		return hecdsa.NewPriKey(pri), nil
+
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		pri, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, ErrNotLegacyKey
+		}
+		return hecdsa.NewPriKey(pri), nil
+
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, ErrNotLegacyKey
+		}
+		return hecdsa.NewPubKey(pub), nil
+
+	default:
+		return nil, ErrNotLegacyKey
+	}
+}
+
+// legacyNameMatches checks file name of legacy key, if it is named by key ID or hex encoded SKI (ex. <SKI>_pri.pem).
+func legacyNameMatches(name string, key heimdall.Key) bool {
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	for _, suffix := range []string{"_pri", "_pub", "_sk", "_pk"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+
+	if heimdall.ValidateKeyID(name) == nil {
+		return heimdall.MatchKeyID(name, key)
+	}
+
+	if ski, err := hex.DecodeString(name); err == nil && len(ski) == len(key.SKI()) {
+		return strings.EqualFold(name, hex.EncodeToString(key.SKI()))
+	}
+
+	return true
+}
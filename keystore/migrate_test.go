@@ -0,0 +1,187 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateLegacyDir(t *testing.T) {
+	// given
+	assert.NoError(t, os.MkdirAll(heimdall.TestKeyDir, 0700))
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	sec1Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	sec1DER, err := x509.MarshalECPrivateKey(sec1Key)
+	assert.NoError(t, err)
+	sec1Pri := hecdsa.NewPriKey(sec1Key)
+	sec1Name := hex.EncodeToString(sec1Pri.SKI()) + "_pri.pem"
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, sec1Name),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1DER}), 0600))
+
+	pkcs8Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(pkcs8Key)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, "node.key"),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DER}), 0600))
+
+	pubKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	pub := hecdsa.NewPubKey(&pubKey.PublicKey)
+	pubDER, err := pub.ToByte()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, pub.ID()), pubDER, 0600))
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	otherDER, err := x509.MarshalECPrivateKey(otherKey)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, sec1Pri.ID()+"_pri.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: otherDER}), 0600))
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, "README"), []byte("legacy keys"), 0600))
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	// when
+	migrations, err := keystore.MigrateLegacyDir(heimdall.TestKeyDir, heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, "password", encOpt, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 5)
+
+	results := make(map[string]*keystore.Migration)
+	for _, migration := range migrations {
+		results[migration.Name] = migration
+	}
+
+	assert.NoError(t, results[sec1Name].Err)
+	assert.Equal(t, sec1Pri.ID(), results[sec1Name].KeyID)
+	assert.True(t, results[sec1Name].Private)
+	loaded, err := hecdsa.LoadPriKey(filepath.Join(heimdall.TestPriKeyDir, sec1Pri.ID()), "password")
+	assert.NoError(t, err)
+	assert.Equal(t, sec1Pri, loaded)
+
+	pkcs8Pri := hecdsa.NewPriKey(pkcs8Key)
+	assert.NoError(t, results["node.key"].Err)
+	assert.Equal(t, pkcs8Pri.ID(), results["node.key"].KeyID)
+	loadedPub, err := hecdsa.LoadPubKey(pkcs8Pri.ID(), heimdall.TestPubKeyDir)
+	assert.NoError(t, err)
+	assert.Equal(t, pkcs8Pri.PublicKey(), loadedPub)
+
+	assert.NoError(t, results[pub.ID()].Err)
+	assert.False(t, results[pub.ID()].Private)
+	loadedPub, err = hecdsa.LoadPubKey(pub.ID(), heimdall.TestPubKeyDir)
+	assert.NoError(t, err)
+	assert.Equal(t, pub, loadedPub)
+
+	assert.Equal(t, keystore.ErrLegacyKeyIDMismatch, results[sec1Pri.ID()+"_pri.pem"].Err)
+	assert.Equal(t, keystore.ErrNotLegacyKey, results["README"].Err)
+}
+
+func TestMigrate(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	keyFilePath := filepath.Join(heimdall.TestKeyDir, pri.ID())
+	jsonKeyFile, err := ioutil.ReadFile(keyFilePath)
+	assert.NoError(t, err)
+	var keyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &keyFile))
+	keyFile.Version, keyFile.Metadata, keyFile.Hints.MAC = 0, nil, nil
+	oldJsonKeyFile, err := json.Marshal(keyFile)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(keyFilePath, oldJsonKeyFile, 0600))
+
+	legacyKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	legacyPub := hecdsa.NewPubKey(&legacyKey.PublicKey)
+	legacyDER, err := x509.MarshalPKIXPublicKey(&legacyKey.PublicKey)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, "node_pub.pem"),
+		pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: legacyDER}), 0600))
+
+	currentPub := setUpPriKey(t).PublicKey()
+	currentDER, err := currentPub.ToByte()
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, currentPub.ID()), currentDER, 0600))
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	// when
+	migrations, err := keystore.Migrate(heimdall.TestKeyDir, "password", encOpt, kdfOpt)
+	assert.NoError(t, err)
+	againMigrations, err := keystore.Migrate(heimdall.TestKeyDir, "password", encOpt, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 2)
+	for _, migration := range migrations {
+		assert.NoError(t, migration.Err)
+	}
+	assert.Empty(t, againMigrations)
+
+	jsonKeyFile, err = ioutil.ReadFile(keyFilePath)
+	assert.NoError(t, err)
+	var migratedKeyFile hecdsa.KeyFile
+	assert.NoError(t, json.Unmarshal(jsonKeyFile, &migratedKeyFile))
+	assert.Equal(t, hecdsa.KeyFileVersion, migratedKeyFile.Version)
+	assert.NotEmpty(t, migratedKeyFile.Hints.MAC)
+	assert.False(t, migratedKeyFile.Metadata.CreatedAt.IsZero())
+	migratedPri, err := hecdsa.DecryptKeyFile(jsonKeyFile, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), migratedPri.ID())
+
+	_, err = os.Stat(filepath.Join(heimdall.TestKeyDir, "node_pub.pem"))
+	assert.True(t, os.IsNotExist(err))
+	loadedPub, err := hecdsa.LoadPubKey(legacyPub.ID(), heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.Equal(t, legacyPub.ID(), loadedPub.ID())
+
+	data, err := ioutil.ReadFile(filepath.Join(heimdall.TestKeyDir, currentPub.ID()))
+	assert.NoError(t, err)
+	assert.Equal(t, currentDER, data)
+}
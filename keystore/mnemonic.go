@@ -0,0 +1,176 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides paper backup of private keys as BIP39 mnemonics, which operators can write down and restore by hand.
+
+package keystore
+
+import (
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"math/big"
+	"strings"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hx25519"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/tyler-smith/go-bip39"
+)
+
+var ErrInvalidMnemonic = errors.New("invalid mnemonic - mnemonic should be 24 words of BIP39 English word list with valid checksum")
+var ErrMnemonicKeyNotSupported = errors.New("key not supported by mnemonic - key should be ECDSA key on 256 bits curve, Ed25519 or X25519 key")
+
+// mnemonicSecretSize is size of private key secret encoded in mnemonic, which is the largest entropy of BIP39 (24 words).
+const mnemonicSecretSize = 32
+
+// mnemonicKDFOpt derives pad of secret from passphrase. Parameters are fixed, since mnemonic has no room for them.
+var mnemonicKDFOpt = &kdf.Opts{
+	KdfName:   kdf.ARGON2ID,
+	KdfParams: map[string]string{"memory": "65536", "iteration": "3", "parallelism": "4"},
+}
+
+// ExportMnemonic loads private key in keyDirPath with pwd, and encodes it into BIP39 mnemonic. (see EncodeMnemonic)
+func ExportMnemonic(keyDirPath, pwd, passphrase string) (string, error) {
+	pri, err := hecdsa.LoadPriKey(keyDirPath, pwd)
+	if err != nil {
+		return "", err
+	}
+	defer pri.Clear()
+
+	return EncodeMnemonic(pri, passphrase)
+}
+
+// ImportMnemonic decodes BIP39 mnemonic into private key of keyGenOpt, and stores the key in keyDirPath as key file encrypted with pwd.
+// Key ID of the restored key should be compared with the lost one, since wrong passphrase restores another valid key.
+func ImportMnemonic(mnemonic, passphrase string, keyGenOpt heimdall.KeyGenOpts, pwd, keyDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) (heimdall.KeyID, error) {
+	pri, err := DecodeMnemonic(mnemonic, passphrase, keyGenOpt)
+	if err != nil {
+		return "", err
+	}
+	defer pri.Clear()
+
+	if err := hecdsa.StorePriKey(pri, pwd, keyDirPath, encOpt, kdfOpt); err != nil {
+		return "", err
+	}
+
+	return pri.ID(), nil
+}
+
+// EncodeMnemonic encodes 32 bytes secret of private key (scalar of ECDSA key or seed of Ed25519 key) into 24 words of BIP39.
+// If passphrase is not empty, the secret is masked by key derived from passphrase by Argon2id, so the words alone do not reveal the key.
+// Algorithm of the key is not encoded, so it should be kept with the words.
+func EncodeMnemonic(pri heimdall.PriKey, passphrase string) (string, error) {
+	secret, err := mnemonicSecret(pri)
+	if err != nil {
+		return "", err
+	}
+
+	if err := maskSecret(secret, passphrase, pri.KeyGenOpt()); err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(secret)
+}
+
+// DecodeMnemonic decodes BIP39 mnemonic encoded by EncodeMnemonic into private key of keyGenOpt.
+func DecodeMnemonic(mnemonic, passphrase string, keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	secret, err := bip39.EntropyFromMnemonic(strings.Join(strings.Fields(strings.ToLower(mnemonic)), " "))
+	if err != nil || len(secret) != mnemonicSecretSize {
+		return nil, ErrInvalidMnemonic
+	}
+
+	if err := maskSecret(secret, passphrase, keyGenOpt); err != nil {
+		return nil, err
+	}
+
+	switch keyGenOpt.Algorithm() {
+	case heimdall.ECDSA:
+		if keyGenOpt.KeySize() != mnemonicSecretSize*8 {
+			return nil, ErrMnemonicKeyNotSupported
+		}
+		return newECPriKey(keyGenOpt.ToString(), secret)
+	case heimdall.ED25519:
+		return hed25519.NewPriKey(ed25519.NewKeyFromSeed(secret)), nil
+	case heimdall.X25519:
+		pri, err := ecdh.X25519().NewPrivateKey(secret)
+		if err != nil {
+			return nil, err
+		}
+		return hx25519.NewPriKey(pri), nil
+	}
+
+	return nil, ErrMnemonicKeyNotSupported
+}
+
+// mnemonicSecret returns 32 bytes secret of private key.
+func mnemonicSecret(pri heimdall.PriKey) ([]byte, error) {
+	priBytes, err := pri.ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch pri.KeyGenOpt().Algorithm() {
+	case heimdall.ECDSA:
+		if pri.KeyGenOpt().KeySize() != mnemonicSecretSize*8 {
+			return nil, ErrMnemonicKeyNotSupported
+		}
+		var privateKey ecPrivateKey
+		if _, err := asn1.Unmarshal(priBytes, &privateKey); err != nil {
+			return nil, err
+		}
+		return new(big.Int).SetBytes(privateKey.PrivateKey).FillBytes(make([]byte, mnemonicSecretSize)), nil
+	case heimdall.ED25519:
+		key, err := x509.ParsePKCS8PrivateKey(priBytes)
+		if err != nil {
+			return nil, err
+		}
+		return key.(ed25519.PrivateKey).Seed(), nil
+	case heimdall.X25519:
+		key, err := x509.ParsePKCS8PrivateKey(priBytes)
+		if err != nil {
+			return nil, err
+		}
+		return key.(*ecdh.PrivateKey).Bytes(), nil
+	}
+
+	return nil, ErrMnemonicKeyNotSupported
+}
+
+// maskSecret XORs secret with key derived from passphrase, salted by algorithm of the key. Empty passphrase leaves secret as it is.
+func maskSecret(secret []byte, passphrase string, keyGenOpt heimdall.KeyGenOpts) error {
+	if passphrase == "" {
+		return nil
+	}
+
+	salt := []byte("heimdall mnemonic " + keyGenOpt.ToString())
+	pad, err := kdf.DeriveKey([]byte(passphrase), salt, mnemonicSecretSize*8, mnemonicKDFOpt)
+	if err != nil {
+		return err
+	}
+
+	for i := range secret {
+		secret[i] ^= pad[i]
+	}
+
+	return nil
+}
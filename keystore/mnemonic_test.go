@@ -0,0 +1,142 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hx25519"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+// test vector of BIP39 for entropy of 0x80 repeated 32 times
+const bip39TestMnemonic = "letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless"
+
+func TestEncodeMnemonic_TestVector(t *testing.T) {
+	// given
+	pri := hed25519.NewPriKey(ed25519.NewKeyFromSeed(bytes.Repeat([]byte{0x80}, 32)))
+
+	// when
+	mnemonic, err := keystore.EncodeMnemonic(pri, "")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, bip39TestMnemonic, mnemonic)
+}
+
+func TestEncodeMnemonic(t *testing.T) {
+	// given
+	p256KeyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	secp256k1KeyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECSECP256K1)
+	assert.NoError(t, err)
+	ed25519KeyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	x25519KeyGenOpt, err := hx25519.NewKeyGenOpt(hx25519.X25519)
+	assert.NoError(t, err)
+
+	keys := make([]heimdall.PriKey, 0)
+	for _, generate := range []func() (heimdall.PriKey, error){
+		func() (heimdall.PriKey, error) { return hecdsa.GenerateKey(p256KeyGenOpt) },
+		func() (heimdall.PriKey, error) { return hecdsa.GenerateKey(secp256k1KeyGenOpt) },
+		func() (heimdall.PriKey, error) { return hed25519.GenerateKey(ed25519KeyGenOpt) },
+		func() (heimdall.PriKey, error) { return hx25519.GenerateKey(x25519KeyGenOpt) },
+	} {
+		pri, err := generate()
+		assert.NoError(t, err)
+		keys = append(keys, pri)
+	}
+
+	for _, pri := range keys {
+		// when
+		mnemonic, err := keystore.EncodeMnemonic(pri, "passphrase")
+
+		// then
+		assert.NoError(t, err)
+		decoded, err := keystore.DecodeMnemonic(mnemonic, "passphrase", pri.KeyGenOpt())
+		assert.NoError(t, err)
+		assert.Equal(t, pri.ID(), decoded.ID())
+
+		plainMnemonic, err := keystore.EncodeMnemonic(pri, "")
+		assert.NoError(t, err)
+		assert.NotEqual(t, plainMnemonic, mnemonic)
+	}
+}
+
+func TestEncodeMnemonic_NotSupported(t *testing.T) {
+	// given
+	p384Pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	_, err := keystore.EncodeMnemonic(p384Pri, "")
+
+	// then
+	assert.Equal(t, keystore.ErrMnemonicKeyNotSupported, err)
+}
+
+func TestDecodeMnemonic_Invalid(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	swapped := "advice letter cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic avoid letter advice cage absurd amount doctor acoustic bless"
+
+	// when
+	_, swappedErr := keystore.DecodeMnemonic(swapped, "", keyGenOpt)
+	_, shortErr := keystore.DecodeMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "", keyGenOpt)
+
+	// then
+	assert.Equal(t, keystore.ErrInvalidMnemonic, swappedErr)
+	assert.Equal(t, keystore.ErrInvalidMnemonic, shortErr)
+}
+
+func TestImportMnemonic(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	mnemonic, err := keystore.EncodeMnemonic(pri, "passphrase")
+	assert.NoError(t, err)
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	keyId, err := keystore.ImportMnemonic(mnemonic, "passphrase", keyGenOpt, "password", heimdall.TestKeyDir, encOpt, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), keyId)
+
+	exported, err := keystore.ExportMnemonic(heimdall.TestKeyDir, "password", "passphrase")
+	assert.NoError(t, err)
+	assert.Equal(t, mnemonic, exported)
+}
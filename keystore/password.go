@@ -0,0 +1,32 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides change of password which encrypts private key file in key directory.
+
+package keystore
+
+import (
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+// ChangePassword re-encrypts private key of key ID in key directory with newPwd, instead of deleting and storing the key again.
+// Key of new password is derived with fresh salt, keeping encryption and key derivation parameters of the key file.
+// The key file is replaced atomically, so it is left encrypted with oldPwd if change fails.
+func ChangePassword(keyId heimdall.KeyID, oldPwd, newPwd, keyDirPath string) error {
+	return hecdsa.ChangePriKeyPassword(keyId, oldPwd, newPwd, keyDirPath)
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChangePassword(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	wrongPwdErr := keystore.ChangePassword(pri.ID(), "wrong", "newPassword", heimdall.TestKeyDir)
+	err := keystore.ChangePassword(pri.ID(), "password", "newPassword", heimdall.TestKeyDir)
+
+	// then
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, wrongPwdErr)
+	assert.NoError(t, err)
+
+	_, oldPwdErr := hecdsa.LoadPriKey(heimdall.TestKeyDir, "password")
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, oldPwdErr)
+	loadedPri, err := hecdsa.LoadPriKey(heimdall.TestKeyDir, "newPassword")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+}
@@ -0,0 +1,165 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides conversion between identities (key files and certificate chains) and password protected PKCS #12 files,
+// which are loaded by browsers, Java applications and load balancers.
+
+package keystore
+
+import (
+	"bytes"
+	"crypto/x509"
+	"errors"
+	"os"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+var ErrPKCS12KeyNotSupported = errors.New("key not supported by PKCS #12 - key should be ECDSA key on NIST curve, RSA or Ed25519 key")
+var ErrPKCS12CertMismatch = errors.New("certificate mismatch - public key of certificate does not correspond to the private key")
+
+// ExportPKCS12 loads private key in keyDirPath with pwd and its certificate chain in certDirPath,
+// and bundles them into PKCS #12 file protected by p12Pwd.
+// The chain stored by cert.StoreChain is bundled, or the certificate stored by cert.Store if the key has no chain.
+func ExportPKCS12(keyDirPath, certDirPath, pwd, p12Pwd string) ([]byte, error) {
+	pri, err := hecdsa.LoadPriKey(keyDirPath, pwd)
+	if err != nil {
+		return nil, err
+	}
+	defer pri.Clear()
+
+	chain, err := cert.LoadChain(pri.ID(), certDirPath)
+	if os.IsNotExist(err) {
+		var leaf *x509.Certificate
+		leaf, err = cert.Load(pri.ID(), certDirPath)
+		chain = []*x509.Certificate{leaf}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return EncodePKCS12(pri, chain, p12Pwd)
+}
+
+// ImportPKCS12 decodes PKCS #12 file with p12Pwd, stores the private key in keyDirPath as key file encrypted with pwd,
+// and stores the certificate in certDirPath. If the file has CA certificates, the chain is stored too.
+func ImportPKCS12(p12Data []byte, p12Pwd, pwd, keyDirPath, certDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) (heimdall.KeyID, error) {
+	pri, chain, err := DecodePKCS12(p12Data, p12Pwd)
+	if err != nil {
+		return "", err
+	}
+	defer pri.Clear()
+
+	if err := hecdsa.StorePriKey(pri, pwd, keyDirPath, encOpt, kdfOpt); err != nil {
+		return "", err
+	}
+	if err := cert.Store(chain[0], certDirPath); err != nil {
+		return "", err
+	}
+	if len(chain) > 1 {
+		if err := cert.StoreChain(chain, certDirPath); err != nil {
+			return "", err
+		}
+	}
+
+	return pri.ID(), nil
+}
+
+// EncodePKCS12 bundles private key and its certificate chain (leaf first) into PKCS #12 file protected by pwd.
+// The file is encrypted with AES-256-CBC and PBKDF2-HMAC-SHA256, which OpenSSL 3 and Java 11 or later understand.
+func EncodePKCS12(pri heimdall.PriKey, chain []*x509.Certificate, pwd string) ([]byte, error) {
+	if len(chain) == 0 {
+		return nil, cert.ErrEmptyChain
+	}
+	if err := checkCertOfKey(chain[0], pri); err != nil {
+		return nil, err
+	}
+
+	der, err := marshalPKCS8(pri)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, ErrPKCS12KeyNotSupported
+	}
+
+	return pkcs12.Modern2023.Encode(key, chain[0], chain[1:], pwd)
+}
+
+// DecodePKCS12 decodes PKCS #12 file with pwd into private key and its certificate chain (leaf first).
+// Algorithm of the key is detected from the file.
+func DecodePKCS12(p12Data []byte, pwd string) (heimdall.PriKey, []*x509.Certificate, error) {
+	key, leaf, caCerts, err := pkcs12.DecodeChain(p12Data, pwd)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pri, err := toPriKey(key)
+	if err != nil {
+		return nil, nil, ErrPKCS12KeyNotSupported
+	}
+	if err := checkCertOfKey(leaf, pri); err != nil {
+		pri.Clear()
+		return nil, nil, err
+	}
+
+	return pri, orderChain(leaf, caCerts), nil
+}
+
+// orderChain orders CA certificates from issuer of leaf to root, since PKCS #12 does not define their order.
+// Certificates which are not in the chain of leaf are dropped.
+func orderChain(leaf *x509.Certificate, caCerts []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{leaf}
+	remaining := append([]*x509.Certificate{}, caCerts...)
+
+	for len(remaining) > 0 {
+		issued := chain[len(chain)-1]
+		found := false
+		for i, caCert := range remaining {
+			if issued.CheckSignatureFrom(caCert) == nil {
+				chain = append(chain, caCert)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	return chain
+}
+
+// checkCertOfKey checks that certificate is issued for public key of the private key.
+func checkCertOfKey(leaf *x509.Certificate, pri heimdall.PriKey) error {
+	pubBytes, err := pri.PublicKey().ToByte()
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(leaf.RawSubjectPublicKeyInfo, pubBytes) {
+		return ErrPKCS12CertMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,148 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+func setUpCertChain(t *testing.T, pri heimdall.PriKey) []*x509.Certificate {
+	ca, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer ca.Close()
+
+	template := mocks.TestCertTemplate
+	leaf, err := ca.Issue(pri.(crypto.Signer).Public(), &template)
+	assert.NoError(t, err)
+
+	return []*x509.Certificate{leaf, ca.RootCert}
+}
+
+func TestExportPKCS12(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	chain := setUpCertChain(t, pri)
+	assert.NoError(t, cert.StoreChain(chain, heimdall.TestCertDir))
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	p12Data, err := keystore.ExportPKCS12(heimdall.TestKeyDir, heimdall.TestCertDir, "password", "p12Password")
+
+	// then
+	assert.NoError(t, err)
+	decoded, decodedChain, err := keystore.DecodePKCS12(p12Data, "p12Password")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), decoded.ID())
+	assert.Equal(t, chain, decodedChain)
+
+	_, _, wrongPwdErr := keystore.DecodePKCS12(p12Data, "wrong")
+	assert.Equal(t, pkcs12.ErrIncorrectPassword, wrongPwdErr)
+}
+
+func TestExportPKCS12_WithoutChain(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	chain := setUpCertChain(t, pri)
+	assert.NoError(t, cert.Store(chain[0], heimdall.TestCertDir))
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	p12Data, err := keystore.ExportPKCS12(heimdall.TestKeyDir, heimdall.TestCertDir, "password", "p12Password")
+
+	// then
+	assert.NoError(t, err)
+	_, decodedChain, err := keystore.DecodePKCS12(p12Data, "p12Password")
+	assert.NoError(t, err)
+	assert.Equal(t, chain[:1], decodedChain)
+}
+
+func TestEncodePKCS12_CertMismatch(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	chain := setUpCertChain(t, setUpPriKey(t))
+
+	// when
+	_, err := keystore.EncodePKCS12(pri, chain, "p12Password")
+
+	// then
+	assert.Equal(t, keystore.ErrPKCS12CertMismatch, err)
+}
+
+func TestImportPKCS12(t *testing.T) {
+	// given
+	ca, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer ca.Close()
+
+	interPri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.NoError(t, err)
+	interTemplate := mocks.TestIntermediateCertTemplate
+	interCert, err := ca.Issue(&interPri.PublicKey, &interTemplate)
+	assert.NoError(t, err)
+
+	leafPri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	leafTemplate := mocks.TestCertTemplate
+	derBytes, err := x509.CreateCertificate(rand.Reader, &leafTemplate, interCert, &leafPri.PublicKey, interPri)
+	assert.NoError(t, err)
+	leafCert, err := x509.ParseCertificate(derBytes)
+	assert.NoError(t, err)
+
+	// CA certificates are bundled from root, and are ordered from intermediate at importing
+	p12Data, err := pkcs12.Modern2023.Encode(leafPri, leafCert, []*x509.Certificate{ca.RootCert, interCert}, "p12Password")
+	assert.NoError(t, err)
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestCertDir)
+
+	// when
+	keyId, err := keystore.ImportPKCS12(p12Data, "p12Password", "password", heimdall.TestKeyDir, heimdall.TestCertDir, encOpt, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hecdsa.NewPriKey(leafPri).ID(), keyId)
+
+	loadedPri, err := hecdsa.LoadPriKey(heimdall.TestKeyDir, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, keyId, loadedPri.ID())
+	loadedChain, err := cert.LoadChain(keyId, heimdall.TestCertDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []*x509.Certificate{leafCert, interCert, ca.RootCert}, loadedChain)
+}
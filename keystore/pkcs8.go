@@ -0,0 +1,478 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides conversion between key files and encrypted PKCS #8 PEM (PBES2 of RFC 8018),
+// which is the private key format of OpenSSL, Java keytool and other standard stacks.
+
+package keystore
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"strconv"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/hx25519"
+	"github.com/DE-labtory/heimdall/kdf"
+)
+
+var ErrInvalidPKCS8PEM = errors.New("invalid PKCS #8 PEM - PEM block should be ENCRYPTED PRIVATE KEY encrypted by PBES2")
+var ErrPKCS8Decryption = errors.New("PKCS #8 decryption failed - wrong password or corrupted PEM")
+var ErrPKCS8KDFNotSupported = errors.New("kdf not supported by PKCS #8 - kdf should be scrypt or pbkdf2 with SHA2-256, SHA384 or SHA512")
+var ErrPKCS8CipherNotSupported = errors.New("cipher not supported by PKCS #8 - cipher should be AES-CBC")
+var ErrPKCS8KeyNotSupported = errors.New("key not supported by PKCS #8 - key should be ECDSA, RSA, Ed25519 or X25519 key")
+
+const pemTypeEncryptedPriKey = "ENCRYPTED PRIVATE KEY"
+
+// DefaultPKCS8KDFOpt is key derivation option for encrypted PKCS #8, which is PBKDF2 with HMAC-SHA256 understood by most stacks.
+// Iteration count is the recommendation of OWASP for PBKDF2-HMAC-SHA256, instead of 2048 of OpenSSL 3 which is too low
+// against offline guessing. Key files derived with 2048 iterations are weaker than this option, so they are upgraded
+// when loaded with upgrade.
+// references
+// https://cheatsheetseries.owasp.org/cheatsheets/Password_Storage_Cheat_Sheet.html#pbkdf2
+var DefaultPKCS8KDFOpt = &kdf.Opts{
+	KdfName:   kdf.PBKDF2,
+	KdfParams: map[string]string{"iteration": "600000", "hashOpt": hashing.SHA2_256},
+}
+
+var (
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidScrypt         = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11591, 4, 11}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidHMACWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 10}
+	oidHMACWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 11}
+	oidAES128CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 2}
+	oidAES192CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 22}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+	oidECPublicKey    = asn1.ObjectIdentifier{1, 2, 840, 10045, 2, 1}
+)
+
+// prfHashOpts maps pseudorandom functions of PBKDF2 to hash options.
+// hmacWithSHA1, the default of PKCS #5 v2.0, is not supported since hashing has no SHA-1.
+var prfHashOpts = []struct {
+	oid     asn1.ObjectIdentifier
+	hashOpt string
+}{
+	{oidHMACWithSHA256, hashing.SHA2_256},
+	{oidHMACWithSHA384, hashing.SHA384},
+	{oidHMACWithSHA512, hashing.SHA512},
+}
+
+// aesCBCKeyLens maps AES-CBC encryption schemes to key lengths in bytes.
+var aesCBCKeyLens = map[string]int{
+	oidAES128CBC.String(): 16,
+	oidAES192CBC.String(): 24,
+	oidAES256CBC.String(): 32,
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc pkix.AlgorithmIdentifier
+	EncryptionScheme  pkix.AlgorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int                      `asn1:"optional"`
+	PRF            pkix.AlgorithmIdentifier `asn1:"optional"`
+}
+
+type scryptParams struct {
+	Salt                     []byte
+	CostParameter            int
+	BlockSize                int
+	ParallelizationParameter int
+	KeyLength                int `asn1:"optional"`
+}
+
+type pkcs8 struct {
+	Version    int
+	Algorithm  pkix.AlgorithmIdentifier
+	PrivateKey []byte
+}
+
+// ImportPKCS8PEM decrypts encrypted PKCS #8 PEM with pemPwd, and stores the key in keyDirPath as key file encrypted with pwd.
+// Algorithm of the key is detected from the PEM.
+func ImportPKCS8PEM(pemBytes []byte, pemPwd, pwd, keyDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) (heimdall.KeyID, error) {
+	pri, err := DecryptPKCS8PEM(pemBytes, pemPwd)
+	if err != nil {
+		return "", err
+	}
+	defer pri.Clear()
+
+	if err := hecdsa.StorePriKey(pri, pwd, keyDirPath, encOpt, kdfOpt); err != nil {
+		return "", err
+	}
+
+	return pri.ID(), nil
+}
+
+// ExportPKCS8PEM loads private key in keyDirPath with pwd, and encrypts it into PKCS #8 PEM with pemPwd.
+// If kdfOpt is nil, DefaultPKCS8KDFOpt is used.
+func ExportPKCS8PEM(keyDirPath, pwd, pemPwd string, kdfOpt *kdf.Opts) ([]byte, error) {
+	pri, err := hecdsa.LoadPriKey(keyDirPath, pwd)
+	if err != nil {
+		return nil, err
+	}
+	defer pri.Clear()
+
+	return EncryptPKCS8PEM(pri, pemPwd, kdfOpt)
+}
+
+// EncryptPKCS8PEM encrypts private key into PKCS #8 PEM by PBES2 with AES-256-CBC.
+// kdfOpt should be scrypt or pbkdf2 with hashing.SHA2_256, hashing.SHA384 or hashing.SHA512. If kdfOpt is nil, DefaultPKCS8KDFOpt is used.
+func EncryptPKCS8PEM(pri heimdall.PriKey, pwd string, kdfOpt *kdf.Opts) ([]byte, error) {
+	if kdfOpt == nil {
+		kdfOpt = DefaultPKCS8KDFOpt
+	}
+
+	der, err := marshalPKCS8(pri)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, kdf.DefaultSaltSize*2)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		return nil, err
+	}
+
+	keyDerivationFunc, err := pkcs8KeyDerivationFunc(kdfOpt, salt)
+	if err != nil {
+		return nil, err
+	}
+	encryptionScheme, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: keyDerivationFunc,
+		EncryptionScheme:  pkix.AlgorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: encryptionScheme}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dKey, err := kdf.DeriveKey([]byte(pwd), salt, 256, kdfOpt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(dKey)
+	if err != nil {
+		return nil, err
+	}
+
+	padLen := aes.BlockSize - len(der)%aes.BlockSize
+	encryptedData := append(der, bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encryptedData, encryptedData)
+
+	info, err := asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     pkix.AlgorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encryptedData,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: pemTypeEncryptedPriKey, Bytes: info}), nil
+}
+
+// DecryptPKCS8PEM decrypts PKCS #8 PEM encrypted by PBES2 with pwd. Algorithm of the key is detected from the PEM.
+func DecryptPKCS8PEM(pemBytes []byte, pwd string) (heimdall.PriKey, error) {
+	pemBlock, _ := pem.Decode(pemBytes)
+	if pemBlock == nil || pemBlock.Type != pemTypeEncryptedPriKey {
+		return nil, ErrInvalidPKCS8PEM
+	}
+
+	var info encryptedPrivateKeyInfo
+	if rest, err := asn1.Unmarshal(pemBlock.Bytes, &info); err != nil || len(rest) != 0 || !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, ErrInvalidPKCS8PEM
+	}
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, ErrInvalidPKCS8PEM
+	}
+
+	keyLen, ok := aesCBCKeyLens[params.EncryptionScheme.Algorithm.String()]
+	if !ok {
+		return nil, ErrPKCS8CipherNotSupported
+	}
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil || len(iv) != aes.BlockSize {
+		return nil, ErrInvalidPKCS8PEM
+	}
+	if len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, ErrInvalidPKCS8PEM
+	}
+
+	kdfOpt, salt, err := pkcs8KDFOpt(params.KeyDerivationFunc, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	dKey, err := kdf.DeriveKeyWithLimits([]byte(pwd), salt, keyLen*8, kdfOpt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(dKey)
+	if err != nil {
+		return nil, err
+	}
+
+	der := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(der, info.EncryptedData)
+
+	padLen := int(der[len(der)-1])
+	if padLen == 0 || padLen > aes.BlockSize || !bytes.Equal(der[len(der)-padLen:], bytes.Repeat([]byte{byte(padLen)}, padLen)) {
+		return nil, ErrPKCS8Decryption
+	}
+
+	pri, err := parsePKCS8(der[:len(der)-padLen])
+	if err != nil {
+		return nil, ErrPKCS8Decryption
+	}
+
+	return pri, nil
+}
+
+// pkcs8KeyDerivationFunc converts kdf option into key derivation function of PBES2.
+func pkcs8KeyDerivationFunc(kdfOpt *kdf.Opts, salt []byte) (pkix.AlgorithmIdentifier, error) {
+	var oid asn1.ObjectIdentifier
+	var params interface{}
+
+	switch kdfOpt.KdfName {
+	case kdf.PBKDF2:
+		var prf asn1.ObjectIdentifier
+		for _, prfHashOpt := range prfHashOpts {
+			if prfHashOpt.hashOpt == kdfOpt.KdfParams["hashOpt"] {
+				prf = prfHashOpt.oid
+			}
+		}
+		if prf == nil {
+			return pkix.AlgorithmIdentifier{}, ErrPKCS8KDFNotSupported
+		}
+
+		iteration, err := strconv.Atoi(kdfOpt.KdfParams["iteration"])
+		if err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+
+		oid = oidPBKDF2
+		params = pbkdf2Params{
+			Salt:           salt,
+			IterationCount: iteration,
+			PRF:            pkix.AlgorithmIdentifier{Algorithm: prf, Parameters: asn1.NullRawValue},
+		}
+	case kdf.SCRYPT:
+		var scrypt scryptParams
+		var err error
+		if scrypt.CostParameter, err = strconv.Atoi(kdfOpt.KdfParams["N"]); err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		if scrypt.BlockSize, err = strconv.Atoi(kdfOpt.KdfParams["R"]); err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		if scrypt.ParallelizationParameter, err = strconv.Atoi(kdfOpt.KdfParams["P"]); err != nil {
+			return pkix.AlgorithmIdentifier{}, err
+		}
+		scrypt.Salt = salt
+
+		oid = oidScrypt
+		params = scrypt
+	default:
+		return pkix.AlgorithmIdentifier{}, ErrPKCS8KDFNotSupported
+	}
+
+	paramBytes, err := asn1.Marshal(params)
+	if err != nil {
+		return pkix.AlgorithmIdentifier{}, err
+	}
+
+	return pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.RawValue{FullBytes: paramBytes}}, nil
+}
+
+// pkcs8KDFOpt converts key derivation function of PBES2 into kdf option and salt.
+func pkcs8KDFOpt(keyDerivationFunc pkix.AlgorithmIdentifier, keyLen int) (*kdf.Opts, []byte, error) {
+	switch {
+	case keyDerivationFunc.Algorithm.Equal(oidPBKDF2):
+		var params pbkdf2Params
+		if _, err := asn1.Unmarshal(keyDerivationFunc.Parameters.FullBytes, &params); err != nil {
+			return nil, nil, ErrInvalidPKCS8PEM
+		}
+		if params.KeyLength != 0 && params.KeyLength != keyLen {
+			return nil, nil, ErrInvalidPKCS8PEM
+		}
+
+		hashOpt := ""
+		for _, prfHashOpt := range prfHashOpts {
+			if prfHashOpt.oid.Equal(params.PRF.Algorithm) {
+				hashOpt = prfHashOpt.hashOpt
+			}
+		}
+		if hashOpt == "" {
+			return nil, nil, ErrPKCS8KDFNotSupported
+		}
+
+		kdfOpt, err := kdf.NewOpts(kdf.PBKDF2, map[string]string{
+			"iteration": strconv.Itoa(params.IterationCount),
+			"hashOpt":   hashOpt,
+		})
+		return kdfOpt, params.Salt, err
+	case keyDerivationFunc.Algorithm.Equal(oidScrypt):
+		var params scryptParams
+		if _, err := asn1.Unmarshal(keyDerivationFunc.Parameters.FullBytes, &params); err != nil {
+			return nil, nil, ErrInvalidPKCS8PEM
+		}
+		if params.KeyLength != 0 && params.KeyLength != keyLen {
+			return nil, nil, ErrInvalidPKCS8PEM
+		}
+
+		kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{
+			"N": strconv.Itoa(params.CostParameter),
+			"R": strconv.Itoa(params.BlockSize),
+			"P": strconv.Itoa(params.ParallelizationParameter),
+		})
+		return kdfOpt, params.Salt, err
+	}
+
+	return nil, nil, ErrPKCS8KDFNotSupported
+}
+
+// marshalPKCS8 encodes private key in unencrypted PKCS #8.
+// ECDSA keys on curves which x509 does not know (ex. secp256k1) are encoded with their curve OID, as OpenSSL does.
+func marshalPKCS8(pri heimdall.PriKey) ([]byte, error) {
+	priBytes, err := pri.ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch pri.KeyGenOpt().Algorithm() {
+	case heimdall.ECDSA:
+		if ecPri, err := x509.ParseECPrivateKey(priBytes); err == nil {
+			return x509.MarshalPKCS8PrivateKey(ecPri)
+		}
+
+		var privateKey ecPrivateKey
+		if _, err := asn1.Unmarshal(priBytes, &privateKey); err != nil {
+			return nil, err
+		}
+		curveOID, err := asn1.Marshal(privateKey.NamedCurveOID)
+		if err != nil {
+			return nil, err
+		}
+
+		return asn1.Marshal(pkcs8{
+			Algorithm:  pkix.AlgorithmIdentifier{Algorithm: oidECPublicKey, Parameters: asn1.RawValue{FullBytes: curveOID}},
+			PrivateKey: priBytes,
+		})
+	case heimdall.RSA:
+		rsaPri, err := x509.ParsePKCS1PrivateKey(priBytes)
+		if err != nil {
+			return nil, err
+		}
+		return x509.MarshalPKCS8PrivateKey(rsaPri)
+	case heimdall.ED25519, heimdall.X25519:
+		return priBytes, nil
+	}
+
+	return nil, ErrPKCS8KeyNotSupported
+}
+
+// parsePKCS8 decodes unencrypted PKCS #8 into private key of the detected algorithm.
+func parsePKCS8(der []byte) (heimdall.PriKey, error) {
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return parseECPKCS8(der)
+	}
+
+	return toPriKey(key)
+}
+
+// toPriKey converts private key of standard library (ex. *ecdsa.PrivateKey) to private key of its algorithm.
+func toPriKey(key interface{}) (heimdall.PriKey, error) {
+	switch key := key.(type) {
+	case *ecdsa.PrivateKey:
+		return hecdsa.NewPriKey(key), nil
+	case *rsa.PrivateKey:
+		return hrsa.NewPriKey(key), nil
+	case ed25519.PrivateKey:
+		return hed25519.NewPriKey(key), nil
+	case *ecdh.PrivateKey:
+		if key.Curve() == ecdh.X25519() {
+			return hx25519.NewPriKey(key), nil
+		}
+	}
+
+	return nil, ErrPKCS8KeyNotSupported
+}
+
+// parseECPKCS8 decodes PKCS #8 of ECDSA key on curve registered to hecdsa, which x509 does not know.
+func parseECPKCS8(der []byte) (heimdall.PriKey, error) {
+	var info pkcs8
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, err
+	}
+	if !info.Algorithm.Algorithm.Equal(oidECPublicKey) {
+		return nil, ErrPKCS8KeyNotSupported
+	}
+
+	var privateKey ecPrivateKey
+	if _, err := asn1.Unmarshal(info.PrivateKey, &privateKey); err != nil {
+		return nil, err
+	}
+	// curve OID may be only in the algorithm identifier
+	if len(privateKey.NamedCurveOID) == 0 {
+		if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &privateKey.NamedCurveOID); err != nil {
+			return nil, err
+		}
+	}
+	priBytes, err := asn1.Marshal(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := (&hecdsa.KeyRecoverer{}).RecoverKeyFromByte(priBytes, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.(heimdall.PriKey), nil
+}
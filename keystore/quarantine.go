@@ -0,0 +1,395 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides quarantine of corrupted key files with diagnostic reports, and repair of quarantined files.
+
+package keystore
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrQuarantineInKeyDir = errors.New("invalid quarantine directory - quarantine directory should not be in key directory")
+
+// reportSuffix is appended to quarantined file name for its diagnostic report.
+const reportSuffix = ".report.json"
+
+// Diagnosis is a diagnostic report of a quarantined key file, stored next to the file in quarantine directory.
+type Diagnosis struct {
+	Name            string
+	QuarantinedName string
+	KeyDirPath      string
+	Kind            string
+	Err             string
+	Size            int
+	SHA256          []byte
+	QuarantinedAt   time.Time
+	Salvage         *Salvage `json:",omitempty"`
+	Restored        bool
+}
+
+// Salvage holds fields which could be read from a corrupted key file.
+type Salvage struct {
+	KeyID        heimdall.KeyID          `json:",omitempty"`
+	SKI          []byte                  `json:",omitempty"`
+	KeyGenOpt    string                  `json:",omitempty"`
+	Hints        *hecdsa.EncryptionHints `json:",omitempty"`
+	EncryptedKey string                  `json:",omitempty"`
+	PublicKey    []byte                  `json:",omitempty"`
+}
+
+// QuarantineError is returned instead of the load error when the key file has been quarantined.
+type QuarantineError struct {
+	Diagnosis *Diagnosis
+	Err       error
+}
+
+func (quarantineErr *QuarantineError) Error() string {
+	return "key file [" + quarantineErr.Diagnosis.Name + "] quarantined as [" +
+		quarantineErr.Diagnosis.QuarantinedName + "]: " + quarantineErr.Diagnosis.Err
+}
+
+func (quarantineErr *QuarantineError) Unwrap() error {
+	return quarantineErr.Err
+}
+
+// QuarantineDirPath returns default quarantine directory of key directory, which is a sibling of key directory
+// because a private key directory should hold only one file.
+func QuarantineDirPath(keyDirPath string) string {
+	return filepath.Clean(keyDirPath) + ".quarantine"
+}
+
+// Quarantine verifies key directory and moves corrupted key files into quarantine directory with diagnostic reports.
+// Orphaned files are left untouched because they are not key files.
+func Quarantine(keyDirPath, quarantineDirPath string) ([]*Diagnosis, error) {
+	if err := checkQuarantineDir(keyDirPath, quarantineDirPath); err != nil {
+		return nil, err
+	}
+
+	report, err := Verify(keyDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	diagnoses := make([]*Diagnosis, 0)
+	for _, fileReport := range report.Files {
+		if fileReport.Status != Corrupted {
+			continue
+		}
+
+		diagnosis, err := quarantineFile(keyDirPath, quarantineDirPath, fileReport)
+		if err != nil {
+			return diagnoses, err
+		}
+		diagnoses = append(diagnoses, diagnosis)
+	}
+
+	return diagnoses, nil
+}
+
+func quarantineFile(keyDirPath, quarantineDirPath string, fileReport *FileReport) (*Diagnosis, error) {
+	if err := checkQuarantineDir(keyDirPath, quarantineDirPath); err != nil {
+		return nil, err
+	}
+
+	if err := fileperm.MkdirAll(quarantineDirPath); err != nil {
+		return nil, err
+	}
+
+	filePath := filepath.Join(keyDirPath, fileReport.Name)
+	keyBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	digest := sha256.Sum256(keyBytes)
+	diagnosis := &Diagnosis{
+		Name:            fileReport.Name,
+		QuarantinedName: fileReport.Name + "." + strconv.FormatInt(now.UnixNano(), 10),
+		KeyDirPath:      keyDirPath,
+		Kind:            fileReport.Kind,
+		Err:             fileReport.Err.Error(),
+		Size:            len(keyBytes),
+		SHA256:          digest[:],
+		QuarantinedAt:   now,
+		Salvage:         salvageFields(keyBytes),
+	}
+
+	if err := os.Rename(filePath, filepath.Join(quarantineDirPath, diagnosis.QuarantinedName)); err != nil {
+		return nil, err
+	}
+
+	return diagnosis, writeDiagnosis(quarantineDirPath, diagnosis)
+}
+
+// checkQuarantineDir rejects quarantine directory inside key directory, where it would be taken as a key file entry.
+func checkQuarantineDir(keyDirPath, quarantineDirPath string) error {
+	relPath, err := filepath.Rel(keyDirPath, quarantineDirPath)
+	if err != nil {
+		return err
+	}
+
+	if relPath == "." || (relPath != ".." && !strings.HasPrefix(relPath, ".."+string(filepath.Separator))) {
+		return ErrQuarantineInKeyDir
+	}
+
+	return nil
+}
+
+func writeDiagnosis(quarantineDirPath string, diagnosis *Diagnosis) error {
+	diagnosisBytes, err := json.MarshalIndent(diagnosis, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return fileperm.WriteFile(filepath.Join(quarantineDirPath, diagnosis.QuarantinedName+reportSuffix), diagnosisBytes)
+}
+
+// salvageFields reads top level fields of json key file one by one, so that leading fields of truncated file are kept.
+func salvageFields(keyBytes []byte) *Salvage {
+	decoder := json.NewDecoder(bytes.NewReader(keyBytes))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('{') {
+		return nil
+	}
+
+	salvage := &Salvage{}
+	fields := map[string]interface{}{
+		"SKI":          &salvage.SKI,
+		"KeyGenOpt":    &salvage.KeyGenOpt,
+		"Hints":        &salvage.Hints,
+		"EncryptedKey": &salvage.EncryptedKey,
+		"PublicKey":    &salvage.PublicKey,
+	}
+
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			break
+		}
+
+		if field, ok := fields[token.(string)]; ok {
+			json.Unmarshal(value, field)
+		}
+	}
+
+	if len(salvage.SKI) != 0 {
+		salvage.KeyID = salvagedKeyID(salvage)
+	}
+
+	return salvage
+}
+
+// salvagedKeyID makes key ID of salvaged SKI, with algorithm of salvaged key generation option if it is readable.
+func salvagedKeyID(salvage *Salvage) heimdall.KeyID {
+	if keyType, err := heimdall.ParseKeyType(salvage.KeyGenOpt); err == nil {
+		if keyId, err := heimdall.MakeKeyID(keyType, salvage.SKI); err == nil {
+			return keyId
+		}
+	}
+
+	return heimdall.SKIToKeyID(salvage.SKI)
+}
+
+// Repair revisits quarantined files and restores files whose content is a readable key into key directory.
+// Key directories are indexed by file names, so a restored file is named by key ID of its content,
+// which rebuilds the index for files stored under wrong names. Unrestorable files are kept in quarantine
+// and their reports are updated with salvaged fields.
+func Repair(quarantineDirPath string) ([]*Diagnosis, error) {
+	files, err := ioutil.ReadDir(quarantineDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	diagnoses := make([]*Diagnosis, 0)
+	for _, file := range files {
+		if !strings.HasSuffix(file.Name(), reportSuffix) {
+			continue
+		}
+
+		diagnosisBytes, err := ioutil.ReadFile(filepath.Join(quarantineDirPath, file.Name()))
+		if err != nil {
+			return diagnoses, err
+		}
+
+		diagnosis := &Diagnosis{}
+		if err := json.Unmarshal(diagnosisBytes, diagnosis); err != nil {
+			return diagnoses, err
+		}
+
+		if diagnosis.Restored {
+			continue
+		}
+
+		if err := repairFile(quarantineDirPath, diagnosis); err != nil {
+			return diagnoses, err
+		}
+		diagnoses = append(diagnoses, diagnosis)
+	}
+
+	return diagnoses, nil
+}
+
+func repairFile(quarantineDirPath string, diagnosis *Diagnosis) error {
+	filePath := filepath.Join(quarantineDirPath, diagnosis.QuarantinedName)
+	keyBytes, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+
+	diagnosis.Salvage = salvageFields(keyBytes)
+
+	keyId := recoveredKeyID(keyBytes, diagnosis.Salvage)
+	if keyId == "" || verifyFile(keyId, keyBytes).Status != Valid {
+		return writeDiagnosis(quarantineDirPath, diagnosis)
+	}
+
+	restoredPath := filepath.Join(diagnosis.KeyDirPath, keyId)
+	if _, err := os.Stat(restoredPath); err == nil {
+		return writeDiagnosis(quarantineDirPath, diagnosis)
+	}
+
+	if err := os.Rename(filePath, restoredPath); err != nil {
+		return err
+	}
+	diagnosis.Restored = true
+
+	return writeDiagnosis(quarantineDirPath, diagnosis)
+}
+
+// recoveredKeyID returns key ID of key file content regardless of the file name, or empty string if unreadable.
+// Content is recovered by the recoverer registered for its key generation option, or by every registered recoverer
+// if the option is unknown.
+func recoveredKeyID(keyBytes []byte, salvage *Salvage) heimdall.KeyID {
+	keyGenOpt := ""
+	if salvage != nil {
+		if salvage.KeyID != "" {
+			return salvage.KeyID
+		}
+
+		if salvage.PublicKey == nil {
+			return ""
+		}
+		keyBytes = salvage.PublicKey
+		keyGenOpt = salvage.KeyGenOpt
+	}
+
+	key, err := heimdall.RecoverKeyByOpt(keyBytes, false, keyGenOpt)
+	if err != nil {
+		key, err = heimdall.RecoverKeyByOpt(keyBytes, true, keyGenOpt)
+	}
+	if err != nil {
+		return ""
+	}
+
+	if pri, ok := key.(heimdall.PriKey); ok {
+		defer pri.Clear()
+	}
+
+	return key.ID()
+}
+
+// quarantiningKeyStore quarantines corrupted key files when loading keys fails.
+type quarantiningKeyStore struct {
+	heimdall.KeyStore
+	priKeyDirPath string
+	pubKeyDirPath string
+}
+
+// WithQuarantine wraps key store on priKeyDirPath and pubKeyDirPath. When loading a key fails and its key file is
+// corrupted, the file is moved into quarantine directory of its key directory and *QuarantineError is returned.
+// Only the key file of the loaded key ID is quarantined, and never when the key is missing or the password is wrong.
+func WithQuarantine(keyStore heimdall.KeyStore, priKeyDirPath, pubKeyDirPath string) heimdall.KeyStore {
+	return &quarantiningKeyStore{
+		KeyStore:      keyStore,
+		priKeyDirPath: priKeyDirPath,
+		pubKeyDirPath: pubKeyDirPath,
+	}
+}
+
+func (keyStore *quarantiningKeyStore) LoadPriKey(keyId heimdall.KeyID, pwd string) (heimdall.PriKey, error) {
+	pri, err := keyStore.KeyStore.LoadPriKey(keyId, pwd)
+	if err == nil {
+		return pri, nil
+	}
+
+	return nil, quarantineKeyFile(keyStore.priKeyDirPath, keyId, err)
+}
+
+func (keyStore *quarantiningKeyStore) LoadPubKey(keyId heimdall.KeyID) (heimdall.PubKey, error) {
+	pub, err := keyStore.KeyStore.LoadPubKey(keyId)
+	if err == nil {
+		return pub, nil
+	}
+
+	return nil, quarantineKeyFile(keyStore.pubKeyDirPath, keyId, err)
+}
+
+// quarantineKeyFile quarantines key file of key ID in key directory if loading it failed with err and the file is corrupted,
+// and returns *QuarantineError of err. Otherwise err is returned as is.
+func quarantineKeyFile(keyDirPath string, keyId heimdall.KeyID, err error) error {
+	if !isIntegrityFailure(err) {
+		return err
+	}
+
+	keyPath := heimdall.KeyIDFilePath(keyDirPath, keyId)
+	keyBytes, readErr := ioutil.ReadFile(keyPath)
+	if readErr != nil {
+		return err
+	}
+
+	fileReport := verifyFile(filepath.Base(keyPath), keyBytes)
+	if fileReport.Status != Corrupted {
+		return err
+	}
+
+	diagnosis, quarantineErr := quarantineFile(keyDirPath, QuarantineDirPath(keyDirPath), fileReport)
+	if quarantineErr != nil {
+		return err
+	}
+
+	return &QuarantineError{Diagnosis: diagnosis, Err: err}
+}
+
+// isIntegrityFailure checks if load error may come from a corrupted key file. Missing keys and wrong passwords are not,
+// and key file MAC mismatch is taken as wrong password since it can not be told apart from tampering.
+func isIntegrityFailure(err error) bool {
+	switch err {
+	case hecdsa.ErrInvalidKeyFileMAC, hecdsa.ErrWrongKeyID, heimdall.ErrKeyNotFound:
+		return false
+	}
+
+	return !os.IsNotExist(err)
+}
@@ -0,0 +1,195 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuarantine(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	quarantineDirPath := keystore.QuarantineDirPath(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(quarantineDirPath)
+
+	jsonKeyFile, err := ioutil.ReadFile(filepath.Join(heimdall.TestKeyDir, pri.ID()))
+	assert.NoError(t, err)
+	truncatedPri := setUpPriKey(t)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, truncatedPri.ID()), jsonKeyFile[:len(jsonKeyFile)/2], 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, "key.tmp"), []byte("partial"), 0600))
+
+	// when
+	diagnoses, err := keystore.Quarantine(heimdall.TestKeyDir, quarantineDirPath)
+	_, inKeyDirErr := keystore.Quarantine(heimdall.TestKeyDir, filepath.Join(heimdall.TestKeyDir, "quarantine"))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, keystore.ErrQuarantineInKeyDir, inKeyDirErr)
+	assert.Len(t, diagnoses, 1)
+	assert.Equal(t, truncatedPri.ID(), diagnoses[0].Name)
+	assert.Equal(t, pri.SKI(), diagnoses[0].Salvage.SKI)
+
+	report, err := keystore.Verify(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.Len(t, report.Files, 2)
+
+	_, err = os.Stat(filepath.Join(quarantineDirPath, diagnoses[0].QuarantinedName))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(quarantineDirPath, diagnoses[0].QuarantinedName+".report.json"))
+	assert.NoError(t, err)
+}
+
+func TestRepair(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	quarantineDirPath := keystore.QuarantineDirPath(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(quarantineDirPath)
+
+	otherPri := setUpPriKey(t)
+	assert.NoError(t, os.Rename(filepath.Join(heimdall.TestKeyDir, pri.ID()), filepath.Join(heimdall.TestKeyDir, otherPri.ID())))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestKeyDir, pri.ID()), []byte("\x00\x01garbage"), 0600))
+	diagnoses, err := keystore.Quarantine(heimdall.TestKeyDir, quarantineDirPath)
+	assert.NoError(t, err)
+	assert.Len(t, diagnoses, 2)
+
+	// when
+	repaired, err := keystore.Repair(quarantineDirPath)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, repaired, 2)
+
+	restored := 0
+	for _, diagnosis := range repaired {
+		if diagnosis.Restored {
+			restored++
+			assert.Equal(t, otherPri.ID(), diagnosis.Name)
+		}
+	}
+	assert.Equal(t, 1, restored)
+
+	report, err := keystore.Verify(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.True(t, report.Healthy())
+	assert.Len(t, report.Files, 1)
+
+	loadedPri, err := hecdsa.LoadPriKey(heimdall.TestKeyDir, "password")
+	assert.NoError(t, err)
+	assert.True(t, heimdall.MatchKeyID(pri.ID(), loadedPri))
+}
+
+func TestWithQuarantine(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyStore := keystore.WithQuarantine(hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt),
+		heimdall.TestPriKeyDir, heimdall.TestPubKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+	defer os.RemoveAll(keystore.QuarantineDirPath(heimdall.TestPriKeyDir))
+	defer os.RemoveAll(keystore.QuarantineDirPath(heimdall.TestPubKeyDir))
+
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, os.MkdirAll(heimdall.TestPubKeyDir, 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestPubKeyDir, pri.ID()), []byte("\x00\x01garbage"), 0600))
+
+	// when
+	_, wrongPwdErr := keyStore.LoadPriKey(pri.ID(), "wrong password")
+	_, err = keyStore.LoadPubKey(pri.ID())
+
+	// then
+	_, isQuarantineErr := wrongPwdErr.(*keystore.QuarantineError)
+	assert.False(t, isQuarantineErr)
+
+	quarantineErr, ok := err.(*keystore.QuarantineError)
+	assert.True(t, ok)
+	assert.Equal(t, pri.ID(), quarantineErr.Diagnosis.Name)
+
+	_, err = os.Stat(filepath.Join(heimdall.TestPubKeyDir, pri.ID()))
+	assert.True(t, os.IsNotExist(err))
+
+	loadedPri, err := keyStore.LoadPriKey(pri.ID(), "password")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), loadedPri.ID())
+}
+
+func TestWithQuarantine_HealthyFiles(t *testing.T) {
+	// given
+	pri := setUpPriKey(t)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	keyStore := keystore.WithQuarantine(hecdsa.NewKeyStore(heimdall.TestPriKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt),
+		heimdall.TestPriKeyDir, heimdall.TestPubKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+	defer os.RemoveAll(keystore.QuarantineDirPath(heimdall.TestPriKeyDir))
+	defer os.RemoveAll(keystore.QuarantineDirPath(heimdall.TestPubKeyDir))
+
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	ed25519Pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	assert.NoError(t, keyStore.StorePubKey(ed25519Pri.PublicKey()))
+	corruptedPri := setUpPriKey(t)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(heimdall.TestPubKeyDir, corruptedPri.ID()), []byte("\x00\x01garbage"), 0600))
+
+	// when
+	_, wrongPwdErr := keyStore.LoadPriKey(pri.ID(), "wrong password")
+	_, missingPriErr := keyStore.LoadPriKey(corruptedPri.ID(), "password")
+	_, missingPubErr := keyStore.LoadPubKey(pri.ID())
+	_, corruptedErr := keyStore.LoadPubKey(corruptedPri.ID())
+
+	// then
+	for _, err := range []error{wrongPwdErr, missingPriErr, missingPubErr} {
+		assert.Error(t, err)
+		_, isQuarantineErr := err.(*keystore.QuarantineError)
+		assert.False(t, isQuarantineErr)
+	}
+
+	quarantineErr, ok := corruptedErr.(*keystore.QuarantineError)
+	assert.True(t, ok)
+	assert.Equal(t, corruptedPri.ID(), quarantineErr.Diagnosis.Name)
+
+	_, err = os.Stat(filepath.Join(heimdall.TestPriKeyDir, pri.ID()))
+	assert.NoError(t, err)
+	pub, err := keyStore.LoadPubKey(ed25519Pri.ID())
+	assert.NoError(t, err)
+	assert.Equal(t, ed25519Pri.ID(), pub.ID())
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides session-bound signer handle, so that applications sign without holding raw private keys.
+
+package keystore
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hbls"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hpq"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/hsm2"
+)
+
+var ErrSignerClosed = errors.New("signer is closed - key is already cleared")
+
+// SignerHandle is a heimdall Signer bound to a private key unlocked from keystore.
+// The key is cleared from memory by Close(), after which signing fails.
+type SignerHandle struct {
+	mutex  sync.RWMutex
+	keyId  heimdall.KeyID
+	pri    heimdall.PriKey
+	signer heimdall.Signer
+}
+
+// OpenSigner unlocks private key of keyId with password and returns signer handle bound to it.
+func OpenSigner(keyStore heimdall.KeyStore, keyId heimdall.KeyID, pwd string) (*SignerHandle, error) {
+	pri, err := keyStore.LoadPriKey(keyId, pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	var signer heimdall.Signer
+	if _, ok := pri.(*hecdsa.PriKey); ok {
+		signer = hecdsa.NewSigner(pri)
+	} else if _, ok := pri.(*hed25519.PriKey); ok {
+		signer = hed25519.NewSigner(pri)
+	} else if _, ok := pri.(*hrsa.PriKey); ok {
+		signer = hrsa.NewSigner(pri)
+	} else if _, ok := pri.(*hbls.PriKey); ok {
+		signer = hbls.NewSigner(pri)
+	} else if _, ok := pri.(*hpq.PriKey); ok {
+		signer = hpq.NewSigner(pri)
+	} else if _, ok := pri.(*hsm2.PriKey); ok {
+		signer = hsm2.NewSigner(pri)
+	} else if signer, err = hecdsa.NewCryptoSigner(pri); err != nil {
+		pri.Clear()
+		return nil, err
+	}
+
+	return &SignerHandle{
+		keyId:  keyId,
+		pri:    pri,
+		signer: signer,
+	}, nil
+}
+
+func (handle *SignerHandle) KeyID() heimdall.KeyID {
+	return handle.keyId
+}
+
+// PublicKey returns public key of the bound private key.
+func (handle *SignerHandle) PublicKey() (heimdall.PubKey, error) {
+	handle.mutex.RLock()
+	defer handle.mutex.RUnlock()
+
+	if handle.pri == nil {
+		return nil, ErrSignerClosed
+	}
+
+	return handle.pri.PublicKey(), nil
+}
+
+func (handle *SignerHandle) Sign(message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	handle.mutex.RLock()
+	defer handle.mutex.RUnlock()
+
+	if handle.signer == nil {
+		return nil, ErrSignerClosed
+	}
+
+	return handle.signer.Sign(message, opts)
+}
+
+// Close clears the bound private key from memory. Closing a closed handle does nothing.
+func (handle *SignerHandle) Close() error {
+	handle.mutex.Lock()
+	defer handle.mutex.Unlock()
+
+	if handle.pri != nil {
+		handle.pri.Clear()
+		handle.pri = nil
+		handle.signer = nil
+	}
+
+	return nil
+}
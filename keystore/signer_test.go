@@ -0,0 +1,202 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hpq"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/hsm2"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func newKeyStore(t *testing.T) heimdall.KeyStore {
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	return hecdsa.NewKeyStore(heimdall.TestKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+}
+
+func TestOpenSigner(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+	message := []byte("hello world")
+
+	// when
+	signer, err := keystore.OpenSigner(newKeyStore(t), pri.ID(), "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), signer.KeyID())
+
+	signature, err := signer.Sign(message, signerOpt)
+	assert.NoError(t, err)
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	pub, err := signer.PublicKey()
+	assert.NoError(t, err)
+	assert.Equal(t, pri.PublicKey(), pub)
+
+	// when
+	assert.NoError(t, signer.Close())
+	assert.NoError(t, signer.Close())
+
+	// then
+	_, err = signer.Sign(message, signerOpt)
+	assert.Equal(t, keystore.ErrSignerClosed, err)
+	_, err = signer.PublicKey()
+	assert.Equal(t, keystore.ErrSignerClosed, err)
+}
+
+func TestOpenSigner_WrongKeyID(t *testing.T) {
+	// given
+	setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	// when
+	signer, err := keystore.OpenSigner(newKeyStore(t), "ITwrongKeyID", "password")
+
+	// then
+	assert.Equal(t, hecdsa.ErrWrongKeyID, err)
+	assert.Nil(t, signer)
+}
+
+func TestOpenSigner_Ed25519(t *testing.T) {
+	// given
+	keyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+	pri, err := hed25519.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := newKeyStore(t)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	message := []byte("hello world")
+
+	// when
+	signer, err := keystore.OpenSigner(keyStore, pri.ID(), "password")
+
+	// then
+	assert.NoError(t, err)
+	signature, err := signer.Sign(message, hed25519.NewSignerOpts())
+	assert.NoError(t, err)
+	valid, err := hed25519.Verify(pri.PublicKey(), signature, message, hed25519.NewSignerOpts())
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.NoError(t, signer.Close())
+}
+
+func TestOpenSigner_RSA(t *testing.T) {
+	// given
+	keyGenOpt, err := hrsa.NewKeyGenOpt(hrsa.RSA1024)
+	assert.NoError(t, err)
+	pri, err := hrsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := newKeyStore(t)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	signerOpt := hrsa.NewSignerOpts(nil).WithPSS(32)
+	message := []byte("hello world")
+
+	// when
+	signer, err := keystore.OpenSigner(keyStore, pri.ID(), "password")
+
+	// then
+	assert.NoError(t, err)
+	signature, err := signer.Sign(message, signerOpt)
+	assert.NoError(t, err)
+	valid, err := hrsa.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.NoError(t, signer.Close())
+}
+
+func TestOpenSigner_Dilithium(t *testing.T) {
+	// given
+	keyGenOpt, err := hpq.NewKeyGenOpt(hpq.DILITHIUM2)
+	assert.NoError(t, err)
+	pri, err := hpq.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := newKeyStore(t)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	signerOpt := hpq.NewSignerOpts()
+	message := []byte("hello world")
+
+	// when
+	signer, err := keystore.OpenSigner(keyStore, pri.ID(), "password")
+
+	// then
+	assert.NoError(t, err)
+	signature, err := signer.Sign(message, signerOpt)
+	assert.NoError(t, err)
+	valid, err := hpq.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.NoError(t, signer.Close())
+}
+
+func TestOpenSigner_SM2(t *testing.T) {
+	// given
+	keyGenOpt, err := hsm2.NewKeyGenOpt(hsm2.SM2)
+	assert.NoError(t, err)
+	pri, err := hsm2.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	keyStore := newKeyStore(t)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	signerOpt := hsm2.NewSignerOpts()
+	message := []byte("hello world")
+
+	// when
+	signer, err := keystore.OpenSigner(keyStore, pri.ID(), "password")
+
+	// then
+	assert.NoError(t, err)
+	signature, err := signer.Sign(message, signerOpt)
+	assert.NoError(t, err)
+	valid, err := hsm2.Verify(pri.PublicKey(), signature, message, signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+	assert.NoError(t, signer.Close())
+}
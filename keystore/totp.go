@@ -0,0 +1,318 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides TOTP(RFC 6238) second factor for unlocking private keys.
+
+package keystore
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+)
+
+var ErrTOTPRequired = errors.New("TOTP code required - use Unlock with TOTP code")
+var ErrTOTPNotProvisioned = errors.New("TOTP secret is not provisioned")
+var ErrWrongTOTP = errors.New("wrong TOTP code or password")
+var ErrTOTPLocked = errors.New("TOTP locked - too many failed unlocks, try again later")
+
+// TOTP parameters compatible with common authenticator apps
+const (
+	TOTPPeriod = 30 * time.Second
+	TOTPDigits = 6
+	// number of periods accepted before and after current time for clock drift
+	totpSkew       = 1
+	totpSecretSize = 20
+	// consecutive failed unlocks before unlock is locked, and duration of the lock
+	totpMaxFailures = 5
+	totpLockout     = 5 * time.Minute
+)
+
+// totpPasswordInfo separates key file password derived from TOTP secret from other uses of the secret.
+const totpPasswordInfo = "heimdall totp key password"
+
+// totpStateSuffix is appended to path of TOTP secret file to name the file keeping unlock state.
+const totpStateSuffix = ".state"
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ProvisionTOTP generates TOTP secret of a keystore and stores it in secretFilePath, resetting unlock state.
+// The returned base32 encoded secret should be registered to authenticator of the operator. Since private keys are
+// encrypted with the secret, secretFilePath should be kept apart from key files, and keys stored under the previous
+// secret can not be unlocked after provisioning again.
+func ProvisionTOTP(secretFilePath string) (string, error) {
+	secret := make([]byte, totpSecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	encoded := totpEncoding.EncodeToString(secret)
+
+	if err := fileperm.MkdirAll(filepath.Dir(secretFilePath)); err != nil {
+		return "", err
+	}
+
+	if err := fileperm.WriteFile(secretFilePath, []byte(encoded)); err != nil {
+		return "", err
+	}
+
+	if err := os.Remove(secretFilePath + totpStateSuffix); err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+
+	return encoded, nil
+}
+
+// TOTPProvisioningURI makes otpauth URI of secret, which authenticator apps can import from QR code.
+func TOTPProvisioningURI(secret, issuer, account string) string {
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", issuer)
+	params.Set("period", fmt.Sprint(int(TOTPPeriod/time.Second)))
+	params.Set("digits", fmt.Sprint(TOTPDigits))
+
+	return "otpauth://totp/" + url.PathEscape(issuer+":"+account) + "?" + params.Encode()
+}
+
+// TOTPCode computes TOTP code of base32 encoded secret at time t.
+func TOTPCode(secret string, t time.Time) (string, error) {
+	key, err := decodeTOTPSecret(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hotp(key, totpCounter(t)), nil
+}
+
+func decodeTOTPSecret(secret string) ([]byte, error) {
+	return totpEncoding.DecodeString(strings.TrimRight(strings.ToUpper(strings.TrimSpace(secret)), "="))
+}
+
+func totpCounter(t time.Time) uint64 {
+	return uint64(t.Unix()) / uint64(TOTPPeriod/time.Second)
+}
+
+// hotp computes HOTP(RFC 4226) code of counter.
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	code := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for i := 0; i < TOTPDigits; i++ {
+		mod *= 10
+	}
+
+	return fmt.Sprintf("%0*d", TOTPDigits, code%mod)
+}
+
+// TOTPKeyStore is a keystore which requires TOTP code along with password to unlock private keys.
+// Private keys are stored with password derived from both password and TOTP secret, so that the wrapped keystore
+// can not load them by password alone.
+type TOTPKeyStore struct {
+	keyStore       heimdall.KeyStore
+	secretFilePath string
+
+	// mutex serializes unlocks, so that concurrent attempts can not exceed failure limit
+	mutex sync.Mutex
+}
+
+// totpState is unlock state kept in a file next to TOTP secret, so that it survives restart of the process.
+type totpState struct {
+	// counter of the last accepted code, to prevent replaying a code
+	LastCounter uint64
+	// consecutive failed unlocks, and time until which unlock is locked
+	Failures    int
+	LockedUntil time.Time
+}
+
+// NewTOTPKeyStore wraps keyStore with TOTP secret provisioned in secretFilePath.
+func NewTOTPKeyStore(keyStore heimdall.KeyStore, secretFilePath string) *TOTPKeyStore {
+	return &TOTPKeyStore{
+		keyStore:       keyStore,
+		secretFilePath: secretFilePath,
+	}
+}
+
+// Unlock loads private key of keyId with password only if TOTP code is valid.
+// A code is used up only when the key is unlocked, and can not be used again. Wrong code and wrong password fail
+// with the same error, so that a right code is not told apart. After totpMaxFailures consecutive failures,
+// every failed unlock locks unlock for totpLockout.
+func (keyStore *TOTPKeyStore) Unlock(keyId heimdall.KeyID, pwd, code string) (heimdall.PriKey, error) {
+	key, err := keyStore.readSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	keyStore.mutex.Lock()
+	defer keyStore.mutex.Unlock()
+
+	state, err := keyStore.readState()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if now.Before(state.LockedUntil) {
+		return nil, ErrTOTPLocked
+	}
+
+	counter, ok := match(key, code, state.LastCounter, now)
+	if !ok {
+		return nil, keyStore.fail(state, now)
+	}
+
+	pri, err := keyStore.keyStore.LoadPriKey(keyId, totpKeyPassword(key, pwd))
+	if err != nil {
+		return nil, keyStore.fail(state, now)
+	}
+
+	state.LastCounter = counter
+	state.Failures = 0
+	if err := keyStore.writeState(state); err != nil {
+		pri.Clear()
+		return nil, err
+	}
+
+	return pri, nil
+}
+
+func (keyStore *TOTPKeyStore) readSecret() ([]byte, error) {
+	secret, err := ioutil.ReadFile(keyStore.secretFilePath)
+	if os.IsNotExist(err) {
+		return nil, ErrTOTPNotProvisioned
+	} else if err != nil {
+		return nil, err
+	}
+
+	return decodeTOTPSecret(string(secret))
+}
+
+// totpKeyPassword derives password of key files from password and TOTP secret.
+func totpKeyPassword(key []byte, pwd string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(totpPasswordInfo))
+	mac.Write([]byte(pwd))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// readState reads unlock state, which is empty if no unlock is tried yet. keyStore mutex should be locked by caller.
+func (keyStore *TOTPKeyStore) readState() (*totpState, error) {
+	state := &totpState{}
+
+	data, err := ioutil.ReadFile(keyStore.secretFilePath + totpStateSuffix)
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// writeState writes unlock state. keyStore mutex should be locked by caller.
+func (keyStore *TOTPKeyStore) writeState(state *totpState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	statePath := keyStore.secretFilePath + totpStateSuffix
+	return fileperm.WriteFileAtomic(statePath, statePath+".tmp", data)
+}
+
+// match finds counter of code within clock drift which is later than the last accepted counter.
+func match(key []byte, code string, lastCounter uint64, now time.Time) (uint64, bool) {
+	current := totpCounter(now)
+	for counter := current - totpSkew; counter <= current+totpSkew; counter++ {
+		if counter <= lastCounter {
+			continue
+		}
+
+		if subtle.ConstantTimeCompare([]byte(hotp(key, counter)), []byte(code)) == 1 {
+			return counter, true
+		}
+	}
+
+	return 0, false
+}
+
+// fail counts failed unlock, and locks unlock if failures reach the limit. It returns ErrWrongTOTP, or error of
+// writing the state. keyStore mutex should be locked by caller.
+func (keyStore *TOTPKeyStore) fail(state *totpState, now time.Time) error {
+	state.Failures++
+	if state.Failures >= totpMaxFailures {
+		state.LockedUntil = now.Add(totpLockout)
+	}
+
+	if err := keyStore.writeState(state); err != nil {
+		return err
+	}
+
+	return ErrWrongTOTP
+}
+
+// StorePriKey stores private key with password derived from password and TOTP secret.
+func (keyStore *TOTPKeyStore) StorePriKey(pri heimdall.PriKey, pwd string) error {
+	key, err := keyStore.readSecret()
+	if err != nil {
+		return err
+	}
+
+	return keyStore.keyStore.StorePriKey(pri, totpKeyPassword(key, pwd))
+}
+
+// LoadPriKey always fails since password alone can not unlock private key, Unlock should be used instead.
+func (keyStore *TOTPKeyStore) LoadPriKey(keyId heimdall.KeyID, pwd string) (heimdall.PriKey, error) {
+	return nil, ErrTOTPRequired
+}
+
+func (keyStore *TOTPKeyStore) StorePubKey(pub heimdall.PubKey) error {
+	return keyStore.keyStore.StorePubKey(pub)
+}
+
+func (keyStore *TOTPKeyStore) LoadPubKey(keyId heimdall.KeyID) (heimdall.PubKey, error) {
+	return keyStore.keyStore.LoadPubKey(keyId)
+}
@@ -0,0 +1,246 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package keystore_test
+
+import (
+	"encoding/base32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTOTPCode(t *testing.T) {
+	// given test vector of RFC 6238
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	for unix, expected := range map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	} {
+		// when
+		code, err := keystore.TOTPCode(secret, time.Unix(unix, 0))
+
+		// then
+		assert.NoError(t, err)
+		assert.Equal(t, expected, code)
+	}
+}
+
+func TestTOTPProvisioningURI(t *testing.T) {
+	// when
+	uri := keystore.TOTPProvisioningURI("JBSWY3DPEHPK3PXP", "heimdall", "node1")
+
+	// then
+	assert.Equal(t, "otpauth://totp/heimdall:node1?digits=6&issuer=heimdall&period=30&secret=JBSWY3DPEHPK3PXP", uri)
+}
+
+func setUpTOTPKeyStore(t *testing.T) (*keystore.TOTPKeyStore, string, heimdall.PriKey) {
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	secretFilePath := filepath.Join(heimdall.TestPriKeyDir, "totp")
+	secret, err := keystore.ProvisionTOTP(secretFilePath)
+	assert.NoError(t, err)
+
+	keyStore := keystore.NewTOTPKeyStore(
+		hecdsa.NewKeyStore(heimdall.TestKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt),
+		secretFilePath,
+	)
+
+	pri := setUpPriKey(t)
+	assert.NoError(t, keyStore.StorePriKey(pri, "password"))
+
+	return keyStore, secret, pri
+}
+
+func TestTOTPKeyStore_Unlock(t *testing.T) {
+	// given
+	keyStore, secret, pri := setUpTOTPKeyStore(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	code, err := keystore.TOTPCode(secret, time.Now())
+	assert.NoError(t, err)
+
+	// when
+	loaded, err := keyStore.Unlock(pri.ID(), "password", code)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri, loaded)
+
+	// when replaying the code
+	loaded, err = keyStore.Unlock(pri.ID(), "password", code)
+
+	// then
+	assert.Equal(t, keystore.ErrWrongTOTP, err)
+	assert.Nil(t, loaded)
+}
+
+func TestTOTPKeyStore_Unlock_WrongCode(t *testing.T) {
+	// given
+	keyStore, secret, pri := setUpTOTPKeyStore(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	code, err := keystore.TOTPCode(secret, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+
+	// when
+	loaded, err := keyStore.Unlock(pri.ID(), "password", code)
+
+	// then
+	assert.Equal(t, keystore.ErrWrongTOTP, err)
+	assert.Nil(t, loaded)
+}
+
+func TestTOTPKeyStore_Unlock_WrongPassword(t *testing.T) {
+	// given
+	keyStore, secret, pri := setUpTOTPKeyStore(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	code, err := keystore.TOTPCode(secret, time.Now())
+	assert.NoError(t, err)
+
+	// when
+	loaded, wrongPwdErr := keyStore.Unlock(pri.ID(), "wrong password", code)
+
+	// then code is not told apart from wrong code, and is not used up
+	assert.Equal(t, keystore.ErrWrongTOTP, wrongPwdErr)
+	assert.Nil(t, loaded)
+
+	loaded, err = keyStore.Unlock(pri.ID(), "password", code)
+	assert.NoError(t, err)
+	assert.Equal(t, pri, loaded)
+}
+
+func TestTOTPKeyStore_Unlock_Locked(t *testing.T) {
+	// given
+	keyStore, secret, pri := setUpTOTPKeyStore(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	wrongCode, err := keystore.TOTPCode(secret, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	code, err := keystore.TOTPCode(secret, time.Now())
+	assert.NoError(t, err)
+
+	// when
+	for i := 0; i < 5; i++ {
+		_, err = keyStore.Unlock(pri.ID(), "password", wrongCode)
+		assert.Equal(t, keystore.ErrWrongTOTP, err)
+	}
+	loaded, lockedErr := keyStore.Unlock(pri.ID(), "password", code)
+
+	// then
+	assert.Equal(t, keystore.ErrTOTPLocked, lockedErr)
+	assert.Nil(t, loaded)
+}
+
+func TestTOTPKeyStore_Unlock_NotProvisioned(t *testing.T) {
+	// given
+	pri := setUpKeyDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyStore := keystore.NewTOTPKeyStore(nil, filepath.Join(heimdall.TestPriKeyDir, "totp"))
+
+	// when
+	loaded, err := keyStore.Unlock(pri.ID(), "password", "000000")
+
+	// then
+	assert.Equal(t, keystore.ErrTOTPNotProvisioned, err)
+	assert.Nil(t, loaded)
+}
+
+func TestTOTPKeyStore_LoadPriKey(t *testing.T) {
+	// given
+	keyStore, _, pri := setUpTOTPKeyStore(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	// when
+	loaded, err := keyStore.LoadPriKey(pri.ID(), "password")
+
+	// then
+	assert.Equal(t, keystore.ErrTOTPRequired, err)
+	assert.Nil(t, loaded)
+}
+
+func TestTOTPKeyStore_LoadPriKeyByPassword(t *testing.T) {
+	// given
+	_, _, pri := setUpTOTPKeyStore(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	plainKeyStore := hecdsa.NewKeyStore(heimdall.TestKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt)
+
+	// when
+	loaded, err := plainKeyStore.LoadPriKey(pri.ID(), "password")
+
+	// then
+	assert.Equal(t, hecdsa.ErrInvalidKeyFileMAC, err)
+	assert.Nil(t, loaded)
+}
+
+func TestTOTPKeyStore_Unlock_LockedAfterRestart(t *testing.T) {
+	// given
+	keyStore, secret, pri := setUpTOTPKeyStore(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	wrongCode, err := keystore.TOTPCode(secret, time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	code, err := keystore.TOTPCode(secret, time.Now())
+	assert.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err = keyStore.Unlock(pri.ID(), "password", wrongCode)
+		assert.Equal(t, keystore.ErrWrongTOTP, err)
+	}
+
+	// when
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+	restarted := keystore.NewTOTPKeyStore(
+		hecdsa.NewKeyStore(heimdall.TestKeyDir, heimdall.TestPubKeyDir, encOpt, kdfOpt),
+		filepath.Join(heimdall.TestPriKeyDir, "totp"),
+	)
+	loaded, err := restarted.Unlock(pri.ID(), "password", code)
+
+	// then
+	assert.Equal(t, keystore.ErrTOTPLocked, err)
+	assert.Nil(t, loaded)
+}
@@ -0,0 +1,341 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides configurable mock CA server for testing certificate issuance and revocation paths.
+
+package mocks
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"golang.org/x/crypto/ocsp"
+)
+
+// paths served by mock CA
+const (
+	CRLPath  = "/crl"
+	OCSPPath = "/ocsp"
+	CSRPath  = "/csr"
+)
+
+var ErrCSRKeyNotSupported = errors.New("invalid CSR - public key of CSR should be ECDSA key")
+var ErrCSRSignatureInvalid = errors.New("invalid CSR - signature of CSR is not valid")
+
+// CA is a mock CA serving CRL, OCSP and CSR signing over httptest server.
+// Revoked certificates, latency and faults can be configured while serving.
+type CA struct {
+	RootCert *x509.Certificate
+	Server   *httptest.Server
+
+	rootPri    *ecdsa.PrivateKey
+	mutex      sync.Mutex
+	serial     int64
+	revoked    []pkix.RevokedCertificate
+	issued     map[string]heimdall.KeyID
+	latency    time.Duration
+	faultCode  int
+	nextUpdate time.Duration
+}
+
+// NewCA makes mock CA with a new self-signed root certificate and starts its server.
+func NewCA() (*CA, error) {
+	rootPri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := TestRootCertTemplate
+	template.SerialNumber = big.NewInt(1)
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour * 24 * 180)
+	template.CRLDistributionPoints = nil
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &rootPri.PublicKey, rootPri)
+	if err != nil {
+		return nil, err
+	}
+
+	rootCert, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ca := &CA{
+		RootCert:   rootCert,
+		rootPri:    rootPri,
+		serial:     1,
+		issued:     make(map[string]heimdall.KeyID),
+		nextUpdate: time.Hour * 24,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(CRLPath, ca.handleCRL)
+	mux.HandleFunc(OCSPPath, ca.handleOCSP)
+	mux.HandleFunc(OCSPPath+"/", ca.handleOCSP)
+	mux.HandleFunc(CSRPath, ca.handleCSR)
+	ca.Server = httptest.NewServer(ca.inject(mux))
+
+	return ca, nil
+}
+
+// Close shuts down server of the CA.
+func (ca *CA) Close() {
+	ca.Server.Close()
+}
+
+// CRLURL returns URL of CRL distribution point of the CA.
+func (ca *CA) CRLURL() string {
+	return ca.Server.URL + CRLPath
+}
+
+// OCSPURL returns URL of OCSP responder of the CA.
+func (ca *CA) OCSPURL() string {
+	return ca.Server.URL + OCSPPath
+}
+
+// CSRURL returns URL where CSR in DER is posted and certificate in DER is returned.
+func (ca *CA) CSRURL() string {
+	return ca.Server.URL + CSRPath
+}
+
+// SetLatency delays every response of the CA.
+func (ca *CA) SetLatency(latency time.Duration) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+
+	ca.latency = latency
+}
+
+// SetFault makes every response of the CA fail with statusCode. (0 to recover)
+func (ca *CA) SetFault(statusCode int) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+
+	ca.faultCode = statusCode
+}
+
+// SetNextUpdate sets how long CRL and OCSP responses of the CA are valid.
+func (ca *CA) SetNextUpdate(nextUpdate time.Duration) {
+	ca.mutex.Lock()
+	defer ca.mutex.Unlock()
+
+	ca.nextUpdate = nextUpdate
+}
+
+// Revoke adds certificate of serialNumber to CRL and OCSP responses.
+func (ca *CA) Revoke(serialNumber *big.Int) {
+	ca.mutex.Lock()
+	ca.revoked = append(ca.revoked, pkix.RevokedCertificate{
+		SerialNumber:   serialNumber,
+		RevocationTime: time.Now(),
+	})
+	keyId := ca.issued[serialNumber.String()]
+	ca.mutex.Unlock()
+
+	event.Publish(event.CertRevoked, keyId, serialNumber.String())
+}
+
+// Issue issues certificate of pub from template, with CRL distribution point and OCSP server of the CA.
+func (ca *CA) Issue(pub crypto.PublicKey, template *x509.Certificate) (*x509.Certificate, error) {
+	ca.mutex.Lock()
+	ca.serial++
+	serial := ca.serial
+	ca.mutex.Unlock()
+
+	certTemplate := *template
+	certTemplate.SerialNumber = big.NewInt(serial)
+	certTemplate.CRLDistributionPoints = []string{ca.CRLURL()}
+	certTemplate.OCSPServer = []string{ca.OCSPURL()}
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &certTemplate, ca.RootCert, pub, ca.rootPri)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(derBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var keyId heimdall.KeyID
+	if ecdsaPub, ok := pub.(*ecdsa.PublicKey); ok {
+		keyId = hecdsa.NewPubKey(ecdsaPub).ID()
+	}
+
+	ca.mutex.Lock()
+	ca.issued[cert.SerialNumber.String()] = keyId
+	ca.mutex.Unlock()
+	event.Publish(event.CertIssued, keyId, cert.SerialNumber.String())
+
+	return cert, nil
+}
+
+// SignCSR issues certificate for certificate signing request in DER.
+func (ca *CA) SignCSR(csrDER []byte) (*x509.Certificate, error) {
+	csr, err := x509.ParseCertificateRequest(csrDER)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = csr.CheckSignature(); err != nil {
+		return nil, ErrCSRSignatureInvalid
+	}
+
+	csrPub, ok := csr.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, ErrCSRKeyNotSupported
+	}
+
+	template := TestCertTemplate
+	template.SubjectKeyId = hecdsa.NewPubKey(csrPub).SKI()
+	template.Subject = csr.Subject
+	template.DNSNames = csr.DNSNames
+	template.IPAddresses = csr.IPAddresses
+	template.EmailAddresses = csr.EmailAddresses
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour * 24 * 180)
+
+	return ca.Issue(csr.PublicKey, &template)
+}
+
+// CRL returns current CRL of the CA in DER.
+func (ca *CA) CRL() ([]byte, error) {
+	ca.mutex.Lock()
+	revoked := append([]pkix.RevokedCertificate{}, ca.revoked...)
+	nextUpdate := ca.nextUpdate
+	ca.mutex.Unlock()
+
+	return ca.RootCert.CreateCRL(rand.Reader, ca.rootPri, revoked, time.Now(), time.Now().Add(nextUpdate))
+}
+
+// inject delays and fails responses as configured.
+func (ca *CA) inject(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ca.mutex.Lock()
+		latency := ca.latency
+		faultCode := ca.faultCode
+		ca.mutex.Unlock()
+
+		time.Sleep(latency)
+
+		if faultCode != 0 {
+			http.Error(w, http.StatusText(faultCode), faultCode)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func (ca *CA) handleCRL(w http.ResponseWriter, r *http.Request) {
+	crlBytes, err := ca.CRL()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pkix-crl")
+	w.Write(crlBytes)
+}
+
+// handleOCSP answers OCSP request in POST body or base64 encoded in GET path.
+func (ca *CA) handleOCSP(w http.ResponseWriter, r *http.Request) {
+	var reqBytes []byte
+	var err error
+
+	if r.Method == http.MethodPost {
+		reqBytes, err = ioutil.ReadAll(r.Body)
+	} else {
+		reqBytes, err = base64.StdEncoding.DecodeString(strings.TrimPrefix(r.URL.Path, OCSPPath+"/"))
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	req, err := ocsp.ParseRequest(reqBytes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ca.mutex.Lock()
+	template := ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: req.SerialNumber,
+		ThisUpdate:   time.Now().Add(-time.Minute),
+		NextUpdate:   time.Now().Add(ca.nextUpdate),
+	}
+
+	for _, revokedCert := range ca.revoked {
+		if revokedCert.SerialNumber.Cmp(req.SerialNumber) == 0 {
+			template.Status = ocsp.Revoked
+			template.RevokedAt = revokedCert.RevocationTime
+			template.RevocationReason = ocsp.Unspecified
+		}
+	}
+	ca.mutex.Unlock()
+
+	respBytes, err := ocsp.CreateResponse(ca.RootCert, ca.RootCert, template, ca.rootPri)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/ocsp-response")
+	w.Write(respBytes)
+}
+
+// handleCSR signs CSR in DER posted to the CA and returns certificate in DER.
+func (ca *CA) handleCSR(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	csrDER, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cert, err := ca.SignCSR(csrDER)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pkix-cert")
+	w.Write(cert.Raw)
+}
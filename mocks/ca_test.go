@@ -0,0 +1,128 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package mocks_test
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/mocks"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ocsp"
+)
+
+func TestCA_OCSP(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	cert, err := testCA.Issue(&pri.PublicKey, &mocks.TestCertTemplate)
+	assert.NoError(t, err)
+	ocspReq, err := ocsp.CreateRequest(cert, testCA.RootCert, nil)
+	assert.NoError(t, err)
+
+	requestStatus := func() int {
+		resp, err := http.Post(cert.OCSPServer[0], "application/ocsp-request", bytes.NewReader(ocspReq))
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, err := ioutil.ReadAll(resp.Body)
+		assert.NoError(t, err)
+		ocspResp, err := ocsp.ParseResponseForCert(body, cert, testCA.RootCert)
+		assert.NoError(t, err)
+
+		return ocspResp.Status
+	}
+
+	// when
+	goodStatus := requestStatus()
+	testCA.Revoke(cert.SerialNumber)
+	revokedStatus := requestStatus()
+
+	// then
+	assert.Equal(t, ocsp.Good, goodStatus)
+	assert.Equal(t, ocsp.Revoked, revokedStatus)
+}
+
+func TestCA_SignCSR(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	pri, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: "it-chain node"},
+		DNSNames: []string{"node.it-chain.io"},
+	}, pri)
+	assert.NoError(t, err)
+
+	// when
+	resp, err := http.Post(testCA.CSRURL(), "application/pkcs10", bytes.NewReader(csr))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	derBytes, err := ioutil.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	// then
+	cert, err := x509.ParseCertificate(derBytes)
+	assert.NoError(t, err)
+	assert.Equal(t, "it-chain node", cert.Subject.CommonName)
+	assert.Equal(t, []string{"node.it-chain.io"}, cert.DNSNames)
+	assert.Equal(t, hecdsa.NewPubKey(&pri.PublicKey).SKI(), cert.SubjectKeyId)
+	assert.NoError(t, cert.CheckSignatureFrom(testCA.RootCert))
+}
+
+func TestCA_Fault(t *testing.T) {
+	// given
+	testCA, err := mocks.NewCA()
+	assert.NoError(t, err)
+	defer testCA.Close()
+
+	testCA.SetLatency(50 * time.Millisecond)
+	testCA.SetFault(http.StatusServiceUnavailable)
+
+	// when
+	start := time.Now()
+	resp, err := http.Get(testCA.CRLURL())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.True(t, time.Since(start) >= 50*time.Millisecond)
+	resp.Body.Close()
+
+	testCA.SetFault(0)
+	resp, err = http.Get(testCA.CRLURL())
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+}
@@ -0,0 +1,50 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides mock certificate verifier whose behaviors are set by functions.
+
+package mocks
+
+import (
+	"crypto/x509"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var _ heimdall.CertVerifier = (*CertVerifier)(nil)
+
+// CertVerifier is a mock certificate verifier. (accepts every certificate if functions are nil)
+type CertVerifier struct {
+	VerifyChainFunc func(cert *x509.Certificate) error
+	VerifyFunc      func(cert *x509.Certificate) error
+}
+
+func (verifier *CertVerifier) VerifyChain(cert *x509.Certificate) error {
+	if verifier.VerifyChainFunc == nil {
+		return nil
+	}
+
+	return verifier.VerifyChainFunc(cert)
+}
+
+func (verifier *CertVerifier) Verify(cert *x509.Certificate) error {
+	if verifier.VerifyFunc == nil {
+		return nil
+	}
+
+	return verifier.VerifyFunc(cert)
+}
@@ -92,41 +92,41 @@ MC4xMAoGCCqGSM49BAMCA0gAMEUCIQD98G1mEzXvYGtLt7MD/Rm998kUSTUSXnuf
 `
 
 const ClientCertForTest = `-----BEGIN CERTIFICATE-----
-MIIC6DCCAo6gAwIBAgIBAzAKBggqhkjOPQQDAjCBjTELMAkGA1UEBhMCS1IxDjAM
+MIIC6TCCApCgAwIBAgIBAzAKBggqhkjOPQQDAjCBjTELMAkGA1UEBhMCS1IxDjAM
 BgNVBAgTBVNlb3VsMRIwEAYDVQQJEwlzdHJlZXQxMjMxDjAMBgNVBBETBTEyMzEy
 MRQwEgYDVQQKEwtpdC1jaGFpbiBjbzEdMBsGA1UECxMUZGV2ZWxvcG1lbnQgZGl2
-aXNpb24xFTATBgNVBAMTDGl0LWNoYWluIGRldjAeFw0xODA4MDQwNjUxMDhaFw0x
-OTAxMzEwNjUxMDhaMIGuMQswCQYDVQQGEwJLUjEOMAwGA1UECBMFU2VvdWwxEjAQ
-BgNVBAkTCXN0cmVldDEyMzEOMAwGA1UEERMFMTIzMTIxFDASBgNVBAoTC2l0LWNo
-YWluIGNvMTkwGwYDVQQLExREZXZlbG9wbWVudCBEaXZpc2lvbjAaBgNVBAsTE0F1
-dGhlbnRpY2F0aW9uIFRlYW0xGjAYBgNVBAMTEWl0LWNoYWluIGRldi1hdXRoMFkw
-EwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEEILSk+W+xC/Y/6LkB1x5CYcLPHaiCru6
-XITSeOy8XdlmH34kqRUvoIPatzQpysBoAsSb5MhydPx7gyWECnYIp6OBuzCBuDAO
-BgNVHQ8BAf8EBAMCBaAwHQYDVR0lBBYwFAYIKwYBBQUHAwEGCCsGAQUFBwMCMAwG
-A1UdEwEB/wQCMAAwKQYDVR0OBCIEIDeghCF6LsfHDuydfp10S7Qwu2zRSHHyi3Pk
-618adNHuMCsGA1UdIwQkMCKAIKRpQd0SRl+uPdWWZlMAmcX8y3WYdTZX0YFQ3J9q
-xYYmMCEGA1UdHwQaMBgwFqAUoBKGEGh0dHA6Ly8xMjcuMC4wLjEwCgYIKoZIzj0E
-AwIDSAAwRQIge7A8ZViOcqGBN/upPM3dmJca4KeucL1g8tYk+E9tOtMCIQCB7M3+
-YPaCDIl3X6u5hBf9zH9Ox9UmHO58Zm5StRksFg==
+aXNpb24xFTATBgNVBAMTDGl0LWNoYWluIGRldjAgFw0xODA4MDQwNjUxMDhaGA8y
+MTE4MDgwNDA2NTEwOFowga4xCzAJBgNVBAYTAktSMQ4wDAYDVQQIEwVTZW91bDES
+MBAGA1UECRMJc3RyZWV0MTIzMQ4wDAYDVQQREwUxMjMxMjEUMBIGA1UEChMLaXQt
+Y2hhaW4gY28xOTAbBgNVBAsTFERldmVsb3BtZW50IERpdmlzaW9uMBoGA1UECxMT
+QXV0aGVudGljYXRpb24gVGVhbTEaMBgGA1UEAxMRaXQtY2hhaW4gZGV2LWF1dGgw
+WTATBgcqhkjOPQIBBggqhkjOPQMBBwNCAAQQgtKT5b7EL9j/ouQHXHkJhws8dqIK
+u7pchNJ47Lxd2WYffiSpFS+gg9q3NCnKwGgCxJvkyHJ0/HuDJYQKdgino4G7MIG4
+MA4GA1UdDwEB/wQEAwIFoDAdBgNVHSUEFjAUBggrBgEFBQcDAQYIKwYBBQUHAwIw
+DAYDVR0TAQH/BAIwADApBgNVHQ4EIgQgN6CEIXoux8cO7J1+nXRLtDC7bNFIcfKL
+c+TrXxp00e4wKwYDVR0jBCQwIoAgpGlB3RJGX6491ZZmUwCZxfzLdZh1NlfRgVDc
+n2rFhiYwIQYDVR0fBBowGDAWoBSgEoYQaHR0cDovLzEyNy4wLjAuMTAKBggqhkjO
+PQQDAgNHADBEAiBrr2ToKpghq5Tq55aVfNVGnH1eS/jKn8ASYMOSJ7ndwgIgMLt1
+Aycs9gwuxktG9GzvK5ylzIiJzBwam6uHywLGm10=
 -----END CERTIFICATE-----
 `
 
 const RevokedCertForTest = `-----BEGIN CERTIFICATE-----
-MIIC5zCCAo6gAwIBAgIBLDAKBggqhkjOPQQDAjCBjTELMAkGA1UEBhMCS1IxDjAM
+MIIC6TCCApCgAwIBAgIBLDAKBggqhkjOPQQDAjCBjTELMAkGA1UEBhMCS1IxDjAM
 BgNVBAgTBVNlb3VsMRIwEAYDVQQJEwlzdHJlZXQxMjMxDjAMBgNVBBETBTEyMzEy
 MRQwEgYDVQQKEwtpdC1jaGFpbiBjbzEdMBsGA1UECxMUZGV2ZWxvcG1lbnQgZGl2
-aXNpb24xFTATBgNVBAMTDGl0LWNoYWluIGRldjAeFw0xODA4MDQwNzAyNTZaFw0x
-OTAxMzEwNzAyNTZaMIGuMQswCQYDVQQGEwJLUjEOMAwGA1UECBMFU2VvdWwxEjAQ
-BgNVBAkTCXN0cmVldDEyMzEOMAwGA1UEERMFMTIzMTIxFDASBgNVBAoTC2l0LWNo
-YWluIGNvMTkwGwYDVQQLExREZXZlbG9wbWVudCBEaXZpc2lvbjAaBgNVBAsTE0F1
-dGhlbnRpY2F0aW9uIFRlYW0xGjAYBgNVBAMTEWl0LWNoYWluIGRldi1hdXRoMFkw
-EwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEmWduiO70gFtlzFj/F2NYkDJ3kF5ct6z0
-A2Lq33O0j3dV9xmQJKBs12YVFpBfia+lrtyIX5nVmRQ6ZvXlnqAUx6OBuzCBuDAO
-BgNVHQ8BAf8EBAMCBaAwHQYDVR0lBBYwFAYIKwYBBQUHAwEGCCsGAQUFBwMCMAwG
-A1UdEwEB/wQCMAAwKQYDVR0OBCIEIKaB6llhw6BzhqVr61rr2zGGDeGeQQYYxSbL
-+eXFEuH7MCsGA1UdIwQkMCKAIKtuiXs7INSR7rHB+m5538DvYZKfolfFjVuSsSSH
-warFMCEGA1UdHwQaMBgwFqAUoBKGEGh0dHA6Ly8xMjcuMC4wLjEwCgYIKoZIzj0E
-AwIDRwAwRAIgGoBo0MBlCBqIAwUzYrfNJejo977xCbah7wiShE7tr+0CIHgl4GM2
-vfD87rXOyKhzaFGf/1xmIQw696moci3YW1XA
+aXNpb24xFTATBgNVBAMTDGl0LWNoYWluIGRldjAgFw0xODA4MDQwNzAyNTZaGA8y
+MTE4MDgwNDA3MDI1Nlowga4xCzAJBgNVBAYTAktSMQ4wDAYDVQQIEwVTZW91bDES
+MBAGA1UECRMJc3RyZWV0MTIzMQ4wDAYDVQQREwUxMjMxMjEUMBIGA1UEChMLaXQt
+Y2hhaW4gY28xOTAbBgNVBAsTFERldmVsb3BtZW50IERpdmlzaW9uMBoGA1UECxMT
+QXV0aGVudGljYXRpb24gVGVhbTEaMBgGA1UEAxMRaXQtY2hhaW4gZGV2LWF1dGgw
+WTATBgcqhkjOPQIBBggqhkjOPQMBBwNCAASZZ26I7vSAW2XMWP8XY1iQMneQXly3
+rPQDYurfc7SPd1X3GZAkoGzXZhUWkF+Jr6Wu3IhfmdWZFDpm9eWeoBTHo4G7MIG4
+MA4GA1UdDwEB/wQEAwIFoDAdBgNVHSUEFjAUBggrBgEFBQcDAQYIKwYBBQUHAwIw
+DAYDVR0TAQH/BAIwADApBgNVHQ4EIgQgpoHqWWHDoHOGpWvrWuvbMYYN4Z5BBhjF
+Jsv55cUS4fswKwYDVR0jBCQwIoAgq26Jezsg1JHuscH6bnnfwO9hkp+iV8WNW5Kx
+JIfBqsUwIQYDVR0fBBowGDAWoBSgEoYQaHR0cDovLzEyNy4wLjAuMTAKBggqhkjO
+PQQDAgNHADBEAiBDPgLzKUxY4+p1k7AWN0A4gS3nXMxJkXQd6gMat1U4HAIgRUUc
+62A7p/yW+VM6ObOlxgkg1AR3ERjQm7SzcjIj11c=
 -----END CERTIFICATE-----
 `
@@ -0,0 +1,130 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides mock keys for testing code depending on heimdall without real crypto.
+
+package mocks
+
+import "github.com/DE-labtory/heimdall"
+
+var _ heimdall.PriKey = (*PriKey)(nil)
+var _ heimdall.PubKey = (*PubKey)(nil)
+
+// KeyGenOpt is a mock key generation option.
+type KeyGenOpt struct {
+	Name string
+	Size int
+}
+
+func (opt *KeyGenOpt) ToString() string {
+	return opt.Name
+}
+
+func (opt *KeyGenOpt) KeySize() int {
+	return opt.Size
+}
+
+func (opt *KeyGenOpt) Algorithm() string {
+	return opt.Name
+}
+
+func (opt *KeyGenOpt) Bits() int {
+	return opt.Size
+}
+
+// PubKey is a mock public key returning its fields.
+type PubKey struct {
+	KeyID     heimdall.KeyID
+	SKIBytes  []byte
+	Bytes     []byte
+	GenOpt    heimdall.KeyGenOpts
+	ToByteErr error
+}
+
+// NewPubKey makes mock public key whose ID is derived from ski.
+func NewPubKey(ski []byte) *PubKey {
+	return &PubKey{
+		KeyID:    heimdall.SKIToKeyID(ski),
+		SKIBytes: ski,
+		Bytes:    ski,
+		GenOpt:   &KeyGenOpt{Name: "MOCK", Size: len(ski)},
+	}
+}
+
+func (pub *PubKey) ID() heimdall.KeyID {
+	return pub.KeyID
+}
+
+func (pub *PubKey) SKI() []byte {
+	return pub.SKIBytes
+}
+
+func (pub *PubKey) ToByte() ([]byte, error) {
+	return pub.Bytes, pub.ToByteErr
+}
+
+func (pub *PubKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return pub.GenOpt
+}
+
+func (pub *PubKey) IsPrivate() bool {
+	return false
+}
+
+// PriKey is a mock private key returning its fields, and recording whether it is cleared.
+type PriKey struct {
+	Pub       *PubKey
+	Bytes     []byte
+	ToByteErr error
+	Cleared   bool
+}
+
+// NewPriKey makes mock private key whose ID is derived from ski.
+func NewPriKey(ski []byte) *PriKey {
+	return &PriKey{
+		Pub:   NewPubKey(ski),
+		Bytes: append([]byte("private-"), ski...),
+	}
+}
+
+func (pri *PriKey) ID() heimdall.KeyID {
+	return pri.Pub.ID()
+}
+
+func (pri *PriKey) SKI() []byte {
+	return pri.Pub.SKI()
+}
+
+func (pri *PriKey) ToByte() ([]byte, error) {
+	return pri.Bytes, pri.ToByteErr
+}
+
+func (pri *PriKey) KeyGenOpt() heimdall.KeyGenOpts {
+	return pri.Pub.KeyGenOpt()
+}
+
+func (pri *PriKey) IsPrivate() bool {
+	return true
+}
+
+func (pri *PriKey) Clear() {
+	pri.Cleared = true
+}
+
+func (pri *PriKey) PublicKey() heimdall.PubKey {
+	return pri.Pub
+}
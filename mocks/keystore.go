@@ -0,0 +1,121 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides in-memory keystore and mock key derivation function.
+
+package mocks
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var _ heimdall.KeyStore = (*KeyStore)(nil)
+var _ heimdall.KDF = (*KDF)(nil)
+
+var ErrKeyNotExist = errors.New("key not exist in mock keystore")
+var ErrWrongPassword = errors.New("wrong password for mock keystore")
+
+type storedPriKey struct {
+	pri heimdall.PriKey
+	pwd string
+}
+
+// KeyStore is an in-memory keystore checking password of private keys.
+type KeyStore struct {
+	mutex   sync.Mutex
+	priKeys map[heimdall.KeyID]*storedPriKey
+	pubKeys map[heimdall.KeyID]heimdall.PubKey
+}
+
+func NewKeyStore() *KeyStore {
+	return &KeyStore{
+		priKeys: make(map[heimdall.KeyID]*storedPriKey),
+		pubKeys: make(map[heimdall.KeyID]heimdall.PubKey),
+	}
+}
+
+func (keyStore *KeyStore) StorePriKey(pri heimdall.PriKey, pwd string) error {
+	keyStore.mutex.Lock()
+	defer keyStore.mutex.Unlock()
+
+	keyStore.priKeys[pri.ID()] = &storedPriKey{pri: pri, pwd: pwd}
+
+	return nil
+}
+
+func (keyStore *KeyStore) LoadPriKey(keyId heimdall.KeyID, pwd string) (heimdall.PriKey, error) {
+	keyStore.mutex.Lock()
+	defer keyStore.mutex.Unlock()
+
+	stored, exists := keyStore.priKeys[keyId]
+	if !exists {
+		return nil, ErrKeyNotExist
+	}
+
+	if stored.pwd != pwd {
+		return nil, ErrWrongPassword
+	}
+
+	return stored.pri, nil
+}
+
+func (keyStore *KeyStore) StorePubKey(pub heimdall.PubKey) error {
+	keyStore.mutex.Lock()
+	defer keyStore.mutex.Unlock()
+
+	keyStore.pubKeys[pub.ID()] = pub
+
+	return nil
+}
+
+func (keyStore *KeyStore) LoadPubKey(keyId heimdall.KeyID) (heimdall.PubKey, error) {
+	keyStore.mutex.Lock()
+	defer keyStore.mutex.Unlock()
+
+	pub, exists := keyStore.pubKeys[keyId]
+	if !exists {
+		return nil, ErrKeyNotExist
+	}
+
+	return pub, nil
+}
+
+// KDF is a mock key derivation function calling DeriveFunc. (repeats password and salt if DeriveFunc is nil)
+type KDF struct {
+	DeriveFunc func(pwd, salt []byte, keyLen int) ([]byte, error)
+}
+
+func (kdf *KDF) DeriveKey(pwd, salt []byte, keyLen int) ([]byte, error) {
+	if kdf.DeriveFunc != nil {
+		return kdf.DeriveFunc(pwd, salt, keyLen)
+	}
+
+	material := append(append([]byte{}, pwd...), salt...)
+	if len(material) == 0 {
+		material = []byte{0}
+	}
+
+	dKey := make([]byte, keyLen/8)
+	for i := range dKey {
+		dKey[i] = material[i%len(material)]
+	}
+
+	return dKey, nil
+}
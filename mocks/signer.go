@@ -0,0 +1,59 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides mock signer and verifier whose behaviors are set by functions.
+
+package mocks
+
+import "github.com/DE-labtory/heimdall"
+
+var _ heimdall.Signer = (*Signer)(nil)
+var _ heimdall.Verifier = (*Verifier)(nil)
+
+// Signer is a mock signer calling SignFunc. (returns message as signature if SignFunc is nil)
+type Signer struct {
+	ID       heimdall.KeyID
+	SignFunc func(message []byte, opts heimdall.SignerOpts) ([]byte, error)
+	Calls    [][]byte
+}
+
+func (signer *Signer) KeyID() heimdall.KeyID {
+	return signer.ID
+}
+
+func (signer *Signer) Sign(message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	signer.Calls = append(signer.Calls, message)
+
+	if signer.SignFunc == nil {
+		return message, nil
+	}
+
+	return signer.SignFunc(message, opts)
+}
+
+// Verifier is a mock verifier calling VerifyFunc. (returns true if VerifyFunc is nil)
+type Verifier struct {
+	VerifyFunc func(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error)
+}
+
+func (verifier *Verifier) Verify(pub heimdall.PubKey, signature, message []byte, opts heimdall.SignerOpts) (bool, error) {
+	if verifier.VerifyFunc == nil {
+		return true, nil
+	}
+
+	return verifier.VerifyFunc(pub, signature, message, opts)
+}
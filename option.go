@@ -16,23 +16,318 @@
 
 package heimdall
 
-import "github.com/DE-labtory/heimdall/hashing"
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/DE-labtory/heimdall/hashing"
+)
 
 type KeyID = string
 
 var OptDelimiter = "_"
 
+var ErrUnknownKeyType = errors.New("unknown key type - key type should be like ECDSA_P-256, RSA_2048, ED25519, BLS12381, X25519, P-256 or RSA2048")
+var ErrInvalidKeyType = errors.New("invalid key type - curve or bit length is not valid for the algorithm")
+var ErrSchemeMismatch = errors.New("scheme mismatch - signer option parameter is not valid for the key algorithm")
+var ErrInvalidSaltLength = errors.New("invalid salt length - PSS salt length should not be negative")
+
+// key algorithm families
+const (
+	ECDSA   = "ECDSA"
+	RSA     = "RSA"
+	ED25519 = "ED25519"
+	// BLS12381 is BLS signature on BLS12-381 curve, whose signatures can be aggregated.
+	BLS12381 = "BLS12381"
+	// DILITHIUM is experimental post-quantum signature of CRYSTALS-Dilithium, whose BitLen is the mode (2, 3 or 5).
+	DILITHIUM = "DILITHIUM"
+	// SM2 is signature of Chinese national standard (GM/T 0003) on its 256 bits curve, with SM3 hash.
+	SM2 = "SM2"
+	// X25519 is Diffie-Hellman key agreement on Curve25519 (RFC 7748). Its keys can not make signatures.
+	X25519 = "X25519"
+)
+
 // options
 
 // KeyGenOpts provides key generation options such as elliptic curve or rsa bits etc.
 type KeyGenOpts interface {
+	// ToString returns backward compatible string of the option. (ex. P-256, RSA2048)
 	ToString() string
 	KeySize() int
+	// Algorithm returns algorithm family of the key. (ex. ECDSA, RSA)
+	Algorithm() string
+	// Bits returns bit length of the curve or RSA modulus.
+	Bits() int
+}
+
+// supported RSA modulus bit lengths
+var rsaBits = map[int]bool{
+	1024: true,
+	2048: true,
+	3072: true,
+	4096: true,
+}
+
+// security strength in bits of Dilithium modes, by NIST PQC security category
+var dilithiumSecurityBits = map[int]int{
+	2: 128,
+	3: 192,
+	5: 256,
+}
+
+// KeyType is a structured key generation option of algorithm family and curve or bit length.
+type KeyType struct {
+	Family string
+	Curve  string
+	BitLen int
+}
+
+// ParseKeyType parses canonical string of key type (ex. ECDSA_P-256, RSA_2048),
+// or backward compatible string (ex. P-256, RSA2048) which ToString of key generation options returns.
+func ParseKeyType(str string) (*KeyType, error) {
+	parts := strings.SplitN(str, OptDelimiter, 2)
+	if len(parts) == 1 {
+		return parseLegacyKeyType(str)
+	}
+
+	keyType := &KeyType{Family: strings.ToUpper(parts[0])}
+	switch keyType.Family {
+	case ECDSA:
+		keyType.Curve = strings.ToUpper(parts[1])
+		if curve, ok := lookupCurve(keyType.Curve); ok {
+			keyType.Curve, keyType.BitLen = curve.name, curve.bitLen
+		}
+	case RSA, DILITHIUM:
+		bits, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return nil, ErrUnknownKeyType
+		}
+		keyType.BitLen = bits
+	default:
+		return nil, ErrUnknownKeyType
+	}
+
+	return keyType, keyType.Validate()
+}
+
+func parseLegacyKeyType(str string) (*KeyType, error) {
+	upper := strings.ToUpper(str)
+
+	if curve, ok := lookupCurve(upper); ok {
+		return &KeyType{Family: ECDSA, Curve: curve.name, BitLen: curve.bitLen}, nil
+	}
+
+	if upper == ED25519 {
+		return &KeyType{Family: ED25519, BitLen: 256}, nil
+	}
+
+	if upper == BLS12381 {
+		return &KeyType{Family: BLS12381, BitLen: 255}, nil
+	}
+
+	if upper == SM2 {
+		return &KeyType{Family: SM2, BitLen: 256}, nil
+	}
+
+	if upper == X25519 {
+		return &KeyType{Family: X25519, BitLen: 256}, nil
+	}
+
+	if strings.HasPrefix(upper, DILITHIUM) {
+		mode, err := strconv.Atoi(strings.TrimPrefix(upper, DILITHIUM))
+		if err != nil {
+			return nil, ErrUnknownKeyType
+		}
+		keyType := &KeyType{Family: DILITHIUM, BitLen: mode}
+		return keyType, keyType.Validate()
+	}
+
+	if strings.HasPrefix(upper, RSA) {
+		bits, err := strconv.Atoi(strings.TrimPrefix(upper, RSA))
+		if err != nil {
+			return nil, ErrUnknownKeyType
+		}
+		keyType := &KeyType{Family: RSA, BitLen: bits}
+		return keyType, keyType.Validate()
+	}
+
+	return nil, ErrUnknownKeyType
+}
+
+// KeyTypeOf makes structured key type of key generation option.
+func KeyTypeOf(opts KeyGenOpts) (*KeyType, error) {
+	if keyType, ok := opts.(*KeyType); ok {
+		return keyType, keyType.Validate()
+	}
+
+	return ParseKeyType(opts.ToString())
+}
+
+// Validate checks if curve or bit length is valid for the algorithm family.
+func (keyType *KeyType) Validate() error {
+	switch keyType.Family {
+	case ECDSA:
+		if curve, ok := lookupCurve(keyType.Curve); !ok || curve.bitLen != keyType.BitLen {
+			return ErrInvalidKeyType
+		}
+	case RSA:
+		if keyType.Curve != "" || !rsaBits[keyType.BitLen] {
+			return ErrInvalidKeyType
+		}
+	case ED25519, SM2, X25519:
+		if keyType.Curve != "" || keyType.BitLen != 256 {
+			return ErrInvalidKeyType
+		}
+	case BLS12381:
+		// bit length of the scalar field order
+		if keyType.Curve != "" || keyType.BitLen != 255 {
+			return ErrInvalidKeyType
+		}
+	case DILITHIUM:
+		if _, ok := dilithiumSecurityBits[keyType.BitLen]; keyType.Curve != "" || !ok {
+			return ErrInvalidKeyType
+		}
+	default:
+		return ErrUnknownKeyType
+	}
+
+	return nil
+}
+
+// String returns canonical string of key type. (ex. ECDSA_P-256, RSA_2048)
+func (keyType *KeyType) String() string {
+	switch keyType.Family {
+	case ECDSA:
+		return keyType.Family + OptDelimiter + keyType.Curve
+	case ED25519, BLS12381, SM2, X25519:
+		return keyType.Family
+	}
+
+	return keyType.Family + OptDelimiter + strconv.Itoa(keyType.BitLen)
 }
 
-// SignerOpts provides signer option for signing
+// ToString returns backward compatible string of key type, which is stored in key files. (ex. P-256, RSA2048)
+func (keyType *KeyType) ToString() string {
+	switch keyType.Family {
+	case ECDSA:
+		return keyType.Curve
+	case ED25519, BLS12381, SM2, X25519:
+		return keyType.Family
+	}
+
+	return keyType.Family + strconv.Itoa(keyType.BitLen)
+}
+
+func (keyType *KeyType) KeySize() int {
+	return keyType.BitLen
+}
+
+func (keyType *KeyType) Algorithm() string {
+	return keyType.Family
+}
+
+func (keyType *KeyType) Bits() int {
+	return keyType.BitLen
+}
+
+// SecurityBits returns approximate security strength of the key in bits. (NIST SP 800-57)
+// RSA keys longer than 3072 bits are regarded as 128 bits, since they do not reach the next level (192 bits).
+func (keyType *KeyType) SecurityBits() int {
+	if keyType.Family == DILITHIUM {
+		return dilithiumSecurityBits[keyType.BitLen]
+	}
+
+	if keyType.Family == RSA {
+		switch {
+		case keyType.BitLen >= 3072:
+			return 128
+		case keyType.BitLen >= 2048:
+			return 112
+		default:
+			return 80
+		}
+	}
+
+	return keyType.BitLen / 2
+}
+
+// DefaultHashOpt selects hash option matching security strength of the key, so that hash does not weaken or waste the key.
+func DefaultHashOpt(keyGenOpts KeyGenOpts) (*hashing.HashOpt, error) {
+	keyType, err := KeyTypeOf(keyGenOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	switch securityBits := keyType.SecurityBits(); {
+	case securityBits <= 128:
+		return hashing.NewHashOpt(hashing.SHA256)
+	case securityBits <= 192:
+		return hashing.NewHashOpt(hashing.SHA384)
+	default:
+		return hashing.NewHashOpt(hashing.SHA512)
+	}
+}
+
+// SignerOpts provides signer option for signing.
+// HashOpt may return nil, then hash option is selected by the key. (see HashOptOf)
 type SignerOpts interface {
 	Algorithm() string
 	HashOpt() *hashing.HashOpt
 	//crypto.SignerOpts
 }
+
+// HashOptOf returns hash option of signer option, or default hash option of the key if signer option does not specify it.
+func HashOptOf(opts SignerOpts, key Key) (*hashing.HashOpt, error) {
+	if opts != nil && opts.HashOpt() != nil {
+		return opts.HashOpt(), nil
+	}
+
+	return DefaultHashOpt(key.KeyGenOpt())
+}
+
+// SchemeParams carries parameters of signature schemes which need more than hash function.
+type SchemeParams struct {
+	// PSSSaltLength is salt length of RSA-PSS signature in bytes. Zero means PKCS #1 v1.5 signature.
+	PSSSaltLength int
+	// Ed25519ph selects pre-hashed variant of Ed25519. (RFC 8032)
+	Ed25519ph bool
+	// Schnorr selects Schnorr signature on the curve of ECDSA key, whose signatures of several signers can be aggregated.
+	Schnorr bool
+}
+
+// SchemeSignerOpts is implemented by signer options which carry scheme parameters.
+type SchemeSignerOpts interface {
+	SignerOpts
+	SchemeParams() *SchemeParams
+}
+
+// SchemeParamsOf returns scheme parameters of signer option, or empty parameters if signer option does not carry them.
+func SchemeParamsOf(opts SignerOpts) *SchemeParams {
+	if schemeOpts, ok := opts.(SchemeSignerOpts); ok && schemeOpts.SchemeParams() != nil {
+		return schemeOpts.SchemeParams()
+	}
+
+	return &SchemeParams{}
+}
+
+// Validate checks if scheme parameters can be used with the key algorithm.
+func (params *SchemeParams) Validate(keyGenOpts KeyGenOpts) error {
+	if params.PSSSaltLength < 0 {
+		return ErrInvalidSaltLength
+	}
+
+	if params.PSSSaltLength != 0 && (keyGenOpts == nil || keyGenOpts.Algorithm() != RSA) {
+		return ErrSchemeMismatch
+	}
+
+	if params.Ed25519ph && (keyGenOpts == nil || keyGenOpts.Algorithm() != ED25519) {
+		return ErrSchemeMismatch
+	}
+
+	if params.Schnorr && (keyGenOpts == nil || keyGenOpts.Algorithm() != ECDSA) {
+		return ErrSchemeMismatch
+	}
+
+	return nil
+}
@@ -0,0 +1,173 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package heimdall_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseKeyType(t *testing.T) {
+	for str, expected := range map[string]*heimdall.KeyType{
+		"ECDSA_P-256": {Family: heimdall.ECDSA, Curve: "P-256", BitLen: 256},
+		"ecdsa_p-384": {Family: heimdall.ECDSA, Curve: "P-384", BitLen: 384},
+		"P-521":       {Family: heimdall.ECDSA, Curve: "P-521", BitLen: 521},
+		"RSA_2048":    {Family: heimdall.RSA, BitLen: 2048},
+		"RSA4096":     {Family: heimdall.RSA, BitLen: 4096},
+		"DILITHIUM_2": {Family: heimdall.DILITHIUM, BitLen: 2},
+		"dilithium5":  {Family: heimdall.DILITHIUM, BitLen: 5},
+		"sm2":         {Family: heimdall.SM2, BitLen: 256},
+		"x25519":      {Family: heimdall.X25519, BitLen: 256},
+	} {
+		// when
+		keyType, err := heimdall.ParseKeyType(str)
+
+		// then
+		assert.NoError(t, err, str)
+		assert.Equal(t, expected, keyType, str)
+	}
+}
+
+func TestParseKeyType_Invalid(t *testing.T) {
+	for str, expected := range map[string]error{
+		"ECDSA_P-255": heimdall.ErrInvalidKeyType,
+		"RSA_1000":    heimdall.ErrInvalidKeyType,
+		"RSA512":      heimdall.ErrInvalidKeyType,
+		"RSA_big":     heimdall.ErrUnknownKeyType,
+		"DILITHIUM4":  heimdall.ErrInvalidKeyType,
+		"DSA_1024":    heimdall.ErrUnknownKeyType,
+		"secp224k1":   heimdall.ErrUnknownKeyType,
+	} {
+		// when
+		_, err := heimdall.ParseKeyType(str)
+
+		// then
+		assert.Equal(t, expected, err, str)
+	}
+}
+
+func TestKeyType_String(t *testing.T) {
+	// given
+	ecdsaType, err := heimdall.ParseKeyType("P-256")
+	assert.NoError(t, err)
+	rsaType, err := heimdall.ParseKeyType("RSA2048")
+	assert.NoError(t, err)
+
+	// then
+	assert.Equal(t, "ECDSA_P-256", ecdsaType.String())
+	assert.Equal(t, "P-256", ecdsaType.ToString())
+	assert.Equal(t, "RSA_2048", rsaType.String())
+	assert.Equal(t, "RSA2048", rsaType.ToString())
+	assert.Equal(t, heimdall.RSA, rsaType.Algorithm())
+	assert.Equal(t, 2048, rsaType.Bits())
+}
+
+func TestKeyTypeOf(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+
+	// when
+	keyType, err := heimdall.KeyTypeOf(keyGenOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "ECDSA_P-384", keyType.String())
+	assert.Equal(t, keyGenOpt.Algorithm(), keyType.Algorithm())
+	assert.Equal(t, keyGenOpt.Bits(), keyType.Bits())
+
+	// when generating key with structured key type
+	pri, err := hecdsa.GenerateKey(keyType)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hecdsa.ECP384, pri.KeyGenOpt().ToString())
+
+	_, err = hecdsa.GenerateKey(&heimdall.KeyType{Family: heimdall.RSA, BitLen: 2048})
+	assert.Equal(t, hecdsa.ErrCurveNotSupported, err)
+}
+
+func TestDefaultHashOpt(t *testing.T) {
+	for str, expected := range map[string]string{
+		"P-224":      hashing.SHA256,
+		"P-256":      hashing.SHA256,
+		"P-384":      hashing.SHA384,
+		"P-521":      hashing.SHA512,
+		"RSA_2048":   hashing.SHA256,
+		"RSA_4096":   hashing.SHA256,
+		"DILITHIUM3": hashing.SHA384,
+	} {
+		// given
+		keyType, err := heimdall.ParseKeyType(str)
+		assert.NoError(t, err, str)
+
+		// when
+		hashOpt, err := heimdall.DefaultHashOpt(keyType)
+
+		// then
+		assert.NoError(t, err, str)
+		assert.Equal(t, expected, hashOpt.Name, str)
+	}
+}
+
+func TestHashOptOf(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	sha512Opt, err := hashing.NewHashOpt(hashing.SHA512)
+	assert.NoError(t, err)
+
+	// when
+	autoHashOpt, autoErr := heimdall.HashOptOf(hecdsa.NewSignerOpts(nil), pri)
+	hashOpt, err := heimdall.HashOptOf(hecdsa.NewSignerOpts(sha512Opt), pri)
+
+	// then
+	assert.NoError(t, autoErr)
+	assert.Equal(t, hashing.SHA384, autoHashOpt.Name)
+	assert.NoError(t, err)
+	assert.Equal(t, sha512Opt, hashOpt)
+}
+
+func TestSchemeParams_Validate(t *testing.T) {
+	// given
+	ecKeyType := &heimdall.KeyType{Family: heimdall.ECDSA, Curve: "P-256", BitLen: 256}
+	rsaKeyType := &heimdall.KeyType{Family: heimdall.RSA, BitLen: 2048}
+
+	// when, then
+	assert.NoError(t, (&heimdall.SchemeParams{}).Validate(ecKeyType))
+	assert.NoError(t, (&heimdall.SchemeParams{PSSSaltLength: 32}).Validate(rsaKeyType))
+	assert.Equal(t, heimdall.ErrSchemeMismatch, (&heimdall.SchemeParams{PSSSaltLength: 32}).Validate(ecKeyType))
+	assert.Equal(t, heimdall.ErrSchemeMismatch, (&heimdall.SchemeParams{Ed25519ph: true}).Validate(rsaKeyType))
+	assert.NoError(t, (&heimdall.SchemeParams{Schnorr: true}).Validate(ecKeyType))
+	assert.Equal(t, heimdall.ErrSchemeMismatch, (&heimdall.SchemeParams{Schnorr: true}).Validate(rsaKeyType))
+	assert.Equal(t, heimdall.ErrInvalidSaltLength, (&heimdall.SchemeParams{PSSSaltLength: -1}).Validate(rsaKeyType))
+}
+
+func TestSchemeParamsOf(t *testing.T) {
+	// when
+	params := heimdall.SchemeParamsOf(hecdsa.NewSignerOpts(nil))
+
+	// then
+	assert.Equal(t, &heimdall.SchemeParams{}, params)
+}
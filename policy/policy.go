@@ -0,0 +1,204 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides policy engine which gates signing requests by rules.
+
+package policy
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+// Approval is a signature of an approver on approval digest of a message requested to be signed. (see ApprovalDigest)
+type Approval struct {
+	KeyID     heimdall.KeyID
+	Signature []byte
+	Expiry    time.Time
+}
+
+// approvalDomain separates approval digests from signatures of approver keys on other data.
+const approvalDomain = "heimdall policy approval"
+
+// ApprovalDigest returns digest which approvers sign to approve signing message by the key with hash option until
+// expiry. Name of hash option is empty if hash is selected by the key. It binds key ID, hash option, message and
+// expiry, so that an approval can not be used for other keys or after expiry, and ordinary signatures of approvers
+// on the message do not count as approvals.
+func ApprovalDigest(keyId heimdall.KeyID, hashOpt string, message []byte, expiry time.Time) []byte {
+	digest := sha256.New()
+	for _, field := range [][]byte{
+		[]byte(approvalDomain),
+		[]byte(keyId),
+		[]byte(hashOpt),
+		message,
+	} {
+		length := make([]byte, 8)
+		binary.BigEndian.PutUint64(length, uint64(len(field)))
+		digest.Write(length)
+		digest.Write(field)
+	}
+
+	expiryBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(expiryBytes, uint64(expiry.UnixNano()))
+	digest.Write(expiryBytes)
+
+	return digest.Sum(nil)
+}
+
+// Request is a signing request evaluated by policy.
+type Request struct {
+	KeyID     heimdall.KeyID
+	Message   []byte
+	Opts      heimdall.SignerOpts
+	Time      time.Time
+	Approvals []*Approval
+}
+
+// Rule evaluates a signing request, and returns error if the request violates the rule.
+type Rule interface {
+	Evaluate(req *Request) error
+}
+
+// Reserver is a rule which holds a slot for a request while the request is signed. (ex. rate ceiling)
+// Reserve evaluates the request and takes the slot at once, and Release gives back the slot of a request
+// whose signature is not released.
+type Reserver interface {
+	Rule
+	Reserve(req *Request) error
+	Release(req *Request)
+}
+
+// RuleFunc is an adapter to use ordinary function as a rule.
+type RuleFunc func(req *Request) error
+
+func (f RuleFunc) Evaluate(req *Request) error {
+	return f(req)
+}
+
+// Policy is a set of rules which every signing request should satisfy.
+type Policy struct {
+	mutex sync.Mutex
+	rules []Rule
+}
+
+func NewPolicy(rules ...Rule) *Policy {
+	return &Policy{rules: rules}
+}
+
+// Evaluate evaluates request by rules in order, and returns the first violation. Evaluate does not reserve
+// slots of Reserver rules, so use Reserve for a request to be signed.
+func (policy *Policy) Evaluate(req *Request) error {
+	policy.mutex.Lock()
+	defer policy.mutex.Unlock()
+
+	for _, rule := range policy.rules {
+		if err := rule.Evaluate(req); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Reserve evaluates request by rules in order and reserves slots of Reserver rules in the same step, so concurrent
+// requests can not pass a rule on the same slot. If a rule is violated, slots reserved for the request are released.
+func (policy *Policy) Reserve(req *Request) error {
+	policy.mutex.Lock()
+	defer policy.mutex.Unlock()
+
+	for i, rule := range policy.rules {
+		var err error
+		if reserver, ok := rule.(Reserver); ok {
+			err = reserver.Reserve(req)
+		} else {
+			err = rule.Evaluate(req)
+		}
+
+		if err != nil {
+			policy.release(req, policy.rules[:i])
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Release releases slots reserved for request whose signature is not released.
+func (policy *Policy) Release(req *Request) {
+	policy.mutex.Lock()
+	defer policy.mutex.Unlock()
+
+	policy.release(req, policy.rules)
+}
+
+func (policy *Policy) release(req *Request, rules []Rule) {
+	for _, rule := range rules {
+		if reserver, ok := rule.(Reserver); ok {
+			reserver.Release(req)
+		}
+	}
+}
+
+// Signer is an implementation of heimdall Signer which releases signature only if policy is satisfied.
+type Signer struct {
+	signer heimdall.Signer
+	policy *Policy
+}
+
+func NewSigner(signer heimdall.Signer, policy *Policy) *Signer {
+	return &Signer{
+		signer: signer,
+		policy: policy,
+	}
+}
+
+func (signer *Signer) KeyID() heimdall.KeyID {
+	return signer.signer.KeyID()
+}
+
+// Sign signs message if the request without approvals satisfies policy.
+func (signer *Signer) Sign(message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	return signer.SignWithApprovals(message, opts, nil)
+}
+
+// SignWithApprovals signs message if the request with approvals satisfies policy.
+// Slots reserved for the request are released if signing fails.
+func (signer *Signer) SignWithApprovals(message []byte, opts heimdall.SignerOpts, approvals []*Approval) ([]byte, error) {
+	req := &Request{
+		KeyID:     signer.signer.KeyID(),
+		Message:   message,
+		Opts:      opts,
+		Time:      time.Now(),
+		Approvals: approvals,
+	}
+
+	if err := signer.policy.Reserve(req); err != nil {
+		return nil, err
+	}
+
+	signature, err := signer.signer.Sign(message, opts)
+	if err != nil {
+		signer.policy.Release(req)
+		return nil, err
+	}
+
+	return signature, nil
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package policy_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Evaluate(t *testing.T) {
+	// given
+	denyErr := errors.New("deny")
+	allow := policy.RuleFunc(func(req *policy.Request) error { return nil })
+	deny := policy.RuleFunc(func(req *policy.Request) error { return denyErr })
+
+	// when
+	allowErr := policy.NewPolicy(allow, allow).Evaluate(&policy.Request{})
+	err := policy.NewPolicy(allow, deny).Evaluate(&policy.Request{})
+
+	// then
+	assert.NoError(t, allowErr)
+	assert.Equal(t, denyErr, err)
+}
+
+func TestSigner_Sign(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	signer := policy.NewSigner(hecdsa.NewSigner(pri), policy.NewPolicy(policy.AllowedPrefixes([]byte("block:"))))
+
+	// when
+	signature, err := signer.Sign([]byte("block:1"), signerOpt)
+	deniedSignature, deniedErr := signer.Sign([]byte("config:1"), signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), signer.KeyID())
+	valid, err := hecdsa.Verify(pri.PublicKey(), signature, []byte("block:1"), signerOpt)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	assert.Nil(t, deniedSignature)
+	assert.Equal(t, policy.ErrMessageNotAllowed, deniedErr)
+}
+
+func TestSigner_Sign_CountOnlyReleased(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	signer := policy.NewSigner(hecdsa.NewSigner(pri), policy.NewPolicy(
+		policy.RateCeiling(1, time.Minute),
+		policy.AllowedPrefixes([]byte("block:")),
+	))
+
+	// when
+	_, deniedErr := signer.Sign([]byte("config:1"), signerOpt)
+	_, err = signer.Sign([]byte("block:1"), signerOpt)
+	_, exceededErr := signer.Sign([]byte("block:2"), signerOpt)
+
+	// then
+	assert.Equal(t, policy.ErrMessageNotAllowed, deniedErr)
+	assert.NoError(t, err)
+	assert.Equal(t, policy.ErrRateExceeded, exceededErr)
+}
+
+// failingSigner fails signing while fail is set.
+type failingSigner struct {
+	heimdall.Signer
+	fail bool
+}
+
+func (signer *failingSigner) Sign(message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	if signer.fail {
+		return nil, errors.New("sign failed")
+	}
+
+	return signer.Signer.Sign(message, opts)
+}
+
+func TestSigner_Sign_ReleaseOnFailure(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	internalSigner := &failingSigner{Signer: hecdsa.NewSigner(pri), fail: true}
+	signer := policy.NewSigner(internalSigner, policy.NewPolicy(policy.RateCeiling(1, time.Minute)))
+
+	// when
+	_, failedErr := signer.Sign([]byte("block:1"), signerOpt)
+	internalSigner.fail = false
+	_, err = signer.Sign([]byte("block:1"), signerOpt)
+	_, exceededErr := signer.Sign([]byte("block:2"), signerOpt)
+
+	// then
+	assert.Error(t, failedErr)
+	assert.NoError(t, err)
+	assert.Equal(t, policy.ErrRateExceeded, exceededErr)
+}
+
+func TestSigner_Sign_Concurrent(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	max := 5
+	signer := policy.NewSigner(hecdsa.NewSigner(pri), policy.NewPolicy(policy.RateCeiling(max, time.Hour)))
+
+	// when
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	released := 0
+	for i := 0; i < 4*max; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := signer.Sign([]byte("block:1"), signerOpt); err == nil {
+				mutex.Lock()
+				released++
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// then
+	assert.Equal(t, max, released)
+}
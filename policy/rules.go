@@ -0,0 +1,202 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides built-in rules of policy engine.
+
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrMessageNotAllowed = errors.New("policy violation - message type is not allowed")
+var ErrOutsideTimeWindow = errors.New("policy violation - signing is not allowed at this time")
+var ErrRateExceeded = errors.New("policy violation - signing rate ceiling is exceeded")
+var ErrNotEnoughApprovals = errors.New("policy violation - not enough approvals")
+
+// AllowedPrefixes allows only messages which start with one of prefixes. (ex. message type header)
+func AllowedPrefixes(prefixes ...[]byte) Rule {
+	return RuleFunc(func(req *Request) error {
+		for _, prefix := range prefixes {
+			if bytes.HasPrefix(req.Message, prefix) {
+				return nil
+			}
+		}
+
+		return ErrMessageNotAllowed
+	})
+}
+
+// AllowedDigestPrefixes allows only messages whose digest by hash option of request starts with one of prefixes.
+// Requests without explicit hash option are not allowed, since the digest depends on the hash selected by the key.
+func AllowedDigestPrefixes(prefixes ...[]byte) Rule {
+	return RuleFunc(func(req *Request) error {
+		if req.Opts == nil || req.Opts.HashOpt() == nil {
+			return ErrMessageNotAllowed
+		}
+
+		digest, err := hashing.Hash(req.Message, req.Opts.HashOpt())
+		if err != nil {
+			return err
+		}
+
+		for _, prefix := range prefixes {
+			if bytes.HasPrefix(digest, prefix) {
+				return nil
+			}
+		}
+
+		return ErrMessageNotAllowed
+	})
+}
+
+// TimeWindow allows signing only between from and to of a day in UTC. (ex. 9*time.Hour ~ 18*time.Hour)
+// The window wraps around midnight if from is later than to.
+func TimeWindow(from, to time.Duration) Rule {
+	return RuleFunc(func(req *Request) error {
+		reqTime := req.Time.UTC()
+		sinceMidnight := reqTime.Sub(time.Date(reqTime.Year(), reqTime.Month(), reqTime.Day(), 0, 0, 0, 0, time.UTC))
+
+		if from <= to {
+			if sinceMidnight >= from && sinceMidnight < to {
+				return nil
+			}
+		} else if sinceMidnight >= from || sinceMidnight < to {
+			return nil
+		}
+
+		return ErrOutsideTimeWindow
+	})
+}
+
+// rateCeiling limits the number of signatures in a sliding time window.
+// Request is counted when it is reserved, and released requests do not consume the ceiling.
+type rateCeiling struct {
+	mutex      sync.Mutex
+	max        int
+	window     time.Duration
+	timestamps []time.Time
+}
+
+// RateCeiling allows at most max signatures in the window.
+func RateCeiling(max int, window time.Duration) Rule {
+	return &rateCeiling{
+		max:    max,
+		window: window,
+	}
+}
+
+func (rule *rateCeiling) Evaluate(req *Request) error {
+	rule.mutex.Lock()
+	defer rule.mutex.Unlock()
+
+	rule.dropExpired(req.Time)
+
+	if len(rule.timestamps) >= rule.max {
+		return ErrRateExceeded
+	}
+
+	return nil
+}
+
+func (rule *rateCeiling) Reserve(req *Request) error {
+	rule.mutex.Lock()
+	defer rule.mutex.Unlock()
+
+	rule.dropExpired(req.Time)
+
+	if len(rule.timestamps) >= rule.max {
+		return ErrRateExceeded
+	}
+	rule.timestamps = append(rule.timestamps, req.Time)
+
+	return nil
+}
+
+func (rule *rateCeiling) Release(req *Request) {
+	rule.mutex.Lock()
+	defer rule.mutex.Unlock()
+
+	for i, timestamp := range rule.timestamps {
+		if timestamp.Equal(req.Time) {
+			rule.timestamps = append(rule.timestamps[:i], rule.timestamps[i+1:]...)
+			return
+		}
+	}
+}
+
+// dropExpired drops timestamps out of window.
+func (rule *rateCeiling) dropExpired(now time.Time) {
+	valid := rule.timestamps[:0]
+	for _, timestamp := range rule.timestamps {
+		if now.Sub(timestamp) < rule.window {
+			valid = append(valid, timestamp)
+		}
+	}
+	rule.timestamps = valid
+}
+
+// RequiredApprovals allows signing only if at least m of approvers signed approval digest of the request which is not
+// expired at the request time. (see ApprovalDigest)
+func RequiredApprovals(m int, approvers []heimdall.PubKey, opts heimdall.SignerOpts) Rule {
+	approverMap := make(map[heimdall.KeyID]heimdall.PubKey)
+	for _, approver := range approvers {
+		approverMap[approver.ID()] = approver
+	}
+
+	return RuleFunc(func(req *Request) error {
+		approved := make(map[heimdall.KeyID]bool)
+		for _, approval := range req.Approvals {
+			approver, exists := approverMap[approval.KeyID]
+			if !exists || approved[approval.KeyID] {
+				continue
+			}
+
+			if !req.Time.Before(approval.Expiry) {
+				continue
+			}
+
+			digest := ApprovalDigest(req.KeyID, hashOptName(req.Opts), req.Message, approval.Expiry)
+			valid, err := hecdsa.Verify(approver, approval.Signature, digest, opts)
+			if err == nil && valid {
+				approved[approval.KeyID] = true
+			}
+		}
+
+		if len(approved) < m {
+			return ErrNotEnoughApprovals
+		}
+
+		return nil
+	})
+}
+
+// hashOptName returns name of hash option in signer option, or empty name if hash is selected by the signing key.
+func hashOptName(opts heimdall.SignerOpts) string {
+	if opts == nil || opts.HashOpt() == nil {
+		return ""
+	}
+
+	return opts.HashOpt().Name
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package policy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllowedPrefixes(t *testing.T) {
+	// given
+	rule := policy.AllowedPrefixes([]byte("block:"), []byte("tx:"))
+
+	// when
+	blockErr := rule.Evaluate(&policy.Request{Message: []byte("block:1")})
+	txErr := rule.Evaluate(&policy.Request{Message: []byte("tx:1")})
+	configErr := rule.Evaluate(&policy.Request{Message: []byte("config:1")})
+
+	// then
+	assert.NoError(t, blockErr)
+	assert.NoError(t, txErr)
+	assert.Equal(t, policy.ErrMessageNotAllowed, configErr)
+}
+
+func TestAllowedDigestPrefixes(t *testing.T) {
+	// given
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA256)
+	assert.NoError(t, err)
+	signerOpts := hecdsa.NewSignerOpts(hashOpt)
+
+	digest, err := hashing.Hash([]byte("block:1"), hashOpt)
+	assert.NoError(t, err)
+	rule := policy.AllowedDigestPrefixes(digest[:4])
+
+	// when
+	allowedErr := rule.Evaluate(&policy.Request{Message: []byte("block:1"), Opts: signerOpts})
+	deniedErr := rule.Evaluate(&policy.Request{Message: []byte("block:2"), Opts: signerOpts})
+	noOptsErr := rule.Evaluate(&policy.Request{Message: []byte("block:1")})
+
+	// then
+	assert.NoError(t, allowedErr)
+	assert.Equal(t, policy.ErrMessageNotAllowed, deniedErr)
+	assert.Equal(t, policy.ErrMessageNotAllowed, noOptsErr)
+}
+
+func TestTimeWindow(t *testing.T) {
+	tests := map[string]struct {
+		from time.Duration
+		to   time.Duration
+		hour int
+		err  error
+	}{
+		"inside window": {
+			from: 9 * time.Hour,
+			to:   18 * time.Hour,
+			hour: 10,
+			err:  nil,
+		},
+		"outside window": {
+			from: 9 * time.Hour,
+			to:   18 * time.Hour,
+			hour: 20,
+			err:  policy.ErrOutsideTimeWindow,
+		},
+		"inside window wrapping midnight": {
+			from: 22 * time.Hour,
+			to:   6 * time.Hour,
+			hour: 2,
+			err:  nil,
+		},
+		"outside window wrapping midnight": {
+			from: 22 * time.Hour,
+			to:   6 * time.Hour,
+			hour: 12,
+			err:  policy.ErrOutsideTimeWindow,
+		},
+	}
+
+	for testName, test := range tests {
+		t.Logf("running test case [%s]", testName)
+
+		// given
+		rule := policy.TimeWindow(test.from, test.to)
+		req := &policy.Request{Time: time.Date(2018, 10, 1, test.hour, 0, 0, 0, time.UTC)}
+
+		// when
+		err := rule.Evaluate(req)
+
+		// then
+		assert.Equal(t, test.err, err)
+	}
+}
+
+func TestRateCeiling(t *testing.T) {
+	// given
+	reserver := policy.RateCeiling(2, time.Minute).(policy.Reserver)
+	now := time.Now()
+
+	// when
+	unreservedErr := reserver.Evaluate(&policy.Request{Time: now})
+	err1 := reserver.Reserve(&policy.Request{Time: now})
+	err2 := reserver.Reserve(&policy.Request{Time: now.Add(time.Second)})
+	err3 := reserver.Reserve(&policy.Request{Time: now.Add(2 * time.Second)})
+	reserver.Release(&policy.Request{Time: now.Add(time.Second)})
+	err4 := reserver.Reserve(&policy.Request{Time: now.Add(3 * time.Second)})
+	err5 := reserver.Reserve(&policy.Request{Time: now.Add(4 * time.Second)})
+	err6 := reserver.Reserve(&policy.Request{Time: now.Add(time.Minute + time.Second)})
+
+	// then
+	assert.NoError(t, unreservedErr)
+	assert.NoError(t, err1)
+	assert.NoError(t, err2)
+	assert.Equal(t, policy.ErrRateExceeded, err3)
+	assert.NoError(t, err4)
+	assert.Equal(t, policy.ErrRateExceeded, err5)
+	assert.NoError(t, err6)
+}
+
+func TestRequiredApprovals(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	approvers := make([]heimdall.PriKey, 3)
+	approverPubs := make([]heimdall.PubKey, 3)
+	for i := range approvers {
+		approvers[i], err = hecdsa.GenerateKey(keyGenOpt)
+		assert.NoError(t, err)
+		approverPubs[i] = approvers[i].PublicKey()
+	}
+
+	keyId := approvers[2].ID()
+	message := []byte("config:update")
+	now := time.Now()
+	expiry := now.Add(time.Minute)
+	approvals := make([]*policy.Approval, 0)
+	for _, approver := range approvers[:2] {
+		digest := policy.ApprovalDigest(keyId, hashing.SHA384, message, expiry)
+		signature, err := hecdsa.Sign(approver, digest, signerOpt)
+		assert.NoError(t, err)
+		approvals = append(approvals, &policy.Approval{KeyID: approver.ID(), Signature: signature, Expiry: expiry})
+	}
+
+	rule := policy.RequiredApprovals(2, approverPubs, signerOpt)
+	request := func(keyId heimdall.KeyID, message []byte, reqTime time.Time, approvals ...*policy.Approval) *policy.Request {
+		return &policy.Request{KeyID: keyId, Message: message, Opts: signerOpt, Time: reqTime, Approvals: approvals}
+	}
+
+	// when
+	err = rule.Evaluate(request(keyId, message, now, approvals...))
+	duplicatedErr := rule.Evaluate(request(keyId, message, now, approvals[0], approvals[0]))
+	otherMessageErr := rule.Evaluate(request(keyId, []byte("config:other"), now, approvals...))
+	otherKeyErr := rule.Evaluate(request(approvers[0].ID(), message, now, approvals...))
+	expiredErr := rule.Evaluate(request(keyId, message, expiry, approvals...))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, policy.ErrNotEnoughApprovals, duplicatedErr)
+	assert.Equal(t, policy.ErrNotEnoughApprovals, otherMessageErr)
+	assert.Equal(t, policy.ErrNotEnoughApprovals, otherKeyErr)
+	assert.Equal(t, policy.ErrNotEnoughApprovals, expiredErr)
+}
+
+func TestRequiredApprovals_PlainSignatureRejected(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	approver, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	message := []byte("config:update")
+	signature, err := hecdsa.Sign(approver, message, signerOpt)
+	assert.NoError(t, err)
+	approval := &policy.Approval{KeyID: approver.ID(), Signature: signature, Expiry: time.Now().Add(time.Minute)}
+
+	rule := policy.RequiredApprovals(1, []heimdall.PubKey{approver.PublicKey()}, signerOpt)
+
+	// when
+	err = rule.Evaluate(&policy.Request{
+		KeyID:     approver.ID(),
+		Message:   message,
+		Opts:      signerOpt,
+		Time:      time.Now(),
+		Approvals: []*policy.Approval{approval},
+	})
+
+	// then
+	assert.Equal(t, policy.ErrNotEnoughApprovals, err)
+}
@@ -0,0 +1,154 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides token bucket rate limiter for signing operations.
+
+package ratelimit
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+)
+
+var ErrRateLimited = errors.New("rate limited - too many signing requests")
+
+// TokenBucket allows bursts up to capacity and refills tokens at constant rate.
+type TokenBucket struct {
+	mutex    sync.Mutex
+	capacity float64
+	rate     float64
+	tokens   float64
+	last     time.Time
+}
+
+// NewTokenBucket makes full token bucket which refills refillRate tokens per second.
+func NewTokenBucket(capacity int, refillRate float64) *TokenBucket {
+	return &TokenBucket{
+		capacity: float64(capacity),
+		rate:     refillRate,
+		tokens:   float64(capacity),
+		last:     time.Now(),
+	}
+}
+
+// Allow takes a token from bucket, and returns false if there is no token.
+func (bucket *TokenBucket) Allow() bool {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	now := time.Now()
+	bucket.tokens += now.Sub(bucket.last).Seconds() * bucket.rate
+	if bucket.tokens > bucket.capacity {
+		bucket.tokens = bucket.capacity
+	}
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+
+	bucket.tokens--
+
+	return true
+}
+
+// Refund puts back a token taken by Allow when the operation is not performed after all.
+func (bucket *TokenBucket) Refund() {
+	bucket.mutex.Lock()
+	defer bucket.mutex.Unlock()
+
+	bucket.tokens++
+	if bucket.tokens > bucket.capacity {
+		bucket.tokens = bucket.capacity
+	}
+}
+
+// Limiter keeps token bucket for each key (ex. key ID or caller).
+type Limiter struct {
+	mutex    sync.Mutex
+	capacity int
+	rate     float64
+	buckets  map[string]*TokenBucket
+}
+
+// NewLimiter makes limiter whose buckets have capacity and refill refillRate tokens per second.
+func NewLimiter(capacity int, refillRate float64) *Limiter {
+	return &Limiter{
+		capacity: capacity,
+		rate:     refillRate,
+		buckets:  make(map[string]*TokenBucket),
+	}
+}
+
+// Allow takes a token from bucket of the key.
+func (limiter *Limiter) Allow(key string) bool {
+	limiter.mutex.Lock()
+	bucket, exists := limiter.buckets[key]
+	if !exists {
+		bucket = NewTokenBucket(limiter.capacity, limiter.rate)
+		limiter.buckets[key] = bucket
+	}
+	limiter.mutex.Unlock()
+
+	return bucket.Allow()
+}
+
+// Refund puts back a token taken from bucket of the key.
+func (limiter *Limiter) Refund(key string) {
+	limiter.mutex.Lock()
+	bucket, exists := limiter.buckets[key]
+	limiter.mutex.Unlock()
+
+	if exists {
+		bucket.Refund()
+	}
+}
+
+// Signer is an implementation of heimdall Signer limiting signing rate of each key.
+type Signer struct {
+	signer  heimdall.Signer
+	limiter *Limiter
+}
+
+func NewSigner(signer heimdall.Signer, limiter *Limiter) heimdall.Signer {
+	return &Signer{
+		signer:  signer,
+		limiter: limiter,
+	}
+}
+
+func (signer *Signer) KeyID() heimdall.KeyID {
+	return signer.signer.KeyID()
+}
+
+// Sign signs message if the key has a token, and puts back the token if signing fails.
+func (signer *Signer) Sign(message []byte, opts heimdall.SignerOpts) ([]byte, error) {
+	if !signer.limiter.Allow(signer.signer.KeyID()) {
+		return nil, ErrRateLimited
+	}
+
+	signature, err := signer.signer.Sign(message, opts)
+	if err != nil {
+		signer.limiter.Refund(signer.signer.KeyID())
+		return nil, err
+	}
+
+	return signature, nil
+}
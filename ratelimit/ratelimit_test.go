@@ -0,0 +1,126 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket_Allow(t *testing.T) {
+	// given
+	bucket := ratelimit.NewTokenBucket(2, 100)
+
+	// when
+	first := bucket.Allow()
+	second := bucket.Allow()
+	third := bucket.Allow()
+	time.Sleep(20 * time.Millisecond)
+	refilled := bucket.Allow()
+
+	// then
+	assert.True(t, first)
+	assert.True(t, second)
+	assert.False(t, third)
+	assert.True(t, refilled)
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	// given
+	limiter := ratelimit.NewLimiter(1, 0.001)
+
+	// when
+	first := limiter.Allow("key1")
+	second := limiter.Allow("key1")
+	otherKey := limiter.Allow("key2")
+
+	// then
+	assert.True(t, first)
+	assert.False(t, second)
+	assert.True(t, otherKey)
+}
+
+func TestLimiter_Refund(t *testing.T) {
+	// given
+	limiter := ratelimit.NewLimiter(1, 0.001)
+	assert.True(t, limiter.Allow("key1"))
+
+	// when
+	limiter.Refund("key1")
+	limiter.Refund("key1")
+	refunded := limiter.Allow("key1")
+	overRefunded := limiter.Allow("key1")
+
+	// then
+	assert.True(t, refunded)
+	assert.False(t, overRefunded)
+}
+
+func TestSigner_Sign(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	signer := ratelimit.NewSigner(hecdsa.NewSigner(pri), ratelimit.NewLimiter(1, 0.001))
+
+	// when
+	signature, err := signer.Sign([]byte("hello"), signerOpt)
+	limitedSignature, limitedErr := signer.Sign([]byte("hello"), signerOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.NotNil(t, signature)
+	assert.Nil(t, limitedSignature)
+	assert.Equal(t, ratelimit.ErrRateLimited, limitedErr)
+}
+
+func TestSigner_Sign_RefundOnFailure(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	hashOpt, err := hashing.NewHashOpt(hashing.SHA384)
+	assert.NoError(t, err)
+	signerOpt := hecdsa.NewSignerOpts(hashOpt)
+
+	signer := ratelimit.NewSigner(hecdsa.NewSigner(pri), ratelimit.NewLimiter(1, 0.001))
+	holder := pri.(heimdall.UsagePolicyHolder)
+
+	// when
+	holder.SetUsagePolicy(&heimdall.KeyUsagePolicy{NotAfter: time.Now().Add(-time.Hour)})
+	_, expiredErr := signer.Sign([]byte("hello"), signerOpt)
+	holder.SetUsagePolicy(nil)
+	signature, err := signer.Sign([]byte("hello"), signerOpt)
+
+	// then
+	assert.Equal(t, heimdall.ErrKeyExpired, expiredErr)
+	assert.NoError(t, err)
+	assert.NotNil(t, signature)
+}
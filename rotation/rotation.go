@@ -0,0 +1,409 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides key rotation with an overlap period, in which the previous key is still loadable for verification.
+
+package rotation
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/event"
+	"github.com/DE-labtory/heimdall/fileperm"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hrsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/keystore"
+)
+
+var ErrNotInitialized = errors.New("rotation not initialized - rotation directory has no state file")
+var ErrAlreadyInitialized = errors.New("rotation already initialized - rotation directory has a state file")
+var ErrNoPreviousKey = errors.New("no previous key - key has never been rotated")
+var ErrGracePeriodOver = errors.New("grace period over - previous key is not usable after the overlap period")
+var ErrKeyNotSupported = errors.New("key not supported - rotation supports ECDSA, RSA and Ed25519 keys")
+var ErrInvalidLink = errors.New("invalid link - cross signatures of rotation link are not valid")
+
+// names of state file and public key directory in rotation directory
+const (
+	stateFileName = "rotation.json"
+	pubKeyDirName = "pub"
+)
+
+// domain separator of messages cross-signed in rotation links
+const linkContext = "heimdall key rotation"
+
+// Link links a rotated key to the previous key. Each key signs the link message, so that peers trusting either key
+// can trust the other one.
+type Link struct {
+	KeyID         heimdall.KeyID
+	PreviousKeyID heimdall.KeyID
+	RotatedAt     time.Time
+	// GraceUntil is the end of overlap period, until which the previous key is loadable for verification.
+	GraceUntil time.Time
+	// ForwardSignature is made by the previous key, and BackwardSignature is made by the new key.
+	ForwardSignature  []byte
+	BackwardSignature []byte
+}
+
+// State is rotation state of a rotation directory. Links are kept in rotated order, the last one being the latest.
+type State struct {
+	Current heimdall.KeyID
+	Links   []*Link
+}
+
+// Previous returns link of the latest rotation, or nil if the key has never been rotated.
+func (state *State) Previous() *Link {
+	if len(state.Links) == 0 {
+		return nil
+	}
+
+	return state.Links[len(state.Links)-1]
+}
+
+// Init stores pri as the first current key in rotationDirPath. Every private key of rotation directory is stored in its
+// own key directory named by key ID, encrypted with pwd, and public keys are stored together in public key directory.
+func Init(pri heimdall.PriKey, pwd, rotationDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) error {
+	if _, err := LoadState(rotationDirPath); err == nil {
+		return ErrAlreadyInitialized
+	} else if err != ErrNotInitialized {
+		return err
+	}
+
+	if _, err := newSigner(pri); err != nil {
+		return err
+	}
+
+	if err := storeKey(pri, pwd, rotationDirPath, encOpt, kdfOpt); err != nil {
+		return err
+	}
+
+	return storeState(&State{Current: pri.ID()}, rotationDirPath)
+}
+
+// RotateKey generates a new key of keyGenOpt, links it to the current key by cross signatures and makes it current.
+// The previous key is kept loadable for verification until gracePeriod passes.
+func RotateKey(rotationDirPath, pwd string, keyGenOpt heimdall.KeyGenOpts, gracePeriod time.Duration, encOpt *encryption.Opts, kdfOpt *kdf.Opts) (*Link, error) {
+	state, err := LoadState(rotationDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := hecdsa.LoadPriKey(KeyDirPath(rotationDirPath, state.Current), pwd)
+	if err != nil {
+		return nil, err
+	}
+	defer previous.Clear()
+
+	pri, err := generateKey(keyGenOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer pri.Clear()
+
+	rotatedAt := time.Now().UTC()
+	link := &Link{
+		KeyID:         pri.ID(),
+		PreviousKeyID: previous.ID(),
+		RotatedAt:     rotatedAt,
+		GraceUntil:    rotatedAt.Add(gracePeriod),
+	}
+
+	if link.ForwardSignature, err = signLink(previous, link); err != nil {
+		return nil, err
+	}
+
+	if link.BackwardSignature, err = signLink(pri, link); err != nil {
+		return nil, err
+	}
+
+	if err := storeKey(pri, pwd, rotationDirPath, encOpt, kdfOpt); err != nil {
+		return nil, err
+	}
+
+	state.Current = link.KeyID
+	state.Links = append(state.Links, link)
+	if err := storeState(state, rotationDirPath); err != nil {
+		return nil, err
+	}
+	event.Publish(event.KeyRotated, link.KeyID, link.PreviousKeyID)
+
+	return link, nil
+}
+
+// LoadState reads rotation state of rotationDirPath.
+func LoadState(rotationDirPath string) (*State, error) {
+	data, err := ioutil.ReadFile(filepath.Join(rotationDirPath, stateFileName))
+	if os.IsNotExist(err) {
+		return nil, ErrNotInitialized
+	} else if err != nil {
+		return nil, err
+	}
+
+	state := &State{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+// KeyDirPath returns key directory of keyId in rotationDirPath.
+func KeyDirPath(rotationDirPath string, keyId heimdall.KeyID) string {
+	return heimdall.KeyIDFilePath(rotationDirPath, keyId)
+}
+
+// PubKeyDirPath returns public key directory of rotationDirPath, which keeps public keys of pruned keys as well.
+func PubKeyDirPath(rotationDirPath string) string {
+	return filepath.Join(rotationDirPath, pubKeyDirName)
+}
+
+// LoadCurrentKey loads the current private key of rotationDirPath with pwd.
+func LoadCurrentKey(rotationDirPath, pwd string) (heimdall.PriKey, error) {
+	state, err := LoadState(rotationDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return hecdsa.LoadPriKey(KeyDirPath(rotationDirPath, state.Current), pwd)
+}
+
+// LoadPreviousKey loads the previous private key of rotationDirPath with pwd, which is allowed during grace period only.
+func LoadPreviousKey(rotationDirPath, pwd string) (heimdall.PriKey, error) {
+	link, err := previousInGrace(rotationDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return hecdsa.LoadPriKey(KeyDirPath(rotationDirPath, link.PreviousKeyID), pwd)
+}
+
+// LoadCurrentPubKey loads public key of the current key of rotationDirPath.
+func LoadCurrentPubKey(rotationDirPath string) (heimdall.PubKey, error) {
+	state, err := LoadState(rotationDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return hecdsa.LoadPubKey(state.Current, PubKeyDirPath(rotationDirPath))
+}
+
+// LoadPreviousPubKey loads public key of the previous key of rotationDirPath, which is allowed during grace period only.
+func LoadPreviousPubKey(rotationDirPath string) (heimdall.PubKey, error) {
+	link, err := previousInGrace(rotationDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return hecdsa.LoadPubKey(link.PreviousKeyID, PubKeyDirPath(rotationDirPath))
+}
+
+// VerificationKeys returns public keys that signatures should be verified with: the current key, and the previous
+// key during grace period.
+func VerificationKeys(rotationDirPath string) ([]heimdall.PubKey, error) {
+	current, err := LoadCurrentPubKey(rotationDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := LoadPreviousPubKey(rotationDirPath)
+	if err == ErrNoPreviousKey || err == ErrGracePeriodOver {
+		return []heimdall.PubKey{current}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return []heimdall.PubKey{current, previous}, nil
+}
+
+func previousInGrace(rotationDirPath string) (*Link, error) {
+	state, err := LoadState(rotationDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	link := state.Previous()
+	if link == nil {
+		return nil, ErrNoPreviousKey
+	}
+
+	if time.Now().After(link.GraceUntil) {
+		return nil, ErrGracePeriodOver
+	}
+
+	return link, nil
+}
+
+// Prune wipes key directories of keys which are neither the current key nor the previous key in grace period,
+// and returns their key IDs. Public keys are kept, so that links of pruned keys are still verifiable.
+func Prune(rotationDirPath string) ([]heimdall.KeyID, error) {
+	state, err := LoadState(rotationDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := map[heimdall.KeyID]bool{state.Current: true}
+	if link := state.Previous(); link != nil && !time.Now().After(link.GraceUntil) {
+		keep[link.PreviousKeyID] = true
+	}
+
+	pruned := make([]heimdall.KeyID, 0)
+	for _, link := range state.Links {
+		keyId := link.PreviousKeyID
+		if keep[keyId] {
+			continue
+		}
+
+		keyDirPath := KeyDirPath(rotationDirPath, keyId)
+		if _, err := os.Stat(keyDirPath); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := keystore.Wipe(keyDirPath); err != nil {
+			return pruned, err
+		}
+		event.Publish(event.KeyExpired, keyId, keyDirPath)
+		pruned = append(pruned, keyId)
+	}
+
+	return pruned, nil
+}
+
+// VerifyLink verifies cross signatures of link by the previous public key and the new public key.
+func VerifyLink(link *Link, previousPub, pub heimdall.PubKey) error {
+	if !heimdall.MatchKeyID(link.PreviousKeyID, previousPub) || !heimdall.MatchKeyID(link.KeyID, pub) {
+		return ErrInvalidLink
+	}
+
+	if err := verifyLink(previousPub, link, link.ForwardSignature); err != nil {
+		return err
+	}
+
+	return verifyLink(pub, link, link.BackwardSignature)
+}
+
+// linkMessage is the message cross-signed in link, which binds both key IDs and rotation time.
+func linkMessage(link *Link) []byte {
+	var message bytes.Buffer
+	message.WriteString(linkContext)
+	message.WriteByte(0)
+	message.WriteString(link.PreviousKeyID)
+	message.WriteByte(0)
+	message.WriteString(link.KeyID)
+	message.WriteByte(0)
+	message.WriteString(strconv.FormatInt(link.RotatedAt.UnixNano(), 10))
+
+	return message.Bytes()
+}
+
+func signLink(pri heimdall.PriKey, link *Link) ([]byte, error) {
+	signer, err := newSigner(pri)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer.Sign(linkMessage(link), signerOptsOf(pri))
+}
+
+func verifyLink(pub heimdall.PubKey, link *Link, signature []byte) error {
+	var verifier heimdall.Verifier
+	if _, ok := pub.(*hecdsa.PubKey); ok {
+		verifier = hecdsa.NewVerifier()
+	} else if _, ok := pub.(*hrsa.PubKey); ok {
+		verifier = hrsa.NewVerifier()
+	} else if _, ok := pub.(*hed25519.PubKey); ok {
+		verifier = hed25519.NewVerifier()
+	} else {
+		return ErrKeyNotSupported
+	}
+
+	valid, err := verifier.Verify(pub, signature, linkMessage(link), signerOptsOf(pub))
+	if err != nil {
+		return err
+	}
+
+	if !valid {
+		return ErrInvalidLink
+	}
+
+	return nil
+}
+
+func newSigner(pri heimdall.PriKey) (heimdall.Signer, error) {
+	if _, ok := pri.(*hecdsa.PriKey); ok {
+		return hecdsa.NewSigner(pri), nil
+	} else if _, ok := pri.(*hrsa.PriKey); ok {
+		return hrsa.NewSigner(pri), nil
+	} else if _, ok := pri.(*hed25519.PriKey); ok {
+		return hed25519.NewSigner(pri), nil
+	}
+
+	return nil, ErrKeyNotSupported
+}
+
+// signerOptsOf returns default signer option of key's algorithm, which hashes message by the key size.
+func signerOptsOf(key heimdall.Key) heimdall.SignerOpts {
+	switch key.KeyGenOpt().Algorithm() {
+	case heimdall.RSA:
+		return hrsa.NewSignerOpts(nil)
+	case heimdall.ED25519:
+		return hed25519.NewSignerOpts()
+	default:
+		return hecdsa.NewSignerOpts(nil)
+	}
+}
+
+func generateKey(keyGenOpt heimdall.KeyGenOpts) (heimdall.PriKey, error) {
+	switch keyGenOpt.Algorithm() {
+	case heimdall.ECDSA:
+		return hecdsa.GenerateKey(keyGenOpt)
+	case heimdall.RSA:
+		return hrsa.GenerateKey(keyGenOpt)
+	case heimdall.ED25519:
+		return hed25519.GenerateKey(keyGenOpt)
+	default:
+		return nil, ErrKeyNotSupported
+	}
+}
+
+// storeKey stores private key file of pri in its key directory, and public key file in public key directory.
+func storeKey(pri heimdall.PriKey, pwd, rotationDirPath string, encOpt *encryption.Opts, kdfOpt *kdf.Opts) error {
+	if err := hecdsa.StorePriKey(pri, pwd, KeyDirPath(rotationDirPath, pri.ID()), encOpt, kdfOpt); err != nil {
+		return err
+	}
+
+	return hecdsa.StorePubKey(pri.PublicKey(), PubKeyDirPath(rotationDirPath))
+}
+
+func storeState(state *State, rotationDirPath string) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	statePath := filepath.Join(rotationDirPath, stateFileName)
+	return fileperm.WriteFileAtomic(statePath, statePath+".tmp", data)
+}
@@ -0,0 +1,192 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package rotation_test
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/hed25519"
+	"github.com/DE-labtory/heimdall/hx25519"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/rotation"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpRotation(t *testing.T) (heimdall.PriKey, *encryption.Opts, *kdf.Opts) {
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	pri, err := hecdsa.GenerateKey(ecdsaKeyGenOpt(t, hecdsa.ECP256))
+	assert.NoError(t, err)
+
+	err = rotation.Init(pri, "password", heimdall.TestKeyDir, encOpt, kdfOpt)
+	assert.NoError(t, err)
+
+	return pri, encOpt, kdfOpt
+}
+
+func ecdsaKeyGenOpt(t *testing.T, curve string) heimdall.KeyGenOpts {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(curve)
+	assert.NoError(t, err)
+
+	return keyGenOpt
+}
+
+func TestInit(t *testing.T) {
+	// given
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	pri, encOpt, kdfOpt := setUpRotation(t)
+
+	// when
+	err := rotation.Init(pri, "password", heimdall.TestKeyDir, encOpt, kdfOpt)
+
+	// then
+	assert.Equal(t, rotation.ErrAlreadyInitialized, err)
+
+	state, err := rotation.LoadState(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), state.Current)
+	assert.Nil(t, state.Previous())
+
+	_, err = rotation.LoadPreviousKey(heimdall.TestKeyDir, "password")
+	assert.Equal(t, rotation.ErrNoPreviousKey, err)
+}
+
+func TestRotateKey(t *testing.T) {
+	// given
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	pri, encOpt, kdfOpt := setUpRotation(t)
+	ed25519KeyGenOpt, err := hed25519.NewKeyGenOpt(hed25519.ED25519)
+	assert.NoError(t, err)
+
+	// when
+	link, err := rotation.RotateKey(heimdall.TestKeyDir, "password", ed25519KeyGenOpt, time.Hour, encOpt, kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), link.PreviousKeyID)
+
+	current, err := rotation.LoadCurrentKey(heimdall.TestKeyDir, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, link.KeyID, current.ID())
+
+	previous, err := rotation.LoadPreviousKey(heimdall.TestKeyDir, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, pri.ID(), previous.ID())
+
+	keys, err := rotation.VerificationKeys(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+
+	state, err := rotation.LoadState(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.NoError(t, rotation.VerifyLink(state.Previous(), keys[1], keys[0]))
+	assert.Equal(t, rotation.ErrInvalidLink, rotation.VerifyLink(state.Previous(), keys[0], keys[1]))
+}
+
+func TestRotateKey_TamperedLink(t *testing.T) {
+	// given
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	pri, encOpt, kdfOpt := setUpRotation(t)
+	link, err := rotation.RotateKey(heimdall.TestKeyDir, "password", ecdsaKeyGenOpt(t, hecdsa.ECP384), time.Hour, encOpt, kdfOpt)
+	assert.NoError(t, err)
+	pub, err := rotation.LoadCurrentPubKey(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+
+	// when
+	link.RotatedAt = link.RotatedAt.Add(time.Second)
+
+	// then
+	assert.Error(t, rotation.VerifyLink(link, pri.PublicKey(), pub))
+}
+
+func TestRotateKey_GracePeriodOver(t *testing.T) {
+	// given
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	pri, encOpt, kdfOpt := setUpRotation(t)
+	_, err := rotation.RotateKey(heimdall.TestKeyDir, "password", ecdsaKeyGenOpt(t, hecdsa.ECP256), time.Nanosecond, encOpt, kdfOpt)
+	assert.NoError(t, err)
+	time.Sleep(time.Millisecond)
+
+	// when
+	_, err = rotation.LoadPreviousKey(heimdall.TestKeyDir, "password")
+
+	// then
+	assert.Equal(t, rotation.ErrGracePeriodOver, err)
+
+	keys, err := rotation.VerificationKeys(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+
+	pruned, err := rotation.Prune(heimdall.TestKeyDir)
+	assert.NoError(t, err)
+	assert.Equal(t, []heimdall.KeyID{pri.ID()}, pruned)
+
+	_, err = os.Stat(rotation.KeyDirPath(heimdall.TestKeyDir, pri.ID()))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestPrune_KeepsPreviousKeyInGracePeriod(t *testing.T) {
+	// given
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	pri, encOpt, kdfOpt := setUpRotation(t)
+	first, err := rotation.RotateKey(heimdall.TestKeyDir, "password", ecdsaKeyGenOpt(t, hecdsa.ECP256), time.Hour, encOpt, kdfOpt)
+	assert.NoError(t, err)
+	_, err = rotation.RotateKey(heimdall.TestKeyDir, "password", ecdsaKeyGenOpt(t, hecdsa.ECP256), time.Hour, encOpt, kdfOpt)
+	assert.NoError(t, err)
+
+	// when
+	pruned, err := rotation.Prune(heimdall.TestKeyDir)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []heimdall.KeyID{pri.ID()}, pruned)
+
+	previous, err := rotation.LoadPreviousKey(heimdall.TestKeyDir, "password")
+	assert.NoError(t, err)
+	assert.Equal(t, first.KeyID, previous.ID())
+}
+
+func TestRotateKey_KeyNotSupported(t *testing.T) {
+	// given
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	_, encOpt, kdfOpt := setUpRotation(t)
+	x25519KeyGenOpt, err := hx25519.NewKeyGenOpt(hx25519.X25519)
+	assert.NoError(t, err)
+
+	// when
+	_, err = rotation.RotateKey(heimdall.TestKeyDir, "password", x25519KeyGenOpt, time.Hour, encOpt, kdfOpt)
+
+	// then
+	assert.Equal(t, rotation.ErrKeyNotSupported, err)
+}
+
+func TestLoadState_NotInitialized(t *testing.T) {
+	// when
+	_, err := rotation.LoadState(heimdall.TestKeyDir)
+
+	// then
+	assert.Equal(t, rotation.ErrNotInitialized, err)
+}
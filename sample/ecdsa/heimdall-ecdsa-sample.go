@@ -21,16 +21,9 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
-	"crypto/x509"
-	"crypto/x509/pkix"
 	"errors"
-	"io"
 	"log"
-	"math/big"
-	"net/http"
-	"net/http/httptest"
 	"os"
-	"time"
 
 	"github.com/DE-labtory/heimdall/cert"
 	"github.com/DE-labtory/heimdall/config"
@@ -84,7 +77,12 @@ func main() {
 	hPri := hecdsa.NewPriKey(ecPri)
 	////////////////////// config CA for sample /////////////////////
 	log.Println("requesting and receiving certificate from testCA...")
-	rootCert, clientCert, sampleCA, err := configCA(&ecPri.PublicKey)
+	sampleCA, err := mocks.NewCA()
+	errorCheck(err)
+	defer sampleCA.Close()
+	rootCert := sampleCA.RootCert
+	mocks.TestCertTemplate.SubjectKeyId = hPri.SKI()
+	clientCert, err := sampleCA.Issue(&ecPri.PublicKey, &mocks.TestCertTemplate)
 	errorCheck(err)
 	log.Println("request and receive certificate from testCA success")
 	//////////////////////////////////////////////////////////////////
@@ -116,8 +114,6 @@ func main() {
 		log.Println("client certificate chain is valid!")
 	}
 
-	// set CRL distribution point
-	clientCert.CRLDistributionPoints = []string{sampleCA.URL}
 	// verifying client cert with rootCert (can be intermediate certs between root and client)
 	log.Println("verifying client certificate...")
 	err = cert.Verify(clientCert)
@@ -155,44 +151,3 @@ func errorCheck(err error) {
 		log.Panicln(err)
 	}
 }
-
-func configCA(clientPub *ecdsa.PublicKey) (rootCert, clientCert *x509.Certificate, sampleCA *httptest.Server, err error) {
-	rootPri, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
-	errorCheck(err)
-	rootPub := &rootPri.PublicKey
-	hRootPub := hecdsa.NewPubKey(rootPub)
-
-	mocks.TestRootCertTemplate.SubjectKeyId = hRootPub.SKI()
-
-	hClientPub := hecdsa.NewPubKey(clientPub)
-	mocks.TestCertTemplate.SubjectKeyId = hClientPub.SKI()
-
-	rootDerBytes, err := x509.CreateCertificate(rand.Reader, &mocks.TestRootCertTemplate, &mocks.TestRootCertTemplate, rootPub, rootPri)
-	errorCheck(err)
-	clientDerBytes, err := x509.CreateCertificate(rand.Reader, &mocks.TestCertTemplate, &mocks.TestRootCertTemplate, clientPub, rootPri)
-	errorCheck(err)
-
-	rootCert, err = cert.DERToX509Cert(rootDerBytes)
-	errorCheck(err)
-	clientCert, err = cert.DERToX509Cert(clientDerBytes)
-	errorCheck(err)
-
-	// revoked certificate
-	revokedCertificate := new(pkix.RevokedCertificate)
-	revokedCertificate.SerialNumber = big.NewInt(44)
-	revokedCertificate.RevocationTime = time.Now()
-	revokedCertificate.Extensions = nil
-
-	revokedCertList := []pkix.RevokedCertificate{*revokedCertificate}
-
-	// create CRL
-	crlBytes, err := rootCert.CreateCRL(rand.Reader, rootPri, revokedCertList, time.Now(), time.Now().Add(time.Hour*24))
-	errorCheck(err)
-
-	// httptest server
-	sampleCA = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		io.WriteString(w, string(crlBytes))
-	}))
-
-	return rootCert, clientCert, sampleCA, nil
-}
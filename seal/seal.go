@@ -0,0 +1,227 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides sealing API which encrypts application secrets under keys in keystore.
+
+package seal
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"golang.org/x/crypto/hkdf"
+)
+
+var ErrKeyNotSupported = errors.New("key not supported - sealing key should be ECDSA key on P-256, P-384 or P-521")
+var ErrKeyIDMismatch = errors.New("key ID mismatch - secret is sealed under other key")
+var ErrEmptySecret = errors.New("secret to seal should not be nil")
+
+// info for deriving sealing key by HKDF
+var hkdfInfo = []byte("heimdall seal")
+
+// sealedSecret is a format of sealed secret.
+type sealedSecret struct {
+	KeyID        heimdall.KeyID
+	EphemeralKey []byte
+	Nonce        []byte
+	Ciphertext   []byte
+}
+
+// Seal encrypts data under public key of keyId stored in pubKeyDirPath.
+func Seal(data []byte, keyId heimdall.KeyID, pubKeyDirPath string) ([]byte, error) {
+	pub, err := hecdsa.LoadPubKey(keyId, pubKeyDirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return SealWithKey(data, pub)
+}
+
+// Unseal decrypts sealed secret by private key stored in priKeyDirPath with password.
+func Unseal(sealed []byte, priKeyDirPath, pwd string) ([]byte, error) {
+	pri, err := hecdsa.LoadPriKey(priKeyDirPath, pwd)
+	if err != nil {
+		return nil, err
+	}
+	defer pri.Clear()
+
+	return UnsealWithKey(sealed, pri)
+}
+
+// SealWithKey encrypts data by ECDH with ephemeral key and AES-GCM, so only the owner of private key can unseal it.
+func SealWithKey(data []byte, pub heimdall.PubKey) ([]byte, error) {
+	if data == nil {
+		return nil, ErrEmptySecret
+	}
+
+	if err := heimdall.CheckKeyUsage(pub, heimdall.PurposeEncrypt); err != nil {
+		return nil, err
+	}
+
+	ecdhPub, err := toECDHPubKey(pub)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPri, err := ecdhPub.Curve().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedSecret, err := ephemeralPri.ECDH(ecdhPub)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(sharedSecret, ephemeralPri.PublicKey().Bytes())
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	keyId := pub.ID()
+
+	return json.Marshal(&sealedSecret{
+		KeyID:        keyId,
+		EphemeralKey: ephemeralPri.PublicKey().Bytes(),
+		Nonce:        nonce,
+		Ciphertext:   aead.Seal(nil, nonce, data, []byte(keyId)),
+	})
+}
+
+// UnsealWithKey decrypts sealed secret by private key.
+func UnsealWithKey(sealed []byte, pri heimdall.PriKey) ([]byte, error) {
+	var secret sealedSecret
+	if err := json.Unmarshal(sealed, &secret); err != nil {
+		return nil, err
+	}
+
+	if !heimdall.MatchKeyID(secret.KeyID, pri) {
+		return nil, ErrKeyIDMismatch
+	}
+
+	if err := heimdall.CheckKeyUsage(pri, heimdall.PurposeEncrypt); err != nil {
+		return nil, err
+	}
+
+	ecdhPri, err := toECDHPriKey(pri)
+	if err != nil {
+		return nil, err
+	}
+
+	ephemeralPub, err := ecdhPri.Curve().NewPublicKey(secret.EphemeralKey)
+	if err != nil {
+		return nil, err
+	}
+
+	sharedSecret, err := ecdhPri.ECDH(ephemeralPub)
+	if err != nil {
+		return nil, err
+	}
+
+	aead, err := newAEAD(sharedSecret, secret.EphemeralKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return aead.Open(nil, secret.Nonce, secret.Ciphertext, []byte(secret.KeyID))
+}
+
+// newAEAD derives AES-256 key from ECDH shared secret by HKDF, and makes AES-GCM with the key.
+func newAEAD(sharedSecret, salt []byte) (cipher.AEAD, error) {
+	key := make([]byte, 32)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, sharedSecret, salt, hkdfInfo), key); err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func toECDHPubKey(pub heimdall.PubKey) (*ecdh.PublicKey, error) {
+	pubBytes, err := pub.ToByte()
+	if err != nil {
+		return nil, err
+	}
+
+	internalPub, err := x509.ParsePKIXPublicKey(pubBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaPub, ok := internalPub.(*ecdsa.PublicKey)
+	if !ok || !supportedCurve(ecdsaPub.Curve) {
+		return nil, ErrKeyNotSupported
+	}
+
+	return ecdsaPub.ECDH()
+}
+
+// toECDHPriKey converts private key to ECDH key, and clears intermediate copies of the private key.
+func toECDHPriKey(pri heimdall.PriKey) (*ecdh.PrivateKey, error) {
+	priBytes, err := pri.ToByte()
+	if err != nil {
+		return nil, ErrKeyNotSupported
+	}
+	defer func() {
+		for i := range priBytes {
+			priBytes[i] = 0
+		}
+	}()
+
+	ecdsaPri, err := x509.ParseECPrivateKey(priBytes)
+	if err != nil {
+		return nil, ErrKeyNotSupported
+	}
+	defer ecdsaPri.D.Set(big.NewInt(0))
+
+	if !supportedCurve(ecdsaPri.Curve) {
+		return nil, ErrKeyNotSupported
+	}
+
+	return ecdsaPri.ECDH()
+}
+
+// supportedCurve reports whether curve is supported by crypto/ecdh.
+func supportedCurve(curve elliptic.Curve) bool {
+	switch curve {
+	case elliptic.P256(), elliptic.P384(), elliptic.P521():
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,106 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package seal_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/seal"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpKey(t *testing.T) heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	return pri
+}
+
+func TestSealWithKey(t *testing.T) {
+	// given
+	pri := setUpKey(t)
+	otherPri := setUpKey(t)
+	secret := []byte("db password")
+
+	// when
+	sealed, err := seal.SealWithKey(secret, pri.PublicKey())
+	_, nilErr := seal.SealWithKey(nil, pri.PublicKey())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, seal.ErrEmptySecret, nilErr)
+
+	unsealed, err := seal.UnsealWithKey(sealed, pri)
+	assert.NoError(t, err)
+	assert.Equal(t, secret, unsealed)
+
+	_, err = seal.UnsealWithKey(sealed, otherPri)
+	assert.Equal(t, seal.ErrKeyIDMismatch, err)
+}
+
+func TestSeal(t *testing.T) {
+	// given
+	pri := setUpKey(t)
+	secret := []byte("api token")
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	err = hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, encOpt, kdfOpt)
+	assert.NoError(t, err)
+	err = hecdsa.StorePubKey(pri.PublicKey(), heimdall.TestPubKeyDir)
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+	defer os.RemoveAll(heimdall.TestPubKeyDir)
+
+	// when
+	sealed, err := seal.Seal(secret, pri.ID(), heimdall.TestPubKeyDir)
+	assert.NoError(t, err)
+	unsealed, err := seal.Unseal(sealed, heimdall.TestPriKeyDir, "password")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, secret, unsealed)
+}
+
+func TestSealWithKey_UnsupportedCurve(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP224)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	sealed := []byte(`{"KeyID":"` + pri.ID() + `"}`)
+
+	// when
+	_, sealErr := seal.SealWithKey([]byte("db password"), pri.PublicKey())
+	_, unsealErr := seal.UnsealWithKey(sealed, pri)
+
+	// then
+	assert.Equal(t, seal.ErrKeyNotSupported, sealErr)
+	assert.Equal(t, seal.ErrKeyNotSupported, unsealErr)
+}
@@ -0,0 +1,172 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides keystore backend reading encrypted key files from mounted Kubernetes or Docker secrets.
+
+package secretstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/iLogger"
+)
+
+// DockerSecretsPath is the directory where Docker mounts secrets of a service.
+const DockerSecretsPath = "/run/secrets"
+
+// dataDirLink is the symbolic link which Kubernetes atomically swaps when secret volume is updated.
+const dataDirLink = "..data"
+
+var ErrKeyNotExist = errors.New("key not exist in mounted secret")
+var ErrKeyIDMismatch = errors.New("key id mismatch - secret does not hold the key of its name")
+
+// SecretStore reads encrypted key files from a mounted secret volume.
+// Key files are named by key ID (same format as hecdsa.StorePriKey) and are decrypted in memory only,
+// nothing is written to the mounted volume or any other place.
+type SecretStore struct {
+	mountPath string
+	pwd       string
+}
+
+// NewSecretStore makes secret store on mountPath which decrypts key files with pwd.
+func NewSecretStore(mountPath, pwd string) *SecretStore {
+	return &SecretStore{
+		mountPath: mountPath,
+		pwd:       pwd,
+	}
+}
+
+// KeyIDs returns IDs of keys in the mounted secret.
+func (store *SecretStore) KeyIDs() ([]heimdall.KeyID, error) {
+	files, err := ioutil.ReadDir(store.mountPath)
+	if err != nil {
+		return nil, err
+	}
+
+	keyIds := make([]heimdall.KeyID, 0, len(files))
+	for _, file := range files {
+		if heimdall.ValidateKeyID(file.Name()) == nil {
+			keyIds = append(keyIds, file.Name())
+		}
+	}
+
+	return keyIds, nil
+}
+
+// LoadPriKey decrypts private key of keyId from the mounted secret.
+func (store *SecretStore) LoadPriKey(keyId heimdall.KeyID) (heimdall.PriKey, error) {
+	if err := heimdall.ValidateKeyID(keyId); err != nil {
+		return nil, err
+	}
+
+	jsonKeyFile, err := ioutil.ReadFile(heimdall.KeyIDFilePath(store.mountPath, keyId))
+	if os.IsNotExist(err) {
+		return nil, ErrKeyNotExist
+	} else if err != nil {
+		return nil, err
+	}
+
+	pri, err := hecdsa.DecryptKeyFile(jsonKeyFile, store.pwd)
+	if err != nil {
+		return nil, err
+	}
+
+	if !heimdall.MatchKeyID(keyId, pri) {
+		pri.Clear()
+		return nil, ErrKeyIDMismatch
+	}
+
+	return pri, nil
+}
+
+// Revision returns revision of the mounted secret which changes whenever the secret is rotated.
+// Target of Kubernetes' atomic writer link is used if it exists,
+// otherwise revision is computed from names, sizes and modification times of files (e.g. Docker secrets).
+func (store *SecretStore) Revision() (string, error) {
+	target, err := os.Readlink(filepath.Join(store.mountPath, dataDirLink))
+	if err == nil {
+		return target, nil
+	}
+
+	files, err := ioutil.ReadDir(store.mountPath)
+	if err != nil {
+		return "", err
+	}
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Name() < files[j].Name() })
+
+	hash := sha256.New()
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), "..") {
+			continue
+		}
+		fmt.Fprintf(hash, "%s:%d:%d;", file.Name(), file.Size(), file.ModTime().UnixNano())
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Watch checks revision of the mounted secret every interval and calls onRotate when the secret is rotated.
+// Returned function stops watching.
+func (store *SecretStore) Watch(interval time.Duration, onRotate func(revision string)) (stop func()) {
+	revision, err := store.Revision()
+	if err != nil {
+		iLogger.Errorf(nil, "[Heimdall] failed to read secret revision - %s", err)
+	}
+
+	done := make(chan struct{})
+	ticker := time.NewTicker(interval)
+
+	go func() {
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				current, err := store.Revision()
+				if err != nil {
+					iLogger.Errorf(nil, "[Heimdall] failed to read secret revision - %s", err)
+					continue
+				}
+
+				if current != revision {
+					revision = current
+					onRotate(current)
+				}
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
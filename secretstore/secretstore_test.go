@@ -0,0 +1,116 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package secretstore_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/encryption"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/secretstore"
+	"github.com/stretchr/testify/assert"
+)
+
+// setUpSecret makes encrypted key file and mounts it like Kubernetes secret volume.
+func setUpSecret(t *testing.T, mountPath, revision string) heimdall.PriKey {
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+	encOpt, err := encryption.NewOpts(encryption.AES, encryption.DefaultKeyLen, encryption.DefaultOpMode)
+	assert.NoError(t, err)
+
+	err = hecdsa.StorePriKey(pri, "password", heimdall.TestPriKeyDir, encOpt, kdfOpt)
+	assert.NoError(t, err)
+	defer os.RemoveAll(heimdall.TestPriKeyDir)
+
+	keyFile, err := ioutil.ReadFile(filepath.Join(heimdall.TestPriKeyDir, pri.ID()))
+	assert.NoError(t, err)
+
+	revisionDir := filepath.Join(mountPath, revision)
+	assert.NoError(t, os.MkdirAll(revisionDir, 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(revisionDir, pri.ID()), keyFile, 0600))
+
+	// swap data link atomically as kubelet does
+	tmpLink := filepath.Join(mountPath, "..data_tmp")
+	assert.NoError(t, os.Symlink(revision, tmpLink))
+	assert.NoError(t, os.Rename(tmpLink, filepath.Join(mountPath, "..data")))
+
+	keyLink := filepath.Join(mountPath, pri.ID())
+	if _, err := os.Lstat(keyLink); os.IsNotExist(err) {
+		assert.NoError(t, os.Symlink(filepath.Join("..data", pri.ID()), keyLink))
+	}
+
+	return pri
+}
+
+func TestSecretStore_LoadPriKey(t *testing.T) {
+	// given
+	pri := setUpSecret(t, heimdall.TestKeyDir, "..2018_10_01")
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	store := secretstore.NewSecretStore(heimdall.TestKeyDir, "password")
+
+	// when
+	keyIds, err := store.KeyIDs()
+	assert.NoError(t, err)
+	key, err := store.LoadPriKey(pri.ID())
+	_, notExistErr := store.LoadPriKey("ITnotExist")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []heimdall.KeyID{pri.ID()}, keyIds)
+	assert.Equal(t, pri.ID(), key.ID())
+	assert.Equal(t, secretstore.ErrKeyNotExist, notExistErr)
+
+	_, err = secretstore.NewSecretStore(heimdall.TestKeyDir, "wrong").LoadPriKey(pri.ID())
+	assert.Error(t, err)
+}
+
+func TestSecretStore_Watch(t *testing.T) {
+	// given
+	setUpSecret(t, heimdall.TestKeyDir, "..2018_10_01")
+	defer os.RemoveAll(heimdall.TestKeyDir)
+
+	store := secretstore.NewSecretStore(heimdall.TestKeyDir, "password")
+	rotated := make(chan string, 1)
+	stop := store.Watch(10*time.Millisecond, func(revision string) {
+		rotated <- revision
+	})
+	defer stop()
+
+	// when
+	setUpSecret(t, heimdall.TestKeyDir, "..2018_10_02")
+
+	// then
+	select {
+	case revision := <-rotated:
+		assert.Equal(t, "..2018_10_02", revision)
+	case <-time.After(time.Second):
+		t.Fatal("rotation not detected")
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides versioned format of signatures produced by heimdall,
+// so that new signature encodings can roll out across a network of mixed versions.
+
+package heimdall
+
+import (
+	"errors"
+)
+
+var ErrUnsupportedSignatureVersion = errors.New("unsupported signature version")
+var ErrEmptySignature = errors.New("empty signature")
+var ErrNoCommonSignatureVersion = errors.New("no common signature version - peer supports none of local signature versions")
+
+// signature versions
+const (
+	// SignatureVersionLegacy is a bare signature without header, produced before signatures were versioned.
+	// ECDSA signatures of this version are ASN.1 sequences, so they always start with asn1Sequence.
+	SignatureVersionLegacy byte = 0
+	// SignatureVersion1 is a signature prefixed by a version byte.
+	SignatureVersion1 byte = 1
+)
+
+// first byte of DER encoded ASN.1 sequence, which never collides with version bytes.
+const asn1Sequence = 0x30
+
+// SupportedSignatureVersions are versions which this release can verify, in order of preference.
+var SupportedSignatureVersions = []byte{SignatureVersion1, SignatureVersionLegacy}
+
+// DefaultSignatureVersion is the version of signatures made with signer options which do not specify a version.
+// It stays legacy until every node of a network verifies versioned signatures.
+var DefaultSignatureVersion = SignatureVersionLegacy
+
+// VersionedSignerOpts is implemented by signer options which specify signature version.
+type VersionedSignerOpts interface {
+	SignerOpts
+	SignatureVersion() byte
+}
+
+// SignatureVersionOf returns signature version of signer option, or DefaultSignatureVersion if signer option does not specify it.
+func SignatureVersionOf(opts SignerOpts) byte {
+	if versionedOpts, ok := opts.(VersionedSignerOpts); ok {
+		return versionedOpts.SignatureVersion()
+	}
+
+	return DefaultSignatureVersion
+}
+
+// EncodeSignature wraps bare signature in format of version.
+func EncodeSignature(version byte, signature []byte) ([]byte, error) {
+	if len(signature) == 0 {
+		return nil, ErrEmptySignature
+	}
+
+	switch version {
+	case SignatureVersionLegacy:
+		return signature, nil
+	case SignatureVersion1:
+		return append([]byte{SignatureVersion1}, signature...), nil
+	default:
+		return nil, ErrUnsupportedSignatureVersion
+	}
+}
+
+// DecodeSignature returns version and bare signature of signature in any supported version.
+func DecodeSignature(signature []byte) (byte, []byte, error) {
+	if len(signature) == 0 {
+		return 0, nil, ErrEmptySignature
+	}
+
+	switch signature[0] {
+	case asn1Sequence:
+		return SignatureVersionLegacy, signature, nil
+	case SignatureVersion1:
+		if len(signature) == 1 {
+			return 0, nil, ErrEmptySignature
+		}
+		return SignatureVersion1, signature[1:], nil
+	default:
+		return 0, nil, ErrUnsupportedSignatureVersion
+	}
+}
+
+// NegotiateSignatureVersion returns the most preferred version of SupportedSignatureVersions which peer also supports,
+// so that signatures sent to the peer are verifiable by it.
+func NegotiateSignatureVersion(peerVersions []byte) (byte, error) {
+	for _, version := range SupportedSignatureVersions {
+		for _, peerVersion := range peerVersions {
+			if version == peerVersion {
+				return version, nil
+			}
+		}
+	}
+
+	return 0, ErrNoCommonSignatureVersion
+}
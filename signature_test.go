@@ -0,0 +1,85 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package heimdall_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeSignature(t *testing.T) {
+	// given
+	signature := []byte{0x30, 0x02, 0x01, 0x01}
+
+	// when
+	legacy, legacyErr := heimdall.EncodeSignature(heimdall.SignatureVersionLegacy, signature)
+	versioned, versionedErr := heimdall.EncodeSignature(heimdall.SignatureVersion1, signature)
+	_, unsupportedErr := heimdall.EncodeSignature(0x7f, signature)
+	_, emptyErr := heimdall.EncodeSignature(heimdall.SignatureVersion1, nil)
+
+	// then
+	assert.NoError(t, legacyErr)
+	assert.NoError(t, versionedErr)
+	assert.Equal(t, signature, legacy)
+	assert.Equal(t, append([]byte{heimdall.SignatureVersion1}, signature...), versioned)
+	assert.Equal(t, heimdall.ErrUnsupportedSignatureVersion, unsupportedErr)
+	assert.Equal(t, heimdall.ErrEmptySignature, emptyErr)
+
+	for _, encoded := range [][]byte{legacy, versioned} {
+		_, decoded, err := heimdall.DecodeSignature(encoded)
+		assert.NoError(t, err)
+		assert.Equal(t, signature, decoded)
+	}
+}
+
+func TestDecodeSignature(t *testing.T) {
+	for _, test := range []struct {
+		signature []byte
+		version   byte
+		err       error
+	}{
+		{[]byte{0x30, 0x00}, heimdall.SignatureVersionLegacy, nil},
+		{[]byte{heimdall.SignatureVersion1, 0x30, 0x00}, heimdall.SignatureVersion1, nil},
+		{[]byte{heimdall.SignatureVersion1}, 0, heimdall.ErrEmptySignature},
+		{[]byte{0x7f, 0x30, 0x00}, 0, heimdall.ErrUnsupportedSignatureVersion},
+		{nil, 0, heimdall.ErrEmptySignature},
+	} {
+		// when
+		version, _, err := heimdall.DecodeSignature(test.signature)
+
+		// then
+		assert.Equal(t, test.err, err)
+		assert.Equal(t, test.version, version)
+	}
+}
+
+func TestNegotiateSignatureVersion(t *testing.T) {
+	// when
+	version, err := heimdall.NegotiateSignatureVersion([]byte{heimdall.SignatureVersionLegacy, heimdall.SignatureVersion1})
+	legacyVersion, legacyErr := heimdall.NegotiateSignatureVersion([]byte{heimdall.SignatureVersionLegacy})
+	_, noCommonErr := heimdall.NegotiateSignatureVersion([]byte{0x7f})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, heimdall.SignatureVersion1, version)
+	assert.NoError(t, legacyErr)
+	assert.Equal(t, heimdall.SignatureVersionLegacy, legacyVersion)
+	assert.Equal(t, heimdall.ErrNoCommonSignatureVersion, noCommonErr)
+}
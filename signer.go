@@ -0,0 +1,31 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides signer and verifier interfaces for signing with a private key wherever the key is.
+
+package heimdall
+
+// Signer signs messages with a private key which is held by the implementation (memory, agent, hardware etc.).
+type Signer interface {
+	KeyID() KeyID
+	Sign(message []byte, opts SignerOpts) ([]byte, error)
+}
+
+// Verifier verifies signatures made by Signer.
+type Verifier interface {
+	Verify(pub PubKey, signature, message []byte, opts SignerOpts) (bool, error)
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides hash function of SKI(Subject Key Identifier), which is SHA-256 unless configured otherwise.
+
+package heimdall
+
+import (
+	"bytes"
+	"errors"
+	"sync"
+
+	"github.com/DE-labtory/heimdall/hashing"
+)
+
+var ErrSKIHashNotSupported = errors.New("SKI hash not supported - SKI hash should be SHA2-256, SHA3-256, BLAKE2B-256 or BLAKE2S-256")
+
+// DefaultSKIHash is hash function of SKI of keys and key files which do not record their SKI hash.
+const DefaultSKIHash = hashing.SHA2_256
+
+// skiHashes are hash functions SKI can be made by, whose digests are longer than SKI.
+var skiHashes = []string{hashing.SHA2_256, hashing.SHA3_256, hashing.BLAKE2B_256, hashing.BLAKE2S_256}
+
+// SKISource is a public key whose SKI can be made by any SKI hash, by hashing its SKI material.
+type SKISource interface {
+	SKIMaterial() []byte
+}
+
+var skiHash = DefaultSKIHash
+var skiHashMutex = &sync.RWMutex{}
+
+// SetSKIHash replaces hash function of SKI of keys in the process. (ex. hashing.SHA3_256) Empty name restores SHA-256.
+// Since key IDs are made of SKI, keys get other key IDs, but key IDs of other SKI hashes still match them. (see MatchKeyID)
+func SetSKIHash(name string) error {
+	if name == "" {
+		name = DefaultSKIHash
+	}
+
+	if !IsSKIHash(name) {
+		return ErrSKIHashNotSupported
+	}
+
+	skiHashMutex.Lock()
+	defer skiHashMutex.Unlock()
+
+	skiHash = name
+	return nil
+}
+
+// CurrentSKIHash returns hash function of SKI of keys in the process.
+func CurrentSKIHash() string {
+	skiHashMutex.RLock()
+	defer skiHashMutex.RUnlock()
+
+	return skiHash
+}
+
+// IsSKIHash checks if SKI can be made by hash function of name.
+func IsSKIHash(name string) bool {
+	for _, skiHash := range skiHashes {
+		if name == skiHash {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MakeSKI makes SKI of SKI material of public key by SKI hash of the process, truncated to 20 bytes.
+func MakeSKI(material []byte) []byte {
+	ski, _ := MakeSKIWithHash(CurrentSKIHash(), material)
+	return ski
+}
+
+// MakeSKIWithHash makes SKI of SKI material of public key by SKI hash of name, truncated to 20 bytes.
+func MakeSKIWithHash(name string, material []byte) ([]byte, error) {
+	if !IsSKIHash(name) {
+		return nil, ErrSKIHashNotSupported
+	}
+
+	hashOpt, err := hashing.NewHashOpt(name)
+	if err != nil {
+		return nil, err
+	}
+
+	hashFunc := hashOpt.HashFunc()
+	hashFunc.Write(material)
+
+	return hashFunc.Sum(nil)[:skiSize], nil
+}
+
+// SKIWithHash returns SKI of the key made by SKI hash of name. Empty name is DefaultSKIHash.
+// SKI of other SKI hash than the process can be made only for keys of SKISource public key.
+func SKIWithHash(key Key, name string) ([]byte, error) {
+	if name == "" {
+		name = DefaultSKIHash
+	}
+
+	if name == CurrentSKIHash() {
+		return key.SKI(), nil
+	}
+
+	if pri, ok := key.(PriKey); ok {
+		key = pri.PublicKey()
+	}
+
+	source, ok := key.(SKISource)
+	if !ok {
+		return nil, ErrSKIHashNotSupported
+	}
+
+	return MakeSKIWithHash(name, source.SKIMaterial())
+}
+
+// matchSKI checks if SKI is SKI of the key by SKI hash of name, or by any SKI hash if name is empty.
+func matchSKI(ski []byte, key Key, name string) bool {
+	if bytes.Equal(ski, key.SKI()) {
+		return true
+	}
+
+	names := skiHashes
+	if name != "" {
+		names = []string{name}
+	}
+
+	for _, name := range names {
+		if keySKI, err := SKIWithHash(key, name); err == nil && bytes.Equal(ski, keySKI) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package heimdall_test
+
+import (
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetSKIHash(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	defaultSKI, defaultKeyId := pri.SKI(), pri.ID()
+
+	// when
+	err = heimdall.SetSKIHash(hashing.SHA3_256)
+	defer heimdall.SetSKIHash("")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, hashing.SHA3_256, heimdall.CurrentSKIHash())
+	assert.Len(t, pri.SKI(), 20)
+	assert.NotEqual(t, defaultSKI, pri.SKI())
+	assert.NotEqual(t, defaultKeyId, pri.ID())
+	assert.Equal(t, pri.SKI(), pri.PublicKey().SKI())
+	ski, err := heimdall.SKIWithHash(pri, "")
+	assert.NoError(t, err)
+	assert.Equal(t, defaultSKI, ski)
+	assert.True(t, heimdall.MatchKeyID(defaultKeyId, pri))
+	assert.True(t, heimdall.MatchKeyID(heimdall.SKIToKeyID(defaultSKI), pri))
+}
+
+func TestSetSKIHash_NotSupported(t *testing.T) {
+	for _, name := range []string{hashing.SHA512, hashing.BLAKE2S_128, "MD5"} {
+		assert.Equal(t, heimdall.ErrSKIHashNotSupported, heimdall.SetSKIHash(name), name)
+	}
+	assert.Equal(t, heimdall.DefaultSKIHash, heimdall.CurrentSKIHash())
+}
+
+func TestMakeMultihashKeyID_SKIHash(t *testing.T) {
+	// given
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	assert.NoError(t, heimdall.SetSKIHash(hashing.BLAKE2B_256))
+	keyId, err := heimdall.MakeMultihashKeyID(keyGenOpt, pri.SKI())
+	assert.NoError(t, err)
+	assert.NoError(t, heimdall.SetSKIHash(""))
+
+	// when
+	info, err := heimdall.ParseKeyID(keyId)
+
+	// then
+	assert.NoError(t, err)
+	assert.True(t, info.Multihash)
+	assert.Equal(t, hashing.BLAKE2B_256, info.SKIHash)
+	assert.True(t, heimdall.MatchKeyID(keyId, pri))
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides deterministic keys, signatures, key files and certificates of known-good heimdall outputs,
+// so that downstream projects can write interoperability tests against them.
+
+package testvectors
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"math/big"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/cert"
+	"github.com/DE-labtory/heimdall/hecdsa"
+)
+
+var ErrInvalidVector = errors.New("invalid test vector - hex value of vector can not be decoded")
+
+// ECDSAVector is a deterministic ECDSA key and signature (R, S) of message. Numbers are hex encoded.
+// HashName is a name of heimdall hash option producing Digest, or empty if no heimdall hash option does,
+// and Digest is the digest of message signed by R and S.
+type ECDSAVector struct {
+	Name     string
+	Curve    elliptic.Curve
+	D        string
+	Qx       string
+	Qy       string
+	KeyID    heimdall.KeyID
+	Message  []byte
+	HashName string
+	Digest   string
+	R        string
+	S        string
+}
+
+// RFC6979P256 is the P-256 key and SHA-256 signature of "sample" in RFC 6979 A.2.5.
+// Note that heimdall hash option SHA256 is SHA-512/256, so the signature should be verified on Digest with crypto/ecdsa,
+// not by hecdsa.Verify with hashing.SHA256.
+var RFC6979P256 = &ECDSAVector{
+	Name:     "RFC 6979 A.2.5 P-256 SHA-256",
+	Curve:    elliptic.P256(),
+	D:        "C9AFA9D845BA75166B5C215767B1D6934E50C3DB36E89B127B8A622B120F6721",
+	Qx:       "60FED4BA255A9D31C961EB74C6356D68C049B8923B61FA6CE669622E60F29FB6",
+	Qy:       "7903FE1008B8BC99A41AE9E95628BC64F2F1B20C2D7E9F5177A3C294D4462299",
+	KeyID:    "ECP256x3UTretnruLYPf12MRfjkwbvRtFU6",
+	Message:  []byte("sample"),
+	HashName: "",
+	Digest:   "AF2BDBE1AA9B6EC1E2ADE1D694F41FC71A831D0268E9891562113D8A62ADD1BF",
+	R:        "EFD48B2AACB6A8FD1140DD9CD45E81D69D2C877B56AAF991C34D0EA84EAF3716",
+	S:        "F7CB1C942D657C41D436C7A1B6E29F65F3E900DBB9AFF4064DC4AB2F843ACDA8",
+}
+
+// RFC6979P384 is the P-384 key and SHA-384 signature of "sample" in RFC 6979 A.2.6, verifiable by hecdsa.Verify with hashing.SHA384.
+var RFC6979P384 = &ECDSAVector{
+	Name:     "RFC 6979 A.2.6 P-384 SHA-384",
+	Curve:    elliptic.P384(),
+	D:        "6B9D3DAD2E1B8C1C05B19875B6659F4DE23C3B667BF297BA9AA47740787137D896D5724E4C70A825F872C9EA60D2EDF5",
+	Qx:       "EC3A4E415B4E19A4568618029F427FA5DA9A8BC4AE92E02E06AAE5286B300C64DEF8F0EA9055866064A254515480BC13",
+	Qy:       "8015D9B72D7D57244EA8EF9AC0C621896708A59367F9DFB9F54CA84B3F1C9DB1288B231C3AE0D4FE7344FD2533264720",
+	KeyID:    "ECP384x3oCDyh7Z4UnnnZznw7HqF6pPzn8t",
+	Message:  []byte("sample"),
+	HashName: "SHA384",
+	Digest:   "9A9083505BC92276AEC4BE312696EF7BF3BF603F4BBD381196A029F340585312313BCA4A9B5B890EFEE42C77B1EE25FE",
+	R:        "94EDBB92A5ECB8AAD4736E56C691916B3F88140666CE9FA73D64C4EA95AD133C81A648152E44ACF96E36DD1E80FABE46",
+	S:        "99EF4AEB15F178CEA1FE40DB2603138F130E740A19624526203B6351D0A3A94FA329C145786E679E7B82C71A38628AC8",
+}
+
+// HeimdallP256SHA256 is a signature of "sample" made by hecdsa with RFC 6979 P-256 key and hashing.SHA256 (SHA-512/256).
+var HeimdallP256SHA256 = &ECDSAVector{
+	Name:     "heimdall P-256 SHA256 (SHA-512/256)",
+	Curve:    elliptic.P256(),
+	D:        RFC6979P256.D,
+	Qx:       RFC6979P256.Qx,
+	Qy:       RFC6979P256.Qy,
+	KeyID:    RFC6979P256.KeyID,
+	Message:  []byte("sample"),
+	HashName: "SHA256",
+	Digest:   "7C7B8386BC94FFD1A48050D821FCD5E4DB3029A4DD93980C9A84EB4BD8F11143",
+	R:        "5217DE306FB440F231B0B87CE654819C2A4A699028F2BA0B6CFF0E41A97778B5",
+	S:        "2381896DB57AB250174D495714A7E157CF6506BEDA603B7A805B19052023EDE9",
+}
+
+// ECDSAVectors are every ECDSA vector.
+var ECDSAVectors = []*ECDSAVector{RFC6979P256, RFC6979P384, HeimdallP256SHA256}
+
+// PriKey returns private key of vector.
+func (vector *ECDSAVector) PriKey() (heimdall.PriKey, error) {
+	d, ok := new(big.Int).SetString(vector.D, 16)
+	if !ok {
+		return nil, ErrInvalidVector
+	}
+
+	pub, err := vector.ecdsaPubKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return hecdsa.NewPriKey(&ecdsa.PrivateKey{PublicKey: *pub, D: d}), nil
+}
+
+// PubKey returns public key of vector.
+func (vector *ECDSAVector) PubKey() (heimdall.PubKey, error) {
+	pub, err := vector.ecdsaPubKey()
+	if err != nil {
+		return nil, err
+	}
+
+	return hecdsa.NewPubKey(pub), nil
+}
+
+func (vector *ECDSAVector) ecdsaPubKey() (*ecdsa.PublicKey, error) {
+	x, ok := new(big.Int).SetString(vector.Qx, 16)
+	if !ok {
+		return nil, ErrInvalidVector
+	}
+
+	y, ok := new(big.Int).SetString(vector.Qy, 16)
+	if !ok {
+		return nil, ErrInvalidVector
+	}
+
+	return &ecdsa.PublicKey{Curve: vector.Curve, X: x, Y: y}, nil
+}
+
+// Signature returns signature of vector in format of version. (ASN.1 for legacy version)
+func (vector *ECDSAVector) Signature(version byte) ([]byte, error) {
+	r, ok := new(big.Int).SetString(vector.R, 16)
+	if !ok {
+		return nil, ErrInvalidVector
+	}
+
+	s, ok := new(big.Int).SetString(vector.S, 16)
+	if !ok {
+		return nil, ErrInvalidVector
+	}
+
+	signature, err := hecdsa.MarshalSignature(r, s)
+	if err != nil {
+		return nil, err
+	}
+
+	return heimdall.EncodeSignature(version, signature)
+}
+
+// DigestBytes returns digest of vector.
+func (vector *ECDSAVector) DigestBytes() ([]byte, error) {
+	digest, err := hex.DecodeString(vector.Digest)
+	if err != nil {
+		return nil, ErrInvalidVector
+	}
+
+	return digest, nil
+}
+
+// KeyFilePassword is the password of KeyFile.
+const KeyFilePassword = "password"
+
+// KeyFile is an encrypted key file of RFC 6979 P-384 key stored by hecdsa.StorePriKey
+// with AES-192-CTR and scrypt (N=16384, R=8, P=1). The file name is KeyFileName.
+const KeyFile = `{"SKI":"yLmo5/FkUVvi5ZvOjveNH+H11gU=","KeyGenOpt":"P-384",` +
+	`"EncryptedKey":"7a82798af29e45cb2a3498bdfd1cc76c2547f647f2c856cdee261d79f214a0403a7eba79f96972b232ad073460094944e228bc423b94782d9ea66a64e50ce2412f439be50ea70ac36e66af656628d4d0215f947c617ebf61702c1d8fb1ad3a3d69c379600f3d537663e7f27232c88ebc429306bf0141161df40842859621e90e129d664cef7b39ba7c3876bfb64bd558f43e49df01033d4bad6d97e99fc2ced08c026b872a6dafee5add1ece0b04a33c7fcb26291c176d",` +
+	`"Hints":{"EncOpt":{"Algorithm":"AES","KeyLen":192,"OpMode":"CTR"},"KDFOpt":{"KdfName":"SCRYPT","KdfParams":{"N":"16384","P":"1","R":"8"}},"KDFSalt":"8w/ul79VW1Y="}}`
+
+// KeyFileName is the file name of KeyFile, which is key ID of the key.
+const KeyFileName = "ECP384x3oCDyh7Z4UnnnZznw7HqF6pPzn8t"
+
+// CertPEM is a self-signed CA certificate of RFC 6979 P-384 key, valid from 2018 to 2048.
+const CertPEM = `-----BEGIN CERTIFICATE-----
+MIIB2DCCAV6gAwIBAgIBATAKBggqhkjOPQQDAzA1MRQwEgYDVQQKEwtpdC1jaGFp
+biBjbzEdMBsGA1UEAxMUaGVpbWRhbGwgdGVzdCB2ZWN0b3IwHhcNMTgwMTAxMDAw
+MDAwWhcNNDgwMTAxMDAwMDAwWjA1MRQwEgYDVQQKEwtpdC1jaGFpbiBjbzEdMBsG
+A1UEAxMUaGVpbWRhbGwgdGVzdCB2ZWN0b3IwdjAQBgcqhkjOPQIBBgUrgQQAIgNi
+AATsOk5BW04ZpFaGGAKfQn+l2pqLxK6S4C4GquUoazAMZN748OqQVYZgZKJUUVSA
+vBOAFdm3LX1XJE6o75rAxiGJZwilk2f537n1TKhLPxydsSiLIxw64NT+c0T9JTMm
+RyCjQjBAMA4GA1UdDwEB/wQEAwIBhjAPBgNVHRMBAf8EBTADAQH/MB0GA1UdDgQW
+BBTIuajn8WRRW+Llm86O940f4fXWBTAKBggqhkjOPQQDAwNoADBlAjAIBJyI+K3g
+93U84m5RjugE7KnBBy3EMWKIo1uG6R6CfFGgJ74DKpT+2JjXfGu465oCMQCv+AzK
+BWV2oH2fF0MDUAnw4/TizR1qreUws3MBVWNMN/6Ei9s2ayKRZZYha+mLzRo=
+-----END CERTIFICATE-----
+`
+
+// Cert returns certificate of CertPEM.
+func Cert() (*x509.Certificate, error) {
+	return cert.PemToX509Cert([]byte(CertPEM))
+}
@@ -0,0 +1,105 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package testvectors_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"math/big"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hashing"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/testvectors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestECDSAVectors(t *testing.T) {
+	for _, vector := range testvectors.ECDSAVectors {
+		t.Logf("running test vector [%s]", vector.Name)
+
+		// given
+		pri, err := vector.PriKey()
+		assert.NoError(t, err)
+		pub, err := vector.PubKey()
+		assert.NoError(t, err)
+		digest, err := vector.DigestBytes()
+		assert.NoError(t, err)
+
+		// then
+		assert.Equal(t, vector.KeyID, pri.ID())
+		assert.Equal(t, vector.KeyID, pub.ID())
+
+		r, _ := new(big.Int).SetString(vector.R, 16)
+		s, _ := new(big.Int).SetString(vector.S, 16)
+		ecdsaPub, err := x509.ParsePKIXPublicKey(mustToByte(t, pub))
+		assert.NoError(t, err)
+		assert.True(t, ecdsa.Verify(ecdsaPub.(*ecdsa.PublicKey), digest, r, s))
+
+		if vector.HashName == "" {
+			continue
+		}
+
+		hashOpt, err := hashing.NewHashOpt(vector.HashName)
+		assert.NoError(t, err)
+		hashed, err := hashing.Hash(vector.Message, hashOpt)
+		assert.NoError(t, err)
+		assert.Equal(t, digest, hashed)
+
+		for _, version := range heimdall.SupportedSignatureVersions {
+			signature, err := vector.Signature(version)
+			assert.NoError(t, err)
+
+			valid, err := hecdsa.Verify(pub, signature, vector.Message, hecdsa.NewSignerOpts(hashOpt))
+			assert.NoError(t, err)
+			assert.True(t, valid)
+		}
+	}
+}
+
+func mustToByte(t *testing.T, key heimdall.Key) []byte {
+	keyBytes, err := key.ToByte()
+	assert.NoError(t, err)
+
+	return keyBytes
+}
+
+func TestKeyFile(t *testing.T) {
+	// when
+	pri, err := hecdsa.DecryptKeyFile([]byte(testvectors.KeyFile), testvectors.KeyFilePassword)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, testvectors.KeyFileName, pri.ID())
+	assert.Equal(t, testvectors.RFC6979P384.KeyID, pri.ID())
+}
+
+func TestCert(t *testing.T) {
+	// when
+	cert, err := testvectors.Cert()
+
+	// then
+	assert.NoError(t, err)
+	assert.NoError(t, cert.CheckSignatureFrom(cert))
+
+	pub, err := testvectors.RFC6979P384.PubKey()
+	assert.NoError(t, err)
+	assert.Equal(t, pub.SKI(), cert.SubjectKeyId)
+	assert.Equal(t, mustToByte(t, pub), cert.RawSubjectPublicKeyInfo)
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides trust store interface which supplies trust anchors and revocation sources for certificate verification.
+
+package heimdall
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+)
+
+// TrustStore provides trust anchors (roots, intermediates and pins) and revocation lists,
+// so that they can come from anywhere such as certificate directory or channel configuration.
+type TrustStore interface {
+	Roots() ([]*x509.Certificate, error)
+	Intermediates() ([]*x509.Certificate, error)
+	// Pins returns SHA-256 hashes of SubjectPublicKeyInfo. If not empty, verified chain should contain one of pinned keys.
+	Pins() ([][]byte, error)
+	// CRLs returns revocation lists which can revoke the certificate.
+	CRLs(cert *x509.Certificate) ([]*pkix.CertificateList, error)
+}
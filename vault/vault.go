@@ -0,0 +1,405 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+// This file provides vault which encrypts a directory of files into a single container protected by password or key,
+// such as genesis material and operator credentials shipped to nodes.
+
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/fileperm"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/seal"
+)
+
+var ErrUnsupportedVersion = errors.New("unsupported vault - vault version is not supported")
+var ErrWrongProtection = errors.New("wrong protection - vault is protected by other method (password or key)")
+var ErrFileNotInVault = errors.New("file not in vault")
+var ErrInvalidPath = errors.New("invalid path - path in vault should be relative and inside of vault")
+var ErrDigestMismatch = errors.New("digest mismatch - decrypted file does not match manifest")
+var ErrVaultClosed = errors.New("vault closed")
+
+// Version is the container format version written by Pack functions.
+const Version = 1
+
+// data key length in bytes (AES-256-GCM)
+const dataKeyLen = 32
+
+// additional data of manifest, file ciphertexts bind their index and path in manifest
+const manifestAD = "heimdall vault manifest"
+
+// Entry is a file in vault described by manifest.
+type Entry struct {
+	Path   string
+	Size   int64
+	SHA256 []byte
+}
+
+// container is a format of vault. Manifest and files are encrypted by AES-GCM under data key,
+// and data key is wrapped under key derived from password or sealed under public key.
+type container struct {
+	Version    int
+	KDFOpt     *kdf.Opts      `json:",omitempty"`
+	KDFSalt    []byte         `json:",omitempty"`
+	WrappedKey []byte         `json:",omitempty"`
+	KeyID      heimdall.KeyID `json:",omitempty"`
+	SealedKey  []byte         `json:",omitempty"`
+	Manifest   []byte
+	Files      [][]byte
+}
+
+// PackWithPwd encrypts every regular file under dirPath into vault protected by password.
+func PackWithPwd(dirPath, pwd string, kdfOpt *kdf.Opts) ([]byte, error) {
+	salt := make([]byte, kdf.DefaultSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+
+	kek, err := kdf.DeriveKey([]byte(pwd), salt, dataKeyLen*8, kdfOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer clearBytes(kek)
+
+	return pack(dirPath, func(dataKey []byte, vault *container) error {
+		wrappedKey, err := encrypt(kek, dataKey, nil)
+		if err != nil {
+			return err
+		}
+
+		vault.KDFOpt = kdfOpt
+		vault.KDFSalt = salt
+		vault.WrappedKey = wrappedKey
+
+		return nil
+	})
+}
+
+// PackWithKey encrypts every regular file under dirPath into vault which only owner of private key of pub can open.
+func PackWithKey(dirPath string, pub heimdall.PubKey) ([]byte, error) {
+	return pack(dirPath, func(dataKey []byte, vault *container) error {
+		sealedKey, err := seal.SealWithKey(dataKey, pub)
+		if err != nil {
+			return err
+		}
+
+		vault.KeyID = pub.ID()
+		vault.SealedKey = sealedKey
+
+		return nil
+	})
+}
+
+// pack encrypts files under dirPath with a new data key, and protects the data key by protect.
+func pack(dirPath string, protect func(dataKey []byte, vault *container) error) ([]byte, error) {
+	dataKey := make([]byte, dataKeyLen)
+	if _, err := rand.Read(dataKey); err != nil {
+		return nil, err
+	}
+	defer clearBytes(dataKey)
+
+	vault := &container{Version: Version}
+	if err := protect(dataKey, vault); err != nil {
+		return nil, err
+	}
+
+	var manifest []*Entry
+	err := filepath.Walk(dirPath, func(filePath string, info os.FileInfo, err error) error {
+		if err != nil || !info.Mode().IsRegular() {
+			return err
+		}
+
+		relPath, err := filepath.Rel(dirPath, filePath)
+		if err != nil {
+			return err
+		}
+
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			return err
+		}
+		defer clearBytes(data)
+
+		digest := sha256.Sum256(data)
+		entry := &Entry{Path: filepath.ToSlash(relPath), Size: int64(len(data)), SHA256: digest[:]}
+
+		ciphertext, err := encrypt(dataKey, data, fileAD(len(manifest), entry.Path))
+		if err != nil {
+			return err
+		}
+
+		manifest = append(manifest, entry)
+		vault.Files = append(vault.Files, ciphertext)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+
+	vault.Manifest, err = encrypt(dataKey, manifestBytes, []byte(manifestAD))
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(vault)
+}
+
+// Vault is opened vault which decrypts files selectively. Close clears data key.
+type Vault struct {
+	dataKey  []byte
+	manifest []*Entry
+	files    [][]byte
+}
+
+// OpenWithPwd opens vault protected by password.
+func OpenWithPwd(vaultBytes []byte, pwd string) (*Vault, error) {
+	vault, err := unmarshalContainer(vaultBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if vault.WrappedKey == nil || vault.KDFOpt == nil {
+		return nil, ErrWrongProtection
+	}
+
+	kdfOpt, err := kdf.NewOpts(vault.KDFOpt.KdfName, vault.KDFOpt.KdfParams)
+	if err != nil {
+		return nil, err
+	}
+
+	kek, err := kdf.DeriveKeyWithLimits([]byte(pwd), vault.KDFSalt, dataKeyLen*8, kdfOpt)
+	if err != nil {
+		return nil, err
+	}
+	defer clearBytes(kek)
+
+	dataKey, err := decrypt(kek, vault.WrappedKey, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(vault, dataKey)
+}
+
+// OpenWithKey opens vault sealed under public key of pri.
+func OpenWithKey(vaultBytes []byte, pri heimdall.PriKey) (*Vault, error) {
+	vault, err := unmarshalContainer(vaultBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if vault.SealedKey == nil {
+		return nil, ErrWrongProtection
+	}
+
+	dataKey, err := seal.UnsealWithKey(vault.SealedKey, pri)
+	if err != nil {
+		return nil, err
+	}
+
+	return open(vault, dataKey)
+}
+
+func unmarshalContainer(vaultBytes []byte) (*container, error) {
+	vault := &container{}
+	if err := json.Unmarshal(vaultBytes, vault); err != nil {
+		return nil, err
+	}
+
+	if vault.Version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	return vault, nil
+}
+
+// open decrypts manifest of vault with data key.
+func open(vault *container, dataKey []byte) (*Vault, error) {
+	manifestBytes, err := decrypt(dataKey, vault.Manifest, []byte(manifestAD))
+	if err != nil {
+		clearBytes(dataKey)
+		return nil, err
+	}
+
+	var manifest []*Entry
+	if err := json.Unmarshal(manifestBytes, &manifest); err != nil {
+		clearBytes(dataKey)
+		return nil, err
+	}
+
+	if len(manifest) != len(vault.Files) {
+		clearBytes(dataKey)
+		return nil, ErrFileNotInVault
+	}
+
+	return &Vault{
+		dataKey:  dataKey,
+		manifest: manifest,
+		files:    vault.Files,
+	}, nil
+}
+
+// List returns entries of files in vault.
+func (vault *Vault) List() []*Entry {
+	return vault.manifest
+}
+
+// Read decrypts a file of path in vault. (ex. genesis/genesis.json)
+func (vault *Vault) Read(filePath string) ([]byte, error) {
+	if vault.dataKey == nil {
+		return nil, ErrVaultClosed
+	}
+
+	for i, entry := range vault.manifest {
+		if entry.Path != filePath {
+			continue
+		}
+
+		data, err := decrypt(vault.dataKey, vault.files[i], fileAD(i, entry.Path))
+		if err != nil {
+			return nil, err
+		}
+
+		digest := sha256.Sum256(data)
+		if int64(len(data)) != entry.Size || string(digest[:]) != string(entry.SHA256) {
+			return nil, ErrDigestMismatch
+		}
+
+		return data, nil
+	}
+
+	return nil, ErrFileNotInVault
+}
+
+// Extract decrypts files of paths into outDirPath with owner only permission. Every file is extracted if no path is given.
+func (vault *Vault) Extract(outDirPath string, filePaths ...string) error {
+	if len(filePaths) == 0 {
+		for _, entry := range vault.manifest {
+			filePaths = append(filePaths, entry.Path)
+		}
+	}
+
+	for _, filePath := range filePaths {
+		outPath, err := extractPath(outDirPath, filePath)
+		if err != nil {
+			return err
+		}
+
+		data, err := vault.Read(filePath)
+		if err != nil {
+			return err
+		}
+
+		if err := fileperm.MkdirAll(filepath.Dir(outPath)); err != nil {
+			clearBytes(data)
+			return err
+		}
+
+		err = fileperm.WriteFile(outPath, data)
+		clearBytes(data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Close clears data key of vault.
+func (vault *Vault) Close() {
+	clearBytes(vault.dataKey)
+	vault.dataKey = nil
+}
+
+// extractPath makes output path of file in vault, rejecting paths escaping outDirPath.
+func extractPath(outDirPath, filePath string) (string, error) {
+	cleanPath := path.Clean(filePath)
+	if path.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, "../") || strings.Contains(cleanPath, "\\") {
+		return "", ErrInvalidPath
+	}
+
+	return filepath.Join(outDirPath, filepath.FromSlash(cleanPath)), nil
+}
+
+// fileAD makes additional data of file ciphertext, so that files can not be swapped or renamed in manifest.
+func fileAD(index int, filePath string) []byte {
+	return []byte("heimdall vault file\x00" + strconv.Itoa(index) + "\x00" + filePath)
+}
+
+// encrypt encrypts plaintext by AES-GCM, and returns nonce followed by ciphertext.
+func encrypt(key, plaintext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// decrypt decrypts nonce followed by ciphertext made by encrypt.
+func decrypt(key, ciphertext, additionalData []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ciphertext) < aead.NonceSize() {
+		return nil, errors.New("invalid ciphertext - ciphertext is shorter than nonce")
+	}
+
+	return aead.Open(nil, ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():], additionalData)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func clearBytes(data []byte) {
+	for i := range data {
+		data[i] = 0
+	}
+}
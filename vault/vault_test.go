@@ -0,0 +1,134 @@
+/*
+ * Copyright 2018 DE-labtory
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ */
+
+package vault_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DE-labtory/heimdall"
+	"github.com/DE-labtory/heimdall/hecdsa"
+	"github.com/DE-labtory/heimdall/kdf"
+	"github.com/DE-labtory/heimdall/vault"
+	"github.com/stretchr/testify/assert"
+)
+
+func setUpDir(t *testing.T) string {
+	dirPath := filepath.Join(heimdall.TestKeyDir, "vault")
+	assert.NoError(t, os.MkdirAll(filepath.Join(dirPath, "genesis"), 0700))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "genesis", "genesis.json"), []byte("{\"chain\":\"it\"}"), 0600))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dirPath, "operator.txt"), []byte("operator credential"), 0600))
+
+	return dirPath
+}
+
+func TestPackWithPwd(t *testing.T) {
+	// given
+	dirPath := setUpDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	kdfOpt, err := kdf.NewOpts(kdf.SCRYPT, map[string]string{"N": "16384", "R": "8", "P": "1"})
+	assert.NoError(t, err)
+
+	// when
+	vaultBytes, err := vault.PackWithPwd(dirPath, "password", kdfOpt)
+
+	// then
+	assert.NoError(t, err)
+
+	opened, err := vault.OpenWithPwd(vaultBytes, "password")
+	assert.NoError(t, err)
+	defer opened.Close()
+	assert.Len(t, opened.List(), 2)
+
+	data, err := opened.Read("genesis/genesis.json")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("{\"chain\":\"it\"}"), data)
+
+	_, err = opened.Read("none.txt")
+	assert.Equal(t, vault.ErrFileNotInVault, err)
+
+	_, err = vault.OpenWithPwd(vaultBytes, "wrong password")
+	assert.Error(t, err)
+}
+
+func TestPackWithKey(t *testing.T) {
+	// given
+	dirPath := setUpDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP384)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	otherPri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+
+	// when
+	vaultBytes, err := vault.PackWithKey(dirPath, pri.PublicKey())
+
+	// then
+	assert.NoError(t, err)
+
+	opened, err := vault.OpenWithKey(vaultBytes, pri)
+	assert.NoError(t, err)
+	data, err := opened.Read("operator.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("operator credential"), data)
+
+	opened.Close()
+	_, err = opened.Read("operator.txt")
+	assert.Equal(t, vault.ErrVaultClosed, err)
+
+	_, err = vault.OpenWithKey(vaultBytes, otherPri)
+	assert.Error(t, err)
+
+	_, err = vault.OpenWithPwd(vaultBytes, "password")
+	assert.Equal(t, vault.ErrWrongProtection, err)
+}
+
+func TestVault_Extract(t *testing.T) {
+	// given
+	dirPath := setUpDir(t)
+	defer os.RemoveAll(heimdall.TestKeyDir)
+	outDirPath := filepath.Join(heimdall.TestKeyDir, "out")
+	keyGenOpt, err := hecdsa.NewKeyGenOpt(hecdsa.ECP256)
+	assert.NoError(t, err)
+	pri, err := hecdsa.GenerateKey(keyGenOpt)
+	assert.NoError(t, err)
+	vaultBytes, err := vault.PackWithKey(dirPath, pri.PublicKey())
+	assert.NoError(t, err)
+	opened, err := vault.OpenWithKey(vaultBytes, pri)
+	assert.NoError(t, err)
+	defer opened.Close()
+
+	// when
+	err = opened.Extract(outDirPath, "genesis/genesis.json")
+	traversalErr := opened.Extract(outDirPath, "../genesis.json")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, vault.ErrInvalidPath, traversalErr)
+
+	data, err := ioutil.ReadFile(filepath.Join(outDirPath, "genesis", "genesis.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("{\"chain\":\"it\"}"), data)
+
+	_, err = os.Stat(filepath.Join(outDirPath, "operator.txt"))
+	assert.True(t, os.IsNotExist(err))
+}